@@ -130,68 +130,6 @@ func (h *TransactionHandler) SearchTransactions(w http.ResponseWriter, r *http.R
 	json.NewEncoder(w).Encode(response)
 }
 
-// CreateManualMatch creates a manual match between transactions
-func (h *TransactionHandler) CreateManualMatch(w http.ResponseWriter, r *http.Request) {
-	// Get user ID from JWT token
-	userID := r.Context().Value("userID").(string)
-
-	// Check if user has the preparer role
-	hasRole, err := h.roleService.HasRole(userID, models.RolePreparer)
-	if err != nil || !hasRole {
-		http.Error(w, "Unauthorized: requires preparer role", http.StatusUnauthorized)
-		return
-	}
-
-	// Parse request body
-	var request struct {
-		TransactionIDs []string `json:"transactionIds"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	// Create manual match
-	match, err := h.transactionService.CreateManualMatch(request.TransactionIDs, userID)
-	if err != nil {
-		http.Error(w, "Error creating match: "+err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// Return match
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(match)
-}
-
-// FindPotentialMatches finds potential matching transactions for a given transaction
-func (h *TransactionHandler) FindPotentialMatches(w http.ResponseWriter, r *http.Request) {
-	// Get user ID from JWT token
-	userID := r.Context().Value("userID").(string)
-
-	// Check if user has the preparer role
-	hasRole, err := h.roleService.HasRole(userID, models.RolePreparer)
-	if err != nil || !hasRole {
-		http.Error(w, "Unauthorized: requires preparer role", http.StatusUnauthorized)
-		return
-	}
-
-	// Extract transaction ID from URL path
-	vars := mux.Vars(r)
-	id := vars["id"]
-
-	// Find potential matches
-	matches, err := h.transactionService.FindPotentialMatches(id)
-	if err != nil {
-		http.Error(w, "Error finding potential matches: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// Return matches
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(matches)
-}
-
 // AutoMatchTransactions attempts to automatically match unmatched transactions
 func (h *TransactionHandler) AutoMatchTransactions(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from JWT token
@@ -0,0 +1,26 @@
+-- +migrate Up
+-- Track which import produced a transaction and the caller's identifier for it
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS external_id VARCHAR(255);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS import_id UUID REFERENCES import_records(id);
+
+-- External IDs are unique within a data source so re-sent records can be detected
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_data_source_external_id
+    ON transactions(data_source_id, external_id)
+    WHERE external_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_import_id ON transactions(import_id);
+
+-- Idempotency key for API batches, unique within a data source
+ALTER TABLE import_records ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_import_records_idempotency_key
+    ON import_records(data_source_id, idempotency_key)
+    WHERE idempotency_key IS NOT NULL;
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_import_records_idempotency_key;
+ALTER TABLE import_records DROP COLUMN IF EXISTS idempotency_key;
+
+DROP INDEX IF EXISTS idx_transactions_import_id;
+DROP INDEX IF EXISTS idx_transactions_data_source_external_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS import_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS external_id;
@@ -0,0 +1,30 @@
+-- +migrate Up
+-- Group matching options on rules
+ALTER TABLE match_rules ADD COLUMN IF NOT EXISTS match_cardinality VARCHAR(10) NOT NULL DEFAULT '1:1'
+    CHECK (match_cardinality IN ('1:1', '1:N', 'N:M'));
+ALTER TABLE match_rules ADD COLUMN IF NOT EXISTS amount_tolerance DECIMAL(19, 4) NOT NULL DEFAULT 0;
+ALTER TABLE match_rules ADD COLUMN IF NOT EXISTS max_group_size INTEGER NOT NULL DEFAULT 5;
+ALTER TABLE match_rules ADD COLUMN IF NOT EXISTS max_candidates INTEGER NOT NULL DEFAULT 25;
+ALTER TABLE match_rules ADD COLUMN IF NOT EXISTS max_search_iterations INTEGER NOT NULL DEFAULT 10000;
+
+-- Tie matches to the tenant and match set that produced them
+ALTER TABLE transaction_matches ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id) ON DELETE CASCADE;
+ALTER TABLE transaction_matches ADD COLUMN IF NOT EXISTS match_set_id UUID REFERENCES match_sets(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_transaction_matches_tenant_id ON transaction_matches(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_matches_match_set_id ON transaction_matches(match_set_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_data_source_status ON transactions(data_source_id, status);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_transactions_data_source_status;
+DROP INDEX IF EXISTS idx_transaction_matches_match_set_id;
+DROP INDEX IF EXISTS idx_transaction_matches_tenant_id;
+
+ALTER TABLE transaction_matches DROP COLUMN IF EXISTS match_set_id;
+ALTER TABLE transaction_matches DROP COLUMN IF EXISTS tenant_id;
+
+ALTER TABLE match_rules DROP COLUMN IF EXISTS max_search_iterations;
+ALTER TABLE match_rules DROP COLUMN IF EXISTS max_candidates;
+ALTER TABLE match_rules DROP COLUMN IF EXISTS max_group_size;
+ALTER TABLE match_rules DROP COLUMN IF EXISTS amount_tolerance;
+ALTER TABLE match_rules DROP COLUMN IF EXISTS match_cardinality;
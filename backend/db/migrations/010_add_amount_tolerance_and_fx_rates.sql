@@ -0,0 +1,29 @@
+-- +migrate Up
+-- Amount tolerances and cross-currency comparison on rules
+ALTER TABLE match_rules ADD COLUMN IF NOT EXISTS amount_tolerance_pct DECIMAL(9, 4) NOT NULL DEFAULT 0;
+ALTER TABLE match_rules ADD COLUMN IF NOT EXISTS compare_across_currencies BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE match_rules ADD COLUMN IF NOT EXISTS fx_tolerance_pct DECIMAL(9, 4) NOT NULL DEFAULT 0;
+
+-- Exchange rates: 1 unit of base_currency = rate units of quote_currency on rate_date.
+-- Rows without a tenant apply to every tenant.
+CREATE TABLE IF NOT EXISTS fx_rates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID REFERENCES tenants(id) ON DELETE CASCADE,
+    base_currency VARCHAR(3) NOT NULL,
+    quote_currency VARCHAR(3) NOT NULL,
+    rate DECIMAL(19, 8) NOT NULL CHECK (rate > 0),
+    rate_date DATE NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fx_rates_pair_date ON fx_rates(
+    COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'), base_currency, quote_currency, rate_date
+);
+CREATE INDEX IF NOT EXISTS idx_fx_rates_rate_date ON fx_rates(rate_date);
+
+-- +migrate Down
+DROP TABLE IF EXISTS fx_rates CASCADE;
+
+ALTER TABLE match_rules DROP COLUMN IF EXISTS fx_tolerance_pct;
+ALTER TABLE match_rules DROP COLUMN IF EXISTS compare_across_currencies;
+ALTER TABLE match_rules DROP COLUMN IF EXISTS amount_tolerance_pct;
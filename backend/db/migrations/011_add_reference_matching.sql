@@ -0,0 +1,20 @@
+-- +migrate Up
+-- Reference comparison options on rules
+ALTER TABLE match_rules ADD COLUMN IF NOT EXISTS reference_mode VARCHAR(20) NOT NULL DEFAULT 'exact'
+    CHECK (reference_mode IN ('exact', 'normalized', 'similarity'));
+ALTER TABLE match_rules ADD COLUMN IF NOT EXISTS reference_pattern TEXT NOT NULL DEFAULT '';
+ALTER TABLE match_rules ADD COLUMN IF NOT EXISTS similarity_algorithm VARCHAR(20) NOT NULL DEFAULT 'levenshtein'
+    CHECK (similarity_algorithm IN ('levenshtein', 'jaro_winkler', 'token_overlap'));
+ALTER TABLE match_rules ADD COLUMN IF NOT EXISTS similarity_threshold DECIMAL(5, 4) NOT NULL DEFAULT 0.8
+    CHECK (similarity_threshold BETWEEN 0 AND 1);
+
+-- Score of the comparison that produced each match (1 for exact and manual matches)
+ALTER TABLE transaction_matches ADD COLUMN IF NOT EXISTS match_score DECIMAL(5, 4) NOT NULL DEFAULT 1;
+
+-- +migrate Down
+ALTER TABLE transaction_matches DROP COLUMN IF EXISTS match_score;
+
+ALTER TABLE match_rules DROP COLUMN IF EXISTS similarity_threshold;
+ALTER TABLE match_rules DROP COLUMN IF EXISTS similarity_algorithm;
+ALTER TABLE match_rules DROP COLUMN IF EXISTS reference_pattern;
+ALTER TABLE match_rules DROP COLUMN IF EXISTS reference_mode;
@@ -0,0 +1,24 @@
+-- +migrate Up
+-- Ordered conditions on rules; rules without conditions fall back to their match_by_* flags
+ALTER TABLE match_rules ADD COLUMN IF NOT EXISTS conditions JSONB NOT NULL DEFAULT '[]';
+
+-- Rules run by a match set, lowest priority first. match_sets.rule_id is used when a set has none.
+CREATE TABLE IF NOT EXISTS match_set_rules (
+    match_set_id UUID NOT NULL REFERENCES match_sets(id) ON DELETE CASCADE,
+    rule_id UUID NOT NULL REFERENCES match_rules(id) ON DELETE CASCADE,
+    priority INTEGER NOT NULL DEFAULT 100,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    PRIMARY KEY (match_set_id, rule_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_match_set_rules_priority ON match_set_rules(match_set_id, priority);
+
+-- Values of custom schema fields, keyed by field name
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '{}';
+
+-- +migrate Down
+ALTER TABLE transactions DROP COLUMN IF EXISTS custom_fields;
+
+DROP TABLE IF EXISTS match_set_rules CASCADE;
+
+ALTER TABLE match_rules DROP COLUMN IF EXISTS conditions;
@@ -0,0 +1,11 @@
+-- +migrate Up
+-- Groups dissolved by an unmatch keep their match row for the audit trail
+ALTER TABLE transaction_matches DROP CONSTRAINT IF EXISTS transaction_matches_match_status_check;
+ALTER TABLE transaction_matches ADD CONSTRAINT transaction_matches_match_status_check
+    CHECK (match_status IN ('Pending', 'Approved', 'Rejected', 'Unmatched'));
+
+-- +migrate Down
+UPDATE transaction_matches SET match_status = 'Rejected' WHERE match_status = 'Unmatched';
+ALTER TABLE transaction_matches DROP CONSTRAINT IF EXISTS transaction_matches_match_status_check;
+ALTER TABLE transaction_matches ADD CONSTRAINT transaction_matches_match_status_check
+    CHECK (match_status IN ('Pending', 'Approved', 'Rejected'));
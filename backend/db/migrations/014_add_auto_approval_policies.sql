@@ -0,0 +1,27 @@
+-- +migrate Up
+-- Policies under which a match set's runs approve matches without a human review
+CREATE TABLE IF NOT EXISTS auto_approval_policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    match_set_id UUID NOT NULL REFERENCES match_sets(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    rule_id UUID REFERENCES match_rules(id) ON DELETE CASCADE,
+    min_score DECIMAL(5, 4) NOT NULL DEFAULT 1,
+    max_amount DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    one_to_one_only BOOLEAN NOT NULL DEFAULT true,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    UNIQUE (match_set_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_auto_approval_policies_match_set_id ON auto_approval_policies(match_set_id);
+
+-- Name of the policy that approved a match; approved_by stays empty for these
+ALTER TABLE transaction_matches ADD COLUMN IF NOT EXISTS approved_by_policy VARCHAR(255);
+
+-- +migrate Down
+ALTER TABLE transaction_matches DROP COLUMN IF EXISTS approved_by_policy;
+
+DROP TABLE IF EXISTS auto_approval_policies CASCADE;
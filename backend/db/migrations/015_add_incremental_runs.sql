@@ -0,0 +1,16 @@
+-- +migrate Up
+-- Mode of the latest run: full reconsiders the whole unmatched backlog, incremental only
+-- pairs transactions imported or changed since the last successful run
+ALTER TABLE match_progress ADD COLUMN IF NOT EXISTS mode VARCHAR(20) NOT NULL DEFAULT 'full'
+    CHECK (mode IN ('full', 'incremental'));
+
+-- Start of the latest successful run; kept when a later run fails
+ALTER TABLE match_progress ADD COLUMN IF NOT EXISTS last_success_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_data_source_status_updated
+    ON transactions(data_source_id, status, updated_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_transactions_data_source_status_updated;
+ALTER TABLE match_progress DROP COLUMN IF EXISTS last_success_at;
+ALTER TABLE match_progress DROP COLUMN IF EXISTS mode;
@@ -0,0 +1,9 @@
+-- +migrate Up
+-- A run holds its match set's progress row under a lease. Another run may only take the row over
+-- once the run finished or its lease expired, so a crashed run cannot block the match set.
+ALTER TABLE match_progress ADD COLUMN IF NOT EXISTS run_id UUID;
+ALTER TABLE match_progress ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMP WITH TIME ZONE;
+
+-- +migrate Down
+ALTER TABLE match_progress DROP COLUMN IF EXISTS lease_expires_at;
+ALTER TABLE match_progress DROP COLUMN IF EXISTS run_id;
@@ -0,0 +1,19 @@
+-- +migrate Up
+-- A cancelled run keeps its checkpoint so it can be resumed
+ALTER TABLE match_progress DROP CONSTRAINT IF EXISTS match_progress_status_check;
+ALTER TABLE match_progress ADD CONSTRAINT match_progress_status_check
+    CHECK (status IN ('Pending', 'Running', 'Completed', 'Failed', 'Cancelled'));
+
+-- Set by a cancel request; the running run picks it up when renewing its lease
+ALTER TABLE match_progress ADD COLUMN IF NOT EXISTS cancel_requested BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Rules of the run and how many of its steps are complete
+ALTER TABLE match_progress ADD COLUMN IF NOT EXISTS checkpoint JSONB;
+
+-- +migrate Down
+ALTER TABLE match_progress DROP COLUMN IF EXISTS checkpoint;
+ALTER TABLE match_progress DROP COLUMN IF EXISTS cancel_requested;
+UPDATE match_progress SET status = 'Failed' WHERE status = 'Cancelled';
+ALTER TABLE match_progress DROP CONSTRAINT IF EXISTS match_progress_status_check;
+ALTER TABLE match_progress ADD CONSTRAINT match_progress_status_check
+    CHECK (status IN ('Pending', 'Running', 'Completed', 'Failed'));
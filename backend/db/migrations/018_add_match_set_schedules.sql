@@ -0,0 +1,39 @@
+-- +migrate Up
+-- Cron schedules that run match sets without anyone clicking run
+CREATE TABLE IF NOT EXISTS match_set_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    match_set_id UUID NOT NULL REFERENCES match_sets(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    cron_expression VARCHAR(100) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    mode VARCHAR(20) NOT NULL DEFAULT 'incremental' CHECK (mode IN ('full', 'incremental')),
+    active BOOLEAN NOT NULL DEFAULT true,
+    next_run_at TIMESTAMP,
+    last_run_at TIMESTAMP,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE INDEX IF NOT EXISTS idx_match_set_schedules_match_set_id ON match_set_schedules(match_set_id);
+CREATE INDEX IF NOT EXISTS idx_match_set_schedules_due ON match_set_schedules(next_run_at) WHERE active;
+
+-- Outcome of each run a schedule was due to start
+CREATE TABLE IF NOT EXISTS scheduled_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    schedule_id UUID NOT NULL REFERENCES match_set_schedules(id) ON DELETE CASCADE,
+    match_set_id UUID NOT NULL REFERENCES match_sets(id) ON DELETE CASCADE,
+    scheduled_for TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('Enqueued', 'Skipped', 'Completed', 'Failed', 'Cancelled')),
+    run_id UUID,
+    message TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    finished_at TIMESTAMP,
+    UNIQUE (schedule_id, scheduled_for)
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_runs_schedule_id ON scheduled_runs(schedule_id, scheduled_for DESC);
+
+-- +migrate Down
+DROP TABLE IF EXISTS scheduled_runs CASCADE;
+DROP TABLE IF EXISTS match_set_schedules CASCADE;
@@ -0,0 +1,17 @@
+-- +migrate Up
+-- Match sets that run by themselves when their data sources receive imports
+CREATE TABLE IF NOT EXISTS match_set_auto_runs (
+    match_set_id UUID PRIMARY KEY REFERENCES match_sets(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT false,
+    debounce_seconds INTEGER NOT NULL DEFAULT 300 CHECK (debounce_seconds >= 0),
+    run_as UUID NOT NULL REFERENCES users(id),
+    due_at TIMESTAMP,
+    last_import_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE INDEX IF NOT EXISTS idx_match_set_auto_runs_due ON match_set_auto_runs(due_at) WHERE enabled;
+
+-- +migrate Down
+DROP TABLE IF EXISTS match_set_auto_runs CASCADE;
@@ -0,0 +1,36 @@
+-- +migrate Up
+-- Unmatched transactions are worked as exceptions: categorized, assigned and driven to a resolution
+ALTER TABLE unmatched_transactions
+    ADD COLUMN IF NOT EXISTS reason_code VARCHAR(32) NOT NULL DEFAULT 'missing_counterpart'
+        CHECK (reason_code IN ('missing_counterpart', 'amount_mismatch', 'timing', 'duplicate')),
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'investigating', 'resolved', 'written_off')),
+    ADD COLUMN IF NOT EXISTS assignee_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC');
+
+CREATE INDEX IF NOT EXISTS idx_unmatched_transactions_tenant_status ON unmatched_transactions(tenant_id, status, created_at);
+CREATE INDEX IF NOT EXISTS idx_unmatched_transactions_assignee ON unmatched_transactions(assignee_id);
+
+-- Notes left while working an exception. They go with the exception once its transaction is matched.
+CREATE TABLE IF NOT EXISTS exception_comments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    unmatched_transaction_id UUID NOT NULL REFERENCES unmatched_transactions(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id),
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE INDEX IF NOT EXISTS idx_exception_comments_unmatched_transaction ON exception_comments(unmatched_transaction_id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS exception_comments CASCADE;
+DROP INDEX IF EXISTS idx_unmatched_transactions_assignee;
+DROP INDEX IF EXISTS idx_unmatched_transactions_tenant_status;
+ALTER TABLE unmatched_transactions
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS resolved_at,
+    DROP COLUMN IF EXISTS assignee_id,
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS reason_code;
@@ -0,0 +1,34 @@
+-- +migrate Up
+-- How each tenant posts adjustments: the data source holding them and the largest allowed write-off
+CREATE TABLE IF NOT EXISTS adjustment_settings (
+    tenant_id UUID PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+    data_source_id UUID NOT NULL REFERENCES data_sources(id),
+    write_off_limit DECIMAL(19, 4) NOT NULL DEFAULT 0 CHECK (write_off_limit >= 0),
+    updated_by UUID NOT NULL REFERENCES users(id),
+    updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+-- Adjustments posted into match groups. Rows are never deleted, so voided adjustments stay in the
+-- audit history after their transaction is removed.
+CREATE TABLE IF NOT EXISTS adjustments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    match_id UUID REFERENCES transaction_matches(id) ON DELETE SET NULL,
+    match_set_id UUID REFERENCES match_sets(id) ON DELETE SET NULL,
+    transaction_id UUID NOT NULL,
+    amount DECIMAL(19, 4) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    reason TEXT NOT NULL,
+    gl_account VARCHAR(50) NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    voided_by UUID REFERENCES users(id),
+    voided_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_adjustments_tenant_created ON adjustments(tenant_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_adjustments_match_id ON adjustments(match_id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS adjustments CASCADE;
+DROP TABLE IF EXISTS adjustment_settings CASCADE;
@@ -0,0 +1,26 @@
+-- +migrate Up
+-- Rendered reconciliation reports. The file lives in storage under file_key; the row keeps the
+-- run the report followed and the checksum of the file, so auditors can retrieve the exact report.
+CREATE TABLE IF NOT EXISTS reconciliation_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    match_set_id UUID REFERENCES match_sets(id) ON DELETE SET NULL,
+    run_id UUID,
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL CHECK (period_end >= period_start),
+    format VARCHAR(10) NOT NULL CHECK (format IN ('csv', 'xlsx', 'pdf')),
+    file_key TEXT NOT NULL,
+    file_size BIGINT NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    generated_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_reports_match_set ON reconciliation_reports(match_set_id, created_at);
+
+-- Reports list the adjustments of a match set
+CREATE INDEX IF NOT EXISTS idx_adjustments_match_set_id ON adjustments(match_set_id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_adjustments_match_set_id;
+DROP TABLE IF EXISTS reconciliation_reports CASCADE;
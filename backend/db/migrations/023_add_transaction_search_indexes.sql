@@ -0,0 +1,15 @@
+-- +migrate Up
+-- Transaction search pages by keyset on its sort columns with the ID breaking ties. These indexes
+-- serve the common orderings, alone and within a data source, without sorting the result.
+CREATE INDEX IF NOT EXISTS idx_transactions_date_id ON transactions(transaction_date, id);
+CREATE INDEX IF NOT EXISTS idx_transactions_amount_id ON transactions(amount, id);
+CREATE INDEX IF NOT EXISTS idx_transactions_data_source_date_id ON transactions(data_source_id, transaction_date, id);
+
+-- Custom field filters use containment
+CREATE INDEX IF NOT EXISTS idx_transactions_custom_fields ON transactions USING gin (custom_fields jsonb_path_ops);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_transactions_custom_fields;
+DROP INDEX IF EXISTS idx_transactions_data_source_date_id;
+DROP INDEX IF EXISTS idx_transactions_amount_id;
+DROP INDEX IF EXISTS idx_transactions_date_id;
@@ -0,0 +1,23 @@
+-- +migrate Up
+-- Exports too large to stream in a request. The job writes the file to storage under file_key
+-- and records how many rows it holds, or why it failed.
+CREATE TABLE IF NOT EXISTS export_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    match_set_id UUID REFERENCES match_sets(id) ON DELETE SET NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('transactions', 'matched', 'unmatched')),
+    format VARCHAR(10) NOT NULL CHECK (format IN ('csv', 'xlsx', 'jsonl', 'parquet')),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('Running', 'Completed', 'Failed')),
+    row_count INTEGER NOT NULL DEFAULT 0,
+    file_key TEXT NOT NULL DEFAULT '',
+    file_size BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_tenant ON export_jobs(tenant_id, created_at);
+
+-- +migrate Down
+DROP TABLE IF EXISTS export_jobs CASCADE;
@@ -0,0 +1,22 @@
+-- +migrate Up
+-- Full-text search over transaction references and descriptions. Bank narratives are codes and
+-- names rather than prose, so the simple configuration indexes every word as written, lower
+-- cased and unstemmed. References weigh more than descriptions in the rank.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', COALESCE(reference, '')), 'A') ||
+    setweight(to_tsvector('simple', COALESCE(description, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_search_vector ON transactions USING gin (search_vector);
+
+-- Trigram indexes serve fuzzy word lookups and the substring filter of transaction search
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_description_trgm ON transactions USING gin (description gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_transactions_reference_trgm ON transactions USING gin (reference gin_trgm_ops);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_transactions_reference_trgm;
+DROP INDEX IF EXISTS idx_transactions_description_trgm;
+DROP INDEX IF EXISTS idx_transactions_search_vector;
+ALTER TABLE transactions DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,57 @@
+-- +migrate Up
+-- Every data source, rule, transaction and upload belongs to a tenant. Data sources and rules
+-- without one take the tenant of the match sets using them; transactions and uploads take the
+-- tenant of their data source. Rows no tenant can be inferred for stay without one, and no
+-- tenant sees them.
+UPDATE data_sources ds
+SET tenant_id = ms.tenant_id
+FROM match_set_data_sources msds
+JOIN match_sets ms ON ms.id = msds.match_set_id
+WHERE msds.data_source_id = ds.id AND ds.tenant_id IS NULL;
+
+UPDATE match_rules mr
+SET tenant_id = ms.tenant_id
+FROM match_sets ms
+WHERE ms.rule_id = mr.id AND mr.tenant_id IS NULL;
+
+UPDATE transactions t SET tenant_id = ds.tenant_id FROM data_sources ds WHERE ds.id = t.data_source_id;
+
+ALTER TABLE transaction_uploads ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id) ON DELETE CASCADE;
+UPDATE transaction_uploads u SET tenant_id = ds.tenant_id FROM data_sources ds WHERE ds.id = u.data_source_id;
+
+-- A transaction belongs to the tenant of its data source
+ALTER TABLE data_sources ADD CONSTRAINT data_sources_id_tenant_key UNIQUE (id, tenant_id);
+ALTER TABLE transactions ADD CONSTRAINT transactions_data_source_tenant_fkey
+    FOREIGN KEY (data_source_id, tenant_id) REFERENCES data_sources(id, tenant_id);
+
+-- Rule names were never unique. Later rules sharing a name within their tenant get the start of
+-- their ID appended, so the oldest keeps the name.
+UPDATE match_rules mr
+SET name = left(mr.name, 89) || ' (' || left(mr.id::text, 8) || ')'
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY tenant_id, name ORDER BY created_at, id) AS position
+    FROM match_rules
+    WHERE tenant_id IS NOT NULL
+) ranked
+WHERE ranked.id = mr.id AND ranked.position > 1;
+
+-- Names are unique within a tenant
+ALTER TABLE data_sources DROP CONSTRAINT IF EXISTS data_sources_name_key;
+ALTER TABLE data_sources ADD CONSTRAINT data_sources_tenant_name_key UNIQUE (tenant_id, name);
+ALTER TABLE match_rules ADD CONSTRAINT match_rules_tenant_name_key UNIQUE (tenant_id, name);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_tenant_date_id ON transactions(tenant_id, transaction_date, id);
+CREATE INDEX IF NOT EXISTS idx_transaction_uploads_tenant_id ON transaction_uploads(tenant_id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_transaction_uploads_tenant_id;
+DROP INDEX IF EXISTS idx_transactions_tenant_date_id;
+
+ALTER TABLE match_rules DROP CONSTRAINT IF EXISTS match_rules_tenant_name_key;
+ALTER TABLE data_sources DROP CONSTRAINT IF EXISTS data_sources_tenant_name_key;
+ALTER TABLE data_sources ADD CONSTRAINT data_sources_name_key UNIQUE (name);
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_data_source_tenant_fkey;
+ALTER TABLE data_sources DROP CONSTRAINT IF EXISTS data_sources_id_tenant_key;
+
+ALTER TABLE transaction_uploads DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,55 @@
+-- +migrate Up
+-- Row-level security backs up the tenant_id filters of the queries on tenant-owned tables. The
+-- repositories set app.tenant_id for the transaction a tenant's statements run in; the policies
+-- then only let them see and write that tenant's rows. Sessions that set no tenant, such as
+-- migrations and the match engine's cross-table queries, are not restricted.
+--
+-- FORCE applies the policies to the table owner the application connects as too. Superusers and
+-- roles with BYPASSRLS are never subject to them.
+CREATE OR REPLACE FUNCTION app_current_tenant_id()
+RETURNS UUID AS $$
+    SELECT NULLIF(current_setting('app.tenant_id', true), '')::uuid;
+$$ LANGUAGE sql STABLE;
+
+ALTER TABLE data_sources ENABLE ROW LEVEL SECURITY;
+ALTER TABLE data_sources FORCE ROW LEVEL SECURITY;
+CREATE POLICY data_sources_tenant_isolation ON data_sources
+    USING (app_current_tenant_id() IS NULL OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_current_tenant_id() IS NULL OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE match_rules ENABLE ROW LEVEL SECURITY;
+ALTER TABLE match_rules FORCE ROW LEVEL SECURITY;
+CREATE POLICY match_rules_tenant_isolation ON match_rules
+    USING (app_current_tenant_id() IS NULL OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_current_tenant_id() IS NULL OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE transactions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE transactions FORCE ROW LEVEL SECURITY;
+CREATE POLICY transactions_tenant_isolation ON transactions
+    USING (app_current_tenant_id() IS NULL OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_current_tenant_id() IS NULL OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE transaction_uploads ENABLE ROW LEVEL SECURITY;
+ALTER TABLE transaction_uploads FORCE ROW LEVEL SECURITY;
+CREATE POLICY transaction_uploads_tenant_isolation ON transaction_uploads
+    USING (app_current_tenant_id() IS NULL OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_current_tenant_id() IS NULL OR tenant_id = app_current_tenant_id());
+
+-- +migrate Down
+DROP POLICY IF EXISTS transaction_uploads_tenant_isolation ON transaction_uploads;
+ALTER TABLE transaction_uploads NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE transaction_uploads DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS transactions_tenant_isolation ON transactions;
+ALTER TABLE transactions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE transactions DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS match_rules_tenant_isolation ON match_rules;
+ALTER TABLE match_rules NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE match_rules DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS data_sources_tenant_isolation ON data_sources;
+ALTER TABLE data_sources NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE data_sources DISABLE ROW LEVEL SECURITY;
+
+DROP FUNCTION IF EXISTS app_current_tenant_id();
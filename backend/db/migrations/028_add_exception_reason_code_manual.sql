@@ -0,0 +1,9 @@
+-- +migrate Up
+-- Runs reclassify the exceptions they leave unmatched. A reason code set by an analyst stays as it
+-- is; the flag tells the runs which codes are theirs to replace.
+ALTER TABLE unmatched_transactions
+    ADD COLUMN IF NOT EXISTS reason_code_manual BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +migrate Down
+ALTER TABLE unmatched_transactions
+    DROP COLUMN IF EXISTS reason_code_manual;
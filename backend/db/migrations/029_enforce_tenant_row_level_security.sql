@@ -0,0 +1,246 @@
+-- +migrate Up
+-- Row-level security now covers every tenant-owned table and denies what a session is not scoped
+-- to. A session that sets no app.tenant_id sees and writes no tenant's rows. Work that spans
+-- tenants, such as migrations, the scheduler and the job queue, sets app.all_tenants to 'on' for
+-- its transaction instead. Connecting those as a separate role with BYPASSRLS works as well.
+--
+-- Tables without a tenant_id of their own follow the row they belong to. Rows of role_permissions
+-- and fx_rates without a tenant are the defaults every tenant reads; only a cross-tenant session
+-- writes them.
+CREATE OR REPLACE FUNCTION app_all_tenants()
+RETURNS BOOLEAN AS $$
+    SELECT COALESCE(current_setting('app.all_tenants', true), '') = 'on';
+$$ LANGUAGE sql STABLE;
+
+DROP POLICY IF EXISTS data_sources_tenant_isolation ON data_sources;
+CREATE POLICY data_sources_tenant_isolation ON data_sources
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+DROP POLICY IF EXISTS match_rules_tenant_isolation ON match_rules;
+CREATE POLICY match_rules_tenant_isolation ON match_rules
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+DROP POLICY IF EXISTS transactions_tenant_isolation ON transactions;
+CREATE POLICY transactions_tenant_isolation ON transactions
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+DROP POLICY IF EXISTS transaction_uploads_tenant_isolation ON transaction_uploads;
+CREATE POLICY transaction_uploads_tenant_isolation ON transaction_uploads
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE tenant_users ENABLE ROW LEVEL SECURITY;
+ALTER TABLE tenant_users FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_users_tenant_isolation ON tenant_users
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE match_sets ENABLE ROW LEVEL SECURITY;
+ALTER TABLE match_sets FORCE ROW LEVEL SECURITY;
+CREATE POLICY match_sets_tenant_isolation ON match_sets
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE matched_transactions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE matched_transactions FORCE ROW LEVEL SECURITY;
+CREATE POLICY matched_transactions_tenant_isolation ON matched_transactions
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE unmatched_transactions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE unmatched_transactions FORCE ROW LEVEL SECURITY;
+CREATE POLICY unmatched_transactions_tenant_isolation ON unmatched_transactions
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE data_source_schemas ENABLE ROW LEVEL SECURITY;
+ALTER TABLE data_source_schemas FORCE ROW LEVEL SECURITY;
+CREATE POLICY data_source_schemas_tenant_isolation ON data_source_schemas
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE transaction_matches ENABLE ROW LEVEL SECURITY;
+ALTER TABLE transaction_matches FORCE ROW LEVEL SECURITY;
+CREATE POLICY transaction_matches_tenant_isolation ON transaction_matches
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE auto_approval_policies ENABLE ROW LEVEL SECURITY;
+ALTER TABLE auto_approval_policies FORCE ROW LEVEL SECURITY;
+CREATE POLICY auto_approval_policies_tenant_isolation ON auto_approval_policies
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE match_set_schedules ENABLE ROW LEVEL SECURITY;
+ALTER TABLE match_set_schedules FORCE ROW LEVEL SECURITY;
+CREATE POLICY match_set_schedules_tenant_isolation ON match_set_schedules
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE match_set_auto_runs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE match_set_auto_runs FORCE ROW LEVEL SECURITY;
+CREATE POLICY match_set_auto_runs_tenant_isolation ON match_set_auto_runs
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE exception_comments ENABLE ROW LEVEL SECURITY;
+ALTER TABLE exception_comments FORCE ROW LEVEL SECURITY;
+CREATE POLICY exception_comments_tenant_isolation ON exception_comments
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE adjustment_settings ENABLE ROW LEVEL SECURITY;
+ALTER TABLE adjustment_settings FORCE ROW LEVEL SECURITY;
+CREATE POLICY adjustment_settings_tenant_isolation ON adjustment_settings
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE adjustments ENABLE ROW LEVEL SECURITY;
+ALTER TABLE adjustments FORCE ROW LEVEL SECURITY;
+CREATE POLICY adjustments_tenant_isolation ON adjustments
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE reconciliation_reports ENABLE ROW LEVEL SECURITY;
+ALTER TABLE reconciliation_reports FORCE ROW LEVEL SECURITY;
+CREATE POLICY reconciliation_reports_tenant_isolation ON reconciliation_reports
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE export_jobs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE export_jobs FORCE ROW LEVEL SECURITY;
+CREATE POLICY export_jobs_tenant_isolation ON export_jobs
+    USING (app_all_tenants() OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE role_permissions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE role_permissions FORCE ROW LEVEL SECURITY;
+CREATE POLICY role_permissions_tenant_isolation ON role_permissions
+    USING (app_all_tenants() OR tenant_id IS NULL OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE fx_rates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE fx_rates FORCE ROW LEVEL SECURITY;
+CREATE POLICY fx_rates_tenant_isolation ON fx_rates
+    USING (app_all_tenants() OR tenant_id IS NULL OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_all_tenants() OR tenant_id = app_current_tenant_id());
+
+ALTER TABLE import_records ENABLE ROW LEVEL SECURITY;
+ALTER TABLE import_records FORCE ROW LEVEL SECURITY;
+CREATE POLICY import_records_tenant_isolation ON import_records
+    USING (app_all_tenants() OR EXISTS (SELECT 1 FROM data_sources ds WHERE ds.id = import_records.data_source_id AND ds.tenant_id = app_current_tenant_id()))
+    WITH CHECK (app_all_tenants() OR EXISTS (SELECT 1 FROM data_sources ds WHERE ds.id = import_records.data_source_id AND ds.tenant_id = app_current_tenant_id()));
+
+ALTER TABLE raw_transactions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE raw_transactions FORCE ROW LEVEL SECURITY;
+CREATE POLICY raw_transactions_tenant_isolation ON raw_transactions
+    USING (app_all_tenants() OR EXISTS (SELECT 1 FROM data_sources ds WHERE ds.id = raw_transactions.data_source_id AND ds.tenant_id = app_current_tenant_id()))
+    WITH CHECK (app_all_tenants() OR EXISTS (SELECT 1 FROM data_sources ds WHERE ds.id = raw_transactions.data_source_id AND ds.tenant_id = app_current_tenant_id()));
+
+ALTER TABLE match_progress ENABLE ROW LEVEL SECURITY;
+ALTER TABLE match_progress FORCE ROW LEVEL SECURITY;
+CREATE POLICY match_progress_tenant_isolation ON match_progress
+    USING (app_all_tenants() OR EXISTS (SELECT 1 FROM match_sets ms WHERE ms.id = match_progress.match_set_id AND ms.tenant_id = app_current_tenant_id()))
+    WITH CHECK (app_all_tenants() OR EXISTS (SELECT 1 FROM match_sets ms WHERE ms.id = match_progress.match_set_id AND ms.tenant_id = app_current_tenant_id()));
+
+-- +migrate Down
+DROP POLICY IF EXISTS match_progress_tenant_isolation ON match_progress;
+ALTER TABLE match_progress NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE match_progress DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS raw_transactions_tenant_isolation ON raw_transactions;
+ALTER TABLE raw_transactions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE raw_transactions DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS import_records_tenant_isolation ON import_records;
+ALTER TABLE import_records NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE import_records DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS fx_rates_tenant_isolation ON fx_rates;
+ALTER TABLE fx_rates NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE fx_rates DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS role_permissions_tenant_isolation ON role_permissions;
+ALTER TABLE role_permissions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE role_permissions DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS export_jobs_tenant_isolation ON export_jobs;
+ALTER TABLE export_jobs NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE export_jobs DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS reconciliation_reports_tenant_isolation ON reconciliation_reports;
+ALTER TABLE reconciliation_reports NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE reconciliation_reports DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS adjustments_tenant_isolation ON adjustments;
+ALTER TABLE adjustments NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE adjustments DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS adjustment_settings_tenant_isolation ON adjustment_settings;
+ALTER TABLE adjustment_settings NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE adjustment_settings DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS exception_comments_tenant_isolation ON exception_comments;
+ALTER TABLE exception_comments NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE exception_comments DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS match_set_auto_runs_tenant_isolation ON match_set_auto_runs;
+ALTER TABLE match_set_auto_runs NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE match_set_auto_runs DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS match_set_schedules_tenant_isolation ON match_set_schedules;
+ALTER TABLE match_set_schedules NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE match_set_schedules DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS auto_approval_policies_tenant_isolation ON auto_approval_policies;
+ALTER TABLE auto_approval_policies NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE auto_approval_policies DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS transaction_matches_tenant_isolation ON transaction_matches;
+ALTER TABLE transaction_matches NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE transaction_matches DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS data_source_schemas_tenant_isolation ON data_source_schemas;
+ALTER TABLE data_source_schemas NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE data_source_schemas DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS unmatched_transactions_tenant_isolation ON unmatched_transactions;
+ALTER TABLE unmatched_transactions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE unmatched_transactions DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS matched_transactions_tenant_isolation ON matched_transactions;
+ALTER TABLE matched_transactions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE matched_transactions DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS match_sets_tenant_isolation ON match_sets;
+ALTER TABLE match_sets NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE match_sets DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_users_tenant_isolation ON tenant_users;
+ALTER TABLE tenant_users NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE tenant_users DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS transaction_uploads_tenant_isolation ON transaction_uploads;
+CREATE POLICY transaction_uploads_tenant_isolation ON transaction_uploads
+    USING (app_current_tenant_id() IS NULL OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_current_tenant_id() IS NULL OR tenant_id = app_current_tenant_id());
+
+DROP POLICY IF EXISTS transactions_tenant_isolation ON transactions;
+CREATE POLICY transactions_tenant_isolation ON transactions
+    USING (app_current_tenant_id() IS NULL OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_current_tenant_id() IS NULL OR tenant_id = app_current_tenant_id());
+
+DROP POLICY IF EXISTS match_rules_tenant_isolation ON match_rules;
+CREATE POLICY match_rules_tenant_isolation ON match_rules
+    USING (app_current_tenant_id() IS NULL OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_current_tenant_id() IS NULL OR tenant_id = app_current_tenant_id());
+
+DROP POLICY IF EXISTS data_sources_tenant_isolation ON data_sources;
+CREATE POLICY data_sources_tenant_isolation ON data_sources
+    USING (app_current_tenant_id() IS NULL OR tenant_id = app_current_tenant_id())
+    WITH CHECK (app_current_tenant_id() IS NULL OR tenant_id = app_current_tenant_id());
+
+DROP FUNCTION IF EXISTS app_all_tenants();
@@ -0,0 +1,13 @@
+-- +migrate Up
+-- The transaction of a voided adjustment is kept with the Voided status, so exports and reports
+-- still show the write-off; it is never matched again
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+    CHECK (status IN ('Unmatched', 'Matched', 'Approved', 'Voided'));
+
+-- +migrate Down
+-- The old status check has no room for voided transactions, so rolling back drops them as voiding used to
+DELETE FROM transactions WHERE status = 'Voided';
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+    CHECK (status IN ('Unmatched', 'Matched', 'Approved'));
@@ -2,6 +2,7 @@ package db
 
 import (
 	"bufio"
+	"database/sql"
 	"fmt"
 	"log"
 	"os"
@@ -94,6 +95,12 @@ func RunMigrations() error {
 			return fmt.Errorf("failed to begin transaction: %v", err)
 		}
 
+		// Migrations work on the rows of every tenant
+		if err := allTenants(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+
 		log.Printf("Applying migration: %s", fileName)
 		_, err = tx.Exec(upSQL)
 		if err != nil {
@@ -172,6 +179,11 @@ func RollbackMigration() error {
 		return fmt.Errorf("failed to begin transaction: %v", err)
 	}
 
+	if err := allTenants(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	log.Printf("Rolling back migration: %s", lastMigration)
 	_, err = tx.Exec(downSQL)
 	if err != nil {
@@ -195,6 +207,15 @@ func RollbackMigration() error {
 	return nil
 }
 
+// allTenants lifts the tenant row-level security policies for the rest of a migration's
+// transaction
+func allTenants(tx *sql.Tx) error {
+	if _, err := tx.Exec("SELECT set_config('app.all_tenants', 'on', true)"); err != nil {
+		return fmt.Errorf("failed to lift tenant row-level security: %v", err)
+	}
+	return nil
+}
+
 // extractMigrationSection extracts either the "up" or "down" section from a migration file
 func extractMigrationSection(filePath string, section string) (string, error) {
 	file, err := os.Open(filePath)
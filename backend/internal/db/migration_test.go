@@ -0,0 +1,126 @@
+package db
+
+import (
+	"backend/internal/testutil"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// useScratchSchema creates a schema in tx for the migrations to build their tables in. Rolling
+// back tx drops it again.
+func useScratchSchema(t *testing.T, tx *sql.Tx) {
+	t.Helper()
+
+	if _, err := tx.Exec("CREATE SCHEMA migration_test"); err != nil {
+		t.Fatalf("Failed to create scratch schema: %v", err)
+	}
+	// Extensions stay reachable in public
+	if _, err := tx.Exec("SET LOCAL search_path TO migration_test, public"); err != nil {
+		t.Fatalf("Failed to switch to scratch schema: %v", err)
+	}
+	if err := allTenants(tx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// applyMigrations runs the UP sections of the migrations from first to last, inclusive, in tx
+func applyMigrations(t *testing.T, tx *sql.Tx, first, last string) {
+	t.Helper()
+
+	migrationsDir := filepath.Join("..", "..", "db", "migrations")
+	files, err := os.ReadDir(migrationsDir)
+	if err != nil {
+		t.Fatalf("Failed to read migrations directory: %v", err)
+	}
+	var migrationFiles []string
+	for _, file := range files {
+		name := file.Name()
+		if !file.IsDir() && strings.HasSuffix(name, ".sql") && name != "template.sql" && name >= first && name <= last {
+			migrationFiles = append(migrationFiles, name)
+		}
+	}
+	sort.Strings(migrationFiles)
+
+	for _, fileName := range migrationFiles {
+		upSQL, err := extractMigrationSection(filepath.Join(migrationsDir, fileName), "up")
+		if err != nil {
+			t.Fatalf("Failed to read migration %s: %v", fileName, err)
+		}
+		if _, err := tx.Exec(upSQL); err != nil {
+			t.Fatalf("Migration %s error = %v", fileName, err)
+		}
+	}
+}
+
+func TestMigration_TenantIsolationRenamesDuplicateRules(t *testing.T) {
+	testDB := testutil.SetupTestDB(t)
+	defer testDB.Close()
+
+	tx, err := testDB.Begin()
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	useScratchSchema(t, tx)
+	applyMigrations(t, tx, "001", "025_add_transaction_full_text_search.sql")
+
+	var userID, tenantA, tenantB string
+	if err := tx.QueryRow(`INSERT INTO users (email, name, password_hash) VALUES ('migration@example.com', 'Migration', 'hash') RETURNING id`).Scan(&userID); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	for _, tenant := range []struct {
+		name string
+		id   *string
+	}{{"Tenant A", &tenantA}, {"Tenant B", &tenantB}} {
+		if err := tx.QueryRow("INSERT INTO tenants (name) VALUES ($1) RETURNING id", tenant.name).Scan(tenant.id); err != nil {
+			t.Fatalf("Failed to create tenant: %v", err)
+		}
+	}
+
+	// Two tenant A rules share a name with the oldest one, and tenant B has a rule of that name too
+	rules := []struct {
+		tenantID, createdAt string
+		id                  *string
+	}{
+		{tenantA, "2024-01-01", new(string)},
+		{tenantA, "2024-02-01", new(string)},
+		{tenantA, "2024-03-01", new(string)},
+		{tenantB, "2024-04-01", new(string)},
+	}
+	for _, rule := range rules {
+		if err := tx.QueryRow(`
+			INSERT INTO match_rules (name, tenant_id, created_by, created_at)
+			VALUES ('Amount', $1, $2, $3)
+			RETURNING id
+		`, rule.tenantID, userID, rule.createdAt).Scan(rule.id); err != nil {
+			t.Fatalf("Failed to create rule: %v", err)
+		}
+	}
+
+	applyMigrations(t, tx, "026", "026_add_tenant_isolation.sql")
+
+	names := make(map[string]bool)
+	for i, rule := range rules {
+		var name string
+		if err := tx.QueryRow("SELECT name FROM match_rules WHERE id = $1", *rule.id).Scan(&name); err != nil {
+			t.Fatalf("Failed to read rule: %v", err)
+		}
+
+		want := "Amount"
+		if i == 1 || i == 2 {
+			want = "Amount (" + (*rule.id)[:8] + ")"
+		}
+		if name != want {
+			t.Errorf("rule %d name = %q, want %q", i, name, want)
+		}
+		names[rule.tenantID+"/"+name] = true
+	}
+	if len(names) != len(rules) {
+		t.Errorf("rule names = %v, want unique within each tenant", names)
+	}
+}
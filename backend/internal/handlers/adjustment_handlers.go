@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"backend/internal/auth"
+	"backend/internal/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// AdjustmentHandlers handles HTTP requests related to adjustments closing match groups
+type AdjustmentHandlers struct {
+	matchService *services.MatchService
+}
+
+// NewAdjustmentHandlers creates a new instance of AdjustmentHandlers
+func NewAdjustmentHandlers(matchService *services.MatchService) *AdjustmentHandlers {
+	return &AdjustmentHandlers{
+		matchService: matchService,
+	}
+}
+
+// RegisterRoutes registers the routes for adjustment operations
+func (h *AdjustmentHandlers) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/adjustments", h.GetAdjustments).Methods("GET")
+	router.HandleFunc("/adjustments/settings", h.GetSettings).Methods("GET")
+	router.HandleFunc("/adjustments/settings", h.UpdateSettings).Methods("PUT")
+	router.HandleFunc("/matches/{id}/adjustments", h.GetMatchAdjustments).Methods("GET")
+	router.HandleFunc("/matches/{id}/adjustments", h.AdjustMatch).Methods("POST")
+}
+
+// adjustmentSettingsRequest is the body of an adjustment settings update
+type adjustmentSettingsRequest struct {
+	WriteOffLimit float64 `json:"write_off_limit"`
+}
+
+// GetAdjustments lists the tenant's adjustments, newest first
+func (h *AdjustmentHandlers) GetAdjustments(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Pagination
+	page := 1
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	pageSize := 20
+	if pageSizeStr := r.URL.Query().Get("pageSize"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
+			pageSize = ps
+		}
+	}
+
+	// Get the adjustments
+	adjustments, total, err := h.matchService.GetAdjustments(pageSize, (page-1)*pageSize, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Create response
+	response := map[string]interface{}{
+		"adjustments": adjustments,
+		"total":       total,
+		"page":        page,
+		"pageSize":    pageSize,
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSettings retrieves the tenant's write-off limit and adjustment data source
+func (h *AdjustmentHandlers) GetSettings(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get the settings
+	settings, err := h.matchService.GetAdjustmentSettings(userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the settings
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// UpdateSettings sets the tenant's write-off limit
+func (h *AdjustmentHandlers) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse request body
+	var req adjustmentSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Save the settings
+	settings, err := h.matchService.SetWriteOffLimit(req.WriteOffLimit, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the saved settings
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// GetMatchAdjustments lists the adjustments posted into a match group
+func (h *AdjustmentHandlers) GetMatchAdjustments(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match ID from URL
+	vars := mux.Vars(r)
+	matchID := vars["id"]
+
+	// Get the adjustments
+	adjustments, err := h.matchService.GetMatchAdjustments(matchID, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the adjustments
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(adjustments)
+}
+
+// AdjustMatch closes the difference between the sides of a pending match group
+func (h *AdjustmentHandlers) AdjustMatch(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match ID from URL
+	vars := mux.Vars(r)
+	matchID := vars["id"]
+
+	// Parse request body
+	var req services.AdjustmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Post the adjustment
+	adjustment, err := h.matchService.AdjustMatch(matchID, req, userID, tenantID)
+	if err != nil {
+		handleMatchError(w, err)
+		return
+	}
+
+	// Return the adjustment
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(adjustment)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"backend/internal/auth"
+	"backend/internal/models"
+	"backend/internal/services"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ApprovalPolicyHandlers handles HTTP requests related to auto-approval policies
+type ApprovalPolicyHandlers struct {
+	policyService *services.ApprovalPolicyService
+}
+
+// NewApprovalPolicyHandlers creates a new instance of ApprovalPolicyHandlers
+func NewApprovalPolicyHandlers(policyService *services.ApprovalPolicyService) *ApprovalPolicyHandlers {
+	return &ApprovalPolicyHandlers{
+		policyService: policyService,
+	}
+}
+
+// RegisterRoutes registers the routes for auto-approval policy operations
+func (h *ApprovalPolicyHandlers) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/match-sets/{id}/approval-policies", h.GetPolicies).Methods("GET")
+	router.HandleFunc("/match-sets/{id}/approval-policies", h.CreatePolicy).Methods("POST")
+	router.HandleFunc("/match-sets/{id}/approval-policies/{policyId}", h.UpdatePolicy).Methods("PUT")
+	router.HandleFunc("/match-sets/{id}/approval-policies/{policyId}", h.DeletePolicy).Methods("DELETE")
+}
+
+// approvalPolicyRequest is the body of a create or update request. Omitted criteria default to
+// the strictest policy: a perfect score, one transaction on each side and active.
+type approvalPolicyRequest struct {
+	Name         string   `json:"name"`
+	RuleID       string   `json:"rule_id"`
+	MinScore     *float64 `json:"min_score"`
+	MaxAmount    float64  `json:"max_amount"`
+	OneToOneOnly *bool    `json:"one_to_one_only"`
+	Active       *bool    `json:"active"`
+}
+
+// policy converts the request to a policy, applying the defaults
+func (req *approvalPolicyRequest) policy() *models.AutoApprovalPolicy {
+	policy := &models.AutoApprovalPolicy{
+		Name:         req.Name,
+		RuleID:       req.RuleID,
+		MinScore:     1,
+		MaxAmount:    req.MaxAmount,
+		OneToOneOnly: true,
+		Active:       true,
+	}
+	if req.MinScore != nil {
+		policy.MinScore = *req.MinScore
+	}
+	if req.OneToOneOnly != nil {
+		policy.OneToOneOnly = *req.OneToOneOnly
+	}
+	if req.Active != nil {
+		policy.Active = *req.Active
+	}
+	return policy
+}
+
+// GetPolicies retrieves the auto-approval policies of a match set
+func (h *ApprovalPolicyHandlers) GetPolicies(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set ID from URL
+	vars := mux.Vars(r)
+	matchSetID := vars["id"]
+
+	// Get the policies
+	policies, err := h.policyService.GetPolicies(matchSetID, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	if policies == nil {
+		policies = []models.AutoApprovalPolicy{}
+	}
+
+	// Return the policies
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policies)
+}
+
+// CreatePolicy adds an auto-approval policy to a match set
+func (h *ApprovalPolicyHandlers) CreatePolicy(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set ID from URL
+	vars := mux.Vars(r)
+	matchSetID := vars["id"]
+
+	// Parse request body
+	var req approvalPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Create the policy
+	policy, err := h.policyService.CreatePolicy(matchSetID, req.policy(), userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the created policy
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(policy)
+}
+
+// UpdatePolicy replaces the criteria of an auto-approval policy
+func (h *ApprovalPolicyHandlers) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set and policy IDs from URL
+	vars := mux.Vars(r)
+	matchSetID := vars["id"]
+	policyID := vars["policyId"]
+
+	// Parse request body
+	var req approvalPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Update the policy
+	policy, err := h.policyService.UpdatePolicy(matchSetID, policyID, req.policy(), userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the updated policy
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+// DeletePolicy removes an auto-approval policy from a match set
+func (h *ApprovalPolicyHandlers) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set and policy IDs from URL
+	vars := mux.Vars(r)
+	matchSetID := vars["id"]
+	policyID := vars["policyId"]
+
+	// Delete the policy
+	if err := h.policyService.DeletePolicy(matchSetID, policyID, userID, tenantID); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
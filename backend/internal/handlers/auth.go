@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"backend/internal/auth"
+	"backend/internal/models"
 	"backend/internal/repository"
 	"backend/internal/services"
 	"encoding/json"
@@ -14,9 +16,9 @@ type AuthHandler struct {
 	roleService *services.RoleService
 }
 
-func NewAuthHandler(userRepo repository.UserRepository, roleService *services.RoleService) *AuthHandler {
+func NewAuthHandler(userRepo repository.UserRepository, tenantRepo repository.TenantRepository, roleService *services.RoleService) *AuthHandler {
 	return &AuthHandler{
-		authService: services.NewAuthService(userRepo),
+		authService: services.NewAuthService(userRepo, tenantRepo),
 		jwtService:  services.NewJWTService(),
 		roleService: roleService,
 	}
@@ -48,10 +50,13 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(user)
 }
 
+// Login authenticates a user and issues a token scoped to one of the user's tenants: the one
+// requested, or the first one when none is. The response lists all of the user's tenants.
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		TenantID string `json:"tenant_id"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -65,14 +70,59 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokenInfo, err := h.jwtService.GenerateToken(user)
+	tenants, tenant, err := h.authService.SelectTenant(user.ID, req.TenantID)
 	if err != nil {
-		http.Error(w, "Error generating token", http.StatusInternalServerError)
+		handleServiceError(w, err)
+		return
+	}
+
+	h.writeToken(w, user, tenants, tenant)
+}
+
+// SwitchTenant issues a new token for the authenticated user scoped to another of the user's
+// tenants
+func (h *AuthHandler) SwitchTenant(w http.ResponseWriter, r *http.Request) {
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		TenantID string `json:"tenant_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
+	if req.TenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.authService.GetUser(userID)
+	if err != nil {
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	tenants, tenant, err := h.authService.SelectTenant(user.ID, req.TenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	h.writeToken(w, user, tenants, tenant)
+}
+
+// writeToken issues a token for a user scoped to a tenant and writes it with the user's roles
+// and tenants
+func (h *AuthHandler) writeToken(w http.ResponseWriter, user *models.User, tenants []models.Tenant, tenant *models.Tenant) {
 	// Get user roles
-	var roles []string
+	roles := []string{}
 	if h.roleService != nil {
 		userRoles, err := h.roleService.GetUserRoles(user.ID)
 		if err == nil {
@@ -86,18 +136,32 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	// Check for admin role
 	isAdmin := false
 	for _, role := range roles {
-		if role == "admin" {
+		if role == string(models.RoleAdmin) {
 			isAdmin = true
 			break
 		}
 	}
 
+	tenantID := ""
+	if tenant != nil {
+		tenantID = tenant.ID
+	}
+
+	tokenInfo, err := h.jwtService.GenerateToken(user, tenantID, roles)
+	if err != nil {
+		http.Error(w, "Error generating token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"token":      tokenInfo.Token,
 		"expires_in": tokenInfo.ExpiresIn,
 		"user":       user,
 		"roles":      roles,
 		"is_admin":   isAdmin,
+		"tenant_id":  tenantID,
+		"tenants":    tenants,
 	})
 }
 
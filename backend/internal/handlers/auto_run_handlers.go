@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"backend/internal/auth"
+	"backend/internal/services"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// AutoRunHandlers handles HTTP requests related to running match sets on imports
+type AutoRunHandlers struct {
+	autoRunService *services.AutoRunService
+}
+
+// NewAutoRunHandlers creates a new instance of AutoRunHandlers
+func NewAutoRunHandlers(autoRunService *services.AutoRunService) *AutoRunHandlers {
+	return &AutoRunHandlers{
+		autoRunService: autoRunService,
+	}
+}
+
+// RegisterRoutes registers the routes for auto-run operations
+func (h *AutoRunHandlers) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/match-sets/{id}/auto-run", h.GetAutoRun).Methods("GET")
+	router.HandleFunc("/match-sets/{id}/auto-run", h.SetAutoRun).Methods("PUT")
+}
+
+// autoRunRequest is the body of an auto-run update. The debounce defaults to five minutes.
+type autoRunRequest struct {
+	Enabled         bool `json:"enabled"`
+	DebounceSeconds *int `json:"debounce_seconds"`
+}
+
+// GetAutoRun retrieves whether a match set runs when its data sources receive imports
+func (h *AutoRunHandlers) GetAutoRun(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set ID from URL
+	vars := mux.Vars(r)
+	matchSetID := vars["id"]
+
+	// Get the auto-run
+	autoRun, err := h.autoRunService.GetAutoRun(matchSetID, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the auto-run
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(autoRun)
+}
+
+// SetAutoRun enables or disables running a match set when its data sources receive imports
+func (h *AutoRunHandlers) SetAutoRun(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set ID from URL
+	vars := mux.Vars(r)
+	matchSetID := vars["id"]
+
+	// Parse request body
+	var req autoRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Save the auto-run
+	autoRun, err := h.autoRunService.SetAutoRun(matchSetID, req.Enabled, req.DebounceSeconds, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the saved auto-run
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(autoRun)
+}
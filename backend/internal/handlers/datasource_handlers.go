@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"backend/internal/auth"
 	"backend/internal/models"
 	"backend/internal/services"
 	"backend/internal/utils"
@@ -30,6 +31,13 @@ func NewDataSourceHandler(
 
 // CreateDataSource handles data source creation
 func (h *DataSourceHandler) CreateDataSource(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
 	// Get user claims from JWT token
 	userClaims, ok := r.Context().Value("user").(*jwt.MapClaims)
 	if !ok || userClaims == nil {
@@ -71,7 +79,7 @@ func (h *DataSourceHandler) CreateDataSource(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Create data source
-	dataSource, err := h.dataSourceService.CreateDataSource(req.Name, req.Description)
+	dataSource, err := h.dataSourceService.CreateDataSource(tenantID, req.Name, req.Description)
 	if err != nil {
 		if err == services.ErrDataSourceExists {
 			http.Error(w, "Data source with this name already exists", http.StatusConflict)
@@ -87,14 +95,21 @@ func (h *DataSourceHandler) CreateDataSource(w http.ResponseWriter, r *http.Requ
 	json.NewEncoder(w).Encode(dataSource)
 }
 
-// GetDataSourceByID retrieves a data source by ID
+// GetDataSourceByID retrieves a data source of the tenant by ID
 func (h *DataSourceHandler) GetDataSourceByID(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
 	// Extract ID from URL path
 	vars := mux.Vars(r)
 	id := vars["id"]
 
 	// Get data source
-	dataSource, err := h.dataSourceService.GetDataSourceByID(id)
+	dataSource, err := h.dataSourceService.GetDataSourceByID(tenantID, id)
 	if err != nil {
 		http.Error(w, "Data source not found", http.StatusNotFound)
 		return
@@ -107,6 +122,13 @@ func (h *DataSourceHandler) GetDataSourceByID(w http.ResponseWriter, r *http.Req
 
 // UpdateDataSource handles data source updates
 func (h *DataSourceHandler) UpdateDataSource(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
 	// Get user claims from JWT token
 	userClaims, ok := r.Context().Value("user").(*jwt.MapClaims)
 	if !ok || userClaims == nil {
@@ -152,7 +174,7 @@ func (h *DataSourceHandler) UpdateDataSource(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Update data source
-	dataSource, err := h.dataSourceService.UpdateDataSource(id, req.Name, req.Description)
+	dataSource, err := h.dataSourceService.UpdateDataSource(tenantID, id, req.Name, req.Description)
 	if err != nil {
 		if err == services.ErrDataSourceNotFound {
 			http.Error(w, "Data source not found", http.StatusNotFound)
@@ -173,6 +195,13 @@ func (h *DataSourceHandler) UpdateDataSource(w http.ResponseWriter, r *http.Requ
 
 // DeleteDataSource handles data source deletion
 func (h *DataSourceHandler) DeleteDataSource(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
 	// Get user claims from JWT token
 	userClaims, ok := r.Context().Value("user").(*jwt.MapClaims)
 	if !ok || userClaims == nil {
@@ -201,7 +230,7 @@ func (h *DataSourceHandler) DeleteDataSource(w http.ResponseWriter, r *http.Requ
 	id := vars["id"]
 
 	// Delete data source
-	err = h.dataSourceService.DeleteDataSource(id)
+	err = h.dataSourceService.DeleteDataSource(tenantID, id)
 	if err != nil {
 		if err == services.ErrDataSourceNotFound {
 			http.Error(w, "Data source not found", http.StatusNotFound)
@@ -215,10 +244,17 @@ func (h *DataSourceHandler) DeleteDataSource(w http.ResponseWriter, r *http.Requ
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// GetAllDataSources retrieves all data sources
+// GetAllDataSources retrieves all data sources of the tenant
 func (h *DataSourceHandler) GetAllDataSources(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
 	// Get all data sources
-	dataSources, err := h.dataSourceService.GetAllDataSources()
+	dataSources, err := h.dataSourceService.GetAllDataSources(tenantID)
 	if err != nil {
 		http.Error(w, "Error retrieving data sources: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -231,6 +267,13 @@ func (h *DataSourceHandler) GetAllDataSources(w http.ResponseWriter, r *http.Req
 
 // SearchDataSources handles searching for data sources
 func (h *DataSourceHandler) SearchDataSources(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
 	// Get query parameters
 	query := r.URL.Query().Get("q")
 	if query == "" {
@@ -243,7 +286,7 @@ func (h *DataSourceHandler) SearchDataSources(w http.ResponseWriter, r *http.Req
 	limit, offset := utils.GetPaginationParams(r)
 
 	// Search data sources
-	dataSources, total, err := h.dataSourceService.SearchDataSources(query, limit, offset)
+	dataSources, total, err := h.dataSourceService.SearchDataSources(tenantID, query, limit, offset)
 	if err != nil {
 		http.Error(w, "Error searching data sources: "+err.Error(), http.StatusInternalServerError)
 		return
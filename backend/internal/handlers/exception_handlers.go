@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"backend/internal/auth"
+	"backend/internal/models"
+	"backend/internal/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ExceptionHandlers handles HTTP requests related to working unmatched transactions
+type ExceptionHandlers struct {
+	exceptionService *services.ExceptionService
+}
+
+// NewExceptionHandlers creates a new instance of ExceptionHandlers
+func NewExceptionHandlers(exceptionService *services.ExceptionService) *ExceptionHandlers {
+	return &ExceptionHandlers{
+		exceptionService: exceptionService,
+	}
+}
+
+// RegisterRoutes registers the routes for exception operations
+func (h *ExceptionHandlers) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/exceptions", h.ListExceptions).Methods("GET")
+	router.HandleFunc("/exceptions/summary", h.GetSummary).Methods("GET")
+	router.HandleFunc("/exceptions/{id}", h.GetException).Methods("GET")
+	router.HandleFunc("/exceptions/{id}", h.UpdateException).Methods("PUT")
+	router.HandleFunc("/exceptions/{id}/comments", h.AddComment).Methods("POST")
+}
+
+// commentRequest is the body of a new exception comment
+type commentRequest struct {
+	Body string `json:"body"`
+}
+
+// ListExceptions lists the exceptions of the tenant. It filters by match_set_id, status,
+// reason_code, age (an age bucket such as 8-30) and assignee, which takes a user ID, "me" or
+// "unassigned".
+func (h *ExceptionHandlers) ListExceptions(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Filters
+	query := r.URL.Query()
+	filter := models.ExceptionFilter{
+		MatchSetID: query.Get("match_set_id"),
+		Status:     query.Get("status"),
+		ReasonCode: query.Get("reason_code"),
+	}
+	switch assignee := query.Get("assignee"); assignee {
+	case "unassigned":
+		filter.Unassigned = true
+	case "me":
+		filter.AssigneeID = userID
+	default:
+		filter.AssigneeID = assignee
+	}
+	if age := query.Get("age"); age != "" {
+		bucket, err := services.ParseExceptionAgeBucket(age)
+		if err != nil {
+			handleServiceError(w, err)
+			return
+		}
+		filter.Age = bucket
+	}
+
+	// Pagination
+	page := 1
+	if pageStr := query.Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	pageSize := 20
+	if pageSizeStr := query.Get("pageSize"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
+			pageSize = ps
+		}
+	}
+
+	// Get the exceptions
+	exceptions, total, err := h.exceptionService.ListExceptions(filter, pageSize, (page-1)*pageSize, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Create response
+	response := map[string]interface{}{
+		"exceptions": exceptions,
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSummary counts the exceptions of the tenant, or of the match set given by match_set_id
+func (h *ExceptionHandlers) GetSummary(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get the summary
+	summary, err := h.exceptionService.GetSummary(r.URL.Query().Get("match_set_id"), userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the summary
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// GetException retrieves an exception and the comments left on it
+func (h *ExceptionHandlers) GetException(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get exception ID from URL
+	vars := mux.Vars(r)
+	exceptionID := vars["id"]
+
+	// Get the exception
+	exception, comments, err := h.exceptionService.GetException(exceptionID, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Create response
+	response := map[string]interface{}{
+		"exception": exception,
+		"comments":  comments,
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdateException changes the status, reason code or assignee of an exception
+func (h *ExceptionHandlers) UpdateException(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get exception ID from URL
+	vars := mux.Vars(r)
+	exceptionID := vars["id"]
+
+	// Parse request body
+	var update models.ExceptionUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Update the exception
+	exception, err := h.exceptionService.UpdateException(exceptionID, update, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the updated exception
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(exception)
+}
+
+// AddComment leaves a comment on an exception
+func (h *ExceptionHandlers) AddComment(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get exception ID from URL
+	vars := mux.Vars(r)
+	exceptionID := vars["id"]
+
+	// Parse request body
+	var req commentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Add the comment
+	comment, err := h.exceptionService.AddComment(exceptionID, req.Body, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the new comment
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"backend/internal/auth"
+	"backend/internal/models"
+	"backend/internal/services"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ExportHandlers handles HTTP requests related to exports
+type ExportHandlers struct {
+	exportService *services.ExportService
+}
+
+// NewExportHandlers creates a new instance of ExportHandlers
+func NewExportHandlers(exportService *services.ExportService) *ExportHandlers {
+	return &ExportHandlers{
+		exportService: exportService,
+	}
+}
+
+// RegisterRoutes registers the routes for export operations
+func (h *ExportHandlers) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/exports/{kind}", h.Export).Methods("GET")
+	router.HandleFunc("/export-jobs/{id}", h.GetExportJob).Methods("GET")
+}
+
+// Export exports transactions, matched groups or unmatched transactions. kind is transactions,
+// matched or unmatched; format is csv (the default), xlsx, jsonl or parquet. The search filters
+// of GET /transactions select the transactions, and matchSetId the match set matched and
+// unmatched exports need. The file streams in the response unless the export is too large,
+// in which case it starts a background job and returns it with 202 Accepted.
+func (h *ExportHandlers) Export(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	filter, err := parseTransactionFilter(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	request := services.ExportRequest{
+		Kind:       mux.Vars(r)["kind"],
+		Format:     query.Get("format"),
+		MatchSetID: query.Get("matchSetId"),
+		Filter:     filter,
+	}
+	if request.Format == "" {
+		request.Format = models.ExportFormatCSV
+	}
+
+	// Check the export
+	plan, err := h.exportService.PlanExport(request, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	if plan.Background {
+		job, err := h.exportService.StartExport(plan)
+		if err != nil {
+			handleServiceError(w, err)
+			return
+		}
+
+		// Return the started job
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job)
+		return
+	}
+
+	// Stream the file. Once rows are sent a failure can only cut the response short.
+	filename := services.ExportFilename(request.Kind, request.Format, time.Now())
+	w.Header().Set("Content-Type", services.ExportContentType(request.Format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if _, err := h.exportService.WriteExport(w, plan); err != nil {
+		log.Printf("Export of %s for tenant %s failed: %v", request.Kind, tenantID, err)
+	}
+}
+
+// GetExportJob returns a background export job, with its download link once it completed
+func (h *ExportHandlers) GetExportJob(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get job ID from URL
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+
+	// Get the job
+	job, err := h.exportService.GetExportJob(jobID, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the job
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
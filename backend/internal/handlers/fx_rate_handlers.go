@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"backend/internal/auth"
+	"backend/internal/models"
+	"backend/internal/services"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// FXRateHandlers handles HTTP requests related to exchange rates
+type FXRateHandlers struct {
+	fxRateService *services.FXRateService
+}
+
+// NewFXRateHandlers creates a new instance of FXRateHandlers
+func NewFXRateHandlers(fxRateService *services.FXRateService) *FXRateHandlers {
+	return &FXRateHandlers{
+		fxRateService: fxRateService,
+	}
+}
+
+// RegisterRoutes registers the routes for exchange rate operations
+func (h *FXRateHandlers) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/fx-rates", h.SaveRates).Methods("POST")
+	router.HandleFunc("/fx-rates", h.GetRates).Methods("GET")
+}
+
+// fxRateRequest is a single rate in a save request. Dates use the YYYY-MM-DD format.
+type fxRateRequest struct {
+	BaseCurrency  string  `json:"base_currency"`
+	QuoteCurrency string  `json:"quote_currency"`
+	Rate          float64 `json:"rate"`
+	RateDate      string  `json:"rate_date"`
+}
+
+// SaveRates stores a batch of exchange rates for the tenant
+func (h *FXRateHandlers) SaveRates(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse request body
+	var req struct {
+		Rates []fxRateRequest `json:"rates"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Rates) == 0 {
+		http.Error(w, "At least one rate is required", http.StatusBadRequest)
+		return
+	}
+
+	rates := make([]models.FXRate, len(req.Rates))
+	for i, rate := range req.Rates {
+		rateDate, err := time.Parse("2006-01-02", rate.RateDate)
+		if err != nil {
+			http.Error(w, "Invalid rate_date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		rates[i] = models.FXRate{
+			BaseCurrency:  rate.BaseCurrency,
+			QuoteCurrency: rate.QuoteCurrency,
+			Rate:          rate.Rate,
+			RateDate:      rateDate,
+		}
+	}
+
+	// Save the rates
+	saved, err := h.fxRateService.SaveRates(rates, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the saved rates
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(saved)
+}
+
+// GetRates retrieves the exchange rates visible to the tenant between the from and to dates
+func (h *FXRateHandlers) GetRates(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Default to the last 30 days
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -30)
+	if value := r.URL.Query().Get("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			http.Error(w, "Invalid from date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			http.Error(w, "Invalid to date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		to = parsed
+	}
+
+	// Get the rates
+	rates, err := h.fxRateService.GetRates(from, to, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the rates
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rates)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"backend/internal/auth"
 	"backend/internal/repository"
 	"backend/internal/utils"
 	"net/http"
@@ -33,12 +34,13 @@ func (h *ImportHandlers) RegisterRoutes(r *mux.Router) {
 func (h *ImportHandlers) GetImportsByDataSource(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	dataSourceID := vars["dataSourceId"]
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 
 	// Parse pagination parameters
 	limit, offset := utils.GetPaginationParams(r)
 
 	// Get imports from repository
-	imports, total, err := h.importRepo.GetImportsByDataSource(dataSourceID, limit, offset)
+	imports, total, err := h.importRepo.GetImportsByDataSource(tenantID, dataSourceID, limit, offset)
 	if err != nil {
 		utils.WriteError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -57,8 +59,9 @@ func (h *ImportHandlers) GetImportsByDataSource(w http.ResponseWriter, r *http.R
 func (h *ImportHandlers) GetImportByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	importID := vars["importId"]
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 
-	importRecord, err := h.importRepo.GetImportByID(importID)
+	importRecord, err := h.importRepo.GetImportByID(tenantID, importID)
 	if err != nil {
 		if err == repository.ErrImportNotFound {
 			utils.WriteError(w, "Import not found", http.StatusNotFound)
@@ -75,8 +78,9 @@ func (h *ImportHandlers) GetImportByID(w http.ResponseWriter, r *http.Request) {
 func (h *ImportHandlers) DeleteImport(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	importID := vars["importId"]
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 
-	err := h.importRepo.DeleteImport(importID)
+	err := h.importRepo.DeleteImport(tenantID, importID)
 	if err != nil {
 		if err == repository.ErrImportNotFound {
 			utils.WriteError(w, "Import not found", http.StatusNotFound)
@@ -93,12 +97,13 @@ func (h *ImportHandlers) DeleteImport(w http.ResponseWriter, r *http.Request) {
 func (h *ImportHandlers) GetRawTransactionsByImport(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	importID := vars["importId"]
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 
 	// Parse pagination parameters
 	limit, offset := utils.GetPaginationParams(r)
 
 	// Get raw transactions from repository
-	transactions, total, err := h.importRepo.GetRawTransactionsByImport(importID, limit, offset)
+	transactions, total, err := h.importRepo.GetRawTransactionsByImport(tenantID, importID, limit, offset)
 	if err != nil {
 		utils.WriteError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -117,8 +122,9 @@ func (h *ImportHandlers) GetRawTransactionsByImport(w http.ResponseWriter, r *ht
 func (h *ImportHandlers) GetRawTransactionByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	rawTransactionID := vars["rawTransactionId"]
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 
-	transaction, err := h.importRepo.GetRawTransactionByID(rawTransactionID)
+	transaction, err := h.importRepo.GetRawTransactionByID(tenantID, rawTransactionID)
 	if err != nil {
 		utils.WriteError(w, err.Error(), http.StatusNotFound)
 		return
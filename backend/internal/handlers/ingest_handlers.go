@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"backend/internal/auth"
+	"backend/internal/models"
+	"backend/internal/services"
+	"backend/internal/utils"
+	"encoding/json"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+)
+
+// IngestHandler handles system-to-system transaction ingest
+type IngestHandler struct {
+	ingestService *services.IngestService
+	roleService   *services.RoleService
+}
+
+// NewIngestHandler creates a new ingest handler
+func NewIngestHandler(
+	ingestService *services.IngestService,
+	roleService *services.RoleService,
+) *IngestHandler {
+	return &IngestHandler{
+		ingestService: ingestService,
+		roleService:   roleService,
+	}
+}
+
+// IngestTransactions accepts a batch of transactions for a data source.
+// The idempotency key may be sent in the body or in the Idempotency-Key header.
+// The first call for a key returns 201; repeats return 200 with the original result.
+func (h *IngestHandler) IngestTransactions(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get user claims from JWT token
+	userClaims, ok := r.Context().Value("user").(*jwt.MapClaims)
+	if !ok || userClaims == nil {
+		http.Error(w, "Unauthorized: invalid or missing authentication", http.StatusUnauthorized)
+		return
+	}
+
+	// Extract user ID from claims
+	userIDValue, ok := (*userClaims)["user_id"]
+	if !ok || userIDValue == nil {
+		http.Error(w, "Unauthorized: user ID not found in token", http.StatusUnauthorized)
+		return
+	}
+
+	userID := userIDValue.(string)
+
+	// Check if user can load transactions
+	hasRole, err := h.roleService.UserHasAnyRole(userID, []models.Role{models.RoleAdmin, models.RolePreparer})
+	if err != nil || !hasRole {
+		http.Error(w, "Unauthorized: requires admin or preparer role", http.StatusUnauthorized)
+		return
+	}
+
+	dataSourceID := mux.Vars(r)["id"]
+
+	var batch services.IngestBatch
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if batch.IdempotencyKey == "" {
+		batch.IdempotencyKey = r.Header.Get("Idempotency-Key")
+	}
+
+	result, err := h.ingestService.IngestTransactions(tenantID, dataSourceID, userID, &batch)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	status := http.StatusCreated
+	if result.Replayed {
+		status = http.StatusOK
+	}
+
+	utils.WriteJSON(w, result, status)
+}
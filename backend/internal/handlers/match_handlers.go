@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"backend/internal/auth"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// MatchHandler handles match-related API endpoints
+type MatchHandler struct {
+	matchService *services.MatchService
+}
+
+// NewMatchHandler creates a new match handler
+func NewMatchHandler(matchService *services.MatchService) *MatchHandler {
+	return &MatchHandler{
+		matchService: matchService,
+	}
+}
+
+// RegisterRoutes registers the routes for match operations
+func (h *MatchHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/matches", h.CreateManualMatch).Methods("POST")
+	router.HandleFunc("/matches/pending", h.GetPendingMatches).Methods("GET")
+	router.HandleFunc("/matches/approve", h.ApproveMatches).Methods("POST")
+	router.HandleFunc("/matches/reject", h.RejectMatches).Methods("POST")
+	router.HandleFunc("/matches/{id}", h.GetMatchDetails).Methods("GET")
+	router.HandleFunc("/matches/{id}", h.Unmatch).Methods("DELETE")
+	router.HandleFunc("/matches/{id}/transactions/{transactionId}", h.AddTransactionToMatch).Methods("POST")
+	router.HandleFunc("/matches/{id}/transactions/{transactionId}", h.RemoveTransactionFromMatch).Methods("DELETE")
+}
+
+// CreateManualMatch creates a manual match between transactions
+func (h *MatchHandler) CreateManualMatch(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse request body
+	var req struct {
+		MatchSetID     string                      `json:"match_set_id"`
+		TransactionIDs []string                    `json:"transaction_ids"`
+		Adjustment     *services.AdjustmentRequest `json:"adjustment"` // Closes the difference between the sides
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.MatchSetID == "" || len(req.TransactionIDs) < 2 {
+		http.Error(w, "A match set ID and at least two transaction IDs are required", http.StatusBadRequest)
+		return
+	}
+
+	// Create the match
+	var match *models.TransactionMatch
+	var err error
+	if req.Adjustment != nil {
+		match, err = h.matchService.CreateAdjustedMatch(req.MatchSetID, req.TransactionIDs, *req.Adjustment, userID, tenantID)
+	} else {
+		match, err = h.matchService.CreateManualMatch(req.MatchSetID, req.TransactionIDs, userID, tenantID)
+	}
+	if err != nil {
+		handleMatchError(w, err)
+		return
+	}
+
+	// Return the match
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(match)
+}
+
+// Unmatch dissolves a match group
+func (h *MatchHandler) Unmatch(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match ID from URL
+	matchID := mux.Vars(r)["id"]
+	if matchID == "" {
+		http.Error(w, "Match ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Unmatch the group
+	if err := h.matchService.Unmatch(matchID, userID, tenantID); err != nil {
+		handleMatchError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddTransactionToMatch adds a transaction to a match group
+func (h *MatchHandler) AddTransactionToMatch(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match and transaction IDs from URL
+	vars := mux.Vars(r)
+	matchID := vars["id"]
+	transactionID := vars["transactionId"]
+	if matchID == "" || transactionID == "" {
+		http.Error(w, "Match ID and transaction ID are required", http.StatusBadRequest)
+		return
+	}
+
+	// Add the transaction
+	match, err := h.matchService.AddTransactionToMatch(matchID, transactionID, userID, tenantID)
+	if err != nil {
+		handleMatchError(w, err)
+		return
+	}
+
+	// Return the match
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(match)
+}
+
+// RemoveTransactionFromMatch removes a transaction from a match group
+func (h *MatchHandler) RemoveTransactionFromMatch(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match and transaction IDs from URL
+	vars := mux.Vars(r)
+	matchID := vars["id"]
+	transactionID := vars["transactionId"]
+	if matchID == "" || transactionID == "" {
+		http.Error(w, "Match ID and transaction ID are required", http.StatusBadRequest)
+		return
+	}
+
+	// Remove the transaction
+	match, err := h.matchService.RemoveTransactionFromMatch(matchID, transactionID, userID, tenantID)
+	if err != nil {
+		handleMatchError(w, err)
+		return
+	}
+
+	// Return the match
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(match)
+}
+
+// GetPendingMatches retrieves the tenant's matches waiting for approval
+func (h *MatchHandler) GetPendingMatches(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Pagination
+	page := 1
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	pageSize := 20
+	if pageSizeStr := r.URL.Query().Get("pageSize"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
+			pageSize = ps
+		}
+	}
+
+	// Get the pending matches
+	matches, total, err := h.matchService.GetPendingMatches(pageSize, (page-1)*pageSize, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Create response
+	response := map[string]interface{}{
+		"matches":  matches,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetMatchDetails retrieves a match and the transactions in its group
+func (h *MatchHandler) GetMatchDetails(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match ID from URL
+	matchID := mux.Vars(r)["id"]
+	if matchID == "" {
+		http.Error(w, "Match ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get the match
+	match, transactions, err := h.matchService.GetMatchDetails(matchID, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Create response
+	response := map[string]interface{}{
+		"match":        match,
+		"transactions": transactions,
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ApproveMatches approves a batch of pending matches
+func (h *MatchHandler) ApproveMatches(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse request body
+	var req struct {
+		MatchIDs []string `json:"match_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Approve the matches
+	results, err := h.matchService.ApproveMatches(req.MatchIDs, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the outcome for each match
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"results": results})
+}
+
+// RejectMatches rejects a batch of pending matches. Each match may carry its own reason;
+// the top-level reason applies to those that do not.
+func (h *MatchHandler) RejectMatches(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse request body
+	var req struct {
+		Matches []services.MatchReview `json:"matches"`
+		Reason  string                 `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	for i := range req.Matches {
+		if req.Matches[i].Reason == "" {
+			req.Matches[i].Reason = req.Reason
+		}
+	}
+
+	// Reject the matches
+	results, err := h.matchService.RejectMatches(req.Matches, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the outcome for each match
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"results": results})
+}
+
+// handleMatchError reports a group changed by a concurrent request as a conflict
+// and everything else as handleServiceError does
+func handleMatchError(w http.ResponseWriter, err error) {
+	if err == repository.ErrMatchGroupChanged {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	handleServiceError(w, err)
+}
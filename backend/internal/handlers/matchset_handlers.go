@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"backend/internal/auth"
+	"backend/internal/repository"
 	"backend/internal/services"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -30,7 +34,13 @@ func (h *MatchSetHandlers) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/match-sets/{id}/data-sources", h.GetMatchSetDataSources).Methods("GET")
 	router.HandleFunc("/match-sets/{id}/data-sources/{dataSourceId}", h.AddDataSourceToMatchSet).Methods("POST")
 	router.HandleFunc("/match-sets/{id}/data-sources/{dataSourceId}", h.RemoveDataSourceFromMatchSet).Methods("DELETE")
+	router.HandleFunc("/match-sets/{id}/rules", h.GetMatchSetRules).Methods("GET")
+	router.HandleFunc("/match-sets/{id}/rules/{ruleId}", h.SetMatchSetRule).Methods("PUT")
+	router.HandleFunc("/match-sets/{id}/rules/{ruleId}", h.RemoveRuleFromMatchSet).Methods("DELETE")
 	router.HandleFunc("/match-sets/{id}/run", h.RunMatchSet).Methods("POST")
+	router.HandleFunc("/match-sets/{id}/run/cancel", h.CancelMatchSetRun).Methods("POST")
+	router.HandleFunc("/match-sets/{id}/run/resume", h.ResumeMatchSetRun).Methods("POST")
+	router.HandleFunc("/match-sets/{id}/simulate", h.SimulateMatchSet).Methods("POST")
 	router.HandleFunc("/match-sets/{id}/status", h.GetMatchSetStatus).Methods("GET")
 }
 
@@ -98,18 +108,295 @@ func (h *MatchSetHandlers) RemoveDataSourceFromMatchSet(w http.ResponseWriter, r
 	json.NewEncoder(w).Encode(map[string]string{"message": "Not implemented yet"})
 }
 
-// RunMatchSet starts the matching process for a match set
+// GetMatchSetRules retrieves the rules of a match set in the order they run
+func (h *MatchSetHandlers) GetMatchSetRules(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set ID from URL
+	matchSetID := mux.Vars(r)["id"]
+	if matchSetID == "" {
+		http.Error(w, "Match set ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get the rules
+	rules, err := h.matchSetService.GetMatchSetRules(matchSetID, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the rules
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+// SetMatchSetRule adds a rule to a match set or changes its priority
+func (h *MatchSetHandlers) SetMatchSetRule(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set and rule IDs from URL
+	vars := mux.Vars(r)
+	matchSetID := vars["id"]
+	ruleID := vars["ruleId"]
+	if matchSetID == "" || ruleID == "" {
+		http.Error(w, "Match set ID and rule ID are required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse request body
+	var req struct {
+		Priority int `json:"priority"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Assign the rule
+	if err := h.matchSetService.SetMatchSetRule(matchSetID, ruleID, req.Priority, userID, tenantID); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveRuleFromMatchSet removes a rule from a match set
+func (h *MatchSetHandlers) RemoveRuleFromMatchSet(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set and rule IDs from URL
+	vars := mux.Vars(r)
+	matchSetID := vars["id"]
+	ruleID := vars["ruleId"]
+	if matchSetID == "" || ruleID == "" {
+		http.Error(w, "Match set ID and rule ID are required", http.StatusBadRequest)
+		return
+	}
+
+	// Remove the rule
+	if err := h.matchSetService.RemoveRuleFromMatchSet(matchSetID, ruleID, userID, tenantID); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RunMatchSet starts the matching process for a match set in the background. The mode query
+// parameter selects a full or an incremental run. The run's progress is reported by the status
+// endpoint.
 func (h *MatchSetHandlers) RunMatchSet(w http.ResponseWriter, r *http.Request) {
-	// Basic handler implementation - uncomment and complete later
+	h.startRun(w, r, services.RunOptions{Mode: r.URL.Query().Get("mode")})
+}
+
+// ResumeMatchSetRun resumes the cancelled run of a match set from its checkpoint in the background
+func (h *MatchSetHandlers) ResumeMatchSetRun(w http.ResponseWriter, r *http.Request) {
+	h.startRun(w, r, services.RunOptions{Resume: true})
+}
+
+// startRun starts a run of a match set and returns its progress as it starts
+func (h *MatchSetHandlers) startRun(w http.ResponseWriter, r *http.Request, options services.RunOptions) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set ID from URL
+	matchSetID := mux.Vars(r)["id"]
+	if matchSetID == "" {
+		http.Error(w, "Match set ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Start the run
+	progress, err := h.matchSetService.StartMatchSetRun(matchSetID, options, userID, tenantID)
+	if err != nil {
+		handleRunError(w, err)
+		return
+	}
+
+	// Return the run progress
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusNotImplemented)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Not implemented yet"})
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(progress)
+}
+
+// CancelMatchSetRun asks the running run of a match set to stop
+func (h *MatchSetHandlers) CancelMatchSetRun(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set ID from URL
+	matchSetID := mux.Vars(r)["id"]
+	if matchSetID == "" {
+		http.Error(w, "Match set ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Request the cancellation
+	progress, err := h.matchSetService.CancelMatchSetRun(matchSetID, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the run progress; the run stops shortly unless its worker was already gone
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(progress)
+}
+
+// handleRunError maps run errors to responses. A match set already running is a conflict that
+// names the running run.
+func handleRunError(w http.ResponseWriter, err error) {
+	var inProgress *repository.RunInProgressError
+	if errors.As(err, &inProgress) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":            inProgress.Error(),
+			"run_id":           inProgress.RunID,
+			"lease_expires_at": inProgress.LeaseExpiresAt,
+		})
+		return
+	}
+	handleServiceError(w, err)
+}
+
+// SimulateMatchSet runs a match set as a dry run and returns what it would match
+func (h *MatchSetHandlers) SimulateMatchSet(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set ID from URL
+	matchSetID := mux.Vars(r)["id"]
+	if matchSetID == "" {
+		http.Error(w, "Match set ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse request body; an empty body simulates the match set's own rules
+	var req struct {
+		RuleID     string `json:"rule_id"`
+		SampleSize int    `json:"sample_size"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Simulate the run
+	simulation, err := h.matchSetService.SimulateMatchSet(matchSetID, req.RuleID, req.SampleSize, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the simulation
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(simulation)
 }
 
 // GetMatchSetStatus retrieves the status of a match set processing
 func (h *MatchSetHandlers) GetMatchSetStatus(w http.ResponseWriter, r *http.Request) {
-	// Basic handler implementation - uncomment and complete later
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set ID from URL
+	matchSetID := mux.Vars(r)["id"]
+	if matchSetID == "" {
+		http.Error(w, "Match set ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get the status
+	status, err := h.matchSetService.GetMatchSetStatus(matchSetID, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the status
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusNotImplemented)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Not implemented yet"})
+	json.NewEncoder(w).Encode(status)
 }
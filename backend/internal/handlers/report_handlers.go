@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"backend/internal/auth"
+	"backend/internal/services"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ReportHandlers handles HTTP requests related to reconciliation reports
+type ReportHandlers struct {
+	reportService *services.ReportService
+}
+
+// NewReportHandlers creates a new instance of ReportHandlers
+func NewReportHandlers(reportService *services.ReportService) *ReportHandlers {
+	return &ReportHandlers{
+		reportService: reportService,
+	}
+}
+
+// RegisterRoutes registers the routes for report operations
+func (h *ReportHandlers) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/match-sets/{id}/report", h.GetReport).Methods("GET")
+	router.HandleFunc("/match-sets/{id}/reports", h.GenerateReport).Methods("POST")
+	router.HandleFunc("/match-sets/{id}/reports", h.GetReports).Methods("GET")
+	router.HandleFunc("/reports/{id}/download", h.DownloadReport).Methods("GET")
+}
+
+// generateReportRequest is the body of a report generation request. Dates are YYYY-MM-DD.
+type generateReportRequest struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Format string `json:"format"`
+}
+
+// GetReport returns the reconciliation statement of a match set for a period without storing it
+func (h *ReportHandlers) GetReport(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set ID from URL
+	vars := mux.Vars(r)
+	matchSetID := vars["id"]
+
+	from, err := time.Parse("2006-01-02", r.URL.Query().Get("from"))
+	if err != nil {
+		http.Error(w, "Invalid from date, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	to, err := time.Parse("2006-01-02", r.URL.Query().Get("to"))
+	if err != nil {
+		http.Error(w, "Invalid to date, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	// Build the report
+	report, err := h.reportService.BuildReport(matchSetID, from, to, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the report
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GenerateReport renders the reconciliation statement of a match set and stores it
+func (h *ReportHandlers) GenerateReport(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set ID from URL
+	vars := mux.Vars(r)
+	matchSetID := vars["id"]
+
+	// Parse request body
+	var req generateReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	from, err := time.Parse("2006-01-02", req.From)
+	if err != nil {
+		http.Error(w, "Invalid from date, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	to, err := time.Parse("2006-01-02", req.To)
+	if err != nil {
+		http.Error(w, "Invalid to date, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	// Generate and store the report
+	report, err := h.reportService.GenerateReport(matchSetID, from, to, req.Format, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the stored report
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetReports lists the reports stored for a match set
+func (h *ReportHandlers) GetReports(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set ID from URL
+	vars := mux.Vars(r)
+	matchSetID := vars["id"]
+
+	// Get the reports
+	reports, err := h.reportService.GetReports(matchSetID, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the reports
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
+
+// DownloadReport returns the file of a stored report
+func (h *ReportHandlers) DownloadReport(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get report ID from URL
+	vars := mux.Vars(r)
+	reportID := vars["id"]
+
+	// Open the report
+	report, file, err := h.reportService.OpenReport(reportID, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+	defer file.Close()
+
+	// Return the file
+	filename := fmt.Sprintf("reconciliation_%s_%s.%s", report.PeriodStart.Format("20060102"), report.PeriodEnd.Format("20060102"), report.Format)
+	w.Header().Set("Content-Type", services.ReportContentType(report.Format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Length", strconv.FormatInt(report.FileSize, 10))
+	io.Copy(w, file)
+}
@@ -0,0 +1,475 @@
+package handlers
+
+import (
+	"backend/internal/auth"
+	"backend/internal/models"
+	"backend/internal/services"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// RuleHandlers handles HTTP requests related to match rules
+type RuleHandlers struct {
+	ruleService *services.RuleService
+}
+
+// NewRuleHandlers creates a new instance of RuleHandlers
+func NewRuleHandlers(ruleService *services.RuleService) *RuleHandlers {
+	return &RuleHandlers{
+		ruleService: ruleService,
+	}
+}
+
+// RegisterRoutes registers the routes for match rule operations
+func (h *RuleHandlers) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/rules", h.GetRules).Methods("GET")
+	router.HandleFunc("/rules", h.CreateRule).Methods("POST")
+	router.HandleFunc("/rules/{id}", h.GetRule).Methods("GET")
+	router.HandleFunc("/rules/{id}", h.UpdateRule).Methods("PUT")
+	router.HandleFunc("/rules/{id}", h.DeleteRule).Methods("DELETE")
+	router.HandleFunc("/rules/{id}/active", h.SetRuleActive).Methods("PUT")
+	router.HandleFunc("/rules/{id}/group-matching", h.UpdateGroupMatching).Methods("PUT")
+	router.HandleFunc("/rules/{id}/amount-tolerance", h.UpdateAmountTolerance).Methods("PUT")
+	router.HandleFunc("/rules/{id}/reference-matching", h.UpdateReferenceMatching).Methods("PUT")
+	router.HandleFunc("/rules/{id}/conditions", h.UpdateConditions).Methods("PUT")
+}
+
+// ruleRequest is the body of a create or update request
+type ruleRequest struct {
+	Name             string `json:"name"`
+	Description      string `json:"description"`
+	MatchByAmount    bool   `json:"match_by_amount"`
+	MatchByDate      bool   `json:"match_by_date"`
+	MatchByReference bool   `json:"match_by_reference"`
+	DateTolerance    int    `json:"date_tolerance"`
+	Active           *bool  `json:"active"`
+}
+
+// GetRules retrieves the match rules of the tenant, only the active ones with ?active=true
+func (h *RuleHandlers) GetRules(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get the rules
+	var rules []models.MatchRule
+	var err error
+	if r.URL.Query().Get("active") == "true" {
+		rules, err = h.ruleService.GetActiveRules(tenantID, userID)
+	} else {
+		rules, err = h.ruleService.GetAllRules(tenantID, userID)
+	}
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	if rules == nil {
+		rules = []models.MatchRule{}
+	}
+
+	// Return the rules
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+// CreateRule creates a match rule in the tenant
+func (h *RuleHandlers) CreateRule(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse request body
+	var req ruleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+
+	// Create the rule
+	rule, err := h.ruleService.CreateRule(
+		tenantID, req.Name, req.Description,
+		req.MatchByAmount, req.MatchByDate, req.MatchByReference,
+		req.DateTolerance,
+		userID,
+	)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the created rule
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// GetRule retrieves a match rule of the tenant
+func (h *RuleHandlers) GetRule(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get rule ID from URL
+	vars := mux.Vars(r)
+	ruleID := vars["id"]
+
+	// Get the rule
+	rule, err := h.ruleService.GetRuleByID(tenantID, userID, ruleID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the rule
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// UpdateRule updates the name, description and match flags of a match rule
+func (h *RuleHandlers) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get rule ID from URL
+	vars := mux.Vars(r)
+	ruleID := vars["id"]
+
+	// Parse request body
+	var req ruleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	// Update the rule
+	rule, err := h.ruleService.UpdateRule(
+		tenantID, userID, ruleID, req.Name, req.Description,
+		req.MatchByAmount, req.MatchByDate, req.MatchByReference, active,
+		req.DateTolerance,
+	)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the updated rule
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// DeleteRule deletes a match rule of the tenant
+func (h *RuleHandlers) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get rule ID from URL
+	vars := mux.Vars(r)
+	ruleID := vars["id"]
+
+	// Delete the rule
+	if err := h.ruleService.DeleteRule(tenantID, userID, ruleID); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetRuleActive activates or deactivates a match rule
+func (h *RuleHandlers) SetRuleActive(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get rule ID from URL
+	vars := mux.Vars(r)
+	ruleID := vars["id"]
+
+	// Parse request body
+	var req struct {
+		Active bool `json:"active"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Update the rule
+	rule, err := h.ruleService.ToggleRuleActive(tenantID, userID, ruleID, req.Active)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the updated rule
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// UpdateGroupMatching sets the cardinality of a match rule and the caps of its group search
+func (h *RuleHandlers) UpdateGroupMatching(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get rule ID from URL
+	vars := mux.Vars(r)
+	ruleID := vars["id"]
+
+	// Parse request body
+	var req struct {
+		MatchCardinality    string  `json:"match_cardinality"`
+		AmountTolerance     float64 `json:"amount_tolerance"`
+		MaxGroupSize        int     `json:"max_group_size"`
+		MaxCandidates       int     `json:"max_candidates"`
+		MaxSearchIterations int     `json:"max_search_iterations"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Update the rule
+	rule, err := h.ruleService.UpdateGroupMatching(
+		tenantID, userID, ruleID, req.MatchCardinality,
+		req.AmountTolerance,
+		req.MaxGroupSize, req.MaxCandidates, req.MaxSearchIterations,
+	)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the updated rule
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// UpdateAmountTolerance sets the amount tolerances of a match rule and how it compares
+// amounts in different currencies
+func (h *RuleHandlers) UpdateAmountTolerance(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get rule ID from URL
+	vars := mux.Vars(r)
+	ruleID := vars["id"]
+
+	// Parse request body
+	var req struct {
+		AmountTolerance         float64 `json:"amount_tolerance"`
+		AmountTolerancePct      float64 `json:"amount_tolerance_pct"`
+		CompareAcrossCurrencies bool    `json:"compare_across_currencies"`
+		FXTolerancePct          float64 `json:"fx_tolerance_pct"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Update the rule
+	rule, err := h.ruleService.UpdateAmountTolerance(
+		tenantID, userID, ruleID,
+		req.AmountTolerance, req.AmountTolerancePct,
+		req.CompareAcrossCurrencies,
+		req.FXTolerancePct,
+	)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the updated rule
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// UpdateReferenceMatching sets how a match rule compares references
+func (h *RuleHandlers) UpdateReferenceMatching(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get rule ID from URL
+	vars := mux.Vars(r)
+	ruleID := vars["id"]
+
+	// Parse request body
+	var req struct {
+		ReferenceMode       string  `json:"reference_mode"`
+		ReferencePattern    string  `json:"reference_pattern"`
+		SimilarityAlgorithm string  `json:"similarity_algorithm"`
+		SimilarityThreshold float64 `json:"similarity_threshold"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Update the rule
+	rule, err := h.ruleService.UpdateReferenceMatching(
+		tenantID, userID, ruleID,
+		req.ReferenceMode, req.ReferencePattern, req.SimilarityAlgorithm,
+		req.SimilarityThreshold,
+	)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the updated rule
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// UpdateConditions replaces the conditions of a match rule
+func (h *RuleHandlers) UpdateConditions(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get rule ID from URL
+	vars := mux.Vars(r)
+	ruleID := vars["id"]
+
+	// Parse request body
+	var req struct {
+		Conditions []models.RuleCondition `json:"conditions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Update the rule
+	rule, err := h.ruleService.UpdateConditions(tenantID, userID, ruleID, req.Conditions)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the updated rule
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"backend/internal/auth"
+	"backend/internal/models"
+	"backend/internal/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ScheduleHandlers handles HTTP requests related to match set schedules
+type ScheduleHandlers struct {
+	scheduleService *services.ScheduleService
+}
+
+// NewScheduleHandlers creates a new instance of ScheduleHandlers
+func NewScheduleHandlers(scheduleService *services.ScheduleService) *ScheduleHandlers {
+	return &ScheduleHandlers{
+		scheduleService: scheduleService,
+	}
+}
+
+// RegisterRoutes registers the routes for match set schedule operations
+func (h *ScheduleHandlers) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/match-sets/{id}/schedules", h.GetSchedules).Methods("GET")
+	router.HandleFunc("/match-sets/{id}/schedules", h.CreateSchedule).Methods("POST")
+	router.HandleFunc("/match-sets/{id}/schedules/{scheduleId}", h.UpdateSchedule).Methods("PUT")
+	router.HandleFunc("/match-sets/{id}/schedules/{scheduleId}", h.DeleteSchedule).Methods("DELETE")
+	router.HandleFunc("/match-sets/{id}/schedules/{scheduleId}/runs", h.GetScheduledRuns).Methods("GET")
+}
+
+// scheduleRequest is the body of a create or update request. The timezone defaults to UTC, the
+// mode to incremental and a schedule is active unless stated otherwise.
+type scheduleRequest struct {
+	CronExpression string `json:"cron_expression"`
+	Timezone       string `json:"timezone"`
+	Mode           string `json:"mode"`
+	Active         *bool  `json:"active"`
+}
+
+// schedule converts the request to a schedule, applying the defaults
+func (req *scheduleRequest) schedule() *models.MatchSetSchedule {
+	schedule := &models.MatchSetSchedule{
+		CronExpression: req.CronExpression,
+		Timezone:       req.Timezone,
+		Mode:           req.Mode,
+		Active:         true,
+	}
+	if req.Active != nil {
+		schedule.Active = *req.Active
+	}
+	return schedule
+}
+
+// GetSchedules retrieves the schedules of a match set
+func (h *ScheduleHandlers) GetSchedules(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set ID from URL
+	vars := mux.Vars(r)
+	matchSetID := vars["id"]
+
+	// Get the schedules
+	schedules, err := h.scheduleService.GetSchedules(matchSetID, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	if schedules == nil {
+		schedules = []models.MatchSetSchedule{}
+	}
+
+	// Return the schedules
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedules)
+}
+
+// CreateSchedule adds a schedule to a match set
+func (h *ScheduleHandlers) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set ID from URL
+	vars := mux.Vars(r)
+	matchSetID := vars["id"]
+
+	// Parse request body
+	var req scheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Create the schedule
+	schedule, err := h.scheduleService.CreateSchedule(matchSetID, req.schedule(), userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the created schedule
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// UpdateSchedule replaces the timing and mode of a schedule
+func (h *ScheduleHandlers) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set and schedule IDs from URL
+	vars := mux.Vars(r)
+	matchSetID := vars["id"]
+	scheduleID := vars["scheduleId"]
+
+	// Parse request body
+	var req scheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Update the schedule
+	schedule, err := h.scheduleService.UpdateSchedule(matchSetID, scheduleID, req.schedule(), userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the updated schedule
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// DeleteSchedule removes a schedule from a match set
+func (h *ScheduleHandlers) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set and schedule IDs from URL
+	vars := mux.Vars(r)
+	matchSetID := vars["id"]
+	scheduleID := vars["scheduleId"]
+
+	// Delete the schedule
+	if err := h.scheduleService.DeleteSchedule(matchSetID, scheduleID, userID, tenantID); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetScheduledRuns retrieves the latest runs of a schedule and their outcomes
+func (h *ScheduleHandlers) GetScheduledRuns(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get match set and schedule IDs from URL
+	vars := mux.Vars(r)
+	matchSetID := vars["id"]
+	scheduleID := vars["scheduleId"]
+
+	// Parse the number of runs to return
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	// Get the runs
+	runs, err := h.scheduleService.GetScheduledRuns(matchSetID, scheduleID, limit, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	if runs == nil {
+		runs = []models.ScheduledRun{}
+	}
+
+	// Return the runs
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runs)
+}
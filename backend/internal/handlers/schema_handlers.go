@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"backend/internal/auth"
 	"backend/internal/models"
 	"backend/internal/services"
 	"encoding/json"
@@ -48,14 +49,14 @@ func (h *SchemaHandlers) RegisterRoutes(router *mux.Router) {
 // CreateSchema handles the creation of a new schema
 func (h *SchemaHandlers) CreateSchema(w http.ResponseWriter, r *http.Request) {
 	// Parse tenant ID from context (would be set by authentication middleware)
-	tenantID := GetTenantIDFromContext(r.Context())
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 	if tenantID == "" {
 		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Parse user ID from context (would be set by authentication middleware)
-	userID := GetUserIDFromContext(r.Context())
+	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
@@ -87,14 +88,14 @@ func (h *SchemaHandlers) CreateSchema(w http.ResponseWriter, r *http.Request) {
 // GetSchemas handles retrieving all schemas for a tenant
 func (h *SchemaHandlers) GetSchemas(w http.ResponseWriter, r *http.Request) {
 	// Parse tenant ID from context (would be set by authentication middleware)
-	tenantID := GetTenantIDFromContext(r.Context())
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 	if tenantID == "" {
 		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Parse user ID from context (would be set by authentication middleware)
-	userID := GetUserIDFromContext(r.Context())
+	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
@@ -115,14 +116,14 @@ func (h *SchemaHandlers) GetSchemas(w http.ResponseWriter, r *http.Request) {
 // GetSchema handles retrieving a single schema
 func (h *SchemaHandlers) GetSchema(w http.ResponseWriter, r *http.Request) {
 	// Parse tenant ID from context (would be set by authentication middleware)
-	tenantID := GetTenantIDFromContext(r.Context())
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 	if tenantID == "" {
 		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Parse user ID from context (would be set by authentication middleware)
-	userID := GetUserIDFromContext(r.Context())
+	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
@@ -151,14 +152,14 @@ func (h *SchemaHandlers) GetSchema(w http.ResponseWriter, r *http.Request) {
 // UpdateSchema handles updating a schema
 func (h *SchemaHandlers) UpdateSchema(w http.ResponseWriter, r *http.Request) {
 	// Parse tenant ID from context (would be set by authentication middleware)
-	tenantID := GetTenantIDFromContext(r.Context())
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 	if tenantID == "" {
 		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Parse user ID from context (would be set by authentication middleware)
-	userID := GetUserIDFromContext(r.Context())
+	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
@@ -198,14 +199,14 @@ func (h *SchemaHandlers) UpdateSchema(w http.ResponseWriter, r *http.Request) {
 // DeleteSchema handles deleting a schema
 func (h *SchemaHandlers) DeleteSchema(w http.ResponseWriter, r *http.Request) {
 	// Parse tenant ID from context (would be set by authentication middleware)
-	tenantID := GetTenantIDFromContext(r.Context())
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 	if tenantID == "" {
 		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Parse user ID from context (would be set by authentication middleware)
-	userID := GetUserIDFromContext(r.Context())
+	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
@@ -232,14 +233,14 @@ func (h *SchemaHandlers) DeleteSchema(w http.ResponseWriter, r *http.Request) {
 // AddSchemaField handles adding a field to a schema
 func (h *SchemaHandlers) AddSchemaField(w http.ResponseWriter, r *http.Request) {
 	// Parse tenant ID from context (would be set by authentication middleware)
-	tenantID := GetTenantIDFromContext(r.Context())
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 	if tenantID == "" {
 		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Parse user ID from context (would be set by authentication middleware)
-	userID := GetUserIDFromContext(r.Context())
+	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
@@ -279,14 +280,14 @@ func (h *SchemaHandlers) AddSchemaField(w http.ResponseWriter, r *http.Request)
 // GetSchemaFields handles retrieving all fields for a schema
 func (h *SchemaHandlers) GetSchemaFields(w http.ResponseWriter, r *http.Request) {
 	// Parse tenant ID from context (would be set by authentication middleware)
-	tenantID := GetTenantIDFromContext(r.Context())
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 	if tenantID == "" {
 		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Parse user ID from context (would be set by authentication middleware)
-	userID := GetUserIDFromContext(r.Context())
+	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
@@ -315,14 +316,14 @@ func (h *SchemaHandlers) GetSchemaFields(w http.ResponseWriter, r *http.Request)
 // UpdateSchemaField handles updating a schema field
 func (h *SchemaHandlers) UpdateSchemaField(w http.ResponseWriter, r *http.Request) {
 	// Parse tenant ID from context (would be set by authentication middleware)
-	tenantID := GetTenantIDFromContext(r.Context())
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 	if tenantID == "" {
 		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Parse user ID from context (would be set by authentication middleware)
-	userID := GetUserIDFromContext(r.Context())
+	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
@@ -367,14 +368,14 @@ func (h *SchemaHandlers) UpdateSchemaField(w http.ResponseWriter, r *http.Reques
 // DeleteSchemaField handles deleting a schema field
 func (h *SchemaHandlers) DeleteSchemaField(w http.ResponseWriter, r *http.Request) {
 	// Parse tenant ID from context (would be set by authentication middleware)
-	tenantID := GetTenantIDFromContext(r.Context())
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 	if tenantID == "" {
 		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Parse user ID from context (would be set by authentication middleware)
-	userID := GetUserIDFromContext(r.Context())
+	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
@@ -406,14 +407,14 @@ func (h *SchemaHandlers) DeleteSchemaField(w http.ResponseWriter, r *http.Reques
 // CreateSchemaMapping handles creating a schema mapping
 func (h *SchemaHandlers) CreateSchemaMapping(w http.ResponseWriter, r *http.Request) {
 	// Parse tenant ID from context (would be set by authentication middleware)
-	tenantID := GetTenantIDFromContext(r.Context())
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 	if tenantID == "" {
 		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Parse user ID from context (would be set by authentication middleware)
-	userID := GetUserIDFromContext(r.Context())
+	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
@@ -453,14 +454,14 @@ func (h *SchemaHandlers) CreateSchemaMapping(w http.ResponseWriter, r *http.Requ
 // GetSchemaMappings handles retrieving all mappings for a schema
 func (h *SchemaHandlers) GetSchemaMappings(w http.ResponseWriter, r *http.Request) {
 	// Parse tenant ID from context (would be set by authentication middleware)
-	tenantID := GetTenantIDFromContext(r.Context())
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 	if tenantID == "" {
 		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Parse user ID from context (would be set by authentication middleware)
-	userID := GetUserIDFromContext(r.Context())
+	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
@@ -489,14 +490,14 @@ func (h *SchemaHandlers) GetSchemaMappings(w http.ResponseWriter, r *http.Reques
 // UpdateSchemaMapping handles updating a schema mapping
 func (h *SchemaHandlers) UpdateSchemaMapping(w http.ResponseWriter, r *http.Request) {
 	// Parse tenant ID from context (would be set by authentication middleware)
-	tenantID := GetTenantIDFromContext(r.Context())
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 	if tenantID == "" {
 		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Parse user ID from context (would be set by authentication middleware)
-	userID := GetUserIDFromContext(r.Context())
+	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
@@ -541,14 +542,14 @@ func (h *SchemaHandlers) UpdateSchemaMapping(w http.ResponseWriter, r *http.Requ
 // DeleteSchemaMapping handles deleting a schema mapping
 func (h *SchemaHandlers) DeleteSchemaMapping(w http.ResponseWriter, r *http.Request) {
 	// Parse tenant ID from context (would be set by authentication middleware)
-	tenantID := GetTenantIDFromContext(r.Context())
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 	if tenantID == "" {
 		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Parse user ID from context (would be set by authentication middleware)
-	userID := GetUserIDFromContext(r.Context())
+	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
@@ -580,14 +581,14 @@ func (h *SchemaHandlers) DeleteSchemaMapping(w http.ResponseWriter, r *http.Requ
 // CreateFileParsingConfig handles creating a file parsing configuration
 func (h *SchemaHandlers) CreateFileParsingConfig(w http.ResponseWriter, r *http.Request) {
 	// Parse tenant ID from context (would be set by authentication middleware)
-	tenantID := GetTenantIDFromContext(r.Context())
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 	if tenantID == "" {
 		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Parse user ID from context (would be set by authentication middleware)
-	userID := GetUserIDFromContext(r.Context())
+	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
@@ -627,14 +628,14 @@ func (h *SchemaHandlers) CreateFileParsingConfig(w http.ResponseWriter, r *http.
 // GetFileParsingConfig handles retrieving a file parsing configuration
 func (h *SchemaHandlers) GetFileParsingConfig(w http.ResponseWriter, r *http.Request) {
 	// Parse tenant ID from context (would be set by authentication middleware)
-	tenantID := GetTenantIDFromContext(r.Context())
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 	if tenantID == "" {
 		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Parse user ID from context (would be set by authentication middleware)
-	userID := GetUserIDFromContext(r.Context())
+	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
@@ -668,14 +669,14 @@ func (h *SchemaHandlers) GetFileParsingConfig(w http.ResponseWriter, r *http.Req
 // UpdateFileParsingConfig handles updating a file parsing configuration
 func (h *SchemaHandlers) UpdateFileParsingConfig(w http.ResponseWriter, r *http.Request) {
 	// Parse tenant ID from context (would be set by authentication middleware)
-	tenantID := GetTenantIDFromContext(r.Context())
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 	if tenantID == "" {
 		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Parse user ID from context (would be set by authentication middleware)
-	userID := GetUserIDFromContext(r.Context())
+	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
@@ -720,14 +721,14 @@ func (h *SchemaHandlers) UpdateFileParsingConfig(w http.ResponseWriter, r *http.
 // DeleteFileParsingConfig handles deleting a file parsing configuration
 func (h *SchemaHandlers) DeleteFileParsingConfig(w http.ResponseWriter, r *http.Request) {
 	// Parse tenant ID from context (would be set by authentication middleware)
-	tenantID := GetTenantIDFromContext(r.Context())
+	tenantID := auth.GetTenantIDFromContext(r.Context())
 	if tenantID == "" {
 		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Parse user ID from context (would be set by authentication middleware)
-	userID := GetUserIDFromContext(r.Context())
+	userID := auth.GetUserIDFromContext(r.Context())
 	if userID == "" {
 		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
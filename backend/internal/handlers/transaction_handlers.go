@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"backend/internal/auth"
+	"backend/internal/models"
+	"backend/internal/services"
+	"backend/internal/utils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// TransactionHandler handles transaction-related API endpoints
+type TransactionHandler struct {
+	transactionService *services.TransactionService
+	suggestionService  *services.MatchSuggestionService
+}
+
+// NewTransactionHandler creates a new transaction handler
+func NewTransactionHandler(transactionService *services.TransactionService, suggestionService *services.MatchSuggestionService) *TransactionHandler {
+	return &TransactionHandler{
+		transactionService: transactionService,
+		suggestionService:  suggestionService,
+	}
+}
+
+// RegisterRoutes registers the routes for transaction operations
+func (h *TransactionHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/transactions", h.SearchTransactions).Methods("GET")
+	router.HandleFunc("/transactions/search", h.SearchTransactionText).Methods("GET")
+	router.HandleFunc("/transactions/{id}/potential-matches", h.FindPotentialMatches).Methods("GET")
+}
+
+// FindPotentialMatches finds potential matching transactions for a given transaction.
+// The optional matchSetId query parameter selects the match set when the transaction's
+// data source is in several; q narrows the candidates to those whose reference or description
+// matches a full-text query, allowing for misspelled words; limit caps the number of candidates.
+func (h *TransactionHandler) FindPotentialMatches(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Extract transaction ID from URL path
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		http.Error(w, "Transaction ID is required", http.StatusBadRequest)
+		return
+	}
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	// Find potential matches
+	candidates, err := h.suggestionService.FindPotentialMatches(id, r.URL.Query().Get("matchSetId"), r.URL.Query().Get("q"), limit, userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return candidates
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(candidates)
+}
+
+// SearchTransactions searches the tenant's transactions. Filters are dateFrom and dateTo
+// (YYYY-MM-DD), amountMin and amountMax, status and dataSourceId (repeated or comma separated),
+// matchSetId, searchTerm and custom.<field>=value. sort lists fields, descending when prefixed
+// with "-"; limit and cursor page the results.
+func (h *TransactionHandler) SearchTransactions(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	filter, err := parseTransactionFilter(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+	filter.Cursor = query.Get("cursor")
+
+	// Search transactions
+	page, err := h.transactionService.SearchTransactions(filter, query.Get("matchSetId"), userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the page
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// SearchTransactionText searches the references and descriptions of the tenant's transactions.
+// q is the query: words, "quoted phrases" and prefixes ending in *, all of which must match.
+// fuzzy=true also matches words spelled alike. status, dataSourceId and matchSetId narrow the
+// search as they do GET /transactions; limit and offset page the hits, best ranked first.
+func (h *TransactionHandler) SearchTransactionText(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse user ID from context (would be set by authentication middleware)
+	userID := auth.GetUserIDFromContext(r.Context())
+	if userID == "" {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	search := models.TextSearch{
+		Statuses:      queryList(query, "status"),
+		DataSourceIDs: queryList(query, "dataSourceId"),
+	}
+	if value := query.Get("fuzzy"); value != "" {
+		fuzzy, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid fuzzy", http.StatusBadRequest)
+			return
+		}
+		search.Fuzzy = fuzzy
+	}
+	search.Limit, search.Offset = utils.GetPaginationParams(r)
+
+	// Search transactions
+	page, err := h.transactionService.SearchTransactionText(query.Get("q"), search, query.Get("matchSetId"), userID, tenantID)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	// Return the page
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// parseTransactionFilter reads the filters and sort of a transaction search from query parameters
+func parseTransactionFilter(query url.Values) (models.TransactionFilter, error) {
+	var filter models.TransactionFilter
+
+	// Date range filter
+	for param, target := range map[string]**time.Time{"dateFrom": &filter.DateFrom, "dateTo": &filter.DateTo} {
+		if value := query.Get(param); value != "" {
+			date, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				return filter, errors.New("invalid " + param + ", expected YYYY-MM-DD")
+			}
+			*target = &date
+		}
+	}
+
+	// Amount range filter
+	for param, target := range map[string]**float64{"amountMin": &filter.AmountMin, "amountMax": &filter.AmountMax} {
+		if value := query.Get(param); value != "" {
+			amount, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return filter, errors.New("invalid " + param)
+			}
+			*target = &amount
+		}
+	}
+
+	filter.Statuses = queryList(query, "status")
+	filter.DataSourceIDs = queryList(query, "dataSourceId")
+	filter.Text = strings.TrimSpace(query.Get("searchTerm"))
+
+	// Custom field filters
+	for param, values := range query {
+		if name := strings.TrimPrefix(param, models.ConditionFieldCustomPrefix); name != param && name != "" {
+			if filter.CustomFields == nil {
+				filter.CustomFields = make(map[string]string)
+			}
+			filter.CustomFields[name] = values[0]
+		}
+	}
+
+	for _, field := range queryList(query, "sort") {
+		key := models.TransactionSort{Field: field}
+		if strings.HasPrefix(field, "-") {
+			key = models.TransactionSort{Field: field[1:], Desc: true}
+		}
+		filter.Sort = append(filter.Sort, key)
+	}
+
+	return filter, nil
+}
+
+// queryList returns the values of a query parameter given repeatedly or comma separated
+func queryList(query url.Values, param string) []string {
+	var list []string
+	for _, value := range query[param] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"backend/internal/auth"
 	"backend/internal/models"
 	"backend/internal/services"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -24,6 +26,7 @@ type UploadHandler struct {
 	dataSourceService  *services.DataSourceService
 	transactionService *services.TransactionService
 	roleService        *services.RoleService
+	autoRunService     *services.AutoRunService
 }
 
 // NewUploadHandler creates a new upload handler
@@ -31,16 +34,25 @@ func NewUploadHandler(
 	dataSourceService *services.DataSourceService,
 	transactionService *services.TransactionService,
 	roleService *services.RoleService,
+	autoRunService *services.AutoRunService,
 ) *UploadHandler {
 	return &UploadHandler{
 		dataSourceService:  dataSourceService,
 		transactionService: transactionService,
 		roleService:        roleService,
+		autoRunService:     autoRunService,
 	}
 }
 
 // UploadTransactions handles transaction data uploads from CSV files
 func (h *UploadHandler) UploadTransactions(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
 	// Get user claims from JWT token
 	userClaims, ok := r.Context().Value("user").(*jwt.MapClaims)
 	if !ok || userClaims == nil {
@@ -108,7 +120,7 @@ func (h *UploadHandler) UploadTransactions(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Get data source
-	_, err = h.dataSourceService.GetDataSourceByID(dataSourceID)
+	_, err = h.dataSourceService.GetDataSourceByID(tenantID, dataSourceID)
 	if err != nil {
 		http.Error(w, "Data source not found: "+err.Error(), http.StatusNotFound)
 		return
@@ -161,6 +173,7 @@ func (h *UploadHandler) UploadTransactions(w http.ResponseWriter, r *http.Reques
 		// Parse transaction data using column mappings
 		transaction := models.Transaction{
 			ID:           uuid.New().String(),
+			TenantID:     tenantID,
 			DataSourceID: dataSourceID,
 			CreatedAt:    time.Now(),
 			UpdatedAt:    time.Now(),
@@ -229,6 +242,11 @@ func (h *UploadHandler) UploadTransactions(w http.ResponseWriter, r *http.Reques
 			http.Error(w, "Failed to save transactions: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
+
+		// The upload is stored either way; a missed auto-run is caught up by the next import or run
+		if err := h.autoRunService.ImportCompleted(tenantID, dataSourceID); err != nil {
+			log.Printf("Failed to request auto-runs for data source %s: %v", dataSourceID, err)
+		}
 	}
 
 	// Return success response
@@ -320,12 +338,19 @@ func (h *UploadHandler) UploadJSONTransactions(w http.ResponseWriter, r *http.Re
 
 // GetUploadByID retrieves an upload by ID
 func (h *UploadHandler) GetUploadByID(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
 	// Extract upload ID from URL path
 	vars := mux.Vars(r)
 	id := vars["id"]
 
 	// Get upload
-	upload, err := h.transactionService.GetTransactionByID(id)
+	upload, err := h.transactionService.GetTransactionByID(tenantID, id)
 	if err != nil {
 		http.Error(w, "Transaction not found", http.StatusNotFound)
 		return
@@ -338,6 +363,13 @@ func (h *UploadHandler) GetUploadByID(w http.ResponseWriter, r *http.Request) {
 
 // GetUploadsByUser retrieves uploads by user
 func (h *UploadHandler) GetUploadsByUser(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
 	// Get user claims from JWT token
 	userClaims, ok := r.Context().Value("user").(*jwt.MapClaims)
 	if !ok || userClaims == nil {
@@ -355,7 +387,7 @@ func (h *UploadHandler) GetUploadsByUser(w http.ResponseWriter, r *http.Request)
 	userID := userIDValue.(string)
 
 	// Get uploads
-	uploads, err := h.transactionService.GetTransactionsByUserID(userID)
+	uploads, err := h.transactionService.GetTransactionsByUserID(tenantID, userID)
 	if err != nil {
 		http.Error(w, "Error retrieving transactions", http.StatusInternalServerError)
 		return
@@ -368,6 +400,13 @@ func (h *UploadHandler) GetUploadsByUser(w http.ResponseWriter, r *http.Request)
 
 // GetRecentUploads retrieves recent uploads
 func (h *UploadHandler) GetRecentUploads(w http.ResponseWriter, r *http.Request) {
+	// Parse tenant ID from context (would be set by authentication middleware)
+	tenantID := auth.GetTenantIDFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "Tenant ID is required", http.StatusBadRequest)
+		return
+	}
+
 	// Get user claims from JWT token
 	userClaims, ok := r.Context().Value("user").(*jwt.MapClaims)
 	if !ok || userClaims == nil {
@@ -403,7 +442,7 @@ func (h *UploadHandler) GetRecentUploads(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Get uploads
-	uploads, err := h.transactionService.GetRecentTransactions(limit)
+	uploads, err := h.transactionService.GetRecentTransactions(tenantID, limit)
 	if err != nil {
 		http.Error(w, "Error retrieving transactions", http.StatusInternalServerError)
 		return
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"backend/internal/auth"
 	"backend/internal/services"
 	"context"
 	"net/http"
@@ -17,6 +18,8 @@ func NewAuthMiddleware(jwtService *services.JWTService) *AuthMiddleware {
 	}
 }
 
+// RequireAuth rejects requests without a valid token and puts the user, the tenant the token is
+// scoped to and the user's roles into the request context
 func (m *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
@@ -41,10 +44,26 @@ func (m *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 		ctx := context.WithValue(r.Context(), "user", claims)
 
 		// Also add userID for backward compatibility
-		if userID, ok := (*claims)["user_id"]; ok && userID != nil {
-			ctx = context.WithValue(ctx, "userID", userID.(string))
+		if userID, ok := (*claims)["user_id"].(string); ok {
+			ctx = context.WithValue(ctx, "userID", userID)
+			ctx = auth.ContextWithUserID(ctx, userID)
 		}
 
+		// Tokens issued before tenant selection carry no tenant; tenant-scoped routes reject them
+		if tenantID, ok := (*claims)["tenant_id"].(string); ok && tenantID != "" {
+			ctx = auth.ContextWithTenantID(ctx, tenantID)
+		}
+
+		roles := []string{}
+		if values, ok := (*claims)["roles"].([]interface{}); ok {
+			for _, value := range values {
+				if role, ok := value.(string); ok {
+					roles = append(roles, role)
+				}
+			}
+		}
+		ctx = auth.ContextWithRoles(ctx, roles)
+
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
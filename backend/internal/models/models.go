@@ -29,6 +29,7 @@ const (
 	PermUpdateMatchSet    Permission = "update:matchset"
 	PermDeleteMatchSet    Permission = "delete:matchset"
 	PermMatchTransactions Permission = "match:transactions"
+	PermApproveMatches    Permission = "approve:matches"
 
 	// Data source permissions
 	PermCreateDataSource Permission = "create:datasource"
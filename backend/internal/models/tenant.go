@@ -32,6 +32,85 @@ type MatchSet struct {
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// MatchSetRule assigns a rule to a match set. Rules run in ascending priority,
+// each pass matching only what earlier passes left unmatched.
+type MatchSetRule struct {
+	MatchSetID string    `json:"match_set_id" db:"match_set_id"`
+	RuleID     string    `json:"rule_id" db:"rule_id"`
+	Priority   int       `json:"priority" db:"priority"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// AutoApprovalPolicy lets a match set's runs approve matches without a human review. A match is
+// approved by the first active policy it meets; everything else stays Pending.
+type AutoApprovalPolicy struct {
+	ID           string    `json:"id" db:"id"`
+	MatchSetID   string    `json:"match_set_id" db:"match_set_id"`
+	TenantID     string    `json:"tenant_id" db:"tenant_id"`
+	Name         string    `json:"name" db:"name"`
+	RuleID       string    `json:"rule_id,omitempty" db:"rule_id"`       // Only matches made by this rule; any rule when empty
+	MinScore     float64   `json:"min_score" db:"min_score"`             // Lowest match score approved
+	MaxAmount    float64   `json:"max_amount" db:"max_amount"`           // Largest group amount approved; no ceiling when 0
+	OneToOneOnly bool      `json:"one_to_one_only" db:"one_to_one_only"` // Only groups of one transaction on each side
+	Active       bool      `json:"active" db:"active"`
+	CreatedBy    string    `json:"created_by" db:"created_by"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// MatchSetSchedule runs a match set on a cron schedule. Each due run is enqueued as the user who
+// created the schedule, so the schedule stops running if that user loses the permission to match.
+type MatchSetSchedule struct {
+	ID             string     `json:"id" db:"id"`
+	MatchSetID     string     `json:"match_set_id" db:"match_set_id"`
+	TenantID       string     `json:"tenant_id" db:"tenant_id"`
+	CronExpression string     `json:"cron_expression" db:"cron_expression"` // minute hour day-of-month month day-of-week
+	Timezone       string     `json:"timezone" db:"timezone"`               // IANA name the expression is read in, e.g. Europe/London
+	Mode           string     `json:"mode" db:"mode"`                       // RunModeFull or RunModeIncremental
+	Active         bool       `json:"active" db:"active"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty" db:"next_run_at"` // Unset while inactive
+	LastRunAt      *time.Time `json:"last_run_at,omitempty" db:"last_run_at"`
+	CreatedBy      string     `json:"created_by" db:"created_by"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Outcomes of a scheduled run
+const (
+	ScheduledRunEnqueued  = "Enqueued" // Waiting for or running on a queue worker
+	ScheduledRunSkipped   = "Skipped"  // The previous run of the match set was still going
+	ScheduledRunCompleted = "Completed"
+	ScheduledRunFailed    = "Failed"
+	ScheduledRunCancelled = "Cancelled"
+)
+
+// ScheduledRun records a run a schedule was due to start and how it went
+type ScheduledRun struct {
+	ID           string     `json:"id" db:"id"`
+	ScheduleID   string     `json:"schedule_id" db:"schedule_id"`
+	MatchSetID   string     `json:"match_set_id" db:"match_set_id"`
+	ScheduledFor time.Time  `json:"scheduled_for" db:"scheduled_for"`
+	Status       string     `json:"status" db:"status"`
+	RunID        string     `json:"run_id,omitempty" db:"run_id"` // Match set run it started or was skipped for
+	Message      string     `json:"message,omitempty" db:"message"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// MatchSetAutoRun makes a match set run by itself when its data sources receive imports. Imports
+// arriving within the debounce of the first one are matched by a single incremental run, which
+// acts as the user who enabled the auto-run.
+type MatchSetAutoRun struct {
+	MatchSetID      string     `json:"match_set_id" db:"match_set_id"`
+	TenantID        string     `json:"tenant_id" db:"tenant_id"`
+	Enabled         bool       `json:"enabled" db:"enabled"`
+	DebounceSeconds int        `json:"debounce_seconds" db:"debounce_seconds"`
+	RunAs           string     `json:"run_as,omitempty" db:"run_as"`
+	DueAt           *time.Time `json:"due_at,omitempty" db:"due_at"` // When the pending run starts; unset when none is pending
+	LastImportAt    *time.Time `json:"last_import_at,omitempty" db:"last_import_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
 // MatchSetDataSource associates data sources with a match set
 type MatchSetDataSource struct {
 	ID           string    `json:"id" db:"id"`
@@ -39,3 +118,195 @@ type MatchSetDataSource struct {
 	DataSourceID string    `json:"data_source_id" db:"data_source_id"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 }
+
+// Modes of a match set run
+const (
+	RunModeFull        = "full"        // Reconsider the whole unmatched backlog
+	RunModeIncremental = "incremental" // Only pair transactions imported or changed since the last successful run
+)
+
+// MatchProgress tracks the latest run of a match set
+type MatchProgress struct {
+	MatchSetID            string         `json:"match_set_id" db:"match_set_id"`
+	RunID                 string         `json:"run_id,omitempty" db:"run_id"` // Run holding the lease, or the latest run
+	Mode                  string         `json:"mode" db:"mode"`
+	TotalTransactions     int            `json:"total_transactions" db:"total_transactions"`
+	ProcessedTransactions int            `json:"processed_transactions" db:"processed_transactions"`
+	MatchedTransactions   int            `json:"matched_transactions" db:"matched_transactions"`
+	UnmatchedTransactions int            `json:"unmatched_transactions" db:"unmatched_transactions"`
+	Status                string         `json:"status" db:"status"` // Pending, Running, Completed, Failed, Cancelled
+	StartedAt             *time.Time     `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt           *time.Time     `json:"completed_at,omitempty" db:"completed_at"`
+	LastSuccessAt         *time.Time     `json:"last_success_at,omitempty" db:"last_success_at"`   // Start of the latest successful run
+	LeaseExpiresAt        *time.Time     `json:"lease_expires_at,omitempty" db:"lease_expires_at"` // Until when the running run holds the match set
+	CancelRequested       bool           `json:"cancel_requested,omitempty" db:"cancel_requested"`
+	Checkpoint            *RunCheckpoint `json:"checkpoint,omitempty" db:"checkpoint"`
+	Error                 string         `json:"error,omitempty" db:"error"`
+}
+
+// RunCheckpoint records how far a run got, so a cancelled run can resume. A run works in steps:
+// each rule, in order, matches the first data source against each of the others in turn.
+type RunCheckpoint struct {
+	RuleIDs        []string  `json:"rule_ids"`
+	CompletedSteps int       `json:"completed_steps"`
+	StartedAt      time.Time `json:"started_at"` // Start of the run before any resume
+}
+
+// MatchSimulation is the outcome of a dry run of a match set. It reports what a run would
+// match over the match set's current and unmatched transactions without writing anything.
+type MatchSimulation struct {
+	MatchSetID            string           `json:"match_set_id"`
+	RuleIDs               []string         `json:"rule_ids"`
+	TotalTransactions     int              `json:"total_transactions"`
+	ProposedGroups        int              `json:"proposed_groups"`
+	MatchedTransactions   int              `json:"matched_transactions"`
+	UnmatchedTransactions int              `json:"unmatched_transactions"`
+	Sample                []SimulatedMatch `json:"sample"` // First proposed groups
+	Diff                  SimulationDiff   `json:"diff"`
+}
+
+// SimulationDiff compares the groups of a dry run with the match set's current groups
+type SimulationDiff struct {
+	NewMatches       int              `json:"new_matches"`       // Proposed groups of currently unmatched transactions
+	UnchangedMatches int              `json:"unchanged_matches"` // Proposed groups identical to a current group
+	LostMatches      int              `json:"lost_matches"`      // Current groups the dry run does not reproduce
+	Conflicts        int              `json:"conflicts"`         // Proposed groups that regroup currently matched transactions
+	Changes          []SimulatedMatch `json:"changes"`           // Sample of new, lost and conflicting groups
+}
+
+// SimulatedMatch is a group proposed by a dry run, or a current group it would lose
+type SimulatedMatch struct {
+	Change           string   `json:"change"` // new, unchanged, lost, conflict
+	RuleID           string   `json:"rule_id,omitempty"`
+	TransactionIDs   []string `json:"transaction_ids"`
+	AmountDifference float64  `json:"amount_difference"`
+	Currency         string   `json:"currency,omitempty"`
+	Score            float64  `json:"score"`
+	CurrentMatchIDs  []string `json:"current_match_ids,omitempty"` // Current groups holding the transactions
+}
+
+// Formats a reconciliation report renders to
+const (
+	ReportFormatCSV  = "csv"
+	ReportFormatXLSX = "xlsx"
+	ReportFormatPDF  = "pdf"
+)
+
+// ReconciliationReport is the reconciliation statement of a match set over a period. Balances
+// are kept per data source and currency; the first data source of the match set is the left
+// side, the others and the tenant's adjustments the right side.
+type ReconciliationReport struct {
+	MatchSetID   string                `json:"match_set_id"`
+	MatchSetName string                `json:"match_set_name"`
+	TenantID     string                `json:"tenant_id"`
+	PeriodStart  time.Time             `json:"period_start"`
+	PeriodEnd    time.Time             `json:"period_end"` // Last day of the period, inclusive
+	GeneratedAt  time.Time             `json:"generated_at"`
+	Sources      []ReportSourceBalance `json:"sources"`
+	Unmatched    []ReportUnmatchedItem `json:"unmatched"`   // Outstanding at the end of the period, oldest first
+	Adjustments  []ReportAdjustment    `json:"adjustments"` // Posted into the match set's groups during the period
+	Differences  []ReportDifference    `json:"differences"` // Closing difference per currency
+}
+
+// ReportSourceBalance is the balance of a data source in one currency over a report period
+type ReportSourceBalance struct {
+	DataSourceID   string  `json:"data_source_id"`
+	DataSourceName string  `json:"data_source_name"`
+	Side           string  `json:"side"` // left or right
+	Currency       string  `json:"currency"`
+	OpeningBalance float64 `json:"opening_balance"` // Transactions dated before the period
+	PeriodActivity float64 `json:"period_activity"` // Transactions dated within the period
+	ClosingBalance float64 `json:"closing_balance"`
+	MatchedCount   int     `json:"matched_count"` // Period transactions in a group of the match set
+	MatchedTotal   float64 `json:"matched_total"`
+	UnmatchedCount int     `json:"unmatched_count"` // Transactions outstanding at the end of the period
+	UnmatchedTotal float64 `json:"unmatched_total"`
+}
+
+// ReportUnmatchedItem is a transaction outstanding at the end of a report period
+type ReportUnmatchedItem struct {
+	TransactionID   string    `json:"transaction_id"`
+	DataSourceID    string    `json:"data_source_id"`
+	DataSourceName  string    `json:"data_source_name"`
+	TransactionDate time.Time `json:"transaction_date"`
+	Reference       string    `json:"reference"`
+	Description     string    `json:"description"`
+	Amount          float64   `json:"amount"`
+	Currency        string    `json:"currency"`
+	AgeDays         int       `json:"age_days"` // Days from the transaction date to the end of the period
+	AgeBucket       string    `json:"age_bucket"`
+}
+
+// ReportAdjustment is an adjustment listed in a report, dated by its transaction
+type ReportAdjustment struct {
+	Adjustment
+	TransactionDate time.Time `json:"transaction_date"`
+}
+
+// ReportDifference is what remains between the sides of a match set at the end of a report
+// period in one currency, once adjustments are counted on the right side
+type ReportDifference struct {
+	Currency     string  `json:"currency"`
+	LeftClosing  float64 `json:"left_closing"`
+	RightClosing float64 `json:"right_closing"`
+	Adjustments  float64 `json:"adjustments"`
+	Difference   float64 `json:"difference"`
+}
+
+// ReportFile is a rendered reconciliation report, stored with the match set run it followed so
+// the exact file can be retrieved later
+type ReportFile struct {
+	ID          string    `json:"id" db:"id"`
+	TenantID    string    `json:"tenant_id" db:"tenant_id"`
+	MatchSetID  string    `json:"match_set_id" db:"match_set_id"`
+	RunID       string    `json:"run_id,omitempty" db:"run_id"` // Latest run of the match set when generated
+	PeriodStart time.Time `json:"period_start" db:"period_start"`
+	PeriodEnd   time.Time `json:"period_end" db:"period_end"`
+	Format      string    `json:"format" db:"format"`
+	FileKey     string    `json:"-" db:"file_key"`
+	FileSize    int64     `json:"file_size" db:"file_size"`
+	Checksum    string    `json:"checksum" db:"checksum"` // SHA-256 of the file, hex encoded
+	GeneratedBy string    `json:"generated_by" db:"generated_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// Kinds of rows an export holds
+const (
+	ExportKindTransactions = "transactions" // Transactions matching a search
+	ExportKindMatched      = "matched"      // Transactions in a group of a match set, with their group
+	ExportKindUnmatched    = "unmatched"    // Transactions of a match set in no group, with their exception
+)
+
+// Formats an export writes
+const (
+	ExportFormatCSV     = "csv"
+	ExportFormatXLSX    = "xlsx"
+	ExportFormatJSONL   = "jsonl"
+	ExportFormatParquet = "parquet"
+)
+
+// Export job statuses
+const (
+	ExportStatusRunning   = "Running"
+	ExportStatusCompleted = "Completed"
+	ExportStatusFailed    = "Failed"
+)
+
+// ExportJob is an export too large to stream in a request, written to storage in the background.
+// DownloadURL is filled in once the file is complete.
+type ExportJob struct {
+	ID          string     `json:"id" db:"id"`
+	TenantID    string     `json:"tenant_id" db:"tenant_id"`
+	MatchSetID  string     `json:"match_set_id,omitempty" db:"match_set_id"`
+	Kind        string     `json:"kind" db:"kind"`
+	Format      string     `json:"format" db:"format"`
+	Status      string     `json:"status" db:"status"`
+	RowCount    int        `json:"row_count" db:"row_count"`
+	FileKey     string     `json:"-" db:"file_key"`
+	FileSize    int64      `json:"file_size" db:"file_size"`
+	Error       string     `json:"error,omitempty" db:"error"`
+	DownloadURL string     `json:"download_url,omitempty" db:"-"`
+	RequestedBy string     `json:"requested_by" db:"requested_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
@@ -87,18 +87,19 @@ func (ds *DataSource) MarshalJSON() ([]byte, error) {
 
 // ImportRecord represents a batch import of transactions
 type ImportRecord struct {
-	ID           string          `json:"id" db:"id"`
-	DataSourceID string          `json:"data_source_id" db:"data_source_id"`
-	FileName     string          `json:"file_name" db:"file_name"`
-	FileSize     int64           `json:"file_size" db:"file_size"`
-	Status       string          `json:"status" db:"status"` // Processing, Completed, Failed
-	RowCount     int             `json:"row_count" db:"row_count"`
-	SuccessCount int             `json:"success_count" db:"success_count"`
-	ErrorCount   int             `json:"error_count" db:"error_count"`
-	ImportedBy   string          `json:"imported_by" db:"imported_by"`
-	CreatedAt    time.Time       `json:"-" db:"created_at"`
-	UpdatedAt    time.Time       `json:"-" db:"updated_at"`
-	Metadata     json.RawMessage `json:"metadata,omitempty" db:"metadata"`
+	ID             string          `json:"id" db:"id"`
+	DataSourceID   string          `json:"data_source_id" db:"data_source_id"`
+	FileName       string          `json:"file_name" db:"file_name"`
+	FileSize       int64           `json:"file_size" db:"file_size"`
+	Status         string          `json:"status" db:"status"` // Processing, Completed, Failed
+	RowCount       int             `json:"row_count" db:"row_count"`
+	SuccessCount   int             `json:"success_count" db:"success_count"`
+	ErrorCount     int             `json:"error_count" db:"error_count"`
+	ImportedBy     string          `json:"imported_by" db:"imported_by"`
+	IdempotencyKey string          `json:"idempotency_key,omitempty" db:"idempotency_key"` // Set for API batch imports
+	CreatedAt      time.Time       `json:"-" db:"created_at"`
+	UpdatedAt      time.Time       `json:"-" db:"updated_at"`
+	Metadata       json.RawMessage `json:"metadata,omitempty" db:"metadata"`
 
 	CreatedAtEpoch int64 `json:"created_at" db:"-"`
 	UpdatedAtEpoch int64 `json:"updated_at" db:"-"`
@@ -159,19 +160,23 @@ func (rt *RawTransaction) MarshalJSON() ([]byte, error) {
 
 // Transaction represents a financial transaction
 type Transaction struct {
-	ID              string         `json:"id" db:"id"`
-	DataSourceID    string         `json:"dataSourceId" db:"data_source_id"`
-	TransactionDate time.Time      `json:"-" db:"transaction_date"`
-	PostDate        time.Time      `json:"-" db:"post_date"`
-	Description     string         `json:"description" db:"description"`
-	Reference       string         `json:"reference" db:"reference"`
-	Amount          float64        `json:"amount" db:"amount"`
-	Currency        string         `json:"currency" db:"currency"`
-	Status          string         `json:"status" db:"status"`
-	MatchID         sql.NullString `json:"matchId,omitempty" db:"match_id"`
-	CreatedBy       string         `json:"createdBy" db:"created_by"`
-	CreatedAt       time.Time      `json:"-" db:"created_at"`
-	UpdatedAt       time.Time      `json:"-" db:"updated_at"`
+	ID              string            `json:"id" db:"id"`
+	TenantID        string            `json:"tenantId" db:"tenant_id"`
+	DataSourceID    string            `json:"dataSourceId" db:"data_source_id"`
+	TransactionDate time.Time         `json:"-" db:"transaction_date"`
+	PostDate        time.Time         `json:"-" db:"post_date"`
+	Description     string            `json:"description" db:"description"`
+	Reference       string            `json:"reference" db:"reference"`
+	Amount          float64           `json:"amount" db:"amount"`
+	Currency        string            `json:"currency" db:"currency"`
+	Status          string            `json:"status" db:"status"`
+	MatchID         sql.NullString    `json:"matchId,omitempty" db:"match_id"`
+	ExternalID      string            `json:"externalId,omitempty" db:"external_id"` // Caller-supplied ID for API ingested records
+	ImportID        string            `json:"importId,omitempty" db:"import_id"`
+	CustomFields    map[string]string `json:"customFields,omitempty" db:"custom_fields"` // Custom schema field values by name
+	CreatedBy       string            `json:"createdBy" db:"created_by"`
+	CreatedAt       time.Time         `json:"-" db:"created_at"`
+	UpdatedAt       time.Time         `json:"-" db:"updated_at"`
 
 	TransactionDateEpoch int64 `json:"transactionDate" db:"-"`
 	PostDateEpoch        int64 `json:"postDate" db:"-"`
@@ -216,35 +221,127 @@ func (t *Transaction) MarshalJSON() ([]byte, error) {
 
 // TransactionMatch represents a match between two or more transactions
 type TransactionMatch struct {
-	ID              string    `json:"id" db:"id"`
-	MatchStatus     string    `json:"match_status" db:"match_status"` // Pending, Approved, Rejected
-	MatchType       string    `json:"match_type" db:"match_type"`     // Automatic, Manual
-	MatchRuleID     string    `json:"match_rule_id,omitempty" db:"match_rule_id"`
-	TenantID        string    `json:"tenant_id" db:"tenant_id"`
-	MatchedBy       string    `json:"matched_by" db:"matched_by"` // User ID
-	ApprovedBy      string    `json:"approved_by,omitempty" db:"approved_by"`
-	ApprovalDate    time.Time `json:"approval_date,omitempty" db:"approval_date"`
-	RejectionReason string    `json:"rejection_reason,omitempty" db:"rejection_reason"`
-	CreatedAt       time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
-}
-
-// MatchRule defines criteria for automatic transaction matching
-type MatchRule struct {
 	ID               string    `json:"id" db:"id"`
-	Name             string    `json:"name" db:"name"`
-	Description      string    `json:"description" db:"description"`
+	MatchStatus      string    `json:"match_status" db:"match_status"` // Pending, Approved, Rejected, Unmatched
+	MatchType        string    `json:"match_type" db:"match_type"`     // Automatic, Manual
+	MatchRuleID      string    `json:"match_rule_id,omitempty" db:"match_rule_id"`
+	MatchSetID       string    `json:"match_set_id,omitempty" db:"match_set_id"`
 	TenantID         string    `json:"tenant_id" db:"tenant_id"`
-	MatchByAmount    bool      `json:"match_by_amount" db:"match_by_amount"`
-	MatchByDate      bool      `json:"match_by_date" db:"match_by_date"`
-	DateTolerance    int       `json:"date_tolerance" db:"date_tolerance"` // Days
-	MatchByReference bool      `json:"match_by_reference" db:"match_by_reference"`
-	Active           bool      `json:"active" db:"active"`
-	CreatedBy        string    `json:"created_by" db:"created_by"`
+	MatchedBy        string    `json:"matched_by" db:"matched_by"` // User ID
+	ApprovedBy       string    `json:"approved_by,omitempty" db:"approved_by"`
+	ApprovedByPolicy string    `json:"approved_by_policy,omitempty" db:"approved_by_policy"` // Auto-approval policy name when no user approved it
+	ApprovalDate     time.Time `json:"approval_date,omitempty" db:"approval_date"`
+	RejectionReason  string    `json:"rejection_reason,omitempty" db:"rejection_reason"`
+	MatchScore       float64   `json:"match_score" db:"match_score"` // 0..1, how closely the compared fields agreed
 	CreatedAt        time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// Match rule cardinalities
+const (
+	MatchCardinalityOneToOne   = "1:1" // One transaction on each side
+	MatchCardinalityOneToMany  = "1:N" // One transaction against a group, in either direction
+	MatchCardinalityManyToMany = "N:M" // Groups on both sides
+)
+
+// Reference comparison modes
+const (
+	ReferenceModeExact      = "exact"      // References must be identical
+	ReferenceModeNormalized = "normalized" // Identical after stripping punctuation, case and leading zeros
+	ReferenceModeSimilarity = "similarity" // Similarity score must reach SimilarityThreshold
+)
+
+// Similarity algorithms for ReferenceModeSimilarity
+const (
+	SimilarityLevenshtein  = "levenshtein"
+	SimilarityJaroWinkler  = "jaro_winkler"
+	SimilarityTokenOverlap = "token_overlap"
+)
+
+// Rule condition fields. Custom schema fields are addressed as "custom.<name>".
+const (
+	ConditionFieldAmount          = "amount"
+	ConditionFieldTransactionDate = "transaction_date"
+	ConditionFieldReference       = "reference"
+	ConditionFieldDescription     = "description"
+	ConditionFieldCurrency        = "currency"
+	ConditionFieldExternalID      = "external_id"
+	ConditionFieldCustomPrefix    = "custom."
+)
+
+// Rule condition operators
+const (
+	ConditionOpEq         = "eq"         // Values are equal
+	ConditionOpWithin     = "within"     // Numbers or dates differ by at most Tolerance
+	ConditionOpContains   = "contains"   // One value contains the other, ignoring case
+	ConditionOpRegex      = "regex"      // Pattern extracts the same token from both values
+	ConditionOpSimilarity = "similarity" // Similarity score reaches Threshold
+)
+
+// RuleCondition compares one field of the transactions on each side of a match
+type RuleCondition struct {
+	Field     string  `json:"field"`
+	Operator  string  `json:"operator"`
+	Tolerance float64 `json:"tolerance,omitempty"` // within: allowed difference, in days for dates
+	Normalize bool    `json:"normalize,omitempty"` // eq, contains: compare normalized references
+	Pattern   string  `json:"pattern,omitempty"`   // regex: first capture group, or the whole match
+	Algorithm string  `json:"algorithm,omitempty"` // similarity: levenshtein, jaro_winkler or token_overlap
+	Threshold float64 `json:"threshold,omitempty"` // similarity: minimum score from 0 to 1
+}
+
+// MatchRule defines criteria for automatic transaction matching
+type MatchRule struct {
+	ID               string `json:"id" db:"id"`
+	Name             string `json:"name" db:"name"`
+	Description      string `json:"description" db:"description"`
+	TenantID         string `json:"tenant_id" db:"tenant_id"`
+	MatchByAmount    bool   `json:"match_by_amount" db:"match_by_amount"`
+	MatchByDate      bool   `json:"match_by_date" db:"match_by_date"`
+	DateTolerance    int    `json:"date_tolerance" db:"date_tolerance"` // Days
+	MatchByReference bool   `json:"match_by_reference" db:"match_by_reference"`
+
+	// Group matching: sums on each side must agree within AmountTolerance
+	MatchCardinality    string  `json:"match_cardinality" db:"match_cardinality"` // 1:1, 1:N, N:M
+	AmountTolerance     float64 `json:"amount_tolerance" db:"amount_tolerance"`
+	MaxGroupSize        int     `json:"max_group_size" db:"max_group_size"`               // Most transactions on either side of a group
+	MaxCandidates       int     `json:"max_candidates" db:"max_candidates"`               // Candidates considered per anchor transaction
+	MaxSearchIterations int     `json:"max_search_iterations" db:"max_search_iterations"` // Subset search steps per anchor
+
+	// Amounts match when within AmountTolerance or AmountTolerancePct of the target, whichever is larger.
+	// Across currencies, amounts are converted with the FX rate table and FXTolerancePct is added for drift.
+	AmountTolerancePct      float64 `json:"amount_tolerance_pct" db:"amount_tolerance_pct"`
+	CompareAcrossCurrencies bool    `json:"compare_across_currencies" db:"compare_across_currencies"`
+	FXTolerancePct          float64 `json:"fx_tolerance_pct" db:"fx_tolerance_pct"`
+
+	// How references are compared when MatchByReference is set. When ReferencePattern is set, the
+	// token it extracts from Description (first capture group, or the whole match) is used as the
+	// reference of transactions whose description contains one.
+	ReferenceMode       string  `json:"reference_mode" db:"reference_mode"`
+	ReferencePattern    string  `json:"reference_pattern,omitempty" db:"reference_pattern"`
+	SimilarityAlgorithm string  `json:"similarity_algorithm" db:"similarity_algorithm"`
+	SimilarityThreshold float64 `json:"similarity_threshold" db:"similarity_threshold"` // 0..1
+
+	// Conditions that must all hold for a match, in evaluation order. When empty the
+	// MatchBy* flags above are translated to conditions.
+	Conditions []RuleCondition `json:"conditions" db:"conditions"`
+
+	Active    bool      `json:"active" db:"active"`
+	CreatedBy string    `json:"created_by" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// FXRate is an exchange rate: 1 unit of BaseCurrency buys Rate units of QuoteCurrency on RateDate
+type FXRate struct {
+	ID            string    `json:"id" db:"id"`
+	TenantID      string    `json:"tenant_id,omitempty" db:"tenant_id"` // Empty for rates shared by all tenants
+	BaseCurrency  string    `json:"base_currency" db:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency" db:"quote_currency"`
+	Rate          float64   `json:"rate" db:"rate"`
+	RateDate      time.Time `json:"rate_date" db:"rate_date"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
 // TransactionUpload tracks file uploads
 type TransactionUpload struct {
 	ID           string    `json:"id" db:"id"`
@@ -307,25 +404,58 @@ func (mt *MatchedTransaction) MarshalJSON() ([]byte, error) {
 	})
 }
 
-// UnmatchedTransaction represents a transaction that couldn't be matched
-type UnmatchedTransaction struct {
-	ID               string    `json:"id" db:"id"`
-	MatchSetID       string    `json:"match_set_id" db:"match_set_id"`
-	TransactionID    string    `json:"transaction_id" db:"transaction_id"`
-	RawTransactionID string    `json:"raw_transaction_id,omitempty" db:"raw_transaction_id"`
-	Reason           string    `json:"reason" db:"reason"`
-	TenantID         string    `json:"tenant_id" db:"tenant_id"`
-	CreatedAt        time.Time `json:"-" db:"created_at"`
+// Categories of why a transaction was left unmatched
+const (
+	ExceptionReasonMissingCounterpart = "missing_counterpart" // Nothing on the other side resembles it
+	ExceptionReasonAmountMismatch     = "amount_mismatch"     // The other side has its reference with a different amount
+	ExceptionReasonTiming             = "timing"              // The other side has its amount on a different date
+	ExceptionReasonDuplicate          = "duplicate"           // Its own side has an identical transaction
+)
 
-	CreatedAtEpoch int64 `json:"created_at" db:"-"`
+// Stages of working an unmatched transaction. Open and investigating exceptions block the
+// month-end close.
+const (
+	ExceptionStatusOpen          = "open"
+	ExceptionStatusInvestigating = "investigating"
+	ExceptionStatusResolved      = "resolved"
+	ExceptionStatusWrittenOff    = "written_off"
+)
+
+// UnmatchedTransaction represents a transaction that couldn't be matched. It is worked as an
+// exception until it is matched, resolved or written off.
+type UnmatchedTransaction struct {
+	ID               string     `json:"id" db:"id"`
+	MatchSetID       string     `json:"match_set_id" db:"match_set_id"`
+	TransactionID    string     `json:"transaction_id" db:"transaction_id"`
+	RawTransactionID string     `json:"raw_transaction_id,omitempty" db:"raw_transaction_id"`
+	Reason           string     `json:"reason" db:"reason"`
+	ReasonCode       string     `json:"reason_code" db:"reason_code"`               // One of the ExceptionReason constants
+	ReasonCodeManual bool       `json:"reason_code_manual" db:"reason_code_manual"` // Set by an analyst, so runs keep it
+	Status           string     `json:"status" db:"status"`                         // One of the ExceptionStatus constants
+	AssigneeID       string     `json:"assignee_id,omitempty" db:"assignee_id"`
+	AgeDays          int        `json:"age_days" db:"-"` // Whole days since it was first left unmatched
+	TenantID         string     `json:"tenant_id" db:"tenant_id"`
+	CreatedAt        time.Time  `json:"-" db:"created_at"`
+	UpdatedAt        time.Time  `json:"-" db:"updated_at"`
+	ResolvedAt       *time.Time `json:"-" db:"resolved_at"` // When it was resolved or written off
+
+	CreatedAtEpoch  int64 `json:"created_at" db:"-"`
+	UpdatedAtEpoch  int64 `json:"updated_at" db:"-"`
+	ResolvedAtEpoch int64 `json:"resolved_at,omitempty" db:"-"`
 }
 
 // MarshalJSON customizes JSON serialization for UnmatchedTransaction
 func (ut *UnmatchedTransaction) MarshalJSON() ([]byte, error) {
-	// Ensure epoch timestamp is set
+	// Ensure epoch timestamps are set
 	if ut.CreatedAtEpoch == 0 && !ut.CreatedAt.IsZero() {
 		ut.CreatedAtEpoch = utils.TimeToMillis(ut.CreatedAt)
 	}
+	if ut.UpdatedAtEpoch == 0 && !ut.UpdatedAt.IsZero() {
+		ut.UpdatedAtEpoch = utils.TimeToMillis(ut.UpdatedAt)
+	}
+	if ut.ResolvedAtEpoch == 0 && ut.ResolvedAt != nil {
+		ut.ResolvedAtEpoch = utils.TimeToMillis(*ut.ResolvedAt)
+	}
 
 	type Alias UnmatchedTransaction
 	return json.Marshal(&struct {
@@ -336,3 +466,226 @@ func (ut *UnmatchedTransaction) MarshalJSON() ([]byte, error) {
 		CreatedAt: ut.CreatedAtEpoch,
 	})
 }
+
+// ExceptionComment is a note left on an unmatched transaction while working it
+type ExceptionComment struct {
+	ID          string    `json:"id" db:"id"`
+	ExceptionID string    `json:"exception_id" db:"unmatched_transaction_id"`
+	TenantID    string    `json:"tenant_id" db:"tenant_id"`
+	UserID      string    `json:"user_id" db:"user_id"`
+	Body        string    `json:"body" db:"body"`
+	CreatedAt   time.Time `json:"-" db:"created_at"`
+
+	CreatedAtEpoch int64 `json:"created_at" db:"-"`
+}
+
+// MarshalJSON customizes JSON serialization for ExceptionComment
+func (c *ExceptionComment) MarshalJSON() ([]byte, error) {
+	// Ensure epoch timestamp is set
+	if c.CreatedAtEpoch == 0 && !c.CreatedAt.IsZero() {
+		c.CreatedAtEpoch = utils.TimeToMillis(c.CreatedAt)
+	}
+
+	type Alias ExceptionComment
+	return json.Marshal(&struct {
+		*Alias
+		CreatedAt int64 `json:"created_at"`
+	}{
+		Alias:     (*Alias)(c),
+		CreatedAt: c.CreatedAtEpoch,
+	})
+}
+
+// ExceptionUpdate changes how an unmatched transaction is being worked. Unset fields are left as
+// they are; an empty assignee unassigns it.
+type ExceptionUpdate struct {
+	Status     *string `json:"status"`
+	ReasonCode *string `json:"reason_code"`
+	AssigneeID *string `json:"assignee_id"`
+}
+
+// ExceptionAgeBucket is a range of exception ages in whole days. MaxDays is negative for the
+// open-ended oldest bucket.
+type ExceptionAgeBucket struct {
+	Label   string `json:"label"`
+	MinDays int    `json:"min_days"`
+	MaxDays int    `json:"max_days"`
+}
+
+// ExceptionAgeBuckets are the age ranges exceptions are listed and summarized by
+var ExceptionAgeBuckets = []ExceptionAgeBucket{
+	{Label: "0-7", MinDays: 0, MaxDays: 7},
+	{Label: "8-30", MinDays: 8, MaxDays: 30},
+	{Label: "31-60", MinDays: 31, MaxDays: 60},
+	{Label: "61-90", MinDays: 61, MaxDays: 90},
+	{Label: "90+", MinDays: 91, MaxDays: -1},
+}
+
+// ExceptionFilter narrows a listing of the exceptions of a tenant. Empty fields match anything.
+type ExceptionFilter struct {
+	TenantID   string
+	MatchSetID string
+	Status     string
+	ReasonCode string
+	AssigneeID string
+	Unassigned bool                // Only exceptions nobody is assigned to
+	Age        *ExceptionAgeBucket // Only exceptions whose age at Now falls in the bucket
+	Now        time.Time
+}
+
+// ExceptionCount is the number of exceptions sharing a status, reason code and age
+type ExceptionCount struct {
+	Status     string
+	ReasonCode string
+	AgeDays    int
+	Count      int
+}
+
+// ExceptionSummary counts the exceptions of a tenant or match set. Outstanding exceptions are the
+// open and investigating ones the month-end close waits on.
+type ExceptionSummary struct {
+	Outstanding         int            `json:"outstanding"`
+	ByStatus            map[string]int `json:"by_status"`
+	OutstandingByAge    map[string]int `json:"outstanding_by_age"`
+	OutstandingByReason map[string]int `json:"outstanding_by_reason"`
+}
+
+// Adjustment is a synthetic transaction posted into a match group to close a small difference
+// between its sides, such as rounding or a known bank fee. Its transaction lives in the tenant's
+// adjustment data source; unmatching the group voids it and removes the transaction.
+type Adjustment struct {
+	ID            string     `json:"id" db:"id"`
+	TenantID      string     `json:"tenant_id" db:"tenant_id"`
+	MatchID       string     `json:"match_id" db:"match_id"`
+	MatchSetID    string     `json:"match_set_id" db:"match_set_id"`
+	TransactionID string     `json:"transaction_id" db:"transaction_id"`
+	Amount        float64    `json:"amount" db:"amount"` // Added to the side other than the match set's first data source
+	Currency      string     `json:"currency" db:"currency"`
+	Reason        string     `json:"reason" db:"reason"`
+	GLAccount     string     `json:"gl_account" db:"gl_account"` // General ledger account the difference is booked to
+	CreatedBy     string     `json:"created_by" db:"created_by"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	VoidedBy      string     `json:"voided_by,omitempty" db:"voided_by"`
+	VoidedAt      *time.Time `json:"voided_at,omitempty" db:"voided_at"`
+}
+
+// AdjustmentSettings holds how a tenant posts adjustments. No adjustment may exceed the write-off
+// limit, read in the currency of the group it closes.
+type AdjustmentSettings struct {
+	TenantID      string    `json:"tenant_id" db:"tenant_id"`
+	DataSourceID  string    `json:"data_source_id" db:"data_source_id"` // Holds the adjustment transactions
+	WriteOffLimit float64   `json:"write_off_limit" db:"write_off_limit"`
+	UpdatedBy     string    `json:"updated_by" db:"updated_by"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Fields transactions can be sorted by
+const (
+	TransactionSortDate        = "transaction_date"
+	TransactionSortPostDate    = "post_date"
+	TransactionSortAmount      = "amount"
+	TransactionSortReference   = "reference"
+	TransactionSortDescription = "description"
+	TransactionSortStatus      = "status"
+	TransactionSortCreatedAt   = "created_at"
+)
+
+// TransactionSort orders transactions by one field
+type TransactionSort struct {
+	Field string
+	Desc  bool
+}
+
+// TransactionFilter narrows a search of the transactions of a tenant. Empty fields match anything.
+// Results are ordered by Sort, then by ID, and paged with the opaque Cursor of the previous page.
+type TransactionFilter struct {
+	TenantID      string
+	DateFrom      *time.Time // Transaction date, inclusive
+	DateTo        *time.Time // Transaction date, inclusive
+	AmountMin     *float64
+	AmountMax     *float64
+	Statuses      []string
+	DataSourceIDs []string
+	Text          string            // Contained in the reference or description, ignoring case
+	CustomFields  map[string]string // Custom schema field values, all of which must equal
+	Sort          []TransactionSort
+	Cursor        string
+	Limit         int
+}
+
+// TransactionPage is a page of transaction search results. NextCursor is empty on the last page.
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"nextCursor,omitempty"`
+	Limit        int           `json:"limit"`
+}
+
+// TextTerm is a term of a full-text query: a word, or a phrase of words that must follow each
+// other. The last word of a prefix term matches any word it starts.
+type TextTerm struct {
+	Words  []string // Lower case letters and digits
+	Prefix bool
+}
+
+// TextSearch is a full-text search of the references and descriptions of the transactions of a
+// tenant. Every term must match. A fuzzy search also lets a single word term match words spelled
+// alike. Results are ordered by rank, then newest first.
+type TextSearch struct {
+	TenantID      string
+	Terms         []TextTerm
+	Fuzzy         bool
+	Statuses      []string
+	DataSourceIDs []string
+	Limit         int
+	Offset        int
+}
+
+// Fields of a transaction full-text search looks in
+const (
+	TextFieldReference   = "reference"
+	TextFieldDescription = "description"
+)
+
+// TextHighlight marks a matching word of a field, from its Start to its End in characters
+type TextHighlight struct {
+	Field string `json:"field"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// TextSearchHit is a transaction a full-text search found, with the words that matched
+type TextSearchHit struct {
+	Transaction Transaction     `json:"transaction"`
+	Rank        float64         `json:"rank"`
+	Highlights  []TextHighlight `json:"highlights"`
+}
+
+// TextSearchPage is a page of full-text search results
+type TextSearchPage struct {
+	Hits   []TextSearchHit `json:"hits"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+// Fields matches can be sorted by
+const (
+	MatchSortCreatedAt = "created_at"
+	MatchSortStatus    = "match_status"
+	MatchSortScore     = "match_score"
+)
+
+// MatchFilter narrows a search of transaction matches. Empty fields match anything. Results are
+// ordered by SortBy, newest first by default, then by ID.
+type MatchFilter struct {
+	TenantID   string
+	MatchSetID string
+	Status     string
+	MatchType  string
+	MatchedBy  string
+	ApprovedBy string
+	DateFrom   *time.Time // Creation time, inclusive
+	DateTo     *time.Time // Creation time, inclusive
+	SortBy     string
+	SortDesc   bool
+}
@@ -0,0 +1,576 @@
+package repository
+
+import (
+	"backend/internal/db"
+	"backend/internal/models"
+	"database/sql"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAdjustmentNotFound         = errors.New("adjustment not found")
+	ErrAdjustmentSettingsNotFound = errors.New("adjustment settings not found")
+)
+
+// AdjustmentRepository defines operations for managing adjustments and the settings they follow
+type AdjustmentRepository interface {
+	GetSettings(tenantID string) (*models.AdjustmentSettings, error)
+	SaveSettings(settings *models.AdjustmentSettings) error
+	CreateAdjustedMatchGroup(match *models.TransactionMatch, transactionIDs []string, transaction *models.Transaction, adjustment *models.Adjustment) error
+	AdjustMatchGroup(match *models.TransactionMatch, expected []string, transaction *models.Transaction, adjustment *models.Adjustment) error
+	GetAdjustmentsByMatch(tenantID, matchID string) ([]models.Adjustment, error)
+	GetAdjustmentsByMatchSet(tenantID, matchSetID string) ([]models.Adjustment, error)
+	GetAdjustmentsByTenant(tenantID string, limit, offset int) ([]models.Adjustment, int, error)
+	DissolveAdjustedMatchGroup(tenantID, matchID string, expected []string, userID string) error
+	RejectAdjustedMatchGroup(tenantID, matchID, userID, reason string) error
+	RemoveFromAdjustedMatchGroup(tenantID, matchID, transactionID string, expected []string, userID string) error
+}
+
+// PostgresAdjustmentRepository implements AdjustmentRepository for PostgreSQL
+type PostgresAdjustmentRepository struct {
+	db *sql.DB
+}
+
+// NewAdjustmentRepository creates a new adjustment repository. The mock repository posts
+// adjustment transactions into the given transaction and match repositories.
+func NewAdjustmentRepository(transactionRepo TransactionRepository, matchRepo MatchRepository) AdjustmentRepository {
+	if db.DB == nil {
+		// Return a mock repository for development
+		return &MockAdjustmentRepository{
+			transactionRepo: transactionRepo,
+			matchRepo:       matchRepo,
+			settings:        make(map[string]*models.AdjustmentSettings),
+		}
+	}
+	return &PostgresAdjustmentRepository{
+		db: db.DB,
+	}
+}
+
+// adjustmentColumns is the column list scanned by scanAdjustment
+const adjustmentColumns = `
+	id, tenant_id, COALESCE(match_id::text, ''), COALESCE(match_set_id::text, ''), transaction_id,
+	amount, currency, reason, gl_account, created_by, created_at, COALESCE(voided_by::text, ''), voided_at
+`
+
+// scanAdjustment scans an adjustment selected with adjustmentColumns
+func scanAdjustment(row rowScanner) (*models.Adjustment, error) {
+	var adjustment models.Adjustment
+	var voidedAt sql.NullTime
+	err := row.Scan(
+		&adjustment.ID,
+		&adjustment.TenantID,
+		&adjustment.MatchID,
+		&adjustment.MatchSetID,
+		&adjustment.TransactionID,
+		&adjustment.Amount,
+		&adjustment.Currency,
+		&adjustment.Reason,
+		&adjustment.GLAccount,
+		&adjustment.CreatedBy,
+		&adjustment.CreatedAt,
+		&adjustment.VoidedBy,
+		&voidedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if voidedAt.Valid {
+		adjustment.VoidedAt = &voidedAt.Time
+	}
+	return &adjustment, nil
+}
+
+// GetSettings retrieves the adjustment settings of a tenant
+func (r *PostgresAdjustmentRepository) GetSettings(tenantID string) (*models.AdjustmentSettings, error) {
+	query := `
+		SELECT tenant_id, data_source_id, write_off_limit, updated_by, updated_at
+		FROM adjustment_settings
+		WHERE tenant_id = $1
+	`
+
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var settings models.AdjustmentSettings
+	err = tx.QueryRow(query, tenantID).Scan(
+		&settings.TenantID,
+		&settings.DataSourceID,
+		&settings.WriteOffLimit,
+		&settings.UpdatedBy,
+		&settings.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrAdjustmentSettingsNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+// SaveSettings creates or replaces the adjustment settings of a tenant. The first save creates
+// the tenant's adjustment data source.
+func (r *PostgresAdjustmentRepository) SaveSettings(settings *models.AdjustmentSettings) error {
+	tx, err := beginTenant(r.db, settings.TenantID)
+	if err != nil {
+		return err
+	}
+
+	var dataSourceID string
+	err = tx.QueryRow(
+		"SELECT data_source_id FROM adjustment_settings WHERE tenant_id = $1 FOR UPDATE",
+		settings.TenantID,
+	).Scan(&dataSourceID)
+	if err == sql.ErrNoRows {
+		err = tx.QueryRow(`
+			INSERT INTO data_sources (name, description, tenant_id)
+			VALUES ('Adjustments ' || $1, 'Adjustment and write-off entries', $1)
+			RETURNING id
+		`, settings.TenantID).Scan(&dataSourceID)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO adjustment_settings (tenant_id, data_source_id, write_off_limit, updated_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			write_off_limit = EXCLUDED.write_off_limit,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW() AT TIME ZONE 'UTC'
+		RETURNING updated_at
+	`, settings.TenantID, dataSourceID, settings.WriteOffLimit, settings.UpdatedBy).Scan(&settings.UpdatedAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	settings.DataSourceID = dataSourceID
+	return tx.Commit()
+}
+
+// CreateAdjustedMatchGroup creates a match for a group of transactions like CreateMatchGroup and
+// closes it with an adjustment transaction, all in a single database transaction
+func (r *PostgresAdjustmentRepository) CreateAdjustedMatchGroup(match *models.TransactionMatch, transactionIDs []string, transaction *models.Transaction, adjustment *models.Adjustment) error {
+	tx, err := beginTenant(r.db, match.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertMatchGroup(tx, match, transactionIDs); err != nil {
+		return err
+	}
+
+	if err := insertAdjustment(tx, match, transactionIDs, transaction, adjustment); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AdjustMatchGroup adds an adjustment transaction to a match group and records the adjustment in
+// a single database transaction. If the group no longer holds the expected transactions nothing
+// is written and ErrMatchGroupChanged is returned.
+func (r *PostgresAdjustmentRepository) AdjustMatchGroup(match *models.TransactionMatch, expected []string, transaction *models.Transaction, adjustment *models.Adjustment) error {
+	tx, err := beginTenant(r.db, match.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertAdjustment(tx, match, expected, transaction, adjustment); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertAdjustment writes an adjustment transaction, adds it to a match group and records the
+// adjustment within a database transaction
+func insertAdjustment(tx *sql.Tx, match *models.TransactionMatch, expected []string, transaction *models.Transaction, adjustment *models.Adjustment) error {
+	if err := insertTransaction(tx, transaction); err != nil {
+		return err
+	}
+
+	if err := addToMatchGroup(tx, match, transaction.ID, expected); err != nil {
+		return err
+	}
+
+	adjustment.MatchID = match.ID
+	adjustment.TransactionID = transaction.ID
+	return tx.QueryRow(`
+		INSERT INTO adjustments (
+			tenant_id, match_id, match_set_id, transaction_id, amount, currency, reason, gl_account, created_by
+		) VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`,
+		adjustment.TenantID,
+		adjustment.MatchID,
+		adjustment.MatchSetID,
+		adjustment.TransactionID,
+		adjustment.Amount,
+		adjustment.Currency,
+		adjustment.Reason,
+		adjustment.GLAccount,
+		adjustment.CreatedBy,
+	).Scan(&adjustment.ID, &adjustment.CreatedAt)
+}
+
+// GetAdjustmentsByMatch retrieves the adjustments posted into a match group, voided ones included
+func (r *PostgresAdjustmentRepository) GetAdjustmentsByMatch(tenantID, matchID string) ([]models.Adjustment, error) {
+	query := "SELECT " + adjustmentColumns + " FROM adjustments WHERE match_id = $1 ORDER BY created_at, id"
+	return r.queryAdjustments(tenantID, query, matchID)
+}
+
+// GetAdjustmentsByMatchSet retrieves the adjustments posted into a match set's groups, voided ones included
+func (r *PostgresAdjustmentRepository) GetAdjustmentsByMatchSet(tenantID, matchSetID string) ([]models.Adjustment, error) {
+	query := "SELECT " + adjustmentColumns + " FROM adjustments WHERE match_set_id = $1 ORDER BY created_at, id"
+	return r.queryAdjustments(tenantID, query, matchSetID)
+}
+
+// queryAdjustments runs a query selecting adjustmentColumns in a tenant's session
+func (r *PostgresAdjustmentRepository) queryAdjustments(tenantID, query string, args ...interface{}) ([]models.Adjustment, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var adjustments []models.Adjustment
+	for rows.Next() {
+		adjustment, err := scanAdjustment(rows)
+		if err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, *adjustment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return adjustments, nil
+}
+
+// GetAdjustmentsByTenant retrieves a page of a tenant's adjustments, newest first, and the total count
+func (r *PostgresAdjustmentRepository) GetAdjustmentsByTenant(tenantID string, limit, offset int) ([]models.Adjustment, int, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	var total int
+	err = tx.QueryRow("SELECT COUNT(*) FROM adjustments WHERE tenant_id = $1", tenantID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT " + adjustmentColumns + `
+		FROM adjustments
+		WHERE tenant_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := tx.Query(query, tenantID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var adjustments []models.Adjustment
+	for rows.Next() {
+		adjustment, err := scanAdjustment(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		adjustments = append(adjustments, *adjustment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return adjustments, total, nil
+}
+
+// DissolveAdjustedMatchGroup unmatches a group like DissolveMatchGroup and voids the adjustments
+// standing in it, all in a single database transaction
+func (r *PostgresAdjustmentRepository) DissolveAdjustedMatchGroup(tenantID, matchID string, expected []string, userID string) error {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := dissolveMatchGroup(tx, matchID, expected); err != nil {
+		return err
+	}
+
+	if err := voidAdjustments(tx, matchID, "", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RejectAdjustedMatchGroup rejects a pending match like RejectMatchGroup and voids the adjustments
+// standing in its group, all in a single database transaction
+func (r *PostgresAdjustmentRepository) RejectAdjustedMatchGroup(tenantID, matchID, userID, reason string) error {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := rejectMatchGroup(tx, matchID, userID, reason); err != nil {
+		return err
+	}
+
+	if err := voidAdjustments(tx, matchID, "", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RemoveFromAdjustedMatchGroup takes a transaction out of a match group like
+// RemoveTransactionFromMatchGroup and, when it is an adjustment's transaction, voids the
+// adjustment in the same database transaction
+func (r *PostgresAdjustmentRepository) RemoveFromAdjustedMatchGroup(tenantID, matchID, transactionID string, expected []string, userID string) error {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := removeFromMatchGroup(tx, matchID, transactionID, expected); err != nil {
+		return err
+	}
+
+	if err := voidAdjustments(tx, matchID, transactionID, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// voidAdjustments marks the adjustments still standing in a match group as voided by a user, only
+// the one posted as transactionID when it is set, within a database transaction. Their
+// transactions are kept with the Voided status so they are never matched again.
+func voidAdjustments(tx *sql.Tx, matchID, transactionID, userID string) error {
+	_, err := tx.Exec(`
+		WITH voided AS (
+			UPDATE adjustments
+			SET voided_by = $3, voided_at = NOW() AT TIME ZONE 'UTC'
+			WHERE match_id = $1 AND voided_at IS NULL AND ($2 = '' OR transaction_id::text = $2)
+			RETURNING transaction_id
+		)
+		UPDATE transactions
+		SET status = 'Voided', match_id = NULL, updated_at = NOW()
+		WHERE id IN (SELECT transaction_id FROM voided)
+	`, matchID, transactionID, userID)
+	return err
+}
+
+// MockAdjustmentRepository is a mock implementation for development
+type MockAdjustmentRepository struct {
+	mu              sync.Mutex
+	transactionRepo TransactionRepository
+	matchRepo       MatchRepository
+	settings        map[string]*models.AdjustmentSettings // tenant ID -> settings
+	adjustments     []models.Adjustment
+}
+
+// GetSettings retrieves the adjustment settings of a tenant from the mock repository
+func (r *MockAdjustmentRepository) GetSettings(tenantID string) (*models.AdjustmentSettings, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	settings, exists := r.settings[tenantID]
+	if !exists {
+		return nil, ErrAdjustmentSettingsNotFound
+	}
+	copied := *settings
+	return &copied, nil
+}
+
+// SaveSettings creates or replaces the adjustment settings of a tenant in the mock repository
+func (r *MockAdjustmentRepository) SaveSettings(settings *models.AdjustmentSettings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	settings.DataSourceID = uuid.New().String()
+	if existing, exists := r.settings[settings.TenantID]; exists {
+		settings.DataSourceID = existing.DataSourceID
+	}
+	settings.UpdatedAt = time.Now()
+
+	stored := *settings
+	r.settings[settings.TenantID] = &stored
+	return nil
+}
+
+// CreateAdjustedMatchGroup creates an adjusted match group in the mock repositories, dissolving
+// the group again if the adjustment cannot be posted
+func (r *MockAdjustmentRepository) CreateAdjustedMatchGroup(match *models.TransactionMatch, transactionIDs []string, transaction *models.Transaction, adjustment *models.Adjustment) error {
+	if err := r.matchRepo.CreateMatchGroup(match, transactionIDs); err != nil {
+		return err
+	}
+
+	if err := r.AdjustMatchGroup(match, transactionIDs, transaction, adjustment); err != nil {
+		r.matchRepo.DissolveMatchGroup(match.TenantID, match.ID, transactionIDs)
+		return err
+	}
+	return nil
+}
+
+// AdjustMatchGroup adds an adjustment transaction to a match group and records the adjustment in
+// the mock repositories, removing the transaction again if the group refuses it
+func (r *MockAdjustmentRepository) AdjustMatchGroup(match *models.TransactionMatch, expected []string, transaction *models.Transaction, adjustment *models.Adjustment) error {
+	if err := r.transactionRepo.CreateTransaction(transaction); err != nil {
+		return err
+	}
+
+	if err := r.matchRepo.AddTransactionToMatchGroup(match, transaction.ID, expected); err != nil {
+		r.transactionRepo.DeleteTransaction(transaction.TenantID, transaction.ID)
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	adjustment.ID = uuid.New().String()
+	adjustment.MatchID = match.ID
+	adjustment.TransactionID = transaction.ID
+	adjustment.CreatedAt = time.Now()
+	r.adjustments = append(r.adjustments, *adjustment)
+	return nil
+}
+
+// GetAdjustmentsByMatch retrieves the adjustments posted into a match group from the mock repository
+func (r *MockAdjustmentRepository) GetAdjustmentsByMatch(tenantID, matchID string) ([]models.Adjustment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var adjustments []models.Adjustment
+	for _, adjustment := range r.adjustments {
+		if adjustment.TenantID == tenantID && adjustment.MatchID == matchID {
+			adjustments = append(adjustments, adjustment)
+		}
+	}
+	return adjustments, nil
+}
+
+// GetAdjustmentsByMatchSet retrieves the adjustments posted into a match set's groups from the mock repository
+func (r *MockAdjustmentRepository) GetAdjustmentsByMatchSet(tenantID, matchSetID string) ([]models.Adjustment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var adjustments []models.Adjustment
+	for _, adjustment := range r.adjustments {
+		if adjustment.TenantID == tenantID && adjustment.MatchSetID == matchSetID {
+			adjustments = append(adjustments, adjustment)
+		}
+	}
+	return adjustments, nil
+}
+
+// GetAdjustmentsByTenant retrieves a page of a tenant's adjustments from the mock repository
+func (r *MockAdjustmentRepository) GetAdjustmentsByTenant(tenantID string, limit, offset int) ([]models.Adjustment, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var adjustments []models.Adjustment
+	for _, adjustment := range r.adjustments {
+		if adjustment.TenantID == tenantID {
+			adjustments = append(adjustments, adjustment)
+		}
+	}
+	sort.SliceStable(adjustments, func(i, j int) bool {
+		return adjustments[i].CreatedAt.After(adjustments[j].CreatedAt)
+	})
+
+	total := len(adjustments)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return adjustments[offset:end], total, nil
+}
+
+// DissolveAdjustedMatchGroup unmatches a group and voids its adjustments in the mock repositories
+func (r *MockAdjustmentRepository) DissolveAdjustedMatchGroup(tenantID, matchID string, expected []string, userID string) error {
+	if err := r.matchRepo.DissolveMatchGroup(tenantID, matchID, expected); err != nil {
+		return err
+	}
+	r.voidAdjustments(tenantID, matchID, "", userID)
+	return nil
+}
+
+// RejectAdjustedMatchGroup rejects a pending match and voids its adjustments in the mock repositories
+func (r *MockAdjustmentRepository) RejectAdjustedMatchGroup(tenantID, matchID, userID, reason string) error {
+	if err := r.matchRepo.RejectMatchGroup(tenantID, matchID, userID, reason); err != nil {
+		return err
+	}
+	r.voidAdjustments(tenantID, matchID, "", userID)
+	return nil
+}
+
+// RemoveFromAdjustedMatchGroup takes a transaction out of a match group in the mock repositories,
+// voiding the adjustment posted as that transaction
+func (r *MockAdjustmentRepository) RemoveFromAdjustedMatchGroup(tenantID, matchID, transactionID string, expected []string, userID string) error {
+	if err := r.matchRepo.RemoveTransactionFromMatchGroup(tenantID, matchID, transactionID, expected); err != nil {
+		return err
+	}
+	r.voidAdjustments(tenantID, matchID, transactionID, userID)
+	return nil
+}
+
+// voidAdjustments voids the standing adjustments of a match group in the mock repository, only
+// the one posted as transactionID when it is set, and marks their transactions as voided
+func (r *MockAdjustmentRepository) voidAdjustments(tenantID, matchID, transactionID, userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.adjustments {
+		adjustment := &r.adjustments[i]
+		if adjustment.TenantID != tenantID || adjustment.MatchID != matchID || adjustment.VoidedAt != nil {
+			continue
+		}
+		if transactionID != "" && adjustment.TransactionID != transactionID {
+			continue
+		}
+
+		now := time.Now()
+		adjustment.VoidedBy = userID
+		adjustment.VoidedAt = &now
+		if transactions, ok := r.transactionRepo.(*MockTransactionRepository); ok {
+			transactions.setStatus(tenantID, adjustment.TransactionID, "Voided")
+		}
+	}
+}
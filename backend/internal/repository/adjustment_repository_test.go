@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"backend/internal/models"
+	"backend/internal/testutil"
+	"testing"
+	"time"
+)
+
+func TestAdjustmentRepository_PostsAdjustmentsAtomically(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	if _, err := db.Exec(`TRUNCATE tenants CASCADE`); err != nil {
+		t.Fatalf("Failed to clear tenants: %v", err)
+	}
+
+	user := &models.User{Email: "adjustments@example.com", Name: "Approver", PasswordHash: "hash", AuthProvider: "local"}
+	if err := (&PostgresUserRepository{db: db}).Create(user); err != nil {
+		t.Fatalf("Create() user error = %v", err)
+	}
+
+	var tenantID, matchSetID string
+	if err := db.QueryRow("INSERT INTO tenants (name) VALUES ('Acme') RETURNING id").Scan(&tenantID); err != nil {
+		t.Fatalf("Failed to create tenant: %v", err)
+	}
+	tx, err := beginTenant(db, tenantID)
+	if err != nil {
+		t.Fatalf("beginTenant() error = %v", err)
+	}
+	if err := tx.QueryRow("INSERT INTO match_sets (name, tenant_id, created_by) VALUES ('Bank', $1, $2) RETURNING id", tenantID, user.ID).Scan(&matchSetID); err != nil {
+		tx.Rollback()
+		t.Fatalf("Failed to create match set: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	source := &models.DataSource{TenantID: tenantID, Name: "Bank"}
+	if err := (&PostgresDataSourceRepository{db: db}).CreateDataSource(source); err != nil {
+		t.Fatalf("CreateDataSource() error = %v", err)
+	}
+
+	transactions := &PostgresTransactionRepository{db: db}
+	newTransaction := func(amount float64) *models.Transaction {
+		return &models.Transaction{
+			TenantID:        tenantID,
+			DataSourceID:    source.ID,
+			TransactionDate: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+			Amount:          amount,
+			Currency:        "USD",
+			CreatedBy:       user.ID,
+		}
+	}
+	var ids []string
+	for _, amount := range []float64{100, -97.5, 40} {
+		transaction := newTransaction(amount)
+		if err := transactions.CreateTransaction(transaction); err != nil {
+			t.Fatalf("CreateTransaction() error = %v", err)
+		}
+		ids = append(ids, transaction.ID)
+	}
+
+	matches := &PostgresMatchRepository{db: db}
+	adjustments := &PostgresAdjustmentRepository{db: db}
+	newAdjustment := func(amount float64) *models.Adjustment {
+		return &models.Adjustment{TenantID: tenantID, MatchSetID: matchSetID, Amount: amount, Currency: "USD", Reason: "Bank fee", GLAccount: "6100", CreatedBy: user.ID}
+	}
+	newMatch := func() *models.TransactionMatch {
+		return &models.TransactionMatch{MatchStatus: "Pending", MatchType: "Manual", MatchedBy: user.ID, TenantID: tenantID, MatchSetID: matchSetID, MatchScore: 1}
+	}
+
+	match := newMatch()
+	fee := newTransaction(-2.5)
+	if err := adjustments.CreateAdjustedMatchGroup(match, ids[:2], fee, newAdjustment(-2.5)); err != nil {
+		t.Fatalf("CreateAdjustedMatchGroup() error = %v", err)
+	}
+	if members, _ := matches.GetMatchGroup(tenantID, match.ID); len(members) != 3 {
+		t.Errorf("adjusted group = %v, want the 2 transactions and the adjustment", members)
+	}
+
+	// Refused adjustments leave neither a transaction nor an adjustment behind
+	stale := newTransaction(-1)
+	if err := adjustments.AdjustMatchGroup(match, ids[:2], stale, newAdjustment(-1)); err != ErrMatchGroupChanged {
+		t.Errorf("AdjustMatchGroup() on a stale group error = %v, want %v", err, ErrMatchGroupChanged)
+	}
+	taken := newTransaction(-1)
+	if err := adjustments.CreateAdjustedMatchGroup(newMatch(), []string{ids[1], ids[2]}, taken, newAdjustment(-1)); err != ErrTransactionAlreadyMatched {
+		t.Errorf("CreateAdjustedMatchGroup() with a matched transaction error = %v, want %v", err, ErrTransactionAlreadyMatched)
+	}
+
+	for _, transaction := range []*models.Transaction{stale, taken} {
+		if _, err := transactions.GetTransactionByID(tenantID, transaction.ID); err != ErrTransactionNotFound {
+			t.Errorf("GetTransactionByID() of a refused adjustment error = %v, want %v", err, ErrTransactionNotFound)
+		}
+	}
+	if posted, _ := adjustments.GetAdjustmentsByMatchSet(tenantID, matchSetID); len(posted) != 1 {
+		t.Errorf("adjustments = %d, want 1", len(posted))
+	}
+	if unmatched, err := transactions.GetTransactionByID(tenantID, ids[2]); err != nil || unmatched.Status != "Unmatched" {
+		t.Errorf("GetTransactionByID() = %+v, %v, want the transaction left unmatched", unmatched, err)
+	}
+}
@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"backend/internal/db"
+	"backend/internal/models"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrApprovalPolicyNotFound = errors.New("approval policy not found")
+	ErrApprovalPolicyExists   = errors.New("approval policy with this name already exists for this match set")
+)
+
+// ApprovalPolicyRepository defines operations for managing auto-approval policies
+type ApprovalPolicyRepository interface {
+	CreatePolicy(policy *models.AutoApprovalPolicy) error
+	GetPolicyByID(tenantID, id string) (*models.AutoApprovalPolicy, error)
+	GetPoliciesByMatchSet(tenantID, matchSetID string) ([]models.AutoApprovalPolicy, error)
+	UpdatePolicy(policy *models.AutoApprovalPolicy) error
+	DeletePolicy(tenantID, id string) error
+}
+
+// PostgresApprovalPolicyRepository implements ApprovalPolicyRepository for PostgreSQL
+type PostgresApprovalPolicyRepository struct {
+	db *sql.DB
+}
+
+// NewApprovalPolicyRepository creates a new approval policy repository
+func NewApprovalPolicyRepository() ApprovalPolicyRepository {
+	if db.DB == nil {
+		// Return a mock repository for development
+		return &MockApprovalPolicyRepository{
+			policies: make(map[string]*models.AutoApprovalPolicy),
+		}
+	}
+	return &PostgresApprovalPolicyRepository{
+		db: db.DB,
+	}
+}
+
+// approvalPolicyColumns is the column list scanned by scanApprovalPolicy
+const approvalPolicyColumns = `
+	id, match_set_id, tenant_id, name, COALESCE(rule_id::text, ''), min_score, max_amount,
+	one_to_one_only, active, created_by, created_at, updated_at
+`
+
+// scanApprovalPolicy scans a policy selected with approvalPolicyColumns
+func scanApprovalPolicy(row rowScanner) (*models.AutoApprovalPolicy, error) {
+	var policy models.AutoApprovalPolicy
+	err := row.Scan(
+		&policy.ID,
+		&policy.MatchSetID,
+		&policy.TenantID,
+		&policy.Name,
+		&policy.RuleID,
+		&policy.MinScore,
+		&policy.MaxAmount,
+		&policy.OneToOneOnly,
+		&policy.Active,
+		&policy.CreatedBy,
+		&policy.CreatedAt,
+		&policy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// policyNameTaken reports whether another policy of the match set already uses a name
+func policyNameTaken(q querier, policy *models.AutoApprovalPolicy) (bool, error) {
+	var exists bool
+	err := q.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM auto_approval_policies WHERE match_set_id = $1 AND name = $2 AND id::text <> $3)",
+		policy.MatchSetID, policy.Name, policy.ID,
+	).Scan(&exists)
+	return exists, err
+}
+
+// CreatePolicy creates a new auto-approval policy
+func (r *PostgresApprovalPolicyRepository) CreatePolicy(policy *models.AutoApprovalPolicy) error {
+	tx, err := beginTenant(r.db, policy.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	exists, err := policyNameTaken(tx, policy)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrApprovalPolicyExists
+	}
+
+	var ruleIDParam interface{} = nil
+	if policy.RuleID != "" {
+		ruleIDParam = policy.RuleID
+	}
+
+	query := `
+		INSERT INTO auto_approval_policies (
+			match_set_id, tenant_id, name, rule_id, min_score, max_amount, one_to_one_only, active, created_by
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		) RETURNING id, created_at, updated_at
+	`
+
+	err = tx.QueryRow(
+		query,
+		policy.MatchSetID,
+		policy.TenantID,
+		policy.Name,
+		ruleIDParam,
+		policy.MinScore,
+		policy.MaxAmount,
+		policy.OneToOneOnly,
+		policy.Active,
+		policy.CreatedBy,
+	).Scan(&policy.ID, &policy.CreatedAt, &policy.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetPolicyByID retrieves an auto-approval policy of a tenant by ID
+func (r *PostgresApprovalPolicyRepository) GetPolicyByID(tenantID, id string) (*models.AutoApprovalPolicy, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT " + approvalPolicyColumns + " FROM auto_approval_policies WHERE id = $1"
+
+	policy, err := scanApprovalPolicy(tx.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrApprovalPolicyNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// GetPoliciesByMatchSet retrieves the auto-approval policies of a match set in the order they were created
+func (r *PostgresApprovalPolicyRepository) GetPoliciesByMatchSet(tenantID, matchSetID string) ([]models.AutoApprovalPolicy, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT " + approvalPolicyColumns + " FROM auto_approval_policies WHERE match_set_id = $1 ORDER BY created_at, id"
+
+	rows, err := tx.Query(query, matchSetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []models.AutoApprovalPolicy
+	for rows.Next() {
+		policy, err := scanApprovalPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *policy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// UpdatePolicy updates an auto-approval policy
+func (r *PostgresApprovalPolicyRepository) UpdatePolicy(policy *models.AutoApprovalPolicy) error {
+	tx, err := beginTenant(r.db, policy.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	exists, err := policyNameTaken(tx, policy)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrApprovalPolicyExists
+	}
+
+	var ruleIDParam interface{} = nil
+	if policy.RuleID != "" {
+		ruleIDParam = policy.RuleID
+	}
+
+	query := `
+		UPDATE auto_approval_policies
+		SET name = $1, rule_id = $2, min_score = $3, max_amount = $4, one_to_one_only = $5,
+			active = $6, updated_at = NOW()
+		WHERE id = $7
+		RETURNING updated_at
+	`
+
+	err = tx.QueryRow(
+		query,
+		policy.Name,
+		ruleIDParam,
+		policy.MinScore,
+		policy.MaxAmount,
+		policy.OneToOneOnly,
+		policy.Active,
+		policy.ID,
+	).Scan(&policy.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return ErrApprovalPolicyNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeletePolicy deletes an auto-approval policy
+func (r *PostgresApprovalPolicyRepository) DeletePolicy(tenantID, id string) error {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM auto_approval_policies WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrApprovalPolicyNotFound
+	}
+
+	return tx.Commit()
+}
+
+// MockApprovalPolicyRepository is a mock implementation for development
+type MockApprovalPolicyRepository struct {
+	policies map[string]*models.AutoApprovalPolicy
+}
+
+// nameTaken reports whether another policy of the match set already uses a name
+func (r *MockApprovalPolicyRepository) nameTaken(policy *models.AutoApprovalPolicy) bool {
+	for id, existing := range r.policies {
+		if id != policy.ID && existing.MatchSetID == policy.MatchSetID && existing.Name == policy.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatePolicy creates an auto-approval policy in the mock repository
+func (r *MockApprovalPolicyRepository) CreatePolicy(policy *models.AutoApprovalPolicy) error {
+	if r.nameTaken(policy) {
+		return ErrApprovalPolicyExists
+	}
+
+	if policy.ID == "" {
+		policy.ID = uuid.New().String()
+	}
+	policy.CreatedAt = time.Now()
+	policy.UpdatedAt = policy.CreatedAt
+
+	stored := *policy
+	r.policies[policy.ID] = &stored
+	return nil
+}
+
+// GetPolicyByID retrieves an auto-approval policy from the mock repository
+func (r *MockApprovalPolicyRepository) GetPolicyByID(tenantID, id string) (*models.AutoApprovalPolicy, error) {
+	policy, exists := r.policies[id]
+	if !exists || policy.TenantID != tenantID {
+		return nil, ErrApprovalPolicyNotFound
+	}
+	copied := *policy
+	return &copied, nil
+}
+
+// GetPoliciesByMatchSet retrieves the auto-approval policies of a match set from the mock repository
+func (r *MockApprovalPolicyRepository) GetPoliciesByMatchSet(tenantID, matchSetID string) ([]models.AutoApprovalPolicy, error) {
+	var policies []models.AutoApprovalPolicy
+	for _, policy := range r.policies {
+		if policy.TenantID == tenantID && policy.MatchSetID == matchSetID {
+			policies = append(policies, *policy)
+		}
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		if !policies[i].CreatedAt.Equal(policies[j].CreatedAt) {
+			return policies[i].CreatedAt.Before(policies[j].CreatedAt)
+		}
+		return policies[i].ID < policies[j].ID
+	})
+	return policies, nil
+}
+
+// UpdatePolicy updates an auto-approval policy in the mock repository
+func (r *MockApprovalPolicyRepository) UpdatePolicy(policy *models.AutoApprovalPolicy) error {
+	existing, exists := r.policies[policy.ID]
+	if !exists || existing.TenantID != policy.TenantID {
+		return ErrApprovalPolicyNotFound
+	}
+	if r.nameTaken(policy) {
+		return ErrApprovalPolicyExists
+	}
+
+	policy.CreatedAt = existing.CreatedAt
+	policy.UpdatedAt = time.Now()
+	stored := *policy
+	r.policies[policy.ID] = &stored
+	return nil
+}
+
+// DeletePolicy deletes an auto-approval policy from the mock repository
+func (r *MockApprovalPolicyRepository) DeletePolicy(tenantID, id string) error {
+	if policy, exists := r.policies[id]; !exists || policy.TenantID != tenantID {
+		return ErrApprovalPolicyNotFound
+	}
+	delete(r.policies, id)
+	return nil
+}
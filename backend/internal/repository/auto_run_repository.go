@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"backend/internal/db"
+	"backend/internal/models"
+	"database/sql"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var ErrAutoRunNotFound = errors.New("match set auto-run not found")
+
+// AutoRunRepository defines operations for running match sets when their data sources receive imports
+type AutoRunRepository interface {
+	GetAutoRun(tenantID, matchSetID string) (*models.MatchSetAutoRun, error)
+	SaveAutoRun(autoRun *models.MatchSetAutoRun) error
+	RequestAutoRuns(tenantID string, matchSetIDs []string, now time.Time) (int, error)
+	GetDueAutoRuns(now time.Time, limit int) ([]models.MatchSetAutoRun, error)
+	ClaimAutoRun(tenantID, matchSetID string, due time.Time) (bool, error)
+}
+
+// PostgresAutoRunRepository implements AutoRunRepository for PostgreSQL
+type PostgresAutoRunRepository struct {
+	db *sql.DB
+}
+
+// NewAutoRunRepository creates a new auto-run repository
+func NewAutoRunRepository() AutoRunRepository {
+	if db.DB == nil {
+		// Return a mock repository for development
+		return &MockAutoRunRepository{
+			autoRuns: make(map[string]*models.MatchSetAutoRun),
+		}
+	}
+	return &PostgresAutoRunRepository{
+		db: db.DB,
+	}
+}
+
+// autoRunColumns is the column list scanned by scanAutoRun
+const autoRunColumns = `
+	match_set_id, tenant_id, enabled, debounce_seconds, run_as, due_at, last_import_at, updated_at
+`
+
+// scanAutoRun scans an auto-run selected with autoRunColumns
+func scanAutoRun(row rowScanner) (*models.MatchSetAutoRun, error) {
+	var autoRun models.MatchSetAutoRun
+	var dueAt, lastImportAt sql.NullTime
+	err := row.Scan(
+		&autoRun.MatchSetID,
+		&autoRun.TenantID,
+		&autoRun.Enabled,
+		&autoRun.DebounceSeconds,
+		&autoRun.RunAs,
+		&dueAt,
+		&lastImportAt,
+		&autoRun.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if dueAt.Valid {
+		autoRun.DueAt = &dueAt.Time
+	}
+	if lastImportAt.Valid {
+		autoRun.LastImportAt = &lastImportAt.Time
+	}
+	return &autoRun, nil
+}
+
+// GetAutoRun retrieves the auto-run of a match set
+func (r *PostgresAutoRunRepository) GetAutoRun(tenantID, matchSetID string) (*models.MatchSetAutoRun, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT " + autoRunColumns + " FROM match_set_auto_runs WHERE match_set_id = $1"
+
+	autoRun, err := scanAutoRun(tx.QueryRow(query, matchSetID))
+	if err == sql.ErrNoRows {
+		return nil, ErrAutoRunNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return autoRun, nil
+}
+
+// SaveAutoRun creates or replaces the auto-run of a match set. Disabling it drops a pending run.
+func (r *PostgresAutoRunRepository) SaveAutoRun(autoRun *models.MatchSetAutoRun) error {
+	query := `
+		INSERT INTO match_set_auto_runs (match_set_id, tenant_id, enabled, debounce_seconds, run_as)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (match_set_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			debounce_seconds = EXCLUDED.debounce_seconds,
+			run_as = EXCLUDED.run_as,
+			due_at = CASE WHEN EXCLUDED.enabled THEN match_set_auto_runs.due_at END,
+			updated_at = NOW()
+		RETURNING due_at, last_import_at, updated_at
+	`
+
+	tx, err := beginTenant(r.db, autoRun.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var dueAt, lastImportAt sql.NullTime
+	err = tx.QueryRow(
+		query,
+		autoRun.MatchSetID,
+		autoRun.TenantID,
+		autoRun.Enabled,
+		autoRun.DebounceSeconds,
+		autoRun.RunAs,
+	).Scan(&dueAt, &lastImportAt, &autoRun.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	autoRun.DueAt = nil
+	if dueAt.Valid {
+		autoRun.DueAt = &dueAt.Time
+	}
+	autoRun.LastImportAt = nil
+	if lastImportAt.Valid {
+		autoRun.LastImportAt = &lastImportAt.Time
+	}
+	return tx.Commit()
+}
+
+// RequestAutoRuns records an import into the data sources of match sets. A match set with the
+// auto-run enabled gets a run due after its debounce, unless one is already pending; the imports
+// then share that run. It returns how many match sets have a run pending.
+func (r *PostgresAutoRunRepository) RequestAutoRuns(tenantID string, matchSetIDs []string, now time.Time) (int, error) {
+	if len(matchSetIDs) == 0 {
+		return 0, nil
+	}
+
+	query := `
+		UPDATE match_set_auto_runs
+		SET due_at = COALESCE(due_at, $2::timestamp + debounce_seconds * INTERVAL '1 second'), last_import_at = $2
+		WHERE enabled AND match_set_id::text = ANY($1)
+	`
+
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, pq.Array(matchSetIDs), now.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), tx.Commit()
+}
+
+// GetDueAutoRuns retrieves the enabled auto-runs of every tenant whose pending run is due,
+// earliest first
+func (r *PostgresAutoRunRepository) GetDueAutoRuns(now time.Time, limit int) ([]models.MatchSetAutoRun, error) {
+	query := "SELECT " + autoRunColumns + `
+		FROM match_set_auto_runs
+		WHERE enabled AND due_at <= $1
+		ORDER BY due_at, match_set_id
+		LIMIT $2
+	`
+
+	tx, err := beginAllTenants(r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(query, now.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var autoRuns []models.MatchSetAutoRun
+	for rows.Next() {
+		autoRun, err := scanAutoRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		autoRuns = append(autoRuns, *autoRun)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return autoRuns, nil
+}
+
+// ClaimAutoRun takes the pending run of a match set, so imports after it get a new run. Only one
+// of the instances that saw the run due claims it; the others get false.
+func (r *PostgresAutoRunRepository) ClaimAutoRun(tenantID, matchSetID string, due time.Time) (bool, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"UPDATE match_set_auto_runs SET due_at = NULL WHERE match_set_id = $1 AND enabled AND due_at = $2",
+		matchSetID, due.UTC(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, tx.Commit()
+}
+
+// MockAutoRunRepository is a mock implementation for development. Imports and the queue worker
+// use it from their own goroutines, so it is guarded by a mutex.
+type MockAutoRunRepository struct {
+	mu       sync.Mutex
+	autoRuns map[string]*models.MatchSetAutoRun
+}
+
+// GetAutoRun retrieves the auto-run of a match set from the mock repository
+func (r *MockAutoRunRepository) GetAutoRun(tenantID, matchSetID string) (*models.MatchSetAutoRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	autoRun, exists := r.autoRuns[matchSetID]
+	if !exists || autoRun.TenantID != tenantID {
+		return nil, ErrAutoRunNotFound
+	}
+	copied := *autoRun
+	return &copied, nil
+}
+
+// SaveAutoRun creates or replaces the auto-run of a match set in the mock repository
+func (r *MockAutoRunRepository) SaveAutoRun(autoRun *models.MatchSetAutoRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	autoRun.DueAt, autoRun.LastImportAt = nil, nil
+	if existing, exists := r.autoRuns[autoRun.MatchSetID]; exists {
+		if autoRun.Enabled {
+			autoRun.DueAt = existing.DueAt
+		}
+		autoRun.LastImportAt = existing.LastImportAt
+	}
+	autoRun.UpdatedAt = time.Now()
+
+	stored := *autoRun
+	r.autoRuns[autoRun.MatchSetID] = &stored
+	return nil
+}
+
+// RequestAutoRuns records an import into the data sources of match sets in the mock repository
+func (r *MockAutoRunRepository) RequestAutoRuns(tenantID string, matchSetIDs []string, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pending := 0
+	for _, matchSetID := range matchSetIDs {
+		autoRun, exists := r.autoRuns[matchSetID]
+		if !exists || autoRun.TenantID != tenantID || !autoRun.Enabled {
+			continue
+		}
+		if autoRun.DueAt == nil {
+			due := now.Add(time.Duration(autoRun.DebounceSeconds) * time.Second)
+			autoRun.DueAt = &due
+		}
+		importedAt := now
+		autoRun.LastImportAt = &importedAt
+		pending++
+	}
+	return pending, nil
+}
+
+// GetDueAutoRuns retrieves the due auto-runs from the mock repository
+func (r *MockAutoRunRepository) GetDueAutoRuns(now time.Time, limit int) ([]models.MatchSetAutoRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var autoRuns []models.MatchSetAutoRun
+	for _, autoRun := range r.autoRuns {
+		if autoRun.Enabled && autoRun.DueAt != nil && !autoRun.DueAt.After(now) {
+			autoRuns = append(autoRuns, *autoRun)
+		}
+	}
+	sort.Slice(autoRuns, func(i, j int) bool {
+		if !autoRuns[i].DueAt.Equal(*autoRuns[j].DueAt) {
+			return autoRuns[i].DueAt.Before(*autoRuns[j].DueAt)
+		}
+		return autoRuns[i].MatchSetID < autoRuns[j].MatchSetID
+	})
+	if len(autoRuns) > limit {
+		autoRuns = autoRuns[:limit]
+	}
+	return autoRuns, nil
+}
+
+// ClaimAutoRun takes the pending run of a match set in the mock repository
+func (r *MockAutoRunRepository) ClaimAutoRun(tenantID, matchSetID string, due time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	autoRun, exists := r.autoRuns[matchSetID]
+	if !exists || autoRun.TenantID != tenantID || !autoRun.Enabled || autoRun.DueAt == nil || !autoRun.DueAt.Equal(due) {
+		return false, nil
+	}
+	autoRun.DueAt = nil
+	return true, nil
+}
@@ -6,6 +6,7 @@ import (
 	"backend/internal/utils"
 	"database/sql"
 	"errors"
+	"sort"
 	"strings"
 	"time"
 )
@@ -15,15 +16,17 @@ var (
 	ErrDataSourceExists   = errors.New("data source with this name already exists")
 )
 
-// DataSourceRepository defines operations for managing data sources
+// DataSourceRepository defines operations for managing data sources. Data sources belong to a
+// tenant: they are created and updated in the tenant they carry, every lookup is scoped to one,
+// and names are unique within a tenant.
 type DataSourceRepository interface {
 	CreateDataSource(source *models.DataSource) error
-	GetDataSourceByID(id string) (*models.DataSource, error)
-	GetDataSourceByName(name string) (*models.DataSource, error)
+	GetDataSourceByID(tenantID, id string) (*models.DataSource, error)
+	GetDataSourceByName(tenantID, name string) (*models.DataSource, error)
 	UpdateDataSource(source *models.DataSource) error
-	DeleteDataSource(id string) error
-	GetAllDataSources() ([]models.DataSource, error)
-	SearchDataSources(query string, limit, offset int) ([]models.DataSource, int, error)
+	DeleteDataSource(tenantID, id string) error
+	GetAllDataSources(tenantID string) ([]models.DataSource, error)
+	SearchDataSources(tenantID, query string, limit, offset int) ([]models.DataSource, int, error)
 }
 
 // PostgresDataSourceRepository implements DataSourceRepository for PostgreSQL
@@ -44,11 +47,42 @@ func NewDataSourceRepository() DataSourceRepository {
 	}
 }
 
-// CreateDataSource creates a new data source
+// dataSourceColumns lists the columns of data_sources in the order scanDataSource expects
+const dataSourceColumns = "id, tenant_id, name, description, created_at, updated_at"
+
+// scanDataSource scans a row of dataSourceColumns into a data source
+func scanDataSource(row rowScanner) (*models.DataSource, error) {
+	var source models.DataSource
+	err := row.Scan(
+		&source.ID,
+		&source.TenantID,
+		&source.Name,
+		&source.Description,
+		&source.CreatedAt,
+		&source.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set epoch timestamps
+	source.CreatedAtEpoch = utils.TimeToMillis(source.CreatedAt)
+	source.UpdatedAtEpoch = utils.TimeToMillis(source.UpdatedAt)
+
+	return &source, nil
+}
+
+// CreateDataSource creates a new data source in the tenant it carries
 func (r *PostgresDataSourceRepository) CreateDataSource(source *models.DataSource) error {
-	// Check if data source with the same name already exists
+	tx, err := beginTenant(r.db, source.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Check if data source of the tenant with the same name already exists
 	var exists bool
-	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM data_sources WHERE name = $1)", source.Name).Scan(&exists)
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM data_sources WHERE tenant_id = $1 AND name = $2)", source.TenantID, source.Name).Scan(&exists)
 	if err != nil {
 		return err
 	}
@@ -58,13 +92,14 @@ func (r *PostgresDataSourceRepository) CreateDataSource(source *models.DataSourc
 	}
 
 	query := `
-		INSERT INTO data_sources (name, description)
-		VALUES ($1, $2)
+		INSERT INTO data_sources (tenant_id, name, description)
+		VALUES ($1, $2, $3)
 		RETURNING id, created_at, updated_at
 	`
 
-	err = r.db.QueryRow(
+	err = tx.QueryRow(
 		query,
+		source.TenantID,
 		source.Name,
 		source.Description,
 	).Scan(&source.ID, &source.CreatedAt, &source.UpdatedAt)
@@ -77,58 +112,31 @@ func (r *PostgresDataSourceRepository) CreateDataSource(source *models.DataSourc
 	source.CreatedAtEpoch = utils.TimeToMillis(source.CreatedAt)
 	source.UpdatedAtEpoch = utils.TimeToMillis(source.UpdatedAt)
 
-	return nil
+	return tx.Commit()
 }
 
-// GetDataSourceByID retrieves a data source by ID
-func (r *PostgresDataSourceRepository) GetDataSourceByID(id string) (*models.DataSource, error) {
-	query := `
-		SELECT id, name, description, created_at, updated_at
-		FROM data_sources
-		WHERE id = $1
-	`
-
-	var source models.DataSource
-	err := r.db.QueryRow(query, id).Scan(
-		&source.ID,
-		&source.Name,
-		&source.Description,
-		&source.CreatedAt,
-		&source.UpdatedAt,
-	)
+// GetDataSourceByID retrieves a data source of a tenant by ID
+func (r *PostgresDataSourceRepository) GetDataSourceByID(tenantID, id string) (*models.DataSource, error) {
+	query := "SELECT " + dataSourceColumns + " FROM data_sources WHERE tenant_id = $1 AND id = $2"
+	return r.getDataSource(tenantID, query, tenantID, id)
+}
 
-	if err == sql.ErrNoRows {
-		return nil, ErrDataSourceNotFound
-	}
+// GetDataSourceByName retrieves a data source of a tenant by name
+func (r *PostgresDataSourceRepository) GetDataSourceByName(tenantID, name string) (*models.DataSource, error) {
+	query := "SELECT " + dataSourceColumns + " FROM data_sources WHERE tenant_id = $1 AND name = $2"
+	return r.getDataSource(tenantID, query, tenantID, name)
+}
 
+// getDataSource runs a query selecting dataSourceColumns of a single data source in a tenant's
+// session
+func (r *PostgresDataSourceRepository) getDataSource(tenantID, query string, args ...interface{}) (*models.DataSource, error) {
+	tx, err := beginTenant(r.db, tenantID)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	// Set epoch timestamps
-	source.CreatedAtEpoch = utils.TimeToMillis(source.CreatedAt)
-	source.UpdatedAtEpoch = utils.TimeToMillis(source.UpdatedAt)
-
-	return &source, nil
-}
-
-// GetDataSourceByName retrieves a data source by name
-func (r *PostgresDataSourceRepository) GetDataSourceByName(name string) (*models.DataSource, error) {
-	query := `
-		SELECT id, name, description, created_at, updated_at
-		FROM data_sources
-		WHERE name = $1
-	`
-
-	var source models.DataSource
-	err := r.db.QueryRow(query, name).Scan(
-		&source.ID,
-		&source.Name,
-		&source.Description,
-		&source.CreatedAt,
-		&source.UpdatedAt,
-	)
-
+	source, err := scanDataSource(tx.QueryRow(query, args...))
 	if err == sql.ErrNoRows {
 		return nil, ErrDataSourceNotFound
 	}
@@ -137,18 +145,20 @@ func (r *PostgresDataSourceRepository) GetDataSourceByName(name string) (*models
 		return nil, err
 	}
 
-	// Set epoch timestamps
-	source.CreatedAtEpoch = utils.TimeToMillis(source.CreatedAt)
-	source.UpdatedAtEpoch = utils.TimeToMillis(source.UpdatedAt)
-
-	return &source, nil
+	return source, nil
 }
 
-// UpdateDataSource updates a data source
+// UpdateDataSource updates a data source of the tenant it carries
 func (r *PostgresDataSourceRepository) UpdateDataSource(source *models.DataSource) error {
-	// Check if another data source with the same name already exists
+	tx, err := beginTenant(r.db, source.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Check if another data source of the tenant with the same name already exists
 	var count int
-	err := r.db.QueryRow("SELECT COUNT(*) FROM data_sources WHERE name = $1 AND id != $2", source.Name, source.ID).Scan(&count)
+	err = tx.QueryRow("SELECT COUNT(*) FROM data_sources WHERE tenant_id = $1 AND name = $2 AND id != $3", source.TenantID, source.Name, source.ID).Scan(&count)
 	if err != nil {
 		return err
 	}
@@ -160,12 +170,12 @@ func (r *PostgresDataSourceRepository) UpdateDataSource(source *models.DataSourc
 	query := `
 		UPDATE data_sources
 		SET name = $1, description = $2, updated_at = NOW()
-		WHERE id = $3
+		WHERE id = $3 AND tenant_id = $4
 		RETURNING updated_at
 	`
 
 	var updatedAt time.Time
-	err = r.db.QueryRow(query, source.Name, source.Description, source.ID).Scan(&updatedAt)
+	err = tx.QueryRow(query, source.Name, source.Description, source.ID, source.TenantID).Scan(&updatedAt)
 
 	if err == sql.ErrNoRows {
 		return ErrDataSourceNotFound
@@ -179,14 +189,20 @@ func (r *PostgresDataSourceRepository) UpdateDataSource(source *models.DataSourc
 	// Set epoch timestamp
 	source.UpdatedAtEpoch = utils.TimeToMillis(updatedAt)
 
-	return nil
+	return tx.Commit()
 }
 
-// DeleteDataSource deletes a data source
-func (r *PostgresDataSourceRepository) DeleteDataSource(id string) error {
+// DeleteDataSource deletes a data source of a tenant
+func (r *PostgresDataSourceRepository) DeleteDataSource(tenantID, id string) error {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Check if there are any existing transactions with this data source
 	var count int
-	err := r.db.QueryRow("SELECT COUNT(*) FROM transactions WHERE data_source_id = $1", id).Scan(&count)
+	err = tx.QueryRow("SELECT COUNT(*) FROM transactions WHERE tenant_id = $1 AND data_source_id = $2", tenantID, id).Scan(&count)
 	if err != nil {
 		return err
 	}
@@ -195,8 +211,8 @@ func (r *PostgresDataSourceRepository) DeleteDataSource(id string) error {
 		return errors.New("cannot delete data source with existing transactions")
 	}
 
-	query := "DELETE FROM data_sources WHERE id = $1"
-	result, err := r.db.Exec(query, id)
+	query := "DELETE FROM data_sources WHERE tenant_id = $1 AND id = $2"
+	result, err := tx.Exec(query, tenantID, id)
 	if err != nil {
 		return err
 	}
@@ -210,116 +226,78 @@ func (r *PostgresDataSourceRepository) DeleteDataSource(id string) error {
 		return ErrDataSourceNotFound
 	}
 
-	return nil
+	return tx.Commit()
 }
 
-// GetAllDataSources retrieves all data sources
-func (r *PostgresDataSourceRepository) GetAllDataSources() ([]models.DataSource, error) {
-	query := `
-		SELECT id, name, description, created_at, updated_at
-		FROM data_sources
-		ORDER BY name
-	`
-
-	rows, err := r.db.Query(query)
+// GetAllDataSources retrieves all data sources of a tenant
+func (r *PostgresDataSourceRepository) GetAllDataSources(tenantID string) ([]models.DataSource, error) {
+	tx, err := beginTenant(r.db, tenantID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var sources []models.DataSource
-	for rows.Next() {
-		var source models.DataSource
-		err := rows.Scan(
-			&source.ID,
-			&source.Name,
-			&source.Description,
-			&source.CreatedAt,
-			&source.UpdatedAt,
-		)
-
-		if err != nil {
-			return nil, err
-		}
+	defer tx.Rollback()
 
-		// Set epoch timestamps
-		source.CreatedAtEpoch = utils.TimeToMillis(source.CreatedAt)
-		source.UpdatedAtEpoch = utils.TimeToMillis(source.UpdatedAt)
-
-		sources = append(sources, source)
-	}
+	query := "SELECT " + dataSourceColumns + " FROM data_sources WHERE tenant_id = $1 ORDER BY name"
+	return queryDataSources(tx, query, tenantID)
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+// SearchDataSources searches for data sources of a tenant matching the query
+func (r *PostgresDataSourceRepository) SearchDataSources(tenantID, query string, limit, offset int) ([]models.DataSource, int, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, 0, err
 	}
+	defer tx.Rollback()
 
-	return sources, nil
-}
+	b := newQueryBuilder().where("tenant_id = ?", tenantID).contains(query, "name", "description").orderByColumn("name", false).paginate(limit, offset)
 
-// SearchDataSources searches for data sources matching the query
-func (r *PostgresDataSourceRepository) SearchDataSources(query string, limit, offset int) ([]models.DataSource, int, error) {
 	// First get total count
-	countQuery := `
-		SELECT COUNT(*) 
-		FROM data_sources
-		WHERE name ILIKE $1 OR description ILIKE $1
-	`
-
-	searchPattern := "%" + query + "%"
+	countQuery, countArgs := b.count("FROM data_sources")
 	var totalCount int
-	err := r.db.QueryRow(countQuery, searchPattern).Scan(&totalCount)
+	err = tx.QueryRow(countQuery, countArgs...).Scan(&totalCount)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Then get the actual results with pagination
-	searchQuery := `
-		SELECT id, name, description, created_at, updated_at
-		FROM data_sources
-		WHERE name ILIKE $1 OR description ILIKE $1
-		ORDER BY name
-		LIMIT $2 OFFSET $3
-	`
-
-	rows, err := r.db.Query(searchQuery, searchPattern, limit, offset)
+	searchQuery, args := b.build("SELECT " + dataSourceColumns + " FROM data_sources")
+	sources, err := queryDataSources(tx, searchQuery, args...)
 	if err != nil {
 		return nil, 0, err
 	}
+
+	return sources, totalCount, nil
+}
+
+// queryDataSources runs a query selecting dataSourceColumns and scans every row
+func queryDataSources(q querier, query string, args ...interface{}) ([]models.DataSource, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	var sources []models.DataSource
 	for rows.Next() {
-		var source models.DataSource
-		err := rows.Scan(
-			&source.ID,
-			&source.Name,
-			&source.Description,
-			&source.CreatedAt,
-			&source.UpdatedAt,
-		)
-
+		source, err := scanDataSource(rows)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 
-		// Set epoch timestamps
-		source.CreatedAtEpoch = utils.TimeToMillis(source.CreatedAt)
-		source.UpdatedAtEpoch = utils.TimeToMillis(source.UpdatedAt)
-
-		sources = append(sources, source)
+		sources = append(sources, *source)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	return sources, totalCount, nil
+	return sources, nil
 }
 
 // MockDataSourceRepository is a mock implementation for development
 type MockDataSourceRepository struct {
 	dataSources map[string]*models.DataSource
-	nameIndex   map[string]string // name -> id mapping
+	nameIndex   map[string]string // tenantKey(tenant, name) -> id mapping
 }
 
 // CreateDataSource creates a data source in the mock repository
@@ -329,8 +307,8 @@ func (r *MockDataSourceRepository) CreateDataSource(source *models.DataSource) e
 		r.nameIndex = make(map[string]string)
 	}
 
-	// Check if data source with the same name exists
-	if _, exists := r.nameIndex[source.Name]; exists {
+	// Check if data source with the same name exists in the tenant
+	if _, exists := r.nameIndex[tenantKey(source.TenantID, source.Name)]; exists {
 		return ErrDataSourceExists
 	}
 
@@ -346,27 +324,27 @@ func (r *MockDataSourceRepository) CreateDataSource(source *models.DataSource) e
 	source.UpdatedAtEpoch = utils.TimeToMillis(source.UpdatedAt)
 
 	r.dataSources[source.ID] = source
-	r.nameIndex[source.Name] = source.ID
+	r.nameIndex[tenantKey(source.TenantID, source.Name)] = source.ID
 
 	return nil
 }
 
-// GetDataSourceByID retrieves a data source by ID from the mock repository
-func (r *MockDataSourceRepository) GetDataSourceByID(id string) (*models.DataSource, error) {
-	if source, exists := r.dataSources[id]; exists {
+// GetDataSourceByID retrieves a data source of a tenant by ID from the mock repository
+func (r *MockDataSourceRepository) GetDataSourceByID(tenantID, id string) (*models.DataSource, error) {
+	if source, exists := r.dataSources[id]; exists && source.TenantID == tenantID {
 		return source, nil
 	}
 	return nil, ErrDataSourceNotFound
 }
 
-// GetDataSourceByName retrieves a data source by name from the mock repository
-func (r *MockDataSourceRepository) GetDataSourceByName(name string) (*models.DataSource, error) {
+// GetDataSourceByName retrieves a data source of a tenant by name from the mock repository
+func (r *MockDataSourceRepository) GetDataSourceByName(tenantID, name string) (*models.DataSource, error) {
 	// Initialize nameIndex if it doesn't exist
 	if r.nameIndex == nil {
 		r.nameIndex = make(map[string]string)
 	}
 
-	id, exists := r.nameIndex[name]
+	id, exists := r.nameIndex[tenantKey(tenantID, name)]
 	if !exists {
 		return nil, ErrDataSourceNotFound
 	}
@@ -374,7 +352,7 @@ func (r *MockDataSourceRepository) GetDataSourceByName(name string) (*models.Dat
 	return r.dataSources[id], nil
 }
 
-// UpdateDataSource updates a data source in the mock repository
+// UpdateDataSource updates a data source of the tenant it carries in the mock repository
 func (r *MockDataSourceRepository) UpdateDataSource(source *models.DataSource) error {
 	// Initialize nameIndex if it doesn't exist
 	if r.nameIndex == nil {
@@ -382,19 +360,19 @@ func (r *MockDataSourceRepository) UpdateDataSource(source *models.DataSource) e
 	}
 
 	existing, exists := r.dataSources[source.ID]
-	if !exists {
+	if !exists || existing.TenantID != source.TenantID {
 		return ErrDataSourceNotFound
 	}
 
-	// Check if another data source with the same name exists
-	if id, nameExists := r.nameIndex[source.Name]; nameExists && id != source.ID {
+	// Check if another data source with the same name exists in the tenant
+	if id, nameExists := r.nameIndex[tenantKey(source.TenantID, source.Name)]; nameExists && id != source.ID {
 		return ErrDataSourceExists
 	}
 
 	// Update the name index if the name has changed
 	if existing.Name != source.Name {
-		delete(r.nameIndex, existing.Name)
-		r.nameIndex[source.Name] = source.ID
+		delete(r.nameIndex, tenantKey(existing.TenantID, existing.Name))
+		r.nameIndex[tenantKey(source.TenantID, source.Name)] = source.ID
 	}
 
 	source.UpdatedAt = time.Now()
@@ -403,46 +381,55 @@ func (r *MockDataSourceRepository) UpdateDataSource(source *models.DataSource) e
 	return nil
 }
 
-// DeleteDataSource deletes a data source from the mock repository
-func (r *MockDataSourceRepository) DeleteDataSource(id string) error {
+// DeleteDataSource deletes a data source of a tenant from the mock repository
+func (r *MockDataSourceRepository) DeleteDataSource(tenantID, id string) error {
 	// Initialize nameIndex if it doesn't exist
 	if r.nameIndex == nil {
 		r.nameIndex = make(map[string]string)
 	}
 
 	source, exists := r.dataSources[id]
-	if !exists {
+	if !exists || source.TenantID != tenantID {
 		return ErrDataSourceNotFound
 	}
 
-	delete(r.nameIndex, source.Name)
+	delete(r.nameIndex, tenantKey(source.TenantID, source.Name))
 	delete(r.dataSources, id)
 
 	return nil
 }
 
-// GetAllDataSources retrieves all data sources from the mock repository
-func (r *MockDataSourceRepository) GetAllDataSources() ([]models.DataSource, error) {
+// GetAllDataSources retrieves all data sources of a tenant from the mock repository
+func (r *MockDataSourceRepository) GetAllDataSources(tenantID string) ([]models.DataSource, error) {
 	var sources []models.DataSource
 	for _, source := range r.dataSources {
-		sources = append(sources, *source)
+		if source.TenantID == tenantID {
+			sources = append(sources, *source)
+		}
 	}
 	return sources, nil
 }
 
-// SearchDataSources searches for data sources in the mock repository
-func (r *MockDataSourceRepository) SearchDataSources(query string, limit, offset int) ([]models.DataSource, int, error) {
+// SearchDataSources searches for data sources of a tenant in the mock repository
+func (r *MockDataSourceRepository) SearchDataSources(tenantID, query string, limit, offset int) ([]models.DataSource, int, error) {
 	var matchingSources []models.DataSource
 	query = strings.ToLower(query)
 
 	// Find all matching sources
 	for _, source := range r.dataSources {
+		if source.TenantID != tenantID {
+			continue
+		}
 		if strings.Contains(strings.ToLower(source.Name), query) ||
 			(source.Description != "" && strings.Contains(strings.ToLower(source.Description), query)) {
 			matchingSources = append(matchingSources, *source)
 		}
 	}
 
+	sort.Slice(matchingSources, func(i, j int) bool {
+		return matchingSources[i].Name < matchingSources[j].Name
+	})
+
 	// Get total count
 	totalCount := len(matchingSources)
 
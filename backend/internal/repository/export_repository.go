@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"backend/internal/db"
+	"backend/internal/models"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrExportNotFound = errors.New("export not found")
+
+// ExportRepository defines operations for managing background export jobs
+type ExportRepository interface {
+	CreateExportJob(job *models.ExportJob) error
+	GetExportJobByID(tenantID, id string) (*models.ExportJob, error)
+	UpdateExportJob(job *models.ExportJob) error
+}
+
+// PostgresExportRepository implements ExportRepository for PostgreSQL
+type PostgresExportRepository struct {
+	db *sql.DB
+}
+
+// NewExportRepository creates a new export repository
+func NewExportRepository() ExportRepository {
+	if db.DB == nil {
+		// Return a mock repository for development
+		return &MockExportRepository{
+			jobs: make(map[string]*models.ExportJob),
+		}
+	}
+	return &PostgresExportRepository{
+		db: db.DB,
+	}
+}
+
+// exportJobColumns is the column list scanned by scanExportJob
+const exportJobColumns = `
+	id, tenant_id, COALESCE(match_set_id::text, ''), kind, format, status, row_count, file_key, file_size,
+	error, COALESCE(requested_by::text, ''), created_at, completed_at
+`
+
+// scanExportJob scans an export job selected with exportJobColumns
+func scanExportJob(row rowScanner) (*models.ExportJob, error) {
+	var job models.ExportJob
+	var completedAt sql.NullTime
+	err := row.Scan(
+		&job.ID,
+		&job.TenantID,
+		&job.MatchSetID,
+		&job.Kind,
+		&job.Format,
+		&job.Status,
+		&job.RowCount,
+		&job.FileKey,
+		&job.FileSize,
+		&job.Error,
+		&job.RequestedBy,
+		&job.CreatedAt,
+		&completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+	return &job, nil
+}
+
+// CreateExportJob records a new export job
+func (r *PostgresExportRepository) CreateExportJob(job *models.ExportJob) error {
+	query := `
+		INSERT INTO export_jobs (tenant_id, match_set_id, kind, format, status, requested_by)
+		VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5, NULLIF($6, '')::uuid)
+		RETURNING id, created_at
+	`
+
+	tx, err := beginTenant(r.db, job.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
+		query,
+		job.TenantID,
+		job.MatchSetID,
+		job.Kind,
+		job.Format,
+		job.Status,
+		job.RequestedBy,
+	).Scan(&job.ID, &job.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetExportJobByID retrieves an export job of a tenant by its ID
+func (r *PostgresExportRepository) GetExportJobByID(tenantID, id string) (*models.ExportJob, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT " + exportJobColumns + " FROM export_jobs WHERE id = $1"
+
+	job, err := scanExportJob(tx.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrExportNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// UpdateExportJob records the status, file and outcome of an export job
+func (r *PostgresExportRepository) UpdateExportJob(job *models.ExportJob) error {
+	query := `
+		UPDATE export_jobs
+		SET status = $2, row_count = $3, file_key = $4, file_size = $5, error = $6, completed_at = $7
+		WHERE id = $1
+	`
+
+	tx, err := beginTenant(r.db, job.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, job.ID, job.Status, job.RowCount, job.FileKey, job.FileSize, job.Error, job.CompletedAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrExportNotFound
+	}
+
+	return tx.Commit()
+}
+
+// MockExportRepository is a mock implementation for development
+type MockExportRepository struct {
+	mu   sync.Mutex
+	jobs map[string]*models.ExportJob
+}
+
+// CreateExportJob records a new export job in the mock repository
+func (r *MockExportRepository) CreateExportJob(job *models.ExportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job.ID = uuid.New().String()
+	job.CreatedAt = time.Now()
+
+	stored := *job
+	r.jobs[job.ID] = &stored
+	return nil
+}
+
+// GetExportJobByID retrieves an export job from the mock repository
+func (r *MockExportRepository) GetExportJobByID(tenantID, id string) (*models.ExportJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, exists := r.jobs[id]
+	if !exists || job.TenantID != tenantID {
+		return nil, ErrExportNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
+
+// UpdateExportJob records the status, file and outcome of an export job in the mock repository
+func (r *MockExportRepository) UpdateExportJob(job *models.ExportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.jobs[job.ID]; !exists {
+		return ErrExportNotFound
+	}
+	stored := *job
+	r.jobs[job.ID] = &stored
+	return nil
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"backend/internal/db"
+	"backend/internal/models"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FXRateRepository defines operations for managing exchange rates
+type FXRateRepository interface {
+	SaveRate(rate *models.FXRate) error
+	GetRates(tenantID string, from, to time.Time) ([]models.FXRate, error)
+}
+
+// PostgresFXRateRepository implements FXRateRepository for PostgreSQL
+type PostgresFXRateRepository struct {
+	db *sql.DB
+}
+
+// NewFXRateRepository creates a new FX rate repository
+func NewFXRateRepository() FXRateRepository {
+	if db.DB == nil {
+		// Return a mock repository for development
+		return &MockFXRateRepository{
+			rates: make(map[string]*models.FXRate),
+		}
+	}
+	return &PostgresFXRateRepository{
+		db: db.DB,
+	}
+}
+
+// SaveRate creates a rate, replacing any existing rate for the same tenant, pair and date
+func (r *PostgresFXRateRepository) SaveRate(rate *models.FXRate) error {
+	// Shared rates belong to no tenant; only a session across tenants writes them
+	var tx *sql.Tx
+	var err error
+	var tenantIDParam interface{} = nil
+	if rate.TenantID != "" {
+		tenantIDParam = rate.TenantID
+		tx, err = beginTenant(r.db, rate.TenantID)
+	} else {
+		tx, err = beginAllTenants(r.db)
+	}
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO fx_rates (tenant_id, base_currency, quote_currency, rate, rate_date)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT ((COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000')), base_currency, quote_currency, rate_date)
+		DO UPDATE SET rate = EXCLUDED.rate
+		RETURNING id, created_at
+	`
+
+	err = tx.QueryRow(
+		query,
+		tenantIDParam,
+		rate.BaseCurrency,
+		rate.QuoteCurrency,
+		rate.Rate,
+		rate.RateDate,
+	).Scan(&rate.ID, &rate.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetRates retrieves the tenant's rates and the shared rates dated between from and to, oldest first
+func (r *PostgresFXRateRepository) GetRates(tenantID string, from, to time.Time) ([]models.FXRate, error) {
+	query := `
+		SELECT id, COALESCE(tenant_id::text, ''), base_currency, quote_currency, rate, rate_date, created_at
+		FROM fx_rates
+		WHERE (tenant_id = $1 OR tenant_id IS NULL) AND rate_date BETWEEN $2 AND $3
+		ORDER BY rate_date, tenant_id NULLS FIRST
+	`
+
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(query, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []models.FXRate
+	for rows.Next() {
+		var rate models.FXRate
+		err := rows.Scan(
+			&rate.ID,
+			&rate.TenantID,
+			&rate.BaseCurrency,
+			&rate.QuoteCurrency,
+			&rate.Rate,
+			&rate.RateDate,
+			&rate.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rates = append(rates, rate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// MockFXRateRepository is a mock implementation for development
+type MockFXRateRepository struct {
+	rates map[string]*models.FXRate
+}
+
+// SaveRate stores a rate in the mock repository
+func (r *MockFXRateRepository) SaveRate(rate *models.FXRate) error {
+	for _, existing := range r.rates {
+		if existing.TenantID == rate.TenantID &&
+			existing.BaseCurrency == rate.BaseCurrency &&
+			existing.QuoteCurrency == rate.QuoteCurrency &&
+			existing.RateDate.Equal(rate.RateDate) {
+			existing.Rate = rate.Rate
+			rate.ID = existing.ID
+			rate.CreatedAt = existing.CreatedAt
+			return nil
+		}
+	}
+
+	if rate.ID == "" {
+		rate.ID = uuid.New().String()
+	}
+	rate.CreatedAt = time.Now()
+	copied := *rate
+	r.rates[rate.ID] = &copied
+	return nil
+}
+
+// GetRates retrieves rates from the mock repository
+func (r *MockFXRateRepository) GetRates(tenantID string, from, to time.Time) ([]models.FXRate, error) {
+	var rates []models.FXRate
+	for _, rate := range r.rates {
+		if rate.TenantID != "" && rate.TenantID != tenantID {
+			continue
+		}
+		if rate.RateDate.Before(from) || rate.RateDate.After(to) {
+			continue
+		}
+		rates = append(rates, *rate)
+	}
+
+	// Oldest first; on the same date shared rates come before tenant rates so the tenant's wins
+	sort.Slice(rates, func(i, j int) bool {
+		if !rates[i].RateDate.Equal(rates[j].RateDate) {
+			return rates[i].RateDate.Before(rates[j].RateDate)
+		}
+		return rates[i].TenantID < rates[j].TenantID
+	})
+	return rates, nil
+}
@@ -4,30 +4,41 @@ import (
 	"backend/internal/db"
 	"backend/internal/models"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 var (
 	ErrImportNotFound = errors.New("import record not found")
+	ErrImportExists   = errors.New("import with this idempotency key already exists")
+	ErrImportInUse    = errors.New("import with this idempotency key already exists and is still processing")
 )
 
-// ImportRepository defines operations for managing import records
+// ImportRepository defines operations for managing import records. Imports belong to the tenant
+// of their data source.
 type ImportRepository interface {
-	CreateImport(importRecord *models.ImportRecord) error
-	GetImportByID(id string) (*models.ImportRecord, error)
-	UpdateImportStatus(id string, status string, rowCount, successCount, errorCount int) error
-	GetImportsByDataSource(dataSourceID string, limit, offset int) ([]models.ImportRecord, int, error)
-	DeleteImport(id string) error
+	CreateImport(tenantID string, importRecord *models.ImportRecord) error
+	GetImportByID(tenantID, id string) (*models.ImportRecord, error)
+	GetImportByIdempotencyKey(tenantID, dataSourceID, idempotencyKey string) (*models.ImportRecord, error)
+	UpdateImportStatus(tenantID, id string, status string, rowCount, successCount, errorCount int) error
+	UpdateImportMetadata(tenantID, id string, metadata json.RawMessage) error
+	ReclaimImport(tenantID, id string, staleAfter time.Duration) error
+	GetImportsByDataSource(tenantID, dataSourceID string, limit, offset int) ([]models.ImportRecord, int, error)
+	DeleteImport(tenantID, id string) error
 
 	// Raw transactions operations
-	CreateRawTransaction(rawTx *models.RawTransaction) error
-	GetRawTransactionsByImport(importID string, limit, offset int) ([]models.RawTransaction, int, error)
-	GetRawTransactionByID(id string) (*models.RawTransaction, error)
+	CreateRawTransaction(tenantID string, rawTx *models.RawTransaction) error
+	GetRawTransactionsByImport(tenantID, importID string, limit, offset int) ([]models.RawTransaction, int, error)
+	GetRawTransactionByID(tenantID, id string) (*models.RawTransaction, error)
 }
 
-// PostgresImportRepository implements ImportRepository for PostgreSQL
+// PostgresImportRepository implements ImportRepository for PostgreSQL. Every statement runs in the
+// tenant's session, where the row-level security policies only show the imports and raw
+// transactions of the tenant's data sources.
 type PostgresImportRepository struct {
 	db *sql.DB
 }
@@ -39,6 +50,7 @@ func NewImportRepository() ImportRepository {
 		return &MockImportRepository{
 			imports:         make(map[string]*models.ImportRecord),
 			rawTransactions: make(map[string]*models.RawTransaction),
+			tenants:         make(map[string]string),
 		}
 	}
 	return &PostgresImportRepository{
@@ -46,12 +58,37 @@ func NewImportRepository() ImportRepository {
 	}
 }
 
-// CreateImport creates a new import record
-func (r *PostgresImportRepository) CreateImport(importRecord *models.ImportRecord) error {
+// CreateImport creates a new import record for a data source of a tenant
+func (r *PostgresImportRepository) CreateImport(tenantID string, importRecord *models.ImportRecord) error {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Check if an import with the same idempotency key already exists for this data source
+	var idempotencyKeyParam interface{} = nil
+	if importRecord.IdempotencyKey != "" {
+		var exists bool
+		err := tx.QueryRow(
+			"SELECT EXISTS(SELECT 1 FROM import_records WHERE data_source_id = $1 AND idempotency_key = $2)",
+			importRecord.DataSourceID, importRecord.IdempotencyKey,
+		).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			return ErrImportExists
+		}
+
+		idempotencyKeyParam = importRecord.IdempotencyKey
+	}
+
 	query := `
 		INSERT INTO import_records (data_source_id, file_name, file_size, status, row_count, 
-			success_count, error_count, imported_by, metadata)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			success_count, error_count, imported_by, metadata, idempotency_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -60,7 +97,7 @@ func (r *PostgresImportRepository) CreateImport(importRecord *models.ImportRecor
 		metadataJSON = importRecord.Metadata
 	}
 
-	return r.db.QueryRow(
+	err = tx.QueryRow(
 		query,
 		importRecord.DataSourceID,
 		importRecord.FileName,
@@ -71,21 +108,66 @@ func (r *PostgresImportRepository) CreateImport(importRecord *models.ImportRecor
 		importRecord.ErrorCount,
 		importRecord.ImportedBy,
 		metadataJSON,
+		idempotencyKeyParam,
 	).Scan(&importRecord.ID, &importRecord.CreatedAt, &importRecord.UpdatedAt)
+
+	// A concurrent call with the same key can pass the check above; the unique index stops it
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrImportExists
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// GetImportByID retrieves an import record by ID
-func (r *PostgresImportRepository) GetImportByID(id string) (*models.ImportRecord, error) {
+// GetImportByID retrieves a tenant's import record by ID
+func (r *PostgresImportRepository) GetImportByID(tenantID, id string) (*models.ImportRecord, error) {
 	query := `
 		SELECT id, data_source_id, file_name, file_size, status, row_count, 
-			success_count, error_count, imported_by, created_at, updated_at, metadata
+			success_count, error_count, imported_by, created_at, updated_at, metadata,
+			idempotency_key
 		FROM import_records
 		WHERE id = $1
 	`
 
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return r.scanImport(tx.QueryRow(query, id))
+}
+
+// GetImportByIdempotencyKey retrieves the import created for an idempotency key within a data
+// source of a tenant
+func (r *PostgresImportRepository) GetImportByIdempotencyKey(tenantID, dataSourceID, idempotencyKey string) (*models.ImportRecord, error) {
+	query := `
+		SELECT id, data_source_id, file_name, file_size, status, row_count, 
+			success_count, error_count, imported_by, created_at, updated_at, metadata,
+			idempotency_key
+		FROM import_records
+		WHERE data_source_id = $1 AND idempotency_key = $2
+	`
+
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return r.scanImport(tx.QueryRow(query, dataSourceID, idempotencyKey))
+}
+
+// scanImport scans a single import record row
+func (r *PostgresImportRepository) scanImport(row *sql.Row) (*models.ImportRecord, error) {
 	var importRecord models.ImportRecord
 	var metadata []byte
-	err := r.db.QueryRow(query, id).Scan(
+	var idempotencyKey sql.NullString
+	err := row.Scan(
 		&importRecord.ID,
 		&importRecord.DataSourceID,
 		&importRecord.FileName,
@@ -98,6 +180,7 @@ func (r *PostgresImportRepository) GetImportByID(id string) (*models.ImportRecor
 		&importRecord.CreatedAt,
 		&importRecord.UpdatedAt,
 		&metadata,
+		&idempotencyKey,
 	)
 
 	if err == sql.ErrNoRows {
@@ -109,6 +192,10 @@ func (r *PostgresImportRepository) GetImportByID(id string) (*models.ImportRecor
 	}
 
 	importRecord.Metadata = metadata
+	if idempotencyKey.Valid {
+		importRecord.IdempotencyKey = idempotencyKey.String
+	}
+
 	// Set epoch timestamps
 	importRecord.CreatedAtEpoch = importRecord.CreatedAt.UTC().UnixNano() / int64(time.Millisecond)
 	importRecord.UpdatedAtEpoch = importRecord.UpdatedAt.UTC().UnixNano() / int64(time.Millisecond)
@@ -116,8 +203,8 @@ func (r *PostgresImportRepository) GetImportByID(id string) (*models.ImportRecor
 	return &importRecord, nil
 }
 
-// UpdateImportStatus updates the status and counts of an import
-func (r *PostgresImportRepository) UpdateImportStatus(id string, status string, rowCount, successCount, errorCount int) error {
+// UpdateImportStatus updates the status and counts of a tenant's import
+func (r *PostgresImportRepository) UpdateImportStatus(tenantID, id string, status string, rowCount, successCount, errorCount int) error {
 	query := `
 		UPDATE import_records
 		SET status = $2, row_count = $3, success_count = $4, error_count = $5, updated_at = NOW()
@@ -125,22 +212,94 @@ func (r *PostgresImportRepository) UpdateImportStatus(id string, status string,
 		RETURNING updated_at
 	`
 
+	return r.updateImport(tenantID, query, id, status, rowCount, successCount, errorCount)
+}
+
+// UpdateImportMetadata replaces the metadata stored with a tenant's import
+func (r *PostgresImportRepository) UpdateImportMetadata(tenantID, id string, metadata json.RawMessage) error {
+	query := `
+		UPDATE import_records
+		SET metadata = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`
+
+	return r.updateImport(tenantID, query, id, []byte(metadata))
+}
+
+// updateImport runs an update of an import returning its updated_at in the tenant's session
+func (r *PostgresImportRepository) updateImport(tenantID, query string, args ...interface{}) error {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	var updatedAt time.Time
-	err := r.db.QueryRow(query, id, status, rowCount, successCount, errorCount).Scan(&updatedAt)
+	err = tx.QueryRow(query, args...).Scan(&updatedAt)
 
 	if err == sql.ErrNoRows {
 		return ErrImportNotFound
 	}
 
-	return err
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// GetImportsByDataSource retrieves import records for a data source with pagination
-func (r *PostgresImportRepository) GetImportsByDataSource(dataSourceID string, limit, offset int) ([]models.ImportRecord, int, error) {
+// ReclaimImport takes over an import whose earlier attempt failed, or has been processing for
+// longer than staleAfter, marking it processing again and dropping the raw transactions of the
+// earlier attempt, which the retry stores again. Of concurrent calls only one takes it over; the
+// others get ErrImportInUse.
+func (r *PostgresImportRepository) ReclaimImport(tenantID, id string, staleAfter time.Duration) error {
+	query := `
+		UPDATE import_records
+		SET status = 'Processing', updated_at = NOW()
+		WHERE id = $1
+			AND (status = 'Failed' OR (status = 'Processing' AND updated_at < NOW() - make_interval(secs => $2)))
+	`
+
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, id, staleAfter.Seconds())
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrImportInUse
+	}
+
+	if _, err := tx.Exec("DELETE FROM raw_transactions WHERE import_id = $1", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetImportsByDataSource retrieves import records for a data source of a tenant with pagination
+func (r *PostgresImportRepository) GetImportsByDataSource(tenantID, dataSourceID string, limit, offset int) ([]models.ImportRecord, int, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
 	// Get total count first
 	var totalCount int
 	countQuery := `SELECT COUNT(*) FROM import_records WHERE data_source_id = $1`
-	err := r.db.QueryRow(countQuery, dataSourceID).Scan(&totalCount)
+	err = tx.QueryRow(countQuery, dataSourceID).Scan(&totalCount)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -155,7 +314,7 @@ func (r *PostgresImportRepository) GetImportsByDataSource(dataSourceID string, l
 		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.Query(query, dataSourceID, limit, offset)
+	rows, err := tx.Query(query, dataSourceID, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -199,10 +358,10 @@ func (r *PostgresImportRepository) GetImportsByDataSource(dataSourceID string, l
 	return imports, totalCount, nil
 }
 
-// DeleteImport deletes an import record and its associated raw transactions
-func (r *PostgresImportRepository) DeleteImport(id string) error {
+// DeleteImport deletes a tenant's import record and its associated raw transactions
+func (r *PostgresImportRepository) DeleteImport(tenantID, id string) error {
 	// Start a transaction
-	tx, err := r.db.Begin()
+	tx, err := beginTenant(r.db, tenantID)
 	if err != nil {
 		return err
 	}
@@ -235,15 +394,21 @@ func (r *PostgresImportRepository) DeleteImport(id string) error {
 	return tx.Commit()
 }
 
-// CreateRawTransaction creates a new raw transaction
-func (r *PostgresImportRepository) CreateRawTransaction(rawTx *models.RawTransaction) error {
+// CreateRawTransaction creates a new raw transaction for an import of a tenant
+func (r *PostgresImportRepository) CreateRawTransaction(tenantID string, rawTx *models.RawTransaction) error {
 	query := `
 		INSERT INTO raw_transactions (import_id, data_source_id, row_number, data, error_message)
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at
 	`
 
-	return r.db.QueryRow(
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
 		query,
 		rawTx.ImportID,
 		rawTx.DataSourceID,
@@ -251,14 +416,25 @@ func (r *PostgresImportRepository) CreateRawTransaction(rawTx *models.RawTransac
 		rawTx.Data,
 		rawTx.ErrorMessage,
 	).Scan(&rawTx.ID, &rawTx.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// GetRawTransactionsByImport retrieves raw transactions for an import with pagination
-func (r *PostgresImportRepository) GetRawTransactionsByImport(importID string, limit, offset int) ([]models.RawTransaction, int, error) {
+// GetRawTransactionsByImport retrieves raw transactions for a tenant's import with pagination
+func (r *PostgresImportRepository) GetRawTransactionsByImport(tenantID, importID string, limit, offset int) ([]models.RawTransaction, int, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
 	// Get total count first
 	var totalCount int
 	countQuery := `SELECT COUNT(*) FROM raw_transactions WHERE import_id = $1`
-	err := r.db.QueryRow(countQuery, importID).Scan(&totalCount)
+	err = tx.QueryRow(countQuery, importID).Scan(&totalCount)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -272,7 +448,7 @@ func (r *PostgresImportRepository) GetRawTransactionsByImport(importID string, l
 		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.Query(query, importID, limit, offset)
+	rows, err := tx.Query(query, importID, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -280,15 +456,15 @@ func (r *PostgresImportRepository) GetRawTransactionsByImport(importID string, l
 
 	var transactions []models.RawTransaction
 	for rows.Next() {
-		var tx models.RawTransaction
+		var rawTx models.RawTransaction
 		err := rows.Scan(
-			&tx.ID,
-			&tx.ImportID,
-			&tx.DataSourceID,
-			&tx.RowNumber,
-			&tx.Data,
-			&tx.ErrorMessage,
-			&tx.CreatedAt,
+			&rawTx.ID,
+			&rawTx.ImportID,
+			&rawTx.DataSourceID,
+			&rawTx.RowNumber,
+			&rawTx.Data,
+			&rawTx.ErrorMessage,
+			&rawTx.CreatedAt,
 		)
 
 		if err != nil {
@@ -296,9 +472,9 @@ func (r *PostgresImportRepository) GetRawTransactionsByImport(importID string, l
 		}
 
 		// Set epoch timestamp
-		tx.CreatedAtEpoch = tx.CreatedAt.UTC().UnixNano() / int64(time.Millisecond)
+		rawTx.CreatedAtEpoch = rawTx.CreatedAt.UTC().UnixNano() / int64(time.Millisecond)
 
-		transactions = append(transactions, tx)
+		transactions = append(transactions, rawTx)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -308,23 +484,29 @@ func (r *PostgresImportRepository) GetRawTransactionsByImport(importID string, l
 	return transactions, totalCount, nil
 }
 
-// GetRawTransactionByID retrieves a raw transaction by ID
-func (r *PostgresImportRepository) GetRawTransactionByID(id string) (*models.RawTransaction, error) {
+// GetRawTransactionByID retrieves a tenant's raw transaction by ID
+func (r *PostgresImportRepository) GetRawTransactionByID(tenantID, id string) (*models.RawTransaction, error) {
 	query := `
 		SELECT id, import_id, data_source_id, row_number, data, error_message, created_at
 		FROM raw_transactions
 		WHERE id = $1
 	`
 
-	var tx models.RawTransaction
-	err := r.db.QueryRow(query, id).Scan(
-		&tx.ID,
-		&tx.ImportID,
-		&tx.DataSourceID,
-		&tx.RowNumber,
-		&tx.Data,
-		&tx.ErrorMessage,
-		&tx.CreatedAt,
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var rawTx models.RawTransaction
+	err = tx.QueryRow(query, id).Scan(
+		&rawTx.ID,
+		&rawTx.ImportID,
+		&rawTx.DataSourceID,
+		&rawTx.RowNumber,
+		&rawTx.Data,
+		&rawTx.ErrorMessage,
+		&rawTx.CreatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -336,39 +518,66 @@ func (r *PostgresImportRepository) GetRawTransactionByID(id string) (*models.Raw
 	}
 
 	// Set epoch timestamp
-	tx.CreatedAtEpoch = tx.CreatedAt.UTC().UnixNano() / int64(time.Millisecond)
+	rawTx.CreatedAtEpoch = rawTx.CreatedAt.UTC().UnixNano() / int64(time.Millisecond)
 
-	return &tx, nil
+	return &rawTx, nil
 }
 
 // MockImportRepository is a mock implementation for development
 type MockImportRepository struct {
 	imports         map[string]*models.ImportRecord
 	rawTransactions map[string]*models.RawTransaction
+	tenants         map[string]string // import ID -> tenant ID
 	lastID          int
 }
 
+// mockImport returns a tenant's import record from the mock repository
+func (r *MockImportRepository) mockImport(tenantID, id string) (*models.ImportRecord, bool) {
+	importRecord, ok := r.imports[id]
+	if !ok || r.tenants[id] != tenantID {
+		return nil, false
+	}
+	return importRecord, true
+}
+
 // CreateImport creates an import record in the mock repository
-func (r *MockImportRepository) CreateImport(importRecord *models.ImportRecord) error {
+func (r *MockImportRepository) CreateImport(tenantID string, importRecord *models.ImportRecord) error {
+	if importRecord.IdempotencyKey != "" {
+		if _, err := r.GetImportByIdempotencyKey(tenantID, importRecord.DataSourceID, importRecord.IdempotencyKey); err == nil {
+			return ErrImportExists
+		}
+	}
+
 	r.lastID++
 	importRecord.ID = fmt.Sprintf("mock-import-%d", r.lastID)
 	importRecord.CreatedAt = time.Now()
 	importRecord.UpdatedAt = time.Now()
 	r.imports[importRecord.ID] = importRecord
+	r.tenants[importRecord.ID] = tenantID
 	return nil
 }
 
-// GetImportByID retrieves an import record by ID from the mock repository
-func (r *MockImportRepository) GetImportByID(id string) (*models.ImportRecord, error) {
-	if importRecord, ok := r.imports[id]; ok {
+// GetImportByID retrieves a tenant's import record by ID from the mock repository
+func (r *MockImportRepository) GetImportByID(tenantID, id string) (*models.ImportRecord, error) {
+	if importRecord, ok := r.mockImport(tenantID, id); ok {
 		return importRecord, nil
 	}
 	return nil, ErrImportNotFound
 }
 
-// UpdateImportStatus updates the status of an import in the mock repository
-func (r *MockImportRepository) UpdateImportStatus(id string, status string, rowCount, successCount, errorCount int) error {
-	if importRecord, ok := r.imports[id]; ok {
+// GetImportByIdempotencyKey retrieves a tenant's import record by idempotency key from the mock repository
+func (r *MockImportRepository) GetImportByIdempotencyKey(tenantID, dataSourceID, idempotencyKey string) (*models.ImportRecord, error) {
+	for id, importRecord := range r.imports {
+		if r.tenants[id] == tenantID && importRecord.DataSourceID == dataSourceID && importRecord.IdempotencyKey == idempotencyKey {
+			return importRecord, nil
+		}
+	}
+	return nil, ErrImportNotFound
+}
+
+// UpdateImportStatus updates the status of a tenant's import in the mock repository
+func (r *MockImportRepository) UpdateImportStatus(tenantID, id string, status string, rowCount, successCount, errorCount int) error {
+	if importRecord, ok := r.mockImport(tenantID, id); ok {
 		importRecord.Status = status
 		importRecord.RowCount = rowCount
 		importRecord.SuccessCount = successCount
@@ -379,13 +588,45 @@ func (r *MockImportRepository) UpdateImportStatus(id string, status string, rowC
 	return ErrImportNotFound
 }
 
-// GetImportsByDataSource retrieves import records for a data source from the mock repository
-func (r *MockImportRepository) GetImportsByDataSource(dataSourceID string, limit, offset int) ([]models.ImportRecord, int, error) {
+// UpdateImportMetadata replaces the metadata of a tenant's import in the mock repository
+func (r *MockImportRepository) UpdateImportMetadata(tenantID, id string, metadata json.RawMessage) error {
+	if importRecord, ok := r.mockImport(tenantID, id); ok {
+		importRecord.Metadata = metadata
+		importRecord.UpdatedAt = time.Now()
+		return nil
+	}
+	return ErrImportNotFound
+}
+
+// ReclaimImport takes over a tenant's failed or stale import in the mock repository
+func (r *MockImportRepository) ReclaimImport(tenantID, id string, staleAfter time.Duration) error {
+	importRecord, ok := r.mockImport(tenantID, id)
+	if !ok {
+		return ErrImportNotFound
+	}
+
+	stale := importRecord.Status == "Processing" && time.Since(importRecord.UpdatedAt) > staleAfter
+	if importRecord.Status != "Failed" && !stale {
+		return ErrImportInUse
+	}
+
+	importRecord.Status = "Processing"
+	importRecord.UpdatedAt = time.Now()
+	for txID, tx := range r.rawTransactions {
+		if tx.ImportID == id {
+			delete(r.rawTransactions, txID)
+		}
+	}
+	return nil
+}
+
+// GetImportsByDataSource retrieves import records for a data source of a tenant from the mock repository
+func (r *MockImportRepository) GetImportsByDataSource(tenantID, dataSourceID string, limit, offset int) ([]models.ImportRecord, int, error) {
 	var imports []models.ImportRecord
 	var filtered []models.ImportRecord
 
-	for _, importRecord := range r.imports {
-		if importRecord.DataSourceID == dataSourceID {
+	for id, importRecord := range r.imports {
+		if r.tenants[id] == tenantID && importRecord.DataSourceID == dataSourceID {
 			filtered = append(filtered, *importRecord)
 		}
 	}
@@ -406,10 +647,11 @@ func (r *MockImportRepository) GetImportsByDataSource(dataSourceID string, limit
 	return imports, total, nil
 }
 
-// DeleteImport deletes an import record from the mock repository
-func (r *MockImportRepository) DeleteImport(id string) error {
-	if _, ok := r.imports[id]; ok {
+// DeleteImport deletes a tenant's import record from the mock repository
+func (r *MockImportRepository) DeleteImport(tenantID, id string) error {
+	if _, ok := r.mockImport(tenantID, id); ok {
 		delete(r.imports, id)
+		delete(r.tenants, id)
 		// Also delete associated raw transactions
 		for txID, tx := range r.rawTransactions {
 			if tx.ImportID == id {
@@ -421,8 +663,12 @@ func (r *MockImportRepository) DeleteImport(id string) error {
 	return ErrImportNotFound
 }
 
-// CreateRawTransaction creates a raw transaction in the mock repository
-func (r *MockImportRepository) CreateRawTransaction(rawTx *models.RawTransaction) error {
+// CreateRawTransaction creates a raw transaction for a tenant's import in the mock repository
+func (r *MockImportRepository) CreateRawTransaction(tenantID string, rawTx *models.RawTransaction) error {
+	if _, ok := r.mockImport(tenantID, rawTx.ImportID); !ok {
+		return ErrImportNotFound
+	}
+
 	r.lastID++
 	rawTx.ID = fmt.Sprintf("mock-rawtx-%d", r.lastID)
 	rawTx.CreatedAt = time.Now()
@@ -430,13 +676,13 @@ func (r *MockImportRepository) CreateRawTransaction(rawTx *models.RawTransaction
 	return nil
 }
 
-// GetRawTransactionsByImport retrieves raw transactions for an import from the mock repository
-func (r *MockImportRepository) GetRawTransactionsByImport(importID string, limit, offset int) ([]models.RawTransaction, int, error) {
+// GetRawTransactionsByImport retrieves raw transactions for a tenant's import from the mock repository
+func (r *MockImportRepository) GetRawTransactionsByImport(tenantID, importID string, limit, offset int) ([]models.RawTransaction, int, error) {
 	var transactions []models.RawTransaction
 	var filtered []models.RawTransaction
 
 	for _, tx := range r.rawTransactions {
-		if tx.ImportID == importID {
+		if tx.ImportID == importID && r.tenants[tx.ImportID] == tenantID {
 			filtered = append(filtered, *tx)
 		}
 	}
@@ -454,9 +700,9 @@ func (r *MockImportRepository) GetRawTransactionsByImport(importID string, limit
 	return transactions, total, nil
 }
 
-// GetRawTransactionByID retrieves a raw transaction by ID from the mock repository
-func (r *MockImportRepository) GetRawTransactionByID(id string) (*models.RawTransaction, error) {
-	if tx, ok := r.rawTransactions[id]; ok {
+// GetRawTransactionByID retrieves a tenant's raw transaction by ID from the mock repository
+func (r *MockImportRepository) GetRawTransactionByID(tenantID, id string) (*models.RawTransaction, error) {
+	if tx, ok := r.rawTransactions[id]; ok && r.tenants[tx.ImportID] == tenantID {
 		return tx, nil
 	}
 	return nil, fmt.Errorf("raw transaction not found: %s", id)
@@ -0,0 +1,468 @@
+package repository
+
+import (
+	"backend/internal/db"
+	"backend/internal/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMatchProgressNotFound = errors.New("match progress not found")
+	ErrRunLeaseLost          = errors.New("match set run lease lost to another run")
+	ErrNoRunInProgress       = errors.New("invalid request: the match set has no running run")
+)
+
+// RunInProgressError is returned when a match set is already held by a running run
+type RunInProgressError struct {
+	RunID          string
+	LeaseExpiresAt time.Time
+}
+
+func (e *RunInProgressError) Error() string {
+	return "match set run " + e.RunID + " already in progress"
+}
+
+// MatchProgressRepository defines operations for tracking match set runs. Progress belongs to the
+// tenant of its match set.
+type MatchProgressRepository interface {
+	GetProgress(tenantID, matchSetID string) (*models.MatchProgress, error)
+	SaveProgress(tenantID string, progress *models.MatchProgress) error
+	AcquireRunLease(tenantID, matchSetID string, lease time.Duration) (*models.MatchProgress, error)
+	RenewRunLease(tenantID, matchSetID, runID string, lease time.Duration) (bool, error)
+	RequestRunCancel(tenantID, matchSetID, runID string) (*models.MatchProgress, error)
+}
+
+// PostgresMatchProgressRepository implements MatchProgressRepository for PostgreSQL. Every
+// statement runs in the tenant's session, where the row-level security policies only show the
+// progress of the tenant's match sets.
+type PostgresMatchProgressRepository struct {
+	db *sql.DB
+}
+
+// NewMatchProgressRepository creates a new match progress repository
+func NewMatchProgressRepository() MatchProgressRepository {
+	if db.DB == nil {
+		// Return a mock repository for development
+		return &MockMatchProgressRepository{
+			progress: make(map[string]*models.MatchProgress),
+			tenants:  make(map[string]string),
+		}
+	}
+	return &PostgresMatchProgressRepository{
+		db: db.DB,
+	}
+}
+
+// matchProgressColumns is the column list scanned by scanMatchProgress
+const matchProgressColumns = `
+	match_set_id, COALESCE(run_id::text, ''), mode, total_transactions, processed_transactions,
+	matched_transactions, unmatched_transactions, status, started_at, completed_at, last_success_at,
+	lease_expires_at, cancel_requested, checkpoint, error
+`
+
+// scanMatchProgress scans a progress row selected with matchProgressColumns
+func scanMatchProgress(row rowScanner) (*models.MatchProgress, error) {
+	var progress models.MatchProgress
+	var startedAt, completedAt, lastSuccessAt, leaseExpiresAt sql.NullTime
+	var errorMessage sql.NullString
+	var checkpoint []byte
+	err := row.Scan(
+		&progress.MatchSetID,
+		&progress.RunID,
+		&progress.Mode,
+		&progress.TotalTransactions,
+		&progress.ProcessedTransactions,
+		&progress.MatchedTransactions,
+		&progress.UnmatchedTransactions,
+		&progress.Status,
+		&startedAt,
+		&completedAt,
+		&lastSuccessAt,
+		&leaseExpiresAt,
+		&progress.CancelRequested,
+		&checkpoint,
+		&errorMessage,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if startedAt.Valid {
+		progress.StartedAt = &startedAt.Time
+	}
+
+	if completedAt.Valid {
+		progress.CompletedAt = &completedAt.Time
+	}
+
+	if lastSuccessAt.Valid {
+		progress.LastSuccessAt = &lastSuccessAt.Time
+	}
+
+	if leaseExpiresAt.Valid {
+		progress.LeaseExpiresAt = &leaseExpiresAt.Time
+	}
+
+	if len(checkpoint) > 0 {
+		progress.Checkpoint = &models.RunCheckpoint{}
+		if err := json.Unmarshal(checkpoint, progress.Checkpoint); err != nil {
+			return nil, err
+		}
+	}
+
+	progress.Error = errorMessage.String
+	return &progress, nil
+}
+
+// GetProgress retrieves the progress of the latest run of a tenant's match set
+func (r *PostgresMatchProgressRepository) GetProgress(tenantID, matchSetID string) (*models.MatchProgress, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return getProgress(tx, matchSetID)
+}
+
+// getProgress reads the progress row of a match set
+func getProgress(q querier, matchSetID string) (*models.MatchProgress, error) {
+	query := "SELECT " + matchProgressColumns + " FROM match_progress WHERE match_set_id = $1"
+
+	progress, err := scanMatchProgress(q.QueryRow(query, matchSetID))
+	if err == sql.ErrNoRows {
+		return nil, ErrMatchProgressNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+// SaveProgress creates or replaces the progress row of a match set. Progress of a run is only
+// saved while the run still holds the row; otherwise ErrRunLeaseLost is returned. The lease is
+// left to AcquireRunLease and RenewRunLease, and released once the run is no longer Running.
+func (r *PostgresMatchProgressRepository) SaveProgress(tenantID string, progress *models.MatchProgress) error {
+	if progress.Status != "Running" {
+		progress.LeaseExpiresAt = nil
+	}
+
+	var runIDParam interface{} = nil
+	if progress.RunID != "" {
+		runIDParam = progress.RunID
+	}
+
+	var checkpointParam interface{} = nil
+	if progress.Checkpoint != nil {
+		checkpoint, err := json.Marshal(progress.Checkpoint)
+		if err != nil {
+			return err
+		}
+		checkpointParam = checkpoint
+	}
+
+	// cancel_requested is left to AcquireRunLease and RequestRunCancel
+	query := `
+		INSERT INTO match_progress (
+			match_set_id, run_id, mode, total_transactions, processed_transactions, matched_transactions,
+			unmatched_transactions, status, started_at, completed_at, last_success_at, checkpoint, error
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NULLIF($13, ''))
+		ON CONFLICT (match_set_id) DO UPDATE SET
+			mode = EXCLUDED.mode,
+			total_transactions = EXCLUDED.total_transactions,
+			processed_transactions = EXCLUDED.processed_transactions,
+			matched_transactions = EXCLUDED.matched_transactions,
+			unmatched_transactions = EXCLUDED.unmatched_transactions,
+			status = EXCLUDED.status,
+			started_at = EXCLUDED.started_at,
+			completed_at = EXCLUDED.completed_at,
+			last_success_at = EXCLUDED.last_success_at,
+			lease_expires_at = CASE WHEN EXCLUDED.status = 'Running' THEN match_progress.lease_expires_at END,
+			checkpoint = EXCLUDED.checkpoint,
+			error = EXCLUDED.error
+		WHERE match_progress.run_id IS NOT DISTINCT FROM EXCLUDED.run_id
+	`
+
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		query,
+		progress.MatchSetID,
+		runIDParam,
+		progress.Mode,
+		progress.TotalTransactions,
+		progress.ProcessedTransactions,
+		progress.MatchedTransactions,
+		progress.UnmatchedTransactions,
+		progress.Status,
+		progress.StartedAt,
+		progress.CompletedAt,
+		progress.LastSuccessAt,
+		checkpointParam,
+		progress.Error,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRunLeaseLost
+	}
+
+	return tx.Commit()
+}
+
+// AcquireRunLease starts a run of a match set. The progress row is taken over by a new run that
+// holds it for the lease duration, unless another run is still Running under an unexpired lease,
+// in which case a *RunInProgressError is returned. The returned progress keeps the previous
+// run's mode, counts, checkpoint and last success, so a cancelled run can be resumed.
+func (r *PostgresMatchProgressRepository) AcquireRunLease(tenantID, matchSetID string, lease time.Duration) (*models.MatchProgress, error) {
+	query := `
+		INSERT INTO match_progress (match_set_id, run_id, status, started_at, lease_expires_at)
+		VALUES ($1, $2, 'Running', NOW(), NOW() + $3 * INTERVAL '1 millisecond')
+		ON CONFLICT (match_set_id) DO UPDATE SET
+			run_id = EXCLUDED.run_id,
+			status = 'Running',
+			started_at = EXCLUDED.started_at,
+			completed_at = NULL,
+			lease_expires_at = EXCLUDED.lease_expires_at,
+			cancel_requested = FALSE,
+			error = NULL
+		WHERE match_progress.status <> 'Running'
+			OR match_progress.lease_expires_at IS NULL
+			OR match_progress.lease_expires_at < NOW()
+		RETURNING ` + matchProgressColumns
+
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	progress, err := scanMatchProgress(tx.QueryRow(query, matchSetID, uuid.New().String(), lease.Milliseconds()))
+	if err == sql.ErrNoRows {
+		// The row is held by a live run
+		running, err := getProgress(tx, matchSetID)
+		if err != nil {
+			return nil, err
+		}
+		inProgress := &RunInProgressError{RunID: running.RunID}
+		if running.LeaseExpiresAt != nil {
+			inProgress.LeaseExpiresAt = *running.LeaseExpiresAt
+		}
+		return nil, inProgress
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+// RenewRunLease extends the lease of a running run and reports whether its cancellation was
+// requested. It returns ErrRunLeaseLost when the run no longer holds the match set.
+func (r *PostgresMatchProgressRepository) RenewRunLease(tenantID, matchSetID, runID string, lease time.Duration) (bool, error) {
+	query := `
+		UPDATE match_progress
+		SET lease_expires_at = NOW() + $3 * INTERVAL '1 millisecond'
+		WHERE match_set_id = $1 AND run_id = $2 AND status = 'Running'
+		RETURNING cancel_requested
+	`
+
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var cancelRequested bool
+	err = tx.QueryRow(query, matchSetID, runID, lease.Milliseconds()).Scan(&cancelRequested)
+	if err == sql.ErrNoRows {
+		return false, ErrRunLeaseLost
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return cancelRequested, tx.Commit()
+}
+
+// RequestRunCancel asks a running run to stop. A run whose lease already expired, because its
+// worker is gone, is marked Cancelled at once so it can be resumed. It returns
+// ErrNoRunInProgress when the run is not running.
+func (r *PostgresMatchProgressRepository) RequestRunCancel(tenantID, matchSetID, runID string) (*models.MatchProgress, error) {
+	query := `
+		UPDATE match_progress
+		SET cancel_requested = TRUE,
+			status = CASE WHEN lease_expires_at < NOW() THEN 'Cancelled' ELSE status END,
+			completed_at = CASE WHEN lease_expires_at < NOW() THEN NOW() ELSE completed_at END,
+			lease_expires_at = CASE WHEN lease_expires_at < NOW() THEN NULL ELSE lease_expires_at END
+		WHERE match_set_id = $1 AND run_id = $2 AND status = 'Running'
+		RETURNING ` + matchProgressColumns
+
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	progress, err := scanMatchProgress(tx.QueryRow(query, matchSetID, runID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNoRunInProgress
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+// MockMatchProgressRepository is a mock implementation for development
+type MockMatchProgressRepository struct {
+	mu       sync.Mutex
+	progress map[string]*models.MatchProgress
+	tenants  map[string]string // match set ID -> tenant ID
+}
+
+// mockProgress returns the progress of a tenant's match set from the mock repository
+func (r *MockMatchProgressRepository) mockProgress(tenantID, matchSetID string) (*models.MatchProgress, bool) {
+	progress, exists := r.progress[matchSetID]
+	if !exists || r.tenants[matchSetID] != tenantID {
+		return nil, false
+	}
+	return progress, true
+}
+
+// GetProgress retrieves the progress of a tenant's match set from the mock repository
+func (r *MockMatchProgressRepository) GetProgress(tenantID, matchSetID string) (*models.MatchProgress, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if progress, exists := r.mockProgress(tenantID, matchSetID); exists {
+		copied := *progress
+		return &copied, nil
+	}
+	return nil, ErrMatchProgressNotFound
+}
+
+// SaveProgress stores the progress of a tenant's match set in the mock repository
+func (r *MockMatchProgressRepository) SaveProgress(tenantID string, progress *models.MatchProgress) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, exists := r.progress[progress.MatchSetID]; exists && (r.tenants[progress.MatchSetID] != tenantID || existing.RunID != progress.RunID) {
+		return ErrRunLeaseLost
+	}
+	if progress.Status != "Running" {
+		progress.LeaseExpiresAt = nil
+	}
+
+	copied := *progress
+	copied.LeaseExpiresAt = nil
+	copied.CancelRequested = false
+	if existing, exists := r.progress[progress.MatchSetID]; exists {
+		copied.CancelRequested = existing.CancelRequested
+		if progress.Status == "Running" {
+			copied.LeaseExpiresAt = existing.LeaseExpiresAt
+		}
+	}
+	r.progress[progress.MatchSetID] = &copied
+	r.tenants[progress.MatchSetID] = tenantID
+	return nil
+}
+
+// AcquireRunLease starts a run of a tenant's match set in the mock repository
+func (r *MockMatchProgressRepository) AcquireRunLease(tenantID, matchSetID string, lease time.Duration) (*models.MatchProgress, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	progress := &models.MatchProgress{MatchSetID: matchSetID, Mode: models.RunModeFull}
+	if _, exists := r.progress[matchSetID]; exists && r.tenants[matchSetID] != tenantID {
+		return nil, ErrMatchProgressNotFound
+	}
+	if existing, exists := r.progress[matchSetID]; exists {
+		if existing.Status == "Running" && existing.LeaseExpiresAt != nil && existing.LeaseExpiresAt.After(now) {
+			return nil, &RunInProgressError{RunID: existing.RunID, LeaseExpiresAt: *existing.LeaseExpiresAt}
+		}
+		*progress = *existing
+	}
+
+	expiresAt := now.Add(lease)
+	progress.RunID = uuid.New().String()
+	progress.Status = "Running"
+	progress.StartedAt = &now
+	progress.CompletedAt = nil
+	progress.LeaseExpiresAt = &expiresAt
+	progress.CancelRequested = false
+	progress.Error = ""
+
+	copied := *progress
+	r.progress[matchSetID] = &copied
+	r.tenants[matchSetID] = tenantID
+	return progress, nil
+}
+
+// RenewRunLease extends the lease of a running run in the mock repository
+func (r *MockMatchProgressRepository) RenewRunLease(tenantID, matchSetID, runID string, lease time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.mockProgress(tenantID, matchSetID)
+	if !exists || existing.RunID != runID || existing.Status != "Running" {
+		return false, ErrRunLeaseLost
+	}
+
+	expiresAt := time.Now().Add(lease)
+	existing.LeaseExpiresAt = &expiresAt
+	return existing.CancelRequested, nil
+}
+
+// RequestRunCancel asks a running run to stop in the mock repository
+func (r *MockMatchProgressRepository) RequestRunCancel(tenantID, matchSetID, runID string) (*models.MatchProgress, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.mockProgress(tenantID, matchSetID)
+	if !exists || existing.RunID != runID || existing.Status != "Running" {
+		return nil, ErrNoRunInProgress
+	}
+
+	existing.CancelRequested = true
+	if now := time.Now(); existing.LeaseExpiresAt != nil && existing.LeaseExpiresAt.Before(now) {
+		existing.Status = "Cancelled"
+		existing.CompletedAt = &now
+		existing.LeaseExpiresAt = nil
+	}
+
+	copied := *existing
+	return &copied, nil
+}
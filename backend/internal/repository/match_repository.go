@@ -5,24 +5,43 @@ import (
 	"backend/internal/models"
 	"database/sql"
 	"errors"
+	"sort"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 var (
-	ErrMatchNotFound = errors.New("match not found")
+	ErrMatchNotFound             = errors.New("match not found")
+	ErrTransactionAlreadyMatched = errors.New("transaction is already matched")
+	ErrTransactionNotInMatch     = errors.New("transaction not found in this match")
+	ErrMatchGroupChanged         = errors.New("match group was changed by another request")
+	ErrMatchNotPending           = errors.New("invalid match: only pending matches can be reviewed")
 )
 
 // MatchRepository defines operations for managing transaction matches
 type MatchRepository interface {
 	CreateMatch(match *models.TransactionMatch) error
-	GetMatchByID(id string) (*models.TransactionMatch, error)
-	GetMatchesByStatus(status string) ([]models.TransactionMatch, error)
-	UpdateMatchStatus(id string, status string, approvedBy string, reason string) error
-	GetMatchesByUser(userID string) ([]models.TransactionMatch, error)
-	SearchMatches(filters map[string]interface{}, limit, offset int) ([]models.TransactionMatch, int, error)
+	CreateMatchGroup(match *models.TransactionMatch, transactionIDs []string) error
+	GetMatchByID(tenantID, id string) (*models.TransactionMatch, error)
+	GetMatchesByStatus(tenantID, status string) ([]models.TransactionMatch, error)
+	GetMatchGroupsByMatchSet(tenantID, matchSetID string) (map[string][]string, error)
+	GetMatchGroup(tenantID, matchID string) ([]string, error)
+	AddTransactionToMatchGroup(match *models.TransactionMatch, transactionID string, expected []string) error
+	RemoveTransactionFromMatchGroup(tenantID, matchID, transactionID string, expected []string) error
+	DissolveMatchGroup(tenantID, matchID string, expected []string) error
+	GetMatchesByTenant(tenantID, status string, limit, offset int) ([]models.TransactionMatch, int, error)
+	ApproveMatchGroup(tenantID, matchID, approvedBy string) error
+	RejectMatchGroup(tenantID, matchID, rejectedBy, reason string) error
+	UpdateMatchStatus(tenantID, id string, status string, approvedBy string, reason string) error
+	GetMatchesByUser(tenantID, userID string) ([]models.TransactionMatch, error)
+	SearchMatches(filter models.MatchFilter, limit, offset int) ([]models.TransactionMatch, int, error)
 }
 
-// PostgresMatchRepository implements MatchRepository for PostgreSQL
+// PostgresMatchRepository implements MatchRepository for PostgreSQL. Every statement runs in the
+// session of the tenant the match belongs to, so lookups by match or match set ID only reach that
+// tenant's rows.
 type PostgresMatchRepository struct {
 	db *sql.DB
 }
@@ -33,6 +52,7 @@ func NewMatchRepository() MatchRepository {
 		// Return a mock repository for development
 		return &MockMatchRepository{
 			matches: make(map[string]*models.TransactionMatch),
+			groups:  make(map[string][]string),
 		}
 	}
 	return &PostgresMatchRepository{
@@ -44,9 +64,9 @@ func NewMatchRepository() MatchRepository {
 func (r *PostgresMatchRepository) CreateMatch(match *models.TransactionMatch) error {
 	query := `
 		INSERT INTO transaction_matches (
-			match_status, match_type, match_rule_id, matched_by
+			match_status, match_type, match_rule_id, matched_by, tenant_id, match_set_id
 		) VALUES (
-			$1, $2, $3, $4
+			$1, $2, $3, $4, $5, NULLIF($6, '')::uuid
 		) RETURNING id, created_at, updated_at
 	`
 
@@ -55,46 +75,158 @@ func (r *PostgresMatchRepository) CreateMatch(match *models.TransactionMatch) er
 		matchRuleIDParam = match.MatchRuleID
 	}
 
-	return r.db.QueryRow(
+	tx, err := beginTenant(r.db, match.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
 		query,
 		match.MatchStatus,
 		match.MatchType,
 		matchRuleIDParam,
 		match.MatchedBy,
+		match.TenantID,
+		match.MatchSetID,
 	).Scan(&match.ID, &match.CreatedAt, &match.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// GetMatchByID retrieves a match by ID
-func (r *PostgresMatchRepository) GetMatchByID(id string) (*models.TransactionMatch, error) {
-	query := `
-		SELECT 
-			id, match_status, match_type, match_rule_id, matched_by, 
-			approved_by, approval_date, rejection_reason, created_at, updated_at
-		FROM transaction_matches
-		WHERE id = $1
-	`
+// CreateMatchGroup creates a match for a group of transactions in a single database transaction.
+// It records the group in matched_transactions, marks the transactions as matched and clears
+// their unmatched records for the match set. A match created as Approved, by an auto-approval
+// policy, marks its transactions as approved instead. If any transaction is no longer unmatched
+// nothing is written and ErrTransactionAlreadyMatched is returned.
+func (r *PostgresMatchRepository) CreateMatchGroup(match *models.TransactionMatch, transactionIDs []string) error {
+	tx, err := beginTenant(r.db, match.TenantID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertMatchGroup(tx, match, transactionIDs); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertMatchGroup writes a match group within a database transaction, as CreateMatchGroup describes
+func insertMatchGroup(tx *sql.Tx, match *models.TransactionMatch, transactionIDs []string) error {
+	var matchRuleIDParam interface{} = nil
+	if match.MatchRuleID != "" {
+		matchRuleIDParam = match.MatchRuleID
+	}
+
+	var approvedByPolicyParam interface{} = nil
+	transactionStatus := "Matched"
+	if match.MatchStatus == "Approved" {
+		approvedByPolicyParam = match.ApprovedByPolicy
+		transactionStatus = "Approved"
+	}
+
+	var approvalDate sql.NullTime
+	err := tx.QueryRow(`
+		INSERT INTO transaction_matches (
+			match_status, match_type, match_rule_id, matched_by, tenant_id, match_set_id, match_score,
+			approved_by_policy, approval_date
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, CASE WHEN $8::text IS NULL THEN NULL ELSE NOW() END
+		) RETURNING id, approval_date, created_at, updated_at
+	`,
+		match.MatchStatus,
+		match.MatchType,
+		matchRuleIDParam,
+		match.MatchedBy,
+		match.TenantID,
+		match.MatchSetID,
+		match.MatchScore,
+		approvedByPolicyParam,
+	).Scan(&match.ID, &approvalDate, &match.CreatedAt, &match.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if approvalDate.Valid {
+		match.ApprovalDate = approvalDate.Time
+	}
+
+	// Only claim transactions that are still unmatched
+	result, err := tx.Exec(`
+		UPDATE transactions
+		SET status = $3, match_id = $1, updated_at = NOW()
+		WHERE id = ANY($2) AND status = 'Unmatched' AND match_id IS NULL
+	`, match.ID, pq.Array(transactionIDs), transactionStatus)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if int(rowsAffected) != len(transactionIDs) {
+		return ErrTransactionAlreadyMatched
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO matched_transactions (match_set_id, transaction_id, match_group_id, tenant_id)
+		VALUES ($1, $2, $3, $4)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, transactionID := range transactionIDs {
+		if _, err := stmt.Exec(match.MatchSetID, transactionID, match.ID, match.TenantID); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(
+		"DELETE FROM unmatched_transactions WHERE match_set_id = $1 AND transaction_id = ANY($2)",
+		match.MatchSetID, pq.Array(transactionIDs),
+	)
+	return err
+}
 
+// matchColumns is the column list scanned by scanMatch; queries alias transaction_matches as tm
+const matchColumns = `
+	tm.id, tm.match_status, tm.match_type, tm.match_rule_id, tm.matched_by,
+	tm.approved_by, COALESCE(tm.approved_by_policy, ''), tm.approval_date, COALESCE(tm.rejection_reason, ''),
+	COALESCE(tm.tenant_id::text, ''), COALESCE(tm.match_set_id::text, ''),
+	tm.match_score, tm.created_at, tm.updated_at
+`
+
+// scanMatch scans a transaction match selected with matchColumns
+func scanMatch(row rowScanner) (*models.TransactionMatch, error) {
 	var match models.TransactionMatch
 	var matchRuleID, approvedBy sql.NullString
 	var approvalDate sql.NullTime
 
-	err := r.db.QueryRow(query, id).Scan(
+	err := row.Scan(
 		&match.ID,
 		&match.MatchStatus,
 		&match.MatchType,
 		&matchRuleID,
 		&match.MatchedBy,
 		&approvedBy,
+		&match.ApprovedByPolicy,
 		&approvalDate,
 		&match.RejectionReason,
+		&match.TenantID,
+		&match.MatchSetID,
+		&match.MatchScore,
 		&match.CreatedAt,
 		&match.UpdatedAt,
 	)
-
-	if err == sql.ErrNoRows {
-		return nil, ErrMatchNotFound
-	}
-
 	if err != nil {
 		return nil, err
 	}
@@ -114,18 +246,9 @@ func (r *PostgresMatchRepository) GetMatchByID(id string) (*models.TransactionMa
 	return &match, nil
 }
 
-// GetMatchesByStatus retrieves matches by status
-func (r *PostgresMatchRepository) GetMatchesByStatus(status string) ([]models.TransactionMatch, error) {
-	query := `
-		SELECT 
-			id, match_status, match_type, match_rule_id, matched_by, 
-			approved_by, approval_date, rejection_reason, created_at, updated_at
-		FROM transaction_matches
-		WHERE match_status = $1
-		ORDER BY created_at DESC
-	`
-
-	rows, err := r.db.Query(query, status)
+// queryMatches runs a query selecting matchColumns and scans every row
+func queryMatches(q querier, query string, args ...interface{}) ([]models.TransactionMatch, error) {
+	rows, err := q.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -133,51 +256,458 @@ func (r *PostgresMatchRepository) GetMatchesByStatus(status string) ([]models.Tr
 
 	var matches []models.TransactionMatch
 	for rows.Next() {
-		var match models.TransactionMatch
-		var matchRuleID, approvedBy sql.NullString
-		var approvalDate sql.NullTime
-
-		err := rows.Scan(
-			&match.ID,
-			&match.MatchStatus,
-			&match.MatchType,
-			&matchRuleID,
-			&match.MatchedBy,
-			&approvedBy,
-			&approvalDate,
-			&match.RejectionReason,
-			&match.CreatedAt,
-			&match.UpdatedAt,
-		)
-
+		match, err := scanMatch(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		if matchRuleID.Valid {
-			match.MatchRuleID = matchRuleID.String
-		}
+		matches = append(matches, *match)
+	}
 
-		if approvedBy.Valid {
-			match.ApprovedBy = approvedBy.String
-		}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// GetMatchByID retrieves a match of a tenant by ID
+func (r *PostgresMatchRepository) GetMatchByID(tenantID, id string) (*models.TransactionMatch, error) {
+	query := "SELECT " + matchColumns + " FROM transaction_matches tm WHERE tm.id = $1"
+
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	match, err := scanMatch(tx.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrMatchNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return match, nil
+}
 
-		if approvalDate.Valid {
-			match.ApprovalDate = approvalDate.Time
+// GetMatchesByStatus retrieves a tenant's matches by status
+func (r *PostgresMatchRepository) GetMatchesByStatus(tenantID, status string) ([]models.TransactionMatch, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return queryMatches(
+		tx,
+		"SELECT "+matchColumns+" FROM transaction_matches tm WHERE tm.match_status = $1 ORDER BY tm.created_at DESC",
+		status,
+	)
+}
+
+// GetMatchGroupsByMatchSet retrieves the transaction IDs of every match group of a tenant's match
+// set, keyed by match ID
+func (r *PostgresMatchRepository) GetMatchGroupsByMatchSet(tenantID, matchSetID string) (map[string][]string, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT match_group_id, transaction_id
+		FROM matched_transactions
+		WHERE match_set_id = $1
+		ORDER BY match_group_id, transaction_id
+	`, matchSetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make(map[string][]string)
+	for rows.Next() {
+		var matchID, transactionID string
+		if err := rows.Scan(&matchID, &transactionID); err != nil {
+			return nil, err
 		}
+		groups[matchID] = append(groups[matchID], transactionID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		matches = append(matches, match)
+	return groups, nil
+}
+
+// GetMatchGroup retrieves the IDs of the transactions in a tenant's match group
+func (r *PostgresMatchRepository) GetMatchGroup(tenantID, matchID string) ([]string, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return queryGroup(tx, matchID)
+}
+
+// queryGroup reads the members of a match group
+func queryGroup(q querier, matchID string) ([]string, error) {
+	rows, err := q.Query(
+		"SELECT transaction_id FROM matched_transactions WHERE match_group_id = $1 ORDER BY transaction_id",
+		matchID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactionIDs []string
+	for rows.Next() {
+		var transactionID string
+		if err := rows.Scan(&transactionID); err != nil {
+			return nil, err
+		}
+		transactionIDs = append(transactionIDs, transactionID)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return matches, nil
+	return transactionIDs, nil
+}
+
+// lockGroup locks a match for the rest of the database transaction and checks that its group
+// still holds the expected transactions, so changes validated against a stale group are refused
+func lockGroup(tx *sql.Tx, matchID string, expected []string) error {
+	var id string
+	err := tx.QueryRow("SELECT id FROM transaction_matches WHERE id = $1 FOR UPDATE", matchID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrMatchNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	current, err := queryGroup(tx, matchID)
+	if err != nil {
+		return err
+	}
+	if !sameMembers(current, expected) {
+		return ErrMatchGroupChanged
+	}
+	return nil
+}
+
+// AddTransactionToMatchGroup adds an unmatched transaction to a match group in a single database
+// transaction, marking it as matched and clearing its unmatched record for the match set
+func (r *PostgresMatchRepository) AddTransactionToMatchGroup(match *models.TransactionMatch, transactionID string, expected []string) error {
+	tx, err := beginTenant(r.db, match.TenantID)
+	if err != nil {
+		return err
+	}
+
+	if err := addToMatchGroup(tx, match, transactionID, expected); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// addToMatchGroup adds a transaction to a match group within a database transaction, as
+// AddTransactionToMatchGroup describes
+func addToMatchGroup(tx *sql.Tx, match *models.TransactionMatch, transactionID string, expected []string) error {
+	if err := lockGroup(tx, match.ID, expected); err != nil {
+		return err
+	}
+
+	// Only claim the transaction if it is still unmatched
+	result, err := tx.Exec(`
+		UPDATE transactions
+		SET status = 'Matched', match_id = $1, updated_at = NOW()
+		WHERE id = $2 AND status = 'Unmatched' AND match_id IS NULL
+	`, match.ID, transactionID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected != 1 {
+		return ErrTransactionAlreadyMatched
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO matched_transactions (match_set_id, transaction_id, match_group_id, tenant_id)
+		VALUES ($1, $2, $3, $4)
+	`, match.MatchSetID, transactionID, match.ID, match.TenantID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"DELETE FROM unmatched_transactions WHERE match_set_id = $1 AND transaction_id = $2",
+		match.MatchSetID, transactionID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.QueryRow(
+		"UPDATE transaction_matches SET updated_at = NOW() WHERE id = $1 RETURNING updated_at",
+		match.ID,
+	).Scan(&match.UpdatedAt)
+}
+
+// RemoveTransactionFromMatchGroup takes a transaction out of a match group in a single database
+// transaction and marks it as unmatched again
+func (r *PostgresMatchRepository) RemoveTransactionFromMatchGroup(tenantID, matchID, transactionID string, expected []string) error {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+
+	if err := removeFromMatchGroup(tx, matchID, transactionID, expected); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// removeFromMatchGroup takes a transaction out of a match group within a database transaction, as
+// RemoveTransactionFromMatchGroup describes
+func removeFromMatchGroup(tx *sql.Tx, matchID, transactionID string, expected []string) error {
+	if err := lockGroup(tx, matchID, expected); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE transactions
+		SET status = 'Unmatched', match_id = NULL, updated_at = NOW()
+		WHERE id = $1 AND match_id = $2
+	`, transactionID, matchID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected != 1 {
+		return ErrTransactionNotInMatch
+	}
+
+	_, err = tx.Exec(
+		"DELETE FROM matched_transactions WHERE match_group_id = $1 AND transaction_id = $2",
+		matchID, transactionID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE transaction_matches SET updated_at = NOW() WHERE id = $1", matchID)
+	return err
+}
+
+// DissolveMatchGroup unmatches a group in a single database transaction. Its transactions are
+// marked as unmatched again and the match is kept with the Unmatched status.
+func (r *PostgresMatchRepository) DissolveMatchGroup(tenantID, matchID string, expected []string) error {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+
+	if err := dissolveMatchGroup(tx, matchID, expected); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// dissolveMatchGroup unmatches a group within a database transaction, as DissolveMatchGroup
+// describes
+func dissolveMatchGroup(tx *sql.Tx, matchID string, expected []string) error {
+	if err := lockGroup(tx, matchID, expected); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(`
+		UPDATE transactions
+		SET status = 'Unmatched', match_id = NULL, updated_at = NOW()
+		WHERE match_id = $1
+	`, matchID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM matched_transactions WHERE match_group_id = $1", matchID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE transaction_matches SET match_status = 'Unmatched', updated_at = NOW() WHERE id = $1",
+		matchID,
+	)
+	return err
+}
+
+// GetMatchesByTenant retrieves a page of a tenant's matches with a status, oldest first, and the total count
+func (r *PostgresMatchRepository) GetMatchesByTenant(tenantID, status string, limit, offset int) ([]models.TransactionMatch, int, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	var total int
+	err = tx.QueryRow(
+		"SELECT COUNT(*) FROM transaction_matches WHERE tenant_id = $1 AND match_status = $2",
+		tenantID, status,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	matches, err := queryMatches(
+		tx,
+		"SELECT "+matchColumns+` FROM transaction_matches tm
+		WHERE tm.tenant_id = $1 AND tm.match_status = $2
+		ORDER BY tm.created_at, tm.id
+		LIMIT $3 OFFSET $4`,
+		tenantID, status, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return matches, total, nil
+}
+
+// ApproveMatchGroup approves a pending match and marks its transactions as approved in a single
+// database transaction. ErrMatchNotPending is returned when the match is no longer pending.
+func (r *PostgresMatchRepository) ApproveMatchGroup(tenantID, matchID, approvedBy string) error {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE transaction_matches
+		SET match_status = 'Approved', approved_by = $1, approval_date = NOW(), updated_at = NOW()
+		WHERE id = $2 AND match_status = 'Pending'
+	`, approvedBy, matchID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := requireOneRow(result); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE transactions SET status = 'Approved', updated_at = NOW() WHERE match_id = $1",
+		matchID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RejectMatchGroup rejects a pending match and releases its transactions back to unmatched in a
+// single database transaction. ErrMatchNotPending is returned when the match is no longer pending.
+func (r *PostgresMatchRepository) RejectMatchGroup(tenantID, matchID, rejectedBy, reason string) error {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+
+	if err := rejectMatchGroup(tx, matchID, rejectedBy, reason); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// rejectMatchGroup rejects a pending match within a database transaction, as RejectMatchGroup
+// describes
+func rejectMatchGroup(tx *sql.Tx, matchID, rejectedBy, reason string) error {
+	result, err := tx.Exec(`
+		UPDATE transaction_matches
+		SET match_status = 'Rejected', approved_by = $1, rejection_reason = $2, updated_at = NOW()
+		WHERE id = $3 AND match_status = 'Pending'
+	`, rejectedBy, reason, matchID)
+	if err != nil {
+		return err
+	}
+
+	if err := requireOneRow(result); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE transactions
+		SET status = 'Unmatched', match_id = NULL, updated_at = NOW()
+		WHERE match_id = $1
+	`, matchID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM matched_transactions WHERE match_group_id = $1", matchID)
+	return err
+}
+
+// requireOneRow returns ErrMatchNotPending unless a status update changed exactly one match
+func requireOneRow(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected != 1 {
+		return ErrMatchNotPending
+	}
+	return nil
+}
+
+// sameMembers reports whether two lists hold the same transaction IDs, in any order
+func sameMembers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int, len(a))
+	for _, id := range a {
+		counts[id]++
+	}
+	for _, id := range b {
+		if counts[id] == 0 {
+			return false
+		}
+		counts[id]--
+	}
+	return true
 }
 
-// UpdateMatchStatus updates a match's status
-func (r *PostgresMatchRepository) UpdateMatchStatus(id string, status string, approvedBy string, reason string) error {
+// UpdateMatchStatus updates the status of a tenant's match
+func (r *PostgresMatchRepository) UpdateMatchStatus(tenantID, id string, status string, approvedBy string, reason string) error {
 	query := `
 		UPDATE transaction_matches
 		SET match_status = $1,
@@ -212,197 +742,114 @@ func (r *PostgresMatchRepository) UpdateMatchStatus(id string, status string, ap
 		params = append(params, id)
 	}
 
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	var updatedAt time.Time
-	err := r.db.QueryRow(query, params...).Scan(&updatedAt)
+	err = tx.QueryRow(query, params...).Scan(&updatedAt)
 
 	if err == sql.ErrNoRows {
 		return ErrMatchNotFound
 	}
 
-	return err
-}
-
-// GetMatchesByUser retrieves matches created by a specific user
-func (r *PostgresMatchRepository) GetMatchesByUser(userID string) ([]models.TransactionMatch, error) {
-	query := `
-		SELECT 
-			id, match_status, match_type, match_rule_id, matched_by, 
-			approved_by, approval_date, rejection_reason, created_at, updated_at
-		FROM transaction_matches
-		WHERE matched_by = $1
-		ORDER BY created_at DESC
-	`
-
-	rows, err := r.db.Query(query, userID)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
 
-	var matches []models.TransactionMatch
-	for rows.Next() {
-		var match models.TransactionMatch
-		var matchRuleID, approvedBy sql.NullString
-		var approvalDate sql.NullTime
-
-		err := rows.Scan(
-			&match.ID,
-			&match.MatchStatus,
-			&match.MatchType,
-			&matchRuleID,
-			&match.MatchedBy,
-			&approvedBy,
-			&approvalDate,
-			&match.RejectionReason,
-			&match.CreatedAt,
-			&match.UpdatedAt,
-		)
-
-		if err != nil {
-			return nil, err
-		}
-
-		if matchRuleID.Valid {
-			match.MatchRuleID = matchRuleID.String
-		}
-
-		if approvedBy.Valid {
-			match.ApprovedBy = approvedBy.String
-		}
-
-		if approvalDate.Valid {
-			match.ApprovalDate = approvalDate.Time
-		}
-
-		matches = append(matches, match)
-	}
+	return tx.Commit()
+}
 
-	if err := rows.Err(); err != nil {
+// GetMatchesByUser retrieves the matches a specific user created in a tenant
+func (r *PostgresMatchRepository) GetMatchesByUser(tenantID, userID string) ([]models.TransactionMatch, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	return matches, nil
+	return queryMatches(
+		tx,
+		"SELECT "+matchColumns+" FROM transaction_matches tm WHERE tm.matched_by = $1 ORDER BY tm.created_at DESC",
+		userID,
+	)
 }
 
-// SearchMatches searches for transaction matches using filters
-func (r *PostgresMatchRepository) SearchMatches(filters map[string]interface{}, limit, offset int) ([]models.TransactionMatch, int, error) {
-	// Start building the base query
-	baseQuery := `
-		FROM transaction_matches tm
-		WHERE 1=1
-	`
-
-	// Build filter conditions and parameters
-	var conditions []string
-	var params []interface{}
-	paramIndex := 1
+// matchSortColumns maps the fields matches can be sorted by to their columns
+var matchSortColumns = map[string]string{
+	models.MatchSortCreatedAt: "tm.created_at",
+	models.MatchSortStatus:    "tm.match_status",
+	models.MatchSortScore:     "tm.match_score",
+}
 
-	// Add filter conditions
-	if val, ok := filters["status"]; ok {
-		conditions = append(conditions, "tm.match_status = $"+string(paramIndex))
-		params = append(params, val)
-		paramIndex++
+// matchSearchQuery returns the query builder selecting the matches of a filter
+func matchSearchQuery(filter models.MatchFilter, limit, offset int) (*queryBuilder, error) {
+	b := newQueryBuilder()
+	if filter.TenantID != "" {
+		b.where("tm.tenant_id = ?", filter.TenantID)
 	}
-
-	if val, ok := filters["matchType"]; ok {
-		conditions = append(conditions, "tm.match_type = $"+string(paramIndex))
-		params = append(params, val)
-		paramIndex++
+	if filter.MatchSetID != "" {
+		b.where("tm.match_set_id = ?", filter.MatchSetID)
 	}
-
-	if val, ok := filters["matchedBy"]; ok {
-		conditions = append(conditions, "tm.matched_by = $"+string(paramIndex))
-		params = append(params, val)
-		paramIndex++
+	if filter.Status != "" {
+		b.where("tm.match_status = ?", filter.Status)
 	}
-
-	if val, ok := filters["approvedBy"]; ok {
-		conditions = append(conditions, "tm.approved_by = $"+string(paramIndex))
-		params = append(params, val)
-		paramIndex++
+	if filter.MatchType != "" {
+		b.where("tm.match_type = ?", filter.MatchType)
 	}
-
-	if val, ok := filters["dateFrom"]; ok {
-		conditions = append(conditions, "tm.created_at >= $"+string(paramIndex))
-		params = append(params, val)
-		paramIndex++
+	if filter.MatchedBy != "" {
+		b.where("tm.matched_by = ?", filter.MatchedBy)
 	}
-
-	if val, ok := filters["dateTo"]; ok {
-		conditions = append(conditions, "tm.created_at <= $"+string(paramIndex))
-		params = append(params, val)
-		paramIndex++
+	if filter.ApprovedBy != "" {
+		b.where("tm.approved_by = ?", filter.ApprovedBy)
+	}
+	if filter.DateFrom != nil {
+		b.where("tm.created_at >= ?", *filter.DateFrom)
+	}
+	if filter.DateTo != nil {
+		b.where("tm.created_at <= ?", *filter.DateTo)
 	}
 
-	// Apply the conditions
-	for _, condition := range conditions {
-		baseQuery += " AND " + condition
+	sortBy, desc := filter.SortBy, filter.SortDesc
+	if sortBy == "" {
+		sortBy, desc = models.MatchSortCreatedAt, true
+	}
+	if err := b.sortBy(matchSortColumns, sortBy, desc); err != nil {
+		return nil, err
 	}
+	b.orderByColumn("tm.id", desc)
 
-	// Count query for pagination
-	countQuery := "SELECT COUNT(*) " + baseQuery
-	var total int
-	err := r.db.QueryRow(countQuery, params...).Scan(&total)
+	b.paginate(limit, offset)
+	return b, nil
+}
+
+// SearchMatches searches for transaction matches using a filter
+func (r *PostgresMatchRepository) SearchMatches(filter models.MatchFilter, limit, offset int) ([]models.TransactionMatch, int, error) {
+	b, err := matchSearchQuery(filter, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	// Data query
-	dataQuery := `
-		SELECT 
-			tm.id, tm.match_status, tm.match_type, tm.match_rule_id, tm.matched_by, 
-			tm.approved_by, tm.approval_date, tm.rejection_reason, tm.created_at, tm.updated_at
-	` + baseQuery + `
-		ORDER BY tm.created_at DESC
-		LIMIT $` + string(paramIndex) + ` OFFSET $` + string(paramIndex+1)
-
-	params = append(params, limit, offset)
-
-	rows, err := r.db.Query(dataQuery, params...)
+	// Searches without a tenant find nothing, as the session is not scoped to one
+	tx, err := beginTenant(r.db, filter.TenantID)
 	if err != nil {
 		return nil, 0, err
 	}
-	defer rows.Close()
-
-	var matches []models.TransactionMatch
-	for rows.Next() {
-		var match models.TransactionMatch
-		var matchRuleID, approvedBy sql.NullString
-		var approvalDate sql.NullTime
-
-		err := rows.Scan(
-			&match.ID,
-			&match.MatchStatus,
-			&match.MatchType,
-			&matchRuleID,
-			&match.MatchedBy,
-			&approvedBy,
-			&approvalDate,
-			&match.RejectionReason,
-			&match.CreatedAt,
-			&match.UpdatedAt,
-		)
-
-		if err != nil {
-			return nil, 0, err
-		}
-
-		if matchRuleID.Valid {
-			match.MatchRuleID = matchRuleID.String
-		}
+	defer tx.Rollback()
 
-		if approvedBy.Valid {
-			match.ApprovedBy = approvedBy.String
-		}
-
-		if approvalDate.Valid {
-			match.ApprovalDate = approvalDate.Time
-		}
-
-		matches = append(matches, match)
+	// Count query for pagination
+	countQuery, countArgs := b.count("FROM transaction_matches tm")
+	var total int
+	if err := tx.QueryRow(countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, 0, err
 	}
 
-	if err := rows.Err(); err != nil {
+	// Data query
+	query, args := b.build("SELECT " + matchColumns + " FROM transaction_matches tm")
+	matches, err := queryMatches(tx, query, args...)
+	if err != nil {
 		return nil, 0, err
 	}
 
@@ -412,6 +859,7 @@ func (r *PostgresMatchRepository) SearchMatches(filters map[string]interface{},
 // MockMatchRepository is a mock implementation for development
 type MockMatchRepository struct {
 	matches map[string]*models.TransactionMatch
+	groups  map[string][]string // match ID -> transaction IDs
 }
 
 // CreateMatch creates a match in the mock repository
@@ -426,28 +874,212 @@ func (r *MockMatchRepository) CreateMatch(match *models.TransactionMatch) error
 	return nil
 }
 
-// GetMatchByID retrieves a match by ID from the mock repository
-func (r *MockMatchRepository) GetMatchByID(id string) (*models.TransactionMatch, error) {
-	if match, exists := r.matches[id]; exists {
+// CreateMatchGroup creates a match for a group of transactions in the mock repository
+func (r *MockMatchRepository) CreateMatchGroup(match *models.TransactionMatch, transactionIDs []string) error {
+	if r.groups == nil {
+		r.groups = make(map[string][]string)
+	}
+
+	// Reject transactions that already belong to a group
+	for _, ids := range r.groups {
+		for _, id := range ids {
+			for _, transactionID := range transactionIDs {
+				if id == transactionID {
+					return ErrTransactionAlreadyMatched
+				}
+			}
+		}
+	}
+
+	if match.ID == "" {
+		match.ID = uuid.New().String()
+	}
+	match.CreatedAt = time.Now()
+	match.UpdatedAt = time.Now()
+	if match.MatchStatus == "Approved" {
+		match.ApprovalDate = match.CreatedAt
+	}
+	r.matches[match.ID] = match
+	r.groups[match.ID] = append([]string(nil), transactionIDs...)
+	return nil
+}
+
+// mockMatch returns a tenant's match from the mock repository
+func (r *MockMatchRepository) mockMatch(tenantID, id string) (*models.TransactionMatch, bool) {
+	match, exists := r.matches[id]
+	if !exists || match.TenantID != tenantID {
+		return nil, false
+	}
+	return match, true
+}
+
+// GetMatchByID retrieves a tenant's match by ID from the mock repository
+func (r *MockMatchRepository) GetMatchByID(tenantID, id string) (*models.TransactionMatch, error) {
+	if match, exists := r.mockMatch(tenantID, id); exists {
 		return match, nil
 	}
 	return nil, ErrMatchNotFound
 }
 
-// GetMatchesByStatus retrieves matches by status from the mock repository
-func (r *MockMatchRepository) GetMatchesByStatus(status string) ([]models.TransactionMatch, error) {
+// GetMatchesByStatus retrieves a tenant's matches by status from the mock repository
+func (r *MockMatchRepository) GetMatchesByStatus(tenantID, status string) ([]models.TransactionMatch, error) {
 	var matches []models.TransactionMatch
 	for _, match := range r.matches {
-		if match.MatchStatus == status {
+		if match.TenantID == tenantID && match.MatchStatus == status {
 			matches = append(matches, *match)
 		}
 	}
 	return matches, nil
 }
 
-// UpdateMatchStatus updates a match's status in the mock repository
-func (r *MockMatchRepository) UpdateMatchStatus(id string, status string, approvedBy string, reason string) error {
-	match, exists := r.matches[id]
+// GetMatchGroupsByMatchSet retrieves the match groups of a tenant's match set from the mock repository
+func (r *MockMatchRepository) GetMatchGroupsByMatchSet(tenantID, matchSetID string) (map[string][]string, error) {
+	groups := make(map[string][]string)
+	for matchID, transactionIDs := range r.groups {
+		if match := r.matches[matchID]; match.TenantID == tenantID && match.MatchSetID == matchSetID {
+			groups[matchID] = append([]string(nil), transactionIDs...)
+		}
+	}
+	return groups, nil
+}
+
+// GetMatchGroup retrieves the members of a tenant's match group from the mock repository
+func (r *MockMatchRepository) GetMatchGroup(tenantID, matchID string) ([]string, error) {
+	if _, exists := r.mockMatch(tenantID, matchID); !exists {
+		return nil, ErrMatchNotFound
+	}
+	return append([]string(nil), r.groups[matchID]...), nil
+}
+
+// mockGroupOf returns the match holding a transaction in the mock repository
+func (r *MockMatchRepository) mockGroupOf(transactionID string) (string, bool) {
+	for matchID, ids := range r.groups {
+		for _, id := range ids {
+			if id == transactionID {
+				return matchID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// checkGroup checks that a tenant's mock match exists and its group holds the expected transactions
+func (r *MockMatchRepository) checkGroup(tenantID, matchID string, expected []string) error {
+	if _, exists := r.mockMatch(tenantID, matchID); !exists {
+		return ErrMatchNotFound
+	}
+	if !sameMembers(r.groups[matchID], expected) {
+		return ErrMatchGroupChanged
+	}
+	return nil
+}
+
+// AddTransactionToMatchGroup adds a transaction to a match group in the mock repository
+func (r *MockMatchRepository) AddTransactionToMatchGroup(match *models.TransactionMatch, transactionID string, expected []string) error {
+	if err := r.checkGroup(match.TenantID, match.ID, expected); err != nil {
+		return err
+	}
+	if _, matched := r.mockGroupOf(transactionID); matched {
+		return ErrTransactionAlreadyMatched
+	}
+
+	r.groups[match.ID] = append(r.groups[match.ID], transactionID)
+	r.matches[match.ID].UpdatedAt = time.Now()
+	match.UpdatedAt = r.matches[match.ID].UpdatedAt
+	return nil
+}
+
+// RemoveTransactionFromMatchGroup takes a transaction out of a match group in the mock repository
+func (r *MockMatchRepository) RemoveTransactionFromMatchGroup(tenantID, matchID, transactionID string, expected []string) error {
+	if err := r.checkGroup(tenantID, matchID, expected); err != nil {
+		return err
+	}
+	if groupID, matched := r.mockGroupOf(transactionID); !matched || groupID != matchID {
+		return ErrTransactionNotInMatch
+	}
+
+	var remaining []string
+	for _, id := range r.groups[matchID] {
+		if id != transactionID {
+			remaining = append(remaining, id)
+		}
+	}
+	r.groups[matchID] = remaining
+	r.matches[matchID].UpdatedAt = time.Now()
+	return nil
+}
+
+// DissolveMatchGroup unmatches a group in the mock repository
+func (r *MockMatchRepository) DissolveMatchGroup(tenantID, matchID string, expected []string) error {
+	if err := r.checkGroup(tenantID, matchID, expected); err != nil {
+		return err
+	}
+
+	delete(r.groups, matchID)
+	r.matches[matchID].MatchStatus = "Unmatched"
+	r.matches[matchID].UpdatedAt = time.Now()
+	return nil
+}
+
+// GetMatchesByTenant retrieves a page of a tenant's matches with a status from the mock repository
+func (r *MockMatchRepository) GetMatchesByTenant(tenantID, status string, limit, offset int) ([]models.TransactionMatch, int, error) {
+	var matches []models.TransactionMatch
+	for _, match := range r.matches {
+		if match.TenantID == tenantID && match.MatchStatus == status {
+			matches = append(matches, *match)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].CreatedAt.Equal(matches[j].CreatedAt) {
+			return matches[i].CreatedAt.Before(matches[j].CreatedAt)
+		}
+		return matches[i].ID < matches[j].ID
+	})
+
+	total := len(matches)
+	start := min(offset, total)
+	end := min(start+limit, total)
+	return matches[start:end], total, nil
+}
+
+// ApproveMatchGroup approves a pending match in the mock repository
+func (r *MockMatchRepository) ApproveMatchGroup(tenantID, matchID, approvedBy string) error {
+	match, exists := r.mockMatch(tenantID, matchID)
+	if !exists {
+		return ErrMatchNotFound
+	}
+	if match.MatchStatus != "Pending" {
+		return ErrMatchNotPending
+	}
+
+	match.MatchStatus = "Approved"
+	match.ApprovedBy = approvedBy
+	match.ApprovalDate = time.Now()
+	match.UpdatedAt = time.Now()
+	return nil
+}
+
+// RejectMatchGroup rejects a pending match and releases its group in the mock repository
+func (r *MockMatchRepository) RejectMatchGroup(tenantID, matchID, rejectedBy, reason string) error {
+	match, exists := r.mockMatch(tenantID, matchID)
+	if !exists {
+		return ErrMatchNotFound
+	}
+	if match.MatchStatus != "Pending" {
+		return ErrMatchNotPending
+	}
+
+	match.MatchStatus = "Rejected"
+	match.ApprovedBy = rejectedBy
+	match.RejectionReason = reason
+	match.UpdatedAt = time.Now()
+	delete(r.groups, matchID)
+	return nil
+}
+
+// UpdateMatchStatus updates the status of a tenant's match in the mock repository
+func (r *MockMatchRepository) UpdateMatchStatus(tenantID, id string, status string, approvedBy string, reason string) error {
+	match, exists := r.mockMatch(tenantID, id)
 	if !exists {
 		return ErrMatchNotFound
 	}
@@ -466,25 +1098,61 @@ func (r *MockMatchRepository) UpdateMatchStatus(id string, status string, approv
 	return nil
 }
 
-// GetMatchesByUser retrieves matches created by a specific user from the mock repository
-func (r *MockMatchRepository) GetMatchesByUser(userID string) ([]models.TransactionMatch, error) {
+// GetMatchesByUser retrieves the matches a specific user created in a tenant from the mock repository
+func (r *MockMatchRepository) GetMatchesByUser(tenantID, userID string) ([]models.TransactionMatch, error) {
 	var matches []models.TransactionMatch
 	for _, match := range r.matches {
-		if match.MatchedBy == userID {
+		if match.TenantID == tenantID && match.MatchedBy == userID {
 			matches = append(matches, *match)
 		}
 	}
 	return matches, nil
 }
 
-// SearchMatches searches for transaction matches using filters in the mock repository
-func (r *MockMatchRepository) SearchMatches(filters map[string]interface{}, limit, offset int) ([]models.TransactionMatch, int, error) {
-	// Simple implementation for mock - just return all matches
+// SearchMatches searches for transaction matches using a filter in the mock repository
+func (r *MockMatchRepository) SearchMatches(filter models.MatchFilter, limit, offset int) ([]models.TransactionMatch, int, error) {
+	sortBy, desc := filter.SortBy, filter.SortDesc
+	if sortBy == "" {
+		sortBy, desc = models.MatchSortCreatedAt, true
+	}
+	if _, ok := matchSortColumns[sortBy]; !ok {
+		return nil, 0, errors.New("invalid sort: unknown field " + sortBy)
+	}
+
 	var matches []models.TransactionMatch
 	for _, match := range r.matches {
+		if match.TenantID != filter.TenantID ||
+			(filter.MatchSetID != "" && match.MatchSetID != filter.MatchSetID) ||
+			(filter.Status != "" && match.MatchStatus != filter.Status) ||
+			(filter.MatchType != "" && match.MatchType != filter.MatchType) ||
+			(filter.MatchedBy != "" && match.MatchedBy != filter.MatchedBy) ||
+			(filter.ApprovedBy != "" && match.ApprovedBy != filter.ApprovedBy) ||
+			(filter.DateFrom != nil && match.CreatedAt.Before(*filter.DateFrom)) ||
+			(filter.DateTo != nil && match.CreatedAt.After(*filter.DateTo)) {
+			continue
+		}
 		matches = append(matches, *match)
 	}
 
+	sort.Slice(matches, func(i, j int) bool {
+		var c int
+		switch sortBy {
+		case models.MatchSortStatus:
+			c = compareSortValues(matches[i].MatchStatus, matches[j].MatchStatus)
+		case models.MatchSortScore:
+			c = compareSortValues(matches[i].MatchScore, matches[j].MatchScore)
+		default:
+			c = compareSortValues(matches[i].CreatedAt, matches[j].CreatedAt)
+		}
+		if c == 0 {
+			c = compareSortValues(matches[i].ID, matches[j].ID)
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
 	// Apply limit and offset
 	start := offset
 	if start > len(matches) {
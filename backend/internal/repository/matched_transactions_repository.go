@@ -5,33 +5,49 @@ import (
 	"backend/internal/models"
 	"database/sql"
 	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/google/uuid"
 )
 
+var ErrUnmatchedTransactionNotFound = errors.New("unmatched transaction not found")
+
 // MatchedTransactionRepository defines operations for managing matched transactions
 type MatchedTransactionRepository interface {
 	CreateMatchedTransaction(matchedTx *models.MatchedTransaction) error
-	GetMatchedTransactionsByMatchSet(matchSetID string, limit, offset int) ([]models.MatchedTransaction, int, error)
+	GetMatchedTransactionsByMatchSet(tenantID, matchSetID string, limit, offset int) ([]models.MatchedTransaction, int, error)
 	GetMatchedTransactionsByTenant(tenantID string, limit, offset int) ([]models.MatchedTransaction, int, error)
-	GetMatchedTransactionByID(id string) (*models.MatchedTransaction, error)
-	GetMatchedTransactionByTransactionID(transactionID string) (*models.MatchedTransaction, error)
-	GetMatchedTransactionsByMatchGroup(matchGroupID string) ([]models.MatchedTransaction, error)
+	GetMatchedTransactionByID(tenantID, id string) (*models.MatchedTransaction, error)
+	GetMatchedTransactionByTransactionID(tenantID, transactionID string) (*models.MatchedTransaction, error)
+	GetMatchedTransactionsByMatchGroup(tenantID, matchGroupID string) ([]models.MatchedTransaction, error)
 }
 
 // UnmatchedTransactionRepository defines operations for managing unmatched transactions
 type UnmatchedTransactionRepository interface {
 	CreateUnmatchedTransaction(unmatchedTx *models.UnmatchedTransaction) error
-	GetUnmatchedTransactionsByMatchSet(matchSetID string, limit, offset int) ([]models.UnmatchedTransaction, int, error)
+	SaveUnmatchedTransaction(unmatchedTx *models.UnmatchedTransaction) error
+	GetUnmatchedTransactionsByMatchSet(tenantID, matchSetID string, limit, offset int) ([]models.UnmatchedTransaction, int, error)
 	GetUnmatchedTransactionsByTenant(tenantID string, limit, offset int) ([]models.UnmatchedTransaction, int, error)
-	GetUnmatchedTransactionByID(id string) (*models.UnmatchedTransaction, error)
+	GetUnmatchedTransactionByID(tenantID, id string) (*models.UnmatchedTransaction, error)
+	ListExceptions(filter models.ExceptionFilter, limit, offset int) ([]models.UnmatchedTransaction, int, error)
+	UpdateException(unmatchedTx *models.UnmatchedTransaction) error
+	CountExceptions(tenantID, matchSetID string, now time.Time) ([]models.ExceptionCount, error)
+	AddExceptionComment(comment *models.ExceptionComment) error
+	GetExceptionComments(tenantID, unmatchedID string) ([]models.ExceptionComment, error)
 }
 
-// PostgresMatchedTransactionRepository implements MatchedTransactionRepository for PostgreSQL
+// PostgresMatchedTransactionRepository implements MatchedTransactionRepository for PostgreSQL.
+// Every statement runs in the session of the tenant the records belong to.
 type PostgresMatchedTransactionRepository struct {
 	db *sql.DB
 }
 
-// PostgresUnmatchedTransactionRepository implements UnmatchedTransactionRepository for PostgreSQL
+// PostgresUnmatchedTransactionRepository implements UnmatchedTransactionRepository for PostgreSQL.
+// Every statement runs in the session of the tenant the records belong to.
 type PostgresUnmatchedTransactionRepository struct {
 	db *sql.DB
 }
@@ -70,17 +86,34 @@ func (r *PostgresMatchedTransactionRepository) CreateMatchedTransaction(matchedT
 		RETURNING id, created_at
 	`
 
-	return r.db.QueryRow(
+	tx, err := beginTenant(r.db, matchedTx.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
 		query,
 		matchedTx.MatchSetID,
 		matchedTx.TransactionID,
 		matchedTx.MatchGroupID,
 		matchedTx.TenantID,
 	).Scan(&matchedTx.ID, &matchedTx.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// GetMatchedTransactionsByMatchSet retrieves matched transactions for a match set with pagination
-func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionsByMatchSet(matchSetID string, limit, offset int) ([]models.MatchedTransaction, int, error) {
+// GetMatchedTransactionsByMatchSet retrieves matched transactions for a tenant's match set with pagination
+func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionsByMatchSet(tenantID, matchSetID string, limit, offset int) ([]models.MatchedTransaction, int, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
 	// Get total count
 	var total int
 	countQuery := `
@@ -88,7 +121,7 @@ func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionsByMatchSet(
 		FROM matched_transactions
 		WHERE match_set_id = $1
 	`
-	err := r.db.QueryRow(countQuery, matchSetID).Scan(&total)
+	err = tx.QueryRow(countQuery, matchSetID).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -102,7 +135,7 @@ func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionsByMatchSet(
 		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.Query(query, matchSetID, limit, offset)
+	rows, err := tx.Query(query, matchSetID, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -136,6 +169,12 @@ func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionsByMatchSet(
 
 // GetMatchedTransactionsByTenant retrieves matched transactions for a tenant with pagination
 func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionsByTenant(tenantID string, limit, offset int) ([]models.MatchedTransaction, int, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
 	// Get total count
 	var total int
 	countQuery := `
@@ -143,7 +182,7 @@ func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionsByTenant(te
 		FROM matched_transactions
 		WHERE tenant_id = $1
 	`
-	err := r.db.QueryRow(countQuery, tenantID).Scan(&total)
+	err = tx.QueryRow(countQuery, tenantID).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -157,7 +196,7 @@ func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionsByTenant(te
 		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.Query(query, tenantID, limit, offset)
+	rows, err := tx.Query(query, tenantID, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -189,8 +228,13 @@ func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionsByTenant(te
 	return matchedTxs, total, nil
 }
 
-// GetMatchedTransactionByID retrieves a matched transaction by ID
-func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionByID(id string) (*models.MatchedTransaction, error) {
+// GetMatchedTransactionByID retrieves a tenant's matched transaction by ID
+func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionByID(tenantID, id string) (*models.MatchedTransaction, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 	query := `
 		SELECT id, match_set_id, transaction_id, match_group_id, tenant_id, created_at
 		FROM matched_transactions
@@ -198,7 +242,7 @@ func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionByID(id stri
 	`
 
 	var matchedTx models.MatchedTransaction
-	err := r.db.QueryRow(query, id).Scan(
+	err = tx.QueryRow(query, id).Scan(
 		&matchedTx.ID,
 		&matchedTx.MatchSetID,
 		&matchedTx.TransactionID,
@@ -218,8 +262,13 @@ func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionByID(id stri
 	return &matchedTx, nil
 }
 
-// GetMatchedTransactionByTransactionID retrieves a matched transaction by transaction ID
-func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionByTransactionID(transactionID string) (*models.MatchedTransaction, error) {
+// GetMatchedTransactionByTransactionID retrieves a tenant's matched transaction by transaction ID
+func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionByTransactionID(tenantID, transactionID string) (*models.MatchedTransaction, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 	query := `
 		SELECT id, match_set_id, transaction_id, match_group_id, tenant_id, created_at
 		FROM matched_transactions
@@ -227,7 +276,7 @@ func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionByTransactio
 	`
 
 	var matchedTx models.MatchedTransaction
-	err := r.db.QueryRow(query, transactionID).Scan(
+	err = tx.QueryRow(query, transactionID).Scan(
 		&matchedTx.ID,
 		&matchedTx.MatchSetID,
 		&matchedTx.TransactionID,
@@ -247,15 +296,20 @@ func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionByTransactio
 	return &matchedTx, nil
 }
 
-// GetMatchedTransactionsByMatchGroup retrieves matched transactions by match group ID
-func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionsByMatchGroup(matchGroupID string) ([]models.MatchedTransaction, error) {
+// GetMatchedTransactionsByMatchGroup retrieves a tenant's matched transactions by match group ID
+func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionsByMatchGroup(tenantID, matchGroupID string) ([]models.MatchedTransaction, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 	query := `
 		SELECT id, match_set_id, transaction_id, match_group_id, tenant_id, created_at
 		FROM matched_transactions
 		WHERE match_group_id = $1
 	`
 
-	rows, err := r.db.Query(query, matchGroupID)
+	rows, err := tx.Query(query, matchGroupID)
 	if err != nil {
 		return nil, err
 	}
@@ -287,47 +341,221 @@ func (r *PostgresMatchedTransactionRepository) GetMatchedTransactionsByMatchGrou
 	return matchedTxs, nil
 }
 
+// unmatchedColumns is the column list scanned by scanUnmatched
+const unmatchedColumns = `
+	id, match_set_id, transaction_id, reason, reason_code, reason_code_manual, status, assignee_id,
+	tenant_id, created_at, updated_at, resolved_at
+`
+
+// scanUnmatched scans an unmatched transaction selected with unmatchedColumns
+func scanUnmatched(row rowScanner) (*models.UnmatchedTransaction, error) {
+	var unmatchedTx models.UnmatchedTransaction
+	var assigneeID sql.NullString
+	var resolvedAt sql.NullTime
+	err := row.Scan(
+		&unmatchedTx.ID,
+		&unmatchedTx.MatchSetID,
+		&unmatchedTx.TransactionID,
+		&unmatchedTx.Reason,
+		&unmatchedTx.ReasonCode,
+		&unmatchedTx.ReasonCodeManual,
+		&unmatchedTx.Status,
+		&assigneeID,
+		&unmatchedTx.TenantID,
+		&unmatchedTx.CreatedAt,
+		&unmatchedTx.UpdatedAt,
+		&resolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	unmatchedTx.AssigneeID = assigneeID.String
+	if resolvedAt.Valid {
+		unmatchedTx.ResolvedAt = &resolvedAt.Time
+	}
+	return &unmatchedTx, nil
+}
+
+// reasonCodeOrDefault returns the reason code of an unmatched transaction, treating an unset one
+// as a missing counterpart
+func reasonCodeOrDefault(unmatchedTx *models.UnmatchedTransaction) string {
+	if unmatchedTx.ReasonCode == "" {
+		return models.ExceptionReasonMissingCounterpart
+	}
+	return unmatchedTx.ReasonCode
+}
+
 // CreateUnmatchedTransaction creates a new unmatched transaction
 func (r *PostgresUnmatchedTransactionRepository) CreateUnmatchedTransaction(unmatchedTx *models.UnmatchedTransaction) error {
 	query := `
-		INSERT INTO unmatched_transactions (match_set_id, transaction_id, reason, tenant_id)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, created_at
+		INSERT INTO unmatched_transactions (match_set_id, transaction_id, reason, reason_code, tenant_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, status, created_at, updated_at
 	`
 
-	return r.db.QueryRow(
+	tx, err := beginTenant(r.db, unmatchedTx.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	unmatchedTx.ReasonCode = reasonCodeOrDefault(unmatchedTx)
+	err = tx.QueryRow(
 		query,
 		unmatchedTx.MatchSetID,
 		unmatchedTx.TransactionID,
 		unmatchedTx.Reason,
+		unmatchedTx.ReasonCode,
 		unmatchedTx.TenantID,
-	).Scan(&unmatchedTx.ID, &unmatchedTx.CreatedAt)
+	).Scan(&unmatchedTx.ID, &unmatchedTx.Status, &unmatchedTx.CreatedAt, &unmatchedTx.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// GetUnmatchedTransactionsByMatchSet retrieves unmatched transactions for a match set with pagination
-func (r *PostgresUnmatchedTransactionRepository) GetUnmatchedTransactionsByMatchSet(matchSetID string, limit, offset int) ([]models.UnmatchedTransaction, int, error) {
+// SaveUnmatchedTransaction records an unmatched transaction, updating the reason if it is already
+// recorded for the match set. The status and assignee of an exception being worked are kept, and
+// so is a reason code an analyst set.
+func (r *PostgresUnmatchedTransactionRepository) SaveUnmatchedTransaction(unmatchedTx *models.UnmatchedTransaction) error {
+	query := `
+		INSERT INTO unmatched_transactions (match_set_id, transaction_id, reason, reason_code, tenant_id)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (transaction_id, match_set_id) DO UPDATE SET
+			reason = EXCLUDED.reason,
+			reason_code = CASE
+				WHEN unmatched_transactions.reason_code_manual THEN unmatched_transactions.reason_code
+				ELSE EXCLUDED.reason_code
+			END
+		RETURNING id, reason_code, reason_code_manual, status, created_at, updated_at
+	`
+
+	tx, err := beginTenant(r.db, unmatchedTx.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	unmatchedTx.ReasonCode = reasonCodeOrDefault(unmatchedTx)
+	err = tx.QueryRow(
+		query,
+		unmatchedTx.MatchSetID,
+		unmatchedTx.TransactionID,
+		unmatchedTx.Reason,
+		unmatchedTx.ReasonCode,
+		unmatchedTx.TenantID,
+	).Scan(
+		&unmatchedTx.ID,
+		&unmatchedTx.ReasonCode,
+		&unmatchedTx.ReasonCodeManual,
+		&unmatchedTx.Status,
+		&unmatchedTx.CreatedAt,
+		&unmatchedTx.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetUnmatchedTransactionsByMatchSet retrieves unmatched transactions for a tenant's match set with pagination
+func (r *PostgresUnmatchedTransactionRepository) GetUnmatchedTransactionsByMatchSet(tenantID, matchSetID string, limit, offset int) ([]models.UnmatchedTransaction, int, error) {
+	return r.listUnmatched(tenantID, "match_set_id = $1", []interface{}{matchSetID}, "created_at DESC", limit, offset)
+}
+
+// GetUnmatchedTransactionsByTenant retrieves unmatched transactions for a tenant with pagination
+func (r *PostgresUnmatchedTransactionRepository) GetUnmatchedTransactionsByTenant(tenantID string, limit, offset int) ([]models.UnmatchedTransaction, int, error) {
+	return r.listUnmatched(tenantID, "tenant_id = $1", []interface{}{tenantID}, "created_at DESC", limit, offset)
+}
+
+// GetUnmatchedTransactionByID retrieves a tenant's unmatched transaction by ID
+func (r *PostgresUnmatchedTransactionRepository) GetUnmatchedTransactionByID(tenantID, id string) (*models.UnmatchedTransaction, error) {
+	query := "SELECT " + unmatchedColumns + " FROM unmatched_transactions WHERE id = $1"
+
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	unmatchedTx, err := scanUnmatched(tx.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrUnmatchedTransactionNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return unmatchedTx, nil
+}
+
+// ListExceptions retrieves the unmatched transactions of a tenant matching a filter, oldest first,
+// with pagination
+func (r *PostgresUnmatchedTransactionRepository) ListExceptions(filter models.ExceptionFilter, limit, offset int) ([]models.UnmatchedTransaction, int, error) {
+	where, args := exceptionConditions(filter)
+	return r.listUnmatched(filter.TenantID, where, args, "created_at, id", limit, offset)
+}
+
+// exceptionConditions builds the WHERE clause and parameters selecting the exceptions a filter
+// matches
+func exceptionConditions(filter models.ExceptionFilter) (string, []interface{}) {
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{filter.TenantID}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, condition+" $"+strconv.Itoa(len(args)))
+	}
+
+	if filter.MatchSetID != "" {
+		add("match_set_id =", filter.MatchSetID)
+	}
+	if filter.Status != "" {
+		add("status =", filter.Status)
+	}
+	if filter.ReasonCode != "" {
+		add("reason_code =", filter.ReasonCode)
+	}
+	if filter.Unassigned {
+		conditions = append(conditions, "assignee_id IS NULL")
+	} else if filter.AssigneeID != "" {
+		add("assignee_id =", filter.AssigneeID)
+	}
+	if filter.Age != nil {
+		// An exception is n days old from n whole days after it was created until n+1
+		add("created_at <=", filter.Now.UTC().AddDate(0, 0, -filter.Age.MinDays))
+		if filter.Age.MaxDays >= 0 {
+			add("created_at >", filter.Now.UTC().AddDate(0, 0, -filter.Age.MaxDays-1))
+		}
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// listUnmatched retrieves a page of a tenant's unmatched transactions selected by a WHERE clause
+// and the total number selected
+func (r *PostgresUnmatchedTransactionRepository) listUnmatched(tenantID, where string, args []interface{}, orderBy string, limit, offset int) ([]models.UnmatchedTransaction, int, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
 	// Get total count
 	var total int
-	countQuery := `
-		SELECT COUNT(*)
-		FROM unmatched_transactions
-		WHERE match_set_id = $1
-	`
-	err := r.db.QueryRow(countQuery, matchSetID).Scan(&total)
+	err = tx.QueryRow("SELECT COUNT(*) FROM unmatched_transactions WHERE "+where, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Get unmatched transactions
-	query := `
-		SELECT id, match_set_id, transaction_id, reason, tenant_id, created_at
-		FROM unmatched_transactions
-		WHERE match_set_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
-	`
+	query := "SELECT " + unmatchedColumns + " FROM unmatched_transactions WHERE " + where +
+		" ORDER BY " + orderBy +
+		" LIMIT $" + strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
 
-	rows, err := r.db.Query(query, matchSetID, limit, offset)
+	rows, err := tx.Query(query, append(args, limit, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -335,21 +563,11 @@ func (r *PostgresUnmatchedTransactionRepository) GetUnmatchedTransactionsByMatch
 
 	var unmatchedTxs []models.UnmatchedTransaction
 	for rows.Next() {
-		var unmatchedTx models.UnmatchedTransaction
-		err := rows.Scan(
-			&unmatchedTx.ID,
-			&unmatchedTx.MatchSetID,
-			&unmatchedTx.TransactionID,
-			&unmatchedTx.Reason,
-			&unmatchedTx.TenantID,
-			&unmatchedTx.CreatedAt,
-		)
-
+		unmatchedTx, err := scanUnmatched(rows)
 		if err != nil {
 			return nil, 0, err
 		}
-
-		unmatchedTxs = append(unmatchedTxs, unmatchedTx)
+		unmatchedTxs = append(unmatchedTxs, *unmatchedTx)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -359,88 +577,163 @@ func (r *PostgresUnmatchedTransactionRepository) GetUnmatchedTransactionsByMatch
 	return unmatchedTxs, total, nil
 }
 
-// GetUnmatchedTransactionsByTenant retrieves unmatched transactions for a tenant with pagination
-func (r *PostgresUnmatchedTransactionRepository) GetUnmatchedTransactionsByTenant(tenantID string, limit, offset int) ([]models.UnmatchedTransaction, int, error) {
-	// Get total count
-	var total int
-	countQuery := `
-		SELECT COUNT(*)
-		FROM unmatched_transactions
-		WHERE tenant_id = $1
+// UpdateException saves the reason code, status and assignee of an unmatched transaction. Moving
+// it to resolved or written off stamps when that happened; reopening it clears the stamp.
+func (r *PostgresUnmatchedTransactionRepository) UpdateException(unmatchedTx *models.UnmatchedTransaction) error {
+	query := `
+		UPDATE unmatched_transactions
+		SET reason_code = $2,
+			reason_code_manual = $5,
+			status = $3,
+			assignee_id = $4,
+			resolved_at = CASE
+				WHEN $3 NOT IN ('resolved', 'written_off') THEN NULL
+				WHEN status = $3 THEN resolved_at
+				ELSE NOW() AT TIME ZONE 'UTC'
+			END,
+			updated_at = NOW() AT TIME ZONE 'UTC'
+		WHERE id = $1
+		RETURNING updated_at, resolved_at
 	`
-	err := r.db.QueryRow(countQuery, tenantID).Scan(&total)
+
+	tx, err := beginTenant(r.db, unmatchedTx.TenantID)
 	if err != nil {
-		return nil, 0, err
+		return err
 	}
+	defer tx.Rollback()
 
-	// Get unmatched transactions
+	var resolvedAt sql.NullTime
+	err = tx.QueryRow(
+		query,
+		unmatchedTx.ID,
+		unmatchedTx.ReasonCode,
+		unmatchedTx.Status,
+		sql.NullString{String: unmatchedTx.AssigneeID, Valid: unmatchedTx.AssigneeID != ""},
+		unmatchedTx.ReasonCodeManual,
+	).Scan(&unmatchedTx.UpdatedAt, &resolvedAt)
+	if err == sql.ErrNoRows {
+		return ErrUnmatchedTransactionNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	unmatchedTx.ResolvedAt = nil
+	if resolvedAt.Valid {
+		unmatchedTx.ResolvedAt = &resolvedAt.Time
+	}
+	return tx.Commit()
+}
+
+// CountExceptions counts the unmatched transactions of a tenant, optionally of one match set, by
+// status, reason code and age in days at now
+func (r *PostgresUnmatchedTransactionRepository) CountExceptions(tenantID, matchSetID string, now time.Time) ([]models.ExceptionCount, error) {
 	query := `
-		SELECT id, match_set_id, transaction_id, reason, tenant_id, created_at
+		SELECT status, reason_code, FLOOR(EXTRACT(EPOCH FROM ($3::timestamp - created_at)) / 86400)::int AS age_days, COUNT(*)
 		FROM unmatched_transactions
-		WHERE tenant_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
+		WHERE tenant_id = $1 AND ($2 = '' OR match_set_id::text = $2)
+		GROUP BY status, reason_code, age_days
 	`
 
-	rows, err := r.db.Query(query, tenantID, limit, offset)
+	tx, err := beginTenant(r.db, tenantID)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(query, tenantID, matchSetID, now.UTC())
+	if err != nil {
+		return nil, err
 	}
 	defer rows.Close()
 
-	var unmatchedTxs []models.UnmatchedTransaction
+	var counts []models.ExceptionCount
 	for rows.Next() {
-		var unmatchedTx models.UnmatchedTransaction
-		err := rows.Scan(
-			&unmatchedTx.ID,
-			&unmatchedTx.MatchSetID,
-			&unmatchedTx.TransactionID,
-			&unmatchedTx.Reason,
-			&unmatchedTx.TenantID,
-			&unmatchedTx.CreatedAt,
-		)
-
-		if err != nil {
-			return nil, 0, err
+		var count models.ExceptionCount
+		if err := rows.Scan(&count.Status, &count.ReasonCode, &count.AgeDays, &count.Count); err != nil {
+			return nil, err
 		}
-
-		unmatchedTxs = append(unmatchedTxs, unmatchedTx)
+		counts = append(counts, count)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	return unmatchedTxs, total, nil
+	return counts, nil
 }
 
-// GetUnmatchedTransactionByID retrieves an unmatched transaction by ID
-func (r *PostgresUnmatchedTransactionRepository) GetUnmatchedTransactionByID(id string) (*models.UnmatchedTransaction, error) {
+// AddExceptionComment adds a comment to an unmatched transaction
+func (r *PostgresUnmatchedTransactionRepository) AddExceptionComment(comment *models.ExceptionComment) error {
 	query := `
-		SELECT id, match_set_id, transaction_id, reason, tenant_id, created_at
-		FROM unmatched_transactions
-		WHERE id = $1
+		INSERT INTO exception_comments (unmatched_transaction_id, tenant_id, user_id, body)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
 	`
 
-	var unmatchedTx models.UnmatchedTransaction
-	err := r.db.QueryRow(query, id).Scan(
-		&unmatchedTx.ID,
-		&unmatchedTx.MatchSetID,
-		&unmatchedTx.TransactionID,
-		&unmatchedTx.Reason,
-		&unmatchedTx.TenantID,
-		&unmatchedTx.CreatedAt,
-	)
+	tx, err := beginTenant(r.db, comment.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	if err == sql.ErrNoRows {
-		return nil, errors.New("unmatched transaction not found")
+	err = tx.QueryRow(
+		query,
+		comment.ExceptionID,
+		comment.TenantID,
+		comment.UserID,
+		comment.Body,
+	).Scan(&comment.ID, &comment.CreatedAt)
+	if err != nil {
+		return err
 	}
 
+	return tx.Commit()
+}
+
+// GetExceptionComments retrieves the comments on a tenant's unmatched transaction, oldest first
+func (r *PostgresUnmatchedTransactionRepository) GetExceptionComments(tenantID, unmatchedID string) ([]models.ExceptionComment, error) {
+	query := `
+		SELECT id, unmatched_transaction_id, tenant_id, user_id, body, created_at
+		FROM exception_comments
+		WHERE unmatched_transaction_id = $1
+		ORDER BY created_at, id
+	`
+
+	tx, err := beginTenant(r.db, tenantID)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	return &unmatchedTx, nil
+	rows, err := tx.Query(query, unmatchedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []models.ExceptionComment
+	for rows.Next() {
+		var comment models.ExceptionComment
+		err := rows.Scan(
+			&comment.ID,
+			&comment.ExceptionID,
+			&comment.TenantID,
+			&comment.UserID,
+			&comment.Body,
+			&comment.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
 }
 
 // MockMatchedTransactionRepository is a mock implementation for development
@@ -459,11 +752,11 @@ func (r *MockMatchedTransactionRepository) CreateMatchedTransaction(matchedTx *m
 	return nil
 }
 
-// GetMatchedTransactionsByMatchSet retrieves matched transactions for a match set from the mock repository
-func (r *MockMatchedTransactionRepository) GetMatchedTransactionsByMatchSet(matchSetID string, limit, offset int) ([]models.MatchedTransaction, int, error) {
+// GetMatchedTransactionsByMatchSet retrieves matched transactions for a tenant's match set from the mock repository
+func (r *MockMatchedTransactionRepository) GetMatchedTransactionsByMatchSet(tenantID, matchSetID string, limit, offset int) ([]models.MatchedTransaction, int, error) {
 	var matchedTxs []models.MatchedTransaction
 	for _, tx := range r.matchedTransactions {
-		if tx.MatchSetID == matchSetID {
+		if tx.TenantID == tenantID && tx.MatchSetID == matchSetID {
 			matchedTxs = append(matchedTxs, *tx)
 		}
 	}
@@ -515,87 +808,158 @@ func (r *MockMatchedTransactionRepository) GetMatchedTransactionsByTenant(tenant
 	return []models.MatchedTransaction{}, total, nil
 }
 
-// GetMatchedTransactionByID retrieves a matched transaction by ID from the mock repository
-func (r *MockMatchedTransactionRepository) GetMatchedTransactionByID(id string) (*models.MatchedTransaction, error) {
-	if tx, exists := r.matchedTransactions[id]; exists {
+// GetMatchedTransactionByID retrieves a tenant's matched transaction by ID from the mock repository
+func (r *MockMatchedTransactionRepository) GetMatchedTransactionByID(tenantID, id string) (*models.MatchedTransaction, error) {
+	if tx, exists := r.matchedTransactions[id]; exists && tx.TenantID == tenantID {
 		return tx, nil
 	}
 	return nil, errors.New("matched transaction not found")
 }
 
-// GetMatchedTransactionByTransactionID retrieves a matched transaction by transaction ID from the mock repository
-func (r *MockMatchedTransactionRepository) GetMatchedTransactionByTransactionID(transactionID string) (*models.MatchedTransaction, error) {
+// GetMatchedTransactionByTransactionID retrieves a tenant's matched transaction by transaction ID from the mock repository
+func (r *MockMatchedTransactionRepository) GetMatchedTransactionByTransactionID(tenantID, transactionID string) (*models.MatchedTransaction, error) {
 	for _, tx := range r.matchedTransactions {
-		if tx.TransactionID == transactionID {
+		if tx.TenantID == tenantID && tx.TransactionID == transactionID {
 			return tx, nil
 		}
 	}
 	return nil, errors.New("matched transaction not found")
 }
 
-// GetMatchedTransactionsByMatchGroup retrieves matched transactions by match group ID from the mock repository
-func (r *MockMatchedTransactionRepository) GetMatchedTransactionsByMatchGroup(matchGroupID string) ([]models.MatchedTransaction, error) {
+// GetMatchedTransactionsByMatchGroup retrieves a tenant's matched transactions by match group ID from the mock repository
+func (r *MockMatchedTransactionRepository) GetMatchedTransactionsByMatchGroup(tenantID, matchGroupID string) ([]models.MatchedTransaction, error) {
 	var matchedTxs []models.MatchedTransaction
 	for _, tx := range r.matchedTransactions {
-		if tx.MatchGroupID == matchGroupID {
+		if tx.TenantID == tenantID && tx.MatchGroupID == matchGroupID {
 			matchedTxs = append(matchedTxs, *tx)
 		}
 	}
 	return matchedTxs, nil
 }
 
-// MockUnmatchedTransactionRepository is a mock implementation for development
+// MockUnmatchedTransactionRepository is a mock implementation for development. Match set runs
+// write to it from the queue worker, so it is guarded by a mutex.
 type MockUnmatchedTransactionRepository struct {
+	mu                    sync.Mutex
 	unmatchedTransactions map[string]*models.UnmatchedTransaction // ID -> UnmatchedTransaction
+	comments              []models.ExceptionComment
 }
 
 // CreateUnmatchedTransaction creates an unmatched transaction in the mock repository
 func (r *MockUnmatchedTransactionRepository) CreateUnmatchedTransaction(unmatchedTx *models.UnmatchedTransaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if unmatchedTx.ID == "" {
 		// Generate a dummy ID - in real implementation we'd use UUID
 		unmatchedTx.ID = "mock-" + time.Now().Format("20060102150405")
 	}
+	unmatchedTx.ReasonCode = reasonCodeOrDefault(unmatchedTx)
+	unmatchedTx.Status = models.ExceptionStatusOpen
 	unmatchedTx.CreatedAt = time.Now()
-	r.unmatchedTransactions[unmatchedTx.ID] = unmatchedTx
+	unmatchedTx.UpdatedAt = unmatchedTx.CreatedAt
+	stored := *unmatchedTx
+	r.unmatchedTransactions[unmatchedTx.ID] = &stored
 	return nil
 }
 
-// GetUnmatchedTransactionsByMatchSet retrieves unmatched transactions for a match set from the mock repository
-func (r *MockUnmatchedTransactionRepository) GetUnmatchedTransactionsByMatchSet(matchSetID string, limit, offset int) ([]models.UnmatchedTransaction, int, error) {
-	var unmatchedTxs []models.UnmatchedTransaction
-	for _, tx := range r.unmatchedTransactions {
-		if tx.MatchSetID == matchSetID {
-			unmatchedTxs = append(unmatchedTxs, *tx)
+// SaveUnmatchedTransaction records an unmatched transaction in the mock repository
+func (r *MockUnmatchedTransactionRepository) SaveUnmatchedTransaction(unmatchedTx *models.UnmatchedTransaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unmatchedTx.ReasonCode = reasonCodeOrDefault(unmatchedTx)
+	for _, existing := range r.unmatchedTransactions {
+		if existing.MatchSetID == unmatchedTx.MatchSetID && existing.TransactionID == unmatchedTx.TransactionID {
+			existing.Reason = unmatchedTx.Reason
+			if !existing.ReasonCodeManual {
+				existing.ReasonCode = unmatchedTx.ReasonCode
+			}
+			unmatchedTx.ID = existing.ID
+			unmatchedTx.ReasonCode = existing.ReasonCode
+			unmatchedTx.ReasonCodeManual = existing.ReasonCodeManual
+			unmatchedTx.Status = existing.Status
+			unmatchedTx.CreatedAt = existing.CreatedAt
+			unmatchedTx.UpdatedAt = existing.UpdatedAt
+			return nil
 		}
 	}
 
-	// Apply pagination
-	total := len(unmatchedTxs)
-	start := offset
-	if start > total {
-		start = total
+	if unmatchedTx.ID == "" {
+		unmatchedTx.ID = uuid.New().String()
 	}
+	unmatchedTx.Status = models.ExceptionStatusOpen
+	unmatchedTx.CreatedAt = time.Now()
+	unmatchedTx.UpdatedAt = unmatchedTx.CreatedAt
+	stored := *unmatchedTx
+	r.unmatchedTransactions[unmatchedTx.ID] = &stored
+	return nil
+}
 
-	end := start + limit
-	if end > total {
-		end = total
-	}
+// GetUnmatchedTransactionsByMatchSet retrieves unmatched transactions for a tenant's match set from the mock repository
+func (r *MockUnmatchedTransactionRepository) GetUnmatchedTransactionsByMatchSet(tenantID, matchSetID string, limit, offset int) ([]models.UnmatchedTransaction, int, error) {
+	return r.listUnmatched(func(tx *models.UnmatchedTransaction) bool {
+		return tx.TenantID == tenantID && tx.MatchSetID == matchSetID
+	}, limit, offset)
+}
 
-	if start < end {
-		return unmatchedTxs[start:end], total, nil
+// GetUnmatchedTransactionsByTenant retrieves unmatched transactions for a tenant from the mock repository
+func (r *MockUnmatchedTransactionRepository) GetUnmatchedTransactionsByTenant(tenantID string, limit, offset int) ([]models.UnmatchedTransaction, int, error) {
+	return r.listUnmatched(func(tx *models.UnmatchedTransaction) bool { return tx.TenantID == tenantID }, limit, offset)
+}
+
+// GetUnmatchedTransactionByID retrieves a tenant's unmatched transaction by ID from the mock repository
+func (r *MockUnmatchedTransactionRepository) GetUnmatchedTransactionByID(tenantID, id string) (*models.UnmatchedTransaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tx, exists := r.unmatchedTransactions[id]; exists && tx.TenantID == tenantID {
+		copied := *tx
+		return &copied, nil
 	}
+	return nil, ErrUnmatchedTransactionNotFound
+}
 
-	return []models.UnmatchedTransaction{}, total, nil
+// ListExceptions retrieves the unmatched transactions matching a filter from the mock repository
+func (r *MockUnmatchedTransactionRepository) ListExceptions(filter models.ExceptionFilter, limit, offset int) ([]models.UnmatchedTransaction, int, error) {
+	return r.listUnmatched(func(tx *models.UnmatchedTransaction) bool {
+		switch {
+		case tx.TenantID != filter.TenantID,
+			filter.MatchSetID != "" && tx.MatchSetID != filter.MatchSetID,
+			filter.Status != "" && tx.Status != filter.Status,
+			filter.ReasonCode != "" && tx.ReasonCode != filter.ReasonCode,
+			filter.Unassigned && tx.AssigneeID != "",
+			!filter.Unassigned && filter.AssigneeID != "" && tx.AssigneeID != filter.AssigneeID:
+			return false
+		}
+		if filter.Age != nil {
+			age := int(filter.Now.Sub(tx.CreatedAt) / (24 * time.Hour))
+			if age < filter.Age.MinDays || (filter.Age.MaxDays >= 0 && age > filter.Age.MaxDays) {
+				return false
+			}
+		}
+		return true
+	}, limit, offset)
 }
 
-// GetUnmatchedTransactionsByTenant retrieves unmatched transactions for a tenant from the mock repository
-func (r *MockUnmatchedTransactionRepository) GetUnmatchedTransactionsByTenant(tenantID string, limit, offset int) ([]models.UnmatchedTransaction, int, error) {
+// listUnmatched retrieves a page of the unmatched transactions a predicate selects, oldest first,
+// and the total number selected
+func (r *MockUnmatchedTransactionRepository) listUnmatched(selected func(tx *models.UnmatchedTransaction) bool, limit, offset int) ([]models.UnmatchedTransaction, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	var unmatchedTxs []models.UnmatchedTransaction
 	for _, tx := range r.unmatchedTransactions {
-		if tx.TenantID == tenantID {
+		if selected(tx) {
 			unmatchedTxs = append(unmatchedTxs, *tx)
 		}
 	}
+	sort.Slice(unmatchedTxs, func(i, j int) bool {
+		if !unmatchedTxs[i].CreatedAt.Equal(unmatchedTxs[j].CreatedAt) {
+			return unmatchedTxs[i].CreatedAt.Before(unmatchedTxs[j].CreatedAt)
+		}
+		return unmatchedTxs[i].ID < unmatchedTxs[j].ID
+	})
 
 	// Apply pagination
 	total := len(unmatchedTxs)
@@ -616,10 +980,83 @@ func (r *MockUnmatchedTransactionRepository) GetUnmatchedTransactionsByTenant(te
 	return []models.UnmatchedTransaction{}, total, nil
 }
 
-// GetUnmatchedTransactionByID retrieves an unmatched transaction by ID from the mock repository
-func (r *MockUnmatchedTransactionRepository) GetUnmatchedTransactionByID(id string) (*models.UnmatchedTransaction, error) {
-	if tx, exists := r.unmatchedTransactions[id]; exists {
-		return tx, nil
+// UpdateException saves the reason code, status and assignee of an unmatched transaction in the
+// mock repository
+func (r *MockUnmatchedTransactionRepository) UpdateException(unmatchedTx *models.UnmatchedTransaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.unmatchedTransactions[unmatchedTx.ID]
+	if !exists || existing.TenantID != unmatchedTx.TenantID {
+		return ErrUnmatchedTransactionNotFound
+	}
+
+	now := time.Now()
+	switch {
+	case unmatchedTx.Status != models.ExceptionStatusResolved && unmatchedTx.Status != models.ExceptionStatusWrittenOff:
+		existing.ResolvedAt = nil
+	case existing.Status != unmatchedTx.Status:
+		existing.ResolvedAt = &now
+	}
+	existing.ReasonCode = unmatchedTx.ReasonCode
+	existing.ReasonCodeManual = unmatchedTx.ReasonCodeManual
+	existing.Status = unmatchedTx.Status
+	existing.AssigneeID = unmatchedTx.AssigneeID
+	existing.UpdatedAt = now
+
+	unmatchedTx.UpdatedAt = existing.UpdatedAt
+	unmatchedTx.ResolvedAt = existing.ResolvedAt
+	return nil
+}
+
+// CountExceptions counts the unmatched transactions of a tenant in the mock repository
+func (r *MockUnmatchedTransactionRepository) CountExceptions(tenantID, matchSetID string, now time.Time) ([]models.ExceptionCount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	indexes := make(map[models.ExceptionCount]int)
+	var counts []models.ExceptionCount
+	for _, tx := range r.unmatchedTransactions {
+		if tx.TenantID != tenantID || (matchSetID != "" && tx.MatchSetID != matchSetID) {
+			continue
+		}
+		key := models.ExceptionCount{
+			Status:     tx.Status,
+			ReasonCode: tx.ReasonCode,
+			AgeDays:    int(now.Sub(tx.CreatedAt) / (24 * time.Hour)),
+		}
+		if i, exists := indexes[key]; exists {
+			counts[i].Count++
+			continue
+		}
+		indexes[key] = len(counts)
+		key.Count = 1
+		counts = append(counts, key)
+	}
+	return counts, nil
+}
+
+// AddExceptionComment adds a comment to an unmatched transaction in the mock repository
+func (r *MockUnmatchedTransactionRepository) AddExceptionComment(comment *models.ExceptionComment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	comment.ID = uuid.New().String()
+	comment.CreatedAt = time.Now()
+	r.comments = append(r.comments, *comment)
+	return nil
+}
+
+// GetExceptionComments retrieves the comments on a tenant's unmatched transaction from the mock repository
+func (r *MockUnmatchedTransactionRepository) GetExceptionComments(tenantID, unmatchedID string) ([]models.ExceptionComment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var comments []models.ExceptionComment
+	for _, comment := range r.comments {
+		if comment.TenantID == tenantID && comment.ExceptionID == unmatchedID {
+			comments = append(comments, comment)
+		}
 	}
-	return nil, errors.New("unmatched transaction not found")
+	return comments, nil
 }
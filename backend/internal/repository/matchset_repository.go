@@ -5,6 +5,7 @@ import (
 	"backend/internal/models"
 	"database/sql"
 	"errors"
+	"sort"
 	"time"
 )
 
@@ -16,16 +17,21 @@ var (
 // MatchSetRepository defines operations for managing match sets
 type MatchSetRepository interface {
 	CreateMatchSet(matchSet *models.MatchSet) error
-	GetMatchSetByID(id string) (*models.MatchSet, error)
+	GetMatchSetByID(tenantID, id string) (*models.MatchSet, error)
 	GetMatchSetsByTenant(tenantID string) ([]models.MatchSet, error)
 	UpdateMatchSet(matchSet *models.MatchSet) error
-	DeleteMatchSet(id string) error
+	DeleteMatchSet(tenantID, id string) error
 	AddDataSourceToMatchSet(matchSetID, dataSourceID string) error
 	RemoveDataSourceFromMatchSet(matchSetID, dataSourceID string) error
-	GetMatchSetDataSources(matchSetID string) ([]models.DataSource, error)
+	GetMatchSetDataSources(tenantID, matchSetID string) ([]models.DataSource, error)
+	GetMatchSetIDsByDataSource(dataSourceID string) ([]string, error)
+	SetMatchSetRule(matchSetID, ruleID string, priority int) error
+	RemoveRuleFromMatchSet(matchSetID, ruleID string) error
+	GetMatchSetRules(matchSetID string) ([]models.MatchSetRule, error)
 }
 
-// PostgresMatchSetRepository implements MatchSetRepository for PostgreSQL
+// PostgresMatchSetRepository implements MatchSetRepository for PostgreSQL. Match sets are read
+// and written in the session of their tenant.
 type PostgresMatchSetRepository struct {
 	db *sql.DB
 }
@@ -46,9 +52,15 @@ func NewMatchSetRepository() MatchSetRepository {
 
 // CreateMatchSet creates a new match set
 func (r *PostgresMatchSetRepository) CreateMatchSet(matchSet *models.MatchSet) error {
+	tx, err := beginTenant(r.db, matchSet.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Check if match set with the same name already exists for this tenant
 	var exists bool
-	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM match_sets WHERE name = $1 AND tenant_id = $2)", matchSet.Name, matchSet.TenantID).Scan(&exists)
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM match_sets WHERE name = $1 AND tenant_id = $2)", matchSet.Name, matchSet.TenantID).Scan(&exists)
 	if err != nil {
 		return err
 	}
@@ -63,7 +75,7 @@ func (r *PostgresMatchSetRepository) CreateMatchSet(matchSet *models.MatchSet) e
 		RETURNING id, created_at, updated_at
 	`
 
-	return r.db.QueryRow(
+	err = tx.QueryRow(
 		query,
 		matchSet.Name,
 		matchSet.Description,
@@ -71,10 +83,21 @@ func (r *PostgresMatchSetRepository) CreateMatchSet(matchSet *models.MatchSet) e
 		matchSet.RuleID,
 		matchSet.CreatedBy,
 	).Scan(&matchSet.ID, &matchSet.CreatedAt, &matchSet.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// GetMatchSetByID retrieves a match set by ID
-func (r *PostgresMatchSetRepository) GetMatchSetByID(id string) (*models.MatchSet, error) {
+// GetMatchSetByID retrieves a match set of a tenant by ID
+func (r *PostgresMatchSetRepository) GetMatchSetByID(tenantID, id string) (*models.MatchSet, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	query := `
 		SELECT id, name, description, tenant_id, rule_id, created_by, created_at, updated_at
 		FROM match_sets
@@ -82,7 +105,7 @@ func (r *PostgresMatchSetRepository) GetMatchSetByID(id string) (*models.MatchSe
 	`
 
 	var matchSet models.MatchSet
-	err := r.db.QueryRow(query, id).Scan(
+	err = tx.QueryRow(query, id).Scan(
 		&matchSet.ID,
 		&matchSet.Name,
 		&matchSet.Description,
@@ -113,7 +136,13 @@ func (r *PostgresMatchSetRepository) GetMatchSetsByTenant(tenantID string) ([]mo
 		ORDER BY name
 	`
 
-	rows, err := r.db.Query(query, tenantID)
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(query, tenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -149,9 +178,15 @@ func (r *PostgresMatchSetRepository) GetMatchSetsByTenant(tenantID string) ([]mo
 
 // UpdateMatchSet updates a match set
 func (r *PostgresMatchSetRepository) UpdateMatchSet(matchSet *models.MatchSet) error {
+	tx, err := beginTenant(r.db, matchSet.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Check if another match set with the same name already exists for this tenant
 	var count int
-	err := r.db.QueryRow("SELECT COUNT(*) FROM match_sets WHERE name = $1 AND tenant_id = $2 AND id != $3", matchSet.Name, matchSet.TenantID, matchSet.ID).Scan(&count)
+	err = tx.QueryRow("SELECT COUNT(*) FROM match_sets WHERE name = $1 AND tenant_id = $2 AND id != $3", matchSet.Name, matchSet.TenantID, matchSet.ID).Scan(&count)
 	if err != nil {
 		return err
 	}
@@ -168,7 +203,7 @@ func (r *PostgresMatchSetRepository) UpdateMatchSet(matchSet *models.MatchSet) e
 	`
 
 	var updatedAt time.Time
-	err = r.db.QueryRow(query, matchSet.Name, matchSet.Description, matchSet.RuleID, matchSet.ID).Scan(&updatedAt)
+	err = tx.QueryRow(query, matchSet.Name, matchSet.Description, matchSet.RuleID, matchSet.ID).Scan(&updatedAt)
 
 	if err == sql.ErrNoRows {
 		return ErrMatchSetNotFound
@@ -179,13 +214,19 @@ func (r *PostgresMatchSetRepository) UpdateMatchSet(matchSet *models.MatchSet) e
 	}
 
 	matchSet.UpdatedAt = updatedAt
-	return nil
+	return tx.Commit()
 }
 
-// DeleteMatchSet deletes a match set
-func (r *PostgresMatchSetRepository) DeleteMatchSet(id string) error {
+// DeleteMatchSet deletes a match set of a tenant
+func (r *PostgresMatchSetRepository) DeleteMatchSet(tenantID, id string) error {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := "DELETE FROM match_sets WHERE id = $1"
-	result, err := r.db.Exec(query, id)
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		return err
 	}
@@ -199,7 +240,7 @@ func (r *PostgresMatchSetRepository) DeleteMatchSet(id string) error {
 		return ErrMatchSetNotFound
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 // AddDataSourceToMatchSet adds a data source to a match set
@@ -247,8 +288,14 @@ func (r *PostgresMatchSetRepository) RemoveDataSourceFromMatchSet(matchSetID, da
 	return nil
 }
 
-// GetMatchSetDataSources gets all data sources for a match set
-func (r *PostgresMatchSetRepository) GetMatchSetDataSources(matchSetID string) ([]models.DataSource, error) {
+// GetMatchSetDataSources gets all data sources for a match set of a tenant
+func (r *PostgresMatchSetRepository) GetMatchSetDataSources(tenantID, matchSetID string) ([]models.DataSource, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	query := `
 		SELECT ds.id, ds.name, ds.description, ds.tenant_id, ds.created_at, ds.updated_at
 		FROM data_sources ds
@@ -257,7 +304,7 @@ func (r *PostgresMatchSetRepository) GetMatchSetDataSources(matchSetID string) (
 		ORDER BY ds.name
 	`
 
-	rows, err := r.db.Query(query, matchSetID)
+	rows, err := tx.Query(query, matchSetID)
 	if err != nil {
 		return nil, err
 	}
@@ -289,10 +336,97 @@ func (r *PostgresMatchSetRepository) GetMatchSetDataSources(matchSetID string) (
 	return dataSources, nil
 }
 
+// GetMatchSetIDsByDataSource gets the IDs of the match sets a data source belongs to
+func (r *PostgresMatchSetRepository) GetMatchSetIDsByDataSource(dataSourceID string) ([]string, error) {
+	rows, err := r.db.Query("SELECT match_set_id FROM match_set_data_sources WHERE data_source_id = $1 ORDER BY match_set_id", dataSourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matchSetIDs []string
+	for rows.Next() {
+		var matchSetID string
+		if err := rows.Scan(&matchSetID); err != nil {
+			return nil, err
+		}
+		matchSetIDs = append(matchSetIDs, matchSetID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return matchSetIDs, nil
+}
+
+// SetMatchSetRule adds a rule to a match set, or changes its priority if already added
+func (r *PostgresMatchSetRepository) SetMatchSetRule(matchSetID, ruleID string, priority int) error {
+	query := `
+		INSERT INTO match_set_rules (match_set_id, rule_id, priority)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (match_set_id, rule_id) DO UPDATE SET priority = EXCLUDED.priority
+	`
+	_, err := r.db.Exec(query, matchSetID, ruleID, priority)
+	return err
+}
+
+// RemoveRuleFromMatchSet removes a rule from a match set
+func (r *PostgresMatchSetRepository) RemoveRuleFromMatchSet(matchSetID, ruleID string) error {
+	result, err := r.db.Exec("DELETE FROM match_set_rules WHERE match_set_id = $1 AND rule_id = $2", matchSetID, ruleID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("match set rule not found")
+	}
+
+	return nil
+}
+
+// GetMatchSetRules gets the rules of a match set in the order they run
+func (r *PostgresMatchSetRepository) GetMatchSetRules(matchSetID string) ([]models.MatchSetRule, error) {
+	query := `
+		SELECT match_set_id, rule_id, priority, created_at
+		FROM match_set_rules
+		WHERE match_set_id = $1
+		ORDER BY priority, created_at
+	`
+
+	rows, err := r.db.Query(query, matchSetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.MatchSetRule
+	for rows.Next() {
+		var rule models.MatchSetRule
+		if err := rows.Scan(&rule.MatchSetID, &rule.RuleID, &rule.Priority, &rule.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
 // MockMatchSetRepository is a mock implementation for development
 type MockMatchSetRepository struct {
 	matchSets           map[string]*models.MatchSet
 	matchSetDataSources map[string][]string // matchSetID -> []dataSourceID
+	matchSetRules       map[string][]models.MatchSetRule
 }
 
 // CreateMatchSet creates a match set in the mock repository
@@ -316,8 +450,8 @@ func (r *MockMatchSetRepository) CreateMatchSet(matchSet *models.MatchSet) error
 }
 
 // GetMatchSetByID retrieves a match set by ID from the mock repository
-func (r *MockMatchSetRepository) GetMatchSetByID(id string) (*models.MatchSet, error) {
-	if matchSet, exists := r.matchSets[id]; exists {
+func (r *MockMatchSetRepository) GetMatchSetByID(tenantID, id string) (*models.MatchSet, error) {
+	if matchSet, exists := r.matchSets[id]; exists && matchSet.TenantID == tenantID {
 		return matchSet, nil
 	}
 	return nil, ErrMatchSetNotFound
@@ -358,8 +492,8 @@ func (r *MockMatchSetRepository) UpdateMatchSet(matchSet *models.MatchSet) error
 }
 
 // DeleteMatchSet deletes a match set from the mock repository
-func (r *MockMatchSetRepository) DeleteMatchSet(id string) error {
-	if _, exists := r.matchSets[id]; !exists {
+func (r *MockMatchSetRepository) DeleteMatchSet(tenantID, id string) error {
+	if matchSet, exists := r.matchSets[id]; !exists || matchSet.TenantID != tenantID {
 		return ErrMatchSetNotFound
 	}
 
@@ -426,8 +560,8 @@ func (r *MockMatchSetRepository) RemoveDataSourceFromMatchSet(matchSetID, dataSo
 }
 
 // GetMatchSetDataSources gets all data sources for a match set from the mock repository
-func (r *MockMatchSetRepository) GetMatchSetDataSources(matchSetID string) ([]models.DataSource, error) {
-	if _, exists := r.matchSets[matchSetID]; !exists {
+func (r *MockMatchSetRepository) GetMatchSetDataSources(tenantID, matchSetID string) ([]models.DataSource, error) {
+	if matchSet, exists := r.matchSets[matchSetID]; !exists || matchSet.TenantID != tenantID {
 		return nil, ErrMatchSetNotFound
 	}
 
@@ -452,3 +586,67 @@ func (r *MockMatchSetRepository) GetMatchSetDataSources(matchSetID string) ([]mo
 
 	return dataSources, nil
 }
+
+// GetMatchSetIDsByDataSource gets the IDs of the match sets a data source belongs to from the mock repository
+func (r *MockMatchSetRepository) GetMatchSetIDsByDataSource(dataSourceID string) ([]string, error) {
+	var matchSetIDs []string
+	for matchSetID, sourceIDs := range r.matchSetDataSources {
+		for _, id := range sourceIDs {
+			if id == dataSourceID {
+				matchSetIDs = append(matchSetIDs, matchSetID)
+				break
+			}
+		}
+	}
+	sort.Strings(matchSetIDs)
+	return matchSetIDs, nil
+}
+
+// SetMatchSetRule adds a rule to a match set in the mock repository, or changes its priority
+func (r *MockMatchSetRepository) SetMatchSetRule(matchSetID, ruleID string, priority int) error {
+	if _, exists := r.matchSets[matchSetID]; !exists {
+		return ErrMatchSetNotFound
+	}
+
+	if r.matchSetRules == nil {
+		r.matchSetRules = make(map[string][]models.MatchSetRule)
+	}
+
+	for i, rule := range r.matchSetRules[matchSetID] {
+		if rule.RuleID == ruleID {
+			r.matchSetRules[matchSetID][i].Priority = priority
+			return nil
+		}
+	}
+
+	r.matchSetRules[matchSetID] = append(r.matchSetRules[matchSetID], models.MatchSetRule{
+		MatchSetID: matchSetID,
+		RuleID:     ruleID,
+		Priority:   priority,
+		CreatedAt:  time.Now(),
+	})
+	return nil
+}
+
+// RemoveRuleFromMatchSet removes a rule from a match set in the mock repository
+func (r *MockMatchSetRepository) RemoveRuleFromMatchSet(matchSetID, ruleID string) error {
+	rules := r.matchSetRules[matchSetID]
+	for i, rule := range rules {
+		if rule.RuleID == ruleID {
+			r.matchSetRules[matchSetID] = append(rules[:i:i], rules[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("match set rule not found")
+}
+
+// GetMatchSetRules gets the rules of a match set from the mock repository in the order they run
+func (r *MockMatchSetRepository) GetMatchSetRules(matchSetID string) ([]models.MatchSetRule, error) {
+	if _, exists := r.matchSets[matchSetID]; !exists {
+		return nil, ErrMatchSetNotFound
+	}
+
+	rules := append([]models.MatchSetRule(nil), r.matchSetRules[matchSetID]...)
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].Priority < rules[j].Priority })
+	return rules, nil
+}
@@ -43,8 +43,23 @@ func NewPermissionRepository(roleRepo RoleRepository) PermissionRepository {
 	}
 }
 
+// beginWrite starts the transaction a change to the permissions of a tenant runs in. The default
+// permissions of every tenant have no tenant, and only a session across tenants writes them.
+func (r *PostgresPermissionRepository) beginWrite(tenantID string) (*sql.Tx, error) {
+	if tenantID == "" {
+		return beginAllTenants(r.db)
+	}
+	return beginTenant(r.db, tenantID)
+}
+
 // AssignPermissionToRole assigns a permission to a role
 func (r *PostgresPermissionRepository) AssignPermissionToRole(roleName string, permission models.Permission, tenantID string) error {
+	tx, err := r.beginWrite(tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Check if the permission already exists for this role and tenant
 	var exists bool
 	var query string
@@ -58,7 +73,7 @@ func (r *PostgresPermissionRepository) AssignPermissionToRole(roleName string, p
 		args = []interface{}{roleName, permission, tenantID}
 	}
 
-	err := r.db.QueryRow(query, args...).Scan(&exists)
+	err = tx.QueryRow(query, args...).Scan(&exists)
 	if err != nil {
 		return err
 	}
@@ -73,16 +88,19 @@ func (r *PostgresPermissionRepository) AssignPermissionToRole(roleName string, p
 			INSERT INTO role_permissions (role_name, permission)
 			VALUES ($1, $2)
 		`
-		_, err = r.db.Exec(query, roleName, permission)
+		_, err = tx.Exec(query, roleName, permission)
 	} else {
 		query = `
 			INSERT INTO role_permissions (role_name, permission, tenant_id)
 			VALUES ($1, $2, $3)
 		`
-		_, err = r.db.Exec(query, roleName, permission, tenantID)
+		_, err = tx.Exec(query, roleName, permission, tenantID)
+	}
+	if err != nil {
+		return err
 	}
 
-	return err
+	return tx.Commit()
 }
 
 // RemovePermissionFromRole removes a permission from a role
@@ -104,7 +122,13 @@ func (r *PostgresPermissionRepository) RemovePermissionFromRole(roleName string,
 		args = []interface{}{roleName, permission, tenantID}
 	}
 
-	result, err := r.db.Exec(query, args...)
+	tx, err := r.beginWrite(tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, args...)
 	if err != nil {
 		return err
 	}
@@ -118,7 +142,7 @@ func (r *PostgresPermissionRepository) RemovePermissionFromRole(roleName string,
 		return ErrPermissionNotFound
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 // GetRolePermissions returns all permissions assigned to a role for a specific tenant
@@ -142,7 +166,13 @@ func (r *PostgresPermissionRepository) GetRolePermissions(roleName string, tenan
 		args = []interface{}{roleName, tenantID}
 	}
 
-	rows, err := r.db.Query(query, args...)
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -164,14 +194,20 @@ func (r *PostgresPermissionRepository) GetRolePermissions(roleName string, tenan
 	return permissions, nil
 }
 
-// GetAllRolePermissions retrieves all role permission mappings
+// GetAllRolePermissions retrieves all role permission mappings of every tenant
 func (r *PostgresPermissionRepository) GetAllRolePermissions() ([]models.RolePermission, error) {
 	query := `
 		SELECT id, role_name, permission, tenant_id
 		FROM role_permissions
 	`
 
-	rows, err := r.db.Query(query)
+	tx, err := beginAllTenants(r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(query)
 	if err != nil {
 		return nil, err
 	}
@@ -208,6 +244,12 @@ func (r *PostgresPermissionRepository) HasPermission(userID string, permission m
 		return false, err
 	}
 
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
 	// For each role, check if it has the required permission
 	for _, role := range roles {
 		query := `
@@ -218,7 +260,7 @@ func (r *PostgresPermissionRepository) HasPermission(userID string, permission m
 			)
 		`
 		var hasPermission bool
-		err := r.db.QueryRow(query, role, permission, tenantID).Scan(&hasPermission)
+		err := tx.QueryRow(query, role, permission, tenantID).Scan(&hasPermission)
 		if err != nil {
 			return false, err
 		}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// queryBuilder assembles the WHERE, ORDER BY and LIMIT clauses of a dynamic query. It numbers
+// the placeholders of the arguments as they are added, so conditions never splice values into
+// the SQL, and only orders by columns a caller allows.
+type queryBuilder struct {
+	conditions []string
+	args       []interface{}
+	orderBy    []string
+	limit      int
+	offset     int
+	paginated  bool
+}
+
+// newQueryBuilder creates an empty query builder
+func newQueryBuilder() *queryBuilder {
+	return &queryBuilder{}
+}
+
+// arg adds an argument and returns its placeholder
+func (b *queryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// where adds a condition. Each ? in it stands for the next of args, in order.
+func (b *queryBuilder) where(condition string, args ...interface{}) *queryBuilder {
+	parts := strings.Split(condition, "?")
+	if len(parts)-1 != len(args) {
+		panic("queryBuilder: condition " + strconv.Quote(condition) + " needs " + strconv.Itoa(len(parts)-1) + " arguments")
+	}
+
+	var clause strings.Builder
+	clause.WriteString(parts[0])
+	for i, arg := range args {
+		clause.WriteString(b.arg(arg))
+		clause.WriteString(parts[i+1])
+	}
+	b.conditions = append(b.conditions, clause.String())
+	return b
+}
+
+// contains adds a condition matching rows where any of the columns contains text, ignoring case.
+// Empty text adds nothing.
+func (b *queryBuilder) contains(text string, columns ...string) *queryBuilder {
+	if text == "" {
+		return b
+	}
+
+	pattern := b.arg("%" + escapeLike(text) + "%")
+	alternatives := make([]string, len(columns))
+	for i, column := range columns {
+		alternatives[i] = column + " ILIKE " + pattern
+	}
+	b.conditions = append(b.conditions, "("+strings.Join(alternatives, " OR ")+")")
+	return b
+}
+
+// sortBy orders by the column a sort field maps to in allowed. Fields missing from allowed are
+// rejected, so sort fields from requests never reach the SQL.
+func (b *queryBuilder) sortBy(allowed map[string]string, field string, desc bool) error {
+	column, ok := allowed[field]
+	if !ok {
+		return errors.New("invalid sort: unknown field " + field)
+	}
+	b.orderByColumn(column, desc)
+	return nil
+}
+
+// orderByColumn orders by a column expression written by the caller
+func (b *queryBuilder) orderByColumn(column string, desc bool) *queryBuilder {
+	if desc {
+		b.orderBy = append(b.orderBy, column+" DESC")
+	} else {
+		b.orderBy = append(b.orderBy, column+" ASC")
+	}
+	return b
+}
+
+// seekAfter adds a condition selecting the rows after a position in an ordering, for keyset
+// pagination. The columns, their directions and the position's values are given in order. An
+// ordering in a single direction compares rows as a whole, which an index on the columns
+// serves; a mixed one expands to "a > x OR (a = x AND b < y) OR ...".
+func (b *queryBuilder) seekAfter(columns []string, descending []bool, values []interface{}) *queryBuilder {
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		placeholders[i] = b.arg(value)
+	}
+
+	comparison := func(i int) string {
+		if descending[i] {
+			return " < "
+		}
+		return " > "
+	}
+
+	uniform := true
+	for _, desc := range descending {
+		uniform = uniform && desc == descending[0]
+	}
+	if uniform {
+		b.conditions = append(b.conditions, "("+strings.Join(columns, ", ")+")"+comparison(0)+"("+strings.Join(placeholders, ", ")+")")
+		return b
+	}
+
+	alternatives := make([]string, len(columns))
+	for i := range columns {
+		terms := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			terms = append(terms, columns[j]+" = "+placeholders[j])
+		}
+		terms = append(terms, columns[i]+comparison(i)+placeholders[i])
+		alternatives[i] = "(" + strings.Join(terms, " AND ") + ")"
+	}
+	b.conditions = append(b.conditions, "("+strings.Join(alternatives, " OR ")+")")
+	return b
+}
+
+// paginate limits the query to a page of rows
+func (b *queryBuilder) paginate(limit, offset int) *queryBuilder {
+	b.limit, b.offset, b.paginated = limit, offset, true
+	return b
+}
+
+// whereClause returns the conditions as a WHERE clause, or "" when there are none
+func (b *queryBuilder) whereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// build returns the query selecting rows from a "SELECT ... FROM ..." prefix, and its arguments
+func (b *queryBuilder) build(selectFrom string) (string, []interface{}) {
+	query := selectFrom + b.whereClause()
+	args := append([]interface{}(nil), b.args...)
+
+	if len(b.orderBy) > 0 {
+		query += " ORDER BY " + strings.Join(b.orderBy, ", ")
+	}
+	if b.paginated {
+		args = append(args, b.limit)
+		query += " LIMIT $" + strconv.Itoa(len(args))
+		if b.offset > 0 {
+			args = append(args, b.offset)
+			query += " OFFSET $" + strconv.Itoa(len(args))
+		}
+	}
+	return query, args
+}
+
+// count returns the query counting every row the conditions select from a "FROM ..." clause,
+// ignoring order and pagination, and its arguments
+func (b *queryBuilder) count(from string) (string, []interface{}) {
+	return "SELECT COUNT(*) " + from + b.whereClause(), b.args
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text)
+}
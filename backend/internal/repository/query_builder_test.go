@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"backend/internal/models"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryBuilder_Build(t *testing.T) {
+	allowed := map[string]string{"name": "name", "created": "created_at"}
+
+	tests := []struct {
+		name      string
+		build     func(b *queryBuilder) error
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "no conditions",
+			build:     func(b *queryBuilder) error { return nil },
+			wantQuery: "SELECT * FROM t",
+		},
+		{
+			name: "placeholders are numbered in order",
+			build: func(b *queryBuilder) error {
+				b.where("a = ?", 1).where("b BETWEEN ? AND ?", 2, 3).where("c IS NULL")
+				return nil
+			},
+			wantQuery: "SELECT * FROM t WHERE a = $1 AND b BETWEEN $2 AND $3 AND c IS NULL",
+			wantArgs:  []interface{}{1, 2, 3},
+		},
+		{
+			name: "contains escapes wildcards",
+			build: func(b *queryBuilder) error {
+				b.where("a = ?", 1).contains(`50%_off\`, "name", "description")
+				return nil
+			},
+			wantQuery: "SELECT * FROM t WHERE a = $1 AND (name ILIKE $2 OR description ILIKE $2)",
+			wantArgs:  []interface{}{1, `%50\%\_off\\%`},
+		},
+		{
+			name: "empty text adds no condition",
+			build: func(b *queryBuilder) error {
+				b.contains("", "name")
+				return nil
+			},
+			wantQuery: "SELECT * FROM t",
+		},
+		{
+			name: "sort and pagination follow the conditions",
+			build: func(b *queryBuilder) error {
+				b.where("a = ?", 1).paginate(20, 40)
+				return b.sortBy(allowed, "created", true)
+			},
+			wantQuery: "SELECT * FROM t WHERE a = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3",
+			wantArgs:  []interface{}{1, 20, 40},
+		},
+		{
+			name: "first page has no offset",
+			build: func(b *queryBuilder) error {
+				b.orderByColumn("id", false).paginate(10, 0)
+				return nil
+			},
+			wantQuery: "SELECT * FROM t ORDER BY id ASC LIMIT $1",
+			wantArgs:  []interface{}{10},
+		},
+		{
+			name: "uniform seek compares rows",
+			build: func(b *queryBuilder) error {
+				b.seekAfter([]string{"a", "id"}, []bool{true, true}, []interface{}{5, "x"})
+				return nil
+			},
+			wantQuery: "SELECT * FROM t WHERE (a, id) < ($1, $2)",
+			wantArgs:  []interface{}{5, "x"},
+		},
+		{
+			name: "mixed seek expands",
+			build: func(b *queryBuilder) error {
+				b.seekAfter([]string{"a", "b", "id"}, []bool{true, false, true}, []interface{}{5, 6, "x"})
+				return nil
+			},
+			wantQuery: "SELECT * FROM t WHERE ((a < $1) OR (a = $1 AND b > $2) OR (a = $1 AND b = $2 AND id < $3))",
+			wantArgs:  []interface{}{5, 6, "x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newQueryBuilder()
+			if err := tt.build(b); err != nil {
+				t.Fatalf("build error = %v", err)
+			}
+			query, args := b.build("SELECT * FROM t")
+			if query != tt.wantQuery {
+				t.Errorf("query = %q, want %q", query, tt.wantQuery)
+			}
+			if len(args) != 0 || len(tt.wantArgs) != 0 {
+				if !reflect.DeepEqual(args, tt.wantArgs) {
+					t.Errorf("args = %v, want %v", args, tt.wantArgs)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryBuilder_Count(t *testing.T) {
+	b := newQueryBuilder().where("a = ?", 1).orderByColumn("a", false).paginate(10, 20)
+
+	query, args := b.count("FROM t")
+	if query != "SELECT COUNT(*) FROM t WHERE a = $1" || !reflect.DeepEqual(args, []interface{}{1}) {
+		t.Errorf("count() = %q %v, want the conditions without order or pagination", query, args)
+	}
+
+	// Building after counting still numbers the pagination after the conditions
+	query, args = b.build("SELECT * FROM t")
+	if !strings.HasSuffix(query, "LIMIT $2 OFFSET $3") || len(args) != 3 {
+		t.Errorf("build() = %q %v, want LIMIT $2 OFFSET $3", query, args)
+	}
+}
+
+func TestQueryBuilder_RejectsUnknownSort(t *testing.T) {
+	b := newQueryBuilder()
+	if err := b.sortBy(map[string]string{"name": "name"}, "name; DROP TABLE users", false); err == nil || !strings.Contains(err.Error(), "invalid sort") {
+		t.Errorf("sortBy() error = %v, want invalid sort", err)
+	}
+	if query, _ := b.build("SELECT * FROM t"); query != "SELECT * FROM t" {
+		t.Errorf("query = %q, want no ORDER BY", query)
+	}
+}
+
+func TestQueryBuilder_WherePanicsOnArgumentMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("where() with a missing argument did not panic")
+		}
+	}()
+	newQueryBuilder().where("a = ? AND b = ?", 1)
+}
+
+func TestMatchSearchQuery(t *testing.T) {
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	b, err := matchSearchQuery(models.MatchFilter{TenantID: "tenant-1", Status: "Pending", MatchedBy: "user-1", DateFrom: &from}, 25, 50)
+	if err != nil {
+		t.Fatalf("matchSearchQuery() error = %v", err)
+	}
+
+	query, args := b.build("SELECT tm.id FROM transaction_matches tm")
+	want := "SELECT tm.id FROM transaction_matches tm WHERE tm.tenant_id = $1 AND tm.match_status = $2 AND tm.matched_by = $3 AND tm.created_at >= $4" +
+		" ORDER BY tm.created_at DESC, tm.id DESC LIMIT $5 OFFSET $6"
+	if query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"tenant-1", "Pending", "user-1", from, 25, 50}) {
+		t.Errorf("args = %v", args)
+	}
+
+	if _, err := matchSearchQuery(models.MatchFilter{SortBy: "password"}, 25, 0); err == nil || !strings.Contains(err.Error(), "invalid sort") {
+		t.Errorf("matchSearchQuery() with an unknown sort error = %v, want invalid sort", err)
+	}
+}
+
+func TestMockMatchRepository_SearchMatches(t *testing.T) {
+	repo := NewMatchRepository()
+	for _, match := range []models.TransactionMatch{
+		{ID: "m1", TenantID: "tenant-1", MatchStatus: "Pending"},
+		{ID: "m2", TenantID: "tenant-1", MatchStatus: "Approved"},
+		{ID: "m3", TenantID: "tenant-1", MatchStatus: "Pending"},
+		{ID: "m4", TenantID: "tenant-2", MatchStatus: "Pending"},
+	} {
+		repo.CreateMatch(&match)
+	}
+
+	matches, total, err := repo.SearchMatches(models.MatchFilter{TenantID: "tenant-1", Status: "Pending"}, 1, 0)
+	if err != nil {
+		t.Fatalf("SearchMatches() error = %v", err)
+	}
+	if total != 2 || len(matches) != 1 || matches[0].ID != "m3" {
+		t.Errorf("SearchMatches() = %+v, %d, want m3 of 2", matches, total)
+	}
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"backend/internal/db"
+	"backend/internal/models"
+	"database/sql"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrReportNotFound = errors.New("report not found")
+
+// ReportRepository defines operations for managing stored reconciliation reports
+type ReportRepository interface {
+	CreateReport(report *models.ReportFile) error
+	GetReportByID(tenantID, id string) (*models.ReportFile, error)
+	GetReportsByMatchSet(tenantID, matchSetID string) ([]models.ReportFile, error)
+}
+
+// PostgresReportRepository implements ReportRepository for PostgreSQL
+type PostgresReportRepository struct {
+	db *sql.DB
+}
+
+// NewReportRepository creates a new report repository
+func NewReportRepository() ReportRepository {
+	if db.DB == nil {
+		// Return a mock repository for development
+		return &MockReportRepository{
+			reports: make(map[string]*models.ReportFile),
+		}
+	}
+	return &PostgresReportRepository{
+		db: db.DB,
+	}
+}
+
+// reportColumns is the column list scanned by scanReport
+const reportColumns = `
+	id, tenant_id, COALESCE(match_set_id::text, ''), COALESCE(run_id::text, ''), period_start, period_end,
+	format, file_key, file_size, checksum, generated_by, created_at
+`
+
+// scanReport scans a report selected with reportColumns
+func scanReport(row rowScanner) (*models.ReportFile, error) {
+	var report models.ReportFile
+	err := row.Scan(
+		&report.ID,
+		&report.TenantID,
+		&report.MatchSetID,
+		&report.RunID,
+		&report.PeriodStart,
+		&report.PeriodEnd,
+		&report.Format,
+		&report.FileKey,
+		&report.FileSize,
+		&report.Checksum,
+		&report.GeneratedBy,
+		&report.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// CreateReport records a stored report
+func (r *PostgresReportRepository) CreateReport(report *models.ReportFile) error {
+	query := `
+		INSERT INTO reconciliation_reports (
+			tenant_id, match_set_id, run_id, period_start, period_end, format, file_key, file_size, checksum, generated_by
+		) VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`
+
+	tx, err := beginTenant(r.db, report.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
+		query,
+		report.TenantID,
+		report.MatchSetID,
+		report.RunID,
+		report.PeriodStart,
+		report.PeriodEnd,
+		report.Format,
+		report.FileKey,
+		report.FileSize,
+		report.Checksum,
+		report.GeneratedBy,
+	).Scan(&report.ID, &report.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetReportByID retrieves a stored report of a tenant by its ID
+func (r *PostgresReportRepository) GetReportByID(tenantID, id string) (*models.ReportFile, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT " + reportColumns + " FROM reconciliation_reports WHERE id = $1"
+
+	report, err := scanReport(tx.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrReportNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// GetReportsByMatchSet retrieves the stored reports of a match set, newest first
+func (r *PostgresReportRepository) GetReportsByMatchSet(tenantID, matchSetID string) ([]models.ReportFile, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT " + reportColumns + " FROM reconciliation_reports WHERE match_set_id = $1 ORDER BY created_at DESC, id"
+
+	rows, err := tx.Query(query, matchSetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []models.ReportFile
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, *report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+// MockReportRepository is a mock implementation for development
+type MockReportRepository struct {
+	mu      sync.Mutex
+	reports map[string]*models.ReportFile
+}
+
+// CreateReport records a stored report in the mock repository
+func (r *MockReportRepository) CreateReport(report *models.ReportFile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report.ID = uuid.New().String()
+	report.CreatedAt = time.Now()
+
+	stored := *report
+	r.reports[report.ID] = &stored
+	return nil
+}
+
+// GetReportByID retrieves a stored report from the mock repository
+func (r *MockReportRepository) GetReportByID(tenantID, id string) (*models.ReportFile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report, exists := r.reports[id]
+	if !exists || report.TenantID != tenantID {
+		return nil, ErrReportNotFound
+	}
+	copied := *report
+	return &copied, nil
+}
+
+// GetReportsByMatchSet retrieves the stored reports of a match set from the mock repository
+func (r *MockReportRepository) GetReportsByMatchSet(tenantID, matchSetID string) ([]models.ReportFile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var reports []models.ReportFile
+	for _, report := range r.reports {
+		if report.TenantID == tenantID && report.MatchSetID == matchSetID {
+			reports = append(reports, *report)
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].CreatedAt.After(reports[j].CreatedAt)
+	})
+	return reports, nil
+}
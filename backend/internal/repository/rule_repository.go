@@ -4,6 +4,7 @@ import (
 	"backend/internal/db"
 	"backend/internal/models"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"time"
 )
@@ -13,15 +14,16 @@ var (
 	ErrRuleExists   = errors.New("match rule with this name already exists")
 )
 
-// RuleRepository defines operations for managing matching rules
+// RuleRepository defines operations for managing matching rules. Rules belong to a tenant: they
+// are created and updated in the tenant they carry, and names are unique within a tenant.
 type RuleRepository interface {
 	CreateRule(rule *models.MatchRule) error
-	GetRuleByID(id string) (*models.MatchRule, error)
-	GetRuleByName(name string) (*models.MatchRule, error)
+	GetRuleByID(tenantID, id string) (*models.MatchRule, error)
+	GetRuleByName(tenantID, name string) (*models.MatchRule, error)
 	UpdateRule(rule *models.MatchRule) error
-	DeleteRule(id string) error
-	GetAllRules() ([]models.MatchRule, error)
-	GetActiveRules() ([]models.MatchRule, error)
+	DeleteRule(tenantID, id string) error
+	GetAllRules(tenantID string) ([]models.MatchRule, error)
+	GetActiveRules(tenantID string) ([]models.MatchRule, error)
 }
 
 // PostgresRuleRepository implements RuleRepository for PostgreSQL
@@ -29,6 +31,26 @@ type PostgresRuleRepository struct {
 	db *sql.DB
 }
 
+// ruleColumns is the column list scanned by scanRule
+const ruleColumns = `
+	id, tenant_id, name, description, match_by_amount, match_by_date, 
+	date_tolerance, match_by_reference, match_cardinality, amount_tolerance,
+	max_group_size, max_candidates, max_search_iterations, amount_tolerance_pct,
+	compare_across_currencies, fx_tolerance_pct, reference_mode, reference_pattern,
+	similarity_algorithm, similarity_threshold, conditions, active, created_by, 
+	created_at, updated_at
+`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// tenantKey keys a name unique within a tenant in the indexes of mock repositories
+func tenantKey(tenantID, name string) string {
+	return tenantID + "/" + name
+}
+
 // NewRuleRepository creates a new rule repository
 func NewRuleRepository() RuleRepository {
 	if db.DB == nil {
@@ -42,11 +64,91 @@ func NewRuleRepository() RuleRepository {
 	}
 }
 
+// scanRule scans a match rule selected with ruleColumns
+func scanRule(row rowScanner) (*models.MatchRule, error) {
+	var rule models.MatchRule
+	var conditions []byte
+	err := row.Scan(
+		&rule.ID,
+		&rule.TenantID,
+		&rule.Name,
+		&rule.Description,
+		&rule.MatchByAmount,
+		&rule.MatchByDate,
+		&rule.DateTolerance,
+		&rule.MatchByReference,
+		&rule.MatchCardinality,
+		&rule.AmountTolerance,
+		&rule.MaxGroupSize,
+		&rule.MaxCandidates,
+		&rule.MaxSearchIterations,
+		&rule.AmountTolerancePct,
+		&rule.CompareAcrossCurrencies,
+		&rule.FXTolerancePct,
+		&rule.ReferenceMode,
+		&rule.ReferencePattern,
+		&rule.SimilarityAlgorithm,
+		&rule.SimilarityThreshold,
+		&conditions,
+		&rule.Active,
+		&rule.CreatedBy,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(conditions, &rule.Conditions); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// conditionsJSON encodes a rule's conditions for the conditions column
+func conditionsJSON(rule *models.MatchRule) ([]byte, error) {
+	if rule.Conditions == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(rule.Conditions)
+}
+
+// applyRuleDefaults fills in group matching and reference settings left unset by the caller
+func applyRuleDefaults(rule *models.MatchRule) {
+	if rule.MatchCardinality == "" {
+		rule.MatchCardinality = models.MatchCardinalityOneToOne
+	}
+	if rule.MaxGroupSize <= 0 {
+		rule.MaxGroupSize = 5
+	}
+	if rule.MaxCandidates <= 0 {
+		rule.MaxCandidates = 25
+	}
+	if rule.MaxSearchIterations <= 0 {
+		rule.MaxSearchIterations = 10000
+	}
+	if rule.ReferenceMode == "" {
+		rule.ReferenceMode = models.ReferenceModeExact
+	}
+	if rule.SimilarityAlgorithm == "" {
+		rule.SimilarityAlgorithm = models.SimilarityLevenshtein
+	}
+	if rule.SimilarityThreshold <= 0 {
+		rule.SimilarityThreshold = 0.8
+	}
+}
+
 // CreateRule creates a new match rule
 func (r *PostgresRuleRepository) CreateRule(rule *models.MatchRule) error {
+	tx, err := beginTenant(r.db, rule.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Check if rule with the same name already exists
 	var exists bool
-	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM match_rules WHERE name = $1)", rule.Name).Scan(&exists)
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM match_rules WHERE tenant_id = $1 AND name = $2)", rule.TenantID, rule.Name).Scan(&exists)
 	if err != nil {
 		return err
 	}
@@ -55,91 +157,80 @@ func (r *PostgresRuleRepository) CreateRule(rule *models.MatchRule) error {
 		return ErrRuleExists
 	}
 
+	applyRuleDefaults(rule)
+
+	conditions, err := conditionsJSON(rule)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO match_rules (
-			name, description, match_by_amount, match_by_date, 
-			date_tolerance, match_by_reference, active, created_by
+			tenant_id, name, description, match_by_amount, match_by_date, 
+			date_tolerance, match_by_reference, match_cardinality, amount_tolerance,
+			max_group_size, max_candidates, max_search_iterations, amount_tolerance_pct,
+			compare_across_currencies, fx_tolerance_pct, reference_mode, reference_pattern,
+			similarity_algorithm, similarity_threshold, conditions, active, created_by
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22
 		) RETURNING id, created_at, updated_at
 	`
 
-	return r.db.QueryRow(
+	err = tx.QueryRow(
 		query,
+		rule.TenantID,
 		rule.Name,
 		rule.Description,
 		rule.MatchByAmount,
 		rule.MatchByDate,
 		rule.DateTolerance,
 		rule.MatchByReference,
+		rule.MatchCardinality,
+		rule.AmountTolerance,
+		rule.MaxGroupSize,
+		rule.MaxCandidates,
+		rule.MaxSearchIterations,
+		rule.AmountTolerancePct,
+		rule.CompareAcrossCurrencies,
+		rule.FXTolerancePct,
+		rule.ReferenceMode,
+		rule.ReferencePattern,
+		rule.SimilarityAlgorithm,
+		rule.SimilarityThreshold,
+		conditions,
 		rule.Active,
 		rule.CreatedBy,
 	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// GetRuleByID retrieves a match rule by ID
-func (r *PostgresRuleRepository) GetRuleByID(id string) (*models.MatchRule, error) {
-	query := `
-		SELECT 
-			id, name, description, match_by_amount, match_by_date, 
-			date_tolerance, match_by_reference, active, created_by, 
-			created_at, updated_at
-		FROM match_rules
-		WHERE id = $1
-	`
+// GetRuleByID retrieves a match rule of a tenant by ID
+func (r *PostgresRuleRepository) GetRuleByID(tenantID, id string) (*models.MatchRule, error) {
+	query := "SELECT " + ruleColumns + " FROM match_rules WHERE tenant_id = $1 AND id = $2"
 
-	var rule models.MatchRule
-	err := r.db.QueryRow(query, id).Scan(
-		&rule.ID,
-		&rule.Name,
-		&rule.Description,
-		&rule.MatchByAmount,
-		&rule.MatchByDate,
-		&rule.DateTolerance,
-		&rule.MatchByReference,
-		&rule.Active,
-		&rule.CreatedBy,
-		&rule.CreatedAt,
-		&rule.UpdatedAt,
-	)
+	return r.getRule(tenantID, query, tenantID, id)
+}
 
-	if err == sql.ErrNoRows {
-		return nil, ErrRuleNotFound
-	}
+// GetRuleByName retrieves a match rule of a tenant by name
+func (r *PostgresRuleRepository) GetRuleByName(tenantID, name string) (*models.MatchRule, error) {
+	query := "SELECT " + ruleColumns + " FROM match_rules WHERE tenant_id = $1 AND name = $2"
+
+	return r.getRule(tenantID, query, tenantID, name)
+}
 
+// getRule runs a query selecting ruleColumns of a single match rule in a tenant's session
+func (r *PostgresRuleRepository) getRule(tenantID, query string, args ...interface{}) (*models.MatchRule, error) {
+	tx, err := beginTenant(r.db, tenantID)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	return &rule, nil
-}
-
-// GetRuleByName retrieves a match rule by name
-func (r *PostgresRuleRepository) GetRuleByName(name string) (*models.MatchRule, error) {
-	query := `
-		SELECT 
-			id, name, description, match_by_amount, match_by_date, 
-			date_tolerance, match_by_reference, active, created_by, 
-			created_at, updated_at
-		FROM match_rules
-		WHERE name = $1
-	`
-
-	var rule models.MatchRule
-	err := r.db.QueryRow(query, name).Scan(
-		&rule.ID,
-		&rule.Name,
-		&rule.Description,
-		&rule.MatchByAmount,
-		&rule.MatchByDate,
-		&rule.DateTolerance,
-		&rule.MatchByReference,
-		&rule.Active,
-		&rule.CreatedBy,
-		&rule.CreatedAt,
-		&rule.UpdatedAt,
-	)
-
+	rule, err := scanRule(tx.QueryRow(query, args...))
 	if err == sql.ErrNoRows {
 		return nil, ErrRuleNotFound
 	}
@@ -148,14 +239,20 @@ func (r *PostgresRuleRepository) GetRuleByName(name string) (*models.MatchRule,
 		return nil, err
 	}
 
-	return &rule, nil
+	return rule, nil
 }
 
-// UpdateRule updates a match rule
+// UpdateRule updates a match rule of the tenant it carries
 func (r *PostgresRuleRepository) UpdateRule(rule *models.MatchRule) error {
-	// Check if another rule with the same name already exists
+	tx, err := beginTenant(r.db, rule.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Check if another rule of the tenant with the same name already exists
 	var count int
-	err := r.db.QueryRow("SELECT COUNT(*) FROM match_rules WHERE name = $1 AND id != $2", rule.Name, rule.ID).Scan(&count)
+	err = tx.QueryRow("SELECT COUNT(*) FROM match_rules WHERE tenant_id = $1 AND name = $2 AND id != $3", rule.TenantID, rule.Name, rule.ID).Scan(&count)
 	if err != nil {
 		return err
 	}
@@ -164,6 +261,13 @@ func (r *PostgresRuleRepository) UpdateRule(rule *models.MatchRule) error {
 		return ErrRuleExists
 	}
 
+	applyRuleDefaults(rule)
+
+	conditions, err := conditionsJSON(rule)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE match_rules
 		SET 
@@ -173,14 +277,27 @@ func (r *PostgresRuleRepository) UpdateRule(rule *models.MatchRule) error {
 			match_by_date = $4, 
 			date_tolerance = $5, 
 			match_by_reference = $6, 
-			active = $7,
+			match_cardinality = $7,
+			amount_tolerance = $8,
+			max_group_size = $9,
+			max_candidates = $10,
+			max_search_iterations = $11,
+			amount_tolerance_pct = $12,
+			compare_across_currencies = $13,
+			fx_tolerance_pct = $14,
+			reference_mode = $15,
+			reference_pattern = $16,
+			similarity_algorithm = $17,
+			similarity_threshold = $18,
+			conditions = $19,
+			active = $20,
 			updated_at = NOW()
-		WHERE id = $8
+		WHERE id = $21 AND tenant_id = $22
 		RETURNING updated_at
 	`
 
 	var updatedAt time.Time
-	err = r.db.QueryRow(
+	err = tx.QueryRow(
 		query,
 		rule.Name,
 		rule.Description,
@@ -188,8 +305,22 @@ func (r *PostgresRuleRepository) UpdateRule(rule *models.MatchRule) error {
 		rule.MatchByDate,
 		rule.DateTolerance,
 		rule.MatchByReference,
+		rule.MatchCardinality,
+		rule.AmountTolerance,
+		rule.MaxGroupSize,
+		rule.MaxCandidates,
+		rule.MaxSearchIterations,
+		rule.AmountTolerancePct,
+		rule.CompareAcrossCurrencies,
+		rule.FXTolerancePct,
+		rule.ReferenceMode,
+		rule.ReferencePattern,
+		rule.SimilarityAlgorithm,
+		rule.SimilarityThreshold,
+		conditions,
 		rule.Active,
 		rule.ID,
+		rule.TenantID,
 	).Scan(&updatedAt)
 
 	if err == sql.ErrNoRows {
@@ -201,14 +332,20 @@ func (r *PostgresRuleRepository) UpdateRule(rule *models.MatchRule) error {
 	}
 
 	rule.UpdatedAt = updatedAt
-	return nil
+	return tx.Commit()
 }
 
-// DeleteRule deletes a match rule
-func (r *PostgresRuleRepository) DeleteRule(id string) error {
+// DeleteRule deletes a match rule of a tenant
+func (r *PostgresRuleRepository) DeleteRule(tenantID, id string) error {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Check if there are any existing matches using this rule
 	var count int
-	err := r.db.QueryRow("SELECT COUNT(*) FROM transaction_matches WHERE match_rule_id = $1", id).Scan(&count)
+	err = tx.QueryRow("SELECT COUNT(*) FROM transaction_matches WHERE match_rule_id = $1", id).Scan(&count)
 	if err != nil {
 		return err
 	}
@@ -217,8 +354,8 @@ func (r *PostgresRuleRepository) DeleteRule(id string) error {
 		return errors.New("cannot delete rule used by existing matches")
 	}
 
-	query := "DELETE FROM match_rules WHERE id = $1"
-	result, err := r.db.Exec(query, id)
+	query := "DELETE FROM match_rules WHERE tenant_id = $1 AND id = $2"
+	result, err := tx.Exec(query, tenantID, id)
 	if err != nil {
 		return err
 	}
@@ -232,70 +369,34 @@ func (r *PostgresRuleRepository) DeleteRule(id string) error {
 		return ErrRuleNotFound
 	}
 
-	return nil
+	return tx.Commit()
 }
 
-// GetAllRules retrieves all match rules
-func (r *PostgresRuleRepository) GetAllRules() ([]models.MatchRule, error) {
-	query := `
-		SELECT 
-			id, name, description, match_by_amount, match_by_date, 
-			date_tolerance, match_by_reference, active, created_by, 
-			created_at, updated_at
-		FROM match_rules
-		ORDER BY name
-	`
-
-	rows, err := r.db.Query(query)
+// GetAllRules retrieves all match rules of a tenant
+func (r *PostgresRuleRepository) GetAllRules(tenantID string) ([]models.MatchRule, error) {
+	tx, err := beginTenant(r.db, tenantID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var rules []models.MatchRule
-	for rows.Next() {
-		var rule models.MatchRule
-		err := rows.Scan(
-			&rule.ID,
-			&rule.Name,
-			&rule.Description,
-			&rule.MatchByAmount,
-			&rule.MatchByDate,
-			&rule.DateTolerance,
-			&rule.MatchByReference,
-			&rule.Active,
-			&rule.CreatedBy,
-			&rule.CreatedAt,
-			&rule.UpdatedAt,
-		)
+	defer tx.Rollback()
 
-		if err != nil {
-			return nil, err
-		}
-
-		rules = append(rules, rule)
-	}
+	return queryRules(tx, "SELECT "+ruleColumns+" FROM match_rules WHERE tenant_id = $1 ORDER BY name", tenantID)
+}
 
-	if err := rows.Err(); err != nil {
+// GetActiveRules retrieves all active match rules of a tenant
+func (r *PostgresRuleRepository) GetActiveRules(tenantID string) ([]models.MatchRule, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	return rules, nil
+	return queryRules(tx, "SELECT "+ruleColumns+" FROM match_rules WHERE tenant_id = $1 AND active = true ORDER BY name", tenantID)
 }
 
-// GetActiveRules retrieves all active match rules
-func (r *PostgresRuleRepository) GetActiveRules() ([]models.MatchRule, error) {
-	query := `
-		SELECT 
-			id, name, description, match_by_amount, match_by_date, 
-			date_tolerance, match_by_reference, active, created_by, 
-			created_at, updated_at
-		FROM match_rules
-		WHERE active = true
-		ORDER BY name
-	`
-
-	rows, err := r.db.Query(query)
+// queryRules runs a query selecting ruleColumns and scans every row
+func queryRules(q querier, query string, args ...interface{}) ([]models.MatchRule, error) {
+	rows, err := q.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -303,26 +404,12 @@ func (r *PostgresRuleRepository) GetActiveRules() ([]models.MatchRule, error) {
 
 	var rules []models.MatchRule
 	for rows.Next() {
-		var rule models.MatchRule
-		err := rows.Scan(
-			&rule.ID,
-			&rule.Name,
-			&rule.Description,
-			&rule.MatchByAmount,
-			&rule.MatchByDate,
-			&rule.DateTolerance,
-			&rule.MatchByReference,
-			&rule.Active,
-			&rule.CreatedBy,
-			&rule.CreatedAt,
-			&rule.UpdatedAt,
-		)
-
+		rule, err := scanRule(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		rules = append(rules, rule)
+		rules = append(rules, *rule)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -335,7 +422,7 @@ func (r *PostgresRuleRepository) GetActiveRules() ([]models.MatchRule, error) {
 // MockRuleRepository is a mock implementation for development
 type MockRuleRepository struct {
 	rules     map[string]*models.MatchRule
-	nameIndex map[string]string // name -> id mapping
+	nameIndex map[string]string // tenantKey(tenant, name) -> id mapping
 }
 
 // CreateRule creates a match rule in the mock repository
@@ -345,8 +432,8 @@ func (r *MockRuleRepository) CreateRule(rule *models.MatchRule) error {
 		r.nameIndex = make(map[string]string)
 	}
 
-	// Check if rule with the same name exists
-	if _, exists := r.nameIndex[rule.Name]; exists {
+	// Check if rule with the same name exists in the tenant
+	if _, exists := r.nameIndex[tenantKey(rule.TenantID, rule.Name)]; exists {
 		return ErrRuleExists
 	}
 
@@ -354,31 +441,32 @@ func (r *MockRuleRepository) CreateRule(rule *models.MatchRule) error {
 		// Generate a dummy ID - in real implementation we'd use UUID
 		rule.ID = "mock-" + time.Now().Format("20060102150405")
 	}
+	applyRuleDefaults(rule)
 	rule.CreatedAt = time.Now()
 	rule.UpdatedAt = time.Now()
 
 	r.rules[rule.ID] = rule
-	r.nameIndex[rule.Name] = rule.ID
+	r.nameIndex[tenantKey(rule.TenantID, rule.Name)] = rule.ID
 
 	return nil
 }
 
-// GetRuleByID retrieves a match rule by ID from the mock repository
-func (r *MockRuleRepository) GetRuleByID(id string) (*models.MatchRule, error) {
-	if rule, exists := r.rules[id]; exists {
+// GetRuleByID retrieves a match rule of a tenant by ID from the mock repository
+func (r *MockRuleRepository) GetRuleByID(tenantID, id string) (*models.MatchRule, error) {
+	if rule, exists := r.rules[id]; exists && rule.TenantID == tenantID {
 		return rule, nil
 	}
 	return nil, ErrRuleNotFound
 }
 
-// GetRuleByName retrieves a match rule by name from the mock repository
-func (r *MockRuleRepository) GetRuleByName(name string) (*models.MatchRule, error) {
+// GetRuleByName retrieves a match rule of a tenant by name from the mock repository
+func (r *MockRuleRepository) GetRuleByName(tenantID, name string) (*models.MatchRule, error) {
 	// Initialize nameIndex if it doesn't exist
 	if r.nameIndex == nil {
 		r.nameIndex = make(map[string]string)
 	}
 
-	id, exists := r.nameIndex[name]
+	id, exists := r.nameIndex[tenantKey(tenantID, name)]
 	if !exists {
 		return nil, ErrRuleNotFound
 	}
@@ -386,7 +474,7 @@ func (r *MockRuleRepository) GetRuleByName(name string) (*models.MatchRule, erro
 	return r.rules[id], nil
 }
 
-// UpdateRule updates a match rule in the mock repository
+// UpdateRule updates a match rule of the tenant it carries in the mock repository
 func (r *MockRuleRepository) UpdateRule(rule *models.MatchRule) error {
 	// Initialize nameIndex if it doesn't exist
 	if r.nameIndex == nil {
@@ -394,59 +482,62 @@ func (r *MockRuleRepository) UpdateRule(rule *models.MatchRule) error {
 	}
 
 	existing, exists := r.rules[rule.ID]
-	if !exists {
+	if !exists || existing.TenantID != rule.TenantID {
 		return ErrRuleNotFound
 	}
 
-	// Check if another rule with the same name exists
-	if id, nameExists := r.nameIndex[rule.Name]; nameExists && id != rule.ID {
+	// Check if another rule with the same name exists in the tenant
+	if id, nameExists := r.nameIndex[tenantKey(rule.TenantID, rule.Name)]; nameExists && id != rule.ID {
 		return ErrRuleExists
 	}
 
 	// Update the name index if the name has changed
 	if existing.Name != rule.Name {
-		delete(r.nameIndex, existing.Name)
-		r.nameIndex[rule.Name] = rule.ID
+		delete(r.nameIndex, tenantKey(existing.TenantID, existing.Name))
+		r.nameIndex[tenantKey(rule.TenantID, rule.Name)] = rule.ID
 	}
 
+	applyRuleDefaults(rule)
 	rule.UpdatedAt = time.Now()
 	r.rules[rule.ID] = rule
 
 	return nil
 }
 
-// DeleteRule deletes a match rule from the mock repository
-func (r *MockRuleRepository) DeleteRule(id string) error {
+// DeleteRule deletes a match rule of a tenant from the mock repository
+func (r *MockRuleRepository) DeleteRule(tenantID, id string) error {
 	// Initialize nameIndex if it doesn't exist
 	if r.nameIndex == nil {
 		r.nameIndex = make(map[string]string)
 	}
 
 	rule, exists := r.rules[id]
-	if !exists {
+	if !exists || rule.TenantID != tenantID {
 		return ErrRuleNotFound
 	}
 
-	delete(r.nameIndex, rule.Name)
+	delete(r.nameIndex, tenantKey(rule.TenantID, rule.Name))
 	delete(r.rules, id)
 
 	return nil
 }
 
-// GetAllRules retrieves all match rules from the mock repository
-func (r *MockRuleRepository) GetAllRules() ([]models.MatchRule, error) {
+// GetAllRules retrieves all match rules of a tenant from the mock repository
+func (r *MockRuleRepository) GetAllRules(tenantID string) ([]models.MatchRule, error) {
 	var rules []models.MatchRule
 	for _, rule := range r.rules {
-		rules = append(rules, *rule)
+		if rule.TenantID == tenantID {
+			rules = append(rules, *rule)
+		}
 	}
 	return rules, nil
 }
 
-// GetActiveRules retrieves all active match rules from the mock repository
-func (r *MockRuleRepository) GetActiveRules() ([]models.MatchRule, error) {
+// GetActiveRules retrieves all active match rules of a tenant from the mock repository
+func (r *MockRuleRepository) GetActiveRules(tenantID string) ([]models.MatchRule, error) {
 	var rules []models.MatchRule
 	for _, rule := range r.rules {
-		if rule.Active {
+		if rule.TenantID == tenantID && rule.Active {
 			rules = append(rules, *rule)
 		}
 	}
@@ -0,0 +1,599 @@
+package repository
+
+import (
+	"backend/internal/db"
+	"backend/internal/models"
+	"database/sql"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrScheduleNotFound     = errors.New("match set schedule not found")
+	ErrScheduledRunNotFound = errors.New("scheduled run not found")
+)
+
+// ScheduleRepository defines operations for managing match set schedules and their runs
+type ScheduleRepository interface {
+	CreateSchedule(schedule *models.MatchSetSchedule) error
+	GetScheduleByID(tenantID, id string) (*models.MatchSetSchedule, error)
+	GetSchedulesByMatchSet(tenantID, matchSetID string) ([]models.MatchSetSchedule, error)
+	UpdateSchedule(schedule *models.MatchSetSchedule) error
+	DeleteSchedule(tenantID, id string) error
+	GetDueSchedules(now time.Time, limit int) ([]models.MatchSetSchedule, error)
+	ClaimScheduledRun(tenantID, scheduleID string, due, next time.Time) (bool, error)
+	CreateScheduledRun(run *models.ScheduledRun) error
+	FinishScheduledRun(run *models.ScheduledRun) error
+	GetScheduledRuns(scheduleID string, limit int) ([]models.ScheduledRun, error)
+}
+
+// PostgresScheduleRepository implements ScheduleRepository for PostgreSQL
+type PostgresScheduleRepository struct {
+	db *sql.DB
+}
+
+// NewScheduleRepository creates a new schedule repository
+func NewScheduleRepository() ScheduleRepository {
+	if db.DB == nil {
+		// Return a mock repository for development
+		return &MockScheduleRepository{
+			schedules: make(map[string]*models.MatchSetSchedule),
+			runs:      make(map[string]*models.ScheduledRun),
+		}
+	}
+	return &PostgresScheduleRepository{
+		db: db.DB,
+	}
+}
+
+// scheduleColumns is the column list scanned by scanSchedule
+const scheduleColumns = `
+	id, match_set_id, tenant_id, cron_expression, timezone, mode, active, next_run_at, last_run_at,
+	created_by, created_at, updated_at
+`
+
+// scanSchedule scans a schedule selected with scheduleColumns
+func scanSchedule(row rowScanner) (*models.MatchSetSchedule, error) {
+	var schedule models.MatchSetSchedule
+	var nextRunAt, lastRunAt sql.NullTime
+	err := row.Scan(
+		&schedule.ID,
+		&schedule.MatchSetID,
+		&schedule.TenantID,
+		&schedule.CronExpression,
+		&schedule.Timezone,
+		&schedule.Mode,
+		&schedule.Active,
+		&nextRunAt,
+		&lastRunAt,
+		&schedule.CreatedBy,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if nextRunAt.Valid {
+		schedule.NextRunAt = &nextRunAt.Time
+	}
+	if lastRunAt.Valid {
+		schedule.LastRunAt = &lastRunAt.Time
+	}
+	return &schedule, nil
+}
+
+// scheduledRunColumns is the column list scanned by scanScheduledRun
+const scheduledRunColumns = `
+	id, schedule_id, match_set_id, scheduled_for, status, COALESCE(run_id::text, ''), COALESCE(message, ''),
+	created_at, finished_at
+`
+
+// scanScheduledRun scans a scheduled run selected with scheduledRunColumns
+func scanScheduledRun(row rowScanner) (*models.ScheduledRun, error) {
+	var run models.ScheduledRun
+	var finishedAt sql.NullTime
+	err := row.Scan(
+		&run.ID,
+		&run.ScheduleID,
+		&run.MatchSetID,
+		&run.ScheduledFor,
+		&run.Status,
+		&run.RunID,
+		&run.Message,
+		&run.CreatedAt,
+		&finishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+	return &run, nil
+}
+
+// nullableTime returns a parameter writing NULL for a nil time
+func nullableTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+// CreateSchedule creates a new match set schedule
+func (r *PostgresScheduleRepository) CreateSchedule(schedule *models.MatchSetSchedule) error {
+	query := `
+		INSERT INTO match_set_schedules (
+			match_set_id, tenant_id, cron_expression, timezone, mode, active, next_run_at, created_by
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		) RETURNING id, created_at, updated_at
+	`
+
+	tx, err := beginTenant(r.db, schedule.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
+		query,
+		schedule.MatchSetID,
+		schedule.TenantID,
+		schedule.CronExpression,
+		schedule.Timezone,
+		schedule.Mode,
+		schedule.Active,
+		nullableTime(schedule.NextRunAt),
+		schedule.CreatedBy,
+	).Scan(&schedule.ID, &schedule.CreatedAt, &schedule.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetScheduleByID retrieves a match set schedule of a tenant by ID
+func (r *PostgresScheduleRepository) GetScheduleByID(tenantID, id string) (*models.MatchSetSchedule, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT " + scheduleColumns + " FROM match_set_schedules WHERE id = $1"
+
+	schedule, err := scanSchedule(tx.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrScheduleNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// GetSchedulesByMatchSet retrieves the schedules of a match set in the order they were created
+func (r *PostgresScheduleRepository) GetSchedulesByMatchSet(tenantID, matchSetID string) ([]models.MatchSetSchedule, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT " + scheduleColumns + " FROM match_set_schedules WHERE match_set_id = $1 ORDER BY created_at, id"
+	return querySchedules(tx, query, matchSetID)
+}
+
+// querySchedules runs a query selecting scheduleColumns
+func querySchedules(q querier, query string, args ...interface{}) ([]models.MatchSetSchedule, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []models.MatchSetSchedule
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *schedule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// UpdateSchedule updates a match set schedule
+func (r *PostgresScheduleRepository) UpdateSchedule(schedule *models.MatchSetSchedule) error {
+	query := `
+		UPDATE match_set_schedules
+		SET cron_expression = $1, timezone = $2, mode = $3, active = $4, next_run_at = $5, updated_at = NOW()
+		WHERE id = $6
+		RETURNING last_run_at, updated_at
+	`
+
+	tx, err := beginTenant(r.db, schedule.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var lastRunAt sql.NullTime
+	err = tx.QueryRow(
+		query,
+		schedule.CronExpression,
+		schedule.Timezone,
+		schedule.Mode,
+		schedule.Active,
+		nullableTime(schedule.NextRunAt),
+		schedule.ID,
+	).Scan(&lastRunAt, &schedule.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return ErrScheduleNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	schedule.LastRunAt = nil
+	if lastRunAt.Valid {
+		schedule.LastRunAt = &lastRunAt.Time
+	}
+	return tx.Commit()
+}
+
+// DeleteSchedule deletes a match set schedule and the record of its runs
+func (r *PostgresScheduleRepository) DeleteSchedule(tenantID, id string) error {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM match_set_schedules WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrScheduleNotFound
+	}
+
+	return tx.Commit()
+}
+
+// GetDueSchedules retrieves active schedules of every tenant whose next run is due, earliest first
+func (r *PostgresScheduleRepository) GetDueSchedules(now time.Time, limit int) ([]models.MatchSetSchedule, error) {
+	query := "SELECT " + scheduleColumns + `
+		FROM match_set_schedules
+		WHERE active AND next_run_at <= $1
+		ORDER BY next_run_at, id
+		LIMIT $2
+	`
+
+	tx, err := beginAllTenants(r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return querySchedules(tx, query, now.UTC(), limit)
+}
+
+// ClaimScheduledRun moves a schedule from its due run to the next one. Only one of the instances
+// that saw the run due claims it; the others get false.
+func (r *PostgresScheduleRepository) ClaimScheduledRun(tenantID, scheduleID string, due, next time.Time) (bool, error) {
+	query := `
+		UPDATE match_set_schedules
+		SET next_run_at = $1, last_run_at = $2
+		WHERE id = $3 AND active AND next_run_at = $2
+	`
+
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, next.UTC(), due.UTC(), scheduleID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, tx.Commit()
+}
+
+// CreateScheduledRun records a run a schedule was due to start
+func (r *PostgresScheduleRepository) CreateScheduledRun(run *models.ScheduledRun) error {
+	var runIDParam interface{} = nil
+	if run.RunID != "" {
+		runIDParam = run.RunID
+	}
+
+	query := `
+		INSERT INTO scheduled_runs (schedule_id, match_set_id, scheduled_for, status, run_id, message, finished_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+		RETURNING id, created_at
+	`
+
+	return r.db.QueryRow(
+		query,
+		run.ScheduleID,
+		run.MatchSetID,
+		run.ScheduledFor.UTC(),
+		run.Status,
+		runIDParam,
+		run.Message,
+		nullableTime(run.FinishedAt),
+	).Scan(&run.ID, &run.CreatedAt)
+}
+
+// FinishScheduledRun records the outcome of a scheduled run
+func (r *PostgresScheduleRepository) FinishScheduledRun(run *models.ScheduledRun) error {
+	var runIDParam interface{} = nil
+	if run.RunID != "" {
+		runIDParam = run.RunID
+	}
+
+	query := `
+		UPDATE scheduled_runs
+		SET status = $1, run_id = COALESCE($2, run_id), message = NULLIF($3, ''), finished_at = NOW()
+		WHERE id = $4
+		RETURNING finished_at
+	`
+
+	var finishedAt time.Time
+	err := r.db.QueryRow(query, run.Status, runIDParam, run.Message, run.ID).Scan(&finishedAt)
+	if err == sql.ErrNoRows {
+		return ErrScheduledRunNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	run.FinishedAt = &finishedAt
+	return nil
+}
+
+// GetScheduledRuns retrieves the latest runs of a schedule, newest first
+func (r *PostgresScheduleRepository) GetScheduledRuns(scheduleID string, limit int) ([]models.ScheduledRun, error) {
+	query := "SELECT " + scheduledRunColumns + `
+		FROM scheduled_runs
+		WHERE schedule_id = $1
+		ORDER BY scheduled_for DESC, created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(query, scheduleID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []models.ScheduledRun
+	for rows.Next() {
+		run, err := scanScheduledRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// MockScheduleRepository is a mock implementation for development. The scheduler and queue
+// workers use it from their own goroutines, so it is guarded by a mutex.
+type MockScheduleRepository struct {
+	mu        sync.Mutex
+	schedules map[string]*models.MatchSetSchedule
+	runs      map[string]*models.ScheduledRun
+}
+
+// CreateSchedule creates a match set schedule in the mock repository
+func (r *MockScheduleRepository) CreateSchedule(schedule *models.MatchSetSchedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if schedule.ID == "" {
+		schedule.ID = uuid.New().String()
+	}
+	schedule.CreatedAt = time.Now()
+	schedule.UpdatedAt = schedule.CreatedAt
+
+	stored := *schedule
+	r.schedules[schedule.ID] = &stored
+	return nil
+}
+
+// GetScheduleByID retrieves a match set schedule from the mock repository
+func (r *MockScheduleRepository) GetScheduleByID(tenantID, id string) (*models.MatchSetSchedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	schedule, exists := r.schedules[id]
+	if !exists || schedule.TenantID != tenantID {
+		return nil, ErrScheduleNotFound
+	}
+	copied := *schedule
+	return &copied, nil
+}
+
+// GetSchedulesByMatchSet retrieves the schedules of a match set from the mock repository
+func (r *MockScheduleRepository) GetSchedulesByMatchSet(tenantID, matchSetID string) ([]models.MatchSetSchedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var schedules []models.MatchSetSchedule
+	for _, schedule := range r.schedules {
+		if schedule.TenantID == tenantID && schedule.MatchSetID == matchSetID {
+			schedules = append(schedules, *schedule)
+		}
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		if !schedules[i].CreatedAt.Equal(schedules[j].CreatedAt) {
+			return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+		}
+		return schedules[i].ID < schedules[j].ID
+	})
+	return schedules, nil
+}
+
+// UpdateSchedule updates a match set schedule in the mock repository
+func (r *MockScheduleRepository) UpdateSchedule(schedule *models.MatchSetSchedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.schedules[schedule.ID]
+	if !exists || existing.TenantID != schedule.TenantID {
+		return ErrScheduleNotFound
+	}
+
+	schedule.LastRunAt = existing.LastRunAt
+	schedule.CreatedAt = existing.CreatedAt
+	schedule.UpdatedAt = time.Now()
+	stored := *schedule
+	r.schedules[schedule.ID] = &stored
+	return nil
+}
+
+// DeleteSchedule deletes a match set schedule and its runs from the mock repository
+func (r *MockScheduleRepository) DeleteSchedule(tenantID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if schedule, exists := r.schedules[id]; !exists || schedule.TenantID != tenantID {
+		return ErrScheduleNotFound
+	}
+	delete(r.schedules, id)
+	for runID, run := range r.runs {
+		if run.ScheduleID == id {
+			delete(r.runs, runID)
+		}
+	}
+	return nil
+}
+
+// GetDueSchedules retrieves the due schedules from the mock repository
+func (r *MockScheduleRepository) GetDueSchedules(now time.Time, limit int) ([]models.MatchSetSchedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var schedules []models.MatchSetSchedule
+	for _, schedule := range r.schedules {
+		if schedule.Active && schedule.NextRunAt != nil && !schedule.NextRunAt.After(now) {
+			schedules = append(schedules, *schedule)
+		}
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		if !schedules[i].NextRunAt.Equal(*schedules[j].NextRunAt) {
+			return schedules[i].NextRunAt.Before(*schedules[j].NextRunAt)
+		}
+		return schedules[i].ID < schedules[j].ID
+	})
+	if len(schedules) > limit {
+		schedules = schedules[:limit]
+	}
+	return schedules, nil
+}
+
+// ClaimScheduledRun moves a schedule to its next run in the mock repository
+func (r *MockScheduleRepository) ClaimScheduledRun(tenantID, scheduleID string, due, next time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	schedule, exists := r.schedules[scheduleID]
+	if !exists || schedule.TenantID != tenantID || !schedule.Active || schedule.NextRunAt == nil || !schedule.NextRunAt.Equal(due) {
+		return false, nil
+	}
+	schedule.NextRunAt = &next
+	schedule.LastRunAt = &due
+	return true, nil
+}
+
+// CreateScheduledRun records a scheduled run in the mock repository
+func (r *MockScheduleRepository) CreateScheduledRun(run *models.ScheduledRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if run.ID == "" {
+		run.ID = uuid.New().String()
+	}
+	run.CreatedAt = time.Now()
+
+	stored := *run
+	r.runs[run.ID] = &stored
+	return nil
+}
+
+// FinishScheduledRun records the outcome of a scheduled run in the mock repository
+func (r *MockScheduleRepository) FinishScheduledRun(run *models.ScheduledRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.runs[run.ID]
+	if !exists {
+		return ErrScheduledRunNotFound
+	}
+
+	finishedAt := time.Now()
+	existing.Status = run.Status
+	if run.RunID != "" {
+		existing.RunID = run.RunID
+	}
+	existing.Message = run.Message
+	existing.FinishedAt = &finishedAt
+	run.FinishedAt = &finishedAt
+	return nil
+}
+
+// GetScheduledRuns retrieves the latest runs of a schedule from the mock repository
+func (r *MockScheduleRepository) GetScheduledRuns(scheduleID string, limit int) ([]models.ScheduledRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var runs []models.ScheduledRun
+	for _, run := range r.runs {
+		if run.ScheduleID == scheduleID {
+			runs = append(runs, *run)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		if !runs[i].ScheduledFor.Equal(runs[j].ScheduledFor) {
+			return runs[i].ScheduledFor.After(runs[j].ScheduledFor)
+		}
+		return runs[i].CreatedAt.After(runs[j].CreatedAt)
+	})
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
@@ -23,10 +23,10 @@ var (
 type SchemaRepository interface {
 	// Schema operations
 	CreateSchema(schema *models.DataSourceSchema) error
-	GetSchemaByID(id string) (*models.DataSourceSchema, error)
+	GetSchemaByID(tenantID, id string) (*models.DataSourceSchema, error)
 	GetSchemasByTenant(tenantID string) ([]models.DataSourceSchema, error)
 	UpdateSchema(schema *models.DataSourceSchema) error
-	DeleteSchema(id string) error
+	DeleteSchema(tenantID, id string) error
 
 	// Schema field operations
 	AddFieldToSchema(field *models.SchemaField) error
@@ -70,9 +70,15 @@ func NewSchemaRepository() SchemaRepository {
 
 // CreateSchema creates a new data source schema
 func (r *PostgresSchemaRepository) CreateSchema(schema *models.DataSourceSchema) error {
+	tx, err := beginTenant(r.db, schema.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Check if schema with this name already exists for the tenant
 	var count int
-	err := r.db.QueryRow("SELECT COUNT(*) FROM data_source_schemas WHERE name = $1 AND tenant_id = $2",
+	err = tx.QueryRow("SELECT COUNT(*) FROM data_source_schemas WHERE name = $1 AND tenant_id = $2",
 		schema.Name, schema.TenantID).Scan(&count)
 	if err != nil {
 		return err
@@ -91,7 +97,7 @@ func (r *PostgresSchemaRepository) CreateSchema(schema *models.DataSourceSchema)
 	schema.CreatedAt = now
 	schema.UpdatedAt = now
 
-	err = r.db.QueryRow(
+	err = tx.QueryRow(
 		query,
 		schema.Name,
 		schema.Description,
@@ -105,6 +111,10 @@ func (r *PostgresSchemaRepository) CreateSchema(schema *models.DataSourceSchema)
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
 	// Create fields if any are provided
 	if len(schema.Fields) > 0 {
 		for i := range schema.Fields {
@@ -118,8 +128,14 @@ func (r *PostgresSchemaRepository) CreateSchema(schema *models.DataSourceSchema)
 	return nil
 }
 
-// GetSchemaByID retrieves a schema by ID with its fields
-func (r *PostgresSchemaRepository) GetSchemaByID(id string) (*models.DataSourceSchema, error) {
+// GetSchemaByID retrieves a schema of a tenant by ID with its fields
+func (r *PostgresSchemaRepository) GetSchemaByID(tenantID, id string) (*models.DataSourceSchema, error) {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	query := `
 		SELECT id, name, description, tenant_id, created_by, created_at, updated_at
 		FROM data_source_schemas
@@ -127,7 +143,7 @@ func (r *PostgresSchemaRepository) GetSchemaByID(id string) (*models.DataSourceS
 	`
 
 	var schema models.DataSourceSchema
-	err := r.db.QueryRow(query, id).Scan(
+	err = tx.QueryRow(query, id).Scan(
 		&schema.ID,
 		&schema.Name,
 		&schema.Description,
@@ -164,7 +180,13 @@ func (r *PostgresSchemaRepository) GetSchemasByTenant(tenantID string) ([]models
 		ORDER BY name
 	`
 
-	rows, err := r.db.Query(query, tenantID)
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(query, tenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -207,14 +229,20 @@ func (r *PostgresSchemaRepository) GetSchemasByTenant(tenantID string) ([]models
 // UpdateSchema updates a schema
 func (r *PostgresSchemaRepository) UpdateSchema(schema *models.DataSourceSchema) error {
 	// Check if schema exists
-	_, err := r.GetSchemaByID(schema.ID)
+	_, err := r.GetSchemaByID(schema.TenantID, schema.ID)
 	if err != nil {
 		return err
 	}
 
+	tx, err := beginTenant(r.db, schema.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Check if the new name conflicts with another schema
 	var count int
-	err = r.db.QueryRow(`
+	err = tx.QueryRow(`
 		SELECT COUNT(*) FROM data_source_schemas 
 		WHERE name = $1 AND tenant_id = $2 AND id != $3
 	`, schema.Name, schema.TenantID, schema.ID).Scan(&count)
@@ -233,7 +261,7 @@ func (r *PostgresSchemaRepository) UpdateSchema(schema *models.DataSourceSchema)
 	`
 	schema.UpdatedAt = time.Now()
 
-	_, err = r.db.Exec(
+	_, err = tx.Exec(
 		query,
 		schema.Name,
 		schema.Description,
@@ -245,24 +273,29 @@ func (r *PostgresSchemaRepository) UpdateSchema(schema *models.DataSourceSchema)
 		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 // DeleteSchema deletes a schema
-func (r *PostgresSchemaRepository) DeleteSchema(id string) error {
+func (r *PostgresSchemaRepository) DeleteSchema(tenantID, id string) error {
 	// Check if schema exists
-	_, err := r.GetSchemaByID(id)
+	if _, err := r.GetSchemaByID(tenantID, id); err != nil {
+		return err
+	}
+
+	tx, err := beginTenant(r.db, tenantID)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
 	// Delete the schema (fields will be cascaded)
-	_, err = r.db.Exec("DELETE FROM data_source_schemas WHERE id = $1", id)
+	_, err = tx.Exec("DELETE FROM data_source_schemas WHERE id = $1", id)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 // AddFieldToSchema adds a field to a schema
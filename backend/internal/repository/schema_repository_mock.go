@@ -46,9 +46,9 @@ func (r *MockSchemaRepository) CreateSchema(schema *models.DataSourceSchema) err
 }
 
 // GetSchemaByID retrieves a schema by ID from the mock repository
-func (r *MockSchemaRepository) GetSchemaByID(id string) (*models.DataSourceSchema, error) {
+func (r *MockSchemaRepository) GetSchemaByID(tenantID, id string) (*models.DataSourceSchema, error) {
 	schema, exists := r.schemas[id]
-	if !exists {
+	if !exists || schema.TenantID != tenantID {
 		return nil, ErrSchemaNotFound
 	}
 
@@ -87,8 +87,8 @@ func (r *MockSchemaRepository) GetSchemasByTenant(tenantID string) ([]models.Dat
 // UpdateSchema updates a schema in the mock repository
 func (r *MockSchemaRepository) UpdateSchema(schema *models.DataSourceSchema) error {
 	// Check if schema exists
-	_, exists := r.schemas[schema.ID]
-	if !exists {
+	existing, exists := r.schemas[schema.ID]
+	if !exists || existing.TenantID != schema.TenantID {
 		return ErrSchemaNotFound
 	}
 
@@ -114,10 +114,10 @@ func (r *MockSchemaRepository) UpdateSchema(schema *models.DataSourceSchema) err
 }
 
 // DeleteSchema deletes a schema from the mock repository
-func (r *MockSchemaRepository) DeleteSchema(id string) error {
+func (r *MockSchemaRepository) DeleteSchema(tenantID, id string) error {
 	// Check if schema exists
-	_, exists := r.schemas[id]
-	if !exists {
+	schema, exists := r.schemas[id]
+	if !exists || schema.TenantID != tenantID {
 		return ErrSchemaNotFound
 	}
 
@@ -0,0 +1,368 @@
+package repository
+
+import (
+	"backend/internal/models"
+	"backend/internal/testutil"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The tests below run against the mock repositories and, where a test database is available,
+// against the PostgreSQL ones. Each one stores a record in one tenant and checks that the other
+// tenant can neither see nor change it.
+
+// isolationTenants are the two tenants an isolation test runs in and a user working in both
+type isolationTenants struct {
+	own, other string
+	userID     string
+}
+
+// runIsolation runs an isolation test with the mock repositories, for which db is nil, and with
+// the PostgreSQL repositories over the test database
+func runIsolation(t *testing.T, test func(t *testing.T, db *sql.DB, tenants isolationTenants)) {
+	t.Run("Mock", func(t *testing.T) {
+		test(t, nil, isolationTenants{own: "tenant-1", other: "tenant-2", userID: "user-1"})
+	})
+
+	t.Run("Postgres", func(t *testing.T) {
+		db := testutil.SetupTestDB(t)
+		if _, err := db.Exec(`TRUNCATE tenants CASCADE`); err != nil {
+			t.Fatalf("Failed to clear tenants: %v", err)
+		}
+
+		user := &models.User{Email: "isolation@example.com", Name: "Isolation", PasswordHash: "hash", AuthProvider: "local"}
+		if err := (&PostgresUserRepository{db: db}).Create(user); err != nil {
+			t.Fatalf("Create() user error = %v", err)
+		}
+
+		tenants := isolationTenants{userID: user.ID}
+		for _, tenant := range []struct {
+			name string
+			id   *string
+		}{{"Tenant A", &tenants.own}, {"Tenant B", &tenants.other}} {
+			if err := db.QueryRow("INSERT INTO tenants (name) VALUES ($1) RETURNING id", tenant.name).Scan(tenant.id); err != nil {
+				t.Fatalf("Failed to create tenant: %v", err)
+			}
+		}
+
+		test(t, db, tenants)
+	})
+}
+
+// isolationDataSources returns the mock data source repository when db is nil and the PostgreSQL one otherwise
+func isolationDataSources(db *sql.DB) DataSourceRepository {
+	if db == nil {
+		return NewDataSourceRepository()
+	}
+	return &PostgresDataSourceRepository{db: db}
+}
+
+// isolationRules returns the mock rule repository when db is nil and the PostgreSQL one otherwise
+func isolationRules(db *sql.DB) RuleRepository {
+	if db == nil {
+		return NewRuleRepository()
+	}
+	return &PostgresRuleRepository{db: db}
+}
+
+// isolationTransactions returns the mock transaction repository when db is nil and the PostgreSQL one otherwise
+func isolationTransactions(db *sql.DB) TransactionRepository {
+	if db == nil {
+		return NewTransactionRepository()
+	}
+	return &PostgresTransactionRepository{db: db}
+}
+
+// isolationUploads returns the mock upload repository when db is nil and the PostgreSQL one otherwise
+func isolationUploads(db *sql.DB) UploadRepository {
+	if db == nil {
+		return NewUploadRepository()
+	}
+	return &PostgresUploadRepository{db: db}
+}
+
+// isolationMatchSets returns the mock match set repository when db is nil and the PostgreSQL one otherwise
+func isolationMatchSets(db *sql.DB) MatchSetRepository {
+	if db == nil {
+		return NewMatchSetRepository()
+	}
+	return &PostgresMatchSetRepository{db: db}
+}
+
+// isolationSchemas returns the mock schema repository when db is nil and the PostgreSQL one otherwise
+func isolationSchemas(db *sql.DB) SchemaRepository {
+	if db == nil {
+		return NewSchemaRepository()
+	}
+	return &PostgresSchemaRepository{db: db}
+}
+
+// createIsolationDataSources creates a data source in each of the tenants
+func createIsolationDataSources(t *testing.T, db *sql.DB, tenants isolationTenants) (own, other string) {
+	repo := isolationDataSources(db)
+	ids := make([]string, 2)
+	for i, tenantID := range []string{tenants.own, tenants.other} {
+		source := &models.DataSource{ID: uuid.New().String(), TenantID: tenantID, Name: "Bank"}
+		if err := repo.CreateDataSource(source); err != nil {
+			t.Fatalf("CreateDataSource() error = %v", err)
+		}
+		ids[i] = source.ID
+	}
+	return ids[0], ids[1]
+}
+
+func TestTenantIsolation_DataSources(t *testing.T) {
+	runIsolation(t, func(t *testing.T, db *sql.DB, tenants isolationTenants) {
+		repo := isolationDataSources(db)
+
+		own := &models.DataSource{ID: uuid.New().String(), TenantID: tenants.own, Name: "Bank"}
+		if err := repo.CreateDataSource(own); err != nil {
+			t.Fatalf("CreateDataSource() error = %v", err)
+		}
+
+		// Names are unique within a tenant only
+		if err := repo.CreateDataSource(&models.DataSource{ID: uuid.New().String(), TenantID: tenants.own, Name: "Bank"}); err != ErrDataSourceExists {
+			t.Errorf("CreateDataSource() with a name taken in the tenant error = %v, want %v", err, ErrDataSourceExists)
+		}
+		theirs := &models.DataSource{ID: uuid.New().String(), TenantID: tenants.other, Name: "Bank"}
+		if err := repo.CreateDataSource(theirs); err != nil {
+			t.Errorf("CreateDataSource() with a name taken in another tenant error = %v", err)
+		}
+
+		if _, err := repo.GetDataSourceByID(tenants.other, own.ID); err != ErrDataSourceNotFound {
+			t.Errorf("GetDataSourceByID() from another tenant error = %v, want %v", err, ErrDataSourceNotFound)
+		}
+		if source, err := repo.GetDataSourceByName(tenants.other, "Bank"); err != nil || source.ID != theirs.ID {
+			t.Errorf("GetDataSourceByName() = %v, %v, want the tenant's own data source", source, err)
+		}
+		if sources, _ := repo.GetAllDataSources(tenants.other); len(sources) != 1 || sources[0].ID != theirs.ID {
+			t.Errorf("GetAllDataSources() = %v, want only the tenant's own data source", sources)
+		}
+		if sources, total, _ := repo.SearchDataSources(tenants.other, "bank", 10, 0); total != 1 || sources[0].ID != theirs.ID {
+			t.Errorf("SearchDataSources() = %v, %d, want only the tenant's own data source", sources, total)
+		}
+
+		renamed := &models.DataSource{ID: own.ID, TenantID: tenants.other, Name: "Stolen"}
+		if err := repo.UpdateDataSource(renamed); err != ErrDataSourceNotFound {
+			t.Errorf("UpdateDataSource() from another tenant error = %v, want %v", err, ErrDataSourceNotFound)
+		}
+		if err := repo.DeleteDataSource(tenants.other, own.ID); err != ErrDataSourceNotFound {
+			t.Errorf("DeleteDataSource() from another tenant error = %v, want %v", err, ErrDataSourceNotFound)
+		}
+
+		if source, err := repo.GetDataSourceByID(tenants.own, own.ID); err != nil || source.Name != "Bank" {
+			t.Errorf("GetDataSourceByID() = %v, %v, want the data source unchanged", source, err)
+		}
+	})
+}
+
+func TestTenantIsolation_Rules(t *testing.T) {
+	runIsolation(t, func(t *testing.T, db *sql.DB, tenants isolationTenants) {
+		repo := isolationRules(db)
+
+		own := &models.MatchRule{ID: uuid.New().String(), TenantID: tenants.own, Name: "Amount", Active: true, CreatedBy: tenants.userID}
+		if err := repo.CreateRule(own); err != nil {
+			t.Fatalf("CreateRule() error = %v", err)
+		}
+
+		// Names are unique within a tenant only
+		if err := repo.CreateRule(&models.MatchRule{ID: uuid.New().String(), TenantID: tenants.own, Name: "Amount", CreatedBy: tenants.userID}); err != ErrRuleExists {
+			t.Errorf("CreateRule() with a name taken in the tenant error = %v, want %v", err, ErrRuleExists)
+		}
+		theirs := &models.MatchRule{ID: uuid.New().String(), TenantID: tenants.other, Name: "Amount", Active: true, CreatedBy: tenants.userID}
+		if err := repo.CreateRule(theirs); err != nil {
+			t.Errorf("CreateRule() with a name taken in another tenant error = %v", err)
+		}
+
+		if _, err := repo.GetRuleByID(tenants.other, own.ID); err != ErrRuleNotFound {
+			t.Errorf("GetRuleByID() from another tenant error = %v, want %v", err, ErrRuleNotFound)
+		}
+		if rule, err := repo.GetRuleByName(tenants.other, "Amount"); err != nil || rule.ID != theirs.ID {
+			t.Errorf("GetRuleByName() = %v, %v, want the tenant's own rule", rule, err)
+		}
+		if rules, _ := repo.GetAllRules(tenants.other); len(rules) != 1 || rules[0].ID != theirs.ID {
+			t.Errorf("GetAllRules() = %v, want only the tenant's own rule", rules)
+		}
+		if rules, _ := repo.GetActiveRules(tenants.other); len(rules) != 1 || rules[0].ID != theirs.ID {
+			t.Errorf("GetActiveRules() = %v, want only the tenant's own rule", rules)
+		}
+
+		changed := &models.MatchRule{ID: own.ID, TenantID: tenants.other, Name: "Stolen", CreatedBy: tenants.userID}
+		if err := repo.UpdateRule(changed); err != ErrRuleNotFound {
+			t.Errorf("UpdateRule() from another tenant error = %v, want %v", err, ErrRuleNotFound)
+		}
+		if err := repo.DeleteRule(tenants.other, own.ID); err != ErrRuleNotFound {
+			t.Errorf("DeleteRule() from another tenant error = %v, want %v", err, ErrRuleNotFound)
+		}
+
+		if rule, err := repo.GetRuleByID(tenants.own, own.ID); err != nil || rule.Name != "Amount" {
+			t.Errorf("GetRuleByID() = %v, %v, want the rule unchanged", rule, err)
+		}
+	})
+}
+
+func TestTenantIsolation_Transactions(t *testing.T) {
+	runIsolation(t, func(t *testing.T, db *sql.DB, tenants isolationTenants) {
+		repo := isolationTransactions(db)
+		ownSource, otherSource := createIsolationDataSources(t, db, tenants)
+
+		date := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+		own := models.Transaction{ID: uuid.New().String(), TenantID: tenants.own, DataSourceID: ownSource, ExternalID: "ext-1", Reference: "INV-1", Currency: "USD", Status: "Unmatched", CreatedBy: tenants.userID, TransactionDate: date}
+		theirs := models.Transaction{ID: uuid.New().String(), TenantID: tenants.other, DataSourceID: otherSource, ExternalID: "ext-2", Reference: "INV-2", Currency: "USD", Status: "Unmatched", CreatedBy: tenants.userID, TransactionDate: date}
+		for _, transaction := range []*models.Transaction{&own, &theirs} {
+			if err := repo.CreateTransaction(transaction); err != nil {
+				t.Fatalf("CreateTransaction() error = %v", err)
+			}
+		}
+
+		if _, err := repo.GetTransactionByID(tenants.other, own.ID); err != ErrTransactionNotFound {
+			t.Errorf("GetTransactionByID() from another tenant error = %v, want %v", err, ErrTransactionNotFound)
+		}
+
+		lists := []struct {
+			name string
+			list func() ([]models.Transaction, error)
+		}{
+			{"GetTransactionsByDataSourceID", func() ([]models.Transaction, error) {
+				return repo.GetTransactionsByDataSourceID(tenants.other, ownSource)
+			}},
+			{"GetTransactionsByStatus", func() ([]models.Transaction, error) {
+				return repo.GetTransactionsByStatus(tenants.other, ownSource, "Unmatched")
+			}},
+			{"GetTransactionsByExternalIDs", func() ([]models.Transaction, error) {
+				return repo.GetTransactionsByExternalIDs(tenants.other, ownSource, []string{"ext-1"})
+			}},
+		}
+		for _, tt := range lists {
+			if transactions, err := tt.list(); err != nil || len(transactions) != 0 {
+				t.Errorf("%s() from another tenant = %v, %v, want none", tt.name, transactions, err)
+			}
+		}
+
+		// A user working in two tenants only sees the transactions of the one asked for
+		if transactions, _ := repo.GetTransactionsByUserID(tenants.other, tenants.userID); len(transactions) != 1 || transactions[0].ID != theirs.ID {
+			t.Errorf("GetTransactionsByUserID() = %v, want only the tenant's own transaction", transactions)
+		}
+		if transactions, _ := repo.GetRecentTransactions(tenants.other, 10); len(transactions) != 1 || transactions[0].ID != theirs.ID {
+			t.Errorf("GetRecentTransactions() = %v, want only the tenant's own transaction", transactions)
+		}
+		if page, _ := repo.SearchTransactions(models.TransactionFilter{TenantID: tenants.other, Limit: 10}); len(page.Transactions) != 1 || page.Transactions[0].ID != theirs.ID {
+			t.Errorf("SearchTransactions() = %v, want only the tenant's own transaction", page.Transactions)
+		}
+		if count, _ := repo.CountTransactions(models.TransactionFilter{TenantID: tenants.other}); count != 1 {
+			t.Errorf("CountTransactions() = %d, want 1", count)
+		}
+		search := models.TextSearch{TenantID: tenants.other, Terms: []models.TextTerm{{Words: []string{"inv"}, Prefix: true}}, Limit: 10}
+		if hits, total, _ := repo.SearchTransactionText(search); total != 1 || hits[0].Transaction.ID != theirs.ID {
+			t.Errorf("SearchTransactionText() = %v, %d, want only the tenant's own transaction", hits, total)
+		}
+
+		if err := repo.DeleteTransaction(tenants.other, own.ID); err != ErrTransactionNotFound {
+			t.Errorf("DeleteTransaction() from another tenant error = %v, want %v", err, ErrTransactionNotFound)
+		}
+		if err := repo.DeleteTransactionsByDataSourceID(tenants.other, ownSource); err != nil {
+			t.Errorf("DeleteTransactionsByDataSourceID() error = %v", err)
+		}
+
+		if _, err := repo.GetTransactionByID(tenants.own, own.ID); err != nil {
+			t.Errorf("GetTransactionByID() error = %v, want the transaction kept", err)
+		}
+	})
+}
+
+func TestTenantIsolation_Uploads(t *testing.T) {
+	runIsolation(t, func(t *testing.T, db *sql.DB, tenants isolationTenants) {
+		repo := isolationUploads(db)
+		ownSource, otherSource := createIsolationDataSources(t, db, tenants)
+
+		own := &models.TransactionUpload{ID: uuid.New().String(), TenantID: tenants.own, DataSourceID: ownSource, FileName: "own.csv", UploadedBy: tenants.userID, Status: "Completed"}
+		theirs := &models.TransactionUpload{ID: uuid.New().String(), TenantID: tenants.other, DataSourceID: otherSource, FileName: "theirs.csv", UploadedBy: tenants.userID, Status: "Completed"}
+		for _, upload := range []*models.TransactionUpload{own, theirs} {
+			if err := repo.CreateUpload(upload); err != nil {
+				t.Fatalf("CreateUpload() error = %v", err)
+			}
+		}
+
+		if _, err := repo.GetUploadByID(tenants.other, own.ID); err != ErrUploadNotFound {
+			t.Errorf("GetUploadByID() from another tenant error = %v, want %v", err, ErrUploadNotFound)
+		}
+		if err := repo.UpdateUploadStatus(tenants.other, own.ID, "Failed", 0, "overwritten"); err != ErrUploadNotFound {
+			t.Errorf("UpdateUploadStatus() from another tenant error = %v, want %v", err, ErrUploadNotFound)
+		}
+		if uploads, _ := repo.GetUploadsByUser(tenants.other, tenants.userID); len(uploads) != 1 || uploads[0].ID != theirs.ID {
+			t.Errorf("GetUploadsByUser() = %v, want only the tenant's own upload", uploads)
+		}
+		if uploads, _ := repo.GetRecentUploads(tenants.other, 10); len(uploads) != 1 || uploads[0].ID != theirs.ID {
+			t.Errorf("GetRecentUploads() = %v, want only the tenant's own upload", uploads)
+		}
+		if uploads, _ := repo.GetUploadsByDataSource(tenants.other, ownSource); len(uploads) != 0 {
+			t.Errorf("GetUploadsByDataSource() from another tenant = %v, want none", uploads)
+		}
+
+		if upload, err := repo.GetUploadByID(tenants.own, own.ID); err != nil || upload.Status != "Completed" {
+			t.Errorf("GetUploadByID() = %v, %v, want the upload unchanged", upload, err)
+		}
+	})
+}
+
+func TestTenantIsolation_MatchSets(t *testing.T) {
+	runIsolation(t, func(t *testing.T, db *sql.DB, tenants isolationTenants) {
+		repo := isolationMatchSets(db)
+		ownSource, _ := createIsolationDataSources(t, db, tenants)
+
+		rule := &models.MatchRule{ID: uuid.New().String(), TenantID: tenants.own, Name: "Amount", Active: true, CreatedBy: tenants.userID}
+		if err := isolationRules(db).CreateRule(rule); err != nil {
+			t.Fatalf("CreateRule() error = %v", err)
+		}
+		own := &models.MatchSet{ID: uuid.New().String(), TenantID: tenants.own, Name: "Bank", RuleID: rule.ID, CreatedBy: tenants.userID}
+		if err := repo.CreateMatchSet(own); err != nil {
+			t.Fatalf("CreateMatchSet() error = %v", err)
+		}
+		if err := repo.AddDataSourceToMatchSet(own.ID, ownSource); err != nil {
+			t.Fatalf("AddDataSourceToMatchSet() error = %v", err)
+		}
+
+		if _, err := repo.GetMatchSetByID(tenants.other, own.ID); err != ErrMatchSetNotFound {
+			t.Errorf("GetMatchSetByID() from another tenant error = %v, want %v", err, ErrMatchSetNotFound)
+		}
+		if sources, _ := repo.GetMatchSetDataSources(tenants.other, own.ID); len(sources) != 0 {
+			t.Errorf("GetMatchSetDataSources() from another tenant = %v, want none", sources)
+		}
+
+		if matchSet, err := repo.GetMatchSetByID(tenants.own, own.ID); err != nil || matchSet.Name != "Bank" {
+			t.Errorf("GetMatchSetByID() = %v, %v, want the tenant's own match set", matchSet, err)
+		}
+		if sources, err := repo.GetMatchSetDataSources(tenants.own, own.ID); err != nil || len(sources) != 1 || sources[0].ID != ownSource {
+			t.Errorf("GetMatchSetDataSources() = %v, %v, want the match set's data source", sources, err)
+		}
+	})
+}
+
+func TestTenantIsolation_Schemas(t *testing.T) {
+	runIsolation(t, func(t *testing.T, db *sql.DB, tenants isolationTenants) {
+		repo := isolationSchemas(db)
+
+		own := &models.DataSourceSchema{ID: uuid.New().String(), TenantID: tenants.own, Name: "Bank statement", CreatedBy: tenants.userID}
+		if err := repo.CreateSchema(own); err != nil {
+			t.Fatalf("CreateSchema() error = %v", err)
+		}
+
+		if _, err := repo.GetSchemaByID(tenants.other, own.ID); err != ErrSchemaNotFound {
+			t.Errorf("GetSchemaByID() from another tenant error = %v, want %v", err, ErrSchemaNotFound)
+		}
+		changed := &models.DataSourceSchema{ID: own.ID, TenantID: tenants.other, Name: "Stolen"}
+		if err := repo.UpdateSchema(changed); err != ErrSchemaNotFound {
+			t.Errorf("UpdateSchema() from another tenant error = %v, want %v", err, ErrSchemaNotFound)
+		}
+		if err := repo.DeleteSchema(tenants.other, own.ID); err != ErrSchemaNotFound {
+			t.Errorf("DeleteSchema() from another tenant error = %v, want %v", err, ErrSchemaNotFound)
+		}
+
+		if schema, err := repo.GetSchemaByID(tenants.own, own.ID); err != nil || schema.Name != "Bank statement" {
+			t.Errorf("GetSchemaByID() = %v, %v, want the schema unchanged", schema, err)
+		}
+	})
+}
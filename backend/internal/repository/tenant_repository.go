@@ -229,9 +229,15 @@ func (r *PostgresTenantRepository) GetAllTenants() ([]models.Tenant, error) {
 
 // AssignUserToTenant assigns a user to a tenant
 func (r *PostgresTenantRepository) AssignUserToTenant(userID, tenantID string) error {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Check if the association already exists
 	var exists bool
-	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM tenant_users WHERE tenant_id = $1 AND user_id = $2)", tenantID, userID).Scan(&exists)
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM tenant_users WHERE tenant_id = $1 AND user_id = $2)", tenantID, userID).Scan(&exists)
 	if err != nil {
 		return err
 	}
@@ -245,8 +251,11 @@ func (r *PostgresTenantRepository) AssignUserToTenant(userID, tenantID string) e
 		INSERT INTO tenant_users (tenant_id, user_id)
 		VALUES ($1, $2)
 	`
-	_, err = r.db.Exec(query, tenantID, userID)
-	return err
+	if _, err := tx.Exec(query, tenantID, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // RemoveUserFromTenant removes a user from a tenant
@@ -255,7 +264,14 @@ func (r *PostgresTenantRepository) RemoveUserFromTenant(userID, tenantID string)
 		DELETE FROM tenant_users
 		WHERE tenant_id = $1 AND user_id = $2
 	`
-	result, err := r.db.Exec(query, tenantID, userID)
+
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, tenantID, userID)
 	if err != nil {
 		return err
 	}
@@ -269,10 +285,11 @@ func (r *PostgresTenantRepository) RemoveUserFromTenant(userID, tenantID string)
 		return errors.New("user not associated with this tenant")
 	}
 
-	return nil
+	return tx.Commit()
 }
 
-// GetUserTenants gets all tenants for a user
+// GetUserTenants gets all tenants for a user. It runs before a request has a tenant, so it looks
+// across tenants.
 func (r *PostgresTenantRepository) GetUserTenants(userID string) ([]models.Tenant, error) {
 	query := `
 		SELECT t.id, t.name, t.description, t.active, t.created_at, t.updated_at
@@ -282,7 +299,13 @@ func (r *PostgresTenantRepository) GetUserTenants(userID string) ([]models.Tenan
 		ORDER BY t.name
 	`
 
-	rows, err := r.db.Query(query, userID)
+	tx, err := beginAllTenants(r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -322,7 +345,13 @@ func (r *PostgresTenantRepository) GetTenantUsers(tenantID string) ([]string, er
 		WHERE tenant_id = $1
 	`
 
-	rows, err := r.db.Query(query, tenantID)
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(query, tenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"backend/internal/models"
+	"backend/internal/testutil"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+// The tests below run against PostgreSQL with the row-level security policies applied. The
+// queries they run in a tenant's session deliberately leave out the tenant_id filter, as a buggy
+// query would, and check that the policies still hide the other tenant's rows.
+
+// setupTenantsForRLS creates a user and two tenants with a data source and a transaction each,
+// returning the tenants' IDs and the IDs of their transactions
+func setupTenantsForRLS(t *testing.T) (*sql.DB, [2]string, [2]string) {
+	db := testutil.SetupTestDB(t)
+
+	// Policies never apply to superusers and roles that bypass them
+	var bypass bool
+	if err := db.QueryRow("SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user").Scan(&bypass); err != nil {
+		t.Fatalf("Failed to read database role: %v", err)
+	}
+	if bypass {
+		t.Skip("Test database role bypasses row-level security")
+	}
+
+	if _, err := db.Exec(`TRUNCATE tenants CASCADE`); err != nil {
+		t.Fatalf("Failed to clear tenants: %v", err)
+	}
+
+	user := &models.User{Email: "rls@example.com", Name: "RLS", PasswordHash: "hash", AuthProvider: "local"}
+	if err := (&PostgresUserRepository{db: db}).Create(user); err != nil {
+		t.Fatalf("Create() user error = %v", err)
+	}
+
+	dataSources := &PostgresDataSourceRepository{db: db}
+	transactions := &PostgresTransactionRepository{db: db}
+
+	var tenantIDs, transactionIDs [2]string
+	for i, name := range []string{"Tenant A", "Tenant B"} {
+		if err := db.QueryRow("INSERT INTO tenants (name) VALUES ($1) RETURNING id", name).Scan(&tenantIDs[i]); err != nil {
+			t.Fatalf("Failed to create tenant: %v", err)
+		}
+
+		source := &models.DataSource{TenantID: tenantIDs[i], Name: "Bank"}
+		if err := dataSources.CreateDataSource(source); err != nil {
+			t.Fatalf("CreateDataSource() error = %v", err)
+		}
+
+		transaction := &models.Transaction{
+			TenantID:        tenantIDs[i],
+			DataSourceID:    source.ID,
+			TransactionDate: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+			Amount:          100,
+			Currency:        "USD",
+			Reference:       "INV-1",
+			CreatedBy:       user.ID,
+		}
+		if err := transactions.CreateTransaction(transaction); err != nil {
+			t.Fatalf("CreateTransaction() error = %v", err)
+		}
+		transactionIDs[i] = transaction.ID
+	}
+
+	return db, tenantIDs, transactionIDs
+}
+
+func TestRowLevelSecurity_HidesOtherTenantsRows(t *testing.T) {
+	db, tenantIDs, transactionIDs := setupTenantsForRLS(t)
+
+	tx, err := beginTenant(db, tenantIDs[1])
+	if err != nil {
+		t.Fatalf("beginTenant() error = %v", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id FROM transactions")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if len(ids) != 1 || ids[0] != transactionIDs[1] {
+		t.Errorf("unscoped transactions query = %v, want only %s", ids, transactionIDs[1])
+	}
+
+	for _, table := range []string{"data_sources", "match_rules", "transaction_uploads"} {
+		var count int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE tenant_id = $1", tenantIDs[0]).Scan(&count); err != nil {
+			t.Fatalf("QueryRow() on %s error = %v", table, err)
+		}
+		if count != 0 {
+			t.Errorf("%s of another tenant visible = %d, want 0", table, count)
+		}
+	}
+
+	// Unscoped writes leave the other tenant's rows alone
+	if _, err := tx.Exec("UPDATE transactions SET description = 'changed'"); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	result, err := tx.Exec("DELETE FROM data_sources WHERE tenant_id = $1", tenantIDs[0])
+	if err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if deleted, _ := result.RowsAffected(); deleted != 0 {
+		t.Errorf("DELETE of another tenant's data sources affected %d rows, want 0", deleted)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	other, err := (&PostgresTransactionRepository{db: db}).GetTransactionByID(tenantIDs[0], transactionIDs[0])
+	if err != nil {
+		t.Fatalf("GetTransactionByID() error = %v", err)
+	}
+	if other.Description == "changed" {
+		t.Error("unscoped UPDATE changed another tenant's transaction")
+	}
+}
+
+func TestRowLevelSecurity_UnscopedSessionSeesNoTenant(t *testing.T) {
+	db, _, transactionIDs := setupTenantsForRLS(t)
+
+	// A session that sets no tenant is denied rather than let through
+	for _, table := range []string{"transactions", "data_sources"} {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+			t.Fatalf("QueryRow() on %s error = %v", table, err)
+		}
+		if count != 0 {
+			t.Errorf("%s visible without a tenant = %d, want 0", table, count)
+		}
+	}
+
+	// Work across tenants opts in explicitly
+	tx, err := beginAllTenants(db)
+	if err != nil {
+		t.Fatalf("beginAllTenants() error = %v", err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM transactions WHERE id = ANY($1::uuid[])", "{"+transactionIDs[0]+","+transactionIDs[1]+"}").Scan(&count); err != nil {
+		t.Fatalf("QueryRow() error = %v", err)
+	}
+	if count != 2 {
+		t.Errorf("transactions visible across tenants = %d, want 2", count)
+	}
+}
+
+func TestRowLevelSecurity_RejectsWritesIntoOtherTenants(t *testing.T) {
+	db, tenantIDs, _ := setupTenantsForRLS(t)
+
+	tx, err := beginTenant(db, tenantIDs[1])
+	if err != nil {
+		t.Fatalf("beginTenant() error = %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT INTO data_sources (tenant_id, name) VALUES ($1, 'Planted')", tenantIDs[0]); err == nil {
+		t.Error("INSERT into another tenant succeeded, want a row-level security violation")
+	}
+}
+
+func TestRowLevelSecurity_RepositoryScopesSession(t *testing.T) {
+	db, tenantIDs, transactionIDs := setupTenantsForRLS(t)
+	repo := &PostgresTransactionRepository{db: db}
+
+	if _, err := repo.GetTransactionByID(tenantIDs[1], transactionIDs[0]); err != ErrTransactionNotFound {
+		t.Errorf("GetTransactionByID() from another tenant error = %v, want %v", err, ErrTransactionNotFound)
+	}
+
+	// The setting ends with each transaction, so pooled connections start unscoped
+	var setting sql.NullString
+	if err := db.QueryRow("SELECT NULLIF(current_setting('app.tenant_id', true), '')").Scan(&setting); err != nil {
+		t.Fatalf("QueryRow() error = %v", err)
+	}
+	if setting.Valid {
+		t.Errorf("app.tenant_id outside a tenant session = %q, want unset", setting.String)
+	}
+}
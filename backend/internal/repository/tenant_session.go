@@ -0,0 +1,49 @@
+package repository
+
+import "database/sql"
+
+// querier is what *sql.DB and *sql.Tx have in common
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// beginTenant starts a transaction whose session is scoped to a tenant. The row-level security
+// policies on tenant-owned tables then hide the rows of every other tenant from its statements,
+// so a query missing its tenant_id filter still cannot read or change them.
+//
+// set_config with is_local set is SET LOCAL taking a bind parameter: the setting ends with the
+// transaction, and a pooled connection never carries it into another tenant's request.
+func beginTenant(db *sql.DB, tenantID string) (*sql.Tx, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("SELECT set_config('app.tenant_id', $1, true)", tenantID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// beginAllTenants starts a transaction whose session opts out of the row-level security policies
+// through app.all_tenants. It is for the paths that work across tenants by design: the scheduler
+// and queue picking up due runs of every tenant, tenant membership lookups made before a request
+// has a tenant, and writes of the shared rows that belong to no tenant. Requests made for a tenant
+// run in its session, and every other session sees no tenant's rows at all.
+func beginAllTenants(db *sql.DB) (*sql.Tx, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("SELECT set_config('app.all_tenants', 'on', true)"); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return tx, nil
+}
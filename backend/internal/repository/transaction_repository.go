@@ -4,26 +4,36 @@ import (
 	"backend/internal/db"
 	"backend/internal/models"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 var (
 	ErrTransactionNotFound = errors.New("transaction not found")
 )
 
-// TransactionRepository defines operations for managing transactions
+// TransactionRepository defines operations for managing transactions. Every method is scoped to
+// a tenant: transactions are created in the tenant they carry, and those of other tenants are
+// never read, changed or deleted.
 type TransactionRepository interface {
 	CreateTransaction(transaction *models.Transaction) error
 	CreateTransactions(transactions []models.Transaction) error
-	GetTransactionByID(id string) (*models.Transaction, error)
-	GetTransactionsByDataSourceID(dataSourceID string) ([]models.Transaction, error)
-	GetTransactionsByUserID(userID string) ([]models.Transaction, error)
-	GetRecentTransactions(limit int) ([]models.Transaction, error)
-	DeleteTransaction(id string) error
-	DeleteTransactionsByDataSourceID(dataSourceID string) error
+	GetTransactionByID(tenantID, id string) (*models.Transaction, error)
+	GetTransactionsByDataSourceID(tenantID, dataSourceID string) ([]models.Transaction, error)
+	GetTransactionsByStatus(tenantID, dataSourceID, status string) ([]models.Transaction, error)
+	GetTransactionsByExternalIDs(tenantID, dataSourceID string, externalIDs []string) ([]models.Transaction, error)
+	GetTransactionsByUserID(tenantID, userID string) ([]models.Transaction, error)
+	GetRecentTransactions(tenantID string, limit int) ([]models.Transaction, error)
+	SearchTransactions(filter models.TransactionFilter) (*models.TransactionPage, error)
+	CountTransactions(filter models.TransactionFilter) (int, error)
+	SearchTransactionText(search models.TextSearch) ([]models.TextSearchHit, int, error)
+	DeleteTransaction(tenantID, id string) error
+	DeleteTransactionsByDataSourceID(tenantID, dataSourceID string) error
 }
 
 // PostgresTransactionRepository implements TransactionRepository for PostgreSQL
@@ -31,6 +41,59 @@ type PostgresTransactionRepository struct {
 	db *sql.DB
 }
 
+// transactionColumns is the column list scanned by scanTransaction; queries alias transactions as t
+const transactionColumns = `
+	t.id, COALESCE(t.tenant_id::text, ''), t.data_source_id, t.transaction_date, t.post_date, 
+	t.description, t.amount, t.currency, t.reference,
+	t.status, t.match_id, COALESCE(t.external_id, ''), COALESCE(t.import_id::text, ''),
+	COALESCE(t.created_by::text, ''), t.custom_fields, t.created_at, t.updated_at
+`
+
+// scanTransaction scans a transaction selected with transactionColumns
+func scanTransaction(row rowScanner) (*models.Transaction, error) {
+	var transaction models.Transaction
+	var description, reference sql.NullString
+	var customFields []byte
+	err := row.Scan(
+		&transaction.ID,
+		&transaction.TenantID,
+		&transaction.DataSourceID,
+		&transaction.TransactionDate,
+		&transaction.PostDate,
+		&description,
+		&transaction.Amount,
+		&transaction.Currency,
+		&reference,
+		&transaction.Status,
+		&transaction.MatchID,
+		&transaction.ExternalID,
+		&transaction.ImportID,
+		&transaction.CreatedBy,
+		&customFields,
+		&transaction.CreatedAt,
+		&transaction.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(customFields, &transaction.CustomFields); err != nil {
+		return nil, err
+	}
+
+	transaction.Description = description.String
+	transaction.Reference = reference.String
+	return &transaction, nil
+}
+
+// customFieldsJSON encodes a transaction's custom field values for the custom_fields column
+func customFieldsJSON(transaction *models.Transaction) ([]byte, error) {
+	if transaction.CustomFields == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(transaction.CustomFields)
+}
+
 // NewTransactionRepository creates a new transaction repository
 func NewTransactionRepository() TransactionRepository {
 	if db.DB == nil {
@@ -46,17 +109,47 @@ func NewTransactionRepository() TransactionRepository {
 
 // CreateTransaction creates a new transaction in the database
 func (r *PostgresTransactionRepository) CreateTransaction(transaction *models.Transaction) error {
+	tx, err := beginTenant(r.db, transaction.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertTransaction(tx, transaction); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertTransaction writes a new transaction, filling in its ID and status when unset
+func insertTransaction(q querier, transaction *models.Transaction) error {
 	query := `
 		INSERT INTO transactions (
-			id, data_source_id, transaction_date, post_date, 
+			id, tenant_id, data_source_id, transaction_date, post_date, 
 			description, amount, currency, reference,
-			created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+			status, created_by, external_id, import_id,
+			custom_fields, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''), NULLIF($13, '')::uuid, $14, $15, $15)
 	`
 
-	_, err := r.db.Exec(
+	if transaction.ID == "" {
+		transaction.ID = uuid.New().String()
+	}
+
+	if transaction.Status == "" {
+		transaction.Status = "Unmatched"
+	}
+
+	customFields, err := customFieldsJSON(transaction)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(
 		query,
 		transaction.ID,
+		transaction.TenantID,
 		transaction.DataSourceID,
 		transaction.TransactionDate,
 		transaction.PostDate,
@@ -64,15 +157,24 @@ func (r *PostgresTransactionRepository) CreateTransaction(transaction *models.Tr
 		transaction.Amount,
 		transaction.Currency,
 		transaction.Reference,
+		transaction.Status,
+		transaction.CreatedBy,
+		transaction.ExternalID,
+		transaction.ImportID,
+		customFields,
 		time.Now(),
 	)
-
 	return err
 }
 
 // CreateTransactions creates multiple transactions in a batch
 func (r *PostgresTransactionRepository) CreateTransactions(transactions []models.Transaction) error {
-	tx, err := r.db.Begin()
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	// A batch belongs to a single tenant; the session's policies reject rows of any other
+	tx, err := beginTenant(r.db, transactions[0].TenantID)
 	if err != nil {
 		return err
 	}
@@ -80,10 +182,11 @@ func (r *PostgresTransactionRepository) CreateTransactions(transactions []models
 	// Prepare the statement for efficient batch insertion
 	stmt, err := tx.Prepare(`
 		INSERT INTO transactions (
-			id, data_source_id, transaction_date, post_date, 
+			id, tenant_id, data_source_id, transaction_date, post_date, 
 			description, amount, currency, reference,
-			created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+			status, created_by, external_id, import_id,
+			custom_fields, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''), NULLIF($13, '')::uuid, $14, $15, $15)
 	`)
 	if err != nil {
 		tx.Rollback()
@@ -92,9 +195,25 @@ func (r *PostgresTransactionRepository) CreateTransactions(transactions []models
 	defer stmt.Close()
 
 	now := time.Now()
-	for _, transaction := range transactions {
-		_, err := stmt.Exec(
+	for i := range transactions {
+		transaction := &transactions[i]
+		if transaction.ID == "" {
+			transaction.ID = uuid.New().String()
+		}
+
+		if transaction.Status == "" {
+			transaction.Status = "Unmatched"
+		}
+
+		customFields, err := customFieldsJSON(transaction)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		_, err = stmt.Exec(
 			transaction.ID,
+			transaction.TenantID,
 			transaction.DataSourceID,
 			transaction.TransactionDate,
 			transaction.PostDate,
@@ -102,6 +221,11 @@ func (r *PostgresTransactionRepository) CreateTransactions(transactions []models
 			transaction.Amount,
 			transaction.Currency,
 			transaction.Reference,
+			transaction.Status,
+			transaction.CreatedBy,
+			transaction.ExternalID,
+			transaction.ImportID,
+			customFields,
 			now,
 		)
 		if err != nil {
@@ -113,30 +237,17 @@ func (r *PostgresTransactionRepository) CreateTransactions(transactions []models
 	return tx.Commit()
 }
 
-// GetTransactionByID retrieves a transaction by ID
-func (r *PostgresTransactionRepository) GetTransactionByID(id string) (*models.Transaction, error) {
-	query := `
-		SELECT id, data_source_id, transaction_date, post_date, 
-			   description, amount, currency, reference,
-			   created_at, updated_at
-		FROM transactions
-		WHERE id = $1
-	`
+// GetTransactionByID retrieves a transaction of a tenant by ID
+func (r *PostgresTransactionRepository) GetTransactionByID(tenantID, id string) (*models.Transaction, error) {
+	query := "SELECT " + transactionColumns + " FROM transactions t WHERE t.tenant_id = $1 AND t.id = $2"
 
-	var transaction models.Transaction
-	err := r.db.QueryRow(query, id).Scan(
-		&transaction.ID,
-		&transaction.DataSourceID,
-		&transaction.TransactionDate,
-		&transaction.PostDate,
-		&transaction.Description,
-		&transaction.Amount,
-		&transaction.Currency,
-		&transaction.Reference,
-		&transaction.CreatedAt,
-		&transaction.UpdatedAt,
-	)
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
+	transaction, err := scanTransaction(tx.QueryRow(query, tenantID, id))
 	if err == sql.ErrNoRows {
 		return nil, ErrTransactionNotFound
 	}
@@ -145,116 +256,106 @@ func (r *PostgresTransactionRepository) GetTransactionByID(id string) (*models.T
 		return nil, err
 	}
 
-	return &transaction, nil
+	return transaction, nil
 }
 
-// GetTransactionsByDataSourceID retrieves transactions for a data source
-func (r *PostgresTransactionRepository) GetTransactionsByDataSourceID(dataSourceID string) ([]models.Transaction, error) {
+// GetTransactionsByDataSourceID retrieves the transactions of a tenant's data source
+func (r *PostgresTransactionRepository) GetTransactionsByDataSourceID(tenantID, dataSourceID string) ([]models.Transaction, error) {
 	query := `
-		SELECT id, data_source_id, transaction_date, post_date, 
-			   description, amount, currency, reference,
-			   created_at, updated_at
-		FROM transactions
-		WHERE data_source_id = $1
-		ORDER BY transaction_date DESC
+		SELECT ` + transactionColumns + `
+		FROM transactions t
+		WHERE t.tenant_id = $1 AND t.data_source_id = $2
+		ORDER BY t.transaction_date DESC
 	`
 
-	rows, err := r.db.Query(query, dataSourceID)
+	tx, err := beginTenant(r.db, tenantID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var transactions []models.Transaction
-	for rows.Next() {
-		var transaction models.Transaction
-		err := rows.Scan(
-			&transaction.ID,
-			&transaction.DataSourceID,
-			&transaction.TransactionDate,
-			&transaction.PostDate,
-			&transaction.Description,
-			&transaction.Amount,
-			&transaction.Currency,
-			&transaction.Reference,
-			&transaction.CreatedAt,
-			&transaction.UpdatedAt,
-		)
+	defer tx.Rollback()
 
-		if err != nil {
-			return nil, err
-		}
+	return queryTransactions(tx, query, tenantID, dataSourceID)
+}
 
-		transactions = append(transactions, transaction)
-	}
+// GetTransactionsByStatus retrieves the transactions of a tenant's data source with the given status
+func (r *PostgresTransactionRepository) GetTransactionsByStatus(tenantID, dataSourceID, status string) ([]models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions t
+		WHERE t.tenant_id = $1 AND t.data_source_id = $2 AND t.status = $3
+		ORDER BY t.transaction_date, t.id
+	`
 
-	if err := rows.Err(); err != nil {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	return transactions, nil
+	return queryTransactions(tx, query, tenantID, dataSourceID, status)
 }
 
-// GetTransactionsByUserID retrieves transactions uploaded by a user
-func (r *PostgresTransactionRepository) GetTransactionsByUserID(userID string) ([]models.Transaction, error) {
+// GetTransactionsByExternalIDs retrieves the transactions of a tenant's data source with any of the given external IDs
+func (r *PostgresTransactionRepository) GetTransactionsByExternalIDs(tenantID, dataSourceID string, externalIDs []string) ([]models.Transaction, error) {
+	if len(externalIDs) == 0 {
+		return nil, nil
+	}
+
 	query := `
-		SELECT t.id, t.data_source_id, t.transaction_date, t.post_date, 
-			   t.description, t.amount, t.currency, t.reference,
-			   t.created_at, t.updated_at
+		SELECT ` + transactionColumns + `
 		FROM transactions t
-		JOIN data_sources ds ON t.data_source_id = ds.id
-		WHERE ds.created_by = $1
-		ORDER BY t.created_at DESC
+		WHERE t.tenant_id = $1 AND t.data_source_id = $2 AND t.external_id = ANY($3)
 	`
 
-	rows, err := r.db.Query(query, userID)
+	tx, err := beginTenant(r.db, tenantID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var transactions []models.Transaction
-	for rows.Next() {
-		var transaction models.Transaction
-		err := rows.Scan(
-			&transaction.ID,
-			&transaction.DataSourceID,
-			&transaction.TransactionDate,
-			&transaction.PostDate,
-			&transaction.Description,
-			&transaction.Amount,
-			&transaction.Currency,
-			&transaction.Reference,
-			&transaction.CreatedAt,
-			&transaction.UpdatedAt,
-		)
+	defer tx.Rollback()
 
-		if err != nil {
-			return nil, err
-		}
+	return queryTransactions(tx, query, tenantID, dataSourceID, pq.Array(externalIDs))
+}
 
-		transactions = append(transactions, transaction)
-	}
+// GetTransactionsByUserID retrieves the transactions a user created in a tenant
+func (r *PostgresTransactionRepository) GetTransactionsByUserID(tenantID, userID string) ([]models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions t
+		WHERE t.tenant_id = $1 AND t.created_by = $2
+		ORDER BY t.created_at DESC
+	`
 
-	if err := rows.Err(); err != nil {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	return transactions, nil
+	return queryTransactions(tx, query, tenantID, userID)
 }
 
-// GetRecentTransactions retrieves recent transactions up to a limit
-func (r *PostgresTransactionRepository) GetRecentTransactions(limit int) ([]models.Transaction, error) {
+// GetRecentTransactions retrieves the recent transactions of a tenant up to a limit
+func (r *PostgresTransactionRepository) GetRecentTransactions(tenantID string, limit int) ([]models.Transaction, error) {
 	query := `
-		SELECT id, data_source_id, transaction_date, post_date, 
-			   description, amount, currency, reference,
-			   created_at, updated_at
-		FROM transactions
-		ORDER BY created_at DESC
-		LIMIT $1
+		SELECT ` + transactionColumns + `
+		FROM transactions t
+		WHERE t.tenant_id = $1
+		ORDER BY t.created_at DESC
+		LIMIT $2
 	`
 
-	rows, err := r.db.Query(query, limit)
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return queryTransactions(tx, query, tenantID, limit)
+}
+
+// queryTransactions runs a query selecting transactionColumns and scans every row
+func queryTransactions(q querier, query string, args ...interface{}) ([]models.Transaction, error) {
+	rows, err := q.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -262,25 +363,12 @@ func (r *PostgresTransactionRepository) GetRecentTransactions(limit int) ([]mode
 
 	var transactions []models.Transaction
 	for rows.Next() {
-		var transaction models.Transaction
-		err := rows.Scan(
-			&transaction.ID,
-			&transaction.DataSourceID,
-			&transaction.TransactionDate,
-			&transaction.PostDate,
-			&transaction.Description,
-			&transaction.Amount,
-			&transaction.Currency,
-			&transaction.Reference,
-			&transaction.CreatedAt,
-			&transaction.UpdatedAt,
-		)
-
+		transaction, err := scanTransaction(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		transactions = append(transactions, transaction)
+		transactions = append(transactions, *transaction)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -290,10 +378,16 @@ func (r *PostgresTransactionRepository) GetRecentTransactions(limit int) ([]mode
 	return transactions, nil
 }
 
-// DeleteTransaction deletes a transaction
-func (r *PostgresTransactionRepository) DeleteTransaction(id string) error {
-	query := "DELETE FROM transactions WHERE id = $1"
-	result, err := r.db.Exec(query, id)
+// DeleteTransaction deletes a transaction of a tenant
+func (r *PostgresTransactionRepository) DeleteTransaction(tenantID, id string) error {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "DELETE FROM transactions WHERE tenant_id = $1 AND id = $2"
+	result, err := tx.Exec(query, tenantID, id)
 	if err != nil {
 		return err
 	}
@@ -307,14 +401,23 @@ func (r *PostgresTransactionRepository) DeleteTransaction(id string) error {
 		return ErrTransactionNotFound
 	}
 
-	return nil
+	return tx.Commit()
 }
 
-// DeleteTransactionsByDataSourceID deletes all transactions for a data source
-func (r *PostgresTransactionRepository) DeleteTransactionsByDataSourceID(dataSourceID string) error {
-	query := "DELETE FROM transactions WHERE data_source_id = $1"
-	_, err := r.db.Exec(query, dataSourceID)
-	return err
+// DeleteTransactionsByDataSourceID deletes all transactions of a tenant's data source
+func (r *PostgresTransactionRepository) DeleteTransactionsByDataSourceID(tenantID, dataSourceID string) error {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "DELETE FROM transactions WHERE tenant_id = $1 AND data_source_id = $2"
+	if _, err := tx.Exec(query, tenantID, dataSourceID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // MockTransactionRepository implements TransactionRepository for testing/development
@@ -352,62 +455,102 @@ func (r *MockTransactionRepository) CreateTransactions(transactions []models.Tra
 	return nil
 }
 
-// GetTransactionByID retrieves a transaction by ID from the mock repository
-func (r *MockTransactionRepository) GetTransactionByID(id string) (*models.Transaction, error) {
+// GetTransactionByID retrieves a transaction of a tenant by ID from the mock repository
+func (r *MockTransactionRepository) GetTransactionByID(tenantID, id string) (*models.Transaction, error) {
 	transaction, exists := r.transactions[id]
-	if !exists {
+	if !exists || transaction.TenantID != tenantID {
 		return nil, ErrTransactionNotFound
 	}
 	return transaction, nil
 }
 
-// GetTransactionsByDataSourceID retrieves transactions for a data source from the mock repository
-func (r *MockTransactionRepository) GetTransactionsByDataSourceID(dataSourceID string) ([]models.Transaction, error) {
+// GetTransactionsByDataSourceID retrieves the transactions of a tenant's data source from the mock repository
+func (r *MockTransactionRepository) GetTransactionsByDataSourceID(tenantID, dataSourceID string) ([]models.Transaction, error) {
 	var transactions []models.Transaction
 	for _, transaction := range r.transactions {
-		if transaction.DataSourceID == dataSourceID {
+		if transaction.TenantID == tenantID && transaction.DataSourceID == dataSourceID {
 			transactions = append(transactions, *transaction)
 		}
 	}
 	return transactions, nil
 }
 
-// GetTransactionsByUserID retrieves transactions uploaded by a user from the mock repository
-func (r *MockTransactionRepository) GetTransactionsByUserID(userID string) ([]models.Transaction, error) {
-	// In a real implementation, this would join with data_sources to filter by created_by
-	// For mock, we'll simply return all transactions
+// GetTransactionsByStatus retrieves the transactions of a tenant's data source by status from the mock repository
+func (r *MockTransactionRepository) GetTransactionsByStatus(tenantID, dataSourceID, status string) ([]models.Transaction, error) {
 	var transactions []models.Transaction
 	for _, transaction := range r.transactions {
-		transactions = append(transactions, *transaction)
+		if transaction.TenantID == tenantID && transaction.DataSourceID == dataSourceID && transaction.Status == status {
+			transactions = append(transactions, *transaction)
+		}
 	}
 	return transactions, nil
 }
 
-// GetRecentTransactions retrieves recent transactions from the mock repository
-func (r *MockTransactionRepository) GetRecentTransactions(limit int) ([]models.Transaction, error) {
+// GetTransactionsByExternalIDs retrieves the transactions of a tenant's data source by external ID from the mock repository
+func (r *MockTransactionRepository) GetTransactionsByExternalIDs(tenantID, dataSourceID string, externalIDs []string) ([]models.Transaction, error) {
+	wanted := make(map[string]bool, len(externalIDs))
+	for _, externalID := range externalIDs {
+		wanted[externalID] = true
+	}
+
 	var transactions []models.Transaction
 	for _, transaction := range r.transactions {
-		transactions = append(transactions, *transaction)
-		if len(transactions) >= limit {
-			break
+		if transaction.TenantID == tenantID && transaction.DataSourceID == dataSourceID && transaction.ExternalID != "" && wanted[transaction.ExternalID] {
+			transactions = append(transactions, *transaction)
 		}
 	}
 	return transactions, nil
 }
 
-// DeleteTransaction deletes a transaction from the mock repository
-func (r *MockTransactionRepository) DeleteTransaction(id string) error {
-	if _, exists := r.transactions[id]; !exists {
+// GetTransactionsByUserID retrieves the transactions a user created in a tenant from the mock repository
+func (r *MockTransactionRepository) GetTransactionsByUserID(tenantID, userID string) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	for _, transaction := range r.transactions {
+		if transaction.TenantID == tenantID && transaction.CreatedBy == userID {
+			transactions = append(transactions, *transaction)
+		}
+	}
+	return transactions, nil
+}
+
+// GetRecentTransactions retrieves the recent transactions of a tenant from the mock repository
+func (r *MockTransactionRepository) GetRecentTransactions(tenantID string, limit int) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	for _, transaction := range r.transactions {
+		if transaction.TenantID == tenantID {
+			transactions = append(transactions, *transaction)
+		}
+	}
+
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].CreatedAt.After(transactions[j].CreatedAt)
+	})
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+	}
+	return transactions, nil
+}
+
+// DeleteTransaction deletes a transaction of a tenant from the mock repository
+func (r *MockTransactionRepository) DeleteTransaction(tenantID, id string) error {
+	if transaction, exists := r.transactions[id]; !exists || transaction.TenantID != tenantID {
 		return ErrTransactionNotFound
 	}
 	delete(r.transactions, id)
 	return nil
 }
 
-// DeleteTransactionsByDataSourceID deletes all transactions for a data source from the mock repository
-func (r *MockTransactionRepository) DeleteTransactionsByDataSourceID(dataSourceID string) error {
+// setStatus changes the status of a tenant's transaction in the mock repository
+func (r *MockTransactionRepository) setStatus(tenantID, id, status string) {
+	if transaction, exists := r.transactions[id]; exists && transaction.TenantID == tenantID {
+		transaction.Status = status
+	}
+}
+
+// DeleteTransactionsByDataSourceID deletes all transactions of a tenant's data source from the mock repository
+func (r *MockTransactionRepository) DeleteTransactionsByDataSourceID(tenantID, dataSourceID string) error {
 	for id, transaction := range r.transactions {
-		if transaction.DataSourceID == dataSourceID {
+		if transaction.TenantID == tenantID && transaction.DataSourceID == dataSourceID {
 			delete(r.transactions, id)
 		}
 	}
@@ -0,0 +1,401 @@
+package repository
+
+import (
+	"backend/internal/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor: it does not belong to this search")
+
+// transactionSortColumns maps the fields transactions can be sorted by to their SQL expressions.
+// Nullable columns sort as empty strings so that every key compares.
+var transactionSortColumns = map[string]string{
+	models.TransactionSortDate:        "t.transaction_date",
+	models.TransactionSortPostDate:    "t.post_date",
+	models.TransactionSortAmount:      "t.amount",
+	models.TransactionSortReference:   "COALESCE(t.reference, '')",
+	models.TransactionSortDescription: "COALESCE(t.description, '')",
+	models.TransactionSortStatus:      "t.status",
+	models.TransactionSortCreatedAt:   "t.created_at",
+}
+
+// defaultTransactionSort orders a search with no sort newest first
+var defaultTransactionSort = []models.TransactionSort{{Field: models.TransactionSortDate, Desc: true}}
+
+// transactionCursor is the position after the last transaction of a page: its sort key values
+// and ID. It records the sort it was issued for, so it cannot page a differently sorted search.
+type transactionCursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+	ID     string   `json:"id"`
+}
+
+// searchSort returns the sort of a search, the default when it has none
+func searchSort(filter models.TransactionFilter) ([]models.TransactionSort, error) {
+	if len(filter.Sort) == 0 {
+		return defaultTransactionSort, nil
+	}
+	for _, key := range filter.Sort {
+		if _, ok := transactionSortColumns[key.Field]; !ok {
+			return nil, errors.New("invalid sort: unknown field " + key.Field)
+		}
+	}
+	return filter.Sort, nil
+}
+
+// sortSignature identifies a sort, such as "transaction_date:desc,amount:asc"
+func sortSignature(sorts []models.TransactionSort) string {
+	keys := make([]string, len(sorts))
+	for i, key := range sorts {
+		direction := "asc"
+		if key.Desc {
+			direction = "desc"
+		}
+		keys[i] = key.Field + ":" + direction
+	}
+	return strings.Join(keys, ",")
+}
+
+// transactionSortValue returns the value a transaction sorts by for a field
+func transactionSortValue(transaction *models.Transaction, field string) interface{} {
+	switch field {
+	case models.TransactionSortDate:
+		return transaction.TransactionDate
+	case models.TransactionSortPostDate:
+		return transaction.PostDate
+	case models.TransactionSortAmount:
+		return transaction.Amount
+	case models.TransactionSortReference:
+		return transaction.Reference
+	case models.TransactionSortDescription:
+		return transaction.Description
+	case models.TransactionSortStatus:
+		return transaction.Status
+	case models.TransactionSortCreatedAt:
+		return transaction.CreatedAt
+	}
+	return nil
+}
+
+// encodeTransactionCursor returns the cursor positioned after a transaction
+func encodeTransactionCursor(sorts []models.TransactionSort, last *models.Transaction) string {
+	cursor := transactionCursor{Sort: sortSignature(sorts), ID: last.ID}
+	for _, key := range sorts {
+		switch value := transactionSortValue(last, key.Field).(type) {
+		case time.Time:
+			cursor.Values = append(cursor.Values, value.UTC().Format(time.RFC3339Nano))
+		case float64:
+			cursor.Values = append(cursor.Values, strconv.FormatFloat(value, 'f', -1, 64))
+		case string:
+			cursor.Values = append(cursor.Values, value)
+		}
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeTransactionCursor returns the sort key values and ID of a cursor issued for sorts
+func decodeTransactionCursor(encoded string, sorts []models.TransactionSort) ([]interface{}, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, "", ErrInvalidCursor
+	}
+
+	var cursor transactionCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, "", ErrInvalidCursor
+	}
+	if cursor.Sort != sortSignature(sorts) || len(cursor.Values) != len(sorts) {
+		return nil, "", ErrInvalidCursor
+	}
+
+	values := make([]interface{}, len(sorts))
+	for i, key := range sorts {
+		switch transactionSortValue(&models.Transaction{}, key.Field).(type) {
+		case time.Time:
+			values[i], err = time.Parse(time.RFC3339Nano, cursor.Values[i])
+		case float64:
+			values[i], err = strconv.ParseFloat(cursor.Values[i], 64)
+		default:
+			values[i] = cursor.Values[i]
+		}
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+	}
+	return values, cursor.ID, nil
+}
+
+// transactionPage trims the extra row a search fetched past its limit into the next cursor
+func transactionPage(transactions []models.Transaction, sorts []models.TransactionSort, limit int) *models.TransactionPage {
+	page := &models.TransactionPage{Transactions: transactions, Limit: limit}
+	if page.Transactions == nil {
+		page.Transactions = []models.Transaction{}
+	}
+	if len(transactions) > limit {
+		page.Transactions = transactions[:limit]
+		page.NextCursor = encodeTransactionCursor(sorts, &page.Transactions[limit-1])
+	}
+	return page
+}
+
+// transactionSearchQuery returns the query builder selecting the page of transactions a search
+// matches after its cursor
+func transactionSearchQuery(filter models.TransactionFilter, sorts []models.TransactionSort) (*queryBuilder, error) {
+	b := newQueryBuilder()
+	b.where("t.tenant_id = ?", filter.TenantID)
+
+	if filter.DateFrom != nil {
+		b.where("t.transaction_date >= ?", filter.DateFrom.Format("2006-01-02"))
+	}
+	if filter.DateTo != nil {
+		b.where("t.transaction_date <= ?", filter.DateTo.Format("2006-01-02"))
+	}
+	if filter.AmountMin != nil {
+		b.where("t.amount >= ?", *filter.AmountMin)
+	}
+	if filter.AmountMax != nil {
+		b.where("t.amount <= ?", *filter.AmountMax)
+	}
+	if len(filter.Statuses) > 0 {
+		b.where("t.status = ANY(?)", pq.Array(filter.Statuses))
+	}
+	if len(filter.DataSourceIDs) > 0 {
+		b.where("t.data_source_id = ANY(?::uuid[])", pq.Array(filter.DataSourceIDs))
+	}
+	b.contains(filter.Text, "t.reference", "t.description")
+	if len(filter.CustomFields) > 0 {
+		customFields, err := json.Marshal(filter.CustomFields)
+		if err != nil {
+			return nil, err
+		}
+		b.where("t.custom_fields @> ?::jsonb", string(customFields))
+	}
+
+	// The ID breaks ties in the direction of the first key
+	columns := make([]string, 0, len(sorts)+1)
+	descending := make([]bool, 0, len(sorts)+1)
+	for _, key := range sorts {
+		if err := b.sortBy(transactionSortColumns, key.Field, key.Desc); err != nil {
+			return nil, err
+		}
+		columns = append(columns, transactionSortColumns[key.Field])
+		descending = append(descending, key.Desc)
+	}
+	b.orderByColumn("t.id", sorts[0].Desc)
+	columns = append(columns, "t.id")
+	descending = append(descending, sorts[0].Desc)
+
+	if filter.Cursor != "" {
+		values, id, err := decodeTransactionCursor(filter.Cursor, sorts)
+		if err != nil {
+			return nil, err
+		}
+		b.seekAfter(columns, descending, append(values, id))
+	}
+
+	// Fetch one row past the limit to know whether another page follows
+	b.paginate(filter.Limit+1, 0)
+	return b, nil
+}
+
+// SearchTransactions retrieves a page of the transactions of a tenant matching a filter
+func (r *PostgresTransactionRepository) SearchTransactions(filter models.TransactionFilter) (*models.TransactionPage, error) {
+	sorts, err := searchSort(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := transactionSearchQuery(filter, sorts)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := beginTenant(r.db, filter.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query, args := b.build("SELECT " + transactionColumns + " FROM transactions t")
+	transactions, err := queryTransactions(tx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return transactionPage(transactions, sorts, filter.Limit), nil
+}
+
+// CountTransactions counts the transactions of a tenant matching a filter, ignoring its cursor
+// and limit
+func (r *PostgresTransactionRepository) CountTransactions(filter models.TransactionFilter) (int, error) {
+	filter.Cursor = ""
+	b, err := transactionSearchQuery(filter, defaultTransactionSort)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := beginTenant(r.db, filter.TenantID)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query, args := b.count("FROM transactions t")
+	var total int
+	if err := tx.QueryRow(query, args...).Scan(&total); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// SearchTransactions retrieves a page of the transactions of a tenant matching a filter from the
+// mock repository
+func (r *MockTransactionRepository) SearchTransactions(filter models.TransactionFilter) (*models.TransactionPage, error) {
+	sorts, err := searchSort(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var after []interface{}
+	var afterID string
+	if filter.Cursor != "" {
+		after, afterID, err = decodeTransactionCursor(filter.Cursor, sorts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// compare orders two positions, each given by its sort key values and ID
+	compare := func(a []interface{}, aID string, b []interface{}, bID string) int {
+		for i, key := range sorts {
+			if c := compareSortValues(a[i], b[i]); c != 0 {
+				if key.Desc {
+					return -c
+				}
+				return c
+			}
+		}
+		c := strings.Compare(aID, bID)
+		if sorts[0].Desc {
+			return -c
+		}
+		return c
+	}
+	position := func(transaction *models.Transaction) []interface{} {
+		values := make([]interface{}, len(sorts))
+		for i, key := range sorts {
+			values[i] = transactionSortValue(transaction, key.Field)
+		}
+		return values
+	}
+
+	var transactions []models.Transaction
+	for _, transaction := range r.transactions {
+		if !mockTransactionMatches(transaction, filter) {
+			continue
+		}
+		if after != nil && compare(position(transaction), transaction.ID, after, afterID) <= 0 {
+			continue
+		}
+		transactions = append(transactions, *transaction)
+	}
+
+	sort.Slice(transactions, func(i, j int) bool {
+		return compare(position(&transactions[i]), transactions[i].ID, position(&transactions[j]), transactions[j].ID) < 0
+	})
+	if len(transactions) > filter.Limit+1 {
+		transactions = transactions[:filter.Limit+1]
+	}
+
+	return transactionPage(transactions, sorts, filter.Limit), nil
+}
+
+// CountTransactions counts the transactions of a tenant matching a filter in the mock repository, ignoring
+// its cursor and limit
+func (r *MockTransactionRepository) CountTransactions(filter models.TransactionFilter) (int, error) {
+	total := 0
+	for _, transaction := range r.transactions {
+		if mockTransactionMatches(transaction, filter) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+// mockTransactionMatches reports whether a transaction matches the conditions of a filter
+func mockTransactionMatches(transaction *models.Transaction, filter models.TransactionFilter) bool {
+	if transaction.TenantID != filter.TenantID {
+		return false
+	}
+	date := transaction.TransactionDate.Format("2006-01-02")
+	if filter.DateFrom != nil && date < filter.DateFrom.Format("2006-01-02") {
+		return false
+	}
+	if filter.DateTo != nil && date > filter.DateTo.Format("2006-01-02") {
+		return false
+	}
+	if filter.AmountMin != nil && transaction.Amount < *filter.AmountMin {
+		return false
+	}
+	if filter.AmountMax != nil && transaction.Amount > *filter.AmountMax {
+		return false
+	}
+	if len(filter.Statuses) > 0 && !containsString(filter.Statuses, transaction.Status) {
+		return false
+	}
+	if len(filter.DataSourceIDs) > 0 && !containsString(filter.DataSourceIDs, transaction.DataSourceID) {
+		return false
+	}
+	if filter.Text != "" {
+		text := strings.ToLower(filter.Text)
+		if !strings.Contains(strings.ToLower(transaction.Reference), text) && !strings.Contains(strings.ToLower(transaction.Description), text) {
+			return false
+		}
+	}
+	for name, value := range filter.CustomFields {
+		if actual, ok := transaction.CustomFields[name]; !ok || actual != value {
+			return false
+		}
+	}
+	return true
+}
+
+// compareSortValues compares two sort key values of the same field
+func compareSortValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case float64:
+		b := b.(float64)
+		if a < b {
+			return -1
+		}
+		if a > b {
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	}
+	return 0
+}
+
+// containsString reports whether a slice holds a value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
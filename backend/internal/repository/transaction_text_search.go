@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"backend/internal/models"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/lib/pq"
+)
+
+// fuzzyWordSimilarity is the share of a word's trigrams another word must hold to match it in a
+// fuzzy search. It is pg_trgm's default word similarity threshold, which the %> operator uses.
+const fuzzyWordSimilarity = 0.6
+
+// textTermQuery returns a term as tsquery text: its words in order, the last one a prefix when
+// the term is. Words are letters and digits only, so quoting them is enough.
+func textTermQuery(term models.TextTerm) string {
+	lexemes := make([]string, len(term.Words))
+	for i, word := range term.Words {
+		lexemes[i] = "'" + word + "'"
+	}
+	if term.Prefix {
+		lexemes[len(lexemes)-1] += ":*"
+	}
+	return strings.Join(lexemes, " <-> ")
+}
+
+// textSearchQuery returns the query builder selecting the transactions a full-text search
+// matches
+func textSearchQuery(search models.TextSearch) *queryBuilder {
+	b := newQueryBuilder()
+	b.where("t.tenant_id = ?", search.TenantID)
+
+	if len(search.Statuses) > 0 {
+		b.where("t.status = ANY(?)", pq.Array(search.Statuses))
+	}
+	if len(search.DataSourceIDs) > 0 {
+		b.where("t.data_source_id = ANY(?::uuid[])", pq.Array(search.DataSourceIDs))
+	}
+
+	for _, term := range search.Terms {
+		if search.Fuzzy && len(term.Words) == 1 {
+			// The trigram indexes find the words spelled alike
+			word := term.Words[0]
+			b.where("(t.search_vector @@ to_tsquery('simple', ?) OR t.description %> ? OR t.reference %> ?)", textTermQuery(term), word, word)
+			continue
+		}
+		b.where("t.search_vector @@ to_tsquery('simple', ?)", textTermQuery(term))
+	}
+	return b
+}
+
+// rankedRowScanner scans a row of transactionColumns followed by a rank
+type rankedRowScanner struct {
+	row  rowScanner
+	rank *float64
+}
+
+func (s rankedRowScanner) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.rank)...)
+}
+
+// SearchTransactionText retrieves a page of the transactions of a tenant a full-text search
+// matches, best ranked first, and how many it matches in all
+func (r *PostgresTransactionRepository) SearchTransactionText(search models.TextSearch) ([]models.TextSearchHit, int, error) {
+	tx, err := beginTenant(r.db, search.TenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	b := textSearchQuery(search)
+
+	countQuery, countArgs := b.count("FROM transactions t")
+	var total int
+	if err := tx.QueryRow(countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	if total <= search.Offset {
+		return []models.TextSearchHit{}, total, nil
+	}
+
+	// Every term must match, so the rank is of their conjunction
+	termQueries := make([]string, len(search.Terms))
+	for i, term := range search.Terms {
+		termQueries[i] = "(" + textTermQuery(term) + ")"
+	}
+	rank := "ts_rank(t.search_vector, to_tsquery('simple', " + b.arg(strings.Join(termQueries, " & ")) + "))"
+	b.orderByColumn(rank, true)
+	b.orderByColumn("t.transaction_date", true)
+	b.orderByColumn("t.id", true)
+	b.paginate(search.Limit, search.Offset)
+
+	query, args := b.build("SELECT " + transactionColumns + ", " + rank + " FROM transactions t")
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	hits := []models.TextSearchHit{}
+	for rows.Next() {
+		var rank float64
+		transaction, err := scanTransaction(rankedRowScanner{row: rows, rank: &rank})
+		if err != nil {
+			return nil, 0, err
+		}
+
+		highlights, _ := textHighlights(transaction, search)
+		hits = append(hits, models.TextSearchHit{Transaction: *transaction, Rank: rank, Highlights: highlights})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return hits, total, nil
+}
+
+// SearchTransactionText retrieves a page of the transactions of a tenant a full-text search
+// matches from the mock repository. They rank by the number of matching words.
+func (r *MockTransactionRepository) SearchTransactionText(search models.TextSearch) ([]models.TextSearchHit, int, error) {
+	hits := []models.TextSearchHit{}
+	for _, transaction := range r.transactions {
+		if transaction.TenantID != search.TenantID {
+			continue
+		}
+		if len(search.Statuses) > 0 && !containsString(search.Statuses, transaction.Status) {
+			continue
+		}
+		if len(search.DataSourceIDs) > 0 && !containsString(search.DataSourceIDs, transaction.DataSourceID) {
+			continue
+		}
+
+		highlights, matches := textHighlights(transaction, search)
+		if !matches {
+			continue
+		}
+		hits = append(hits, models.TextSearchHit{Transaction: *transaction, Rank: float64(len(highlights)), Highlights: highlights})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := &hits[i], &hits[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if !a.Transaction.TransactionDate.Equal(b.Transaction.TransactionDate) {
+			return a.Transaction.TransactionDate.After(b.Transaction.TransactionDate)
+		}
+		return a.Transaction.ID > b.Transaction.ID
+	})
+
+	total := len(hits)
+	hits = hits[min(search.Offset, total):]
+	if len(hits) > search.Limit {
+		hits = hits[:search.Limit]
+	}
+	return hits, total, nil
+}
+
+// textWord is a word of a text field, with its position in characters
+type textWord struct {
+	text       string // Lower case
+	start, end int
+}
+
+// textWords splits a text into its words, the runs of letters and digits
+func textWords(text string) []textWord {
+	var words []textWord
+	var word strings.Builder
+	start, position := 0, 0
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if word.Len() == 0 {
+				start = position
+			}
+			word.WriteRune(unicode.ToLower(r))
+		} else if word.Len() > 0 {
+			words = append(words, textWord{text: word.String(), start: start, end: position})
+			word.Reset()
+		}
+		position++
+	}
+	if word.Len() > 0 {
+		words = append(words, textWord{text: word.String(), start: start, end: position})
+	}
+	return words
+}
+
+// textHighlights returns the words of a transaction's reference and description that match the
+// terms of a search, and whether every term matches one of the fields
+func textHighlights(transaction *models.Transaction, search models.TextSearch) ([]models.TextHighlight, bool) {
+	fields := []struct {
+		name  string
+		words []textWord
+	}{
+		{models.TextFieldReference, textWords(transaction.Reference)},
+		{models.TextFieldDescription, textWords(transaction.Description)},
+	}
+
+	marked := make([][]bool, len(fields))
+	for f := range fields {
+		marked[f] = make([]bool, len(fields[f].words))
+	}
+
+	matches := true
+	for _, term := range search.Terms {
+		found := false
+		for f, field := range fields {
+			found = markTerm(term, field.words, marked[f], search.Fuzzy) || found
+		}
+		matches = matches && found
+	}
+
+	highlights := []models.TextHighlight{}
+	for f, field := range fields {
+		for i, word := range field.words {
+			if marked[f][i] {
+				highlights = append(highlights, models.TextHighlight{Field: field.name, Start: word.start, End: word.end})
+			}
+		}
+	}
+	return highlights, matches
+}
+
+// markTerm marks every occurrence of a term among words and reports whether there was one
+func markTerm(term models.TextTerm, words []textWord, marked []bool, fuzzy bool) bool {
+	found := false
+	for i := 0; i+len(term.Words) <= len(words); i++ {
+		occurs := true
+		for j, want := range term.Words {
+			word := words[i+j].text
+			last := j == len(term.Words)-1
+			if word == want || (last && term.Prefix && strings.HasPrefix(word, want)) ||
+				(fuzzy && len(term.Words) == 1 && wordSimilarity(want, word) >= fuzzyWordSimilarity) {
+				continue
+			}
+			occurs = false
+			break
+		}
+		if occurs {
+			found = true
+			for j := range term.Words {
+				marked[i+j] = true
+			}
+		}
+	}
+	return found
+}
+
+// trigrams returns the trigrams of a word the way pg_trgm takes them: padded with two spaces in
+// front and one behind
+func trigrams(word string) map[string]bool {
+	padded := []rune("  " + word + " ")
+	set := make(map[string]bool, len(padded)-2)
+	for i := 0; i+3 <= len(padded); i++ {
+		set[string(padded[i:i+3])] = true
+	}
+	return set
+}
+
+// wordSimilarity returns the share of the trigrams of want found in word, from 0 to 1. It is
+// pg_trgm's word similarity for a single word of text.
+func wordSimilarity(want, word string) float64 {
+	wanted := trigrams(want)
+	found := trigrams(word)
+	shared := 0
+	for trigram := range wanted {
+		if found[trigram] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wanted))
+}
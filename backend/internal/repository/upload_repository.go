@@ -5,6 +5,7 @@ import (
 	"backend/internal/models"
 	"database/sql"
 	"errors"
+	"sort"
 	"time"
 )
 
@@ -12,14 +13,15 @@ var (
 	ErrUploadNotFound = errors.New("transaction upload not found")
 )
 
-// UploadRepository defines operations for managing transaction uploads
+// UploadRepository defines operations for managing transaction uploads. Uploads belong to a
+// tenant and every lookup is scoped to one.
 type UploadRepository interface {
 	CreateUpload(upload *models.TransactionUpload) error
-	GetUploadByID(id string) (*models.TransactionUpload, error)
-	UpdateUploadStatus(id string, status string, recordCount int, errorMessage string) error
-	GetUploadsByUser(userID string) ([]models.TransactionUpload, error)
-	GetRecentUploads(limit int) ([]models.TransactionUpload, error)
-	GetUploadsByDataSource(dataSourceID string) ([]models.TransactionUpload, error)
+	GetUploadByID(tenantID, id string) (*models.TransactionUpload, error)
+	UpdateUploadStatus(tenantID, id string, status string, recordCount int, errorMessage string) error
+	GetUploadsByUser(tenantID, userID string) ([]models.TransactionUpload, error)
+	GetRecentUploads(tenantID string, limit int) ([]models.TransactionUpload, error)
+	GetUploadsByDataSource(tenantID, dataSourceID string) ([]models.TransactionUpload, error)
 }
 
 // PostgresUploadRepository implements UploadRepository for PostgreSQL
@@ -40,19 +42,52 @@ func NewUploadRepository() UploadRepository {
 	}
 }
 
-// CreateUpload creates a new transaction upload
+// uploadColumns lists the columns of transaction_uploads in the order scanUpload expects
+const uploadColumns = `
+	id, tenant_id, data_source_id, file_name, file_size, uploaded_by,
+	upload_date, status, record_count, error_message`
+
+// scanUpload scans a row of uploadColumns into a transaction upload
+func scanUpload(row rowScanner) (*models.TransactionUpload, error) {
+	var upload models.TransactionUpload
+	err := row.Scan(
+		&upload.ID,
+		&upload.TenantID,
+		&upload.DataSourceID,
+		&upload.FileName,
+		&upload.FileSize,
+		&upload.UploadedBy,
+		&upload.UploadDate,
+		&upload.Status,
+		&upload.RecordCount,
+		&upload.ErrorMessage,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// CreateUpload creates a new transaction upload in the tenant it carries
 func (r *PostgresUploadRepository) CreateUpload(upload *models.TransactionUpload) error {
 	query := `
 		INSERT INTO transaction_uploads (
-			data_source_id, file_name, file_size, uploaded_by, 
+			tenant_id, data_source_id, file_name, file_size, uploaded_by,
 			status, record_count, error_message
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7
+			$1, $2, $3, $4, $5, $6, $7, $8
 		) RETURNING id, upload_date
 	`
 
-	return r.db.QueryRow(
+	tx, err := beginTenant(r.db, upload.TenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
 		query,
+		upload.TenantID,
 		upload.DataSourceID,
 		upload.FileName,
 		upload.FileSize,
@@ -61,31 +96,24 @@ func (r *PostgresUploadRepository) CreateUpload(upload *models.TransactionUpload
 		upload.RecordCount,
 		upload.ErrorMessage,
 	).Scan(&upload.ID, &upload.UploadDate)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// GetUploadByID retrieves a transaction upload by ID
-func (r *PostgresUploadRepository) GetUploadByID(id string) (*models.TransactionUpload, error) {
-	query := `
-		SELECT 
-			id, data_source_id, file_name, file_size, uploaded_by, 
-			upload_date, status, record_count, error_message
-		FROM transaction_uploads
-		WHERE id = $1
-	`
+// GetUploadByID retrieves a transaction upload of a tenant by ID
+func (r *PostgresUploadRepository) GetUploadByID(tenantID, id string) (*models.TransactionUpload, error) {
+	query := "SELECT " + uploadColumns + " FROM transaction_uploads WHERE tenant_id = $1 AND id = $2"
 
-	var upload models.TransactionUpload
-	err := r.db.QueryRow(query, id).Scan(
-		&upload.ID,
-		&upload.DataSourceID,
-		&upload.FileName,
-		&upload.FileSize,
-		&upload.UploadedBy,
-		&upload.UploadDate,
-		&upload.Status,
-		&upload.RecordCount,
-		&upload.ErrorMessage,
-	)
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
+	upload, err := scanUpload(tx.QueryRow(query, tenantID, id))
 	if err == sql.ErrNoRows {
 		return nil, ErrUploadNotFound
 	}
@@ -94,18 +122,24 @@ func (r *PostgresUploadRepository) GetUploadByID(id string) (*models.Transaction
 		return nil, err
 	}
 
-	return &upload, nil
+	return upload, nil
 }
 
-// UpdateUploadStatus updates a transaction upload's status
-func (r *PostgresUploadRepository) UpdateUploadStatus(id string, status string, recordCount int, errorMessage string) error {
+// UpdateUploadStatus updates the status of a transaction upload of a tenant
+func (r *PostgresUploadRepository) UpdateUploadStatus(tenantID, id string, status string, recordCount int, errorMessage string) error {
 	query := `
 		UPDATE transaction_uploads
 		SET status = $1, record_count = $2, error_message = $3
-		WHERE id = $4
+		WHERE tenant_id = $4 AND id = $5
 	`
 
-	result, err := r.db.Exec(query, status, recordCount, errorMessage, id)
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, status, recordCount, errorMessage, tenantID, id)
 	if err != nil {
 		return err
 	}
@@ -119,113 +153,51 @@ func (r *PostgresUploadRepository) UpdateUploadStatus(id string, status string,
 		return ErrUploadNotFound
 	}
 
-	return nil
+	return tx.Commit()
 }
 
-// GetUploadsByUser retrieves transaction uploads by user
-func (r *PostgresUploadRepository) GetUploadsByUser(userID string) ([]models.TransactionUpload, error) {
-	query := `
-		SELECT 
-			id, data_source_id, file_name, file_size, uploaded_by, 
-			upload_date, status, record_count, error_message
-		FROM transaction_uploads
-		WHERE uploaded_by = $1
-		ORDER BY upload_date DESC
-	`
+// GetUploadsByUser retrieves the transaction uploads of a user in a tenant
+func (r *PostgresUploadRepository) GetUploadsByUser(tenantID, userID string) ([]models.TransactionUpload, error) {
+	query := "SELECT " + uploadColumns + " FROM transaction_uploads WHERE tenant_id = $1 AND uploaded_by = $2 ORDER BY upload_date DESC"
 
-	rows, err := r.db.Query(query, userID)
+	tx, err := beginTenant(r.db, tenantID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var uploads []models.TransactionUpload
-	for rows.Next() {
-		var upload models.TransactionUpload
-		err := rows.Scan(
-			&upload.ID,
-			&upload.DataSourceID,
-			&upload.FileName,
-			&upload.FileSize,
-			&upload.UploadedBy,
-			&upload.UploadDate,
-			&upload.Status,
-			&upload.RecordCount,
-			&upload.ErrorMessage,
-		)
+	defer tx.Rollback()
 
-		if err != nil {
-			return nil, err
-		}
-
-		uploads = append(uploads, upload)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return uploads, nil
+	return queryUploads(tx, query, tenantID, userID)
 }
 
-// GetRecentUploads retrieves recent transaction uploads
-func (r *PostgresUploadRepository) GetRecentUploads(limit int) ([]models.TransactionUpload, error) {
-	query := `
-		SELECT 
-			id, data_source_id, file_name, file_size, uploaded_by, 
-			upload_date, status, record_count, error_message
-		FROM transaction_uploads
-		ORDER BY upload_date DESC
-		LIMIT $1
-	`
+// GetRecentUploads retrieves the recent transaction uploads of a tenant
+func (r *PostgresUploadRepository) GetRecentUploads(tenantID string, limit int) ([]models.TransactionUpload, error) {
+	query := "SELECT " + uploadColumns + " FROM transaction_uploads WHERE tenant_id = $1 ORDER BY upload_date DESC LIMIT $2"
 
-	rows, err := r.db.Query(query, limit)
+	tx, err := beginTenant(r.db, tenantID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var uploads []models.TransactionUpload
-	for rows.Next() {
-		var upload models.TransactionUpload
-		err := rows.Scan(
-			&upload.ID,
-			&upload.DataSourceID,
-			&upload.FileName,
-			&upload.FileSize,
-			&upload.UploadedBy,
-			&upload.UploadDate,
-			&upload.Status,
-			&upload.RecordCount,
-			&upload.ErrorMessage,
-		)
+	defer tx.Rollback()
 
-		if err != nil {
-			return nil, err
-		}
+	return queryUploads(tx, query, tenantID, limit)
+}
 
-		uploads = append(uploads, upload)
-	}
+// GetUploadsByDataSource retrieves the transaction uploads of a data source in a tenant
+func (r *PostgresUploadRepository) GetUploadsByDataSource(tenantID, dataSourceID string) ([]models.TransactionUpload, error) {
+	query := "SELECT " + uploadColumns + " FROM transaction_uploads WHERE tenant_id = $1 AND data_source_id = $2 ORDER BY upload_date DESC"
 
-	if err := rows.Err(); err != nil {
+	tx, err := beginTenant(r.db, tenantID)
+	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	return uploads, nil
+	return queryUploads(tx, query, tenantID, dataSourceID)
 }
 
-// GetUploadsByDataSource retrieves transaction uploads by data source
-func (r *PostgresUploadRepository) GetUploadsByDataSource(dataSourceID string) ([]models.TransactionUpload, error) {
-	query := `
-		SELECT 
-			id, data_source_id, file_name, file_size, uploaded_by, 
-			upload_date, status, record_count, error_message
-		FROM transaction_uploads
-		WHERE data_source_id = $1
-		ORDER BY upload_date DESC
-	`
-
-	rows, err := r.db.Query(query, dataSourceID)
+// queryUploads runs a query selecting uploadColumns and scans every row
+func queryUploads(q querier, query string, args ...interface{}) ([]models.TransactionUpload, error) {
+	rows, err := q.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -233,24 +205,12 @@ func (r *PostgresUploadRepository) GetUploadsByDataSource(dataSourceID string) (
 
 	var uploads []models.TransactionUpload
 	for rows.Next() {
-		var upload models.TransactionUpload
-		err := rows.Scan(
-			&upload.ID,
-			&upload.DataSourceID,
-			&upload.FileName,
-			&upload.FileSize,
-			&upload.UploadedBy,
-			&upload.UploadDate,
-			&upload.Status,
-			&upload.RecordCount,
-			&upload.ErrorMessage,
-		)
-
+		upload, err := scanUpload(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		uploads = append(uploads, upload)
+		uploads = append(uploads, *upload)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -276,18 +236,18 @@ func (r *MockUploadRepository) CreateUpload(upload *models.TransactionUpload) er
 	return nil
 }
 
-// GetUploadByID retrieves a transaction upload by ID from the mock repository
-func (r *MockUploadRepository) GetUploadByID(id string) (*models.TransactionUpload, error) {
-	if upload, exists := r.uploads[id]; exists {
+// GetUploadByID retrieves a transaction upload of a tenant by ID from the mock repository
+func (r *MockUploadRepository) GetUploadByID(tenantID, id string) (*models.TransactionUpload, error) {
+	if upload, exists := r.uploads[id]; exists && upload.TenantID == tenantID {
 		return upload, nil
 	}
 	return nil, ErrUploadNotFound
 }
 
-// UpdateUploadStatus updates a transaction upload's status in the mock repository
-func (r *MockUploadRepository) UpdateUploadStatus(id string, status string, recordCount int, errorMessage string) error {
+// UpdateUploadStatus updates the status of a transaction upload of a tenant in the mock repository
+func (r *MockUploadRepository) UpdateUploadStatus(tenantID, id string, status string, recordCount int, errorMessage string) error {
 	upload, exists := r.uploads[id]
-	if !exists {
+	if !exists || upload.TenantID != tenantID {
 		return ErrUploadNotFound
 	}
 
@@ -298,30 +258,18 @@ func (r *MockUploadRepository) UpdateUploadStatus(id string, status string, reco
 	return nil
 }
 
-// GetUploadsByUser retrieves transaction uploads by user from the mock repository
-func (r *MockUploadRepository) GetUploadsByUser(userID string) ([]models.TransactionUpload, error) {
-	var uploads []models.TransactionUpload
-	for _, upload := range r.uploads {
-		if upload.UploadedBy == userID {
-			uploads = append(uploads, *upload)
-		}
-	}
-
-	// Sort uploads by upload date (newest first)
-	// In a real implementation, we would use a proper sorting function
-
-	return uploads, nil
+// GetUploadsByUser retrieves the transaction uploads of a user in a tenant from the mock repository
+func (r *MockUploadRepository) GetUploadsByUser(tenantID, userID string) ([]models.TransactionUpload, error) {
+	return r.filterUploads(func(upload *models.TransactionUpload) bool {
+		return upload.TenantID == tenantID && upload.UploadedBy == userID
+	}), nil
 }
 
-// GetRecentUploads retrieves recent transaction uploads from the mock repository
-func (r *MockUploadRepository) GetRecentUploads(limit int) ([]models.TransactionUpload, error) {
-	var uploads []models.TransactionUpload
-	for _, upload := range r.uploads {
-		uploads = append(uploads, *upload)
-	}
-
-	// Sort uploads by upload date (newest first)
-	// In a real implementation, we would use a proper sorting function
+// GetRecentUploads retrieves the recent transaction uploads of a tenant from the mock repository
+func (r *MockUploadRepository) GetRecentUploads(tenantID string, limit int) ([]models.TransactionUpload, error) {
+	uploads := r.filterUploads(func(upload *models.TransactionUpload) bool {
+		return upload.TenantID == tenantID
+	})
 
 	// Apply limit
 	if len(uploads) > limit {
@@ -331,17 +279,26 @@ func (r *MockUploadRepository) GetRecentUploads(limit int) ([]models.Transaction
 	return uploads, nil
 }
 
-// GetUploadsByDataSource retrieves transaction uploads by data source from the mock repository
-func (r *MockUploadRepository) GetUploadsByDataSource(dataSourceID string) ([]models.TransactionUpload, error) {
+// GetUploadsByDataSource retrieves the transaction uploads of a data source in a tenant from the
+// mock repository
+func (r *MockUploadRepository) GetUploadsByDataSource(tenantID, dataSourceID string) ([]models.TransactionUpload, error) {
+	return r.filterUploads(func(upload *models.TransactionUpload) bool {
+		return upload.TenantID == tenantID && upload.DataSourceID == dataSourceID
+	}), nil
+}
+
+// filterUploads returns the uploads a predicate keeps, newest first
+func (r *MockUploadRepository) filterUploads(keep func(upload *models.TransactionUpload) bool) []models.TransactionUpload {
 	var uploads []models.TransactionUpload
 	for _, upload := range r.uploads {
-		if upload.DataSourceID == dataSourceID {
+		if keep(upload) {
 			uploads = append(uploads, *upload)
 		}
 	}
 
-	// Sort uploads by upload date (newest first)
-	// In a real implementation, we would use a proper sorting function
+	sort.Slice(uploads, func(i, j int) bool {
+		return uploads[i].UploadDate.After(uploads[j].UploadDate)
+	})
 
-	return uploads, nil
+	return uploads
 }
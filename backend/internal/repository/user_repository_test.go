@@ -4,7 +4,6 @@ import (
 	"backend/internal/models"
 	"backend/internal/testutil"
 	"testing"
-	"time"
 )
 
 func TestUserRepository_Create(t *testing.T) {
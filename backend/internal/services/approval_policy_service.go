@@ -0,0 +1,205 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"errors"
+	"math"
+	"strings"
+)
+
+// ApprovalPolicyService provides methods for managing the auto-approval policies of match sets
+type ApprovalPolicyService struct {
+	policyRepo     repository.ApprovalPolicyRepository
+	matchSetRepo   repository.MatchSetRepository
+	ruleRepo       repository.RuleRepository
+	permissionRepo repository.PermissionRepository
+}
+
+// NewApprovalPolicyService creates a new approval policy service
+func NewApprovalPolicyService(
+	policyRepo repository.ApprovalPolicyRepository,
+	matchSetRepo repository.MatchSetRepository,
+	ruleRepo repository.RuleRepository,
+	permissionRepo repository.PermissionRepository,
+) *ApprovalPolicyService {
+	return &ApprovalPolicyService{
+		policyRepo:     policyRepo,
+		matchSetRepo:   matchSetRepo,
+		ruleRepo:       ruleRepo,
+		permissionRepo: permissionRepo,
+	}
+}
+
+// GetPolicies retrieves the auto-approval policies of a match set in the order they are applied
+func (s *ApprovalPolicyService) GetPolicies(matchSetID, userID, tenantID string) ([]models.AutoApprovalPolicy, error) {
+	// Check if user has permission to view match sets
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermViewMatchSet, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission {
+		return nil, errors.New("unauthorized: requires view match set permission")
+	}
+
+	if _, err := s.tenantMatchSet(matchSetID, tenantID); err != nil {
+		return nil, err
+	}
+
+	return s.policyRepo.GetPoliciesByMatchSet(tenantID, matchSetID)
+}
+
+// CreatePolicy adds an auto-approval policy to a match set
+func (s *ApprovalPolicyService) CreatePolicy(matchSetID string, policy *models.AutoApprovalPolicy, userID, tenantID string) (*models.AutoApprovalPolicy, error) {
+	if err := s.authorize(userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	matchSet, err := s.tenantMatchSet(matchSetID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validatePolicy(policy, tenantID); err != nil {
+		return nil, err
+	}
+
+	policy.ID = ""
+	policy.MatchSetID = matchSet.ID
+	policy.TenantID = matchSet.TenantID
+	policy.CreatedBy = userID
+	if err := s.policyRepo.CreatePolicy(policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// UpdatePolicy replaces the criteria of an auto-approval policy
+func (s *ApprovalPolicyService) UpdatePolicy(matchSetID, policyID string, policy *models.AutoApprovalPolicy, userID, tenantID string) (*models.AutoApprovalPolicy, error) {
+	if err := s.authorize(userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.matchSetPolicy(matchSetID, policyID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validatePolicy(policy, tenantID); err != nil {
+		return nil, err
+	}
+
+	policy.ID = existing.ID
+	policy.MatchSetID = existing.MatchSetID
+	policy.TenantID = existing.TenantID
+	policy.CreatedBy = existing.CreatedBy
+	if err := s.policyRepo.UpdatePolicy(policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// DeletePolicy removes an auto-approval policy from a match set
+func (s *ApprovalPolicyService) DeletePolicy(matchSetID, policyID, userID, tenantID string) error {
+	if err := s.authorize(userID, tenantID); err != nil {
+		return err
+	}
+
+	if _, err := s.matchSetPolicy(matchSetID, policyID, tenantID); err != nil {
+		return err
+	}
+
+	return s.policyRepo.DeletePolicy(tenantID, policyID)
+}
+
+// authorize checks that a user may manage auto-approval policies. Policies approve matches on
+// the user's behalf, so they are managed by approvers.
+func (s *ApprovalPolicyService) authorize(userID, tenantID string) error {
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermApproveMatches, tenantID)
+	if err != nil {
+		return err
+	}
+	if !hasPermission {
+		return errors.New("unauthorized: requires approve matches permission")
+	}
+	return nil
+}
+
+// tenantMatchSet returns a match set when it belongs to the tenant
+func (s *ApprovalPolicyService) tenantMatchSet(matchSetID, tenantID string) (*models.MatchSet, error) {
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
+	if err != nil {
+		return nil, err
+	}
+	if matchSet.TenantID != tenantID {
+		return nil, errors.New("match set not found in this tenant")
+	}
+	return matchSet, nil
+}
+
+// matchSetPolicy returns a policy when it belongs to the match set and the tenant
+func (s *ApprovalPolicyService) matchSetPolicy(matchSetID, policyID, tenantID string) (*models.AutoApprovalPolicy, error) {
+	if _, err := s.tenantMatchSet(matchSetID, tenantID); err != nil {
+		return nil, err
+	}
+
+	policy, err := s.policyRepo.GetPolicyByID(tenantID, policyID)
+	if err != nil {
+		return nil, err
+	}
+	if policy.MatchSetID != matchSetID {
+		return nil, errors.New("approval policy not found in this match set")
+	}
+	return policy, nil
+}
+
+// validatePolicy checks the criteria of a policy
+func (s *ApprovalPolicyService) validatePolicy(policy *models.AutoApprovalPolicy, tenantID string) error {
+	policy.Name = strings.TrimSpace(policy.Name)
+	if policy.Name == "" {
+		return errors.New("invalid policy: name is required")
+	}
+	if policy.MinScore < 0 || policy.MinScore > 1 {
+		return errors.New("invalid policy: min_score must be between 0 and 1")
+	}
+	if policy.MaxAmount < 0 {
+		return errors.New("invalid policy: max_amount cannot be negative")
+	}
+
+	if policy.RuleID != "" {
+		_, err := s.ruleRepo.GetRuleByID(tenantID, policy.RuleID)
+		if err == repository.ErrRuleNotFound {
+			return errors.New("invalid policy: rule not found")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// matchingPolicy returns the first active policy that approves a group proposed under a rule
+func matchingPolicy(policies []models.AutoApprovalPolicy, rule *models.MatchRule, proposal *ProposedMatch) *models.AutoApprovalPolicy {
+	amount := 0.0
+	for _, t := range proposal.Left {
+		amount += t.Amount
+	}
+	amount = math.Abs(amount)
+
+	for i := range policies {
+		policy := &policies[i]
+		switch {
+		case !policy.Active:
+		case policy.RuleID != "" && policy.RuleID != rule.ID:
+		case proposal.Score < policy.MinScore:
+		case policy.MaxAmount > 0 && amount > policy.MaxAmount:
+		case policy.OneToOneOnly && (len(proposal.Left) != 1 || len(proposal.Right) != 1):
+		default:
+			return policy
+		}
+	}
+	return nil
+}
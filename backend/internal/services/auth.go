@@ -12,12 +12,14 @@ import (
 )
 
 type AuthService struct {
-	userRepo repository.UserRepository
+	userRepo   repository.UserRepository
+	tenantRepo repository.TenantRepository
 }
 
-func NewAuthService(userRepo repository.UserRepository) *AuthService {
+func NewAuthService(userRepo repository.UserRepository, tenantRepo repository.TenantRepository) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
+		userRepo:   userRepo,
+		tenantRepo: tenantRepo,
 	}
 }
 
@@ -83,6 +85,51 @@ func (s *AuthService) Login(email, password string) (*models.User, error) {
 	return user, nil
 }
 
+// GetUser retrieves a user by ID
+func (s *AuthService) GetUser(userID string) (*models.User, error) {
+	return s.userRepo.FindByID(userID)
+}
+
+// GetUserTenants returns the active tenants a user belongs to
+func (s *AuthService) GetUserTenants(userID string) ([]models.Tenant, error) {
+	tenants, err := s.tenantRepo.GetUserTenants(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	active := []models.Tenant{}
+	for _, tenant := range tenants {
+		if tenant.Active {
+			active = append(active, tenant)
+		}
+	}
+	return active, nil
+}
+
+// SelectTenant picks the tenant a user's token is scoped to from the active tenants the user
+// belongs to, which it also returns. A requested tenant must be one of them; without a request
+// the first one is picked, and a user of no tenant gets none.
+func (s *AuthService) SelectTenant(userID, tenantID string) ([]models.Tenant, *models.Tenant, error) {
+	tenants, err := s.GetUserTenants(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if tenantID == "" {
+		if len(tenants) == 0 {
+			return tenants, nil, nil
+		}
+		return tenants, &tenants[0], nil
+	}
+
+	for i := range tenants {
+		if tenants[i].ID == tenantID {
+			return tenants, &tenants[i], nil
+		}
+	}
+	return nil, nil, errors.New("tenant not found for this user")
+}
+
 func (s *AuthService) GetGoogleAuthURL() string {
 	// TODO: Implement Google OAuth URL generation
 	return ""
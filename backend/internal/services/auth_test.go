@@ -0,0 +1,89 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"testing"
+)
+
+func TestAuthService_SelectTenant(t *testing.T) {
+	tenantRepo := repository.NewTenantRepository()
+	for _, tenant := range []*models.Tenant{
+		{ID: "tenant-1", Name: "Acme", Active: true},
+		{ID: "tenant-2", Name: "Globex", Active: true},
+		{ID: "tenant-3", Name: "Initech", Active: false},
+		{ID: "tenant-4", Name: "Umbrella", Active: true},
+	} {
+		if err := tenantRepo.CreateTenant(tenant); err != nil {
+			t.Fatalf("CreateTenant() error = %v", err)
+		}
+	}
+	for _, tenantID := range []string{"tenant-1", "tenant-2", "tenant-3"} {
+		if err := tenantRepo.AssignUserToTenant("user-1", tenantID); err != nil {
+			t.Fatalf("AssignUserToTenant() error = %v", err)
+		}
+	}
+
+	service := NewAuthService(repository.NewUserRepository(), tenantRepo)
+
+	tests := []struct {
+		name     string
+		userID   string
+		tenantID string
+		want     string
+		wantErr  bool
+	}{
+		{name: "first tenant by default", userID: "user-1", want: "tenant-1"},
+		{name: "requested tenant", userID: "user-1", tenantID: "tenant-2", want: "tenant-2"},
+		{name: "inactive tenant", userID: "user-1", tenantID: "tenant-3", wantErr: true},
+		{name: "tenant of other users", userID: "user-1", tenantID: "tenant-4", wantErr: true},
+		{name: "user of no tenant", userID: "user-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenants, tenant, err := service.SelectTenant(tt.userID, tt.tenantID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SelectTenant() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got := ""
+			if tenant != nil {
+				got = tenant.ID
+			}
+			if got != tt.want {
+				t.Errorf("SelectTenant() tenant = %q, want %q", got, tt.want)
+			}
+			for _, candidate := range tenants {
+				if !candidate.Active {
+					t.Errorf("SelectTenant() tenants include inactive tenant %s", candidate.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestJWTService_TokenCarriesTenantAndRoles(t *testing.T) {
+	service := NewJWTService()
+
+	tokenInfo, err := service.GenerateToken(&models.User{ID: "user-1", Email: "user@example.com"}, "tenant-2", []string{string(models.RoleAdmin)})
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	claims, err := service.ValidateToken(tokenInfo.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+
+	if tenantID := (*claims)["tenant_id"]; tenantID != "tenant-2" {
+		t.Errorf("tenant_id claim = %v, want tenant-2", tenantID)
+	}
+	roles, ok := (*claims)["roles"].([]interface{})
+	if !ok || len(roles) != 1 || roles[0] != string(models.RoleAdmin) {
+		t.Errorf("roles claim = %v, want [%s]", (*claims)["roles"], models.RoleAdmin)
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"errors"
+	"time"
+)
+
+// defaultAutoRunDebounce is how long a match set waits after an import before running, so a
+// burst of uploads is matched by one run
+const defaultAutoRunDebounce = 5 * time.Minute
+
+// maxAutoRunDebounce is the longest a match set may wait after an import before running
+const maxAutoRunDebounce = 24 * time.Hour
+
+// autoRunBatchSize is the most due auto-runs claimed at once
+const autoRunBatchSize = 100
+
+// AutoRunService provides methods for running match sets when their data sources receive imports
+type AutoRunService struct {
+	autoRunRepo    repository.AutoRunRepository
+	matchSetRepo   repository.MatchSetRepository
+	progressRepo   repository.MatchProgressRepository
+	permissionRepo repository.PermissionRepository
+}
+
+// NewAutoRunService creates a new auto-run service
+func NewAutoRunService(
+	autoRunRepo repository.AutoRunRepository,
+	matchSetRepo repository.MatchSetRepository,
+	progressRepo repository.MatchProgressRepository,
+	permissionRepo repository.PermissionRepository,
+) *AutoRunService {
+	return &AutoRunService{
+		autoRunRepo:    autoRunRepo,
+		matchSetRepo:   matchSetRepo,
+		progressRepo:   progressRepo,
+		permissionRepo: permissionRepo,
+	}
+}
+
+// GetAutoRun retrieves the auto-run of a match set. A match set that never enabled one gets a
+// disabled auto-run with the default debounce.
+func (s *AutoRunService) GetAutoRun(matchSetID, userID, tenantID string) (*models.MatchSetAutoRun, error) {
+	// Check if user has permission to view match sets
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermViewMatchSet, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission {
+		return nil, errors.New("unauthorized: requires view match set permission")
+	}
+
+	matchSet, err := s.tenantMatchSet(matchSetID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	autoRun, err := s.autoRunRepo.GetAutoRun(tenantID, matchSet.ID)
+	if err == repository.ErrAutoRunNotFound {
+		return &models.MatchSetAutoRun{
+			MatchSetID:      matchSet.ID,
+			TenantID:        matchSet.TenantID,
+			DebounceSeconds: int(defaultAutoRunDebounce / time.Second),
+		}, nil
+	}
+	return autoRun, err
+}
+
+// SetAutoRun enables or disables the auto-run of a match set. Its runs act as the user setting
+// it, who must be allowed to run the match set.
+func (s *AutoRunService) SetAutoRun(matchSetID string, enabled bool, debounceSeconds *int, userID, tenantID string) (*models.MatchSetAutoRun, error) {
+	// Check if user has permission to match transactions
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermMatchTransactions, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission {
+		return nil, errors.New("unauthorized: requires match transactions permission")
+	}
+
+	matchSet, err := s.tenantMatchSet(matchSetID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	debounce := int(defaultAutoRunDebounce / time.Second)
+	if debounceSeconds != nil {
+		debounce = *debounceSeconds
+	}
+	if debounce < 0 || debounce > int(maxAutoRunDebounce/time.Second) {
+		return nil, errors.New("invalid auto-run: debounce_seconds must be between 0 and 86400")
+	}
+
+	autoRun := &models.MatchSetAutoRun{
+		MatchSetID:      matchSet.ID,
+		TenantID:        matchSet.TenantID,
+		Enabled:         enabled,
+		DebounceSeconds: debounce,
+		RunAs:           userID,
+	}
+	if err := s.autoRunRepo.SaveAutoRun(autoRun); err != nil {
+		return nil, err
+	}
+
+	return autoRun, nil
+}
+
+// ImportCompleted records that a data source of a tenant received an import. The match sets it
+// belongs to that have the auto-run enabled get a run due once their debounce passes.
+func (s *AutoRunService) ImportCompleted(tenantID, dataSourceID string) error {
+	matchSetIDs, err := s.matchSetRepo.GetMatchSetIDsByDataSource(dataSourceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.autoRunRepo.RequestAutoRuns(tenantID, matchSetIDs, time.Now())
+	return err
+}
+
+// ClaimDueAutoRuns takes the auto-runs due at now for the caller to start. A match set whose
+// previous run is still going keeps its run pending until that run finishes, as the run may have
+// started before the import arrived.
+func (s *AutoRunService) ClaimDueAutoRuns(now time.Time) ([]models.MatchSetAutoRun, error) {
+	autoRuns, err := s.autoRunRepo.GetDueAutoRuns(now, autoRunBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var claimed []models.MatchSetAutoRun
+	for _, autoRun := range autoRuns {
+		progress, err := s.progressRepo.GetProgress(autoRun.TenantID, autoRun.MatchSetID)
+		if err != nil && err != repository.ErrMatchProgressNotFound {
+			return claimed, err
+		}
+		if err == nil && progress.Status == "Running" && progress.LeaseExpiresAt != nil && progress.LeaseExpiresAt.After(now) {
+			continue
+		}
+
+		// Unclaimed runs were started by another instance
+		ok, err := s.autoRunRepo.ClaimAutoRun(autoRun.TenantID, autoRun.MatchSetID, *autoRun.DueAt)
+		if err != nil {
+			return claimed, err
+		}
+		if ok {
+			claimed = append(claimed, autoRun)
+		}
+	}
+
+	return claimed, nil
+}
+
+// tenantMatchSet returns a match set when it belongs to the tenant
+func (s *AutoRunService) tenantMatchSet(matchSetID, tenantID string) (*models.MatchSet, error) {
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
+	if err != nil {
+		return nil, err
+	}
+	if matchSet.TenantID != tenantID {
+		return nil, errors.New("match set not found in this tenant")
+	}
+	return matchSet, nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAutoRunService_DebouncesImports(t *testing.T) {
+	matchSetRepo := repository.NewMatchSetRepository()
+	ruleRepo := repository.NewRuleRepository()
+	progressRepo := repository.NewMatchProgressRepository()
+	autoRunRepo := repository.NewAutoRunRepository()
+
+	rule := &models.MatchRule{ID: "rule-amount", TenantID: "tenant-1", Name: "Amount", Active: true, Conditions: []models.RuleCondition{
+		{Field: models.ConditionFieldAmount, Operator: models.ConditionOpEq},
+	}}
+	ruleRepo.CreateRule(rule)
+
+	for _, matchSet := range []*models.MatchSet{
+		{ID: "set-auto", Name: "Bank", TenantID: "tenant-1", RuleID: rule.ID},
+		{ID: "set-manual", Name: "Cards", TenantID: "tenant-1", RuleID: rule.ID},
+	} {
+		matchSetRepo.CreateMatchSet(matchSet)
+		matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "ledger")
+		matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "bank")
+	}
+
+	matchSetService := NewMatchSetService(
+		matchSetRepo,
+		ruleRepo,
+		repository.NewDataSourceRepository(),
+		repository.NewTransactionRepository(),
+		allowAllPermissions{},
+		repository.NewMatchRepository(),
+		repository.NewUnmatchedTransactionRepository(),
+		progressRepo,
+		repository.NewFXRateRepository(),
+		repository.NewApprovalPolicyRepository(),
+	)
+	service := NewAutoRunService(autoRunRepo, matchSetRepo, progressRepo, allowAllPermissions{})
+	queueService := NewQueueService(nil, nil, matchSetService, nil, nil, repository.NewScheduleRepository(), service)
+
+	negative := -1
+	if _, err := service.SetAutoRun("set-auto", true, &negative, "user-1", "tenant-1"); err == nil || !strings.Contains(err.Error(), "invalid") {
+		t.Errorf("SetAutoRun() with a negative debounce error = %v, want an invalid auto-run error", err)
+	}
+	if _, err := service.SetAutoRun("set-auto", true, nil, "user-1", "tenant-1"); err != nil {
+		t.Fatalf("SetAutoRun() error = %v", err)
+	}
+
+	// Three imports into a data source of the match set share one pending run
+	payload, _ := json.Marshal(ProcessDataSourcePayload{DataSourceID: "bank", TenantID: "tenant-1"})
+	if err := queueService.HandleMessage(QueueMessage{Type: MessageTypeProcessDataSource, Payload: payload}); err != nil {
+		t.Fatalf("HandleMessage() error = %v", err)
+	}
+	first, _ := service.GetAutoRun("set-auto", "user-1", "tenant-1")
+	if first.DueAt == nil {
+		t.Fatalf("auto-run after an import has no pending run")
+	}
+	service.ImportCompleted("tenant-1", "bank")
+	service.ImportCompleted("tenant-1", "ledger")
+
+	autoRun, _ := service.GetAutoRun("set-auto", "user-1", "tenant-1")
+	if !autoRun.DueAt.Equal(*first.DueAt) || autoRun.DueAt.Sub(*autoRun.LastImportAt) > defaultAutoRunDebounce {
+		t.Errorf("pending run due %v after imports until %v, want %v", autoRun.DueAt, autoRun.LastImportAt, first.DueAt)
+	}
+	if manual, _ := service.GetAutoRun("set-manual", "user-1", "tenant-1"); manual.Enabled || manual.DueAt != nil {
+		t.Errorf("match set without an auto-run = %+v, want disabled and nothing pending", manual)
+	}
+
+	// Nothing runs within the debounce
+	queueService.RunDueAutoRuns(autoRun.DueAt.Add(-time.Second))
+	if _, err := progressRepo.GetProgress("tenant-1", "set-auto"); err != repository.ErrMatchProgressNotFound {
+		t.Fatalf("match set ran within the debounce, progress error = %v", err)
+	}
+
+	// The debounce passing starts one run
+	queueService.RunDueAutoRuns(*autoRun.DueAt)
+	queueService.RunDueAutoRuns(*autoRun.DueAt)
+	progress, err := progressRepo.GetProgress("tenant-1", "set-auto")
+	if err != nil || progress.Status != "Completed" {
+		t.Fatalf("auto-run progress = %+v, %v, want a completed run", progress, err)
+	}
+	if _, err := progressRepo.GetProgress("tenant-1", "set-manual"); err != repository.ErrMatchProgressNotFound {
+		t.Errorf("match set without an auto-run ran, progress error = %v", err)
+	}
+
+	// An import while a run is going waits for that run
+	service.ImportCompleted("tenant-1", "bank")
+	running, _ := progressRepo.AcquireRunLease("tenant-1", "set-auto", time.Hour)
+	queueService.RunDueAutoRuns(time.Now().Add(time.Hour - time.Second))
+	autoRun, _ = service.GetAutoRun("set-auto", "user-1", "tenant-1")
+	if autoRun.DueAt == nil {
+		t.Errorf("auto-run behind run %s was dropped, want it pending", running.RunID)
+	}
+}
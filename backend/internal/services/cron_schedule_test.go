@@ -0,0 +1,69 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCronSchedule_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"0 6 * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * FOO *",
+	}
+
+	for _, expression := range tests {
+		if _, err := ParseCronSchedule(expression, time.UTC); err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Errorf("ParseCronSchedule(%q) error = %v, want an invalid expression error", expression, err)
+		}
+	}
+}
+
+func TestCronSchedule_Next(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, london)
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		after      time.Time
+		want       time.Time
+	}{
+		{"weekday mornings skip the weekend", "0 6 * * MON-FRI", at(time.October, 16, 7, 0), at(time.October, 19, 6, 0)},
+		{"strictly after", "0 6 * * *", at(time.October, 16, 6, 0), at(time.October, 17, 6, 0)},
+		{"seconds round up", "*/15 * * * *", at(time.October, 16, 10, 7).Add(30 * time.Second), at(time.October, 16, 10, 15)},
+		{"list and range with step", "0 8-18/5,20 * * *", at(time.October, 16, 13, 1), at(time.October, 16, 18, 0)},
+		{"day of month or day of week", "0 0 13 * FRI", at(time.March, 1, 0, 0), at(time.March, 6, 0, 0)},
+		{"sunday as 7", "0 12 * * 7", at(time.October, 12, 0, 0), at(time.October, 18, 12, 0)},
+		{"month names", "0 0 1 jan,jul *", at(time.March, 1, 0, 0), at(time.July, 1, 0, 0)},
+		{"time skipped by the clocks going forward", "30 1 * * *", at(time.March, 28, 12, 0), at(time.March, 30, 1, 30)},
+		{"time repeated by the clocks going back runs once", "30 1 * * *",
+			time.Date(2026, time.October, 25, 0, 30, 0, 0, time.UTC), at(time.October, 26, 1, 30)},
+		{"never", "0 0 30 2 *", at(time.January, 1, 0, 0), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCronSchedule(tt.expression, london)
+			if err != nil {
+				t.Fatalf("ParseCronSchedule(%q) error = %v", tt.expression, err)
+			}
+			if got := schedule.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.after, got, tt.want)
+			}
+		})
+	}
+}
@@ -12,7 +12,7 @@ var (
 	ErrDataSourceExists   = errors.New("data source with this name already exists")
 )
 
-// DataSourceService provides methods for managing data sources
+// DataSourceService provides methods for managing the data sources of a tenant
 type DataSourceService struct {
 	dataSourceRepo repository.DataSourceRepository
 }
@@ -24,9 +24,10 @@ func NewDataSourceService(dataSourceRepo repository.DataSourceRepository) *DataS
 	}
 }
 
-// CreateDataSource creates a new data source
-func (s *DataSourceService) CreateDataSource(name, description string) (*models.DataSource, error) {
+// CreateDataSource creates a new data source in a tenant
+func (s *DataSourceService) CreateDataSource(tenantID, name, description string) (*models.DataSource, error) {
 	dataSource := &models.DataSource{
+		TenantID:    tenantID,
 		Name:        name,
 		Description: description,
 	}
@@ -42,9 +43,9 @@ func (s *DataSourceService) CreateDataSource(name, description string) (*models.
 	return dataSource, nil
 }
 
-// GetDataSourceByID retrieves a data source by ID
-func (s *DataSourceService) GetDataSourceByID(id string) (*models.DataSource, error) {
-	dataSource, err := s.dataSourceRepo.GetDataSourceByID(id)
+// GetDataSourceByID retrieves a data source of a tenant by ID
+func (s *DataSourceService) GetDataSourceByID(tenantID, id string) (*models.DataSource, error) {
+	dataSource, err := s.dataSourceRepo.GetDataSourceByID(tenantID, id)
 	if err != nil {
 		if err == repository.ErrDataSourceNotFound {
 			return nil, ErrDataSourceNotFound
@@ -54,9 +55,9 @@ func (s *DataSourceService) GetDataSourceByID(id string) (*models.DataSource, er
 	return dataSource, nil
 }
 
-// GetDataSourceByName retrieves a data source by name
-func (s *DataSourceService) GetDataSourceByName(name string) (*models.DataSource, error) {
-	dataSource, err := s.dataSourceRepo.GetDataSourceByName(name)
+// GetDataSourceByName retrieves a data source of a tenant by name
+func (s *DataSourceService) GetDataSourceByName(tenantID, name string) (*models.DataSource, error) {
+	dataSource, err := s.dataSourceRepo.GetDataSourceByName(tenantID, name)
 	if err != nil {
 		if err == repository.ErrDataSourceNotFound {
 			return nil, ErrDataSourceNotFound
@@ -66,9 +67,9 @@ func (s *DataSourceService) GetDataSourceByName(name string) (*models.DataSource
 	return dataSource, nil
 }
 
-// UpdateDataSource updates a data source
-func (s *DataSourceService) UpdateDataSource(id, name, description string) (*models.DataSource, error) {
-	dataSource, err := s.dataSourceRepo.GetDataSourceByID(id)
+// UpdateDataSource updates a data source of a tenant
+func (s *DataSourceService) UpdateDataSource(tenantID, id, name, description string) (*models.DataSource, error) {
+	dataSource, err := s.dataSourceRepo.GetDataSourceByID(tenantID, id)
 	if err != nil {
 		if err == repository.ErrDataSourceNotFound {
 			return nil, ErrDataSourceNotFound
@@ -84,15 +85,18 @@ func (s *DataSourceService) UpdateDataSource(id, name, description string) (*mod
 		if err == repository.ErrDataSourceExists {
 			return nil, ErrDataSourceExists
 		}
+		if err == repository.ErrDataSourceNotFound {
+			return nil, ErrDataSourceNotFound
+		}
 		return nil, err
 	}
 
 	return dataSource, nil
 }
 
-// DeleteDataSource deletes a data source
-func (s *DataSourceService) DeleteDataSource(id string) error {
-	err := s.dataSourceRepo.DeleteDataSource(id)
+// DeleteDataSource deletes a data source of a tenant
+func (s *DataSourceService) DeleteDataSource(tenantID, id string) error {
+	err := s.dataSourceRepo.DeleteDataSource(tenantID, id)
 	if err != nil {
 		if err == repository.ErrDataSourceNotFound {
 			return ErrDataSourceNotFound
@@ -102,12 +106,12 @@ func (s *DataSourceService) DeleteDataSource(id string) error {
 	return nil
 }
 
-// GetAllDataSources retrieves all data sources
-func (s *DataSourceService) GetAllDataSources() ([]models.DataSource, error) {
-	return s.dataSourceRepo.GetAllDataSources()
+// GetAllDataSources retrieves all data sources of a tenant
+func (s *DataSourceService) GetAllDataSources(tenantID string) ([]models.DataSource, error) {
+	return s.dataSourceRepo.GetAllDataSources(tenantID)
 }
 
-// SearchDataSources searches for data sources matching the query
-func (s *DataSourceService) SearchDataSources(query string, limit, offset int) ([]models.DataSource, int, error) {
-	return s.dataSourceRepo.SearchDataSources(query, limit, offset)
+// SearchDataSources searches for data sources of a tenant matching the query
+func (s *DataSourceService) SearchDataSources(tenantID, query string, limit, offset int) ([]models.DataSource, int, error) {
+	return s.dataSourceRepo.SearchDataSources(tenantID, query, limit, offset)
 }
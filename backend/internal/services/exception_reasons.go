@@ -0,0 +1,105 @@
+package services
+
+import (
+	"backend/internal/models"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// unmatchedReason is why a transaction was left unmatched, as a reason code and a message
+type unmatchedReason struct {
+	code    string
+	message string
+}
+
+// classifyUnmatched works out why each transaction left in the pools after a run found no match,
+// keyed by transaction ID. It looks for the near misses an analyst would check first, in order:
+//   - duplicate: its own pool holds another transaction with the same date, amount, currency and reference
+//   - amount mismatch: another pool holds its reference with a different amount
+//   - timing: another pool holds its amount and currency on a different date
+//
+// Anything else is missing its counterpart. fallback is the message used then.
+func classifyUnmatched(pools [][]models.Transaction, fallback string) map[string]unmatchedReason {
+	type poolAmount struct {
+		pool  int
+		cents int64
+	}
+	duplicates := make(map[string]int)
+	references := make(map[string][]poolAmount)
+	amounts := make(map[string][]int)
+	for i, pool := range pools {
+		for _, transaction := range pool {
+			duplicates[duplicateKey(i, transaction)]++
+			if reference := normalizedReference(transaction); reference != "" {
+				references[reference] = append(references[reference], poolAmount{i, amountCents(transaction)})
+			}
+			amounts[amountKey(transaction)] = append(amounts[amountKey(transaction)], i)
+		}
+	}
+
+	reasons := make(map[string]unmatchedReason)
+	for i, pool := range pools {
+	transactions:
+		for _, transaction := range pool {
+			if duplicates[duplicateKey(i, transaction)] > 1 {
+				reasons[transaction.ID] = unmatchedReason{
+					code:    models.ExceptionReasonDuplicate,
+					message: "Another transaction from the same source has the same date, amount and reference",
+				}
+				continue
+			}
+
+			if reference := normalizedReference(transaction); reference != "" {
+				for _, other := range references[reference] {
+					if other.pool != i && other.cents != amountCents(transaction) {
+						reasons[transaction.ID] = unmatchedReason{
+							code:    models.ExceptionReasonAmountMismatch,
+							message: "A transaction with reference " + transaction.Reference + " on the other side has a different amount",
+						}
+						continue transactions
+					}
+				}
+			}
+
+			for _, other := range amounts[amountKey(transaction)] {
+				if other != i {
+					reasons[transaction.ID] = unmatchedReason{
+						code:    models.ExceptionReasonTiming,
+						message: "A transaction with the same amount on the other side falls outside the date window of the rules",
+					}
+					continue transactions
+				}
+			}
+
+			reasons[transaction.ID] = unmatchedReason{code: models.ExceptionReasonMissingCounterpart, message: fallback}
+		}
+	}
+	return reasons
+}
+
+// amountCents returns the size of an amount in cents. Sides often record the same movement with
+// opposite signs, so the sign is dropped.
+func amountCents(transaction models.Transaction) int64 {
+	return int64(math.Round(math.Abs(transaction.Amount) * 100))
+}
+
+// amountKey groups transactions of the same size in the same currency
+func amountKey(transaction models.Transaction) string {
+	return strings.ToUpper(transaction.Currency) + " " + strconv.FormatInt(amountCents(transaction), 10)
+}
+
+// duplicateKey groups the transactions of a pool that look identical
+func duplicateKey(pool int, transaction models.Transaction) string {
+	return strings.Join([]string{
+		strconv.Itoa(pool),
+		transaction.TransactionDate.Format("2006-01-02"),
+		amountKey(transaction),
+		normalizedReference(transaction),
+	}, "|")
+}
+
+// normalizedReference returns a reference compared without case or surrounding space
+func normalizedReference(transaction models.Transaction) string {
+	return strings.ToUpper(strings.TrimSpace(transaction.Reference))
+}
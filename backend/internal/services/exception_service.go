@@ -0,0 +1,329 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"errors"
+	"strings"
+	"time"
+)
+
+// maxExceptionCommentLength is the longest comment that may be left on an exception
+const maxExceptionCommentLength = 4000
+
+// ExceptionService provides methods for working the transactions match set runs leave unmatched
+type ExceptionService struct {
+	unmatchedRepo  repository.UnmatchedTransactionRepository
+	matchSetRepo   repository.MatchSetRepository
+	tenantRepo     repository.TenantRepository
+	permissionRepo repository.PermissionRepository
+}
+
+// NewExceptionService creates a new exception service
+func NewExceptionService(
+	unmatchedRepo repository.UnmatchedTransactionRepository,
+	matchSetRepo repository.MatchSetRepository,
+	tenantRepo repository.TenantRepository,
+	permissionRepo repository.PermissionRepository,
+) *ExceptionService {
+	return &ExceptionService{
+		unmatchedRepo:  unmatchedRepo,
+		matchSetRepo:   matchSetRepo,
+		tenantRepo:     tenantRepo,
+		permissionRepo: permissionRepo,
+	}
+}
+
+// ListExceptions retrieves the exceptions of a tenant matching a filter, oldest first, with
+// pagination
+func (s *ExceptionService) ListExceptions(filter models.ExceptionFilter, limit, offset int, userID, tenantID string) ([]models.UnmatchedTransaction, int, error) {
+	if err := s.authorizeView(userID, tenantID); err != nil {
+		return nil, 0, err
+	}
+
+	if filter.MatchSetID != "" {
+		if err := s.checkMatchSet(filter.MatchSetID, tenantID); err != nil {
+			return nil, 0, err
+		}
+	}
+	if filter.Status != "" && !validExceptionStatus(filter.Status) {
+		return nil, 0, errors.New("invalid filter: unknown status " + filter.Status)
+	}
+	if filter.ReasonCode != "" && !validExceptionReason(filter.ReasonCode) {
+		return nil, 0, errors.New("invalid filter: unknown reason code " + filter.ReasonCode)
+	}
+
+	filter.TenantID = tenantID
+	filter.Now = time.Now()
+	exceptions, total, err := s.unmatchedRepo.ListExceptions(filter, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i := range exceptions {
+		setExceptionAge(&exceptions[i], filter.Now)
+	}
+	return exceptions, total, nil
+}
+
+// GetException retrieves an exception and the comments left on it
+func (s *ExceptionService) GetException(id, userID, tenantID string) (*models.UnmatchedTransaction, []models.ExceptionComment, error) {
+	if err := s.authorizeView(userID, tenantID); err != nil {
+		return nil, nil, err
+	}
+
+	exception, err := s.tenantException(id, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	comments, err := s.unmatchedRepo.GetExceptionComments(tenantID, exception.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if comments == nil {
+		comments = []models.ExceptionComment{}
+	}
+
+	return exception, comments, nil
+}
+
+// UpdateException changes the status, reason code or assignee of an exception. Writing one off
+// takes it out of the reconciliation for good, so it requires the approve matches permission.
+func (s *ExceptionService) UpdateException(id string, update models.ExceptionUpdate, userID, tenantID string) (*models.UnmatchedTransaction, error) {
+	if err := s.authorizeWork(userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	exception, err := s.tenantException(id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Status != nil {
+		if !validExceptionStatus(*update.Status) {
+			return nil, errors.New("invalid exception: status must be open, investigating, resolved or written_off")
+		}
+		if *update.Status == models.ExceptionStatusWrittenOff && exception.Status != models.ExceptionStatusWrittenOff {
+			hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermApproveMatches, tenantID)
+			if err != nil {
+				return nil, err
+			}
+			if !hasPermission {
+				return nil, errors.New("unauthorized: requires approve matches permission to write off an exception")
+			}
+		}
+		exception.Status = *update.Status
+	}
+
+	if update.ReasonCode != nil {
+		if !validExceptionReason(*update.ReasonCode) {
+			return nil, errors.New("invalid exception: reason_code must be missing_counterpart, amount_mismatch, timing or duplicate")
+		}
+		exception.ReasonCode = *update.ReasonCode
+		exception.ReasonCodeManual = true
+	}
+
+	if update.AssigneeID != nil {
+		assigneeID := strings.TrimSpace(*update.AssigneeID)
+		if assigneeID != "" {
+			if err := s.checkTenantUser(assigneeID, tenantID); err != nil {
+				return nil, err
+			}
+		}
+		exception.AssigneeID = assigneeID
+	}
+
+	if err := s.unmatchedRepo.UpdateException(exception); err != nil {
+		return nil, err
+	}
+
+	setExceptionAge(exception, time.Now())
+	return exception, nil
+}
+
+// AddComment leaves a comment on an exception
+func (s *ExceptionService) AddComment(id, body, userID, tenantID string) (*models.ExceptionComment, error) {
+	if err := s.authorizeWork(userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	exception, err := s.tenantException(id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, errors.New("invalid comment: body is required")
+	}
+	if len(body) > maxExceptionCommentLength {
+		return nil, errors.New("invalid comment: body must be at most 4000 characters")
+	}
+
+	comment := &models.ExceptionComment{
+		ExceptionID: exception.ID,
+		TenantID:    exception.TenantID,
+		UserID:      userID,
+		Body:        body,
+	}
+	if err := s.unmatchedRepo.AddExceptionComment(comment); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// GetSummary counts the exceptions of a tenant, or of one of its match sets when matchSetID is
+// set, by status, and the outstanding ones by age bucket and reason code
+func (s *ExceptionService) GetSummary(matchSetID, userID, tenantID string) (*models.ExceptionSummary, error) {
+	if err := s.authorizeView(userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	if matchSetID != "" {
+		if err := s.checkMatchSet(matchSetID, tenantID); err != nil {
+			return nil, err
+		}
+	}
+
+	counts, err := s.unmatchedRepo.CountExceptions(tenantID, matchSetID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.ExceptionSummary{
+		ByStatus:            make(map[string]int),
+		OutstandingByAge:    make(map[string]int),
+		OutstandingByReason: make(map[string]int),
+	}
+	for _, bucket := range models.ExceptionAgeBuckets {
+		summary.OutstandingByAge[bucket.Label] = 0
+	}
+	for _, count := range counts {
+		summary.ByStatus[count.Status] += count.Count
+		if count.Status != models.ExceptionStatusOpen && count.Status != models.ExceptionStatusInvestigating {
+			continue
+		}
+		summary.Outstanding += count.Count
+		summary.OutstandingByReason[count.ReasonCode] += count.Count
+		if bucket := exceptionAgeBucket(count.AgeDays); bucket != nil {
+			summary.OutstandingByAge[bucket.Label] += count.Count
+		}
+	}
+
+	return summary, nil
+}
+
+// ParseExceptionAgeBucket returns the age bucket with a label such as 8-30 or 90+
+func ParseExceptionAgeBucket(label string) (*models.ExceptionAgeBucket, error) {
+	for i := range models.ExceptionAgeBuckets {
+		if models.ExceptionAgeBuckets[i].Label == label {
+			bucket := models.ExceptionAgeBuckets[i]
+			return &bucket, nil
+		}
+	}
+	return nil, errors.New("invalid filter: age bucket must be one of 0-7, 8-30, 31-60, 61-90 or 90+")
+}
+
+// exceptionAgeBucket returns the bucket an age in days falls in
+func exceptionAgeBucket(ageDays int) *models.ExceptionAgeBucket {
+	for i, bucket := range models.ExceptionAgeBuckets {
+		if ageDays >= bucket.MinDays && (bucket.MaxDays < 0 || ageDays <= bucket.MaxDays) {
+			return &models.ExceptionAgeBuckets[i]
+		}
+	}
+	return nil
+}
+
+// setExceptionAge sets the age of an exception in whole days at now
+func setExceptionAge(exception *models.UnmatchedTransaction, now time.Time) {
+	exception.AgeDays = 0
+	if age := now.Sub(exception.CreatedAt); age > 0 {
+		exception.AgeDays = int(age / (24 * time.Hour))
+	}
+}
+
+// validExceptionStatus reports whether a status is one of the exception statuses
+func validExceptionStatus(status string) bool {
+	switch status {
+	case models.ExceptionStatusOpen, models.ExceptionStatusInvestigating,
+		models.ExceptionStatusResolved, models.ExceptionStatusWrittenOff:
+		return true
+	}
+	return false
+}
+
+// validExceptionReason reports whether a reason code is one of the exception reason codes
+func validExceptionReason(reasonCode string) bool {
+	switch reasonCode {
+	case models.ExceptionReasonMissingCounterpart, models.ExceptionReasonAmountMismatch,
+		models.ExceptionReasonTiming, models.ExceptionReasonDuplicate:
+		return true
+	}
+	return false
+}
+
+// authorizeView checks that a user may view exceptions
+func (s *ExceptionService) authorizeView(userID, tenantID string) error {
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermViewTransactions, tenantID)
+	if err != nil {
+		return err
+	}
+	if !hasPermission {
+		return errors.New("unauthorized: requires view transactions permission")
+	}
+	return nil
+}
+
+// authorizeWork checks that a user may work exceptions
+func (s *ExceptionService) authorizeWork(userID, tenantID string) error {
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermMatchTransactions, tenantID)
+	if err != nil {
+		return err
+	}
+	if !hasPermission {
+		return errors.New("unauthorized: requires match transactions permission")
+	}
+	return nil
+}
+
+// tenantException returns an exception when it belongs to the tenant, with its age set
+func (s *ExceptionService) tenantException(id, tenantID string) (*models.UnmatchedTransaction, error) {
+	exception, err := s.unmatchedRepo.GetUnmatchedTransactionByID(tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if exception.TenantID != tenantID {
+		return nil, repository.ErrUnmatchedTransactionNotFound
+	}
+	setExceptionAge(exception, time.Now())
+	return exception, nil
+}
+
+// checkMatchSet checks that a match set belongs to the tenant
+func (s *ExceptionService) checkMatchSet(matchSetID, tenantID string) error {
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
+	if err != nil {
+		return err
+	}
+	if matchSet.TenantID != tenantID {
+		return errors.New("match set not found in this tenant")
+	}
+	return nil
+}
+
+// checkTenantUser checks that a user belongs to the tenant, so exceptions are only assigned to
+// users who can see them
+func (s *ExceptionService) checkTenantUser(userID, tenantID string) error {
+	userIDs, err := s.tenantRepo.GetTenantUsers(tenantID)
+	if err != nil {
+		return err
+	}
+	for _, id := range userIDs {
+		if id == userID {
+			return nil
+		}
+	}
+	return errors.New("invalid exception: assignee is not a user of this tenant")
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"strings"
+	"testing"
+	"time"
+)
+
+// denyPermission grants every permission but one
+type denyPermission struct {
+	repository.PermissionRepository
+	denied models.Permission
+}
+
+func (p denyPermission) HasPermission(userID string, permission models.Permission, tenantID string) (bool, error) {
+	return permission != p.denied, nil
+}
+
+func TestClassifyUnmatched(t *testing.T) {
+	ledger := []models.Transaction{
+		tx("L-dup-1", 50, 1, "INV-1"),
+		tx("L-dup-2", 50, 1, "inv-1 "),
+		tx("L-short", 99.5, 2, "INV-2"),
+		tx("L-late", 75, 3, "INV-3"),
+		tx("L-alone", 12, 4, "INV-4"),
+	}
+	bank := []models.Transaction{
+		tx("B-short", -100, 2, "INV-2"),
+		tx("B-late", -75, 20, "PAYMENT"),
+	}
+
+	reasons := classifyUnmatched([][]models.Transaction{ledger, bank}, "No matching transaction found")
+
+	want := map[string]string{
+		"L-dup-1": models.ExceptionReasonDuplicate,
+		"L-dup-2": models.ExceptionReasonDuplicate,
+		"L-short": models.ExceptionReasonAmountMismatch,
+		"B-short": models.ExceptionReasonAmountMismatch,
+		"L-late":  models.ExceptionReasonTiming,
+		"B-late":  models.ExceptionReasonTiming,
+		"L-alone": models.ExceptionReasonMissingCounterpart,
+	}
+	for id, code := range want {
+		if reasons[id].code != code {
+			t.Errorf("%s: expected reason code %s, got %s", id, code, reasons[id].code)
+		}
+	}
+	if reasons["L-alone"].message != "No matching transaction found" {
+		t.Errorf("expected the fallback message for a missing counterpart, got %q", reasons["L-alone"].message)
+	}
+	if !strings.Contains(reasons["L-short"].message, "INV-2") {
+		t.Errorf("expected the amount mismatch message to name the reference, got %q", reasons["L-short"].message)
+	}
+}
+
+func TestExceptionService_WorksExceptions(t *testing.T) {
+	matchSetRepo := repository.NewMatchSetRepository()
+	unmatchedRepo := repository.NewUnmatchedTransactionRepository()
+	tenantRepo := repository.NewTenantRepository()
+
+	matchSetRepo.CreateMatchSet(&models.MatchSet{ID: "set-1", Name: "Bank", TenantID: "tenant-1"})
+	tenantRepo.CreateTenant(&models.Tenant{ID: "tenant-1", Name: "Acme"})
+	if err := tenantRepo.AssignUserToTenant("user-2", "tenant-1"); err != nil {
+		t.Fatalf("failed to assign user: %v", err)
+	}
+
+	var exceptions []*models.UnmatchedTransaction
+	for _, unmatchedTx := range []*models.UnmatchedTransaction{
+		{MatchSetID: "set-1", TransactionID: "t-1", TenantID: "tenant-1", ReasonCode: models.ExceptionReasonTiming},
+		{MatchSetID: "set-1", TransactionID: "t-2", TenantID: "tenant-1"},
+		{MatchSetID: "set-1", TransactionID: "t-3", TenantID: "tenant-1", ReasonCode: models.ExceptionReasonDuplicate},
+		{MatchSetID: "set-other", TransactionID: "t-4", TenantID: "tenant-2"},
+	} {
+		if err := unmatchedRepo.SaveUnmatchedTransaction(unmatchedTx); err != nil {
+			t.Fatalf("failed to save unmatched transaction: %v", err)
+		}
+		exceptions = append(exceptions, unmatchedTx)
+	}
+
+	service := NewExceptionService(unmatchedRepo, matchSetRepo, tenantRepo, allowAllPermissions{})
+
+	// New exceptions are open, unassigned and categorized
+	open, total, err := service.ListExceptions(models.ExceptionFilter{Status: models.ExceptionStatusOpen}, 20, 0, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("failed to list exceptions: %v", err)
+	}
+	if total != 3 || len(open) != 3 {
+		t.Fatalf("expected the 3 exceptions of the tenant to be open, got %d", total)
+	}
+	if exceptions[1].ReasonCode != models.ExceptionReasonMissingCounterpart {
+		t.Errorf("expected an uncategorized exception to be missing its counterpart, got %s", exceptions[1].ReasonCode)
+	}
+
+	// Assign one and start investigating it
+	investigating := models.ExceptionStatusInvestigating
+	assignee := "user-2"
+	updated, err := service.UpdateException(exceptions[0].ID, models.ExceptionUpdate{Status: &investigating, AssigneeID: &assignee}, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("failed to update exception: %v", err)
+	}
+	if updated.Status != investigating || updated.AssigneeID != "user-2" || updated.ResolvedAt != nil {
+		t.Errorf("expected an unresolved exception investigated by user-2, got %+v", updated)
+	}
+
+	// A later run keeps the reason code an analyst set but replaces its own
+	amountMismatch := models.ExceptionReasonAmountMismatch
+	if _, err := service.UpdateException(exceptions[0].ID, models.ExceptionUpdate{ReasonCode: &amountMismatch}, "user-1", "tenant-1"); err != nil {
+		t.Fatalf("failed to update exception: %v", err)
+	}
+	for _, unmatchedTx := range []*models.UnmatchedTransaction{
+		{MatchSetID: "set-1", TransactionID: "t-1", TenantID: "tenant-1", ReasonCode: models.ExceptionReasonTiming},
+		{MatchSetID: "set-1", TransactionID: "t-2", TenantID: "tenant-1", ReasonCode: models.ExceptionReasonTiming},
+	} {
+		if err := unmatchedRepo.SaveUnmatchedTransaction(unmatchedTx); err != nil {
+			t.Fatalf("failed to save unmatched transaction: %v", err)
+		}
+	}
+	if exception, _, _ := service.GetException(exceptions[0].ID, "user-1", "tenant-1"); exception.ReasonCode != amountMismatch {
+		t.Errorf("expected a rerun to keep the analyst's reason code, got %s", exception.ReasonCode)
+	}
+	if exception, _, _ := service.GetException(exceptions[1].ID, "user-1", "tenant-1"); exception.ReasonCode != models.ExceptionReasonTiming {
+		t.Errorf("expected a rerun to reclassify the exception, got %s", exception.ReasonCode)
+	}
+
+	stranger := "user-9"
+	if _, err := service.UpdateException(exceptions[0].ID, models.ExceptionUpdate{AssigneeID: &stranger}, "user-1", "tenant-1"); err == nil || !strings.Contains(err.Error(), "invalid") {
+		t.Errorf("expected assigning a user outside the tenant to be invalid, got %v", err)
+	}
+
+	mine, _, err := service.ListExceptions(models.ExceptionFilter{AssigneeID: "user-2"}, 20, 0, "user-2", "tenant-1")
+	if err != nil || len(mine) != 1 || mine[0].ID != exceptions[0].ID {
+		t.Errorf("expected user-2 to own one exception, got %v (%v)", mine, err)
+	}
+	unassigned, _, err := service.ListExceptions(models.ExceptionFilter{Unassigned: true}, 20, 0, "user-1", "tenant-1")
+	if err != nil || len(unassigned) != 2 {
+		t.Errorf("expected 2 unassigned exceptions, got %d (%v)", len(unassigned), err)
+	}
+
+	// Filter by age bucket
+	fresh, _ := ParseExceptionAgeBucket("0-7")
+	aged, _ := ParseExceptionAgeBucket("8-30")
+	if list, _, _ := service.ListExceptions(models.ExceptionFilter{Age: fresh}, 20, 0, "user-1", "tenant-1"); len(list) != 3 {
+		t.Errorf("expected all exceptions to be in the 0-7 bucket, got %d", len(list))
+	}
+	if list, _, _ := service.ListExceptions(models.ExceptionFilter{Age: aged}, 20, 0, "user-1", "tenant-1"); len(list) != 0 {
+		t.Errorf("expected no exceptions in the 8-30 bucket, got %d", len(list))
+	}
+	if _, err := ParseExceptionAgeBucket("3-5"); err == nil {
+		t.Error("expected an unknown age bucket to be rejected")
+	}
+
+	// Comments
+	if _, err := service.AddComment(exceptions[0].ID, "  ", "user-2", "tenant-1"); err == nil {
+		t.Error("expected an empty comment to be rejected")
+	}
+	if _, err := service.AddComment(exceptions[0].ID, "Bank shows it on the 20th", "user-2", "tenant-1"); err != nil {
+		t.Fatalf("failed to add comment: %v", err)
+	}
+	_, comments, err := service.GetException(exceptions[0].ID, "user-1", "tenant-1")
+	if err != nil || len(comments) != 1 || comments[0].UserID != "user-2" {
+		t.Errorf("expected the comment of user-2, got %v (%v)", comments, err)
+	}
+
+	// Writing off requires the approve matches permission
+	writtenOff := models.ExceptionStatusWrittenOff
+	restricted := NewExceptionService(unmatchedRepo, matchSetRepo, tenantRepo, denyPermission{denied: models.PermApproveMatches})
+	if _, err := restricted.UpdateException(exceptions[2].ID, models.ExceptionUpdate{Status: &writtenOff}, "user-2", "tenant-1"); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("expected writing off without approval permission to be unauthorized, got %v", err)
+	}
+	updated, err = service.UpdateException(exceptions[2].ID, models.ExceptionUpdate{Status: &writtenOff}, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("failed to write off exception: %v", err)
+	}
+	if updated.ResolvedAt == nil {
+		t.Error("expected a written off exception to record when it was resolved")
+	}
+
+	// The close waits on the open and investigating exceptions only
+	summary, err := service.GetSummary("", "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("failed to summarize exceptions: %v", err)
+	}
+	if summary.Outstanding != 2 || summary.OutstandingByAge["0-7"] != 2 || summary.ByStatus[models.ExceptionStatusWrittenOff] != 1 {
+		t.Errorf("expected 2 outstanding exceptions aged 0-7 and 1 written off, got %+v", summary)
+	}
+	if summary.OutstandingByReason[models.ExceptionReasonAmountMismatch] != 1 || summary.OutstandingByReason[models.ExceptionReasonTiming] != 1 {
+		t.Errorf("expected outstanding exceptions by reason, got %+v", summary.OutstandingByReason)
+	}
+
+	// Exceptions of other tenants are out of reach
+	if _, _, err := service.GetException(exceptions[3].ID, "user-1", "tenant-1"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected another tenant's exception to be not found, got %v", err)
+	}
+}
+
+func TestExceptionAgeBucket(t *testing.T) {
+	now := time.Date(2024, time.March, 31, 12, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		createdAt time.Time
+		bucket    string
+	}{
+		{now.Add(-time.Hour), "0-7"},
+		{now.AddDate(0, 0, -7), "0-7"},
+		{now.AddDate(0, 0, -8), "8-30"},
+		{now.AddDate(0, 0, -90), "61-90"},
+		{now.AddDate(0, 0, -91), "90+"},
+	} {
+		exception := &models.UnmatchedTransaction{CreatedAt: test.createdAt}
+		setExceptionAge(exception, now)
+		if bucket := exceptionAgeBucket(exception.AgeDays); bucket == nil || bucket.Label != test.bucket {
+			t.Errorf("created %s: expected bucket %s, got %v", test.createdAt, test.bucket, bucket)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// Export sizes
+const (
+	exportPageSize              = maxTransactionSearchLimit // Transactions read per search page
+	exportExceptionPageSize     = 500
+	defaultExportStreamRowLimit = 50000 // Larger exports run in the background
+)
+
+// ExportRequest describes an export: the kind of rows, their format, and the search selecting
+// the transactions they are made of. Matched and unmatched exports need a match set.
+type ExportRequest struct {
+	Kind       string
+	Format     string
+	MatchSetID string
+	Filter     models.TransactionFilter
+}
+
+// ExportPlan is an export request checked for a user, ready to stream or to start in the
+// background
+type ExportPlan struct {
+	Request    ExportRequest
+	Rows       int  // Transactions the search selects; matched and unmatched exports hold part of them
+	Background bool // Too many rows to stream in a request
+
+	userID   string
+	tenantID string
+}
+
+// ExportService streams transactions and match results in the formats of spreadsheets and BI
+// tools. Rows are read a search page at a time and written as they are read.
+type ExportService struct {
+	transactionService *TransactionService
+	matchRepo          repository.MatchRepository
+	unmatchedRepo      repository.UnmatchedTransactionRepository
+	exportRepo         repository.ExportRepository
+	permissionRepo     repository.PermissionRepository
+	storage            StorageService
+	streamRowLimit     int
+}
+
+// NewExportService creates a new export service
+func NewExportService(
+	transactionService *TransactionService,
+	matchRepo repository.MatchRepository,
+	unmatchedRepo repository.UnmatchedTransactionRepository,
+	exportRepo repository.ExportRepository,
+	permissionRepo repository.PermissionRepository,
+	storage StorageService,
+) *ExportService {
+	return &ExportService{
+		transactionService: transactionService,
+		matchRepo:          matchRepo,
+		unmatchedRepo:      unmatchedRepo,
+		exportRepo:         exportRepo,
+		permissionRepo:     permissionRepo,
+		storage:            storage,
+		streamRowLimit:     defaultExportStreamRowLimit,
+	}
+}
+
+// PlanExport checks an export request for a user and counts the transactions it selects, which
+// decides whether it streams or runs in the background. The cursor and limit of its search are
+// ignored: an export holds every page.
+func (s *ExportService) PlanExport(request ExportRequest, userID, tenantID string) (*ExportPlan, error) {
+	switch request.Kind {
+	case models.ExportKindTransactions:
+	case models.ExportKindMatched, models.ExportKindUnmatched:
+		if request.MatchSetID == "" {
+			return nil, errors.New("invalid export: " + request.Kind + " exports need a match set")
+		}
+	default:
+		return nil, errors.New("invalid export: kind must be transactions, matched or unmatched")
+	}
+	if ExportContentType(request.Format) == "" {
+		return nil, errors.New("invalid export: format must be csv, xlsx, jsonl or parquet")
+	}
+
+	request.Filter.Cursor = ""
+	request.Filter.Limit = 0
+	rows, err := s.transactionService.CountTransactions(request.Filter, request.MatchSetID, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExportPlan{
+		Request:    request,
+		Rows:       rows,
+		Background: rows > s.streamRowLimit,
+		userID:     userID,
+		tenantID:   tenantID,
+	}, nil
+}
+
+// WriteExport writes the rows of a planned export to w as they are read and returns how many it
+// wrote. Groups and exceptions of the match set are looked up in memory; transactions stream.
+func (s *ExportService) WriteExport(w io.Writer, plan *ExportPlan) (int, error) {
+	request := plan.Request
+
+	var groupOf map[string]string
+	var exceptionOf map[string]*models.UnmatchedTransaction
+	if request.Kind != models.ExportKindTransactions {
+		groups, err := s.matchRepo.GetMatchGroupsByMatchSet(plan.tenantID, request.MatchSetID)
+		if err != nil {
+			return 0, err
+		}
+		groupOf = make(map[string]string)
+		for matchID, transactionIDs := range groups {
+			for _, transactionID := range transactionIDs {
+				groupOf[transactionID] = matchID
+			}
+		}
+	}
+	if request.Kind == models.ExportKindUnmatched {
+		var err error
+		if exceptionOf, err = s.matchSetExceptions(request.MatchSetID, plan.tenantID); err != nil {
+			return 0, err
+		}
+	}
+
+	writer, err := newExportWriter(w, request.Format, exportColumns(request.Kind))
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	filter := request.Filter
+	filter.Limit = exportPageSize
+	for {
+		page, err := s.transactionService.SearchTransactions(filter, request.MatchSetID, plan.userID, plan.tenantID)
+		if err != nil {
+			return written, err
+		}
+
+		for i := range page.Transactions {
+			transaction := &page.Transactions[i]
+			matchID, matched := groupOf[transaction.ID]
+
+			var row []interface{}
+			switch request.Kind {
+			case models.ExportKindMatched:
+				if !matched {
+					continue
+				}
+				row = append([]interface{}{matchID}, transactionExportRow(transaction)...)
+			case models.ExportKindUnmatched:
+				if matched {
+					continue
+				}
+				row = append(transactionExportRow(transaction), exceptionExportRow(exceptionOf[transaction.ID])...)
+			default:
+				row = transactionExportRow(transaction)
+			}
+
+			if err := writer.WriteRow(row); err != nil {
+				return written, err
+			}
+			written++
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	return written, writer.Close()
+}
+
+// StartExport records a background job for a planned export and writes its file to storage in
+// the background. The job's download link is available once it completes.
+func (s *ExportService) StartExport(plan *ExportPlan) (*models.ExportJob, error) {
+	job := &models.ExportJob{
+		TenantID:    plan.tenantID,
+		MatchSetID:  plan.Request.MatchSetID,
+		Kind:        plan.Request.Kind,
+		Format:      plan.Request.Format,
+		Status:      models.ExportStatusRunning,
+		RequestedBy: plan.userID,
+	}
+	if err := s.exportRepo.CreateExportJob(job); err != nil {
+		return nil, err
+	}
+
+	started := *job
+	go s.runExport(job, plan)
+	return &started, nil
+}
+
+// runExport writes the file of an export job to storage and records its outcome
+func (s *ExportService) runExport(job *models.ExportJob, plan *ExportPlan) {
+	reader, writer := io.Pipe()
+	counter := &countingWriter{w: writer}
+	rows := make(chan int, 1)
+	go func() {
+		written, err := s.WriteExport(counter, plan)
+		writer.CloseWithError(err)
+		rows <- written
+	}()
+
+	fileKey, err := s.storage.SaveFile(job.TenantID, "exports", ExportFilename(job.Kind, job.Format, job.CreatedAt), reader)
+	// Stop the export when storage gave up on it
+	reader.CloseWithError(err)
+
+	job.RowCount = <-rows
+	completedAt := time.Now()
+	job.CompletedAt = &completedAt
+	if err != nil {
+		job.Status = models.ExportStatusFailed
+		job.Error = err.Error()
+		log.Printf("Export %s failed: %v", job.ID, err)
+	} else {
+		job.Status = models.ExportStatusCompleted
+		job.FileKey = fileKey
+		job.FileSize = counter.n
+	}
+
+	if err := s.exportRepo.UpdateExportJob(job); err != nil {
+		log.Printf("Failed to record the outcome of export %s: %v", job.ID, err)
+	}
+}
+
+// GetExportJob retrieves a background export job, with its download link once it completed
+func (s *ExportService) GetExportJob(jobID, userID, tenantID string) (*models.ExportJob, error) {
+	// Check if user has permission to view transactions
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermViewTransactions, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission {
+		return nil, errors.New("unauthorized: requires view transactions permission")
+	}
+
+	job, err := s.exportRepo.GetExportJobByID(tenantID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.TenantID != tenantID {
+		return nil, errors.New("export not found in this tenant")
+	}
+
+	if job.Status == models.ExportStatusCompleted {
+		if job.DownloadURL, err = s.storage.GetFileURL(tenantID, job.FileKey); err != nil {
+			return nil, err
+		}
+	}
+	return job, nil
+}
+
+// matchSetExceptions returns the exceptions recorded for the transactions of a match set by
+// transaction ID
+func (s *ExportService) matchSetExceptions(matchSetID, tenantID string) (map[string]*models.UnmatchedTransaction, error) {
+	filter := models.ExceptionFilter{TenantID: tenantID, MatchSetID: matchSetID, Now: time.Now()}
+	exceptions := make(map[string]*models.UnmatchedTransaction)
+	for offset := 0; ; offset += exportExceptionPageSize {
+		page, total, err := s.unmatchedRepo.ListExceptions(filter, exportExceptionPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for i := range page {
+			setExceptionAge(&page[i], filter.Now)
+			exceptions[page[i].TransactionID] = &page[i]
+		}
+		if len(page) == 0 || offset+len(page) >= total {
+			return exceptions, nil
+		}
+	}
+}
+
+// exportColumns returns the columns of an export kind
+func exportColumns(kind string) []exportColumn {
+	columns := []exportColumn{
+		{"transaction_id", exportText},
+		{"data_source_id", exportText},
+		{"transaction_date", exportText},
+		{"post_date", exportText},
+		{"amount", exportAmount},
+		{"currency", exportText},
+		{"reference", exportText},
+		{"description", exportText},
+		{"status", exportText},
+		{"custom_fields", exportText},
+	}
+	switch kind {
+	case models.ExportKindMatched:
+		columns = append([]exportColumn{{"match_id", exportText}}, columns...)
+	case models.ExportKindUnmatched:
+		columns = append(columns,
+			exportColumn{"exception_status", exportText},
+			exportColumn{"reason_code", exportText},
+			exportColumn{"reason", exportText},
+			exportColumn{"assignee_id", exportText},
+			exportColumn{"age_days", exportCount},
+		)
+	}
+	return columns
+}
+
+// transactionExportRow returns the transaction cells of an export row. Custom fields are a JSON
+// object so that every row has the same columns.
+func transactionExportRow(transaction *models.Transaction) []interface{} {
+	customFields := ""
+	if len(transaction.CustomFields) > 0 {
+		data, _ := json.Marshal(transaction.CustomFields)
+		customFields = string(data)
+	}
+
+	return []interface{}{
+		transaction.ID,
+		transaction.DataSourceID,
+		exportDate(transaction.TransactionDate),
+		exportDate(transaction.PostDate),
+		transaction.Amount,
+		transaction.Currency,
+		transaction.Reference,
+		transaction.Description,
+		transaction.Status,
+		customFields,
+	}
+}
+
+// exceptionExportRow returns the exception cells of an unmatched export row, empty for a
+// transaction no run has recorded an exception for
+func exceptionExportRow(exception *models.UnmatchedTransaction) []interface{} {
+	if exception == nil {
+		return []interface{}{"", "", "", "", 0}
+	}
+	return []interface{}{exception.Status, exception.ReasonCode, exception.Reason, exception.AssigneeID, exception.AgeDays}
+}
+
+// exportDate formats a date of an export, empty when it is not set
+func exportDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format("2006-01-02")
+}
+
+// ExportContentType returns the content type of an export format, or "" for an unknown format
+func ExportContentType(format string) string {
+	switch format {
+	case models.ExportFormatCSV:
+		return "text/csv"
+	case models.ExportFormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case models.ExportFormatJSONL:
+		return "application/x-ndjson"
+	case models.ExportFormatParquet:
+		return "application/vnd.apache.parquet"
+	}
+	return ""
+}
+
+// ExportFilename returns the file name of an export started at a time
+func ExportFilename(kind, format string, startedAt time.Time) string {
+	return fmt.Sprintf("%s_%s.%s", kind, startedAt.UTC().Format("20060102_150405"), format)
+}
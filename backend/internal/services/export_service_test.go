@@ -0,0 +1,231 @@
+package services
+
+import (
+	"archive/zip"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestExportService returns an export service over a match set of two data sources holding
+// a matched pair, an unmatched transaction with an exception, and one outside the match set
+func newTestExportService(t *testing.T) *ExportService {
+	matchSetRepo := repository.NewMatchSetRepository()
+	transactionRepo := repository.NewTransactionRepository()
+	matchRepo := repository.NewMatchRepository()
+	unmatchedRepo := repository.NewUnmatchedTransactionRepository()
+
+	matchSet := &models.MatchSet{ID: "set-1", Name: "Bank", TenantID: "tenant-1"}
+	matchSetRepo.CreateMatchSet(matchSet)
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "ledger")
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "bank")
+
+	for _, transaction := range []models.Transaction{
+		fxTx("L1", 100, 1, "USD"),
+		fxTx("R1", 100, 2, "USD"),
+		fxTx("L2", 42.125, 3, "USD"),
+		fxTx("X1", 7, 4, "USD"),
+	} {
+		transaction.DataSourceID = "ledger"
+		switch transaction.ID {
+		case "R1":
+			transaction.DataSourceID = "bank"
+		case "L2":
+			transaction.Description = `Wire "42", café`
+			transaction.CustomFields = map[string]string{"cost_center": "ops"}
+		case "X1":
+			transaction.DataSourceID = "other"
+		}
+		transactionRepo.CreateTransaction(&transaction)
+	}
+
+	matchRepo.CreateMatchGroup(&models.TransactionMatch{ID: "match-1", TenantID: matchSet.TenantID, MatchSetID: matchSet.ID}, []string{"L1", "R1"})
+	unmatchedRepo.SaveUnmatchedTransaction(&models.UnmatchedTransaction{
+		MatchSetID: matchSet.ID, TransactionID: "L2", TenantID: "tenant-1", ReasonCode: models.ExceptionReasonTiming,
+	})
+
+	storage, err := NewStorageService(map[string]string{"basePath": t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewStorageService() error = %v", err)
+	}
+
+	transactionService := NewTransactionService(transactionRepo, matchSetRepo, allowAllPermissions{})
+	return NewExportService(transactionService, matchRepo, unmatchedRepo, repository.NewExportRepository(), allowAllPermissions{}, storage)
+}
+
+// export plans and writes an export, failing the test on error
+func export(t *testing.T, service *ExportService, request ExportRequest) []byte {
+	plan, err := service.PlanExport(request, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("PlanExport(%s %s) error = %v", request.Kind, request.Format, err)
+	}
+	if plan.Background {
+		t.Fatalf("PlanExport(%s %s) runs in the background, want a stream", request.Kind, request.Format)
+	}
+
+	var buf bytes.Buffer
+	if _, err := service.WriteExport(&buf, plan); err != nil {
+		t.Fatalf("WriteExport(%s %s) error = %v", request.Kind, request.Format, err)
+	}
+	return buf.Bytes()
+}
+
+func TestExportService_ExportsKinds(t *testing.T) {
+	service := newTestExportService(t)
+	oldestFirst := models.TransactionFilter{Sort: []models.TransactionSort{{Field: models.TransactionSortDate}}}
+
+	tests := []struct {
+		name    string
+		request ExportRequest
+		want    string
+	}{
+		{
+			name:    "transactions",
+			request: ExportRequest{Kind: models.ExportKindTransactions, Format: models.ExportFormatCSV, Filter: oldestFirst},
+			want: "transaction_id,data_source_id,transaction_date,post_date,amount,currency,reference,description,status,custom_fields\n" +
+				"L1,ledger,2024-03-01,,100,USD,,,,\n" +
+				"R1,bank,2024-03-02,,100,USD,,,,\n" +
+				`L2,ledger,2024-03-03,,42.125,USD,,"Wire ""42"", café",,"{""cost_center"":""ops""}"` + "\n" +
+				"X1,other,2024-03-04,,7,USD,,,,\n",
+		},
+		{
+			name: "search filters",
+			request: ExportRequest{Kind: models.ExportKindTransactions, Format: models.ExportFormatCSV,
+				Filter: models.TransactionFilter{Text: "WIRE"}},
+			want: "transaction_id,data_source_id,transaction_date,post_date,amount,currency,reference,description,status,custom_fields\n" +
+				`L2,ledger,2024-03-03,,42.125,USD,,"Wire ""42"", café",,"{""cost_center"":""ops""}"` + "\n",
+		},
+		{
+			name:    "matched",
+			request: ExportRequest{Kind: models.ExportKindMatched, Format: models.ExportFormatCSV, MatchSetID: "set-1", Filter: oldestFirst},
+			want: "match_id,transaction_id,data_source_id,transaction_date,post_date,amount,currency,reference,description,status,custom_fields\n" +
+				"match-1,L1,ledger,2024-03-01,,100,USD,,,,\n" +
+				"match-1,R1,bank,2024-03-02,,100,USD,,,,\n",
+		},
+		{
+			name:    "unmatched",
+			request: ExportRequest{Kind: models.ExportKindUnmatched, Format: models.ExportFormatJSONL, MatchSetID: "set-1"},
+			want: `{"transaction_id":"L2","data_source_id":"ledger","transaction_date":"2024-03-03","post_date":"","amount":42.125,` +
+				`"currency":"USD","reference":"","description":"Wire \"42\", café","status":"","custom_fields":"{\"cost_center\":\"ops\"}",` +
+				`"exception_status":"open","reason_code":"timing","reason":"","assignee_id":"","age_days":0}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(export(t, service, tt.request)); got != tt.want {
+				t.Errorf("export =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportService_WritesSpreadsheetsAndParquet(t *testing.T) {
+	service := newTestExportService(t)
+	request := ExportRequest{Kind: models.ExportKindMatched, MatchSetID: "set-1"}
+
+	request.Format = models.ExportFormatXLSX
+	content := export(t, service, request)
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("XLSX export is not a zip archive: %v", err)
+	}
+	var sheet []byte
+	for _, file := range archive.File {
+		if file.Name == "xl/worksheets/sheet1.xml" {
+			part, _ := file.Open()
+			sheet, _ = io.ReadAll(part)
+		}
+	}
+	if !bytes.Contains(sheet, []byte(`<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">match_id</t>`)) ||
+		!bytes.Contains(sheet, []byte(`<c r="F3" s="2"><v>100</v></c>`)) || !bytes.HasSuffix(sheet, []byte("</sheetData></worksheet>")) {
+		t.Errorf("XLSX worksheet does not hold the header and both matched rows:\n%s", sheet)
+	}
+
+	request.Format = models.ExportFormatParquet
+	content = export(t, service, request)
+	if !bytes.HasPrefix(content, []byte("PAR1")) || !bytes.HasSuffix(content, []byte("PAR1")) {
+		t.Fatalf("Parquet export is not framed by PAR1")
+	}
+	footerSize := int(binary.LittleEndian.Uint32(content[len(content)-8:]))
+	footer := content[len(content)-8-footerSize : len(content)-8]
+	if !bytes.Contains(footer, []byte("match_id")) || !bytes.Contains(footer, []byte("custom_fields")) {
+		t.Errorf("Parquet footer does not describe the columns")
+	}
+
+	// The first column chunk is a data page of both match IDs, right after the magic
+	if content[4] != 0x15 || !bytes.Contains(content[4:len(content)-8-footerSize], []byte("\x07\x00\x00\x00match-1\x07\x00\x00\x00match-1")) {
+		t.Errorf("Parquet export does not start with the match_id page")
+	}
+}
+
+func TestExportService_RunsLargeExportsInBackground(t *testing.T) {
+	service := newTestExportService(t)
+	service.streamRowLimit = 2
+
+	plan, err := service.PlanExport(ExportRequest{Kind: models.ExportKindTransactions, Format: models.ExportFormatCSV}, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("PlanExport() error = %v", err)
+	}
+	if plan.Rows != 4 || !plan.Background {
+		t.Fatalf("PlanExport() = %d rows, background %v, want 4 rows in the background", plan.Rows, plan.Background)
+	}
+
+	job, err := service.StartExport(plan)
+	if err != nil {
+		t.Fatalf("StartExport() error = %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status == models.ExportStatusRunning && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		if job, err = service.GetExportJob(job.ID, "user-1", "tenant-1"); err != nil {
+			t.Fatalf("GetExportJob() error = %v", err)
+		}
+	}
+	if job.Status != models.ExportStatusCompleted || job.RowCount != 4 || job.DownloadURL == "" {
+		t.Fatalf("GetExportJob() = %+v, want completed with 4 rows and a download link", job)
+	}
+
+	content, err := os.ReadFile(job.DownloadURL)
+	if err != nil {
+		t.Fatalf("download link does not lead to the file: %v", err)
+	}
+	if int64(len(content)) != job.FileSize || strings.Count(string(content), "\n") != 5 {
+		t.Errorf("exported file = %d bytes:\n%s\nwant %d bytes of a header and 4 rows", len(content), content, job.FileSize)
+	}
+
+	if _, err := service.GetExportJob(job.ID, "user-1", "tenant-2"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("GetExportJob() from another tenant error = %v, want not found", err)
+	}
+}
+
+func TestExportService_RejectsInvalidExports(t *testing.T) {
+	service := newTestExportService(t)
+
+	tests := []struct {
+		name     string
+		request  ExportRequest
+		tenantID string
+		want     string
+	}{
+		{"unknown kind", ExportRequest{Kind: "users", Format: models.ExportFormatCSV}, "tenant-1", "invalid export"},
+		{"unknown format", ExportRequest{Kind: models.ExportKindTransactions, Format: "docx"}, "tenant-1", "invalid export"},
+		{"matched without a match set", ExportRequest{Kind: models.ExportKindMatched, Format: models.ExportFormatCSV}, "tenant-1", "invalid export"},
+		{"invalid search", ExportRequest{Kind: models.ExportKindTransactions, Format: models.ExportFormatCSV,
+			Filter: models.TransactionFilter{Sort: []models.TransactionSort{{Field: "amount"}, {Field: "amount"}}}}, "tenant-1", "invalid sort"},
+		{"match set of another tenant", ExportRequest{Kind: models.ExportKindUnmatched, Format: models.ExportFormatCSV, MatchSetID: "set-1"}, "tenant-2", "not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.PlanExport(tt.request, "user-1", tt.tenantID); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("PlanExport() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
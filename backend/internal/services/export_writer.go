@@ -0,0 +1,175 @@
+package services
+
+import (
+	"archive/zip"
+	"backend/internal/models"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// Types of export columns. Text cells are strings, amounts float64 and counts int.
+const (
+	exportText = iota
+	exportAmount
+	exportCount
+)
+
+// xlsxMaxRows is the number of rows a worksheet holds, header included
+const xlsxMaxRows = 1048576
+
+// exportColumn is a column of an export
+type exportColumn struct {
+	name string
+	kind int
+}
+
+// exportWriter writes the rows of an export to a stream as they come. Close writes whatever the
+// format keeps for its end; it does not close the stream.
+type exportWriter interface {
+	WriteRow(row []interface{}) error
+	Close() error
+}
+
+// newExportWriter returns the writer of an export format
+func newExportWriter(w io.Writer, format string, columns []exportColumn) (exportWriter, error) {
+	switch format {
+	case models.ExportFormatCSV:
+		return newCSVExportWriter(w, columns)
+	case models.ExportFormatXLSX:
+		return newXLSXExportWriter(w, columns)
+	case models.ExportFormatJSONL:
+		return &jsonlExportWriter{out: bufio.NewWriter(w), columns: columns}, nil
+	case models.ExportFormatParquet:
+		return newParquetWriter(w, columns), nil
+	}
+	return nil, errors.New("invalid export: format must be csv, xlsx, jsonl or parquet")
+}
+
+// exportCellText formats an export cell as text. Amounts keep every decimal.
+func exportCellText(cell interface{}) string {
+	if amount, ok := cell.(float64); ok {
+		return strconv.FormatFloat(amount, 'f', -1, 64)
+	}
+	return reportCellText(cell)
+}
+
+// csvExportWriter writes a header line, then a line per row
+type csvExportWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func newCSVExportWriter(w io.Writer, columns []exportColumn) (*csvExportWriter, error) {
+	writer := csv.NewWriter(w)
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.name
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	return &csvExportWriter{writer: writer, record: make([]string, len(columns))}, nil
+}
+
+func (e *csvExportWriter) WriteRow(row []interface{}) error {
+	for i, cell := range row {
+		e.record[i] = exportCellText(cell)
+	}
+	return e.writer.Write(e.record)
+}
+
+func (e *csvExportWriter) Close() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// jsonlExportWriter writes a JSON object per row, its keys in column order
+type jsonlExportWriter struct {
+	out     *bufio.Writer
+	columns []exportColumn
+}
+
+func (e *jsonlExportWriter) WriteRow(row []interface{}) error {
+	e.out.WriteByte('{')
+	for i, cell := range row {
+		if i > 0 {
+			e.out.WriteByte(',')
+		}
+		key, _ := json.Marshal(e.columns[i].name)
+		value, err := json.Marshal(cell)
+		if err != nil {
+			return err
+		}
+		e.out.Write(key)
+		e.out.WriteByte(':')
+		e.out.Write(value)
+	}
+	e.out.WriteByte('}')
+	return e.out.WriteByte('\n')
+}
+
+func (e *jsonlExportWriter) Close() error {
+	return e.out.Flush()
+}
+
+// xlsxExportWriter writes a workbook of a single worksheet, compressing rows into it as they come
+type xlsxExportWriter struct {
+	archive *zip.Writer
+	sheet   io.Writer
+	rows    int
+}
+
+func newXLSXExportWriter(w io.Writer, columns []exportColumn) (*xlsxExportWriter, error) {
+	archive := zip.NewWriter(w)
+	if err := writeXLSXWorkbook(archive, []string{"Export"}); err != nil {
+		return nil, err
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheet, xlsxSheetHead); err != nil {
+		return nil, err
+	}
+
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = column.name
+	}
+	if err := writeXLSXRow(sheet, 1, header, true); err != nil {
+		return nil, err
+	}
+	return &xlsxExportWriter{archive: archive, sheet: sheet, rows: 1}, nil
+}
+
+func (e *xlsxExportWriter) WriteRow(row []interface{}) error {
+	if e.rows == xlsxMaxRows {
+		return errors.New("invalid export: more rows than an XLSX worksheet holds, export to csv, jsonl or parquet")
+	}
+	e.rows++
+	return writeXLSXRow(e.sheet, e.rows, row, false)
+}
+
+func (e *xlsxExportWriter) Close() error {
+	if _, err := io.WriteString(e.sheet, xlsxSheetTail); err != nil {
+		return err
+	}
+	return e.archive.Close()
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
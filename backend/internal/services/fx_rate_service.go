@@ -0,0 +1,146 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FXRateTable looks up exchange rates by currency pair and date
+type FXRateTable struct {
+	rates map[string][]models.FXRate // "BASE/QUOTE" -> rates ordered by date
+}
+
+// NewFXRateTable builds a rate table. When several rates share a pair and date the last one wins.
+func NewFXRateTable(rates []models.FXRate) *FXRateTable {
+	table := &FXRateTable{rates: make(map[string][]models.FXRate)}
+	for _, rate := range rates {
+		key := fxPairKey(rate.BaseCurrency, rate.QuoteCurrency)
+		pair := table.rates[key]
+		if n := len(pair); n > 0 && sameDay(pair[n-1].RateDate, rate.RateDate) {
+			pair[n-1] = rate
+			continue
+		}
+		table.rates[key] = append(pair, rate)
+	}
+
+	for key := range table.rates {
+		pair := table.rates[key]
+		sort.SliceStable(pair, func(i, j int) bool { return pair[i].RateDate.Before(pair[j].RateDate) })
+	}
+	return table
+}
+
+// Rate returns how many units of to one unit of from buys on date, using the latest rate on or
+// before that date. The inverse pair is used when only the opposite direction is known.
+func (t *FXRateTable) Rate(from, to string, date time.Time) (float64, bool) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, true
+	}
+	if t == nil {
+		return 0, false
+	}
+
+	if rate, ok := latestRate(t.rates[fxPairKey(from, to)], date); ok {
+		return rate, true
+	}
+	if rate, ok := latestRate(t.rates[fxPairKey(to, from)], date); ok {
+		return 1 / rate, true
+	}
+	return 0, false
+}
+
+// Convert converts an amount between currencies at the rate for date
+func (t *FXRateTable) Convert(amount float64, from, to string, date time.Time) (float64, bool) {
+	rate, ok := t.Rate(from, to, date)
+	if !ok {
+		return 0, false
+	}
+	return amount * rate, true
+}
+
+// latestRate returns the last rate dated on or before date
+func latestRate(pair []models.FXRate, date time.Time) (float64, bool) {
+	i := sort.Search(len(pair), func(n int) bool { return pair[n].RateDate.After(date) })
+	if i == 0 {
+		return 0, false
+	}
+	return pair[i-1].Rate, true
+}
+
+func fxPairKey(base, quote string) string {
+	return strings.ToUpper(base) + "/" + strings.ToUpper(quote)
+}
+
+func sameDay(a, b time.Time) bool {
+	return daysBetween(a, b) == 0
+}
+
+// FXRateService provides methods for managing exchange rates
+type FXRateService struct {
+	fxRateRepo     repository.FXRateRepository
+	permissionRepo repository.PermissionRepository
+}
+
+// NewFXRateService creates a new FX rate service
+func NewFXRateService(fxRateRepo repository.FXRateRepository, permissionRepo repository.PermissionRepository) *FXRateService {
+	return &FXRateService{
+		fxRateRepo:     fxRateRepo,
+		permissionRepo: permissionRepo,
+	}
+}
+
+// SaveRates stores exchange rates for a tenant, replacing rates for the same pair and date
+func (s *FXRateService) SaveRates(rates []models.FXRate, userID, tenantID string) ([]models.FXRate, error) {
+	// Rates drive rule evaluation, so they are managed with rule permissions
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermUpdateRule, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission {
+		return nil, errors.New("unauthorized: requires update rule permission")
+	}
+
+	for i := range rates {
+		rates[i].BaseCurrency = strings.ToUpper(strings.TrimSpace(rates[i].BaseCurrency))
+		rates[i].QuoteCurrency = strings.ToUpper(strings.TrimSpace(rates[i].QuoteCurrency))
+		if len(rates[i].BaseCurrency) != 3 || len(rates[i].QuoteCurrency) != 3 {
+			return nil, errors.New("invalid currency: expected 3-letter ISO codes")
+		}
+		if rates[i].BaseCurrency == rates[i].QuoteCurrency {
+			return nil, errors.New("invalid currency pair: base and quote currency are the same")
+		}
+		if rates[i].Rate <= 0 {
+			return nil, errors.New("invalid rate: must be greater than zero")
+		}
+		if rates[i].RateDate.IsZero() {
+			return nil, errors.New("invalid rate date: required")
+		}
+	}
+
+	for i := range rates {
+		rates[i].TenantID = tenantID
+		if err := s.fxRateRepo.SaveRate(&rates[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return rates, nil
+}
+
+// GetRates retrieves the exchange rates visible to a tenant for a period
+func (s *FXRateService) GetRates(from, to time.Time, userID, tenantID string) ([]models.FXRate, error) {
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermViewRule, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission {
+		return nil, errors.New("unauthorized: requires view rule permission")
+	}
+
+	return s.fxRateRepo.GetRates(tenantID, from, to)
+}
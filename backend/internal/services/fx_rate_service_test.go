@@ -0,0 +1,43 @@
+package services
+
+import (
+	"backend/internal/models"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFXRateTable_Rate(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, time.March, d, 0, 0, 0, 0, time.UTC) }
+
+	table := NewFXRateTable([]models.FXRate{
+		{BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: 1.08, RateDate: day(1)},
+		{BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: 1.10, RateDate: day(4)},
+		{BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: 1.12, RateDate: day(4)}, // Later rate for the same day wins
+	})
+
+	tests := []struct {
+		name     string
+		from, to string
+		date     time.Time
+		want     float64
+		wantOK   bool
+	}{
+		{"same currency", "usd", "USD", day(1), 1, true},
+		{"exact date", "EUR", "USD", day(1), 1.08, true},
+		{"latest before date", "EUR", "USD", day(3), 1.08, true},
+		{"last rate of the day", "EUR", "USD", day(10), 1.12, true},
+		{"inverse pair", "USD", "EUR", day(1), 1 / 1.08, true},
+		{"before first rate", "EUR", "USD", day(0), 0, false},
+		{"unknown pair", "GBP", "USD", day(4), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := table.Rate(tt.from, tt.to, tt.date)
+			if ok != tt.wantOK || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Rate(%s, %s) = %v, %v, want %v, %v", tt.from, tt.to, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
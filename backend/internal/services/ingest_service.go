@@ -0,0 +1,412 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Per-record ingest outcomes
+const (
+	IngestRecordCreated   = "created"
+	IngestRecordDuplicate = "duplicate"
+	IngestRecordError     = "error"
+)
+
+// Error definitions
+var (
+	ErrIdempotencyKeyRequired = errors.New("invalid request: idempotency key is required")
+	ErrEmptyBatch             = errors.New("invalid request: batch contains no records")
+)
+
+// IngestRecord is a single transaction pushed by an external system
+type IngestRecord struct {
+	ExternalID      string  `json:"externalId"`
+	TransactionDate string  `json:"transactionDate"` // YYYY-MM-DD
+	PostDate        string  `json:"postDate,omitempty"`
+	Description     string  `json:"description"`
+	Reference       string  `json:"reference"`
+	Amount          float64 `json:"amount"`
+	Currency        string  `json:"currency,omitempty"`
+
+	// Values of the data source's custom schema fields, by field name
+	CustomFields map[string]interface{} `json:"customFields,omitempty"`
+}
+
+// IngestBatch is a batch of records submitted in a single call
+type IngestBatch struct {
+	IdempotencyKey string         `json:"idempotencyKey"`
+	Records        []IngestRecord `json:"records"`
+}
+
+// IngestRecordResult reports what happened to a single record of a batch
+type IngestRecordResult struct {
+	Index         int    `json:"index"`
+	ExternalID    string `json:"externalId"`
+	Status        string `json:"status"` // created, duplicate, error
+	TransactionID string `json:"transactionId,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// IngestResult is the outcome of a batch, stored with the import so retries return the same answer
+type IngestResult struct {
+	ImportID       string               `json:"importId"`
+	DataSourceID   string               `json:"dataSourceId"`
+	IdempotencyKey string               `json:"idempotencyKey"`
+	Replayed       bool                 `json:"replayed"`
+	Total          int                  `json:"total"`
+	Created        int                  `json:"created"`
+	Duplicates     int                  `json:"duplicates"`
+	Errors         int                  `json:"errors"`
+	Results        []IngestRecordResult `json:"results"`
+}
+
+// IngestService accepts transactions pushed over the API by other systems
+type IngestService struct {
+	importRepo      repository.ImportRepository
+	transactionRepo repository.TransactionRepository
+	dataSourceRepo  repository.DataSourceRepository
+	autoRunService  *AutoRunService
+	maxBatchSize    int
+	staleAfter      time.Duration
+}
+
+// NewIngestService creates a new ingest service
+func NewIngestService(
+	importRepo repository.ImportRepository,
+	transactionRepo repository.TransactionRepository,
+	dataSourceRepo repository.DataSourceRepository,
+	autoRunService *AutoRunService,
+) *IngestService {
+	return &IngestService{
+		importRepo:      importRepo,
+		transactionRepo: transactionRepo,
+		dataSourceRepo:  dataSourceRepo,
+		autoRunService:  autoRunService,
+		maxBatchSize:    utils.GetEnvIntOrDefault("INGEST_MAX_BATCH_SIZE", 1000),
+		staleAfter:      time.Duration(utils.GetEnvIntOrDefault("INGEST_STALE_AFTER_MINUTES", 15)) * time.Minute,
+	}
+}
+
+// MaxBatchSize returns the largest number of records accepted in one batch
+func (s *IngestService) MaxBatchSize() int {
+	return s.maxBatchSize
+}
+
+// IngestTransactions stores a batch of transactions for a data source of a tenant.
+// Batches are idempotent on (data source, idempotency key): a repeated key
+// returns the stored result of the first call instead of importing again. A key
+// whose earlier batch failed, or stalled while processing, can be retried.
+func (s *IngestService) IngestTransactions(tenantID, dataSourceID, userID string, batch *IngestBatch) (*IngestResult, error) {
+	key := strings.TrimSpace(batch.IdempotencyKey)
+	if key == "" {
+		return nil, ErrIdempotencyKeyRequired
+	}
+
+	if len(batch.Records) == 0 {
+		return nil, ErrEmptyBatch
+	}
+
+	if len(batch.Records) > s.maxBatchSize {
+		return nil, fmt.Errorf("invalid request: batch contains %d records, maximum is %d", len(batch.Records), s.maxBatchSize)
+	}
+
+	// Make sure the data source exists in the tenant
+	if _, err := s.dataSourceRepo.GetDataSourceByID(tenantID, dataSourceID); err != nil {
+		if err == repository.ErrDataSourceNotFound {
+			return nil, ErrDataSourceNotFound
+		}
+		return nil, err
+	}
+
+	// Return the stored result if this batch was already processed
+	importRecord, replayed, err := s.claimImport(tenantID, dataSourceID, userID, key, len(batch.Records))
+	if err != nil {
+		return nil, err
+	}
+	if replayed != nil {
+		return replayed, nil
+	}
+
+	result := &IngestResult{
+		ImportID:       importRecord.ID,
+		DataSourceID:   dataSourceID,
+		IdempotencyKey: key,
+		Total:          len(batch.Records),
+		Results:        make([]IngestRecordResult, len(batch.Records)),
+	}
+
+	// Look up records that were already ingested by an earlier batch
+	externalIDs := make([]string, 0, len(batch.Records))
+	for _, record := range batch.Records {
+		if record.ExternalID != "" {
+			externalIDs = append(externalIDs, record.ExternalID)
+		}
+	}
+
+	existing, err := s.transactionRepo.GetTransactionsByExternalIDs(tenantID, dataSourceID, externalIDs)
+	if err != nil {
+		s.importRepo.UpdateImportStatus(tenantID, importRecord.ID, "Failed", len(batch.Records), 0, len(batch.Records))
+		return nil, err
+	}
+
+	existingIDs := make(map[string]string, len(existing))
+	for _, transaction := range existing {
+		existingIDs[transaction.ExternalID] = transaction.ID
+	}
+
+	seen := make(map[string]bool, len(batch.Records))
+	var transactions []models.Transaction
+	var created []int
+
+	for i, record := range batch.Records {
+		recordResult := IngestRecordResult{
+			Index:      i,
+			ExternalID: record.ExternalID,
+		}
+
+		transaction, err := s.buildTransaction(&record, tenantID, dataSourceID, userID, importRecord.ID)
+		switch {
+		case err != nil:
+			recordResult.Status = IngestRecordError
+			recordResult.Error = err.Error()
+		case existingIDs[record.ExternalID] != "":
+			recordResult.Status = IngestRecordDuplicate
+			recordResult.TransactionID = existingIDs[record.ExternalID]
+		case seen[record.ExternalID]:
+			recordResult.Status = IngestRecordError
+			recordResult.Error = "external ID appears more than once in this batch"
+		default:
+			seen[record.ExternalID] = true
+			recordResult.Status = IngestRecordCreated
+			transactions = append(transactions, *transaction)
+			created = append(created, i)
+		}
+
+		result.Results[i] = recordResult
+		s.storeRawRecord(tenantID, importRecord, i, &record, recordResult.Error)
+	}
+
+	// Valid records are written together so a failure leaves no partial batch behind
+	if len(transactions) > 0 {
+		if err := s.transactionRepo.CreateTransactions(transactions); err != nil {
+			s.importRepo.UpdateImportStatus(tenantID, importRecord.ID, "Failed", len(batch.Records), 0, len(batch.Records))
+			return nil, err
+		}
+
+		for n, i := range created {
+			result.Results[i].TransactionID = transactions[n].ID
+		}
+	}
+
+	for _, recordResult := range result.Results {
+		switch recordResult.Status {
+		case IngestRecordCreated:
+			result.Created++
+		case IngestRecordDuplicate:
+			result.Duplicates++
+		default:
+			result.Errors++
+		}
+	}
+
+	metadata, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.importRepo.UpdateImportMetadata(tenantID, importRecord.ID, metadata); err != nil {
+		return nil, err
+	}
+
+	if err := s.importRepo.UpdateImportStatus(tenantID, importRecord.ID, "Completed", result.Total, result.Created, result.Errors); err != nil {
+		return nil, err
+	}
+
+	// The batch is stored either way; a missed auto-run is caught up by the next import or run
+	if result.Created > 0 {
+		if err := s.autoRunService.ImportCompleted(tenantID, dataSourceID); err != nil {
+			log.Printf("Failed to request auto-runs for data source %s: %v", dataSourceID, err)
+		}
+	}
+
+	return result, nil
+}
+
+// claimImport returns the import a batch is processed under: a new one for a new key, or the
+// import of an earlier attempt that failed or stalled. For a key whose batch completed it
+// returns the stored result instead.
+func (s *IngestService) claimImport(tenantID, dataSourceID, userID, key string, rowCount int) (*models.ImportRecord, *IngestResult, error) {
+	existing, err := s.importRepo.GetImportByIdempotencyKey(tenantID, dataSourceID, key)
+	if err == repository.ErrImportNotFound {
+		importRecord := &models.ImportRecord{
+			DataSourceID:   dataSourceID,
+			FileName:       "api-batch-" + key,
+			Status:         "Processing",
+			RowCount:       rowCount,
+			ImportedBy:     userID,
+			IdempotencyKey: key,
+		}
+
+		if err := s.importRepo.CreateImport(tenantID, importRecord); err != nil {
+			if err == repository.ErrImportExists {
+				// A concurrent call with the same key got there first
+				result, err := s.replay(tenantID, dataSourceID, key)
+				return nil, result, err
+			}
+			return nil, nil, err
+		}
+		return importRecord, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if existing.Status == "Completed" {
+		result, err := replayImport(existing, key)
+		return nil, result, err
+	}
+
+	// Take over an attempt that failed or stalled; records it already stored come back as
+	// duplicates. Only one of several concurrent retries gets it.
+	if err := s.importRepo.ReclaimImport(tenantID, existing.ID, s.staleAfter); err != nil {
+		if err == repository.ErrImportInUse {
+			return nil, nil, fmt.Errorf("batch with idempotency key %q already exists and is still processing", key)
+		}
+		return nil, nil, err
+	}
+	return existing, nil, nil
+}
+
+// replay returns the stored result of an earlier batch with the same idempotency key
+func (s *IngestService) replay(tenantID, dataSourceID, key string) (*IngestResult, error) {
+	importRecord, err := s.importRepo.GetImportByIdempotencyKey(tenantID, dataSourceID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return replayImport(importRecord, key)
+}
+
+// replayImport returns the result stored with a completed import
+func replayImport(importRecord *models.ImportRecord, key string) (*IngestResult, error) {
+	if importRecord.Status != "Completed" || len(importRecord.Metadata) == 0 {
+		return nil, fmt.Errorf("batch with idempotency key %q already exists with status %s", key, importRecord.Status)
+	}
+
+	var result IngestResult
+	if err := json.Unmarshal(importRecord.Metadata, &result); err != nil {
+		return nil, err
+	}
+
+	result.Replayed = true
+	return &result, nil
+}
+
+// buildTransaction validates an ingest record and converts it to a transaction
+func (s *IngestService) buildTransaction(record *IngestRecord, tenantID, dataSourceID, userID, importID string) (*models.Transaction, error) {
+	if strings.TrimSpace(record.ExternalID) == "" {
+		return nil, errors.New("externalId is required")
+	}
+
+	if record.TransactionDate == "" {
+		return nil, errors.New("transactionDate is required")
+	}
+
+	transactionDate, err := time.Parse("2006-01-02", record.TransactionDate)
+	if err != nil {
+		return nil, errors.New("invalid transactionDate, expected YYYY-MM-DD")
+	}
+
+	postDate := transactionDate
+	if record.PostDate != "" {
+		postDate, err = time.Parse("2006-01-02", record.PostDate)
+		if err != nil {
+			return nil, errors.New("invalid postDate, expected YYYY-MM-DD")
+		}
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(record.Currency))
+	if currency == "" {
+		currency = "USD"
+	}
+
+	if len(currency) != 3 {
+		return nil, errors.New("invalid currency, expected a 3-letter ISO code")
+	}
+
+	customFields, err := customFieldValues(record.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Transaction{
+		TenantID:        tenantID,
+		DataSourceID:    dataSourceID,
+		TransactionDate: transactionDate,
+		PostDate:        postDate,
+		Description:     record.Description,
+		Reference:       record.Reference,
+		Amount:          record.Amount,
+		Currency:        currency,
+		Status:          "Unmatched",
+		ExternalID:      record.ExternalID,
+		ImportID:        importID,
+		CreatedBy:       userID,
+		CustomFields:    customFields,
+	}, nil
+}
+
+// customFieldValues converts custom field values to the strings stored on transactions.
+// Strings are kept as they are; numbers, booleans and nested values are stored as JSON.
+func customFieldValues(fields map[string]interface{}) (map[string]string, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	values := make(map[string]string, len(fields))
+	for name, value := range fields {
+		if strings.TrimSpace(name) == "" {
+			return nil, errors.New("invalid customFields: field names must not be empty")
+		}
+
+		switch v := value.(type) {
+		case nil:
+			continue
+		case string:
+			values[name] = v
+		default:
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid customFields: %s: %v", name, err)
+			}
+			values[name] = string(encoded)
+		}
+	}
+	return values, nil
+}
+
+// storeRawRecord keeps the record as it was received, like rows of an uploaded file
+func (s *IngestService) storeRawRecord(tenantID string, importRecord *models.ImportRecord, index int, record *IngestRecord, errorMessage string) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+
+	rawTx := &models.RawTransaction{
+		ImportID:     importRecord.ID,
+		DataSourceID: importRecord.DataSourceID,
+		RowNumber:    index + 1,
+		Data:         data,
+		ErrorMessage: errorMessage,
+	}
+
+	// Raw rows are for audit only; a failure here should not fail the batch
+	s.importRepo.CreateRawTransaction(tenantID, rawTx)
+}
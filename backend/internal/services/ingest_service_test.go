@@ -0,0 +1,144 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestIngestService creates an ingest service over mock repositories with a "bank" data
+// source in tenant-1
+func newTestIngestService(t *testing.T) (*IngestService, repository.ImportRepository, repository.TransactionRepository) {
+	importRepo := repository.NewImportRepository()
+	transactionRepo := repository.NewTransactionRepository()
+	dataSourceRepo := repository.NewDataSourceRepository()
+	matchSetRepo := repository.NewMatchSetRepository()
+
+	if err := dataSourceRepo.CreateDataSource(&models.DataSource{ID: "bank", TenantID: "tenant-1", Name: "Bank"}); err != nil {
+		t.Fatalf("CreateDataSource() error = %v", err)
+	}
+
+	autoRunService := NewAutoRunService(repository.NewAutoRunRepository(), matchSetRepo, repository.NewMatchProgressRepository(), allowAllPermissions{})
+	return NewIngestService(importRepo, transactionRepo, dataSourceRepo, autoRunService), importRepo, transactionRepo
+}
+
+func ingestRecord(externalID string, amount float64) IngestRecord {
+	return IngestRecord{ExternalID: externalID, TransactionDate: "2024-03-01", Reference: "INV-" + externalID, Amount: amount}
+}
+
+func TestIngestService_ReportsEveryRecord(t *testing.T) {
+	service, _, transactionRepo := newTestIngestService(t)
+
+	first := &IngestBatch{IdempotencyKey: "batch-1", Records: []IngestRecord{ingestRecord("e-1", 100)}}
+	if _, err := service.IngestTransactions("tenant-1", "bank", "user-1", first); err != nil {
+		t.Fatalf("IngestTransactions() error = %v", err)
+	}
+
+	invalid := ingestRecord("e-4", 40)
+	invalid.TransactionDate = "01/03/2024"
+	batch := &IngestBatch{IdempotencyKey: "batch-2", Records: []IngestRecord{
+		ingestRecord("e-1", 100),
+		ingestRecord("e-2", 20),
+		ingestRecord("e-2", 30),
+		invalid,
+	}}
+
+	result, err := service.IngestTransactions("tenant-1", "bank", "user-1", batch)
+	if err != nil {
+		t.Fatalf("IngestTransactions() error = %v", err)
+	}
+
+	want := []string{IngestRecordDuplicate, IngestRecordCreated, IngestRecordError, IngestRecordError}
+	for i, status := range want {
+		if result.Results[i].Status != status {
+			t.Errorf("record %d status = %s, want %s", i, result.Results[i].Status, status)
+		}
+	}
+	if result.Created != 1 || result.Duplicates != 1 || result.Errors != 2 {
+		t.Errorf("IngestTransactions() counts = %d created, %d duplicates, %d errors, want 1, 1, 2", result.Created, result.Duplicates, result.Errors)
+	}
+
+	if transactions, _ := transactionRepo.GetTransactionsByDataSourceID("tenant-1", "bank"); len(transactions) != 2 {
+		t.Errorf("stored transactions = %d, want 2", len(transactions))
+	}
+
+	if _, err := service.IngestTransactions("tenant-2", "bank", "user-1", &IngestBatch{IdempotencyKey: "batch-3", Records: []IngestRecord{ingestRecord("e-5", 1)}}); err != ErrDataSourceNotFound {
+		t.Errorf("IngestTransactions() into another tenant's data source error = %v, want %v", err, ErrDataSourceNotFound)
+	}
+}
+
+func TestIngestService_ReplaysCompletedBatch(t *testing.T) {
+	service, _, transactionRepo := newTestIngestService(t)
+
+	batch := &IngestBatch{IdempotencyKey: "batch-1", Records: []IngestRecord{ingestRecord("e-1", 100), ingestRecord("e-2", 20)}}
+	first, err := service.IngestTransactions("tenant-1", "bank", "user-1", batch)
+	if err != nil {
+		t.Fatalf("IngestTransactions() error = %v", err)
+	}
+
+	again, err := service.IngestTransactions("tenant-1", "bank", "user-1", batch)
+	if err != nil {
+		t.Fatalf("IngestTransactions() repeated error = %v", err)
+	}
+	if !again.Replayed || again.ImportID != first.ImportID || again.Created != 2 {
+		t.Errorf("repeated IngestTransactions() = %+v, want the first result replayed", again)
+	}
+
+	if transactions, _ := transactionRepo.GetTransactionsByDataSourceID("tenant-1", "bank"); len(transactions) != 2 {
+		t.Errorf("stored transactions = %d, want 2", len(transactions))
+	}
+}
+
+func TestIngestService_RetriesFailedAndStaleBatches(t *testing.T) {
+	service, importRepo, _ := newTestIngestService(t)
+
+	tests := []struct {
+		name    string
+		status  string
+		age     time.Duration
+		wantErr bool
+	}{
+		{name: "failed", status: "Failed"},
+		{name: "stale processing", status: "Processing", age: time.Hour},
+		{name: "processing", status: "Processing", age: time.Minute, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := "batch-" + tt.name
+			earlier := &models.ImportRecord{DataSourceID: "bank", FileName: "api-batch-" + key, Status: tt.status, IdempotencyKey: key}
+			if err := importRepo.CreateImport("tenant-1", earlier); err != nil {
+				t.Fatalf("CreateImport() error = %v", err)
+			}
+			// The earlier attempt got as far as keeping its raw record
+			if err := importRepo.CreateRawTransaction("tenant-1", &models.RawTransaction{ImportID: earlier.ID, DataSourceID: "bank", RowNumber: 1}); err != nil {
+				t.Fatalf("CreateRawTransaction() error = %v", err)
+			}
+			earlier.UpdatedAt = time.Now().Add(-tt.age)
+
+			batch := &IngestBatch{IdempotencyKey: key, Records: []IngestRecord{ingestRecord("ext-"+tt.name, 10)}}
+			result, err := service.IngestTransactions("tenant-1", "bank", "user-1", batch)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "already exists") {
+					t.Errorf("IngestTransactions() error = %v, want a conflict", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("IngestTransactions() error = %v", err)
+			}
+
+			if result.Replayed || result.ImportID != earlier.ID || result.Created != 1 {
+				t.Errorf("IngestTransactions() = %+v, want the batch imported under the earlier import", result)
+			}
+			if earlier.Status != "Completed" {
+				t.Errorf("import status = %s, want Completed", earlier.Status)
+			}
+			if _, total, _ := importRepo.GetRawTransactionsByImport("tenant-1", earlier.ID, 10, 0); total != 1 {
+				t.Errorf("raw transactions = %d, want 1", total)
+			}
+		})
+	}
+}
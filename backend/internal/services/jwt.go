@@ -19,13 +19,17 @@ func NewJWTService() *JWTService {
 	return &JWTService{}
 }
 
-func (s *JWTService) GenerateToken(user *models.User) (*TokenInfo, error) {
+// GenerateToken issues a token for a user scoped to a tenant and carrying the user's roles. The
+// tenant ID is empty for a user who belongs to no tenant.
+func (s *JWTService) GenerateToken(user *models.User, tenantID string, roles []string) (*TokenInfo, error) {
 	expirationTime := time.Now().Add(time.Minute * time.Duration(config.JWTExpiryMinutes))
 
 	claims := jwt.MapClaims{
-		"user_id": user.ID,
-		"email":   user.Email,
-		"exp":     expirationTime.Unix(),
+		"user_id":   user.ID,
+		"email":     user.Email,
+		"tenant_id": tenantID,
+		"roles":     roles,
+		"exp":       expirationTime.Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxGLAccountLength is the longest general ledger account an adjustment may name
+const maxGLAccountLength = 50
+
+// AdjustmentRequest describes an adjustment closing the difference in a match group. The amount
+// is always the difference, so a group closed by an adjustment balances exactly.
+type AdjustmentRequest struct {
+	Reason    string `json:"reason"`
+	GLAccount string `json:"gl_account"`
+}
+
+// GetAdjustmentSettings retrieves the adjustment settings of a tenant. A tenant that never set a
+// write-off limit gets a limit of zero, which allows no adjustments.
+func (s *MatchService) GetAdjustmentSettings(userID, tenantID string) (*models.AdjustmentSettings, error) {
+	// Check if user has permission to view transactions
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermViewTransactions, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission {
+		return nil, errors.New("unauthorized: requires view transactions permission")
+	}
+
+	settings, err := s.adjustmentRepo.GetSettings(tenantID)
+	if err == repository.ErrAdjustmentSettingsNotFound {
+		return &models.AdjustmentSettings{TenantID: tenantID}, nil
+	}
+	return settings, err
+}
+
+// SetWriteOffLimit sets the largest adjustment a tenant's approvers may post
+func (s *MatchService) SetWriteOffLimit(limit float64, userID, tenantID string) (*models.AdjustmentSettings, error) {
+	// Check if user has permission to manage tenants
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermManageTenants, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission {
+		return nil, errors.New("unauthorized: requires manage tenants permission")
+	}
+
+	if limit < 0 {
+		return nil, errors.New("invalid settings: write_off_limit cannot be negative")
+	}
+
+	settings := &models.AdjustmentSettings{
+		TenantID:      tenantID,
+		WriteOffLimit: limit,
+		UpdatedBy:     userID,
+	}
+	if err := s.adjustmentRepo.SaveSettings(settings); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// GetAdjustments retrieves a page of the tenant's adjustments, newest first, with the total count
+func (s *MatchService) GetAdjustments(limit, offset int, userID, tenantID string) ([]models.Adjustment, int, error) {
+	// Check if user has permission to view transactions
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermViewTransactions, tenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !hasPermission {
+		return nil, 0, errors.New("unauthorized: requires view transactions permission")
+	}
+
+	return s.adjustmentRepo.GetAdjustmentsByTenant(tenantID, limit, offset)
+}
+
+// GetMatchAdjustments retrieves the adjustments posted into a match group, voided ones included
+func (s *MatchService) GetMatchAdjustments(matchID, userID, tenantID string) ([]models.Adjustment, error) {
+	// Check if user has permission to view match sets
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermViewMatchSet, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission {
+		return nil, errors.New("unauthorized: requires view match set permission")
+	}
+
+	match, err := s.matchRepo.GetMatchByID(tenantID, matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the match belongs to the tenant
+	if match.TenantID != tenantID {
+		return nil, errors.New("match not found in this tenant")
+	}
+
+	adjustments, err := s.adjustmentRepo.GetAdjustmentsByMatch(tenantID, match.ID)
+	if err != nil {
+		return nil, err
+	}
+	if adjustments == nil {
+		adjustments = []models.Adjustment{}
+	}
+	return adjustments, nil
+}
+
+// AdjustMatch closes the difference between the sides of a pending match group with an
+// adjustment. The difference may not exceed the tenant's write-off limit.
+func (s *MatchService) AdjustMatch(matchID string, request AdjustmentRequest, userID, tenantID string) (*models.Adjustment, error) {
+	if err := s.authorizeAdjustment(userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	request, err := validateAdjustment(request)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.writeOffSettings(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	match, matchSet, err := s.activeMatch(matchID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.matchRepo.GetMatchGroup(tenantID, match.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.loadTransactions(matchSet, members)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, adjustment, err := s.newAdjustment(matchSet, settings, transactions, request, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.adjustmentRepo.AdjustMatchGroup(match, members, transaction, adjustment); err != nil {
+		return nil, groupError(err)
+	}
+
+	return adjustment, nil
+}
+
+// CreateAdjustedMatch groups unmatched transactions of a match set like CreateManualMatch and
+// closes the difference between the sides with an adjustment
+func (s *MatchService) CreateAdjustedMatch(matchSetID string, transactionIDs []string, request AdjustmentRequest, userID, tenantID string) (*models.TransactionMatch, error) {
+	if err := s.authorize(userID, tenantID); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeAdjustment(userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	request, err := validateAdjustment(request)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.writeOffSettings(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the match set
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the match set belongs to the tenant
+	if matchSet.TenantID != tenantID {
+		return nil, errors.New("match set not found in this tenant")
+	}
+
+	transactions, err := s.loadTransactions(matchSet, transactionIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range transactions {
+		if err := requireUnmatched(&transaction); err != nil {
+			return nil, err
+		}
+	}
+
+	transaction, adjustment, err := s.newAdjustment(matchSet, settings, transactions, request, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	match := &models.TransactionMatch{
+		MatchStatus: "Pending",
+		MatchType:   "Manual",
+		MatchSetID:  matchSet.ID,
+		TenantID:    matchSet.TenantID,
+		MatchedBy:   userID,
+		MatchScore:  1,
+	}
+	if err := s.adjustmentRepo.CreateAdjustedMatchGroup(match, transactionIDs, transaction, adjustment); err != nil {
+		return nil, groupError(err)
+	}
+
+	return match, nil
+}
+
+// newAdjustment builds the adjustment closing the difference between the sides of a group and
+// its transaction, which the repository posts into the group together
+func (s *MatchService) newAdjustment(matchSet *models.MatchSet, settings *models.AdjustmentSettings, transactions []models.Transaction, request AdjustmentRequest, userID string) (*models.Transaction, *models.Adjustment, error) {
+	balance, err := s.adjustmentFor(matchSet, settings, transactions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The adjustment is dated with the latest transaction it closes
+	date := transactions[0].TransactionDate
+	for _, transaction := range transactions {
+		if transaction.TransactionDate.After(date) {
+			date = transaction.TransactionDate
+		}
+	}
+
+	transaction := &models.Transaction{
+		TenantID:        matchSet.TenantID,
+		DataSourceID:    settings.DataSourceID,
+		TransactionDate: date,
+		PostDate:        date,
+		Description:     "Adjustment: " + request.Reason,
+		Reference:       request.GLAccount,
+		Amount:          float64(balance.left-balance.right) / amountScale,
+		Currency:        balance.currency,
+		Status:          "Unmatched",
+		CreatedBy:       userID,
+		CustomFields: map[string]string{
+			"gl_account":        request.GLAccount,
+			"adjustment_reason": request.Reason,
+		},
+	}
+	adjustment := &models.Adjustment{
+		TenantID:   matchSet.TenantID,
+		MatchSetID: matchSet.ID,
+		Amount:     transaction.Amount,
+		Currency:   transaction.Currency,
+		Reason:     request.Reason,
+		GLAccount:  request.GLAccount,
+		CreatedBy:  userID,
+	}
+
+	return transaction, adjustment, nil
+}
+
+// adjustmentFor works out the balance of a group an adjustment would close, checking that there
+// is a difference to close and that it is within the write-off limit
+func (s *MatchService) adjustmentFor(matchSet *models.MatchSet, settings *models.AdjustmentSettings, transactions []models.Transaction) (*groupBalance, error) {
+	balance, err := s.balance(matchSet, transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	difference := absInt64(balance.left - balance.right)
+	if difference == 0 {
+		return nil, errors.New("invalid adjustment: the sides already balance")
+	}
+	if difference > toAmountUnits(settings.WriteOffLimit) {
+		return nil, fmt.Errorf("invalid adjustment: the sides differ by %.2f %s, more than the write-off limit of %.2f",
+			float64(difference)/amountScale, balance.currency, settings.WriteOffLimit)
+	}
+	return balance, nil
+}
+
+// adjustmentSource returns the data source holding a tenant's adjustments, or an empty ID when
+// the tenant has none
+func (s *MatchService) adjustmentSource(tenantID string) (string, error) {
+	settings, err := s.adjustmentRepo.GetSettings(tenantID)
+	if err == repository.ErrAdjustmentSettingsNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return settings.DataSourceID, nil
+}
+
+// writeOffSettings returns the adjustment settings of a tenant that allows adjustments
+func (s *MatchService) writeOffSettings(tenantID string) (*models.AdjustmentSettings, error) {
+	settings, err := s.adjustmentRepo.GetSettings(tenantID)
+	if err == repository.ErrAdjustmentSettingsNotFound || (err == nil && settings.WriteOffLimit <= 0) {
+		return nil, errors.New("invalid adjustment: no write-off limit is set for this tenant")
+	}
+	return settings, err
+}
+
+// authorizeAdjustment checks that a user may post adjustments. Adjustments write amounts off, so
+// only approvers may post them.
+func (s *MatchService) authorizeAdjustment(userID, tenantID string) error {
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermApproveMatches, tenantID)
+	if err != nil {
+		return err
+	}
+	if !hasPermission {
+		return errors.New("unauthorized: requires approve matches permission to post adjustments")
+	}
+	return nil
+}
+
+// validateAdjustment checks the reason and general ledger account of an adjustment
+func validateAdjustment(request AdjustmentRequest) (AdjustmentRequest, error) {
+	request.Reason = strings.TrimSpace(request.Reason)
+	request.GLAccount = strings.TrimSpace(request.GLAccount)
+	if request.Reason == "" {
+		return request, errors.New("invalid adjustment: a reason is required")
+	}
+	if request.GLAccount == "" {
+		return request, errors.New("invalid adjustment: a gl_account is required")
+	}
+	if len(request.GLAccount) > maxGLAccountLength {
+		return request, fmt.Errorf("invalid adjustment: gl_account must be at most %d characters", maxGLAccountLength)
+	}
+	return request, nil
+}
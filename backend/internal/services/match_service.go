@@ -0,0 +1,599 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MatchService provides methods for creating and changing match groups by hand and for reviewing them
+type MatchService struct {
+	matchRepo       repository.MatchRepository
+	matchSetRepo    repository.MatchSetRepository
+	ruleRepo        repository.RuleRepository
+	transactionRepo repository.TransactionRepository
+	permissionRepo  repository.PermissionRepository
+	fxRateRepo      repository.FXRateRepository
+	adjustmentRepo  repository.AdjustmentRepository
+}
+
+// NewMatchService creates a new match service
+func NewMatchService(
+	matchRepo repository.MatchRepository,
+	matchSetRepo repository.MatchSetRepository,
+	ruleRepo repository.RuleRepository,
+	transactionRepo repository.TransactionRepository,
+	permissionRepo repository.PermissionRepository,
+	fxRateRepo repository.FXRateRepository,
+	adjustmentRepo repository.AdjustmentRepository,
+) *MatchService {
+	return &MatchService{
+		matchRepo:       matchRepo,
+		matchSetRepo:    matchSetRepo,
+		ruleRepo:        ruleRepo,
+		transactionRepo: transactionRepo,
+		permissionRepo:  permissionRepo,
+		fxRateRepo:      fxRateRepo,
+		adjustmentRepo:  adjustmentRepo,
+	}
+}
+
+// CreateManualMatch groups unmatched transactions from at least two data sources of a match set.
+// The group must balance within the amount tolerance of the match set's rule.
+func (s *MatchService) CreateManualMatch(matchSetID string, transactionIDs []string, userID, tenantID string) (*models.TransactionMatch, error) {
+	if err := s.authorize(userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	// Get the match set
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the match set belongs to the tenant
+	if matchSet.TenantID != tenantID {
+		return nil, errors.New("match set not found in this tenant")
+	}
+
+	transactions, err := s.loadTransactions(matchSet, transactionIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range transactions {
+		if err := requireUnmatched(&transaction); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.checkBalance(matchSet, transactions); err != nil {
+		return nil, err
+	}
+
+	match := &models.TransactionMatch{
+		MatchStatus: "Pending",
+		MatchType:   "Manual",
+		MatchSetID:  matchSet.ID,
+		TenantID:    matchSet.TenantID,
+		MatchedBy:   userID,
+		MatchScore:  1,
+	}
+	if err := s.matchRepo.CreateMatchGroup(match, transactionIDs); err != nil {
+		return nil, groupError(err)
+	}
+
+	return match, nil
+}
+
+// Unmatch dissolves a match group and marks its transactions as unmatched again. Adjustments
+// posted into the group are voided.
+func (s *MatchService) Unmatch(matchID, userID, tenantID string) error {
+	if err := s.authorize(userID, tenantID); err != nil {
+		return err
+	}
+
+	match, _, err := s.activeMatch(matchID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	members, err := s.matchRepo.GetMatchGroup(tenantID, match.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.adjustmentRepo.DissolveAdjustedMatchGroup(tenantID, match.ID, members, userID); err != nil {
+		return groupError(err)
+	}
+
+	return nil
+}
+
+// AddTransactionToMatch adds an unmatched transaction to a match group. The group must still
+// balance afterwards.
+func (s *MatchService) AddTransactionToMatch(matchID, transactionID, userID, tenantID string) (*models.TransactionMatch, error) {
+	if err := s.authorize(userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	match, matchSet, err := s.activeMatch(matchID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.matchRepo.GetMatchGroup(tenantID, match.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range members {
+		if id == transactionID {
+			return nil, fmt.Errorf("invalid transaction %s: already in this match", transactionID)
+		}
+	}
+
+	transactions, err := s.loadTransactions(matchSet, append(append([]string(nil), members...), transactionID))
+	if err != nil {
+		return nil, err
+	}
+	if err := requireUnmatched(&transactions[len(transactions)-1]); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkBalance(matchSet, transactions); err != nil {
+		return nil, err
+	}
+
+	if err := s.matchRepo.AddTransactionToMatchGroup(match, transactionID, members); err != nil {
+		return nil, groupError(err)
+	}
+
+	return match, nil
+}
+
+// RemoveTransactionFromMatch takes a transaction out of a match group and marks it as unmatched
+// again. The rest of the group must still balance; to break a group up entirely, unmatch it.
+func (s *MatchService) RemoveTransactionFromMatch(matchID, transactionID, userID, tenantID string) (*models.TransactionMatch, error) {
+	if err := s.authorize(userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	match, matchSet, err := s.activeMatch(matchID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.matchRepo.GetMatchGroup(tenantID, match.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var remaining []string
+	for _, id := range members {
+		if id != transactionID {
+			remaining = append(remaining, id)
+		}
+	}
+	if len(remaining) == len(members) {
+		return nil, repository.ErrTransactionNotInMatch
+	}
+
+	transactions, err := s.loadTransactions(matchSet, remaining)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkBalance(matchSet, transactions); err != nil {
+		return nil, err
+	}
+
+	// An adjustment taken out of its group is voided
+	if err := s.adjustmentRepo.RemoveFromAdjustedMatchGroup(tenantID, match.ID, transactionID, members, userID); err != nil {
+		return nil, groupError(err)
+	}
+
+	return s.matchRepo.GetMatchByID(tenantID, match.ID)
+}
+
+// maxReviewBatch is the largest number of matches reviewed in one request
+const maxReviewBatch = 500
+
+// MatchReview is the decision on one match in a bulk rejection
+type MatchReview struct {
+	MatchID string `json:"match_id"`
+	Reason  string `json:"reason"`
+}
+
+// MatchReviewResult is the outcome of the review of one match in a bulk request
+type MatchReviewResult struct {
+	MatchID string `json:"match_id"`
+	Status  string `json:"status"` // Approved, Rejected or Failed
+	Error   string `json:"error,omitempty"`
+}
+
+// GetPendingMatches retrieves a page of the tenant's matches waiting for approval, oldest first,
+// with the total number waiting
+func (s *MatchService) GetPendingMatches(limit, offset int, userID, tenantID string) ([]models.TransactionMatch, int, error) {
+	// Check if user has permission to approve matches
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermApproveMatches, tenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !hasPermission {
+		return nil, 0, errors.New("unauthorized: requires approve matches permission")
+	}
+
+	return s.matchRepo.GetMatchesByTenant(tenantID, "Pending", limit, offset)
+}
+
+// GetMatchDetails retrieves a match of the tenant and the transactions in its group
+func (s *MatchService) GetMatchDetails(matchID, userID, tenantID string) (*models.TransactionMatch, []models.Transaction, error) {
+	// Check if user has permission to view match sets
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermViewMatchSet, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !hasPermission {
+		return nil, nil, errors.New("unauthorized: requires view match set permission")
+	}
+
+	match, err := s.matchRepo.GetMatchByID(tenantID, matchID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Ensure the match belongs to the tenant
+	if match.TenantID != tenantID {
+		return nil, nil, errors.New("match not found in this tenant")
+	}
+
+	members, err := s.matchRepo.GetMatchGroup(tenantID, match.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transactions := make([]models.Transaction, 0, len(members))
+	for _, id := range members {
+		transaction, err := s.transactionRepo.GetTransactionByID(tenantID, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		transactions = append(transactions, *transaction)
+	}
+
+	return match, transactions, nil
+}
+
+// ApproveMatches approves pending matches of the tenant and marks their transactions as approved.
+// Each match is approved on its own; a user cannot approve a match they made.
+func (s *MatchService) ApproveMatches(matchIDs []string, userID, tenantID string) ([]MatchReviewResult, error) {
+	if err := s.authorizeReview(len(matchIDs), userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	results := make([]MatchReviewResult, len(matchIDs))
+	for i, matchID := range matchIDs {
+		results[i] = reviewResult(matchID, "Approved", s.approveMatch(matchID, userID, tenantID))
+	}
+	return results, nil
+}
+
+// RejectMatches rejects pending matches of the tenant with a reason for each, releasing their
+// transactions back to unmatched and voiding their adjustments. Each match is rejected on its own.
+func (s *MatchService) RejectMatches(reviews []MatchReview, userID, tenantID string) ([]MatchReviewResult, error) {
+	if err := s.authorizeReview(len(reviews), userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	results := make([]MatchReviewResult, len(reviews))
+	for i, review := range reviews {
+		results[i] = reviewResult(review.MatchID, "Rejected", s.rejectMatch(review, userID, tenantID))
+	}
+	return results, nil
+}
+
+// approveMatch approves one match
+func (s *MatchService) approveMatch(matchID, userID, tenantID string) error {
+	match, err := s.pendingMatch(matchID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	// Maker-checker: the user who made a match cannot approve it
+	if match.MatchedBy == userID {
+		return errors.New("unauthorized: matches cannot be approved by the user who made them")
+	}
+
+	return s.matchRepo.ApproveMatchGroup(tenantID, match.ID, userID)
+}
+
+// rejectMatch rejects one match
+func (s *MatchService) rejectMatch(review MatchReview, userID, tenantID string) error {
+	if strings.TrimSpace(review.Reason) == "" {
+		return errors.New("invalid rejection: a reason is required")
+	}
+
+	match, err := s.pendingMatch(review.MatchID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	return s.adjustmentRepo.RejectAdjustedMatchGroup(tenantID, match.ID, userID, strings.TrimSpace(review.Reason))
+}
+
+// pendingMatch returns a match of the tenant that is waiting for review
+func (s *MatchService) pendingMatch(matchID, tenantID string) (*models.TransactionMatch, error) {
+	match, err := s.matchRepo.GetMatchByID(tenantID, matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the match belongs to the tenant
+	if match.TenantID != tenantID {
+		return nil, errors.New("match not found in this tenant")
+	}
+
+	if match.MatchStatus != "Pending" {
+		return nil, repository.ErrMatchNotPending
+	}
+	return match, nil
+}
+
+// authorizeReview checks that a user may review matches and that the batch size is acceptable
+func (s *MatchService) authorizeReview(count int, userID, tenantID string) error {
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermApproveMatches, tenantID)
+	if err != nil {
+		return err
+	}
+	if !hasPermission {
+		return errors.New("unauthorized: requires approve matches permission")
+	}
+
+	if count == 0 {
+		return errors.New("invalid request: at least one match is required")
+	}
+	if count > maxReviewBatch {
+		return fmt.Errorf("invalid request: at most %d matches can be reviewed at once", maxReviewBatch)
+	}
+	return nil
+}
+
+// reviewResult records the outcome of reviewing one match
+func reviewResult(matchID, status string, err error) MatchReviewResult {
+	if err != nil {
+		return MatchReviewResult{MatchID: matchID, Status: "Failed", Error: err.Error()}
+	}
+	return MatchReviewResult{MatchID: matchID, Status: status}
+}
+
+// authorize checks that a user may match transactions in a tenant
+func (s *MatchService) authorize(userID, tenantID string) error {
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermMatchTransactions, tenantID)
+	if err != nil {
+		return err
+	}
+	if !hasPermission {
+		return errors.New("unauthorized: requires match transactions permission")
+	}
+	return nil
+}
+
+// activeMatch returns a match of the tenant that can still be changed, with its match set
+func (s *MatchService) activeMatch(matchID, tenantID string) (*models.TransactionMatch, *models.MatchSet, error) {
+	match, err := s.matchRepo.GetMatchByID(tenantID, matchID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Ensure the match belongs to the tenant
+	if match.TenantID != tenantID || match.MatchSetID == "" {
+		return nil, nil, errors.New("match not found in this tenant")
+	}
+
+	switch match.MatchStatus {
+	case "Pending":
+	case "Approved":
+		return nil, nil, errors.New("invalid match: approved matches cannot be changed")
+	default:
+		return nil, nil, fmt.Errorf("invalid match: the match is %s", match.MatchStatus)
+	}
+
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, match.MatchSetID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return match, matchSet, nil
+}
+
+// loadTransactions loads the transactions of a group in the given order. Every transaction
+// must belong to a data source of the match set or be an adjustment.
+func (s *MatchService) loadTransactions(matchSet *models.MatchSet, transactionIDs []string) ([]models.Transaction, error) {
+	dataSources, err := s.matchSetRepo.GetMatchSetDataSources(matchSet.TenantID, matchSet.ID)
+	if err != nil {
+		return nil, err
+	}
+	inMatchSet := make(map[string]bool, len(dataSources))
+	for _, dataSource := range dataSources {
+		inMatchSet[dataSource.ID] = true
+	}
+
+	adjustmentsChecked := false
+	seen := make(map[string]bool, len(transactionIDs))
+	transactions := make([]models.Transaction, 0, len(transactionIDs))
+	for _, id := range transactionIDs {
+		if seen[id] {
+			return nil, fmt.Errorf("invalid match: transaction %s is listed twice", id)
+		}
+		seen[id] = true
+
+		transaction, err := s.transactionRepo.GetTransactionByID(matchSet.TenantID, id)
+		if err == repository.ErrTransactionNotFound {
+			return nil, fmt.Errorf("transaction %s not found", id)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Transactions outside the match set are treated as not found
+		if !inMatchSet[transaction.DataSourceID] && !adjustmentsChecked {
+			adjustmentsChecked = true
+			adjustmentSource, err := s.adjustmentSource(matchSet.TenantID)
+			if err != nil {
+				return nil, err
+			}
+			if adjustmentSource != "" {
+				inMatchSet[adjustmentSource] = true
+			}
+		}
+		if !inMatchSet[transaction.DataSourceID] {
+			return nil, fmt.Errorf("transaction %s not found in this match set", id)
+		}
+		transactions = append(transactions, *transaction)
+	}
+
+	return transactions, nil
+}
+
+// groupBalance is what each side of a group adds up to in the currency of its first side
+type groupBalance struct {
+	left      int64
+	right     int64
+	currency  string
+	converted bool // Some amounts were converted from another currency
+	rates     *FXRateTable
+}
+
+// checkBalance checks that a group spans at least two data sources of the match set and that its
+// sides net to zero within the amount tolerance of the match set's rules. A run would accept the
+// group under any of its rules, so the most lenient tolerance applies.
+func (s *MatchService) checkBalance(matchSet *models.MatchSet, transactions []models.Transaction) error {
+	balance, err := s.balance(matchSet, transactions)
+	if err != nil {
+		return err
+	}
+
+	rules, err := matchSetRules(s.matchSetRepo, s.ruleRepo, matchSet)
+	if err != nil && err != repository.ErrRuleNotFound && err != errNoActiveRules {
+		return err
+	}
+
+	var tolerance int64
+	for _, rule := range rules {
+		engine := NewMatchingEngine(rule, balance.rates)
+		if engine.amount != nil {
+			tolerance = max(tolerance, engine.toleranceFor(balance.left, balance.converted))
+		}
+	}
+
+	if difference := absInt64(balance.left - balance.right); difference > tolerance {
+		return fmt.Errorf("invalid match: the sides differ by %.2f %s, more than the allowed %.2f",
+			float64(difference)/amountScale, balance.currency, float64(tolerance)/amountScale)
+	}
+	return nil
+}
+
+// balance adds up the sides of a group. As in a matching run, the transactions of the first data
+// source of the match set present in the group form one side and everything else, adjustments
+// included, the other. Adjustments do not count towards the two data sources a group spans.
+func (s *MatchService) balance(matchSet *models.MatchSet, transactions []models.Transaction) (*groupBalance, error) {
+	dataSources, err := s.matchSetRepo.GetMatchSetDataSources(matchSet.TenantID, matchSet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	present := make(map[string]bool)
+	for _, transaction := range transactions {
+		present[transaction.DataSourceID] = true
+	}
+
+	var leftSource string
+	spanned := 0
+	for _, dataSource := range dataSources {
+		if present[dataSource.ID] {
+			if leftSource == "" {
+				leftSource = dataSource.ID
+			}
+			spanned++
+		}
+	}
+	if spanned < 2 {
+		return nil, errors.New("invalid match: transactions from at least two data sources are required")
+	}
+
+	balance := &groupBalance{}
+	for _, transaction := range transactions {
+		if transaction.DataSourceID == leftSource && transaction.Currency != "" {
+			balance.currency = transaction.Currency
+			break
+		}
+	}
+
+	balance.rates, err = s.loadRates(matchSet.TenantID, balance.currency, transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, transaction := range transactions {
+		amount := transaction.Amount
+		if !sameCurrency(transaction.Currency, balance.currency) {
+			var ok bool
+			amount, ok = balance.rates.Convert(transaction.Amount, transaction.Currency, balance.currency, transaction.TransactionDate)
+			if !ok {
+				return nil, fmt.Errorf("invalid match: no exchange rate from %s to %s", transaction.Currency, balance.currency)
+			}
+			balance.converted = true
+		}
+
+		if transaction.DataSourceID == leftSource {
+			balance.left += toAmountUnits(amount)
+		} else {
+			balance.right += toAmountUnits(amount)
+		}
+	}
+	return balance, nil
+}
+
+// loadRates loads the exchange rates needed to express every transaction in currency
+func (s *MatchService) loadRates(tenantID, currency string, transactions []models.Transaction) (*FXRateTable, error) {
+	var from, to time.Time
+	for _, transaction := range transactions {
+		if sameCurrency(transaction.Currency, currency) {
+			continue
+		}
+		if from.IsZero() || transaction.TransactionDate.Before(from) {
+			from = transaction.TransactionDate
+		}
+		if transaction.TransactionDate.After(to) {
+			to = transaction.TransactionDate
+		}
+	}
+	if from.IsZero() {
+		return NewFXRateTable(nil), nil
+	}
+
+	rates, err := s.fxRateRepo.GetRates(tenantID, from.Add(-fxRateLookback), to)
+	if err != nil {
+		return nil, err
+	}
+	return NewFXRateTable(rates), nil
+}
+
+// requireUnmatched checks that a transaction is not in any active group
+func requireUnmatched(transaction *models.Transaction) error {
+	if transaction.Status != "Unmatched" || transaction.MatchID.Valid {
+		return fmt.Errorf("invalid transaction %s: already matched", transaction.ID)
+	}
+	return nil
+}
+
+// groupError rewords the repository's conflict errors for callers. A transaction claimed by
+// another group since it was checked is reported as invalid; other errors pass through.
+func groupError(err error) error {
+	if err == repository.ErrTransactionAlreadyMatched {
+		return errors.New("invalid match: a transaction was matched by another request")
+	}
+	return err
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"strings"
+	"testing"
+)
+
+func TestMatchService_ManualMatching(t *testing.T) {
+	matchSetRepo := repository.NewMatchSetRepository()
+	ruleRepo := repository.NewRuleRepository()
+	transactionRepo := repository.NewTransactionRepository()
+	matchRepo := repository.NewMatchRepository()
+
+	rule := &models.MatchRule{ID: "rule-1", TenantID: "tenant-1", Name: "Amount", Active: true, Conditions: []models.RuleCondition{
+		{Field: models.ConditionFieldAmount, Operator: models.ConditionOpWithin, Tolerance: 1},
+	}}
+	ruleRepo.CreateRule(rule)
+
+	matchSet := &models.MatchSet{ID: "set-1", Name: "Bank", TenantID: "tenant-1", RuleID: rule.ID}
+	matchSetRepo.CreateMatchSet(matchSet)
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "ledger")
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "bank")
+
+	for _, transaction := range []models.Transaction{
+		tx("L1", 100, 1, ""),
+		tx("L2", 0.5, 1, ""),
+		tx("L3", 20, 1, ""),
+		tx("R1", 60, 1, ""),
+		tx("R2", 40, 1, ""),
+		tx("X1", 100, 1, ""), // In a data source outside the match set
+	} {
+		switch transaction.ID[0] {
+		case 'L':
+			transaction.DataSourceID = "ledger"
+		case 'R':
+			transaction.DataSourceID = "bank"
+		default:
+			transaction.DataSourceID = "other"
+		}
+		transaction.Status = "Unmatched"
+		transactionRepo.CreateTransaction(&transaction)
+	}
+
+	service := NewMatchService(matchRepo, matchSetRepo, ruleRepo, transactionRepo, allowAllPermissions{}, repository.NewFXRateRepository(), repository.NewAdjustmentRepository(transactionRepo, matchRepo))
+
+	rejected := []struct {
+		name           string
+		transactionIDs []string
+		tenantID       string
+		wantErr        string
+	}{
+		{"one data source", []string{"L1", "L3"}, "tenant-1", "at least two data sources"},
+		{"unbalanced", []string{"L1", "R1"}, "tenant-1", "differ by 40.00"},
+		{"outside the match set", []string{"L1", "X1"}, "tenant-1", "not found in this match set"},
+		{"other tenant", []string{"L1", "R1", "R2"}, "tenant-2", "match set not found"},
+	}
+	for _, tt := range rejected {
+		if _, err := service.CreateManualMatch(matchSet.ID, tt.transactionIDs, "user-1", tt.tenantID); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("CreateManualMatch(%s) error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	match, err := service.CreateManualMatch(matchSet.ID, []string{"L1", "R1", "R2"}, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("CreateManualMatch() error = %v", err)
+	}
+	if match.MatchType != "Manual" || match.MatchStatus != "Pending" {
+		t.Errorf("CreateManualMatch() = %s %s, want Manual Pending", match.MatchType, match.MatchStatus)
+	}
+
+	// R1 is in the group now
+	if _, err := service.CreateManualMatch(matchSet.ID, []string{"L3", "R1"}, "user-1", "tenant-1"); err == nil {
+		t.Error("CreateManualMatch() reusing a matched transaction succeeded, want error")
+	}
+
+	// 0.5 stays within the rule's tolerance of 1, 20 does not
+	if _, err := service.AddTransactionToMatch(match.ID, "L2", "user-1", "tenant-1"); err != nil {
+		t.Errorf("AddTransactionToMatch(L2) error = %v", err)
+	}
+	if _, err := service.AddTransactionToMatch(match.ID, "L3", "user-1", "tenant-1"); err == nil {
+		t.Error("AddTransactionToMatch(L3) succeeded, want unbalanced error")
+	}
+
+	if _, err := service.RemoveTransactionFromMatch(match.ID, "R2", "user-1", "tenant-1"); err == nil {
+		t.Error("RemoveTransactionFromMatch(R2) succeeded, want unbalanced error")
+	}
+	if _, err := service.RemoveTransactionFromMatch(match.ID, "L2", "user-1", "tenant-1"); err != nil {
+		t.Errorf("RemoveTransactionFromMatch(L2) error = %v", err)
+	}
+
+	if err := service.Unmatch(match.ID, "user-1", "tenant-1"); err != nil {
+		t.Fatalf("Unmatch() error = %v", err)
+	}
+	if members, _ := matchRepo.GetMatchGroup("tenant-1", match.ID); len(members) != 0 {
+		t.Errorf("group after Unmatch() = %v, want empty", members)
+	}
+	if err := service.Unmatch(match.ID, "user-1", "tenant-1"); err == nil {
+		t.Error("Unmatch() of a dissolved match succeeded, want error")
+	}
+}
+
+func TestMatchService_ManualMatchingUsesPrioritizedRules(t *testing.T) {
+	matchSetRepo := repository.NewMatchSetRepository()
+	ruleRepo := repository.NewRuleRepository()
+	transactionRepo := repository.NewTransactionRepository()
+
+	exact := &models.MatchRule{ID: "rule-exact", TenantID: "tenant-1", Name: "Exact", Active: true, Conditions: []models.RuleCondition{
+		{Field: models.ConditionFieldAmount, Operator: models.ConditionOpEq},
+	}}
+	tolerant := &models.MatchRule{ID: "rule-tolerant", TenantID: "tenant-1", Name: "Tolerant", Active: true, Conditions: []models.RuleCondition{
+		{Field: models.ConditionFieldAmount, Operator: models.ConditionOpWithin, Tolerance: 1},
+	}}
+	ruleRepo.CreateRule(exact)
+	ruleRepo.CreateRule(tolerant)
+
+	// The match set has no legacy rule, only prioritized ones
+	matchSet := &models.MatchSet{ID: "set-1", Name: "Bank", TenantID: "tenant-1"}
+	matchSetRepo.CreateMatchSet(matchSet)
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "ledger")
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "bank")
+	matchSetRepo.SetMatchSetRule(matchSet.ID, exact.ID, 1)
+	matchSetRepo.SetMatchSetRule(matchSet.ID, tolerant.ID, 2)
+
+	for _, transaction := range []models.Transaction{tx("L1", 100, 1, ""), tx("R1", 99.5, 1, ""), tx("L2", 50, 1, ""), tx("R2", 45, 1, "")} {
+		transaction.DataSourceID = "ledger"
+		if transaction.ID[0] == 'R' {
+			transaction.DataSourceID = "bank"
+		}
+		transaction.Status = "Unmatched"
+		transactionRepo.CreateTransaction(&transaction)
+	}
+
+	matchRepo := repository.NewMatchRepository()
+	service := NewMatchService(matchRepo, matchSetRepo, ruleRepo, transactionRepo, allowAllPermissions{}, repository.NewFXRateRepository(), repository.NewAdjustmentRepository(transactionRepo, matchRepo))
+
+	// 0.5 is within the second rule's tolerance, 5 is within none
+	if _, err := service.CreateManualMatch(matchSet.ID, []string{"L1", "R1"}, "user-1", "tenant-1"); err != nil {
+		t.Errorf("CreateManualMatch(L1, R1) error = %v", err)
+	}
+	if _, err := service.CreateManualMatch(matchSet.ID, []string{"L2", "R2"}, "user-1", "tenant-1"); err == nil || !strings.Contains(err.Error(), "differ by 5.00") {
+		t.Errorf("CreateManualMatch(L2, R2) error = %v, want unbalanced error", err)
+	}
+}
+
+func TestMatchService_ReviewMatches(t *testing.T) {
+	matchSetRepo := repository.NewMatchSetRepository()
+	transactionRepo := repository.NewTransactionRepository()
+	matchRepo := repository.NewMatchRepository()
+
+	matchSet := &models.MatchSet{ID: "set-1", Name: "Bank", TenantID: "tenant-1"}
+	matchSetRepo.CreateMatchSet(matchSet)
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "ledger")
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "bank")
+
+	for _, transaction := range []models.Transaction{
+		tx("L1", 100, 1, ""),
+		tx("L2", 50, 1, ""),
+		tx("R1", 100, 1, ""),
+		tx("R2", 50, 1, ""),
+	} {
+		transaction.DataSourceID = "bank"
+		if transaction.ID[0] == 'L' {
+			transaction.DataSourceID = "ledger"
+		}
+		transaction.Status = "Unmatched"
+		transactionRepo.CreateTransaction(&transaction)
+	}
+
+	service := NewMatchService(matchRepo, matchSetRepo, repository.NewRuleRepository(), transactionRepo, allowAllPermissions{}, repository.NewFXRateRepository(), repository.NewAdjustmentRepository(transactionRepo, matchRepo))
+
+	first, err := service.CreateManualMatch(matchSet.ID, []string{"L1", "R1"}, "maker", "tenant-1")
+	if err != nil {
+		t.Fatalf("CreateManualMatch() error = %v", err)
+	}
+	second, err := service.CreateManualMatch(matchSet.ID, []string{"L2", "R2"}, "maker", "tenant-1")
+	if err != nil {
+		t.Fatalf("CreateManualMatch() error = %v", err)
+	}
+
+	pending, total, err := service.GetPendingMatches(10, 0, "checker", "tenant-1")
+	if err != nil || total != 2 || len(pending) != 2 {
+		t.Fatalf("GetPendingMatches() = %d of %d, %v, want 2 of 2", len(pending), total, err)
+	}
+
+	// The maker cannot approve their own match
+	results, err := service.ApproveMatches([]string{first.ID}, "maker", "tenant-1")
+	if err != nil || results[0].Status != "Failed" {
+		t.Errorf("ApproveMatches() by maker = %+v, %v, want Failed", results, err)
+	}
+
+	results, _ = service.ApproveMatches([]string{first.ID, "missing"}, "checker", "tenant-1")
+	if results[0].Status != "Approved" || results[1].Status != "Failed" {
+		t.Errorf("ApproveMatches() = %+v, want Approved then Failed", results)
+	}
+
+	results, _ = service.RejectMatches([]MatchReview{{MatchID: second.ID}}, "checker", "tenant-1")
+	if results[0].Status != "Failed" {
+		t.Errorf("RejectMatches() without reason = %+v, want Failed", results)
+	}
+	results, _ = service.RejectMatches([]MatchReview{{MatchID: second.ID, Reason: "Different payees"}}, "checker", "tenant-1")
+	if results[0].Status != "Rejected" {
+		t.Errorf("RejectMatches() = %+v, want Rejected", results)
+	}
+
+	// The rejected transactions can be matched again, the approved ones cannot be changed
+	if members, _ := matchRepo.GetMatchGroup("tenant-1", second.ID); len(members) != 0 {
+		t.Errorf("rejected group = %v, want released", members)
+	}
+	if err := service.Unmatch(first.ID, "maker", "tenant-1"); err == nil {
+		t.Error("Unmatch() of an approved match succeeded, want error")
+	}
+	if _, total, _ := service.GetPendingMatches(10, 0, "checker", "tenant-1"); total != 0 {
+		t.Errorf("pending after review = %d, want 0", total)
+	}
+}
+
+func TestMatchService_Adjustments(t *testing.T) {
+	matchSetRepo := repository.NewMatchSetRepository()
+	ruleRepo := repository.NewRuleRepository()
+	transactionRepo := repository.NewTransactionRepository()
+	matchRepo := repository.NewMatchRepository()
+	adjustmentRepo := repository.NewAdjustmentRepository(transactionRepo, matchRepo)
+
+	rule := &models.MatchRule{ID: "rule-1", TenantID: "tenant-1", Name: "Amount", Active: true, Conditions: []models.RuleCondition{
+		{Field: models.ConditionFieldAmount, Operator: models.ConditionOpWithin, Tolerance: 1},
+	}}
+	ruleRepo.CreateRule(rule)
+
+	matchSet := &models.MatchSet{ID: "set-1", Name: "Bank", TenantID: "tenant-1", RuleID: rule.ID}
+	matchSetRepo.CreateMatchSet(matchSet)
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "ledger")
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "bank")
+
+	for _, transaction := range []models.Transaction{
+		tx("L1", 100, 1, ""),
+		tx("R1", 97.5, 3, ""),
+		tx("L2", 50, 1, ""),
+		tx("R2", 49.6, 1, ""),
+	} {
+		transaction.DataSourceID = "ledger"
+		if transaction.ID[0] == 'R' {
+			transaction.DataSourceID = "bank"
+		}
+		transaction.Status = "Unmatched"
+		transaction.Currency = "USD"
+		transactionRepo.CreateTransaction(&transaction)
+	}
+
+	service := NewMatchService(matchRepo, matchSetRepo, ruleRepo, transactionRepo, allowAllPermissions{}, repository.NewFXRateRepository(), adjustmentRepo)
+	fee := AdjustmentRequest{Reason: "Bank fee", GLAccount: "6100"}
+
+	if _, err := service.CreateAdjustedMatch(matchSet.ID, []string{"L1", "R1"}, fee, "approver", "tenant-1"); err == nil || !strings.Contains(err.Error(), "no write-off limit") {
+		t.Errorf("CreateAdjustedMatch() without a limit error = %v, want no write-off limit", err)
+	}
+
+	if _, err := service.SetWriteOffLimit(1, "admin", "tenant-1"); err != nil {
+		t.Fatalf("SetWriteOffLimit() error = %v", err)
+	}
+	if _, err := service.CreateAdjustedMatch(matchSet.ID, []string{"L1", "R1"}, fee, "approver", "tenant-1"); err == nil || !strings.Contains(err.Error(), "write-off limit of 1.00") {
+		t.Errorf("CreateAdjustedMatch() over the limit error = %v, want write-off limit", err)
+	}
+	if _, err := service.CreateAdjustedMatch(matchSet.ID, []string{"L1", "R1"}, AdjustmentRequest{Reason: "Bank fee"}, "approver", "tenant-1"); err == nil || !strings.Contains(err.Error(), "gl_account") {
+		t.Errorf("CreateAdjustedMatch() without a GL account error = %v, want gl_account", err)
+	}
+
+	preparer := NewMatchService(matchRepo, matchSetRepo, ruleRepo, transactionRepo, denyPermission{denied: models.PermApproveMatches}, repository.NewFXRateRepository(), adjustmentRepo)
+	if _, err := preparer.CreateAdjustedMatch(matchSet.ID, []string{"L1", "R1"}, fee, "preparer", "tenant-1"); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("CreateAdjustedMatch() by a preparer error = %v, want unauthorized", err)
+	}
+
+	settings, err := service.SetWriteOffLimit(5, "admin", "tenant-1")
+	if err != nil {
+		t.Fatalf("SetWriteOffLimit() error = %v", err)
+	}
+
+	// A 2.50 bank fee closes the group
+	match, err := service.CreateAdjustedMatch(matchSet.ID, []string{"L1", "R1"}, fee, "approver", "tenant-1")
+	if err != nil {
+		t.Fatalf("CreateAdjustedMatch() error = %v", err)
+	}
+	adjustments, err := service.GetMatchAdjustments(match.ID, "approver", "tenant-1")
+	if err != nil || len(adjustments) != 1 {
+		t.Fatalf("GetMatchAdjustments() = %v, %v, want one adjustment", adjustments, err)
+	}
+	adjustment := adjustments[0]
+	if adjustment.Amount != 2.5 || adjustment.Currency != "USD" || adjustment.GLAccount != "6100" || adjustment.CreatedBy != "approver" {
+		t.Errorf("adjustment = %+v, want 2.50 USD to 6100 by approver", adjustment)
+	}
+
+	_, transactions, err := service.GetMatchDetails(match.ID, "approver", "tenant-1")
+	if err != nil || len(transactions) != 3 {
+		t.Fatalf("GetMatchDetails() = %d transactions, %v, want 3", len(transactions), err)
+	}
+	synthetic := transactions[2]
+	if synthetic.DataSourceID != settings.DataSourceID || synthetic.Amount != 2.5 || !synthetic.TransactionDate.Equal(tx("", 0, 3, "").TransactionDate) {
+		t.Errorf("adjustment transaction = %+v, want 2.50 in the adjustment data source dated the 3rd", synthetic)
+	}
+
+	if _, err := service.AdjustMatch(match.ID, fee, "approver", "tenant-1"); err == nil || !strings.Contains(err.Error(), "already balance") {
+		t.Errorf("AdjustMatch() on a balanced group error = %v, want already balance", err)
+	}
+
+	// A group within the rule's tolerance can be closed exactly too
+	rounding, err := service.CreateManualMatch(matchSet.ID, []string{"L2", "R2"}, "preparer", "tenant-1")
+	if err != nil {
+		t.Fatalf("CreateManualMatch() error = %v", err)
+	}
+	if _, err := service.AdjustMatch(rounding.ID, AdjustmentRequest{Reason: "Rounding", GLAccount: "6900"}, "approver", "tenant-1"); err != nil {
+		t.Errorf("AdjustMatch() error = %v", err)
+	}
+
+	// Unmatching voids the adjustment; its transaction stays, voided, and is never matched again
+	if err := service.Unmatch(match.ID, "approver", "tenant-1"); err != nil {
+		t.Fatalf("Unmatch() error = %v", err)
+	}
+	adjustments, _ = service.GetMatchAdjustments(match.ID, "approver", "tenant-1")
+	if len(adjustments) != 1 || adjustments[0].VoidedAt == nil || adjustments[0].VoidedBy != "approver" {
+		t.Errorf("adjustments after Unmatch() = %+v, want one voided by approver", adjustments)
+	}
+	if voided, err := transactionRepo.GetTransactionByID("tenant-1", adjustment.TransactionID); err != nil || voided.Status != "Voided" {
+		t.Errorf("adjustment transaction after Unmatch() = %+v, %v, want it kept as Voided", voided, err)
+	}
+	if _, err := service.CreateManualMatch(matchSet.ID, []string{"L1", "R1", adjustment.TransactionID}, "preparer", "tenant-1"); err == nil {
+		t.Error("CreateManualMatch() with a voided adjustment transaction succeeded, want an error")
+	}
+
+	// Taking the adjustment out of a group voids it too
+	rounded, _ := service.GetMatchAdjustments(rounding.ID, "approver", "tenant-1")
+	if len(rounded) != 1 {
+		t.Fatalf("GetMatchAdjustments() = %+v, want one adjustment", rounded)
+	}
+	if _, err := service.RemoveTransactionFromMatch(rounding.ID, rounded[0].TransactionID, "approver", "tenant-1"); err != nil {
+		t.Fatalf("RemoveTransactionFromMatch() error = %v", err)
+	}
+	rounded, _ = service.GetMatchAdjustments(rounding.ID, "approver", "tenant-1")
+	if len(rounded) != 1 || rounded[0].VoidedAt == nil {
+		t.Errorf("adjustments after RemoveTransactionFromMatch() = %+v, want one voided", rounded)
+	}
+	if voided, err := transactionRepo.GetTransactionByID("tenant-1", rounded[0].TransactionID); err != nil || voided.Status != "Voided" {
+		t.Errorf("removed adjustment transaction = %+v, %v, want it kept as Voided", voided, err)
+	}
+
+	all, total, err := service.GetAdjustments(20, 0, "auditor", "tenant-1")
+	if err != nil || total != 2 || len(all) != 2 {
+		t.Errorf("GetAdjustments() = %d of %d, %v, want 2", len(all), total, err)
+	}
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"errors"
+	"math"
+	"sort"
+)
+
+// Weights of the parts of a candidate's score; they add up to 1
+const (
+	amountScoreWeight    = 0.5
+	dateScoreWeight      = 0.25
+	referenceScoreWeight = 0.25
+)
+
+// suggestionDayHorizon is the day gap at which the date part of a candidate's score reaches zero
+const suggestionDayHorizon = 30
+
+// Limits on the number of candidates returned for a transaction
+const (
+	defaultSuggestionLimit = 10
+	maxSuggestionLimit     = 50
+)
+
+// suggestionTextPoolSize caps the transactions a text lookup adds to the pool of candidates
+const suggestionTextPoolSize = 500
+
+// MatchCandidate is a transaction suggested as a match for another, with the breakdown of its score
+type MatchCandidate struct {
+	Transaction         models.Transaction `json:"transaction"`
+	Score               float64            `json:"score"`        // Weighted sum of the part scores, 0..1
+	AmountDelta         float64            `json:"amount_delta"` // Candidate amount minus the transaction amount
+	Currency            string             `json:"currency,omitempty"`
+	AmountScore         float64            `json:"amount_score"`
+	DayGap              int                `json:"day_gap"`
+	DateScore           float64            `json:"date_score"`
+	ReferenceSimilarity float64            `json:"reference_similarity"`
+	MatchingRuleID      string             `json:"matching_rule_id,omitempty"` // First rule of the match set the pair satisfies
+}
+
+// MatchSuggestionService suggests match candidates for transactions that need a manual match
+type MatchSuggestionService struct {
+	matchSetRepo    repository.MatchSetRepository
+	ruleRepo        repository.RuleRepository
+	transactionRepo repository.TransactionRepository
+	permissionRepo  repository.PermissionRepository
+	fxRateRepo      repository.FXRateRepository
+}
+
+// NewMatchSuggestionService creates a new match suggestion service
+func NewMatchSuggestionService(
+	matchSetRepo repository.MatchSetRepository,
+	ruleRepo repository.RuleRepository,
+	transactionRepo repository.TransactionRepository,
+	permissionRepo repository.PermissionRepository,
+	fxRateRepo repository.FXRateRepository,
+) *MatchSuggestionService {
+	return &MatchSuggestionService{
+		matchSetRepo:    matchSetRepo,
+		ruleRepo:        ruleRepo,
+		transactionRepo: transactionRepo,
+		permissionRepo:  permissionRepo,
+		fxRateRepo:      fxRateRepo,
+	}
+}
+
+// FindPotentialMatches ranks the unmatched transactions of the other data sources of a match set
+// as candidates for an unmatched transaction. When matchSetID is empty the match set is the one
+// of the tenant that contains the transaction's data source. A full-text query, such as a name
+// or invoice number from the narrative, narrows the pool to the transactions whose reference or
+// description matches it, allowing for misspelled words. Candidates in another currency are only
+// suggested when an exchange rate is known.
+func (s *MatchSuggestionService) FindPotentialMatches(transactionID, matchSetID, query string, limit int, userID, tenantID string) ([]MatchCandidate, error) {
+	// Check if user has permission to match transactions
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermMatchTransactions, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission {
+		return nil, errors.New("unauthorized: requires match transactions permission")
+	}
+
+	transaction, err := s.transactionRepo.GetTransactionByID(tenantID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.Status != "Unmatched" {
+		return nil, errors.New("invalid transaction: already matched")
+	}
+
+	matchSet, dataSources, err := s.transactionMatchSet(transaction, matchSetID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultSuggestionLimit
+	}
+	limit = min(limit, maxSuggestionLimit)
+
+	// Load the unmatched transactions on the other sides
+	var otherIDs []string
+	for _, dataSource := range dataSources {
+		if dataSource.ID != transaction.DataSourceID {
+			otherIDs = append(otherIDs, dataSource.ID)
+		}
+	}
+	pool, err := s.candidatePool(otherIDs, query, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rules are optional here; candidates are ranked whether or not any rule accepts them
+	rules, err := s.activeRules(matchSet)
+	if err != nil {
+		return nil, err
+	}
+
+	rates, err := s.loadRates(tenantID, transaction, pool)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm := models.SimilarityLevenshtein
+	if len(rules) > 0 && rules[0].SimilarityAlgorithm != "" {
+		algorithm = rules[0].SimilarityAlgorithm
+	}
+
+	engines := make([]*MatchingEngine, len(rules))
+	for i, rule := range rules {
+		engines[i] = NewMatchingEngine(rule, rates)
+	}
+
+	candidates := make([]MatchCandidate, 0, len(pool))
+	for i := range pool {
+		candidate, ok := scoreCandidate(transaction, &pool[i], algorithm, rates)
+		if !ok {
+			continue
+		}
+		for n, engine := range engines {
+			if _, ok := engine.pairScore(transaction, &pool[i]); ok {
+				candidate.MatchingRuleID = rules[n].ID
+				break
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	sort.SliceStable(candidates, func(a, b int) bool {
+		if candidates[a].Score != candidates[b].Score {
+			return candidates[a].Score > candidates[b].Score
+		}
+		if candidates[a].DayGap != candidates[b].DayGap {
+			return candidates[a].DayGap < candidates[b].DayGap
+		}
+		return candidates[a].Transaction.ID < candidates[b].Transaction.ID
+	})
+
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates, nil
+}
+
+// candidatePool returns the unmatched transactions of data sources, only those a full-text query
+// matches fuzzily when there is one
+func (s *MatchSuggestionService) candidatePool(dataSourceIDs []string, query, tenantID string) ([]models.Transaction, error) {
+	if query == "" {
+		var pool []models.Transaction
+		for _, dataSourceID := range dataSourceIDs {
+			transactions, err := s.transactionRepo.GetTransactionsByStatus(tenantID, dataSourceID, "Unmatched")
+			if err != nil {
+				return nil, err
+			}
+			pool = append(pool, transactions...)
+		}
+		return pool, nil
+	}
+
+	if len(dataSourceIDs) == 0 {
+		return nil, nil
+	}
+	terms, err := parseTextQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	hits, _, err := s.transactionRepo.SearchTransactionText(models.TextSearch{
+		TenantID:      tenantID,
+		Terms:         terms,
+		Fuzzy:         true,
+		Statuses:      []string{"Unmatched"},
+		DataSourceIDs: dataSourceIDs,
+		Limit:         suggestionTextPoolSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pool := make([]models.Transaction, len(hits))
+	for i := range hits {
+		pool[i] = hits[i].Transaction
+	}
+	return pool, nil
+}
+
+// transactionMatchSet returns the match set a transaction is matched in and its data sources
+func (s *MatchSuggestionService) transactionMatchSet(transaction *models.Transaction, matchSetID, tenantID string) (*models.MatchSet, []models.DataSource, error) {
+	var matchSets []models.MatchSet
+	if matchSetID != "" {
+		matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if matchSet.TenantID != tenantID {
+			return nil, nil, errors.New("match set not found in this tenant")
+		}
+		matchSets = []models.MatchSet{*matchSet}
+	} else {
+		var err error
+		matchSets, err = s.matchSetRepo.GetMatchSetsByTenant(tenantID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var found *models.MatchSet
+	var foundSources []models.DataSource
+	for i := range matchSets {
+		dataSources, err := s.matchSetRepo.GetMatchSetDataSources(tenantID, matchSets[i].ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, dataSource := range dataSources {
+			if dataSource.ID != transaction.DataSourceID {
+				continue
+			}
+			if found != nil {
+				return nil, nil, errors.New("invalid request: the transaction is in several match sets, a match set is required")
+			}
+			found, foundSources = &matchSets[i], dataSources
+			break
+		}
+	}
+
+	// A transaction outside the tenant's match sets is treated as not found
+	if found == nil {
+		return nil, nil, errors.New("transaction not found in a match set of this tenant")
+	}
+	return found, foundSources, nil
+}
+
+// activeRules returns the active rules of a match set in the order they run
+func (s *MatchSuggestionService) activeRules(matchSet *models.MatchSet) ([]*models.MatchRule, error) {
+	assigned, err := s.matchSetRepo.GetMatchSetRules(matchSet.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(assigned) == 0 && matchSet.RuleID != "" {
+		assigned = []models.MatchSetRule{{MatchSetID: matchSet.ID, RuleID: matchSet.RuleID}}
+	}
+
+	var rules []*models.MatchRule
+	for _, matchSetRule := range assigned {
+		rule, err := s.ruleRepo.GetRuleByID(matchSet.TenantID, matchSetRule.RuleID)
+		if err == repository.ErrRuleNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if rule.Active {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// loadRates loads the exchange rates needed to compare the pool with the transaction
+func (s *MatchSuggestionService) loadRates(tenantID string, transaction *models.Transaction, pool []models.Transaction) (*FXRateTable, error) {
+	from, to := transaction.TransactionDate, transaction.TransactionDate
+	converts := false
+	for _, t := range pool {
+		if sameCurrency(t.Currency, transaction.Currency) {
+			continue
+		}
+		converts = true
+		if t.TransactionDate.Before(from) {
+			from = t.TransactionDate
+		}
+		if t.TransactionDate.After(to) {
+			to = t.TransactionDate
+		}
+	}
+	if !converts {
+		return NewFXRateTable(nil), nil
+	}
+
+	rates, err := s.fxRateRepo.GetRates(tenantID, from.Add(-fxRateLookback), to)
+	if err != nil {
+		return nil, err
+	}
+	return NewFXRateTable(rates), nil
+}
+
+// scoreCandidate scores a candidate against a transaction. It fails when the candidate's amount
+// cannot be expressed in the transaction's currency.
+func scoreCandidate(transaction, candidate *models.Transaction, algorithm string, rates *FXRateTable) (MatchCandidate, bool) {
+	amount := candidate.Amount
+	if !sameCurrency(transaction.Currency, candidate.Currency) {
+		converted, ok := rates.Convert(candidate.Amount, candidate.Currency, transaction.Currency, candidate.TransactionDate)
+		if !ok {
+			return MatchCandidate{}, false
+		}
+		amount = converted
+	}
+
+	delta := math.Round((amount-transaction.Amount)*amountScale) / amountScale
+	amountScore := 0.0
+	switch {
+	case delta == 0:
+		amountScore = 1
+	case transaction.Amount != 0:
+		amountScore = math.Max(0, 1-math.Abs(delta)/math.Abs(transaction.Amount))
+	}
+
+	dayGap := daysBetween(transaction.TransactionDate, candidate.TransactionDate)
+	dateScore := math.Max(0, 1-float64(dayGap)/suggestionDayHorizon)
+
+	referenceScore := 0.0
+	if transaction.Reference != "" && candidate.Reference != "" {
+		referenceScore = similarity(algorithm, transaction.Reference, candidate.Reference)
+	}
+
+	currency := transaction.Currency
+	if currency == "" {
+		currency = candidate.Currency
+	}
+
+	return MatchCandidate{
+		Transaction:         *candidate,
+		Score:               amountScoreWeight*amountScore + dateScoreWeight*dateScore + referenceScoreWeight*referenceScore,
+		AmountDelta:         delta,
+		Currency:            currency,
+		AmountScore:         amountScore,
+		DayGap:              dayGap,
+		DateScore:           dateScore,
+		ReferenceSimilarity: referenceScore,
+	}, true
+}
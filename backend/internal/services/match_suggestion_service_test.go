@@ -0,0 +1,90 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"math"
+	"testing"
+)
+
+func TestMatchSuggestionService_FindPotentialMatches(t *testing.T) {
+	matchSetRepo := repository.NewMatchSetRepository()
+	ruleRepo := repository.NewRuleRepository()
+	transactionRepo := repository.NewTransactionRepository()
+
+	rule := &models.MatchRule{ID: "rule-1", TenantID: "tenant-1", Name: "Amount and date", Active: true, Conditions: []models.RuleCondition{
+		{Field: models.ConditionFieldAmount, Operator: models.ConditionOpEq},
+		{Field: models.ConditionFieldTransactionDate, Operator: models.ConditionOpWithin, Tolerance: 3},
+	}}
+	ruleRepo.CreateRule(rule)
+
+	matchSet := &models.MatchSet{ID: "set-1", Name: "Bank", TenantID: "tenant-1", RuleID: rule.ID}
+	matchSetRepo.CreateMatchSet(matchSet)
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "ledger")
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "bank")
+
+	for _, transaction := range []models.Transaction{
+		tx("L1", 100, 10, "INV-1001"),
+		tx("L2", 100, 10, ""),        // Same side, never a candidate
+		tx("R1", 100, 12, "INV1001"), // Same amount and reference, two days apart
+		tx("R2", 100, 25, ""),
+		tx("R3", 60, 10, "INV-1001"),
+		fxTx("R4", 100, 10, "EUR"), // No rate to compare it with
+		tx("R5", 100, 10, "INV-1001"),
+	} {
+		transaction.DataSourceID = "bank"
+		if transaction.ID[0] == 'L' {
+			transaction.DataSourceID = "ledger"
+		}
+		if transaction.Currency == "" {
+			transaction.Currency = "USD"
+		}
+		transaction.Status = "Unmatched"
+		if transaction.ID == "R5" {
+			transaction.Status = "Matched"
+		}
+		transactionRepo.CreateTransaction(&transaction)
+	}
+
+	service := NewMatchSuggestionService(matchSetRepo, ruleRepo, transactionRepo, allowAllPermissions{}, repository.NewFXRateRepository())
+
+	candidates, err := service.FindPotentialMatches("L1", "", "", 0, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("FindPotentialMatches() error = %v", err)
+	}
+
+	want := []string{"R1", "R3", "R2"}
+	if len(candidates) != len(want) {
+		t.Fatalf("FindPotentialMatches() returned %d candidates, want %d", len(candidates), len(want))
+	}
+	for i, id := range want {
+		if candidates[i].Transaction.ID != id {
+			t.Errorf("candidate %d = %s, want %s", i, candidates[i].Transaction.ID, id)
+		}
+	}
+
+	best := candidates[0]
+	if best.AmountDelta != 0 || best.DayGap != 2 || best.ReferenceSimilarity != 1 || best.MatchingRuleID != rule.ID {
+		t.Errorf("best candidate = %+v, want no amount delta, two day gap, identical reference and rule-1", best)
+	}
+	if math.Abs(candidates[1].AmountDelta+40) > 1e-9 || candidates[1].MatchingRuleID != "" {
+		t.Errorf("second candidate = %+v, want amount delta -40 and no matching rule", candidates[1])
+	}
+
+	// A text query keeps the candidates whose reference holds a word like it: R1's INV1001 is
+	// close enough to 1001, R2 has no reference
+	candidates, err = service.FindPotentialMatches("L1", "", "1001", 0, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("FindPotentialMatches() with a query error = %v", err)
+	}
+	if len(candidates) != 2 || candidates[0].Transaction.ID != "R1" || candidates[1].Transaction.ID != "R3" {
+		t.Errorf("FindPotentialMatches() with a query = %+v, want R1 and R3", candidates)
+	}
+
+	if _, err := service.FindPotentialMatches("R5", "", "", 0, "user-1", "tenant-1"); err == nil {
+		t.Error("FindPotentialMatches() on a matched transaction succeeded, want error")
+	}
+	if _, err := service.FindPotentialMatches("L1", "", "", 0, "user-1", "tenant-2"); err == nil {
+		t.Error("FindPotentialMatches() from another tenant succeeded, want error")
+	}
+}
@@ -0,0 +1,630 @@
+package services
+
+import (
+	"backend/internal/models"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// amountScale converts amounts to integer units so sums compare exactly (4 decimal places, as stored)
+const amountScale = 10000
+
+// ProposedMatch is a group of transactions the engine proposes to match.
+// Left holds transactions from the primary data source, Right from the other source.
+type ProposedMatch struct {
+	Left             []models.Transaction `json:"left"`
+	Right            []models.Transaction `json:"right"`
+	AmountDifference float64              `json:"amount_difference"`
+	Currency         string               `json:"currency,omitempty"` // Currency of AmountDifference
+	Score            float64              `json:"score"`              // Weakest field condition score in the group, 1 when there are none
+}
+
+// TransactionIDs returns the IDs of every transaction in the group
+func (p *ProposedMatch) TransactionIDs() []string {
+	ids := make([]string, 0, len(p.Left)+len(p.Right))
+	for _, t := range p.Left {
+		ids = append(ids, t.ID)
+	}
+	for _, t := range p.Right {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+// MatchingEngine proposes matches between two sets of transactions according to a rule.
+// It does no I/O; callers load the transactions and rates and persist the proposals.
+// An engine caches reference keys per transaction ID and is not safe for concurrent use.
+type MatchingEngine struct {
+	rule    *models.MatchRule
+	rates   *FXRateTable
+	pattern *regexp.Regexp
+	keys    map[string]string // transaction ID -> reference key
+
+	// The rule's conditions: amounts and dates drive the search, the rest filter and score pairs
+	amount    *models.RuleCondition
+	date      *models.RuleCondition
+	dateDays  int
+	fields    []fieldCondition
+	hasFields bool
+}
+
+// NewMatchingEngine creates a matching engine for a rule. rates is only consulted when the rule
+// compares across currencies and may be nil otherwise. Invalid patterns are ignored;
+// RuleService rejects them when rules are saved.
+func NewMatchingEngine(rule *models.MatchRule, rates *FXRateTable) *MatchingEngine {
+	engine := &MatchingEngine{
+		rule:  rule,
+		rates: rates,
+		keys:  make(map[string]string),
+	}
+	if rule.ReferencePattern != "" {
+		engine.pattern, _ = regexp.Compile(rule.ReferencePattern)
+	}
+
+	for _, c := range ruleConditions(rule) {
+		switch c.Field {
+		case models.ConditionFieldAmount:
+			engine.amount = &c
+		case models.ConditionFieldTransactionDate:
+			engine.date = &c
+			if c.Operator == models.ConditionOpWithin {
+				engine.dateDays = int(c.Tolerance)
+			}
+		default:
+			field := fieldCondition{RuleCondition: c}
+			if c.Operator == models.ConditionOpRegex {
+				field.pattern, _ = regexp.Compile(c.Pattern)
+			}
+			engine.fields = append(engine.fields, field)
+		}
+	}
+	engine.hasFields = len(engine.fields) > 0
+	return engine
+}
+
+// Match proposes groups between left and right. Each transaction is used at most once.
+// One-to-one matches are taken first; depending on the rule's cardinality the engine then
+// looks for one-to-many groups in both directions and finally many-to-many groups.
+// The leftovers on each side are returned with the proposals.
+func (e *MatchingEngine) Match(left, right []models.Transaction) ([]ProposedMatch, []models.Transaction, []models.Transaction) {
+	l := sortedTransactions(left)
+	r := sortedTransactions(right)
+	usedL := make([]bool, len(l))
+	usedR := make([]bool, len(r))
+
+	var proposals []ProposedMatch
+	proposals = append(proposals, e.matchOneToOne(l, r, usedL, usedR)...)
+
+	// Group matching only makes sense when amounts are compared
+	if e.amount != nil {
+		switch e.rule.MatchCardinality {
+		case models.MatchCardinalityOneToMany:
+			proposals = append(proposals, e.matchOneToMany(l, r, usedL, usedR, false)...)
+			proposals = append(proposals, e.matchOneToMany(r, l, usedR, usedL, true)...)
+		case models.MatchCardinalityManyToMany:
+			proposals = append(proposals, e.matchOneToMany(l, r, usedL, usedR, false)...)
+			proposals = append(proposals, e.matchOneToMany(r, l, usedR, usedL, true)...)
+			proposals = append(proposals, e.matchManyToMany(l, r, usedL, usedR)...)
+		}
+	}
+
+	return proposals, unused(l, usedL), unused(r, usedR)
+}
+
+// matchOneToOne pairs each left transaction with the closest eligible right transaction
+func (e *MatchingEngine) matchOneToOne(l, r []models.Transaction, usedL, usedR []bool) []ProposedMatch {
+	if e.amount == nil && e.date == nil && !e.hasFields {
+		// A rule without criteria would match everything with everything
+		return nil
+	}
+
+	var proposals []ProposedMatch
+	for i := range l {
+		if usedL[i] {
+			continue
+		}
+
+		// Prefer the best reference score, then the closest amount, then the closest date
+		best := -1
+		var bestScore float64
+		var bestDiff int64
+		var bestGap int
+		for _, j := range e.window(l[i], r, usedR) {
+			score, ok := e.pairScore(&l[i], &r[j])
+			if !ok {
+				continue
+			}
+
+			amount, _ := e.amountIn(&r[j], l[i].Currency)
+			diff := absInt64(toAmountUnits(l[i].Amount) - amount)
+			gap := daysBetween(l[i].TransactionDate, r[j].TransactionDate)
+			if best == -1 || score > bestScore ||
+				(score == bestScore && (diff < bestDiff || (diff == bestDiff && gap < bestGap))) {
+				best, bestScore, bestDiff, bestGap = j, score, diff, gap
+			}
+		}
+
+		if best >= 0 {
+			usedL[i] = true
+			usedR[best] = true
+			proposals = append(proposals, e.newProposal([]models.Transaction{l[i]}, []models.Transaction{r[best]}, bestScore))
+		}
+	}
+
+	return proposals
+}
+
+// matchOneToMany finds, for each unused anchor, a subset of the pool whose sum matches the anchor.
+// When swapped is true the anchors are right-side transactions and the proposal sides are flipped.
+func (e *MatchingEngine) matchOneToMany(anchors, pool []models.Transaction, usedA, usedP []bool, swapped bool) []ProposedMatch {
+	var proposals []ProposedMatch
+	for i := range anchors {
+		if usedA[i] {
+			continue
+		}
+
+		candidates := e.candidates(anchors[i], pool, usedP)
+		if len(candidates) < 2 {
+			continue
+		}
+
+		// Group members are compared in the anchor's currency
+		converted := false
+		amounts := make([]int64, len(candidates))
+		for n, j := range candidates {
+			amounts[n], _ = e.amountIn(&pool[j], anchors[i].Currency)
+			converted = converted || !sameCurrency(pool[j].Currency, anchors[i].Currency)
+		}
+
+		target := toAmountUnits(anchors[i].Amount)
+		tolerance := e.toleranceFor(target, converted)
+		subset := findSubset(amounts, target, tolerance, 2, e.rule.MaxGroupSize, e.rule.MaxSearchIterations)
+		if subset == nil {
+			continue
+		}
+
+		usedA[i] = true
+		group := make([]models.Transaction, 0, len(subset))
+		for _, n := range subset {
+			usedP[candidates[n]] = true
+			group = append(group, pool[candidates[n]])
+		}
+
+		score := e.groupScore(&anchors[i], group)
+		anchor := []models.Transaction{anchors[i]}
+		if swapped {
+			proposals = append(proposals, e.newProposal(group, anchor, score))
+		} else {
+			proposals = append(proposals, e.newProposal(anchor, group, score))
+		}
+	}
+
+	return proposals
+}
+
+// matchManyToMany looks for groups with at least two transactions on each side.
+// Each unused left transaction seeds a search over nearby transactions on both sides.
+func (e *MatchingEngine) matchManyToMany(l, r []models.Transaction, usedL, usedR []bool) []ProposedMatch {
+	var proposals []ProposedMatch
+	for i := range l {
+		if usedL[i] {
+			continue
+		}
+
+		leftCandidates := append([]int{i}, e.candidates(l[i], l, excluding(usedL, i))...)
+		rightCandidates := e.candidates(l[i], r, usedR)
+		if len(leftCandidates) < 2 || len(rightCandidates) < 2 {
+			continue
+		}
+
+		// Both sides are compared in the seed's currency
+		currency := l[i].Currency
+		converted := false
+		leftAmounts := make([]int64, len(leftCandidates))
+		for n, j := range leftCandidates {
+			leftAmounts[n], _ = e.amountIn(&l[j], currency)
+			converted = converted || !sameCurrency(l[j].Currency, currency)
+		}
+		rightAmounts := make([]int64, len(rightCandidates))
+		for n, j := range rightCandidates {
+			rightAmounts[n], _ = e.amountIn(&r[j], currency)
+			converted = converted || !sameCurrency(r[j].Currency, currency)
+		}
+
+		// Split the search budget between the two sides
+		limit := e.rule.MaxSearchIterations / 2
+		leftSubsets := enumerateSubsets(leftAmounts, true, 2, e.rule.MaxGroupSize, limit)
+		rightSubsets := enumerateSubsets(rightAmounts, false, 2, e.rule.MaxGroupSize, limit)
+		if len(leftSubsets) == 0 || len(rightSubsets) == 0 {
+			continue
+		}
+
+		sort.SliceStable(rightSubsets, func(a, b int) bool { return rightSubsets[a].sum < rightSubsets[b].sum })
+		sort.SliceStable(leftSubsets, func(a, b int) bool { return len(leftSubsets[a].indexes) < len(leftSubsets[b].indexes) })
+
+		for _, ls := range leftSubsets {
+			tolerance := e.toleranceFor(ls.sum, converted)
+			k := sort.Search(len(rightSubsets), func(n int) bool { return rightSubsets[n].sum >= ls.sum-tolerance })
+			if k == len(rightSubsets) || rightSubsets[k].sum > ls.sum+tolerance {
+				continue
+			}
+
+			var leftGroup, rightGroup []models.Transaction
+			for _, n := range ls.indexes {
+				usedL[leftCandidates[n]] = true
+				leftGroup = append(leftGroup, l[leftCandidates[n]])
+			}
+			for _, n := range rightSubsets[k].indexes {
+				usedR[rightCandidates[n]] = true
+				rightGroup = append(rightGroup, r[rightCandidates[n]])
+			}
+
+			score := min(e.groupScore(&l[i], leftGroup), e.groupScore(&l[i], rightGroup))
+			proposals = append(proposals, e.newProposal(leftGroup, rightGroup, score))
+			break
+		}
+	}
+
+	return proposals
+}
+
+// pairScore reports whether two transactions satisfy every enabled criterion of the rule and
+// the reference score of the pair. Transactions in different currencies never match unless the
+// rule compares across currencies.
+func (e *MatchingEngine) pairScore(a, b *models.Transaction) (float64, bool) {
+	if !sameCurrency(a.Currency, b.Currency) && !e.rule.CompareAcrossCurrencies {
+		return 0, false
+	}
+	if e.amount != nil {
+		amount, ok := e.amountIn(b, a.Currency)
+		if !ok {
+			return 0, false
+		}
+		target := toAmountUnits(a.Amount)
+		if absInt64(target-amount) > e.toleranceFor(target, !sameCurrency(a.Currency, b.Currency)) {
+			return 0, false
+		}
+	}
+	if e.date != nil && daysBetween(a.TransactionDate, b.TransactionDate) > e.dateDays {
+		return 0, false
+	}
+	return e.fieldScore(a, b)
+}
+
+// referenceKey returns the reference a transaction is compared by: the token the rule's pattern
+// extracts from the description when there is one, otherwise the transaction's reference
+func (e *MatchingEngine) referenceKey(t *models.Transaction) string {
+	if key, ok := e.keys[t.ID]; ok && t.ID != "" {
+		return key
+	}
+
+	key := t.Reference
+	if e.pattern != nil {
+		if match := e.pattern.FindStringSubmatch(t.Description); match != nil {
+			key = match[0]
+			if len(match) > 1 {
+				key = match[1]
+			}
+		}
+	}
+
+	if t.ID != "" {
+		e.keys[t.ID] = key
+	}
+	return key
+}
+
+// fieldValue returns the value of a condition field other than amount and date
+func (e *MatchingEngine) fieldValue(t *models.Transaction, field string) string {
+	switch field {
+	case models.ConditionFieldReference:
+		return e.referenceKey(t)
+	case models.ConditionFieldDescription:
+		return t.Description
+	case models.ConditionFieldCurrency:
+		return t.Currency
+	case models.ConditionFieldExternalID:
+		return t.ExternalID
+	}
+	return t.CustomFields[strings.TrimPrefix(field, models.ConditionFieldCustomPrefix)]
+}
+
+// fieldScore evaluates the rule's field conditions in order. It returns the weakest score and
+// whether every condition holds; a rule without field conditions scores 1.
+func (e *MatchingEngine) fieldScore(a, b *models.Transaction) (float64, bool) {
+	score := 1.0
+	for i := range e.fields {
+		s, ok := e.fields[i].score(e.fieldValue(a, e.fields[i].Field), e.fieldValue(b, e.fields[i].Field))
+		if !ok {
+			return 0, false
+		}
+		score = min(score, s)
+	}
+	return score, true
+}
+
+// groupScore returns the weakest field score between an anchor and the other members of a group
+func (e *MatchingEngine) groupScore(anchor *models.Transaction, group []models.Transaction) float64 {
+	score := 1.0
+	if !e.hasFields {
+		return score
+	}
+
+	for i := range group {
+		if group[i].ID == anchor.ID {
+			continue
+		}
+		s, _ := e.fieldScore(anchor, &group[i])
+		score = min(score, s)
+	}
+	return score
+}
+
+// window returns the unused indexes of pool that fall inside the rule's date window around anchor.
+// pool must be sorted by transaction date.
+func (e *MatchingEngine) window(anchor models.Transaction, pool []models.Transaction, used []bool) []int {
+	lo, hi := 0, len(pool)
+	if e.date != nil {
+		from := anchor.TransactionDate.AddDate(0, 0, -e.dateDays-1)
+		to := anchor.TransactionDate.AddDate(0, 0, e.dateDays+1)
+		lo = sort.Search(len(pool), func(n int) bool { return !pool[n].TransactionDate.Before(from) })
+		hi = sort.Search(len(pool), func(n int) bool { return pool[n].TransactionDate.After(to) })
+	}
+
+	var indexes []int
+	for j := lo; j < hi; j++ {
+		if !used[j] {
+			indexes = append(indexes, j)
+		}
+	}
+	return indexes
+}
+
+// candidates returns group members for an anchor: unused pool transactions within the date
+// window that satisfy the rule's field conditions against it and whose amount can be expressed
+// in the anchor's currency, closest in date first, capped at MaxCandidates
+func (e *MatchingEngine) candidates(anchor models.Transaction, pool []models.Transaction, used []bool) []int {
+	var indexes []int
+	for _, j := range e.window(anchor, pool, used) {
+		if _, ok := e.amountIn(&pool[j], anchor.Currency); !ok {
+			continue
+		}
+		if e.date != nil && daysBetween(anchor.TransactionDate, pool[j].TransactionDate) > e.dateDays {
+			continue
+		}
+		if _, ok := e.fieldScore(&anchor, &pool[j]); !ok {
+			continue
+		}
+		indexes = append(indexes, j)
+	}
+
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return daysBetween(anchor.TransactionDate, pool[indexes[a]].TransactionDate) <
+			daysBetween(anchor.TransactionDate, pool[indexes[b]].TransactionDate)
+	})
+
+	if e.rule.MaxCandidates > 0 && len(indexes) > e.rule.MaxCandidates {
+		indexes = indexes[:e.rule.MaxCandidates]
+	}
+	return indexes
+}
+
+// findSubset searches for a subset of amounts with minSize..maxSize members whose sum is within
+// tolerance of target. The search stops after maxIterations steps. Returns the chosen indexes or nil.
+func findSubset(amounts []int64, target, tolerance int64, minSize, maxSize, maxIterations int) []int {
+	n := len(amounts)
+
+	// Suffix sums of positive and negative amounts bound what the rest of the search can still add
+	sufPos := make([]int64, n+1)
+	sufNeg := make([]int64, n+1)
+	for i := n - 1; i >= 0; i-- {
+		sufPos[i], sufNeg[i] = sufPos[i+1], sufNeg[i+1]
+		if amounts[i] > 0 {
+			sufPos[i] += amounts[i]
+		} else {
+			sufNeg[i] += amounts[i]
+		}
+	}
+
+	iterations := 0
+	chosen := make([]int, 0, maxSize)
+	var search func(start int, sum int64) bool
+	search = func(start int, sum int64) bool {
+		iterations++
+		if len(chosen) >= minSize && absInt64(sum-target) <= tolerance {
+			return true
+		}
+		if len(chosen) == maxSize || iterations >= maxIterations {
+			return false
+		}
+		if sum+sufPos[start] < target-tolerance || sum+sufNeg[start] > target+tolerance {
+			return false
+		}
+
+		for i := start; i < n; i++ {
+			chosen = append(chosen, i)
+			if search(i+1, sum+amounts[i]) {
+				return true
+			}
+			chosen = chosen[:len(chosen)-1]
+			if iterations >= maxIterations {
+				return false
+			}
+		}
+		return false
+	}
+
+	if search(0, 0) {
+		return append([]int(nil), chosen...)
+	}
+	return nil
+}
+
+// amountSubset is a candidate group and its total
+type amountSubset struct {
+	indexes []int
+	sum     int64
+}
+
+// enumerateSubsets lists subsets of amounts with minSize..maxSize members, up to limit subsets.
+// When includeFirst is true every subset contains index 0.
+func enumerateSubsets(amounts []int64, includeFirst bool, minSize, maxSize, limit int) []amountSubset {
+	var subsets []amountSubset
+	chosen := make([]int, 0, maxSize)
+
+	var walk func(start int, sum int64)
+	walk = func(start int, sum int64) {
+		if len(subsets) >= limit {
+			return
+		}
+		if len(chosen) >= minSize {
+			subsets = append(subsets, amountSubset{indexes: append([]int(nil), chosen...), sum: sum})
+		}
+		if len(chosen) == maxSize {
+			return
+		}
+		for i := start; i < len(amounts); i++ {
+			chosen = append(chosen, i)
+			walk(i+1, sum+amounts[i])
+			chosen = chosen[:len(chosen)-1]
+		}
+	}
+
+	if includeFirst {
+		if len(amounts) == 0 {
+			return nil
+		}
+		chosen = append(chosen, 0)
+		walk(1, amounts[0])
+	} else {
+		walk(0, 0)
+	}
+	return subsets
+}
+
+// amountIn returns the transaction's amount in currency as integer units. It fails when the
+// currencies differ and either the rule does not compare across currencies or no rate is known.
+func (e *MatchingEngine) amountIn(t *models.Transaction, currency string) (int64, bool) {
+	if sameCurrency(t.Currency, currency) {
+		return toAmountUnits(t.Amount), true
+	}
+	if !e.rule.CompareAcrossCurrencies {
+		return 0, false
+	}
+
+	converted, ok := e.rates.Convert(t.Amount, t.Currency, currency, t.TransactionDate)
+	if !ok {
+		return 0, false
+	}
+	return toAmountUnits(converted), true
+}
+
+// toleranceFor returns the allowed difference when matching target. An eq amount condition
+// allows none; within allows the larger of its tolerance and the rule's percentage tolerance.
+// Either is widened by the FX tolerance when amounts were converted.
+func (e *MatchingEngine) toleranceFor(target int64, converted bool) int64 {
+	var tolerance int64
+	if e.amount.Operator == models.ConditionOpWithin {
+		tolerance = toAmountUnits(math.Abs(e.amount.Tolerance))
+		if pct := percentOf(target, e.rule.AmountTolerancePct); pct > tolerance {
+			tolerance = pct
+		}
+	}
+	if converted {
+		tolerance += percentOf(target, e.rule.FXTolerancePct)
+	}
+	return tolerance
+}
+
+// newProposal builds a proposal and records the difference between the two sides
+// in the currency of the first left transaction
+func (e *MatchingEngine) newProposal(left, right []models.Transaction, score float64) ProposedMatch {
+	currency := left[0].Currency
+
+	var diff int64
+	for i := range left {
+		amount, _ := e.amountIn(&left[i], currency)
+		diff += amount
+	}
+	for i := range right {
+		amount, _ := e.amountIn(&right[i], currency)
+		diff -= amount
+	}
+
+	return ProposedMatch{
+		Left:             left,
+		Right:            right,
+		AmountDifference: float64(diff) / amountScale,
+		Currency:         currency,
+		Score:            score,
+	}
+}
+
+// sortedTransactions returns a copy of transactions ordered by date, then ID
+func sortedTransactions(transactions []models.Transaction) []models.Transaction {
+	sorted := append([]models.Transaction(nil), transactions...)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		if !sorted[a].TransactionDate.Equal(sorted[b].TransactionDate) {
+			return sorted[a].TransactionDate.Before(sorted[b].TransactionDate)
+		}
+		return sorted[a].ID < sorted[b].ID
+	})
+	return sorted
+}
+
+// unused returns the transactions not marked as used
+func unused(transactions []models.Transaction, used []bool) []models.Transaction {
+	var remaining []models.Transaction
+	for i, t := range transactions {
+		if !used[i] {
+			remaining = append(remaining, t)
+		}
+	}
+	return remaining
+}
+
+// excluding returns a copy of used with index i also marked as used
+func excluding(used []bool, i int) []bool {
+	copied := append([]bool(nil), used...)
+	copied[i] = true
+	return copied
+}
+
+// toAmountUnits converts an amount to integer units of amountScale
+func toAmountUnits(amount float64) int64 {
+	return int64(math.Round(amount * amountScale))
+}
+
+// percentOf returns pct percent of amount, in the same units
+func percentOf(amount int64, pct float64) int64 {
+	return int64(math.Round(math.Abs(float64(amount)) * math.Abs(pct) / 100))
+}
+
+// sameCurrency reports whether two currency codes are the same.
+// A transaction without a currency is assumed to be in the other transaction's currency.
+func sameCurrency(a, b string) bool {
+	return a == "" || b == "" || strings.EqualFold(a, b)
+}
+
+// daysBetween returns the absolute number of calendar days between two dates
+func daysBetween(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	da := time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)
+	db := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
+	days := int(da.Sub(db).Hours() / 24)
+	if days < 0 {
+		return -days
+	}
+	return days
+}
+
+func absInt64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
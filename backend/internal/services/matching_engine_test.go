@@ -0,0 +1,349 @@
+package services
+
+import (
+	"backend/internal/models"
+	"sort"
+	"testing"
+	"time"
+)
+
+func tx(id string, amount float64, day int, reference string) models.Transaction {
+	return models.Transaction{
+		ID:              id,
+		TenantID:        "tenant-1",
+		Amount:          amount,
+		TransactionDate: time.Date(2024, time.March, day, 0, 0, 0, 0, time.UTC),
+		Reference:       reference,
+	}
+}
+
+func fxTx(id string, amount float64, day int, currency string) models.Transaction {
+	t := tx(id, amount, day, "")
+	t.Currency = currency
+	return t
+}
+
+func groupIDs(proposal ProposedMatch) (left, right []string) {
+	for _, t := range proposal.Left {
+		left = append(left, t.ID)
+	}
+	for _, t := range proposal.Right {
+		right = append(right, t.ID)
+	}
+	sort.Strings(left)
+	sort.Strings(right)
+	return left, right
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func groupRule(cardinality string) *models.MatchRule {
+	return &models.MatchRule{
+		MatchByAmount:       true,
+		MatchByDate:         true,
+		DateTolerance:       2,
+		MatchCardinality:    cardinality,
+		MaxGroupSize:        4,
+		MaxCandidates:       10,
+		MaxSearchIterations: 10000,
+	}
+}
+
+func TestMatchingEngine_OneToOne(t *testing.T) {
+	engine := NewMatchingEngine(groupRule(models.MatchCardinalityOneToOne), nil)
+
+	left := []models.Transaction{tx("L1", 100, 1, ""), tx("L2", 50, 5, "")}
+	right := []models.Transaction{tx("R1", 50, 6, ""), tx("R2", 100, 2, ""), tx("R3", 100, 20, "")}
+
+	proposals, unmatchedLeft, unmatchedRight := engine.Match(left, right)
+	if len(proposals) != 2 {
+		t.Fatalf("Match() proposals = %d, want 2", len(proposals))
+	}
+	if len(unmatchedLeft) != 0 {
+		t.Errorf("Match() unmatched left = %d, want 0", len(unmatchedLeft))
+	}
+	if len(unmatchedRight) != 1 || unmatchedRight[0].ID != "R3" {
+		t.Errorf("Match() unmatched right = %v, want [R3]", unmatchedRight)
+	}
+}
+
+func TestMatchingEngine_OneToOneIgnoresGroups(t *testing.T) {
+	engine := NewMatchingEngine(groupRule(models.MatchCardinalityOneToOne), nil)
+
+	left := []models.Transaction{tx("L1", 100, 1, "")}
+	right := []models.Transaction{tx("R1", 60, 1, ""), tx("R2", 40, 1, "")}
+
+	proposals, _, _ := engine.Match(left, right)
+	if len(proposals) != 0 {
+		t.Errorf("Match() proposals = %d, want 0 for a 1:1 rule", len(proposals))
+	}
+}
+
+func TestMatchingEngine_OneToMany(t *testing.T) {
+	engine := NewMatchingEngine(groupRule(models.MatchCardinalityOneToMany), nil)
+
+	// One deposit against a batch of receipts
+	left := []models.Transaction{tx("DEP", 1000, 10, "")}
+	right := []models.Transaction{
+		tx("R1", 250, 9, ""),
+		tx("R2", 300, 10, ""),
+		tx("R3", 450, 11, ""),
+		tx("R4", 999, 30, ""), // outside the date window
+	}
+
+	proposals, unmatchedLeft, unmatchedRight := engine.Match(left, right)
+	if len(proposals) != 1 {
+		t.Fatalf("Match() proposals = %d, want 1", len(proposals))
+	}
+
+	gotLeft, gotRight := groupIDs(proposals[0])
+	if !equalIDs(gotLeft, []string{"DEP"}) || !equalIDs(gotRight, []string{"R1", "R2", "R3"}) {
+		t.Errorf("Match() group = %v / %v, want [DEP] / [R1 R2 R3]", gotLeft, gotRight)
+	}
+	if len(unmatchedLeft) != 0 || len(unmatchedRight) != 1 {
+		t.Errorf("Match() leftovers = %d / %d, want 0 / 1", len(unmatchedLeft), len(unmatchedRight))
+	}
+}
+
+func TestMatchingEngine_ManyToOne(t *testing.T) {
+	engine := NewMatchingEngine(groupRule(models.MatchCardinalityOneToMany), nil)
+
+	// Several card settlements against one payout
+	left := []models.Transaction{tx("S1", 120.10, 3, ""), tx("S2", 79.90, 4, "")}
+	right := []models.Transaction{tx("PAYOUT", 200, 4, "")}
+
+	proposals, _, _ := engine.Match(left, right)
+	if len(proposals) != 1 {
+		t.Fatalf("Match() proposals = %d, want 1", len(proposals))
+	}
+
+	gotLeft, gotRight := groupIDs(proposals[0])
+	if !equalIDs(gotLeft, []string{"S1", "S2"}) || !equalIDs(gotRight, []string{"PAYOUT"}) {
+		t.Errorf("Match() group = %v / %v, want [S1 S2] / [PAYOUT]", gotLeft, gotRight)
+	}
+}
+
+func TestMatchingEngine_ManyToMany(t *testing.T) {
+	engine := NewMatchingEngine(groupRule(models.MatchCardinalityManyToMany), nil)
+
+	left := []models.Transaction{tx("L1", 70, 1, ""), tx("L2", 30, 2, "")}
+	right := []models.Transaction{tx("R1", 55, 1, ""), tx("R2", 45, 2, "")}
+
+	proposals, unmatchedLeft, unmatchedRight := engine.Match(left, right)
+	if len(proposals) != 1 {
+		t.Fatalf("Match() proposals = %d, want 1", len(proposals))
+	}
+
+	gotLeft, gotRight := groupIDs(proposals[0])
+	if !equalIDs(gotLeft, []string{"L1", "L2"}) || !equalIDs(gotRight, []string{"R1", "R2"}) {
+		t.Errorf("Match() group = %v / %v, want [L1 L2] / [R1 R2]", gotLeft, gotRight)
+	}
+	if len(unmatchedLeft) != 0 || len(unmatchedRight) != 0 {
+		t.Errorf("Match() leftovers = %d / %d, want 0 / 0", len(unmatchedLeft), len(unmatchedRight))
+	}
+}
+
+func TestMatchingEngine_GroupTolerance(t *testing.T) {
+	rule := groupRule(models.MatchCardinalityOneToMany)
+
+	left := []models.Transaction{tx("DEP", 100, 1, "")}
+	right := []models.Transaction{tx("R1", 60, 1, ""), tx("R2", 39.98, 1, "")}
+
+	proposals, _, _ := NewMatchingEngine(rule, nil).Match(left, right)
+	if len(proposals) != 0 {
+		t.Fatalf("Match() without tolerance proposals = %d, want 0", len(proposals))
+	}
+
+	rule.AmountTolerance = 0.05
+	proposals, _, _ = NewMatchingEngine(rule, nil).Match(left, right)
+	if len(proposals) != 1 {
+		t.Fatalf("Match() with tolerance proposals = %d, want 1", len(proposals))
+	}
+	if diff := proposals[0].AmountDifference; diff < 0.0199 || diff > 0.0201 {
+		t.Errorf("AmountDifference = %v, want 0.02", diff)
+	}
+}
+
+func TestMatchingEngine_PercentTolerance(t *testing.T) {
+	rule := groupRule(models.MatchCardinalityOneToOne)
+	rule.AmountTolerance = 0.50
+	rule.AmountTolerancePct = 0.1
+
+	// A 1000.00 wire that arrives 0.95 short after bank fees: 0.1% of 1000 allows 1.00
+	left := []models.Transaction{tx("L1", 1000, 1, ""), tx("L2", 10, 1, "")}
+	right := []models.Transaction{tx("R1", 999.05, 1, ""), tx("R2", 9.45, 1, "")}
+
+	proposals, _, unmatchedRight := NewMatchingEngine(rule, nil).Match(left, right)
+	if len(proposals) != 1 || proposals[0].Right[0].ID != "R1" {
+		t.Fatalf("Match() = %v, want only L1 matched to R1", proposals)
+	}
+	// For the small amount the absolute tolerance of 0.50 is the larger one and 0.55 exceeds it
+	if len(unmatchedRight) != 1 || unmatchedRight[0].ID != "R2" {
+		t.Errorf("Match() unmatched right = %v, want [R2]", unmatchedRight)
+	}
+}
+
+func TestMatchingEngine_CurrencyMismatch(t *testing.T) {
+	rule := groupRule(models.MatchCardinalityOneToMany)
+
+	left := []models.Transaction{fxTx("L1", 100, 1, "USD")}
+	right := []models.Transaction{fxTx("R1", 100, 1, "EUR"), fxTx("R2", 60, 1, "EUR"), fxTx("R3", 40, 1, "EUR")}
+
+	proposals, _, _ := NewMatchingEngine(rule, nil).Match(left, right)
+	if len(proposals) != 0 {
+		t.Errorf("Match() proposals = %d, want 0 across currencies when the rule does not allow it", len(proposals))
+	}
+}
+
+func TestMatchingEngine_AcrossCurrencies(t *testing.T) {
+	rates := NewFXRateTable([]models.FXRate{
+		{BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: 1.10, RateDate: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)},
+	})
+
+	rule := groupRule(models.MatchCardinalityOneToOne)
+	rule.CompareAcrossCurrencies = true
+
+	// 100 EUR is 110 USD; the statement shows 110.80 USD after the bank's spread
+	left := []models.Transaction{fxTx("L1", 110.80, 3, "USD")}
+	right := []models.Transaction{fxTx("R1", 100, 3, "EUR")}
+
+	proposals, _, _ := NewMatchingEngine(rule, rates).Match(left, right)
+	if len(proposals) != 0 {
+		t.Fatalf("Match() without FX tolerance proposals = %d, want 0", len(proposals))
+	}
+
+	rule.FXTolerancePct = 1
+	proposals, _, _ = NewMatchingEngine(rule, rates).Match(left, right)
+	if len(proposals) != 1 {
+		t.Fatalf("Match() with FX tolerance proposals = %d, want 1", len(proposals))
+	}
+	if proposals[0].Currency != "USD" {
+		t.Errorf("Currency = %q, want USD", proposals[0].Currency)
+	}
+	if diff := proposals[0].AmountDifference; diff < 0.7999 || diff > 0.8001 {
+		t.Errorf("AmountDifference = %v, want 0.80", diff)
+	}
+
+	// Without a rate for the period nothing can be compared
+	proposals, _, _ = NewMatchingEngine(rule, NewFXRateTable(nil)).Match(left, right)
+	if len(proposals) != 0 {
+		t.Errorf("Match() without rates proposals = %d, want 0", len(proposals))
+	}
+}
+
+func TestMatchingEngine_GroupSizeCap(t *testing.T) {
+	rule := groupRule(models.MatchCardinalityOneToMany)
+	rule.MaxGroupSize = 2
+
+	left := []models.Transaction{tx("DEP", 30, 1, "")}
+	right := []models.Transaction{tx("R1", 10, 1, ""), tx("R2", 10, 1, ""), tx("R3", 10, 1, "")}
+
+	proposals, _, _ := NewMatchingEngine(rule, nil).Match(left, right)
+	if len(proposals) != 0 {
+		t.Errorf("Match() proposals = %d, want 0 when the group exceeds MaxGroupSize", len(proposals))
+	}
+}
+
+func TestMatchingEngine_ReferenceRequired(t *testing.T) {
+	rule := groupRule(models.MatchCardinalityOneToOne)
+	rule.MatchByReference = true
+
+	left := []models.Transaction{tx("L1", 100, 1, "INV-1")}
+	right := []models.Transaction{tx("R1", 100, 1, "INV-2"), tx("R2", 100, 1, "INV-1")}
+
+	proposals, _, _ := NewMatchingEngine(rule, nil).Match(left, right)
+	if len(proposals) != 1 || proposals[0].Right[0].ID != "R2" {
+		t.Errorf("Match() = %v, want L1 matched to R2", proposals)
+	}
+}
+
+func TestMatchingEngine_NormalizedReference(t *testing.T) {
+	rule := groupRule(models.MatchCardinalityOneToOne)
+	rule.MatchByReference = true
+	rule.ReferenceMode = models.ReferenceModeNormalized
+
+	left := []models.Transaction{tx("L1", 100, 1, "INV-00042")}
+	right := []models.Transaction{tx("R1", 100, 1, "inv 42")}
+
+	proposals, _, _ := NewMatchingEngine(rule, nil).Match(left, right)
+	if len(proposals) != 1 || proposals[0].Score != 1 {
+		t.Errorf("Match() = %v, want L1 matched to R1 with score 1", proposals)
+	}
+}
+
+func TestMatchingEngine_ReferencePattern(t *testing.T) {
+	rule := groupRule(models.MatchCardinalityOneToOne)
+	rule.MatchByReference = true
+	rule.ReferencePattern = `INV-(\d+)`
+
+	// The bank side only carries the invoice number inside its narrative
+	left := []models.Transaction{tx("GL1", 100, 1, "1042"), tx("GL2", 100, 1, "1043")}
+	bank := tx("B1", 100, 1, "")
+	bank.Description = "ACH CREDIT ACME CORP INV-1043 THANK YOU"
+	right := []models.Transaction{bank}
+
+	proposals, _, _ := NewMatchingEngine(rule, nil).Match(left, right)
+	if len(proposals) != 1 || proposals[0].Left[0].ID != "GL2" {
+		t.Errorf("Match() = %v, want GL2 matched to B1", proposals)
+	}
+}
+
+func TestMatchingEngine_SimilarityScore(t *testing.T) {
+	rule := groupRule(models.MatchCardinalityOneToOne)
+	rule.MatchByReference = true
+	rule.ReferenceMode = models.ReferenceModeSimilarity
+	rule.SimilarityAlgorithm = models.SimilarityLevenshtein
+	rule.SimilarityThreshold = 0.8
+
+	left := []models.Transaction{tx("L1", 100, 1, "PO-558123")}
+	right := []models.Transaction{
+		tx("R1", 100, 1, "PO558124"), // One digit off
+		tx("R2", 100, 1, "PO558123"), // Same after normalization
+		tx("R3", 100, 1, "REFUND"),
+	}
+
+	proposals, _, _ := NewMatchingEngine(rule, nil).Match(left, right)
+	if len(proposals) != 1 || proposals[0].Right[0].ID != "R2" || proposals[0].Score != 1 {
+		t.Fatalf("Match() = %v, want L1 matched to R2 with score 1", proposals)
+	}
+
+	// Without the exact candidate the near miss is taken and its score recorded
+	proposals, _, _ = NewMatchingEngine(rule, nil).Match(left, []models.Transaction{right[0], right[2]})
+	if len(proposals) != 1 || proposals[0].Right[0].ID != "R1" {
+		t.Fatalf("Match() = %v, want L1 matched to R1", proposals)
+	}
+	if score := proposals[0].Score; score < 0.87 || score > 0.88 {
+		t.Errorf("Score = %v, want 7/8", score)
+	}
+}
+
+func TestFindSubset(t *testing.T) {
+	amounts := []int64{500, 300, 200, 100}
+
+	if got := findSubset(amounts, 600, 0, 2, 3, 1000); got == nil {
+		t.Errorf("findSubset() = nil, want a subset summing to 600")
+	}
+
+	if got := findSubset(amounts, 1100, 0, 2, 3, 1000); got != nil {
+		t.Errorf("findSubset() = %v, want nil when more than 3 members are needed", got)
+	}
+
+	if got := findSubset(amounts, 5000, 0, 2, 4, 1000); got != nil {
+		t.Errorf("findSubset() = %v, want nil for an unreachable target", got)
+	}
+
+	// The iteration cap stops the search even if a subset exists
+	if got := findSubset(amounts, 600, 0, 2, 3, 1); got != nil {
+		t.Errorf("findSubset() = %v, want nil when the iteration cap is reached", got)
+	}
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// runLeaseDuration is how long a run holds its match set without renewing the lease. A crashed
+// run blocks its match set for at most this long.
+const runLeaseDuration = 2 * time.Minute
+
+// runLeaseRenewal is how often a running run renews its lease. A cancellation requested on
+// another instance reaches the run within this time.
+const runLeaseRenewal = runLeaseDuration / 4
+
+// ErrRunCancelled stops a run whose cancellation was requested
+var ErrRunCancelled = errors.New("match set run cancelled")
+
+// runLease keeps a run's lease on its match set alive while the run works. Its context is
+// cancelled when the run is cancelled or loses the lease; the cause tells which.
+type runLease struct {
+	progressRepo repository.MatchProgressRepository
+	tenantID     string
+	matchSetID   string
+	runID        string
+	ctx          context.Context
+	cancel       context.CancelCauseFunc
+	stop         chan struct{}
+	stopped      sync.WaitGroup
+}
+
+// holdLease renews the lease of a run in the background until it is released. The lease is
+// registered so the run can be cancelled from this instance at once.
+func (s *MatchSetService) holdLease(tenantID string, progress *models.MatchProgress) *runLease {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	lease := &runLease{
+		progressRepo: s.progressRepo,
+		tenantID:     tenantID,
+		matchSetID:   progress.MatchSetID,
+		runID:        progress.RunID,
+		ctx:          ctx,
+		cancel:       cancel,
+		stop:         make(chan struct{}),
+	}
+
+	s.runsMu.Lock()
+	s.runs[lease.matchSetID] = lease
+	s.runsMu.Unlock()
+
+	lease.stopped.Add(1)
+	go lease.renew()
+	return lease
+}
+
+// releaseLease stops renewing a run's lease. The lease itself ends when the run saves its final
+// progress.
+func (s *MatchSetService) releaseLease(lease *runLease) {
+	close(lease.stop)
+	lease.stopped.Wait()
+	lease.cancel(nil)
+
+	s.runsMu.Lock()
+	if s.runs[lease.matchSetID] == lease {
+		delete(s.runs, lease.matchSetID)
+	}
+	s.runsMu.Unlock()
+}
+
+// activeLease returns the lease of a run of a match set working on this instance
+func (s *MatchSetService) activeLease(matchSetID, runID string) *runLease {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+
+	if lease := s.runs[matchSetID]; lease != nil && lease.runID == runID {
+		return lease
+	}
+	return nil
+}
+
+// renew extends the lease until it is released or lost, and picks up cancellation requests
+func (l *runLease) renew() {
+	defer l.stopped.Done()
+
+	ticker := time.NewTicker(runLeaseRenewal)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			cancelRequested, err := l.progressRepo.RenewRunLease(l.tenantID, l.matchSetID, l.runID, runLeaseDuration)
+			if err == repository.ErrRunLeaseLost {
+				log.Printf("Run %s of match set %s lost its lease", l.runID, l.matchSetID)
+				l.cancel(repository.ErrRunLeaseLost)
+				return
+			}
+			if err != nil {
+				// Retried on the next tick; the lease outlives a few failed renewals
+				log.Printf("Failed to renew lease of run %s of match set %s: %v", l.runID, l.matchSetID, err)
+				continue
+			}
+			if cancelRequested {
+				l.cancel(ErrRunCancelled)
+			}
+		}
+	}
+}
+
+// err returns why the run must stop: ErrRunCancelled, repository.ErrRunLeaseLost, or nil while
+// it may go on
+func (l *runLease) err() error {
+	return context.Cause(l.ctx)
+}
@@ -5,6 +5,8 @@ import (
 	"backend/internal/repository"
 	"errors"
 	"log"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -15,6 +17,15 @@ type MatchSetService struct {
 	dataSourceRepo  repository.DataSourceRepository
 	transactionRepo repository.TransactionRepository
 	permissionRepo  repository.PermissionRepository
+	matchRepo       repository.MatchRepository
+	unmatchedRepo   repository.UnmatchedTransactionRepository
+	progressRepo    repository.MatchProgressRepository
+	fxRateRepo      repository.FXRateRepository
+	policyRepo      repository.ApprovalPolicyRepository
+
+	// Runs working on this instance, by match set
+	runsMu sync.Mutex
+	runs   map[string]*runLease
 }
 
 // NewMatchSetService creates a new match set service
@@ -24,6 +35,11 @@ func NewMatchSetService(
 	dataSourceRepo repository.DataSourceRepository,
 	transactionRepo repository.TransactionRepository,
 	permissionRepo repository.PermissionRepository,
+	matchRepo repository.MatchRepository,
+	unmatchedRepo repository.UnmatchedTransactionRepository,
+	progressRepo repository.MatchProgressRepository,
+	fxRateRepo repository.FXRateRepository,
+	policyRepo repository.ApprovalPolicyRepository,
 ) *MatchSetService {
 	return &MatchSetService{
 		matchSetRepo:    matchSetRepo,
@@ -31,6 +47,12 @@ func NewMatchSetService(
 		dataSourceRepo:  dataSourceRepo,
 		transactionRepo: transactionRepo,
 		permissionRepo:  permissionRepo,
+		matchRepo:       matchRepo,
+		unmatchedRepo:   unmatchedRepo,
+		progressRepo:    progressRepo,
+		fxRateRepo:      fxRateRepo,
+		policyRepo:      policyRepo,
+		runs:            make(map[string]*runLease),
 	}
 }
 
@@ -45,6 +67,11 @@ func (s *MatchSetService) CreateMatchSet(name, description, tenantID, ruleID, us
 		return nil, errors.New("unauthorized: requires create match set permission")
 	}
 
+	// Ensure the rule exists in the tenant
+	if _, err := s.ruleRepo.GetRuleByID(tenantID, ruleID); err != nil {
+		return nil, err
+	}
+
 	// Create the match set
 	matchSet := &models.MatchSet{
 		Name:        name,
@@ -73,7 +100,7 @@ func (s *MatchSetService) GetMatchSetByID(id, userID, tenantID string) (*models.
 	}
 
 	// Get the match set
-	matchSet, err := s.matchSetRepo.GetMatchSetByID(id)
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, id)
 	if err != nil {
 		return nil, err
 	}
@@ -113,7 +140,7 @@ func (s *MatchSetService) UpdateMatchSet(id, name, description, ruleID, userID,
 	}
 
 	// Get the existing match set
-	matchSet, err := s.matchSetRepo.GetMatchSetByID(id)
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, id)
 	if err != nil {
 		return nil, err
 	}
@@ -123,6 +150,11 @@ func (s *MatchSetService) UpdateMatchSet(id, name, description, ruleID, userID,
 		return nil, errors.New("match set not found in this tenant")
 	}
 
+	// Ensure the rule exists in the tenant
+	if _, err := s.ruleRepo.GetRuleByID(tenantID, ruleID); err != nil {
+		return nil, err
+	}
+
 	// Update the match set
 	matchSet.Name = name
 	matchSet.Description = description
@@ -147,7 +179,7 @@ func (s *MatchSetService) DeleteMatchSet(id, userID, tenantID string) error {
 	}
 
 	// Get the existing match set
-	matchSet, err := s.matchSetRepo.GetMatchSetByID(id)
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, id)
 	if err != nil {
 		return err
 	}
@@ -158,7 +190,7 @@ func (s *MatchSetService) DeleteMatchSet(id, userID, tenantID string) error {
 	}
 
 	// Delete the match set
-	return s.matchSetRepo.DeleteMatchSet(id)
+	return s.matchSetRepo.DeleteMatchSet(tenantID, id)
 }
 
 // AddDataSourceToMatchSet adds a data source to a match set
@@ -173,7 +205,7 @@ func (s *MatchSetService) AddDataSourceToMatchSet(matchSetID, dataSourceID, user
 	}
 
 	// Get the match set
-	matchSet, err := s.matchSetRepo.GetMatchSetByID(matchSetID)
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
 	if err != nil {
 		return err
 	}
@@ -183,17 +215,11 @@ func (s *MatchSetService) AddDataSourceToMatchSet(matchSetID, dataSourceID, user
 		return errors.New("match set not found in this tenant")
 	}
 
-	// Get the data source
-	dataSource, err := s.dataSourceRepo.GetDataSourceByID(dataSourceID)
-	if err != nil {
+	// Ensure the data source exists in the tenant
+	if _, err := s.dataSourceRepo.GetDataSourceByID(tenantID, dataSourceID); err != nil {
 		return err
 	}
 
-	// Ensure the data source belongs to the tenant
-	if dataSource.TenantID != tenantID {
-		return errors.New("data source not found in this tenant")
-	}
-
 	// Add the data source to the match set
 	return s.matchSetRepo.AddDataSourceToMatchSet(matchSetID, dataSourceID)
 }
@@ -210,7 +236,7 @@ func (s *MatchSetService) RemoveDataSourceFromMatchSet(matchSetID, dataSourceID,
 	}
 
 	// Get the match set
-	matchSet, err := s.matchSetRepo.GetMatchSetByID(matchSetID)
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
 	if err != nil {
 		return err
 	}
@@ -236,7 +262,7 @@ func (s *MatchSetService) GetMatchSetDataSources(matchSetID, userID, tenantID st
 	}
 
 	// Get the match set
-	matchSet, err := s.matchSetRepo.GetMatchSetByID(matchSetID)
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
 	if err != nil {
 		return nil, err
 	}
@@ -247,22 +273,22 @@ func (s *MatchSetService) GetMatchSetDataSources(matchSetID, userID, tenantID st
 	}
 
 	// Get data sources
-	return s.matchSetRepo.GetMatchSetDataSources(matchSetID)
+	return s.matchSetRepo.GetMatchSetDataSources(tenantID, matchSetID)
 }
 
-// RunMatchSet executes the matching process for a specific match set
-func (s *MatchSetService) RunMatchSet(matchSetID, userID, tenantID string) error {
-	// Check if user has permission to match transactions
-	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermMatchTransactions, tenantID)
+// SetMatchSetRule adds a rule to a match set or changes its priority. Lower priorities run first.
+func (s *MatchSetService) SetMatchSetRule(matchSetID, ruleID string, priority int, userID, tenantID string) error {
+	// Check if user has permission to update match sets
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermUpdateMatchSet, tenantID)
 	if err != nil {
 		return err
 	}
 	if !hasPermission {
-		return errors.New("unauthorized: requires match transactions permission")
+		return errors.New("unauthorized: requires update match set permission")
 	}
 
 	// Get the match set
-	matchSet, err := s.matchSetRepo.GetMatchSetByID(matchSetID)
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
 	if err != nil {
 		return err
 	}
@@ -272,30 +298,633 @@ func (s *MatchSetService) RunMatchSet(matchSetID, userID, tenantID string) error
 		return errors.New("match set not found in this tenant")
 	}
 
-	// Get the match rule
-	rule, err := s.ruleRepo.GetRuleByID(matchSet.RuleID)
+	// Ensure the rule exists in the tenant
+	if _, err := s.ruleRepo.GetRuleByID(tenantID, ruleID); err != nil {
+		return err
+	}
+
+	return s.matchSetRepo.SetMatchSetRule(matchSetID, ruleID, priority)
+}
+
+// RemoveRuleFromMatchSet removes a rule from a match set
+func (s *MatchSetService) RemoveRuleFromMatchSet(matchSetID, ruleID, userID, tenantID string) error {
+	// Check if user has permission to update match sets
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermUpdateMatchSet, tenantID)
 	if err != nil {
 		return err
 	}
+	if !hasPermission {
+		return errors.New("unauthorized: requires update match set permission")
+	}
 
-	// Get data sources for this match set
-	dataSources, err := s.matchSetRepo.GetMatchSetDataSources(matchSetID)
+	// Get the match set
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
 	if err != nil {
 		return err
 	}
 
-	// Process is started - would typically queue this for async processing
-	// For this implementation, we'll run it synchronously
-	log.Printf("Starting matching process for match set %s with rule %s", matchSet.Name, rule.Name)
-	log.Printf("Using %d data sources for matching", len(dataSources))
+	// Ensure the match set belongs to the tenant
+	if matchSet.TenantID != tenantID {
+		return errors.New("match set not found in this tenant")
+	}
+
+	return s.matchSetRepo.RemoveRuleFromMatchSet(matchSetID, ruleID)
+}
+
+// GetMatchSetRules retrieves the rules of a match set in the order they run
+func (s *MatchSetService) GetMatchSetRules(matchSetID, userID, tenantID string) ([]models.MatchSetRule, error) {
+	// Check if user has permission to view match sets
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermViewMatchSet, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission {
+		return nil, errors.New("unauthorized: requires view match set permission")
+	}
+
+	// Get the match set
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the match set belongs to the tenant
+	if matchSet.TenantID != tenantID {
+		return nil, errors.New("match set not found in this tenant")
+	}
+
+	return s.matchSetRepo.GetMatchSetRules(matchSetID)
+}
+
+// RunOptions selects how a match set run goes
+type RunOptions struct {
+	Mode   string // models.RunModeFull or models.RunModeIncremental; full when empty
+	Resume bool   // Continue the cancelled run from its checkpoint instead of starting over
+}
+
+// RunMatchSet executes the matching process for a specific match set and waits for it to finish.
+// A full run reconsiders the whole unmatched backlog; an incremental run only pairs transactions
+// imported or changed since the last successful run with each other and with the backlog.
+// Groups already made, approved or pending, are never reconsidered. A run cancelled while it
+// works returns its progress with the Cancelled status.
+func (s *MatchSetService) RunMatchSet(matchSetID string, options RunOptions, userID, tenantID string) (*models.MatchProgress, error) {
+	run, err := s.startRun(matchSetID, options, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.executeRun(run)
+}
+
+// StartMatchSetRun starts a run of a match set in the background and returns its progress as it
+// starts. The outcome is recorded in the match set's progress.
+func (s *MatchSetService) StartMatchSetRun(matchSetID string, options RunOptions, userID, tenantID string) (*models.MatchProgress, error) {
+	run, err := s.startRun(matchSetID, options, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	started := *run.progress
+	go func() {
+		if _, err := s.executeRun(run); err != nil {
+			log.Printf("Run %s of match set %s failed: %v", started.RunID, run.matchSet.Name, err)
+		}
+	}()
+
+	return &started, nil
+}
+
+// CancelMatchSetRun asks the running run of a match set to stop. The run finishes the group it
+// is writing, keeps the groups it made and records a checkpoint to resume from. A run whose
+// worker is gone is marked Cancelled at once.
+func (s *MatchSetService) CancelMatchSetRun(matchSetID, userID, tenantID string) (*models.MatchProgress, error) {
+	// Check if user has permission to match transactions
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermMatchTransactions, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission {
+		return nil, errors.New("unauthorized: requires match transactions permission")
+	}
+
+	// Get the match set
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the match set belongs to the tenant
+	if matchSet.TenantID != tenantID {
+		return nil, errors.New("match set not found in this tenant")
+	}
+
+	progress, err := s.progressRepo.GetProgress(matchSet.TenantID, matchSet.ID)
+	if err == repository.ErrMatchProgressNotFound {
+		return nil, repository.ErrNoRunInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The request reaches runs on other instances when they renew their lease
+	progress, err = s.progressRepo.RequestRunCancel(matchSet.TenantID, matchSet.ID, progress.RunID)
+	if err != nil {
+		return nil, err
+	}
+
+	if lease := s.activeLease(matchSet.ID, progress.RunID); lease != nil {
+		lease.cancel(ErrRunCancelled)
+	}
+
+	return progress, nil
+}
+
+// incrementalOverlap is how far before the start of the last successful run an incremental run
+// looks for changes. It covers clock skew between the application and the database; a
+// transaction considered twice is harmless.
+const incrementalOverlap = 5 * time.Minute
+
+// matchRun is a run of a match set that holds the match set's lease
+type matchRun struct {
+	matchSet    *models.MatchSet
+	rules       []*models.MatchRule
+	dataSources []models.DataSource
+	policies    []models.AutoApprovalPolicy
+	progress    *models.MatchProgress
+	lease       *runLease
+	resume      bool
+	since       time.Time // Transactions changed at or after this are fresh; unused in a full run
+	userID      string
+}
 
-	// This would be a good place to spawn a goroutine or queue a task
-	// For now, we'll just log a message
-	log.Printf("Matching process completed for match set %s", matchSet.Name)
+// startRun checks that a match set can run and takes its lease, so a second run of the match set
+// fails with a *repository.RunInProgressError instead of writing groups alongside it.
+//
+// An incremental run falls back to a full run when there is no successful run to start from or a
+// rule changed since, as pairs of backlog transactions may match under the new criteria. A
+// resumed run keeps the mode of the cancelled run and cannot resume once its rules changed.
+func (s *MatchSetService) startRun(matchSetID string, options RunOptions, userID, tenantID string) (*matchRun, error) {
+	// Check if user has permission to match transactions
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermMatchTransactions, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission {
+		return nil, errors.New("unauthorized: requires match transactions permission")
+	}
+
+	mode := options.Mode
+	if mode == "" {
+		mode = models.RunModeFull
+	}
+	if mode != models.RunModeFull && mode != models.RunModeIncremental {
+		return nil, errors.New("invalid run mode: expected full or incremental")
+	}
+
+	// Get the match set
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the match set belongs to the tenant
+	if matchSet.TenantID != tenantID {
+		return nil, errors.New("match set not found in this tenant")
+	}
+
+	// Get the match rules
+	rules, err := matchSetRules(s.matchSetRepo, s.ruleRepo, matchSet)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get data sources for this match set
+	dataSources, err := s.matchSetRepo.GetMatchSetDataSources(matchSet.TenantID, matchSet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dataSources) < 2 {
+		return nil, errors.New("invalid match set: at least two data sources are required")
+	}
+
+	// Groups meeting one of these policies are approved as they are created
+	policies, err := s.policyRepo.GetPoliciesByMatchSet(matchSet.TenantID, matchSet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Resume {
+		previous, err := s.progressRepo.GetProgress(matchSet.TenantID, matchSet.ID)
+		if err != nil && err != repository.ErrMatchProgressNotFound {
+			return nil, err
+		}
+		if previous == nil || previous.Status != "Cancelled" || previous.Checkpoint == nil {
+			return nil, errors.New("invalid request: the match set has no cancelled run to resume")
+		}
+		if err := checkpointApplies(previous.Checkpoint, rules); err != nil {
+			return nil, err
+		}
+	}
+
+	// Take the match set over; a second run gets the running run's ID instead
+	progress, err := s.progressRepo.AcquireRunLease(matchSet.TenantID, matchSet.ID, runLeaseDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &matchRun{
+		matchSet:    matchSet,
+		rules:       rules,
+		dataSources: dataSources,
+		policies:    policies,
+		progress:    progress,
+		lease:       s.holdLease(matchSet.TenantID, progress),
+		resume:      options.Resume,
+		userID:      userID,
+	}
+
+	if options.Resume {
+		// Another run may have finished between the check and taking the lease
+		if progress.Checkpoint == nil {
+			_, err := s.failRun(run, errors.New("invalid request: the match set has no cancelled run to resume"))
+			s.releaseLease(run.lease)
+			return nil, err
+		}
+		mode = progress.Mode
+	} else {
+		ruleIDs := make([]string, len(rules))
+		for i, rule := range rules {
+			ruleIDs[i] = rule.ID
+		}
+		*progress = models.MatchProgress{
+			MatchSetID:      progress.MatchSetID,
+			RunID:           progress.RunID,
+			Status:          progress.Status,
+			StartedAt:       progress.StartedAt,
+			LastSuccessAt:   progress.LastSuccessAt,
+			LeaseExpiresAt:  progress.LeaseExpiresAt,
+			CancelRequested: progress.CancelRequested,
+			Checkpoint:      &models.RunCheckpoint{RuleIDs: ruleIDs, StartedAt: *progress.StartedAt},
+		}
+	}
+
+	if mode == models.RunModeIncremental {
+		if progress.LastSuccessAt == nil {
+			mode = models.RunModeFull
+		} else {
+			run.since = progress.LastSuccessAt.Add(-incrementalOverlap)
+			for _, rule := range rules {
+				if rule.UpdatedAt.After(run.since) {
+					log.Printf("Rule %s changed since the last run of match set %s, running in full", rule.Name, matchSet.Name)
+					mode = models.RunModeFull
+					break
+				}
+			}
+		}
+	}
+	progress.Mode = mode
+
+	if err := s.progressRepo.SaveProgress(run.matchSet.TenantID, progress); err != nil {
+		_, err = s.failRun(run, err)
+		s.releaseLease(run.lease)
+		return nil, err
+	}
+
+	return run, nil
+}
 
+// checkpointApplies checks that a cancelled run's checkpoint still describes the match set's rules
+func checkpointApplies(checkpoint *models.RunCheckpoint, rules []*models.MatchRule) error {
+	changed := errors.New("invalid request: the match set's rules changed since the run was cancelled, start a new run")
+	if len(checkpoint.RuleIDs) != len(rules) {
+		return changed
+	}
+	for i, rule := range rules {
+		if checkpoint.RuleIDs[i] != rule.ID || rule.UpdatedAt.After(checkpoint.StartedAt) {
+			return changed
+		}
+	}
 	return nil
 }
 
+// executeRun matches the unmatched transactions of a match set's data sources and records the outcome.
+// Each rule runs as a pass over what earlier passes left unmatched. Within a pass the first data
+// source of the match set is matched against each of the others in turn; each of these steps is
+// checkpointed as it completes. A resumed run skips the steps its checkpoint completed.
+func (s *MatchSetService) executeRun(run *matchRun) (*models.MatchProgress, error) {
+	defer s.releaseLease(run.lease)
+
+	matchSet, rules, progress := run.matchSet, run.rules, run.progress
+
+	// Load the transactions still waiting for a match. In an incremental run only those
+	// imported or changed since the last successful run are fresh; the rest is the backlog.
+	pools := make([][]models.Transaction, len(run.dataSources))
+	var fresh map[string]bool
+	if progress.Mode == models.RunModeIncremental {
+		fresh = make(map[string]bool)
+	}
+	total := 0
+	for i, dataSource := range run.dataSources {
+		var err error
+		pools[i], err = s.transactionRepo.GetTransactionsByStatus(matchSet.TenantID, dataSource.ID, "Unmatched")
+		if err != nil {
+			return s.failRun(run, err)
+		}
+		total += len(pools[i])
+		if fresh != nil {
+			for _, transaction := range pools[i] {
+				if !transaction.UpdatedAt.Before(run.since) {
+					fresh[transaction.ID] = true
+				}
+			}
+		}
+	}
+
+	// A resumed run keeps the total of the run it continues
+	if !run.resume {
+		progress.TotalTransactions = total
+		if err := s.progressRepo.SaveProgress(run.matchSet.TenantID, progress); err != nil {
+			return s.failRun(run, err)
+		}
+	}
+
+	ruleNames := make([]string, len(rules))
+	compareAcrossCurrencies := false
+	for i, rule := range rules {
+		ruleNames[i] = rule.Name
+		compareAcrossCurrencies = compareAcrossCurrencies || rule.CompareAcrossCurrencies
+	}
+
+	log.Printf("Starting matching process for match set %s with rules %s", matchSet.Name, strings.Join(ruleNames, ", "))
+	log.Printf("Using %d data sources and %d unmatched transactions", len(run.dataSources), total)
+	if fresh != nil {
+		log.Printf("Incremental run: %d transactions new or changed since %s", len(fresh), run.since.Format(time.RFC3339))
+	}
+	if run.resume {
+		log.Printf("Resuming run of match set %s after step %d", matchSet.Name, progress.Checkpoint.CompletedSteps)
+	}
+
+	var rates *FXRateTable
+	if compareAcrossCurrencies {
+		var err error
+		rates, err = s.loadRates(matchSet.TenantID, pools)
+		if err != nil {
+			return s.failRun(run, err)
+		}
+	}
+
+	groups := 0
+	for i, rule := range rules {
+		passGroups, approved, err := s.runPass(run, i, rates, pools, fresh)
+		groups += passGroups
+		if err == ErrRunCancelled {
+			return s.cancelRun(run, groups)
+		}
+		if err != nil {
+			return s.failRun(run, err)
+		}
+		log.Printf("Rule %s matched %d groups in match set %s, %d approved by policy", rule.Name, passGroups, matchSet.Name, approved)
+	}
+
+	// Record what is left on every side. The backlog of an incremental run is already recorded.
+	reason := "No matching transaction found under rules " + strings.Join(ruleNames, ", ")
+	if len(rules) == 1 {
+		reason = "No matching transaction found under rule " + rules[0].Name
+	}
+	reasons := classifyUnmatched(pools, reason)
+	progress.UnmatchedTransactions = 0
+	for _, pool := range pools {
+		for _, transaction := range pool {
+			if fresh != nil && !fresh[transaction.ID] {
+				progress.UnmatchedTransactions++
+				continue
+			}
+			unmatchedTx := &models.UnmatchedTransaction{
+				MatchSetID:    matchSet.ID,
+				TransactionID: transaction.ID,
+				Reason:        reasons[transaction.ID].message,
+				ReasonCode:    reasons[transaction.ID].code,
+				TenantID:      matchSet.TenantID,
+			}
+			if err := s.unmatchedRepo.SaveUnmatchedTransaction(unmatchedTx); err != nil {
+				return s.failRun(run, err)
+			}
+			progress.UnmatchedTransactions++
+		}
+	}
+
+	// Changes made while the run, or the run it resumed, was working are fresh for the next one
+	completedAt := time.Now().UTC()
+	startedAt := progress.Checkpoint.StartedAt
+	progress.ProcessedTransactions = progress.TotalTransactions
+	progress.Status = "Completed"
+	progress.CompletedAt = &completedAt
+	progress.LastSuccessAt = &startedAt
+	progress.Checkpoint = nil
+	if err := s.progressRepo.SaveProgress(run.matchSet.TenantID, progress); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Matching process completed for match set %s: %d groups, %d matched, %d unmatched",
+		matchSet.Name, groups, progress.MatchedTransactions, progress.UnmatchedTransactions)
+
+	return progress, nil
+}
+
+// runPass matches the pools under one rule and persists the groups found. Groups meeting an
+// auto-approval policy are created approved under the policy's name; the rest wait for review.
+// When fresh is set only groups with a fresh transaction on either side are sought. The pass
+// stops between groups once the run is cancelled or loses its lease; every group is written in
+// a single transaction, so the groups made so far stay whole. The pools are replaced with what
+// the pass left unmatched. It returns the number of groups created and how many of them were
+// approved.
+func (s *MatchSetService) runPass(run *matchRun, ruleIndex int, rates *FXRateTable, pools [][]models.Transaction, fresh map[string]bool) (int, int, error) {
+	rule, progress := run.rules[ruleIndex], run.progress
+	engine := NewMatchingEngine(rule, rates)
+	groups, approved := 0, 0
+	for i := 1; i < len(pools); i++ {
+		// Steps before the checkpoint were completed by the run this one resumes
+		step := ruleIndex*(len(pools)-1) + i - 1
+		if step < progress.Checkpoint.CompletedSteps {
+			continue
+		}
+		if err := run.lease.err(); err != nil {
+			return groups, approved, err
+		}
+
+		var proposals []ProposedMatch
+		if fresh == nil {
+			proposals, pools[0], pools[i] = engine.Match(pools[0], pools[i])
+		} else {
+			proposals, pools[0], pools[i] = matchFresh(engine, pools[0], pools[i], fresh)
+		}
+
+		for n := range proposals {
+			if err := run.lease.err(); err != nil {
+				return groups, approved, err
+			}
+
+			transactionIDs := proposals[n].TransactionIDs()
+			match := &models.TransactionMatch{
+				MatchStatus: "Pending",
+				MatchType:   "Automatic",
+				MatchRuleID: rule.ID,
+				MatchSetID:  run.matchSet.ID,
+				TenantID:    run.matchSet.TenantID,
+				MatchedBy:   run.userID,
+				MatchScore:  proposals[n].Score,
+			}
+			if policy := matchingPolicy(run.policies, rule, &proposals[n]); policy != nil {
+				match.MatchStatus = "Approved"
+				match.ApprovedByPolicy = policy.Name
+			}
+
+			err := s.matchRepo.CreateMatchGroup(match, transactionIDs)
+			if err == repository.ErrTransactionAlreadyMatched {
+				// Matched elsewhere since we loaded them; leave them to that match
+				log.Printf("Skipping group in match set %s: transactions already matched", run.matchSet.Name)
+				continue
+			}
+			if err != nil {
+				return groups, approved, err
+			}
+
+			groups++
+			if match.MatchStatus == "Approved" {
+				approved++
+			}
+			progress.MatchedTransactions += len(transactionIDs)
+		}
+
+		progress.Checkpoint.CompletedSteps = step + 1
+		if err := s.progressRepo.SaveProgress(run.matchSet.TenantID, progress); err != nil {
+			return groups, approved, err
+		}
+	}
+
+	return groups, approved, nil
+}
+
+// matchFresh matches two pools without pairing backlog transactions with each other. Every
+// transaction on the left may pair with a fresh one on the right; the fresh ones left over on
+// the left then try the backlog on the right.
+func matchFresh(engine *MatchingEngine, left, right []models.Transaction, fresh map[string]bool) ([]ProposedMatch, []models.Transaction, []models.Transaction) {
+	freshRight, backlogRight := splitFresh(right, fresh)
+	proposals, left, freshRight := engine.Match(left, freshRight)
+
+	freshLeft, backlogLeft := splitFresh(left, fresh)
+	more, freshLeft, backlogRight := engine.Match(freshLeft, backlogRight)
+
+	return append(proposals, more...), append(backlogLeft, freshLeft...), append(freshRight, backlogRight...)
+}
+
+// splitFresh separates the fresh transactions of a pool from the backlog
+func splitFresh(pool []models.Transaction, fresh map[string]bool) ([]models.Transaction, []models.Transaction) {
+	var freshPart, backlog []models.Transaction
+	for _, transaction := range pool {
+		if fresh[transaction.ID] {
+			freshPart = append(freshPart, transaction)
+		} else {
+			backlog = append(backlog, transaction)
+		}
+	}
+	return freshPart, backlog
+}
+
+// errNoActiveRules is returned for a match set whose assigned rules are all inactive
+var errNoActiveRules = errors.New("invalid match set: no active rules")
+
+// matchSetRules returns the active rules of a match set in the order they run.
+// A match set without assigned rules runs its own rule.
+func matchSetRules(matchSetRepo repository.MatchSetRepository, ruleRepo repository.RuleRepository, matchSet *models.MatchSet) ([]*models.MatchRule, error) {
+	assigned, err := matchSetRepo.GetMatchSetRules(matchSet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(assigned) == 0 {
+		rule, err := ruleRepo.GetRuleByID(matchSet.TenantID, matchSet.RuleID)
+		if err != nil {
+			return nil, err
+		}
+		return []*models.MatchRule{rule}, nil
+	}
+
+	var rules []*models.MatchRule
+	for _, matchSetRule := range assigned {
+		rule, err := ruleRepo.GetRuleByID(matchSet.TenantID, matchSetRule.RuleID)
+		if err != nil {
+			return nil, err
+		}
+		if rule.Active {
+			rules = append(rules, rule)
+		}
+	}
+
+	if len(rules) == 0 {
+		return nil, errNoActiveRules
+	}
+	return rules, nil
+}
+
+// cancelRun marks a run as cancelled. Its checkpoint is kept for a resume.
+func (s *MatchSetService) cancelRun(run *matchRun, groups int) (*models.MatchProgress, error) {
+	progress := run.progress
+	completedAt := time.Now().UTC()
+	progress.Status = "Cancelled"
+	progress.CompletedAt = &completedAt
+	if err := s.progressRepo.SaveProgress(run.matchSet.TenantID, progress); err != nil {
+		log.Printf("Failed to record cancelled run for match set %s: %v", progress.MatchSetID, err)
+	}
+
+	log.Printf("Matching process cancelled for match set %s after step %d: %d groups, %d matched",
+		run.matchSet.Name, progress.Checkpoint.CompletedSteps, groups, progress.MatchedTransactions)
+
+	return progress, nil
+}
+
+// failRun marks a run as failed and returns the error that stopped it
+func (s *MatchSetService) failRun(run *matchRun, runErr error) (*models.MatchProgress, error) {
+	progress := run.progress
+	completedAt := time.Now().UTC()
+	progress.Status = "Failed"
+	progress.Error = runErr.Error()
+	progress.CompletedAt = &completedAt
+	if err := s.progressRepo.SaveProgress(run.matchSet.TenantID, progress); err != nil {
+		log.Printf("Failed to record failed run for match set %s: %v", progress.MatchSetID, err)
+	}
+	return progress, runErr
+}
+
+// fxRateLookback is how far before the earliest transaction rates are loaded,
+// so transactions dated on weekends and holidays still find the last published rate
+const fxRateLookback = 7 * 24 * time.Hour
+
+// loadRates loads the exchange rates covering the dates of the transactions being matched
+func (s *MatchSetService) loadRates(tenantID string, pools [][]models.Transaction) (*FXRateTable, error) {
+	var from, to time.Time
+	for _, pool := range pools {
+		for _, t := range pool {
+			if from.IsZero() || t.TransactionDate.Before(from) {
+				from = t.TransactionDate
+			}
+			if t.TransactionDate.After(to) {
+				to = t.TransactionDate
+			}
+		}
+	}
+	if from.IsZero() {
+		return NewFXRateTable(nil), nil
+	}
+
+	rates, err := s.fxRateRepo.GetRates(tenantID, from.Add(-fxRateLookback), to)
+	if err != nil {
+		return nil, err
+	}
+	return NewFXRateTable(rates), nil
+}
+
 // GetMatchSetStatus provides information about the match set processing status
 func (s *MatchSetService) GetMatchSetStatus(matchSetID, userID, tenantID string) (map[string]interface{}, error) {
 	// Check if user has permission to view match sets
@@ -308,7 +937,7 @@ func (s *MatchSetService) GetMatchSetStatus(matchSetID, userID, tenantID string)
 	}
 
 	// Get the match set
-	matchSet, err := s.matchSetRepo.GetMatchSetByID(matchSetID)
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
 	if err != nil {
 		return nil, err
 	}
@@ -319,22 +948,36 @@ func (s *MatchSetService) GetMatchSetStatus(matchSetID, userID, tenantID string)
 	}
 
 	// Get data sources for this match set
-	dataSources, err := s.matchSetRepo.GetMatchSetDataSources(matchSetID)
+	dataSources, err := s.matchSetRepo.GetMatchSetDataSources(tenantID, matchSetID)
 	if err != nil {
 		return nil, err
 	}
 
-	// In a real implementation, we would get the actual status from a database
-	// For now, we'll return some dummy data
+	// A match set that has never run has no progress yet
+	progress, err := s.progressRepo.GetProgress(tenantID, matchSetID)
+	if err == repository.ErrMatchProgressNotFound {
+		progress = &models.MatchProgress{MatchSetID: matchSetID, Status: "Pending"}
+	} else if err != nil {
+		return nil, err
+	}
+
+	var lastRun interface{}
+	if progress.CompletedAt != nil {
+		lastRun = progress.CompletedAt.Format(time.RFC3339)
+	}
+
 	status := map[string]interface{}{
 		"match_set_id":           matchSetID,
 		"name":                   matchSet.Name,
-		"status":                 "Completed", // Example status: "Running", "Completed", "Failed"
+		"status":                 progress.Status,
+		"run_id":                 progress.RunID,
+		"mode":                   progress.Mode,
 		"data_sources":           len(dataSources),
-		"total_transactions":     1000, // Example data
-		"matched_transactions":   800,
-		"unmatched_transactions": 200,
-		"last_run":               time.Now().Add(-24 * time.Hour).Format(time.RFC3339), // Example: Last run 24 hours ago
+		"total_transactions":     progress.TotalTransactions,
+		"matched_transactions":   progress.MatchedTransactions,
+		"unmatched_transactions": progress.UnmatchedTransactions,
+		"last_run":               lastRun,
+		"error":                  progress.Error,
 	}
 
 	return status, nil
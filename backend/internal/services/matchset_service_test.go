@@ -0,0 +1,614 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"errors"
+	"testing"
+	"time"
+)
+
+// allowAllPermissions grants every permission
+type allowAllPermissions struct {
+	repository.PermissionRepository
+}
+
+func (allowAllPermissions) HasPermission(userID string, permission models.Permission, tenantID string) (bool, error) {
+	return true, nil
+}
+
+func TestMatchSetService_RunsRulesInPriorityOrder(t *testing.T) {
+	matchSetRepo := repository.NewMatchSetRepository()
+	ruleRepo := repository.NewRuleRepository()
+	transactionRepo := repository.NewTransactionRepository()
+	matchRepo := repository.NewMatchRepository()
+
+	exact := &models.MatchRule{ID: "rule-exact", TenantID: "tenant-1", Name: "Reference and amount", Active: true, Conditions: []models.RuleCondition{
+		{Field: models.ConditionFieldReference, Operator: models.ConditionOpEq},
+		{Field: models.ConditionFieldAmount, Operator: models.ConditionOpEq},
+	}}
+	loose := &models.MatchRule{ID: "rule-loose", TenantID: "tenant-1", Name: "Amount and date", Active: true, Conditions: []models.RuleCondition{
+		{Field: models.ConditionFieldAmount, Operator: models.ConditionOpEq},
+		{Field: models.ConditionFieldTransactionDate, Operator: models.ConditionOpWithin, Tolerance: 2},
+	}}
+	for _, rule := range []*models.MatchRule{exact, loose} {
+		if err := ruleRepo.CreateRule(rule); err != nil {
+			t.Fatalf("CreateRule() error = %v", err)
+		}
+	}
+
+	matchSet := &models.MatchSet{ID: "set-1", Name: "Bank", TenantID: "tenant-1", RuleID: loose.ID}
+	if err := matchSetRepo.CreateMatchSet(matchSet); err != nil {
+		t.Fatalf("CreateMatchSet() error = %v", err)
+	}
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "ledger")
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "bank")
+	matchSetRepo.SetMatchSetRule(matchSet.ID, loose.ID, 20)
+	matchSetRepo.SetMatchSetRule(matchSet.ID, exact.ID, 10)
+
+	// Without the exact pass L1 could pair with either R1 or R2
+	for _, transaction := range []models.Transaction{
+		tx("L1", 100, 1, "INV-1"),
+		tx("L2", 50, 1, ""),
+		tx("R1", 100, 2, "INV-1"),
+		tx("R2", 100, 1, ""),
+		tx("R3", 50, 3, ""),
+	} {
+		transaction.DataSourceID = "bank"
+		if transaction.ID[0] == 'L' {
+			transaction.DataSourceID = "ledger"
+		}
+		transaction.Status = "Unmatched"
+		transactionRepo.CreateTransaction(&transaction)
+	}
+
+	service := NewMatchSetService(
+		matchSetRepo,
+		ruleRepo,
+		repository.NewDataSourceRepository(),
+		transactionRepo,
+		allowAllPermissions{},
+		matchRepo,
+		repository.NewUnmatchedTransactionRepository(),
+		repository.NewMatchProgressRepository(),
+		repository.NewFXRateRepository(),
+		repository.NewApprovalPolicyRepository(),
+	)
+
+	progress, err := service.RunMatchSet(matchSet.ID, RunOptions{Mode: models.RunModeFull}, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("RunMatchSet() error = %v", err)
+	}
+	if progress.MatchedTransactions != 4 || progress.UnmatchedTransactions != 1 {
+		t.Errorf("RunMatchSet() matched %d, unmatched %d, want 4 and 1", progress.MatchedTransactions, progress.UnmatchedTransactions)
+	}
+
+	matches, _ := matchRepo.GetMatchesByStatus("tenant-1", "Pending")
+	byRule := make(map[string]int)
+	for _, match := range matches {
+		byRule[match.MatchRuleID]++
+	}
+	if byRule[exact.ID] != 1 || byRule[loose.ID] != 1 {
+		t.Errorf("matches by rule = %v, want one for each rule", byRule)
+	}
+}
+
+func TestMatchSetService_RuleOfAnotherTenant(t *testing.T) {
+	ruleRepo := repository.NewRuleRepository()
+	own := &models.MatchRule{ID: "rule-own", TenantID: "tenant-1", Name: "Amount", Active: true}
+	foreign := &models.MatchRule{ID: "rule-foreign", TenantID: "tenant-2", Name: "Amount", Active: true}
+	for _, rule := range []*models.MatchRule{own, foreign} {
+		if err := ruleRepo.CreateRule(rule); err != nil {
+			t.Fatalf("CreateRule() error = %v", err)
+		}
+	}
+
+	service := NewMatchSetService(
+		repository.NewMatchSetRepository(),
+		ruleRepo,
+		repository.NewDataSourceRepository(),
+		repository.NewTransactionRepository(),
+		allowAllPermissions{},
+		repository.NewMatchRepository(),
+		repository.NewUnmatchedTransactionRepository(),
+		repository.NewMatchProgressRepository(),
+		repository.NewFXRateRepository(),
+		repository.NewApprovalPolicyRepository(),
+	)
+
+	if _, err := service.CreateMatchSet("Foreign", "", "tenant-1", foreign.ID, "user-1"); err != repository.ErrRuleNotFound {
+		t.Errorf("CreateMatchSet() with another tenant's rule error = %v, want %v", err, repository.ErrRuleNotFound)
+	}
+
+	matchSet, err := service.CreateMatchSet("Bank", "", "tenant-1", own.ID, "user-1")
+	if err != nil {
+		t.Fatalf("CreateMatchSet() error = %v", err)
+	}
+
+	if _, err := service.UpdateMatchSet(matchSet.ID, "Bank", "", foreign.ID, "user-1", "tenant-1"); err != repository.ErrRuleNotFound {
+		t.Errorf("UpdateMatchSet() with another tenant's rule error = %v, want %v", err, repository.ErrRuleNotFound)
+	}
+	stored, err := service.GetMatchSetByID(matchSet.ID, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("GetMatchSetByID() error = %v", err)
+	}
+	if stored.RuleID != own.ID {
+		t.Errorf("RuleID = %q, want %q", stored.RuleID, own.ID)
+	}
+
+	if _, err := service.UpdateMatchSet(matchSet.ID, "Bank", "Renamed", own.ID, "user-1", "tenant-1"); err != nil {
+		t.Errorf("UpdateMatchSet() error = %v", err)
+	}
+}
+
+func TestMatchSetService_SimulateMatchSet(t *testing.T) {
+	matchSetRepo := repository.NewMatchSetRepository()
+	ruleRepo := repository.NewRuleRepository()
+	transactionRepo := repository.NewTransactionRepository()
+	matchRepo := repository.NewMatchRepository()
+
+	rule := &models.MatchRule{ID: "rule-amount", TenantID: "tenant-1", Name: "Amount and date", Conditions: []models.RuleCondition{
+		{Field: models.ConditionFieldAmount, Operator: models.ConditionOpEq},
+		{Field: models.ConditionFieldTransactionDate, Operator: models.ConditionOpWithin, Tolerance: 2},
+	}}
+	ruleRepo.CreateRule(rule)
+
+	matchSet := &models.MatchSet{ID: "set-1", Name: "Bank", TenantID: "tenant-1", RuleID: rule.ID}
+	matchSetRepo.CreateMatchSet(matchSet)
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "ledger")
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "bank")
+
+	for _, transaction := range []models.Transaction{
+		tx("L1", 100, 1, ""), // Matched to R1, reproduced
+		tx("L2", 50, 1, ""),  // Matched to R2 by hand, regrouped with R3
+		tx("L4", 30, 1, ""),
+		tx("R1", 100, 1, ""),
+		tx("R2", 70, 1, ""),
+		tx("R3", 50, 2, ""),
+		tx("R4", 30, 1, ""),
+		tx("L9", 90, 1, ""), // Approved with R9, left alone
+		tx("R9", 90, 1, ""),
+	} {
+		transaction.DataSourceID = "bank"
+		if transaction.ID[0] == 'L' {
+			transaction.DataSourceID = "ledger"
+		}
+		transaction.Status = "Unmatched"
+		if transaction.ID == "L1" || transaction.ID == "R1" || transaction.ID == "L2" || transaction.ID == "R2" {
+			transaction.Status = "Matched"
+		}
+		if transaction.ID == "L9" || transaction.ID == "R9" {
+			transaction.Status = "Approved"
+		}
+		transactionRepo.CreateTransaction(&transaction)
+	}
+	matchRepo.CreateMatchGroup(&models.TransactionMatch{ID: "match-1", TenantID: matchSet.TenantID, MatchSetID: matchSet.ID}, []string{"L1", "R1"})
+	matchRepo.CreateMatchGroup(&models.TransactionMatch{ID: "match-2", TenantID: matchSet.TenantID, MatchSetID: matchSet.ID}, []string{"L2", "R2"})
+	matchRepo.CreateMatchGroup(&models.TransactionMatch{ID: "match-9", TenantID: matchSet.TenantID, MatchSetID: matchSet.ID, MatchStatus: "Approved"}, []string{"L9", "R9"})
+
+	service := NewMatchSetService(
+		matchSetRepo,
+		ruleRepo,
+		repository.NewDataSourceRepository(),
+		transactionRepo,
+		allowAllPermissions{},
+		matchRepo,
+		repository.NewUnmatchedTransactionRepository(),
+		repository.NewMatchProgressRepository(),
+		repository.NewFXRateRepository(),
+		repository.NewApprovalPolicyRepository(),
+	)
+
+	// The rule is inactive, but can still be simulated on its own
+	simulation, err := service.SimulateMatchSet(matchSet.ID, rule.ID, 0, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("SimulateMatchSet() error = %v", err)
+	}
+
+	if simulation.TotalTransactions != 7 || simulation.ProposedGroups != 3 || simulation.UnmatchedTransactions != 1 {
+		t.Errorf("SimulateMatchSet() total %d, groups %d, unmatched %d, want 7, 3 and 1",
+			simulation.TotalTransactions, simulation.ProposedGroups, simulation.UnmatchedTransactions)
+	}
+
+	diff := simulation.Diff
+	if diff.NewMatches != 1 || diff.UnchangedMatches != 1 || diff.Conflicts != 1 || diff.LostMatches != 1 {
+		t.Errorf("SimulateMatchSet() diff = %+v, want one of each change", diff)
+	}
+
+	changes := make(map[string][]string)
+	for _, change := range diff.Changes {
+		changes[change.Change] = change.CurrentMatchIDs
+	}
+	if len(changes["conflict"]) != 1 || changes["conflict"][0] != "match-2" {
+		t.Errorf("conflict current matches = %v, want [match-2]", changes["conflict"])
+	}
+	if len(changes["lost"]) != 1 || changes["lost"][0] != "match-2" {
+		t.Errorf("lost current matches = %v, want [match-2]", changes["lost"])
+	}
+
+	// A dry run writes nothing
+	groups, _ := matchRepo.GetMatchGroupsByMatchSet(matchSet.TenantID, matchSet.ID)
+	if len(groups) != 3 {
+		t.Errorf("match groups after simulation = %d, want 3", len(groups))
+	}
+}
+
+func TestMatchSetService_AutoApprovesUnderPolicy(t *testing.T) {
+	matchSetRepo := repository.NewMatchSetRepository()
+	ruleRepo := repository.NewRuleRepository()
+	transactionRepo := repository.NewTransactionRepository()
+	matchRepo := repository.NewMatchRepository()
+	policyRepo := repository.NewApprovalPolicyRepository()
+
+	rule := &models.MatchRule{ID: "rule-exact", TenantID: "tenant-1", Name: "Reference and amount", Active: true, Conditions: []models.RuleCondition{
+		{Field: models.ConditionFieldReference, Operator: models.ConditionOpEq},
+		{Field: models.ConditionFieldAmount, Operator: models.ConditionOpEq},
+	}}
+	ruleRepo.CreateRule(rule)
+
+	matchSet := &models.MatchSet{ID: "set-1", Name: "Bank", TenantID: "tenant-1", RuleID: rule.ID}
+	matchSetRepo.CreateMatchSet(matchSet)
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "ledger")
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "bank")
+
+	// The inactive policy would approve everything
+	policyRepo.CreatePolicy(&models.AutoApprovalPolicy{MatchSetID: matchSet.ID, TenantID: matchSet.TenantID, Name: "Everything", MinScore: 0})
+	policyRepo.CreatePolicy(&models.AutoApprovalPolicy{
+		MatchSetID: matchSet.ID, TenantID: matchSet.TenantID, Name: "Small exact", RuleID: rule.ID, MinScore: 1, MaxAmount: 500, OneToOneOnly: true, Active: true,
+	})
+
+	for _, transaction := range []models.Transaction{
+		tx("L1", 100, 1, "INV-1"),
+		tx("L2", 1000, 1, "INV-2"), // Over the policy's ceiling
+		tx("R1", 100, 1, "INV-1"),
+		tx("R2", 1000, 1, "INV-2"),
+	} {
+		transaction.DataSourceID = "bank"
+		if transaction.ID[0] == 'L' {
+			transaction.DataSourceID = "ledger"
+		}
+		transaction.Status = "Unmatched"
+		transactionRepo.CreateTransaction(&transaction)
+	}
+
+	service := NewMatchSetService(
+		matchSetRepo,
+		ruleRepo,
+		repository.NewDataSourceRepository(),
+		transactionRepo,
+		allowAllPermissions{},
+		matchRepo,
+		repository.NewUnmatchedTransactionRepository(),
+		repository.NewMatchProgressRepository(),
+		repository.NewFXRateRepository(),
+		policyRepo,
+	)
+
+	if _, err := service.RunMatchSet(matchSet.ID, RunOptions{Mode: models.RunModeFull}, "user-1", "tenant-1"); err != nil {
+		t.Fatalf("RunMatchSet() error = %v", err)
+	}
+
+	approved, _ := matchRepo.GetMatchesByStatus("tenant-1", "Approved")
+	if len(approved) != 1 || approved[0].ApprovedByPolicy != "Small exact" || approved[0].ApprovedBy != "" {
+		t.Fatalf("approved matches = %+v, want one approved by policy Small exact", approved)
+	}
+	groups, _ := matchRepo.GetMatchGroupsByMatchSet(matchSet.TenantID, matchSet.ID)
+	if !sameTransactions(groups[approved[0].ID], []string{"L1", "R1"}) {
+		t.Errorf("approved group = %v, want [L1 R1]", groups[approved[0].ID])
+	}
+
+	pending, _ := matchRepo.GetMatchesByStatus("tenant-1", "Pending")
+	if len(pending) != 1 || pending[0].ApprovedByPolicy != "" {
+		t.Errorf("pending matches = %+v, want one awaiting review", pending)
+	}
+}
+
+func TestMatchSetService_IncrementalRun(t *testing.T) {
+	matchSetRepo := repository.NewMatchSetRepository()
+	ruleRepo := repository.NewRuleRepository()
+	transactionRepo := repository.NewTransactionRepository()
+	matchRepo := repository.NewMatchRepository()
+	progressRepo := repository.NewMatchProgressRepository()
+
+	now := time.Now()
+	rule := &models.MatchRule{ID: "rule-amount", TenantID: "tenant-1", Name: "Amount and date", Active: true, Conditions: []models.RuleCondition{
+		{Field: models.ConditionFieldAmount, Operator: models.ConditionOpEq},
+		{Field: models.ConditionFieldTransactionDate, Operator: models.ConditionOpWithin, Tolerance: 2},
+	}}
+	ruleRepo.CreateRule(rule)
+	rule.UpdatedAt = now.Add(-3 * time.Hour)
+
+	matchSet := &models.MatchSet{ID: "set-1", Name: "Bank", TenantID: "tenant-1", RuleID: rule.ID}
+	matchSetRepo.CreateMatchSet(matchSet)
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "ledger")
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "bank")
+
+	lastSuccessAt := now.Add(-time.Hour)
+	progressRepo.SaveProgress(matchSet.TenantID, &models.MatchProgress{MatchSetID: matchSet.ID, Status: "Completed", LastSuccessAt: &lastSuccessAt})
+
+	// L1 and R1 were both in the backlog of the last run, so only a full run pairs them
+	for _, transaction := range []models.Transaction{
+		tx("L1", 100, 1, ""),
+		tx("L2", 50, 1, ""),
+		tx("L3", 70, 1, ""),
+		tx("R1", 100, 1, ""),
+		tx("R2", 50, 1, ""),
+		tx("R3", 70, 1, ""),
+	} {
+		transaction.DataSourceID = "bank"
+		if transaction.ID[0] == 'L' {
+			transaction.DataSourceID = "ledger"
+		}
+		transaction.Status = "Unmatched"
+		transactionRepo.CreateTransaction(&transaction)
+	}
+	for _, id := range []string{"L1", "L3", "R1", "R2"} {
+		transaction, _ := transactionRepo.GetTransactionByID("tenant-1", id)
+		transaction.UpdatedAt = now.Add(-2 * time.Hour)
+	}
+
+	service := NewMatchSetService(
+		matchSetRepo,
+		ruleRepo,
+		repository.NewDataSourceRepository(),
+		transactionRepo,
+		allowAllPermissions{},
+		matchRepo,
+		repository.NewUnmatchedTransactionRepository(),
+		progressRepo,
+		repository.NewFXRateRepository(),
+		repository.NewApprovalPolicyRepository(),
+	)
+
+	progress, err := service.RunMatchSet(matchSet.ID, RunOptions{Mode: models.RunModeIncremental}, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("RunMatchSet() error = %v", err)
+	}
+	if progress.Mode != models.RunModeIncremental || progress.MatchedTransactions != 4 || progress.UnmatchedTransactions != 2 {
+		t.Errorf("incremental run mode %s, matched %d, unmatched %d, want incremental, 4 and 2",
+			progress.Mode, progress.MatchedTransactions, progress.UnmatchedTransactions)
+	}
+	if progress.LastSuccessAt == nil || !progress.LastSuccessAt.After(lastSuccessAt) {
+		t.Errorf("incremental run last success = %v, want the start of the run", progress.LastSuccessAt)
+	}
+
+	groups, _ := matchRepo.GetMatchGroupsByMatchSet(matchSet.TenantID, matchSet.ID)
+	for _, group := range groups {
+		if sameTransactions(group, []string{"L1", "R1"}) {
+			t.Errorf("incremental run paired two backlog transactions")
+		}
+	}
+
+	// A changed rule may match the backlog differently, so the run falls back to a full run
+	rule.UpdatedAt = now
+	progress, err = service.RunMatchSet(matchSet.ID, RunOptions{Mode: models.RunModeIncremental}, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("RunMatchSet() error = %v", err)
+	}
+	if progress.Mode != models.RunModeFull || progress.MatchedTransactions != 2 {
+		t.Errorf("run after rule change mode %s, matched %d, want full and 2", progress.Mode, progress.MatchedTransactions)
+	}
+}
+
+// groupHookMatchRepository calls afterGroup once the first group is written
+type groupHookMatchRepository struct {
+	repository.MatchRepository
+	afterGroup func()
+}
+
+func (r *groupHookMatchRepository) CreateMatchGroup(match *models.TransactionMatch, transactionIDs []string) error {
+	err := r.MatchRepository.CreateMatchGroup(match, transactionIDs)
+	if afterGroup := r.afterGroup; afterGroup != nil {
+		r.afterGroup = nil
+		afterGroup()
+	}
+	return err
+}
+
+func TestMatchSetService_RunLease(t *testing.T) {
+	matchSetRepo := repository.NewMatchSetRepository()
+	ruleRepo := repository.NewRuleRepository()
+	transactionRepo := repository.NewTransactionRepository()
+	matchRepo := &groupHookMatchRepository{MatchRepository: repository.NewMatchRepository()}
+	progressRepo := repository.NewMatchProgressRepository()
+
+	rule := &models.MatchRule{ID: "rule-amount", TenantID: "tenant-1", Name: "Amount", Active: true, Conditions: []models.RuleCondition{
+		{Field: models.ConditionFieldAmount, Operator: models.ConditionOpEq},
+	}}
+	ruleRepo.CreateRule(rule)
+
+	matchSet := &models.MatchSet{ID: "set-1", Name: "Bank", TenantID: "tenant-1", RuleID: rule.ID}
+	matchSetRepo.CreateMatchSet(matchSet)
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "ledger")
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "bank")
+
+	service := NewMatchSetService(
+		matchSetRepo,
+		ruleRepo,
+		repository.NewDataSourceRepository(),
+		transactionRepo,
+		allowAllPermissions{},
+		matchRepo,
+		repository.NewUnmatchedTransactionRepository(),
+		progressRepo,
+		repository.NewFXRateRepository(),
+		repository.NewApprovalPolicyRepository(),
+	)
+
+	// A crashed run's expired lease is taken over, and the crashed run can no longer write
+	crashed, _ := progressRepo.AcquireRunLease(matchSet.TenantID, matchSet.ID, -time.Minute)
+	progress, err := service.RunMatchSet(matchSet.ID, RunOptions{Mode: models.RunModeFull}, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("RunMatchSet() over an expired lease error = %v", err)
+	}
+	if progress.Status != "Completed" || progress.RunID == crashed.RunID {
+		t.Errorf("RunMatchSet() status %s, run %s, want a completed new run", progress.Status, progress.RunID)
+	}
+	crashed.Status = "Completed"
+	if err := progressRepo.SaveProgress(matchSet.TenantID, crashed); err != repository.ErrRunLeaseLost {
+		t.Errorf("SaveProgress() by the crashed run error = %v, want %v", err, repository.ErrRunLeaseLost)
+	}
+
+	// A second run started while a run writes its groups finds the match set taken, and the
+	// first run keeps its lease through the progress it saves
+	for _, transaction := range []models.Transaction{tx("L1", 100, 1, "A"), tx("R1", 100, 1, "B")} {
+		transaction.DataSourceID = "bank"
+		if transaction.ID[0] == 'L' {
+			transaction.DataSourceID = "ledger"
+		}
+		transaction.Status = "Unmatched"
+		transactionRepo.CreateTransaction(&transaction)
+	}
+	var inProgress *repository.RunInProgressError
+	matchRepo.afterGroup = func() {
+		_, err := service.RunMatchSet(matchSet.ID, RunOptions{Mode: models.RunModeFull}, "user-2", "tenant-1")
+		if !errors.As(err, &inProgress) {
+			t.Errorf("RunMatchSet() during a run error = %v, want a run in progress", err)
+		}
+	}
+	progress, err = service.RunMatchSet(matchSet.ID, RunOptions{Mode: models.RunModeFull}, "user-1", "tenant-1")
+	if err != nil || progress.Status != "Completed" || progress.MatchedTransactions != 2 {
+		t.Fatalf("RunMatchSet() = %+v, %v, want a completed run matching 2", progress, err)
+	}
+	if inProgress == nil || inProgress.RunID != progress.RunID {
+		t.Errorf("RunMatchSet() during a run reported %+v, want run %s in progress", inProgress, progress.RunID)
+	}
+
+	// A started run holds its lease in the progress it returns and in every progress it saves, so
+	// the scheduler sees it as running
+	for _, transaction := range []models.Transaction{tx("L2", 50, 2, "C"), tx("R2", 50, 2, "D")} {
+		transaction.DataSourceID = "bank"
+		if transaction.ID[0] == 'L' {
+			transaction.DataSourceID = "ledger"
+		}
+		transaction.Status = "Unmatched"
+		transactionRepo.CreateTransaction(&transaction)
+	}
+	checked := make(chan struct{})
+	matchRepo.afterGroup = func() {
+		defer close(checked)
+		saved, err := progressRepo.GetProgress(matchSet.TenantID, matchSet.ID)
+		if err != nil || saved.Status != "Running" || saved.LeaseExpiresAt == nil || !saved.LeaseExpiresAt.After(time.Now()) {
+			t.Errorf("GetProgress() during a run = %+v, %v, want Running under a live lease", saved, err)
+		}
+	}
+	started, err := service.StartMatchSetRun(matchSet.ID, RunOptions{Mode: models.RunModeFull}, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("StartMatchSetRun() error = %v", err)
+	}
+	if started.LeaseExpiresAt == nil {
+		t.Errorf("StartMatchSetRun() = %+v, want the run's lease", started)
+	}
+	<-checked
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		saved, _ := progressRepo.GetProgress(matchSet.TenantID, matchSet.ID)
+		if saved.Status != "Running" || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A live run keeps the match set
+	running, _ := progressRepo.AcquireRunLease(matchSet.TenantID, matchSet.ID, time.Hour)
+	_, err = service.RunMatchSet(matchSet.ID, RunOptions{Mode: models.RunModeFull}, "user-2", "tenant-1")
+	if !errors.As(err, &inProgress) || inProgress.RunID != running.RunID {
+		t.Errorf("RunMatchSet() while running error = %v, want run %s in progress", err, running.RunID)
+	}
+}
+
+func TestMatchSetService_CancelAndResumeRun(t *testing.T) {
+	matchSetRepo := repository.NewMatchSetRepository()
+	ruleRepo := repository.NewRuleRepository()
+	transactionRepo := repository.NewTransactionRepository()
+	matchRepo := &groupHookMatchRepository{MatchRepository: repository.NewMatchRepository()}
+
+	exact := &models.MatchRule{ID: "rule-exact", TenantID: "tenant-1", Name: "Reference and amount", Active: true, Conditions: []models.RuleCondition{
+		{Field: models.ConditionFieldReference, Operator: models.ConditionOpEq},
+		{Field: models.ConditionFieldAmount, Operator: models.ConditionOpEq},
+	}}
+	loose := &models.MatchRule{ID: "rule-loose", TenantID: "tenant-1", Name: "Amount and date", Active: true, Conditions: []models.RuleCondition{
+		{Field: models.ConditionFieldAmount, Operator: models.ConditionOpEq},
+		{Field: models.ConditionFieldTransactionDate, Operator: models.ConditionOpWithin, Tolerance: 2},
+	}}
+	ruleRepo.CreateRule(exact)
+	ruleRepo.CreateRule(loose)
+	exact.UpdatedAt = time.Now().Add(-time.Hour)
+	loose.UpdatedAt = time.Now().Add(-time.Hour)
+
+	matchSet := &models.MatchSet{ID: "set-1", Name: "Bank", TenantID: "tenant-1", RuleID: loose.ID}
+	matchSetRepo.CreateMatchSet(matchSet)
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "ledger")
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "bank")
+	matchSetRepo.SetMatchSetRule(matchSet.ID, exact.ID, 10)
+	matchSetRepo.SetMatchSetRule(matchSet.ID, loose.ID, 20)
+
+	addTransactions := func(transactions ...models.Transaction) {
+		for _, transaction := range transactions {
+			transaction.DataSourceID = "bank"
+			if transaction.ID[0] == 'L' {
+				transaction.DataSourceID = "ledger"
+			}
+			transaction.Status = "Unmatched"
+			transactionRepo.CreateTransaction(&transaction)
+		}
+	}
+	addTransactions(
+		tx("L1", 100, 1, "INV-1"),
+		tx("L2", 50, 1, "A"),
+		tx("R1", 100, 1, "INV-1"),
+		tx("R2", 50, 2, "B"),
+	)
+
+	service := NewMatchSetService(
+		matchSetRepo,
+		ruleRepo,
+		repository.NewDataSourceRepository(),
+		transactionRepo,
+		allowAllPermissions{},
+		matchRepo,
+		repository.NewUnmatchedTransactionRepository(),
+		repository.NewMatchProgressRepository(),
+		repository.NewFXRateRepository(),
+		repository.NewApprovalPolicyRepository(),
+	)
+
+	// The run is cancelled while the exact rule writes its group, and stops before the loose rule
+	matchRepo.afterGroup = func() {
+		if _, err := service.CancelMatchSetRun(matchSet.ID, "user-2", "tenant-1"); err != nil {
+			t.Errorf("CancelMatchSetRun() error = %v", err)
+		}
+	}
+	progress, err := service.RunMatchSet(matchSet.ID, RunOptions{}, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("RunMatchSet() error = %v", err)
+	}
+	if progress.Status != "Cancelled" || progress.MatchedTransactions != 2 || progress.Checkpoint.CompletedSteps != 1 {
+		t.Fatalf("cancelled run status %s, matched %d, checkpoint %+v, want Cancelled, 2 and 1 step",
+			progress.Status, progress.MatchedTransactions, progress.Checkpoint)
+	}
+
+	// The resumed run skips the exact rule, so a pair it would have taken falls to the loose rule
+	addTransactions(tx("L4", 70, 1, "INV-4"), tx("R4", 70, 1, "INV-4"))
+	progress, err = service.RunMatchSet(matchSet.ID, RunOptions{Resume: true}, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("RunMatchSet() resume error = %v", err)
+	}
+	if progress.Status != "Completed" || progress.MatchedTransactions != 6 || progress.Checkpoint != nil {
+		t.Errorf("resumed run status %s, matched %d, checkpoint %+v, want Completed, 6 and none",
+			progress.Status, progress.MatchedTransactions, progress.Checkpoint)
+	}
+
+	matches, _ := matchRepo.GetMatchesByStatus("tenant-1", "Pending")
+	byRule := make(map[string]int)
+	for _, match := range matches {
+		byRule[match.MatchRuleID]++
+	}
+	if byRule[exact.ID] != 1 || byRule[loose.ID] != 2 {
+		t.Errorf("matches by rule = %v, want 1 exact and 2 loose", byRule)
+	}
+
+	if _, err := service.RunMatchSet(matchSet.ID, RunOptions{Resume: true}, "user-1", "tenant-1"); err == nil {
+		t.Errorf("RunMatchSet() resuming a completed run succeeded, want an error")
+	}
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"backend/internal/models"
+	"errors"
+	"slices"
+	"sort"
+)
+
+// Limits on the number of groups a simulation returns in each sample
+const (
+	defaultSimulationSample = 20
+	maxSimulationSample     = 100
+)
+
+// SimulateMatchSet runs the matching process of a match set as a dry run. It matches the match
+// set's unmatched transactions together with those in its current groups, and compares the
+// outcome with the current groups. Nothing is written.
+//
+// When ruleID is set only that rule runs, whether or not it is active, so a rule can be
+// reviewed before it is activated or assigned. Otherwise the match set's rules run in order.
+func (s *MatchSetService) SimulateMatchSet(matchSetID, ruleID string, sampleSize int, userID, tenantID string) (*models.MatchSimulation, error) {
+	// Check if user has permission to view match sets
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermViewMatchSet, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission {
+		return nil, errors.New("unauthorized: requires view match set permission")
+	}
+
+	// Get the match set
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the match set belongs to the tenant
+	if matchSet.TenantID != tenantID {
+		return nil, errors.New("match set not found in this tenant")
+	}
+
+	if sampleSize <= 0 {
+		sampleSize = defaultSimulationSample
+	}
+	sampleSize = min(sampleSize, maxSimulationSample)
+
+	// Get the rules to simulate
+	var rules []*models.MatchRule
+	if ruleID != "" {
+		rule, err := s.ruleRepo.GetRuleByID(tenantID, ruleID)
+		if err != nil {
+			return nil, err
+		}
+		rules = []*models.MatchRule{rule}
+	} else {
+		rules, err = matchSetRules(s.matchSetRepo, s.ruleRepo, matchSet)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Get data sources for this match set
+	dataSources, err := s.matchSetRepo.GetMatchSetDataSources(matchSet.TenantID, matchSet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dataSources) < 2 {
+		return nil, errors.New("invalid match set: at least two data sources are required")
+	}
+
+	// Map every currently matched transaction to its group
+	currentGroups, err := s.matchRepo.GetMatchGroupsByMatchSet(matchSet.TenantID, matchSet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Approved groups are final: a run never regroups their members, which are not loaded below
+	for matchID := range currentGroups {
+		match, err := s.matchRepo.GetMatchByID(matchSet.TenantID, matchID)
+		if err != nil {
+			return nil, err
+		}
+		if match.MatchStatus == "Approved" {
+			delete(currentGroups, matchID)
+		}
+	}
+
+	groupOf := make(map[string]string)
+	for matchID, transactionIDs := range currentGroups {
+		for _, transactionID := range transactionIDs {
+			groupOf[transactionID] = matchID
+		}
+	}
+
+	// Load the unmatched transactions and those matched within this match set
+	pools := make([][]models.Transaction, len(dataSources))
+	for i, dataSource := range dataSources {
+		pools[i], err = s.transactionRepo.GetTransactionsByStatus(tenantID, dataSource.ID, "Unmatched")
+		if err != nil {
+			return nil, err
+		}
+
+		matched, err := s.transactionRepo.GetTransactionsByStatus(tenantID, dataSource.ID, "Matched")
+		if err != nil {
+			return nil, err
+		}
+		for _, transaction := range matched {
+			if _, ok := groupOf[transaction.ID]; ok {
+				pools[i] = append(pools[i], transaction)
+			}
+		}
+	}
+
+	simulation := &models.MatchSimulation{
+		MatchSetID: matchSet.ID,
+		Sample:     []models.SimulatedMatch{},
+		Diff:       models.SimulationDiff{Changes: []models.SimulatedMatch{}},
+	}
+	for _, pool := range pools {
+		simulation.TotalTransactions += len(pool)
+	}
+
+	compareAcrossCurrencies := false
+	for _, rule := range rules {
+		simulation.RuleIDs = append(simulation.RuleIDs, rule.ID)
+		compareAcrossCurrencies = compareAcrossCurrencies || rule.CompareAcrossCurrencies
+	}
+
+	var rates *FXRateTable
+	if compareAcrossCurrencies {
+		rates, err = s.loadRates(matchSet.TenantID, pools)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Run the passes exactly as a real run would, keeping the proposals instead of saving them
+	reproduced := make(map[string]bool)
+	changeSamples := make(map[string]int)
+	addChange := func(group models.SimulatedMatch) {
+		if changeSamples[group.Change] < sampleSize {
+			changeSamples[group.Change]++
+			simulation.Diff.Changes = append(simulation.Diff.Changes, group)
+		}
+	}
+
+	for _, rule := range rules {
+		engine := NewMatchingEngine(rule, rates)
+		for i := 1; i < len(pools); i++ {
+			var proposals []ProposedMatch
+			proposals, pools[0], pools[i] = engine.Match(pools[0], pools[i])
+
+			for n := range proposals {
+				group := models.SimulatedMatch{
+					RuleID:           rule.ID,
+					TransactionIDs:   proposals[n].TransactionIDs(),
+					AmountDifference: proposals[n].AmountDifference,
+					Currency:         proposals[n].Currency,
+					Score:            proposals[n].Score,
+				}
+				group.CurrentMatchIDs = currentMatchIDs(group.TransactionIDs, groupOf)
+
+				switch {
+				case len(group.CurrentMatchIDs) == 0:
+					group.Change = "new"
+					simulation.Diff.NewMatches++
+					addChange(group)
+				case len(group.CurrentMatchIDs) == 1 && sameTransactions(group.TransactionIDs, currentGroups[group.CurrentMatchIDs[0]]):
+					group.Change = "unchanged"
+					simulation.Diff.UnchangedMatches++
+					reproduced[group.CurrentMatchIDs[0]] = true
+				default:
+					group.Change = "conflict"
+					simulation.Diff.Conflicts++
+					addChange(group)
+				}
+
+				simulation.ProposedGroups++
+				simulation.MatchedTransactions += len(group.TransactionIDs)
+				if len(simulation.Sample) < sampleSize {
+					simulation.Sample = append(simulation.Sample, group)
+				}
+			}
+		}
+	}
+
+	for _, pool := range pools {
+		simulation.UnmatchedTransactions += len(pool)
+	}
+
+	// Current groups the dry run does not reproduce would be lost, in order of match ID
+	matchIDs := make([]string, 0, len(currentGroups))
+	for matchID := range currentGroups {
+		if !reproduced[matchID] {
+			matchIDs = append(matchIDs, matchID)
+		}
+	}
+	sort.Strings(matchIDs)
+	simulation.Diff.LostMatches = len(matchIDs)
+	for _, matchID := range matchIDs {
+		addChange(models.SimulatedMatch{
+			Change:          "lost",
+			TransactionIDs:  currentGroups[matchID],
+			CurrentMatchIDs: []string{matchID},
+		})
+	}
+
+	return simulation, nil
+}
+
+// currentMatchIDs returns the distinct current groups holding any of the transactions
+func currentMatchIDs(transactionIDs []string, groupOf map[string]string) []string {
+	var matchIDs []string
+	seen := make(map[string]bool)
+	for _, transactionID := range transactionIDs {
+		if matchID, ok := groupOf[transactionID]; ok && !seen[matchID] {
+			seen[matchID] = true
+			matchIDs = append(matchIDs, matchID)
+		}
+	}
+	return matchIDs
+}
+
+// sameTransactions reports whether two groups hold the same transactions
+func sameTransactions(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	return slices.Equal(a, b)
+}
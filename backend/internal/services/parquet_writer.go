@@ -0,0 +1,307 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// parquetRowGroupSize is the number of rows buffered before they are written as a row group
+const parquetRowGroupSize = 10000
+
+// Parquet format constants, as numbered in parquet.thrift
+const (
+	parquetMagic = "PAR1"
+
+	parquetTypeInt64     = 2
+	parquetTypeDouble    = 5
+	parquetTypeByteArray = 6
+
+	parquetRequired     = 0
+	parquetUTF8         = 0
+	parquetPlain        = 0
+	parquetRLE          = 3
+	parquetUncompressed = 0
+	parquetDataPage     = 0
+)
+
+// parquetWriter writes rows to a Parquet file as they come, a row group at a time. Every column
+// is required and PLAIN encoded without compression, which any reader understands: text is a
+// UTF-8 byte array, amounts doubles and counts 64-bit integers.
+type parquetWriter struct {
+	out     *countingWriter
+	columns []exportColumn
+	values  []bytes.Buffer // Encoded values of each column in the current row group
+	rows    int            // Rows in the current row group
+	groups  []parquetRowGroup
+	err     error
+}
+
+// parquetRowGroup records where the column chunks of a written row group are
+type parquetRowGroup struct {
+	rows   int
+	chunks []parquetChunk
+}
+
+// parquetChunk is a column chunk of a single data page
+type parquetChunk struct {
+	offset int64 // Of the page header
+	size   int64 // Of the page header and values
+}
+
+func newParquetWriter(w io.Writer, columns []exportColumn) *parquetWriter {
+	e := &parquetWriter{out: &countingWriter{w: w}, columns: columns, values: make([]bytes.Buffer, len(columns))}
+	_, e.err = io.WriteString(e.out, parquetMagic)
+	return e
+}
+
+func (e *parquetWriter) WriteRow(row []interface{}) error {
+	if e.err != nil {
+		return e.err
+	}
+
+	for i, column := range e.columns {
+		values := &e.values[i]
+		switch column.kind {
+		case exportAmount:
+			amount, _ := row[i].(float64)
+			binary.Write(values, binary.LittleEndian, math.Float64bits(amount))
+		case exportCount:
+			count, _ := row[i].(int)
+			binary.Write(values, binary.LittleEndian, int64(count))
+		default:
+			text := reportCellText(row[i])
+			binary.Write(values, binary.LittleEndian, uint32(len(text)))
+			values.WriteString(text)
+		}
+	}
+
+	e.rows++
+	if e.rows == parquetRowGroupSize {
+		e.err = e.flushRowGroup()
+	}
+	return e.err
+}
+
+// flushRowGroup writes the buffered rows as a row group, a data page per column
+func (e *parquetWriter) flushRowGroup() error {
+	group := parquetRowGroup{rows: e.rows}
+	for i := range e.columns {
+		values := &e.values[i]
+
+		var header thriftWriter
+		header.beginStruct()
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(values.Len()))
+		header.i32(3, int32(values.Len()))
+		header.structField(5, func() {
+			header.i32(1, int32(e.rows))
+			header.i32(2, parquetPlain)
+			header.i32(3, parquetRLE)
+			header.i32(4, parquetRLE)
+		})
+		header.endStruct()
+
+		chunk := parquetChunk{offset: e.out.n, size: int64(header.buf.Len() + values.Len())}
+		if _, err := e.out.Write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if _, err := e.out.Write(values.Bytes()); err != nil {
+			return err
+		}
+		values.Reset()
+		group.chunks = append(group.chunks, chunk)
+	}
+
+	e.groups = append(e.groups, group)
+	e.rows = 0
+	return nil
+}
+
+// Close writes the last row group and the footer describing the schema and every row group
+func (e *parquetWriter) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	if e.rows > 0 {
+		if err := e.flushRowGroup(); err != nil {
+			return err
+		}
+	}
+
+	var totalRows int64
+	for _, group := range e.groups {
+		totalRows += int64(group.rows)
+	}
+
+	var footer thriftWriter
+	footer.beginStruct()
+	footer.i32(1, 1)
+	footer.structList(2, len(e.columns)+1, func(i int) {
+		if i == 0 {
+			footer.binary(4, "schema")
+			footer.i32(5, int32(len(e.columns)))
+			return
+		}
+		column := e.columns[i-1]
+		footer.i32(1, parquetColumnType(column))
+		footer.i32(3, parquetRequired)
+		footer.binary(4, column.name)
+		if column.kind == exportText {
+			footer.i32(6, parquetUTF8)
+		}
+	})
+	footer.i64(3, totalRows)
+	footer.structList(4, len(e.groups), func(g int) {
+		group := e.groups[g]
+		var groupSize int64
+		footer.structList(1, len(group.chunks), func(c int) {
+			chunk := group.chunks[c]
+			column := e.columns[c]
+			groupSize += chunk.size
+			footer.i64(2, chunk.offset)
+			footer.structField(3, func() {
+				footer.i32(1, parquetColumnType(column))
+				footer.i32List(2, []int32{parquetPlain, parquetRLE})
+				footer.binaryList(3, []string{column.name})
+				footer.i32(4, parquetUncompressed)
+				footer.i64(5, int64(group.rows))
+				footer.i64(6, chunk.size)
+				footer.i64(7, chunk.size)
+				footer.i64(9, chunk.offset)
+			})
+		})
+		footer.i64(2, groupSize)
+		footer.i64(3, int64(group.rows))
+	})
+	footer.endStruct()
+
+	if _, err := e.out.Write(footer.buf.Bytes()); err != nil {
+		return err
+	}
+	if err := binary.Write(e.out, binary.LittleEndian, uint32(footer.buf.Len())); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.out, parquetMagic)
+	return err
+}
+
+// parquetColumnType returns the physical type an export column is stored as
+func parquetColumnType(column exportColumn) int32 {
+	switch column.kind {
+	case exportAmount:
+		return parquetTypeDouble
+	case exportCount:
+		return parquetTypeInt64
+	}
+	return parquetTypeByteArray
+}
+
+// Thrift compact protocol field types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the structs of Parquet metadata with the Thrift compact protocol. Fields
+// must be written in increasing ID order within a struct.
+type thriftWriter struct {
+	buf    bytes.Buffer
+	lastID []int16 // ID of the last field written in each open struct
+}
+
+func (t *thriftWriter) beginStruct() {
+	t.lastID = append(t.lastID, 0)
+}
+
+func (t *thriftWriter) endStruct() {
+	t.buf.WriteByte(0)
+	t.lastID = t.lastID[:len(t.lastID)-1]
+}
+
+// field writes a field header, as a delta from the previous field ID when it fits in four bits
+func (t *thriftWriter) field(id int16, fieldType byte) {
+	last := &t.lastID[len(t.lastID)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		t.buf.WriteByte(fieldType)
+		t.varint(int64(id))
+	}
+	*last = id
+}
+
+// varint writes a zigzag encoded variable length integer
+func (t *thriftWriter) varint(v int64) {
+	var buf [binary.MaxVarintLen64]byte
+	t.buf.Write(buf[:binary.PutUvarint(buf[:], uint64(v<<1^v>>63))])
+}
+
+// uvarint writes an unsigned variable length integer, as used for lengths
+func (t *thriftWriter) uvarint(v int) {
+	var buf [binary.MaxVarintLen64]byte
+	t.buf.Write(buf[:binary.PutUvarint(buf[:], uint64(v))])
+}
+
+func (t *thriftWriter) listHeader(elementType byte, size int) {
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elementType)
+		return
+	}
+	t.buf.WriteByte(0xf0 | elementType)
+	t.uvarint(size)
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) binary(id int16, v string) {
+	t.field(id, thriftBinary)
+	t.uvarint(len(v))
+	t.buf.WriteString(v)
+}
+
+func (t *thriftWriter) structField(id int16, fields func()) {
+	t.field(id, thriftStruct)
+	t.beginStruct()
+	fields()
+	t.endStruct()
+}
+
+func (t *thriftWriter) structList(id int16, size int, element func(i int)) {
+	t.field(id, thriftList)
+	t.listHeader(thriftStruct, size)
+	for i := 0; i < size; i++ {
+		t.beginStruct()
+		element(i)
+		t.endStruct()
+	}
+}
+
+func (t *thriftWriter) i32List(id int16, values []int32) {
+	t.field(id, thriftList)
+	t.listHeader(thriftI32, len(values))
+	for _, v := range values {
+		t.varint(int64(v))
+	}
+}
+
+func (t *thriftWriter) binaryList(id int16, values []string) {
+	t.field(id, thriftList)
+	t.listHeader(thriftBinary, len(values))
+	for _, v := range values {
+		t.uvarint(len(v))
+		t.buf.WriteString(v)
+	}
+}
@@ -0,0 +1,463 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"testing"
+)
+
+// The reader below decodes Parquet metadata as parquet.thrift defines it, independently of the
+// writer, so that a file the writer gets wrong fails to read back.
+
+// Thrift compact protocol field types and parquet.thrift enum values, as the specifications number them
+const (
+	compactI32    = 5
+	compactI64    = 6
+	compactBinary = 8
+	compactList   = 9
+	compactStruct = 12
+
+	specTypeInt64     = 2
+	specTypeDouble    = 5
+	specTypeByteArray = 6
+	specRequired      = 0
+	specUTF8          = 0
+	specPlain         = 0
+	specUncompressed  = 0
+	specDataPage      = 0
+)
+
+// compactReader decodes the Thrift compact protocol
+type compactReader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (r *compactReader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf("offset %d: %s", r.pos, fmt.Sprintf(format, args...))
+	}
+}
+
+func (r *compactReader) readByte() byte {
+	if r.err != nil || r.pos >= len(r.buf) {
+		r.fail("unexpected end of data")
+		return 0
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *compactReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		r.fail("malformed varint")
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *compactReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *compactReader) binary() []byte {
+	size := int(r.uvarint())
+	if r.err != nil || size < 0 || r.pos+size > len(r.buf) {
+		r.fail("binary of %d bytes overruns the data", size)
+		return nil
+	}
+	v := r.buf[r.pos : r.pos+size]
+	r.pos += size
+	return v
+}
+
+// readStruct reads the fields of a struct, handing each one to field, which reads or skips its value
+func (r *compactReader) readStruct(field func(id int16, fieldType byte)) {
+	var lastID int16
+	for r.err == nil {
+		header := r.readByte()
+		if header == 0 {
+			return
+		}
+		fieldType := header & 0x0f
+		id := lastID + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		lastID = id
+		field(id, fieldType)
+	}
+}
+
+// readList reads a list header and hands each element to element
+func (r *compactReader) readList(element func(elementType byte)) {
+	header := r.readByte()
+	size := int(header >> 4)
+	if size == 15 {
+		size = int(r.uvarint())
+	}
+	for i := 0; i < size && r.err == nil; i++ {
+		element(header & 0x0f)
+	}
+}
+
+func (r *compactReader) skip(fieldType byte) {
+	switch fieldType {
+	case 1, 2: // Booleans are held in the field type
+	case 3:
+		r.readByte()
+	case 4, 5, 6:
+		r.zigzag()
+	case 7:
+		r.pos += 8
+	case 8:
+		r.binary()
+	case 9, 10:
+		r.readList(func(elementType byte) {
+			// Booleans in a list take a byte each
+			if elementType == 1 || elementType == 2 {
+				r.readByte()
+				return
+			}
+			r.skip(elementType)
+		})
+	case 12:
+		r.readStruct(func(_ int16, fieldType byte) { r.skip(fieldType) })
+	default:
+		r.fail("cannot skip field type %d", fieldType)
+	}
+}
+
+// expect checks the type of a field before its value is read
+func (r *compactReader) expect(fieldType, want byte, id int16) bool {
+	if fieldType != want {
+		r.fail("field %d has type %d, want %d", id, fieldType, want)
+		return false
+	}
+	return true
+}
+
+// The parquet.thrift structs the writer produces, with the fields it sets
+type (
+	parquetFileMetaData struct {
+		version   int64
+		schema    []parquetSchemaElement
+		numRows   int64
+		rowGroups []parquetRowGroupMeta
+	}
+	parquetSchemaElement struct {
+		physicalType   int64
+		repetitionType int64
+		name           string
+		numChildren    int64
+		convertedType  int64
+	}
+	parquetRowGroupMeta struct {
+		columns       []parquetColumnMeta
+		totalByteSize int64
+		numRows       int64
+	}
+	parquetColumnMeta struct {
+		fileOffset        int64
+		physicalType      int64
+		encodings         []int64
+		path              []string
+		codec             int64
+		numValues         int64
+		totalUncompressed int64
+		totalCompressed   int64
+		dataPageOffset    int64
+		hasMetaData       bool
+		hasDataPageOffset bool
+	}
+	parquetPageHeader struct {
+		pageType         int64
+		uncompressedSize int64
+		compressedSize   int64
+		numValues        int64
+		encoding         int64
+	}
+)
+
+func readFileMetaData(r *compactReader) parquetFileMetaData {
+	meta := parquetFileMetaData{}
+	r.readStruct(func(id int16, fieldType byte) {
+		switch {
+		case id == 1 && r.expect(fieldType, compactI32, id):
+			meta.version = r.zigzag()
+		case id == 2 && r.expect(fieldType, compactList, id):
+			r.readList(func(byte) { meta.schema = append(meta.schema, readSchemaElement(r)) })
+		case id == 3 && r.expect(fieldType, compactI64, id):
+			meta.numRows = r.zigzag()
+		case id == 4 && r.expect(fieldType, compactList, id):
+			r.readList(func(byte) { meta.rowGroups = append(meta.rowGroups, readRowGroup(r)) })
+		default:
+			r.skip(fieldType)
+		}
+	})
+	return meta
+}
+
+func readSchemaElement(r *compactReader) parquetSchemaElement {
+	element := parquetSchemaElement{physicalType: -1, repetitionType: -1, convertedType: -1}
+	r.readStruct(func(id int16, fieldType byte) {
+		switch {
+		case id == 1 && r.expect(fieldType, compactI32, id):
+			element.physicalType = r.zigzag()
+		case id == 3 && r.expect(fieldType, compactI32, id):
+			element.repetitionType = r.zigzag()
+		case id == 4 && r.expect(fieldType, compactBinary, id):
+			element.name = string(r.binary())
+		case id == 5 && r.expect(fieldType, compactI32, id):
+			element.numChildren = r.zigzag()
+		case id == 6 && r.expect(fieldType, compactI32, id):
+			element.convertedType = r.zigzag()
+		default:
+			r.skip(fieldType)
+		}
+	})
+	return element
+}
+
+func readRowGroup(r *compactReader) parquetRowGroupMeta {
+	group := parquetRowGroupMeta{}
+	r.readStruct(func(id int16, fieldType byte) {
+		switch {
+		case id == 1 && r.expect(fieldType, compactList, id):
+			r.readList(func(byte) { group.columns = append(group.columns, readColumnChunk(r)) })
+		case id == 2 && r.expect(fieldType, compactI64, id):
+			group.totalByteSize = r.zigzag()
+		case id == 3 && r.expect(fieldType, compactI64, id):
+			group.numRows = r.zigzag()
+		default:
+			r.skip(fieldType)
+		}
+	})
+	return group
+}
+
+func readColumnChunk(r *compactReader) parquetColumnMeta {
+	column := parquetColumnMeta{}
+	r.readStruct(func(id int16, fieldType byte) {
+		switch {
+		case id == 2 && r.expect(fieldType, compactI64, id):
+			column.fileOffset = r.zigzag()
+		case id == 3 && r.expect(fieldType, compactStruct, id):
+			column.hasMetaData = true
+			r.readStruct(func(id int16, fieldType byte) {
+				switch {
+				case id == 1 && r.expect(fieldType, compactI32, id):
+					column.physicalType = r.zigzag()
+				case id == 2 && r.expect(fieldType, compactList, id):
+					r.readList(func(byte) { column.encodings = append(column.encodings, r.zigzag()) })
+				case id == 3 && r.expect(fieldType, compactList, id):
+					r.readList(func(byte) { column.path = append(column.path, string(r.binary())) })
+				case id == 4 && r.expect(fieldType, compactI32, id):
+					column.codec = r.zigzag()
+				case id == 5 && r.expect(fieldType, compactI64, id):
+					column.numValues = r.zigzag()
+				case id == 6 && r.expect(fieldType, compactI64, id):
+					column.totalUncompressed = r.zigzag()
+				case id == 7 && r.expect(fieldType, compactI64, id):
+					column.totalCompressed = r.zigzag()
+				case id == 9 && r.expect(fieldType, compactI64, id):
+					column.dataPageOffset = r.zigzag()
+					column.hasDataPageOffset = true
+				default:
+					r.skip(fieldType)
+				}
+			})
+		default:
+			r.skip(fieldType)
+		}
+	})
+	return column
+}
+
+func readPageHeader(r *compactReader) parquetPageHeader {
+	header := parquetPageHeader{pageType: -1, encoding: -1}
+	r.readStruct(func(id int16, fieldType byte) {
+		switch {
+		case id == 1 && r.expect(fieldType, compactI32, id):
+			header.pageType = r.zigzag()
+		case id == 2 && r.expect(fieldType, compactI32, id):
+			header.uncompressedSize = r.zigzag()
+		case id == 3 && r.expect(fieldType, compactI32, id):
+			header.compressedSize = r.zigzag()
+		case id == 5 && r.expect(fieldType, compactStruct, id):
+			r.readStruct(func(id int16, fieldType byte) {
+				switch {
+				case id == 1 && r.expect(fieldType, compactI32, id):
+					header.numValues = r.zigzag()
+				case id == 2 && r.expect(fieldType, compactI32, id):
+					header.encoding = r.zigzag()
+				default:
+					r.skip(fieldType)
+				}
+			})
+		default:
+			r.skip(fieldType)
+		}
+	})
+	return header
+}
+
+// readParquet decodes the footer of a Parquet file and reads the PLAIN encoded values of every
+// column chunk back, as rows of text
+func readParquet(content []byte) (parquetFileMetaData, [][]string, error) {
+	if len(content) < 12 || string(content[:4]) != "PAR1" || string(content[len(content)-4:]) != "PAR1" {
+		return parquetFileMetaData{}, nil, errors.New("not framed by PAR1")
+	}
+	footerSize := int(binary.LittleEndian.Uint32(content[len(content)-8:]))
+	footerStart := len(content) - 8 - footerSize
+	if footerStart < 4 {
+		return parquetFileMetaData{}, nil, fmt.Errorf("footer of %d bytes does not fit the file", footerSize)
+	}
+
+	footer := &compactReader{buf: content[footerStart : len(content)-8]}
+	meta := readFileMetaData(footer)
+	if footer.err != nil {
+		return meta, nil, fmt.Errorf("footer: %v", footer.err)
+	}
+	if footer.pos != footerSize {
+		return meta, nil, fmt.Errorf("footer: decoded %d of %d bytes", footer.pos, footerSize)
+	}
+
+	var rows [][]string
+	for g, group := range meta.rowGroups {
+		groupRows := make([][]string, group.numRows)
+		for c, column := range group.columns {
+			if !column.hasMetaData || !column.hasDataPageOffset {
+				return meta, nil, fmt.Errorf("row group %d column %d has no metadata", g, c)
+			}
+			if column.dataPageOffset < 4 || column.dataPageOffset+column.totalCompressed > int64(footerStart) {
+				return meta, nil, fmt.Errorf("row group %d column %d lies outside the data", g, c)
+			}
+
+			page := &compactReader{buf: content[column.dataPageOffset : column.dataPageOffset+column.totalCompressed]}
+			header := readPageHeader(page)
+			if page.err != nil {
+				return meta, nil, fmt.Errorf("row group %d column %d page header: %v", g, c, page.err)
+			}
+			if header.pageType != specDataPage || header.encoding != specPlain || header.numValues != group.numRows {
+				return meta, nil, fmt.Errorf("row group %d column %d page header = %+v", g, c, header)
+			}
+			if int64(page.pos)+header.compressedSize != column.totalCompressed || header.compressedSize != header.uncompressedSize {
+				return meta, nil, fmt.Errorf("row group %d column %d page of %d bytes after a %d byte header, chunk of %d", g, c, header.compressedSize, page.pos, column.totalCompressed)
+			}
+
+			values := page.buf[page.pos:]
+			for i := range groupRows {
+				var value string
+				switch column.physicalType {
+				case specTypeDouble:
+					if len(values) < 8 {
+						return meta, nil, fmt.Errorf("row group %d column %d ends early", g, c)
+					}
+					value = strconv.FormatFloat(math.Float64frombits(binary.LittleEndian.Uint64(values)), 'f', -1, 64)
+					values = values[8:]
+				case specTypeInt64:
+					if len(values) < 8 {
+						return meta, nil, fmt.Errorf("row group %d column %d ends early", g, c)
+					}
+					value = strconv.FormatInt(int64(binary.LittleEndian.Uint64(values)), 10)
+					values = values[8:]
+				case specTypeByteArray:
+					if len(values) < 4 || len(values) < 4+int(binary.LittleEndian.Uint32(values)) {
+						return meta, nil, fmt.Errorf("row group %d column %d ends early", g, c)
+					}
+					size := int(binary.LittleEndian.Uint32(values))
+					value = string(values[4 : 4+size])
+					values = values[4+size:]
+				default:
+					return meta, nil, fmt.Errorf("row group %d column %d has type %d", g, c, column.physicalType)
+				}
+				groupRows[i] = append(groupRows[i], value)
+			}
+			if len(values) != 0 {
+				return meta, nil, fmt.Errorf("row group %d column %d has %d bytes after its values", g, c, len(values))
+			}
+		}
+		rows = append(rows, groupRows...)
+	}
+	return meta, rows, nil
+}
+
+func TestParquetWriter_ReadsBack(t *testing.T) {
+	columns := []exportColumn{{"reference", exportText}, {"amount", exportAmount}, {"age_days", exportCount}}
+
+	// One more row than a row group holds, so the file has a full row group and a partial one
+	var want [][]string
+	var buf bytes.Buffer
+	writer := newParquetWriter(&buf, columns)
+	for i := 0; i <= parquetRowGroupSize; i++ {
+		reference := "INV-" + strconv.Itoa(i)
+		if i == 1 {
+			reference = "Wire \"42\", café"
+		}
+		amount := float64(i) - 0.125
+		if err := writer.WriteRow([]interface{}{reference, amount, i % 90}); err != nil {
+			t.Fatalf("WriteRow() error = %v", err)
+		}
+		want = append(want, []string{reference, strconv.FormatFloat(amount, 'f', -1, 64), strconv.Itoa(i % 90)})
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	meta, rows, err := readParquet(buf.Bytes())
+	if err != nil {
+		t.Fatalf("readParquet() error = %v", err)
+	}
+
+	if meta.version != 1 || meta.numRows != int64(len(want)) {
+		t.Errorf("FileMetaData version = %d, num_rows = %d, want 1 and %d", meta.version, meta.numRows, len(want))
+	}
+	wantSchema := []parquetSchemaElement{
+		{physicalType: -1, repetitionType: -1, name: "schema", numChildren: 3, convertedType: -1},
+		{physicalType: specTypeByteArray, repetitionType: specRequired, name: "reference", convertedType: specUTF8},
+		{physicalType: specTypeDouble, repetitionType: specRequired, name: "amount", convertedType: -1},
+		{physicalType: specTypeInt64, repetitionType: specRequired, name: "age_days", convertedType: -1},
+	}
+	if fmt.Sprint(meta.schema) != fmt.Sprint(wantSchema) {
+		t.Errorf("FileMetaData schema = %+v, want %+v", meta.schema, wantSchema)
+	}
+
+	if len(meta.rowGroups) != 2 || meta.rowGroups[0].numRows != parquetRowGroupSize || meta.rowGroups[1].numRows != 1 {
+		t.Fatalf("FileMetaData row groups = %d, want a full one and one of a single row", len(meta.rowGroups))
+	}
+	for g, group := range meta.rowGroups {
+		var groupSize int64
+		for c, column := range group.columns {
+			groupSize += column.totalCompressed
+			if column.fileOffset != column.dataPageOffset || column.numValues != group.numRows || column.codec != specUncompressed ||
+				column.totalUncompressed != column.totalCompressed || len(column.path) != 1 || column.path[0] != columns[c].name {
+				t.Errorf("row group %d column %d metadata = %+v", g, c, column)
+			}
+		}
+		if group.totalByteSize != groupSize {
+			t.Errorf("row group %d total_byte_size = %d, want %d", g, group.totalByteSize, groupSize)
+		}
+	}
+
+	if len(rows) != len(want) {
+		t.Fatalf("read %d rows, want %d", len(rows), len(want))
+	}
+	for i := range want {
+		if fmt.Sprint(rows[i]) != fmt.Sprint(want[i]) {
+			t.Errorf("row %d = %q, want %q", i, rows[i], want[i])
+		}
+	}
+}
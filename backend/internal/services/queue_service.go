@@ -1,6 +1,8 @@
 package services
 
 import (
+	"backend/internal/models"
+	"backend/internal/repository"
 	"encoding/json"
 	"errors"
 	"log"
@@ -30,8 +32,11 @@ type ProcessDataSourcePayload struct {
 
 // RunMatchSetPayload contains data for running a match set
 type RunMatchSetPayload struct {
-	MatchSetID string `json:"match_set_id"`
-	TenantID   string `json:"tenant_id"`
+	MatchSetID     string `json:"match_set_id"`
+	TenantID       string `json:"tenant_id"`
+	UserID         string `json:"user_id"`                    // User the run acts as
+	Mode           string `json:"mode,omitempty"`             // models.RunModeFull or models.RunModeIncremental
+	ScheduledRunID string `json:"scheduled_run_id,omitempty"` // Scheduled run to record the outcome on
 }
 
 // QueueService provides methods for handling messages from a queue
@@ -41,6 +46,8 @@ type QueueService struct {
 	matchSetService    *MatchSetService
 	transactionService *TransactionService
 	uploadService      *UploadService
+	scheduleRepo       repository.ScheduleRepository
+	autoRunService     *AutoRunService
 	// In a real implementation, we would have an SQS client here
 	// sqsClient       *sqs.SQS
 }
@@ -52,6 +59,8 @@ func NewQueueService(
 	matchSetService *MatchSetService,
 	transactionService *TransactionService,
 	uploadService *UploadService,
+	scheduleRepo repository.ScheduleRepository,
+	autoRunService *AutoRunService,
 ) *QueueService {
 	return &QueueService{
 		schemaService:      schemaService,
@@ -59,6 +68,8 @@ func NewQueueService(
 		matchSetService:    matchSetService,
 		transactionService: transactionService,
 		uploadService:      uploadService,
+		scheduleRepo:       scheduleRepo,
+		autoRunService:     autoRunService,
 	}
 }
 
@@ -74,11 +85,15 @@ func (s *QueueService) SendProcessDataSourceMessage(dataSourceID, schemaID, uplo
 	return s.sendMessage(MessageTypeProcessDataSource, payload)
 }
 
-// SendRunMatchSetMessage sends a message to run a match set
-func (s *QueueService) SendRunMatchSetMessage(matchSetID, tenantID string) error {
+// SendRunMatchSetMessage sends a message to run a match set as a user. A scheduled run gets its
+// outcome recorded once the run finishes.
+func (s *QueueService) SendRunMatchSetMessage(matchSetID, tenantID, userID, mode, scheduledRunID string) error {
 	payload := RunMatchSetPayload{
-		MatchSetID: matchSetID,
-		TenantID:   tenantID,
+		MatchSetID:     matchSetID,
+		TenantID:       tenantID,
+		UserID:         userID,
+		Mode:           mode,
+		ScheduledRunID: scheduledRunID,
 	}
 
 	return s.sendMessage(MessageTypeRunMatchSet, payload)
@@ -120,6 +135,38 @@ func (s *QueueService) StartListener() {
 	log.Println("Queue listener started")
 }
 
+// autoRunTickInterval is how often due auto-runs are started, and so how late after its debounce
+// an auto-run may start
+const autoRunTickInterval = 15 * time.Second
+
+// StartAutoRunner starts the runs of match sets whose data sources received imports once their
+// debounce passes
+func (s *QueueService) StartAutoRunner() {
+	go func() {
+		ticker := time.NewTicker(autoRunTickInterval)
+		defer ticker.Stop()
+
+		for now := range ticker.C {
+			if err := s.RunDueAutoRuns(now); err != nil {
+				log.Printf("Failed to start due auto-runs: %v", err)
+			}
+		}
+	}()
+
+	log.Println("Match set auto-runner started")
+}
+
+// RunDueAutoRuns enqueues an incremental run of each match set whose auto-run is due at now
+func (s *QueueService) RunDueAutoRuns(now time.Time) error {
+	autoRuns, err := s.autoRunService.ClaimDueAutoRuns(now)
+	for _, autoRun := range autoRuns {
+		if err := s.SendRunMatchSetMessage(autoRun.MatchSetID, autoRun.TenantID, autoRun.RunAs, models.RunModeIncremental, ""); err != nil {
+			log.Printf("Auto-run of match set %s failed: %v", autoRun.MatchSetID, err)
+		}
+	}
+	return err
+}
+
 // HandleMessage processes a single message from the queue
 func (s *QueueService) HandleMessage(message QueueMessage) error {
 	log.Printf("Processing message of type: %s", message.Type)
@@ -157,22 +204,30 @@ func (s *QueueService) handleProcessDataSource(payload ProcessDataSourcePayload)
 	// For development, we'll just log it
 	log.Printf("Data source %s processed successfully", payload.DataSourceID)
 
-	return nil
+	// Match sets of the data source that run on imports pick it up after their debounce
+	return s.autoRunService.ImportCompleted(payload.TenantID, payload.DataSourceID)
 }
 
-// handleRunMatchSet runs a match set
+// handleRunMatchSet runs a match set. The outcome of a scheduled run is recorded rather than
+// returned, so a failed or skipped run is not retried; it waits for the schedule's next run.
 func (s *QueueService) handleRunMatchSet(payload RunMatchSetPayload) error {
 	log.Printf("Running match set %s for tenant %s", payload.MatchSetID, payload.TenantID)
 
-	// In a real implementation, this would:
-	// 1. Get the match set and its data sources
-	// 2. Get the matching rules
-	// 3. Apply the rules to find matches
-	// 4. Create matched_transaction and unmatched_transaction records
-	// 5. Update the match progress
+	progress, err := s.matchSetService.RunMatchSet(payload.MatchSetID, RunOptions{Mode: payload.Mode}, payload.UserID, payload.TenantID)
+	if payload.ScheduledRunID == "" {
+		if err != nil {
+			return err
+		}
+		log.Printf("Match set %s run %s finished with status %s", payload.MatchSetID, progress.RunID, progress.Status)
+		return nil
+	}
 
-	// For development, we'll just log it
-	log.Printf("Match set %s completed successfully", payload.MatchSetID)
+	run := scheduledRunOutcome(progress, err)
+	run.ID = payload.ScheduledRunID
+	if err := s.scheduleRepo.FinishScheduledRun(run); err != nil {
+		return err
+	}
 
+	log.Printf("Scheduled run %s of match set %s finished with status %s", run.ID, payload.MatchSetID, run.Status)
 	return nil
 }
@@ -0,0 +1,443 @@
+package services
+
+import (
+	"archive/zip"
+	"backend/internal/models"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// reportTable is one section of a rendered report. Cells are strings, ints or float64 amounts.
+type reportTable struct {
+	title  string
+	header []string
+	rows   [][]interface{}
+}
+
+// renderReport renders a reconciliation report to a format
+func renderReport(report *models.ReconciliationReport, format string) ([]byte, error) {
+	tables := reportTables(report)
+	switch format {
+	case models.ReportFormatCSV:
+		return renderReportCSV(tables)
+	case models.ReportFormatXLSX:
+		return renderReportXLSX(tables)
+	case models.ReportFormatPDF:
+		return renderReportPDF(tables), nil
+	}
+	return nil, errors.New("invalid report: format must be csv, xlsx or pdf")
+}
+
+// reportTables lays a reconciliation report out as the sections every format renders
+func reportTables(report *models.ReconciliationReport) []reportTable {
+	summary := reportTable{
+		title:  "Reconciliation",
+		header: []string{"Field", "Value"},
+		rows: [][]interface{}{
+			{"Match set", report.MatchSetName},
+			{"Match set ID", report.MatchSetID},
+			{"Period start", report.PeriodStart.Format("2006-01-02")},
+			{"Period end", report.PeriodEnd.Format("2006-01-02")},
+			{"Generated at", report.GeneratedAt.Format("2006-01-02 15:04:05 MST")},
+		},
+	}
+
+	balances := reportTable{
+		title: "Balances",
+		header: []string{"Data source", "Side", "Currency", "Opening balance", "Period activity", "Closing balance",
+			"Matched", "Matched total", "Unmatched", "Unmatched total"},
+	}
+	for _, source := range report.Sources {
+		balances.rows = append(balances.rows, []interface{}{
+			source.DataSourceName, source.Side, source.Currency, source.OpeningBalance, source.PeriodActivity,
+			source.ClosingBalance, source.MatchedCount, source.MatchedTotal, source.UnmatchedCount, source.UnmatchedTotal,
+		})
+	}
+
+	differences := reportTable{
+		title:  "Closing difference",
+		header: []string{"Currency", "Left closing", "Right closing", "Adjustments", "Difference"},
+	}
+	for _, difference := range report.Differences {
+		differences.rows = append(differences.rows, []interface{}{
+			difference.Currency, difference.LeftClosing, difference.RightClosing, difference.Adjustments, difference.Difference,
+		})
+	}
+
+	unmatched := reportTable{
+		title:  "Unmatched items",
+		header: []string{"Date", "Data source", "Reference", "Description", "Amount", "Currency", "Age (days)", "Age bucket"},
+	}
+	for _, item := range report.Unmatched {
+		unmatched.rows = append(unmatched.rows, []interface{}{
+			item.TransactionDate.Format("2006-01-02"), item.DataSourceName, item.Reference, item.Description,
+			item.Amount, item.Currency, item.AgeDays, item.AgeBucket,
+		})
+	}
+
+	adjustments := reportTable{
+		title:  "Adjustments",
+		header: []string{"Date", "Match", "Amount", "Currency", "GL account", "Reason", "Posted by"},
+	}
+	for _, adjustment := range report.Adjustments {
+		adjustments.rows = append(adjustments.rows, []interface{}{
+			adjustment.TransactionDate.Format("2006-01-02"), adjustment.MatchID, adjustment.Amount, adjustment.Currency,
+			adjustment.GLAccount, adjustment.Reason, adjustment.CreatedBy,
+		})
+	}
+
+	return []reportTable{summary, balances, differences, unmatched, adjustments}
+}
+
+// reportCellText formats a report cell as text. Amounts keep two decimals.
+func reportCellText(cell interface{}) string {
+	switch value := cell.(type) {
+	case float64:
+		return strconv.FormatFloat(value, 'f', 2, 64)
+	case int:
+		return strconv.Itoa(value)
+	case string:
+		return value
+	}
+	return fmt.Sprint(cell)
+}
+
+// renderReportCSV renders the sections one after the other, each under its title and separated
+// by a blank line
+func renderReportCSV(tables []reportTable) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	for i, table := range tables {
+		if i > 0 {
+			writer.Write(nil)
+		}
+		writer.Write([]string{table.title})
+		writer.Write(table.header)
+		for _, row := range table.rows {
+			record := make([]string, len(row))
+			for j, cell := range row {
+				record[j] = reportCellText(cell)
+			}
+			writer.Write(record)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// XLSX package parts that do not depend on the report
+const (
+	xlsxContentTypesHead = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+	// Style 1 is a bold header cell, style 2 an amount with two decimals
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/><xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>
+</styleSheet>`
+	xlsxSheetHead = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetTail = "</sheetData></worksheet>"
+)
+
+// renderReportXLSX renders each section to its own worksheet of an XLSX workbook
+func renderReportXLSX(tables []reportTable) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	titles := make([]string, len(tables))
+	for i, table := range tables {
+		titles[i] = table.title
+	}
+	if err := writeXLSXWorkbook(archive, titles); err != nil {
+		return nil, err
+	}
+
+	for i, table := range tables {
+		sheet, err := archive.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(sheet, xlsxSheet(table)); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeXLSXWorkbook writes the parts of an XLSX workbook other than its worksheets, which the
+// caller adds as xl/worksheets/sheetN.xml in the order of their titles
+func writeXLSXWorkbook(archive *zip.Writer, titles []string) error {
+	var contentTypes, workbook, workbookRels strings.Builder
+	contentTypes.WriteString(xlsxContentTypesHead)
+	workbook.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	for i, title := range titles {
+		sheet := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`+"\n", sheet)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(title), sheet, sheet)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, sheet, sheet)
+	}
+	contentTypes.WriteString("</Types>")
+	workbook.WriteString("</sheets></workbook>")
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`, len(titles)+1)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		w, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, part.content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// xlsxSheet renders a section as worksheet XML, header first. Amounts and counts are numeric cells.
+func xlsxSheet(table reportTable) string {
+	var sheet strings.Builder
+	sheet.WriteString(xlsxSheetHead)
+
+	header := make([]interface{}, len(table.header))
+	for i, title := range table.header {
+		header[i] = title
+	}
+	writeXLSXRow(&sheet, 1, header, true)
+	for r, row := range table.rows {
+		writeXLSXRow(&sheet, r+2, row, false)
+	}
+
+	sheet.WriteString(xlsxSheetTail)
+	return sheet.String()
+}
+
+// writeXLSXRow writes a worksheet row at a one-based index. Floats are amounts with two
+// decimals, ints plain numbers and anything else inline text, bold in a header.
+func writeXLSXRow(w io.Writer, index int, row []interface{}, header bool) error {
+	var line strings.Builder
+	fmt.Fprintf(&line, `<row r="%d">`, index)
+	for c, cell := range row {
+		ref := xlsxColumn(c) + strconv.Itoa(index)
+		switch value := cell.(type) {
+		case float64:
+			fmt.Fprintf(&line, `<c r="%s" s="2"><v>%s</v></c>`, ref, strconv.FormatFloat(value, 'f', -1, 64))
+		case int:
+			fmt.Fprintf(&line, `<c r="%s"><v>%d</v></c>`, ref, value)
+		default:
+			style := ""
+			if header {
+				style = ` s="1"`
+			}
+			fmt.Fprintf(&line, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, xmlEscape(reportCellText(cell)))
+		}
+	}
+	line.WriteString("</row>")
+	_, err := io.WriteString(w, line.String())
+	return err
+}
+
+// xlsxColumn returns the column letters of a zero-based column index
+func xlsxColumn(index int) string {
+	column := ""
+	for index >= 0 {
+		column = string(rune('A'+index%26)) + column
+		index = index/26 - 1
+	}
+	return column
+}
+
+// xmlEscape escapes text for XML content and attributes
+func xmlEscape(text string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}
+
+// PDF page layout: landscape A4 in points, monospaced text
+const (
+	pdfPageWidth     = 842
+	pdfPageHeight    = 595
+	pdfMargin        = 36
+	pdfFontSize      = 8
+	pdfLineHeight    = 10
+	pdfLineChars     = 160 // Courier is 0.6 em wide, so this fills the line between the margins
+	pdfMaxCellChars  = 32
+	pdfLinesPerPage  = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+	pdfColumnSpacing = 2
+)
+
+// renderReportPDF renders the sections as fixed-width text tables in a PDF document
+func renderReportPDF(tables []reportTable) []byte {
+	var lines []string
+	for i, table := range tables {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, strings.ToUpper(table.title))
+		lines = append(lines, pdfTableLines(table)...)
+	}
+
+	var pages [][]string
+	for len(lines) > 0 {
+		n := len(lines)
+		if n > pdfLinesPerPage {
+			n = pdfLinesPerPage
+		}
+		pages = append(pages, lines[:n])
+		lines = lines[n:]
+	}
+
+	// Objects 1 to 3 are the catalog, the page tree and the font; each page adds a page object
+	// and its content stream
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+	)
+	for i, page := range pages {
+		var content strings.Builder
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin-pdfFontSize)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) '\n", pdfEscape(line))
+		}
+		content.WriteString("ET")
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// pdfTableLines lays a section out as padded text lines. Amounts and counts are right-aligned
+// and long text is cut short.
+func pdfTableLines(table reportTable) []string {
+	widths := make([]int, len(table.header))
+	cells := make([][]string, len(table.rows))
+	for i, title := range table.header {
+		widths[i] = len(title)
+	}
+	for r, row := range table.rows {
+		cells[r] = make([]string, len(row))
+		for c, cell := range row {
+			text := []rune(reportCellText(cell))
+			if len(text) > pdfMaxCellChars {
+				text = append(text[:pdfMaxCellChars-3], []rune("...")...)
+			}
+			cells[r][c] = string(text)
+			if len(text) > widths[c] {
+				widths[c] = len(text)
+			}
+		}
+	}
+
+	format := func(values []string, row []interface{}) string {
+		var line strings.Builder
+		for c, value := range values {
+			padding := strings.Repeat(" ", widths[c]-len([]rune(value)))
+			switch row[c].(type) {
+			case float64, int:
+				line.WriteString(padding + value)
+			default:
+				line.WriteString(value + padding)
+			}
+			line.WriteString(strings.Repeat(" ", pdfColumnSpacing))
+		}
+		text := []rune(strings.TrimRight(line.String(), " "))
+		if len(text) > pdfLineChars {
+			text = text[:pdfLineChars]
+		}
+		return string(text)
+	}
+
+	header := make([]interface{}, len(table.header))
+	rules := make([]string, len(table.header))
+	for i := range table.header {
+		header[i] = table.header[i]
+		rules[i] = strings.Repeat("-", widths[i])
+	}
+
+	lines := []string{format(table.header, header), format(rules, header)}
+	for r, row := range table.rows {
+		lines = append(lines, format(cells[r], row))
+	}
+	if len(table.rows) == 0 {
+		lines = append(lines, "(none)")
+	}
+	return lines
+}
+
+// pdfEscape escapes a line for a PDF string literal. Characters outside Latin-1 become '?'.
+func pdfEscape(line string) string {
+	var escaped strings.Builder
+	for _, r := range line {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			escaped.WriteRune('\\')
+			escaped.WriteRune(r)
+		case r < 32 || r > 255:
+			escaped.WriteByte('?')
+		case r > 126:
+			fmt.Fprintf(&escaped, "\\%03o", r)
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+	return escaped.String()
+}
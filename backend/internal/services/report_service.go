@@ -0,0 +1,433 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxReportPeriod is the longest period a reconciliation report may cover
+const maxReportPeriod = 366 * 24 * time.Hour
+
+// ReportService provides methods for generating and retrieving reconciliation reports
+type ReportService struct {
+	reportRepo      repository.ReportRepository
+	matchSetRepo    repository.MatchSetRepository
+	transactionRepo repository.TransactionRepository
+	matchRepo       repository.MatchRepository
+	adjustmentRepo  repository.AdjustmentRepository
+	progressRepo    repository.MatchProgressRepository
+	permissionRepo  repository.PermissionRepository
+	storage         StorageService
+}
+
+// NewReportService creates a new report service
+func NewReportService(
+	reportRepo repository.ReportRepository,
+	matchSetRepo repository.MatchSetRepository,
+	transactionRepo repository.TransactionRepository,
+	matchRepo repository.MatchRepository,
+	adjustmentRepo repository.AdjustmentRepository,
+	progressRepo repository.MatchProgressRepository,
+	permissionRepo repository.PermissionRepository,
+	storage StorageService,
+) *ReportService {
+	return &ReportService{
+		reportRepo:      reportRepo,
+		matchSetRepo:    matchSetRepo,
+		transactionRepo: transactionRepo,
+		matchRepo:       matchRepo,
+		adjustmentRepo:  adjustmentRepo,
+		progressRepo:    progressRepo,
+		permissionRepo:  permissionRepo,
+		storage:         storage,
+	}
+}
+
+// BuildReport assembles the reconciliation statement of a match set for the days from
+// periodStart to periodEnd, both included, without storing it
+func (s *ReportService) BuildReport(matchSetID string, periodStart, periodEnd time.Time, userID, tenantID string) (*models.ReconciliationReport, error) {
+	if err := s.authorize(userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	matchSet, err := s.tenantMatchSet(matchSetID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	periodStart, periodEnd, err = reportPeriod(periodStart, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.build(matchSet, periodStart, periodEnd)
+}
+
+// GenerateReport renders the reconciliation statement of a match set to a format and stores the
+// file with the match set's latest run. A report cannot be generated while a run is changing
+// the groups it reports on.
+func (s *ReportService) GenerateReport(matchSetID string, periodStart, periodEnd time.Time, format, userID, tenantID string) (*models.ReportFile, error) {
+	if err := s.authorize(userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	matchSet, err := s.tenantMatchSet(matchSetID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	periodStart, periodEnd, err = reportPeriod(periodStart, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+	if ReportContentType(format) == "" {
+		return nil, errors.New("invalid report: format must be csv, xlsx or pdf")
+	}
+
+	var runID string
+	progress, err := s.progressRepo.GetProgress(matchSet.TenantID, matchSet.ID)
+	if err != nil && err != repository.ErrMatchProgressNotFound {
+		return nil, err
+	}
+	if err == nil {
+		if progress.Status == "Running" && progress.LeaseExpiresAt != nil && progress.LeaseExpiresAt.After(time.Now()) {
+			return nil, errors.New("invalid report: a run of this match set is in progress")
+		}
+		runID = progress.RunID
+	}
+
+	report, err := s.build(matchSet, periodStart, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := renderReport(report, format)
+	if err != nil {
+		return nil, err
+	}
+
+	filename := fmt.Sprintf("reconciliation_%s_%s.%s", periodStart.Format("20060102"), periodEnd.Format("20060102"), format)
+	fileKey, err := s.storage.SaveFile(tenantID, "reports", filename, bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := sha256.Sum256(content)
+	file := &models.ReportFile{
+		TenantID:    tenantID,
+		MatchSetID:  matchSet.ID,
+		RunID:       runID,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		Format:      format,
+		FileKey:     fileKey,
+		FileSize:    int64(len(content)),
+		Checksum:    hex.EncodeToString(checksum[:]),
+		GeneratedBy: userID,
+	}
+	if err := s.reportRepo.CreateReport(file); err != nil {
+		s.storage.DeleteFile(tenantID, fileKey)
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// GetReports retrieves the reports stored for a match set, newest first
+func (s *ReportService) GetReports(matchSetID, userID, tenantID string) ([]models.ReportFile, error) {
+	if err := s.authorize(userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	matchSet, err := s.tenantMatchSet(matchSetID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.reportRepo.GetReportsByMatchSet(tenantID, matchSet.ID)
+}
+
+// OpenReport retrieves a stored report and opens its file. The caller closes the file.
+func (s *ReportService) OpenReport(reportID, userID, tenantID string) (*models.ReportFile, io.ReadCloser, error) {
+	if err := s.authorize(userID, tenantID); err != nil {
+		return nil, nil, err
+	}
+
+	report, err := s.reportRepo.GetReportByID(tenantID, reportID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if report.TenantID != tenantID {
+		return nil, nil, errors.New("report not found in this tenant")
+	}
+
+	file, err := s.storage.OpenFile(tenantID, report.FileKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return report, file, nil
+}
+
+// ReportContentType returns the content type of a report format, or "" for an unknown format
+func ReportContentType(format string) string {
+	switch format {
+	case models.ReportFormatCSV:
+		return "text/csv"
+	case models.ReportFormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case models.ReportFormatPDF:
+		return "application/pdf"
+	}
+	return ""
+}
+
+// sourceTotals accumulates the balance of a data source in one currency, in amount units
+type sourceTotals struct {
+	opening, activity            int64
+	matched, unmatched           int64
+	matchedCount, unmatchedCount int
+}
+
+// build assembles the reconciliation statement of a match set over a validated period
+func (s *ReportService) build(matchSet *models.MatchSet, periodStart, periodEnd time.Time) (*models.ReconciliationReport, error) {
+	dataSources, err := s.matchSetRepo.GetMatchSetDataSources(matchSet.TenantID, matchSet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.matchRepo.GetMatchGroupsByMatchSet(matchSet.TenantID, matchSet.ID)
+	if err != nil {
+		return nil, err
+	}
+	matched := make(map[string]bool)
+	for _, transactionIDs := range groups {
+		for _, transactionID := range transactionIDs {
+			matched[transactionID] = true
+		}
+	}
+
+	report := &models.ReconciliationReport{
+		MatchSetID:   matchSet.ID,
+		MatchSetName: matchSet.Name,
+		TenantID:     matchSet.TenantID,
+		PeriodStart:  periodStart,
+		PeriodEnd:    periodEnd,
+		GeneratedAt:  time.Now().UTC(),
+		Sources:      []models.ReportSourceBalance{},
+		Unmatched:    []models.ReportUnmatchedItem{},
+		Adjustments:  []models.ReportAdjustment{},
+		Differences:  []models.ReportDifference{},
+	}
+
+	// Transactions dated on or after periodEnd's next day are outside the report
+	periodClose := periodEnd.AddDate(0, 0, 1)
+	left := make(map[string]int64)
+	right := make(map[string]int64)
+
+	for i, dataSource := range dataSources {
+		transactions, err := s.transactionRepo.GetTransactionsByDataSourceID(matchSet.TenantID, dataSource.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		totals := make(map[string]*sourceTotals)
+		currencies := make(map[string]bool)
+		for _, transaction := range transactions {
+			// The transaction of a voided adjustment no longer counts
+			if transaction.Status == "Voided" || !transaction.TransactionDate.Before(periodClose) {
+				continue
+			}
+
+			currency := strings.ToUpper(transaction.Currency)
+			if totals[currency] == nil {
+				totals[currency] = &sourceTotals{}
+				currencies[currency] = true
+			}
+			total := totals[currency]
+
+			units := toAmountUnits(transaction.Amount)
+			if transaction.TransactionDate.Before(periodStart) {
+				total.opening += units
+			} else {
+				total.activity += units
+				if matched[transaction.ID] {
+					total.matched += units
+					total.matchedCount++
+				}
+			}
+
+			if !matched[transaction.ID] {
+				total.unmatched += units
+				total.unmatchedCount++
+
+				ageDays := daysBetween(transaction.TransactionDate, periodEnd)
+				item := models.ReportUnmatchedItem{
+					TransactionID:   transaction.ID,
+					DataSourceID:    dataSource.ID,
+					DataSourceName:  dataSource.Name,
+					TransactionDate: transaction.TransactionDate,
+					Reference:       transaction.Reference,
+					Description:     transaction.Description,
+					Amount:          transaction.Amount,
+					Currency:        currency,
+					AgeDays:         ageDays,
+				}
+				if bucket := exceptionAgeBucket(ageDays); bucket != nil {
+					item.AgeBucket = bucket.Label
+				}
+				report.Unmatched = append(report.Unmatched, item)
+			}
+		}
+
+		side, closing := "right", right
+		if i == 0 {
+			side, closing = "left", left
+		}
+		for _, currency := range sortedCurrencies(currencies) {
+			total := totals[currency]
+			closing[currency] += total.opening + total.activity
+			report.Sources = append(report.Sources, models.ReportSourceBalance{
+				DataSourceID:   dataSource.ID,
+				DataSourceName: dataSource.Name,
+				Side:           side,
+				Currency:       currency,
+				OpeningBalance: fromAmountUnits(total.opening),
+				PeriodActivity: fromAmountUnits(total.activity),
+				ClosingBalance: fromAmountUnits(total.opening + total.activity),
+				MatchedCount:   total.matchedCount,
+				MatchedTotal:   fromAmountUnits(total.matched),
+				UnmatchedCount: total.unmatchedCount,
+				UnmatchedTotal: fromAmountUnits(total.unmatched),
+			})
+		}
+	}
+
+	sort.SliceStable(report.Unmatched, func(i, j int) bool {
+		a, b := report.Unmatched[i], report.Unmatched[j]
+		if !a.TransactionDate.Equal(b.TransactionDate) {
+			return a.TransactionDate.Before(b.TransactionDate)
+		}
+		return a.TransactionID < b.TransactionID
+	})
+
+	// Voided adjustments no longer count
+	adjusted := make(map[string]int64)
+	adjustments, err := s.adjustmentRepo.GetAdjustmentsByMatchSet(matchSet.TenantID, matchSet.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, adjustment := range adjustments {
+		if adjustment.VoidedAt != nil {
+			continue
+		}
+		transaction, err := s.transactionRepo.GetTransactionByID(adjustment.TenantID, adjustment.TransactionID)
+		if err == repository.ErrTransactionNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !transaction.TransactionDate.Before(periodClose) {
+			continue
+		}
+
+		currency := strings.ToUpper(adjustment.Currency)
+		adjusted[currency] += toAmountUnits(adjustment.Amount)
+		if !transaction.TransactionDate.Before(periodStart) {
+			report.Adjustments = append(report.Adjustments, models.ReportAdjustment{
+				Adjustment:      adjustment,
+				TransactionDate: transaction.TransactionDate,
+			})
+		}
+	}
+
+	currencies := make(map[string]bool)
+	for _, balances := range []map[string]int64{left, right, adjusted} {
+		for currency := range balances {
+			currencies[currency] = true
+		}
+	}
+	for _, currency := range sortedCurrencies(currencies) {
+		report.Differences = append(report.Differences, models.ReportDifference{
+			Currency:     currency,
+			LeftClosing:  fromAmountUnits(left[currency]),
+			RightClosing: fromAmountUnits(right[currency]),
+			Adjustments:  fromAmountUnits(adjusted[currency]),
+			Difference:   fromAmountUnits(left[currency] - right[currency] - adjusted[currency]),
+		})
+	}
+
+	return report, nil
+}
+
+// reportPeriod validates a report period and truncates it to whole UTC days
+func reportPeriod(periodStart, periodEnd time.Time) (time.Time, time.Time, error) {
+	if periodStart.IsZero() || periodEnd.IsZero() {
+		return time.Time{}, time.Time{}, errors.New("invalid report: from and to dates are required")
+	}
+
+	periodStart = startOfDay(periodStart)
+	periodEnd = startOfDay(periodEnd)
+	if periodEnd.Before(periodStart) {
+		return time.Time{}, time.Time{}, errors.New("invalid report: to must not be before from")
+	}
+	if periodEnd.Sub(periodStart) >= maxReportPeriod {
+		return time.Time{}, time.Time{}, errors.New("invalid report: a report covers at most 366 days")
+	}
+	return periodStart, periodEnd, nil
+}
+
+// startOfDay returns midnight UTC of the day of t
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// fromAmountUnits converts amount units back to an amount
+func fromAmountUnits(units int64) float64 {
+	return float64(units) / amountScale
+}
+
+// sortedCurrencies returns a set of currencies in order
+func sortedCurrencies(set map[string]bool) []string {
+	currencies := make([]string, 0, len(set))
+	for currency := range set {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// authorize checks that a user may view the reports of a tenant
+func (s *ReportService) authorize(userID, tenantID string) error {
+	// Check if user has permission to view transactions
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermViewTransactions, tenantID)
+	if err != nil {
+		return err
+	}
+	if !hasPermission {
+		return errors.New("unauthorized: requires view transactions permission")
+	}
+	return nil
+}
+
+// tenantMatchSet returns a match set when it belongs to the tenant
+func (s *ReportService) tenantMatchSet(matchSetID, tenantID string) (*models.MatchSet, error) {
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
+	if err != nil {
+		return nil, err
+	}
+	if matchSet.TenantID != tenantID {
+		return nil, errors.New("match set not found in this tenant")
+	}
+	return matchSet, nil
+}
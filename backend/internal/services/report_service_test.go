@@ -0,0 +1,216 @@
+package services
+
+import (
+	"archive/zip"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportService_ReconcilesPeriod(t *testing.T) {
+	matchSetRepo := repository.NewMatchSetRepository()
+	ruleRepo := repository.NewRuleRepository()
+	transactionRepo := repository.NewTransactionRepository()
+	matchRepo := repository.NewMatchRepository()
+	adjustmentRepo := repository.NewAdjustmentRepository(transactionRepo, matchRepo)
+	progressRepo := repository.NewMatchProgressRepository()
+
+	rule := &models.MatchRule{ID: "rule-1", TenantID: "tenant-1", Name: "Amount", Active: true, Conditions: []models.RuleCondition{
+		{Field: models.ConditionFieldAmount, Operator: models.ConditionOpWithin, Tolerance: 1},
+	}}
+	ruleRepo.CreateRule(rule)
+
+	matchSet := &models.MatchSet{ID: "set-1", Name: "Bank", TenantID: "tenant-1", RuleID: rule.ID}
+	matchSetRepo.CreateMatchSet(matchSet)
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "ledger")
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "bank")
+
+	opening := tx("L1", 100, 1, "")
+	opening.TransactionDate = time.Date(2024, time.February, 25, 0, 0, 0, 0, time.UTC)
+	late := tx("R4", 30, 1, "")
+	late.TransactionDate = time.Date(2024, time.April, 2, 0, 0, 0, 0, time.UTC)
+
+	for _, transaction := range []models.Transaction{
+		opening,
+		tx("L2", 200, 5, "INV-2"),
+		tx("R2", 200, 6, "INV-2"),
+		tx("L3", 50, 10, ""),
+		tx("R3", 49, 10, ""),
+		late,
+	} {
+		transaction.DataSourceID = "ledger"
+		if transaction.ID[0] == 'R' {
+			transaction.DataSourceID = "bank"
+		}
+		transaction.Status = "Unmatched"
+		transaction.Currency = "usd"
+		transactionRepo.CreateTransaction(&transaction)
+	}
+
+	matchService := NewMatchService(matchRepo, matchSetRepo, ruleRepo, transactionRepo, allowAllPermissions{}, repository.NewFXRateRepository(), adjustmentRepo)
+	if _, err := matchService.CreateManualMatch(matchSet.ID, []string{"L2", "R2"}, "preparer", "tenant-1"); err != nil {
+		t.Fatalf("CreateManualMatch() error = %v", err)
+	}
+	if _, err := matchService.SetWriteOffLimit(5, "admin", "tenant-1"); err != nil {
+		t.Fatalf("SetWriteOffLimit() error = %v", err)
+	}
+	if _, err := matchService.CreateAdjustedMatch(matchSet.ID, []string{"L3", "R3"}, AdjustmentRequest{Reason: "Bank fee", GLAccount: "6100"}, "approver", "tenant-1"); err != nil {
+		t.Fatalf("CreateAdjustedMatch() error = %v", err)
+	}
+
+	storage, err := NewStorageService(map[string]string{"basePath": t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewStorageService() error = %v", err)
+	}
+	service := NewReportService(repository.NewReportRepository(), matchSetRepo, transactionRepo, matchRepo, adjustmentRepo, progressRepo, allowAllPermissions{}, storage)
+
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC)
+	report, err := service.BuildReport(matchSet.ID, from, to, "auditor", "tenant-1")
+	if err != nil {
+		t.Fatalf("BuildReport() error = %v", err)
+	}
+
+	want := []models.ReportSourceBalance{
+		{DataSourceID: "ledger", Side: "left", Currency: "USD", OpeningBalance: 100, PeriodActivity: 250, ClosingBalance: 350,
+			MatchedCount: 2, MatchedTotal: 250, UnmatchedCount: 1, UnmatchedTotal: 100},
+		{DataSourceID: "bank", Side: "right", Currency: "USD", OpeningBalance: 0, PeriodActivity: 249, ClosingBalance: 249,
+			MatchedCount: 2, MatchedTotal: 249},
+	}
+	if len(report.Sources) != len(want) {
+		t.Fatalf("Sources = %+v, want %d balances", report.Sources, len(want))
+	}
+	for i := range want {
+		got := report.Sources[i]
+		got.DataSourceName = ""
+		if got != want[i] {
+			t.Errorf("Sources[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	if len(report.Unmatched) != 1 || report.Unmatched[0].TransactionID != "L1" || report.Unmatched[0].AgeDays != 35 || report.Unmatched[0].AgeBucket != "31-60" {
+		t.Errorf("Unmatched = %+v, want L1 aged 35 days", report.Unmatched)
+	}
+	if len(report.Adjustments) != 1 || report.Adjustments[0].Amount != 1 || report.Adjustments[0].GLAccount != "6100" {
+		t.Errorf("Adjustments = %+v, want the 1.00 bank fee", report.Adjustments)
+	}
+
+	// The closing difference is the outstanding opening item once the fee is written off
+	wantDifference := models.ReportDifference{Currency: "USD", LeftClosing: 350, RightClosing: 249, Adjustments: 1, Difference: 100}
+	if len(report.Differences) != 1 || report.Differences[0] != wantDifference {
+		t.Errorf("Differences = %+v, want %+v", report.Differences, wantDifference)
+	}
+
+	if _, err := service.BuildReport(matchSet.ID, to, from, "auditor", "tenant-1"); err == nil || !strings.Contains(err.Error(), "invalid") {
+		t.Errorf("BuildReport() with to before from error = %v, want invalid", err)
+	}
+	if _, err := service.BuildReport(matchSet.ID, from, to, "auditor", "tenant-2"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("BuildReport() from another tenant error = %v, want not found", err)
+	}
+}
+
+func TestReportService_StoresRenderedReports(t *testing.T) {
+	matchSetRepo := repository.NewMatchSetRepository()
+	transactionRepo := repository.NewTransactionRepository()
+	progressRepo := repository.NewMatchProgressRepository()
+
+	matchSet := &models.MatchSet{ID: "set-1", Name: "Bank (USD)", TenantID: "tenant-1"}
+	matchSetRepo.CreateMatchSet(matchSet)
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "ledger")
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "bank")
+
+	unmatched := fxTx("L1", 12.5, 3, "USD")
+	unmatched.DataSourceID = "ledger"
+	unmatched.Description = "Wire (ref 42) \\ café"
+	transactionRepo.CreateTransaction(&unmatched)
+
+	runID := "run-1"
+	progressRepo.SaveProgress(matchSet.TenantID, &models.MatchProgress{MatchSetID: matchSet.ID, RunID: runID, Status: "Completed"})
+
+	storage, err := NewStorageService(map[string]string{"basePath": t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewStorageService() error = %v", err)
+	}
+	matchRepo := repository.NewMatchRepository()
+	service := NewReportService(repository.NewReportRepository(), matchSetRepo, transactionRepo, matchRepo,
+		repository.NewAdjustmentRepository(transactionRepo, matchRepo), progressRepo, allowAllPermissions{}, storage)
+
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC)
+
+	contains := map[string]func(content []byte) bool{
+		models.ReportFormatCSV: func(content []byte) bool {
+			return bytes.Contains(content, []byte("Unmatched items\n")) && bytes.Contains(content, []byte("2024-03-03,Mock Data Source ledger,,Wire (ref 42) \\ café,12.50,USD,28,8-30"))
+		},
+		models.ReportFormatXLSX: func(content []byte) bool {
+			archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+			if err != nil {
+				return false
+			}
+			for _, file := range archive.File {
+				if file.Name != "xl/worksheets/sheet4.xml" {
+					continue
+				}
+				sheet, _ := file.Open()
+				data, _ := io.ReadAll(sheet)
+				return bytes.Contains(data, []byte(`<v>12.5</v>`)) && bytes.Contains(data, []byte("Wire (ref 42)"))
+			}
+			return false
+		},
+		models.ReportFormatPDF: func(content []byte) bool {
+			return bytes.HasPrefix(content, []byte("%PDF-1.4")) && bytes.HasSuffix(content, []byte("%%EOF\n")) &&
+				bytes.Contains(content, []byte(`Wire \(ref 42\) \\ caf\351`))
+		},
+	}
+
+	for _, format := range []string{models.ReportFormatCSV, models.ReportFormatXLSX, models.ReportFormatPDF} {
+		stored, err := service.GenerateReport(matchSet.ID, from, to, format, "auditor", "tenant-1")
+		if err != nil {
+			t.Fatalf("GenerateReport(%s) error = %v", format, err)
+		}
+		if stored.RunID != runID || stored.Format != format || stored.GeneratedBy != "auditor" {
+			t.Errorf("GenerateReport(%s) = %+v, want run %s by auditor", format, stored, runID)
+		}
+
+		report, file, err := service.OpenReport(stored.ID, "auditor", "tenant-1")
+		if err != nil {
+			t.Fatalf("OpenReport(%s) error = %v", format, err)
+		}
+		content, _ := io.ReadAll(file)
+		file.Close()
+
+		checksum := sha256.Sum256(content)
+		if report.Checksum != hex.EncodeToString(checksum[:]) || report.FileSize != int64(len(content)) {
+			t.Errorf("OpenReport(%s) content does not match the stored checksum and size", format)
+		}
+		if !contains[format](content) {
+			t.Errorf("%s report does not hold the unmatched item:\n%s", format, content)
+		}
+
+		if _, _, err := service.OpenReport(stored.ID, "auditor", "tenant-2"); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("OpenReport(%s) from another tenant error = %v, want not found", format, err)
+		}
+	}
+
+	reports, err := service.GetReports(matchSet.ID, "auditor", "tenant-1")
+	if err != nil || len(reports) != 3 {
+		t.Errorf("GetReports() = %d reports, %v, want 3", len(reports), err)
+	}
+
+	if _, err := service.GenerateReport(matchSet.ID, from, to, "docx", "auditor", "tenant-1"); err == nil || !strings.Contains(err.Error(), "invalid report") {
+		t.Errorf("GenerateReport(docx) error = %v, want invalid report", err)
+	}
+
+	if _, err := progressRepo.AcquireRunLease(matchSet.TenantID, matchSet.ID, time.Minute); err != nil {
+		t.Fatalf("AcquireRunLease() error = %v", err)
+	}
+	if _, err := service.GenerateReport(matchSet.ID, from, to, models.ReportFormatCSV, "auditor", "tenant-1"); err == nil || !strings.Contains(err.Error(), "in progress") {
+		t.Errorf("GenerateReport() during a run error = %v, want in progress", err)
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"backend/internal/models"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ruleConditions returns the conditions a rule matches by. Rules without explicit conditions
+// are translated from their MatchBy* flags, so older rules keep matching as before.
+func ruleConditions(rule *models.MatchRule) []models.RuleCondition {
+	if len(rule.Conditions) > 0 {
+		return rule.Conditions
+	}
+
+	var conditions []models.RuleCondition
+	if rule.MatchByReference {
+		switch rule.ReferenceMode {
+		case models.ReferenceModeNormalized:
+			conditions = append(conditions, models.RuleCondition{
+				Field:     models.ConditionFieldReference,
+				Operator:  models.ConditionOpEq,
+				Normalize: true,
+			})
+		case models.ReferenceModeSimilarity:
+			conditions = append(conditions, models.RuleCondition{
+				Field:     models.ConditionFieldReference,
+				Operator:  models.ConditionOpSimilarity,
+				Algorithm: rule.SimilarityAlgorithm,
+				Threshold: rule.SimilarityThreshold,
+			})
+		default:
+			conditions = append(conditions, models.RuleCondition{
+				Field:    models.ConditionFieldReference,
+				Operator: models.ConditionOpEq,
+			})
+		}
+	}
+	if rule.MatchByAmount {
+		conditions = append(conditions, models.RuleCondition{
+			Field:     models.ConditionFieldAmount,
+			Operator:  models.ConditionOpWithin,
+			Tolerance: rule.AmountTolerance,
+		})
+	}
+	if rule.MatchByDate {
+		conditions = append(conditions, models.RuleCondition{
+			Field:     models.ConditionFieldTransactionDate,
+			Operator:  models.ConditionOpWithin,
+			Tolerance: float64(rule.DateTolerance),
+		})
+	}
+	return conditions
+}
+
+// validateConditions checks that every condition names a known field and an operator that
+// applies to it. Amounts and dates may each be compared by at most one condition.
+func validateConditions(conditions []models.RuleCondition) error {
+	seen := make(map[string]bool)
+	for i, c := range conditions {
+		switch {
+		case c.Field == models.ConditionFieldAmount || c.Field == models.ConditionFieldTransactionDate:
+			if seen[c.Field] {
+				return fmt.Errorf("invalid condition %d: %s can only be compared once", i+1, c.Field)
+			}
+			seen[c.Field] = true
+			if c.Operator != models.ConditionOpEq && c.Operator != models.ConditionOpWithin {
+				return fmt.Errorf("invalid condition %d: %s supports eq and within only", i+1, c.Field)
+			}
+		case c.Field == models.ConditionFieldReference, c.Field == models.ConditionFieldDescription,
+			c.Field == models.ConditionFieldCurrency, c.Field == models.ConditionFieldExternalID:
+		case strings.HasPrefix(c.Field, models.ConditionFieldCustomPrefix) && len(c.Field) > len(models.ConditionFieldCustomPrefix):
+		default:
+			return fmt.Errorf("invalid condition %d: unknown field %q", i+1, c.Field)
+		}
+
+		switch c.Operator {
+		case models.ConditionOpEq, models.ConditionOpContains:
+		case models.ConditionOpWithin:
+			if c.Tolerance < 0 {
+				return fmt.Errorf("invalid condition %d: tolerance must not be negative", i+1)
+			}
+		case models.ConditionOpRegex:
+			if _, err := regexp.Compile(c.Pattern); err != nil || c.Pattern == "" {
+				return fmt.Errorf("invalid condition %d: a valid pattern is required", i+1)
+			}
+		case models.ConditionOpSimilarity:
+			switch c.Algorithm {
+			case models.SimilarityLevenshtein, models.SimilarityJaroWinkler, models.SimilarityTokenOverlap:
+			default:
+				return fmt.Errorf("invalid condition %d: algorithm must be levenshtein, jaro_winkler or token_overlap", i+1)
+			}
+			if c.Threshold <= 0 || c.Threshold > 1 {
+				return fmt.Errorf("invalid condition %d: threshold must be greater than 0 and at most 1", i+1)
+			}
+		default:
+			return fmt.Errorf("invalid condition %d: unknown operator %q", i+1, c.Operator)
+		}
+	}
+	return nil
+}
+
+// fieldCondition is a condition over a text or custom field, with its pattern compiled
+type fieldCondition struct {
+	models.RuleCondition
+	pattern *regexp.Regexp
+}
+
+// score compares two field values. It returns the score and whether the condition holds;
+// every operator except similarity scores 1 when it holds.
+func (c *fieldCondition) score(a, b string) (float64, bool) {
+	if a == "" || b == "" {
+		return 0, false
+	}
+
+	switch c.Operator {
+	case models.ConditionOpEq:
+		if c.Normalize {
+			a, b = normalizeReference(a), normalizeReference(b)
+		}
+		return 1, a != "" && a == b
+	case models.ConditionOpContains:
+		if c.Normalize {
+			a, b = normalizeReference(a), normalizeReference(b)
+		} else {
+			a, b = strings.ToUpper(a), strings.ToUpper(b)
+		}
+		return 1, a != "" && b != "" && (strings.Contains(a, b) || strings.Contains(b, a))
+	case models.ConditionOpWithin:
+		fa, errA := strconv.ParseFloat(strings.TrimSpace(a), 64)
+		fb, errB := strconv.ParseFloat(strings.TrimSpace(b), 64)
+		return 1, errA == nil && errB == nil && math.Abs(fa-fb) <= c.Tolerance
+	case models.ConditionOpRegex:
+		ta, tb := extractToken(c.pattern, a), extractToken(c.pattern, b)
+		return 1, ta != "" && ta == tb
+	case models.ConditionOpSimilarity:
+		score := similarity(c.Algorithm, a, b)
+		return score, score >= c.Threshold
+	}
+	return 0, false
+}
+
+// similarity scores two references with the named algorithm
+func similarity(algorithm, a, b string) float64 {
+	switch algorithm {
+	case models.SimilarityJaroWinkler:
+		return jaroWinklerSimilarity(normalizeReference(a), normalizeReference(b))
+	case models.SimilarityTokenOverlap:
+		return tokenOverlapSimilarity(a, b)
+	default:
+		return levenshteinSimilarity(normalizeReference(a), normalizeReference(b))
+	}
+}
+
+// extractToken returns the first capture group of pattern in value, or the whole match
+// when the pattern has no groups. It returns "" when the pattern does not match.
+func extractToken(pattern *regexp.Regexp, value string) string {
+	if pattern == nil {
+		return ""
+	}
+	match := pattern.FindStringSubmatch(value)
+	if match == nil {
+		return ""
+	}
+	if len(match) > 1 {
+		return match[1]
+	}
+	return match[0]
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"backend/internal/models"
+	"testing"
+)
+
+func TestRuleConditions_LegacyFlags(t *testing.T) {
+	rule := &models.MatchRule{
+		MatchByAmount:    true,
+		MatchByDate:      true,
+		DateTolerance:    3,
+		MatchByReference: true,
+		ReferenceMode:    models.ReferenceModeNormalized,
+		AmountTolerance:  0.5,
+	}
+
+	got := ruleConditions(rule)
+	want := []models.RuleCondition{
+		{Field: models.ConditionFieldReference, Operator: models.ConditionOpEq, Normalize: true},
+		{Field: models.ConditionFieldAmount, Operator: models.ConditionOpWithin, Tolerance: 0.5},
+		{Field: models.ConditionFieldTransactionDate, Operator: models.ConditionOpWithin, Tolerance: 3},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ruleConditions() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ruleConditions()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// Explicit conditions replace the flags
+	rule.Conditions = []models.RuleCondition{{Field: models.ConditionFieldAmount, Operator: models.ConditionOpEq}}
+	if got := ruleConditions(rule); len(got) != 1 || got[0].Operator != models.ConditionOpEq {
+		t.Errorf("ruleConditions() = %v, want the rule's own conditions", got)
+	}
+}
+
+func TestValidateConditions(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []models.RuleCondition
+		wantErr    bool
+	}{
+		{"valid", []models.RuleCondition{
+			{Field: "amount", Operator: "within", Tolerance: 1},
+			{Field: "custom.invoice_no", Operator: "eq"},
+			{Field: "description", Operator: "regex", Pattern: `INV-(\d+)`},
+			{Field: "reference", Operator: "similarity", Algorithm: "jaro_winkler", Threshold: 0.9},
+		}, false},
+		{"unknown field", []models.RuleCondition{{Field: "memo", Operator: "eq"}}, true},
+		{"empty custom field", []models.RuleCondition{{Field: "custom.", Operator: "eq"}}, true},
+		{"unknown operator", []models.RuleCondition{{Field: "reference", Operator: "like"}}, true},
+		{"text operator on amount", []models.RuleCondition{{Field: "amount", Operator: "contains"}}, true},
+		{"amount twice", []models.RuleCondition{{Field: "amount", Operator: "eq"}, {Field: "amount", Operator: "within"}}, true},
+		{"bad pattern", []models.RuleCondition{{Field: "description", Operator: "regex", Pattern: "("}}, true},
+		{"missing threshold", []models.RuleCondition{{Field: "reference", Operator: "similarity", Algorithm: "levenshtein"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateConditions(tt.conditions); (err != nil) != tt.wantErr {
+				t.Errorf("validateConditions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchingEngine_Conditions(t *testing.T) {
+	rule := &models.MatchRule{
+		MatchCardinality: models.MatchCardinalityOneToOne,
+		Conditions: []models.RuleCondition{
+			{Field: models.ConditionFieldAmount, Operator: models.ConditionOpEq},
+			{Field: "custom.store", Operator: models.ConditionOpEq},
+			{Field: models.ConditionFieldDescription, Operator: models.ConditionOpContains},
+		},
+	}
+
+	withFields := func(t models.Transaction, store, description string) models.Transaction {
+		t.CustomFields = map[string]string{"store": store}
+		t.Description = description
+		return t
+	}
+
+	left := []models.Transaction{withFields(tx("L1", 100, 1, ""), "042", "ACME")}
+	right := []models.Transaction{
+		withFields(tx("R1", 100, 9, ""), "041", "ACME CORP"),    // Wrong store
+		withFields(tx("R2", 100.01, 9, ""), "042", "ACME CORP"), // Amount must be exact
+		withFields(tx("R3", 100, 20, ""), "042", "acme corp"),   // Dates are not compared by this rule
+	}
+
+	proposals, _, _ := NewMatchingEngine(rule, nil).Match(left, right)
+	if len(proposals) != 1 || proposals[0].Right[0].ID != "R3" {
+		t.Errorf("Match() = %v, want L1 matched to R3", proposals)
+	}
+}
@@ -3,28 +3,58 @@ package services
 import (
 	"backend/internal/models"
 	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"regexp"
 )
 
-// RuleService provides methods for managing match rules
+// RuleService provides methods for managing the match rules of a tenant
 type RuleService struct {
-	ruleRepo repository.RuleRepository
+	ruleRepo       repository.RuleRepository
+	permissionRepo repository.PermissionRepository
 }
 
 // NewRuleService creates a new rule service
-func NewRuleService(ruleRepo repository.RuleRepository) *RuleService {
+func NewRuleService(ruleRepo repository.RuleRepository, permissionRepo repository.PermissionRepository) *RuleService {
 	return &RuleService{
-		ruleRepo: ruleRepo,
+		ruleRepo:       ruleRepo,
+		permissionRepo: permissionRepo,
 	}
 }
 
-// CreateRule creates a new match rule
+// checkPermission fails unless a user holds a rule permission in a tenant
+func (s *RuleService) checkPermission(tenantID, userID string, permission models.Permission, action string) error {
+	hasPermission, err := s.permissionRepo.HasPermission(userID, permission, tenantID)
+	if err != nil {
+		return err
+	}
+	if !hasPermission {
+		return errors.New("unauthorized: requires " + action + " rule permission")
+	}
+	return nil
+}
+
+// updatableRule retrieves a rule of a tenant for a user allowed to update it
+func (s *RuleService) updatableRule(tenantID, userID, id string) (*models.MatchRule, error) {
+	if err := s.checkPermission(tenantID, userID, models.PermUpdateRule, "update"); err != nil {
+		return nil, err
+	}
+	return s.ruleRepo.GetRuleByID(tenantID, id)
+}
+
+// CreateRule creates a new match rule in a tenant
 func (s *RuleService) CreateRule(
-	name, description string,
+	tenantID, name, description string,
 	matchByAmount, matchByDate, matchByReference bool,
 	dateTolerance int,
 	createdBy string,
 ) (*models.MatchRule, error) {
+	if err := s.checkPermission(tenantID, createdBy, models.PermCreateRule, "create"); err != nil {
+		return nil, err
+	}
+
 	rule := &models.MatchRule{
+		TenantID:         tenantID,
 		Name:             name,
 		Description:      description,
 		MatchByAmount:    matchByAmount,
@@ -42,23 +72,29 @@ func (s *RuleService) CreateRule(
 	return rule, nil
 }
 
-// GetRuleByID retrieves a match rule by ID
-func (s *RuleService) GetRuleByID(id string) (*models.MatchRule, error) {
-	return s.ruleRepo.GetRuleByID(id)
+// GetRuleByID retrieves a match rule of a tenant by ID
+func (s *RuleService) GetRuleByID(tenantID, userID, id string) (*models.MatchRule, error) {
+	if err := s.checkPermission(tenantID, userID, models.PermViewRule, "view"); err != nil {
+		return nil, err
+	}
+	return s.ruleRepo.GetRuleByID(tenantID, id)
 }
 
-// GetRuleByName retrieves a match rule by name
-func (s *RuleService) GetRuleByName(name string) (*models.MatchRule, error) {
-	return s.ruleRepo.GetRuleByName(name)
+// GetRuleByName retrieves a match rule of a tenant by name
+func (s *RuleService) GetRuleByName(tenantID, userID, name string) (*models.MatchRule, error) {
+	if err := s.checkPermission(tenantID, userID, models.PermViewRule, "view"); err != nil {
+		return nil, err
+	}
+	return s.ruleRepo.GetRuleByName(tenantID, name)
 }
 
-// UpdateRule updates a match rule
+// UpdateRule updates a match rule of a tenant
 func (s *RuleService) UpdateRule(
-	id, name, description string,
+	tenantID, userID, id, name, description string,
 	matchByAmount, matchByDate, matchByReference, active bool,
 	dateTolerance int,
 ) (*models.MatchRule, error) {
-	rule, err := s.ruleRepo.GetRuleByID(id)
+	rule, err := s.updatableRule(tenantID, userID, id)
 	if err != nil {
 		return nil, err
 	}
@@ -78,24 +114,170 @@ func (s *RuleService) UpdateRule(
 	return rule, nil
 }
 
-// DeleteRule deletes a match rule
-func (s *RuleService) DeleteRule(id string) error {
-	return s.ruleRepo.DeleteRule(id)
+// UpdateGroupMatching sets how a rule groups transactions and the caps that bound the group search
+func (s *RuleService) UpdateGroupMatching(
+	tenantID, userID, id, cardinality string,
+	amountTolerance float64,
+	maxGroupSize, maxCandidates, maxSearchIterations int,
+) (*models.MatchRule, error) {
+	switch cardinality {
+	case models.MatchCardinalityOneToOne, models.MatchCardinalityOneToMany, models.MatchCardinalityManyToMany:
+	default:
+		return nil, errors.New("invalid match cardinality: must be 1:1, 1:N or N:M")
+	}
+
+	if amountTolerance < 0 {
+		return nil, errors.New("invalid amount tolerance: must not be negative")
+	}
+
+	if maxGroupSize < 2 || maxCandidates < 1 || maxSearchIterations < 1 {
+		return nil, errors.New("invalid search caps: group size must be at least 2 and limits must be positive")
+	}
+
+	rule, err := s.updatableRule(tenantID, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.MatchCardinality = cardinality
+	rule.AmountTolerance = amountTolerance
+	rule.MaxGroupSize = maxGroupSize
+	rule.MaxCandidates = maxCandidates
+	rule.MaxSearchIterations = maxSearchIterations
+
+	if err := s.ruleRepo.UpdateRule(rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
 }
 
-// GetAllRules retrieves all match rules
-func (s *RuleService) GetAllRules() ([]models.MatchRule, error) {
-	return s.ruleRepo.GetAllRules()
+// UpdateAmountTolerance sets the absolute and percentage amount tolerances of a rule and
+// whether amounts in different currencies are compared through the FX rate table
+func (s *RuleService) UpdateAmountTolerance(
+	tenantID, userID, id string,
+	amountTolerance, amountTolerancePct float64,
+	compareAcrossCurrencies bool,
+	fxTolerancePct float64,
+) (*models.MatchRule, error) {
+	if amountTolerance < 0 {
+		return nil, errors.New("invalid amount tolerance: must not be negative")
+	}
+
+	if amountTolerancePct < 0 || amountTolerancePct > 100 || fxTolerancePct < 0 || fxTolerancePct > 100 {
+		return nil, errors.New("invalid tolerance percentage: must be between 0 and 100")
+	}
+
+	rule, err := s.updatableRule(tenantID, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.AmountTolerance = amountTolerance
+	rule.AmountTolerancePct = amountTolerancePct
+	rule.CompareAcrossCurrencies = compareAcrossCurrencies
+	rule.FXTolerancePct = fxTolerancePct
+
+	if err := s.ruleRepo.UpdateRule(rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// UpdateReferenceMatching sets how a rule compares references: the comparison mode, an optional
+// pattern extracting the reference from the description, and the similarity algorithm and threshold
+func (s *RuleService) UpdateReferenceMatching(
+	tenantID, userID, id, mode, pattern, algorithm string,
+	threshold float64,
+) (*models.MatchRule, error) {
+	switch mode {
+	case models.ReferenceModeExact, models.ReferenceModeNormalized, models.ReferenceModeSimilarity:
+	default:
+		return nil, errors.New("invalid reference mode: must be exact, normalized or similarity")
+	}
+
+	switch algorithm {
+	case models.SimilarityLevenshtein, models.SimilarityJaroWinkler, models.SimilarityTokenOverlap:
+	default:
+		return nil, errors.New("invalid similarity algorithm: must be levenshtein, jaro_winkler or token_overlap")
+	}
+
+	if threshold <= 0 || threshold > 1 {
+		return nil, errors.New("invalid similarity threshold: must be greater than 0 and at most 1")
+	}
+
+	if pattern != "" {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid reference pattern: %v", err)
+		}
+	}
+
+	rule, err := s.updatableRule(tenantID, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.ReferenceMode = mode
+	rule.ReferencePattern = pattern
+	rule.SimilarityAlgorithm = algorithm
+	rule.SimilarityThreshold = threshold
+
+	if err := s.ruleRepo.UpdateRule(rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// UpdateConditions replaces the conditions of a rule. An empty list makes the rule
+// match by its MatchBy* flags again.
+func (s *RuleService) UpdateConditions(tenantID, userID, id string, conditions []models.RuleCondition) (*models.MatchRule, error) {
+	if err := validateConditions(conditions); err != nil {
+		return nil, err
+	}
+
+	rule, err := s.updatableRule(tenantID, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.Conditions = conditions
+
+	if err := s.ruleRepo.UpdateRule(rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// DeleteRule deletes a match rule of a tenant
+func (s *RuleService) DeleteRule(tenantID, userID, id string) error {
+	if err := s.checkPermission(tenantID, userID, models.PermDeleteRule, "delete"); err != nil {
+		return err
+	}
+	return s.ruleRepo.DeleteRule(tenantID, id)
 }
 
-// GetActiveRules retrieves all active match rules
-func (s *RuleService) GetActiveRules() ([]models.MatchRule, error) {
-	return s.ruleRepo.GetActiveRules()
+// GetAllRules retrieves all match rules of a tenant
+func (s *RuleService) GetAllRules(tenantID, userID string) ([]models.MatchRule, error) {
+	if err := s.checkPermission(tenantID, userID, models.PermViewRule, "view"); err != nil {
+		return nil, err
+	}
+	return s.ruleRepo.GetAllRules(tenantID)
+}
+
+// GetActiveRules retrieves all active match rules of a tenant
+func (s *RuleService) GetActiveRules(tenantID, userID string) ([]models.MatchRule, error) {
+	if err := s.checkPermission(tenantID, userID, models.PermViewRule, "view"); err != nil {
+		return nil, err
+	}
+	return s.ruleRepo.GetActiveRules(tenantID)
 }
 
-// ToggleRuleActive toggles the active status of a rule
-func (s *RuleService) ToggleRuleActive(id string, active bool) (*models.MatchRule, error) {
-	rule, err := s.ruleRepo.GetRuleByID(id)
+// ToggleRuleActive toggles the active status of a rule of a tenant
+func (s *RuleService) ToggleRuleActive(tenantID, userID, id string, active bool) (*models.MatchRule, error) {
+	rule, err := s.updatableRule(tenantID, userID, id)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"strings"
+	"testing"
+)
+
+func TestRuleService_UpdatesMatchingSettings(t *testing.T) {
+	service := NewRuleService(repository.NewRuleRepository(), allowAllPermissions{})
+
+	rule, err := service.CreateRule("tenant-1", "Amount", "", true, true, false, 2, "user-1")
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+
+	if _, err := service.UpdateGroupMatching("tenant-1", "user-1", rule.ID, models.MatchCardinalityOneToMany, 0.5, 4, 50, 1000); err != nil {
+		t.Fatalf("UpdateGroupMatching() error = %v", err)
+	}
+	if _, err := service.UpdateAmountTolerance("tenant-1", "user-1", rule.ID, 0.5, 1, true, 2); err != nil {
+		t.Fatalf("UpdateAmountTolerance() error = %v", err)
+	}
+	conditions := []models.RuleCondition{{Field: models.ConditionFieldAmount, Operator: models.ConditionOpWithin, Tolerance: 1}}
+	if _, err := service.UpdateConditions("tenant-1", "user-1", rule.ID, conditions); err != nil {
+		t.Fatalf("UpdateConditions() error = %v", err)
+	}
+
+	got, err := service.GetRuleByID("tenant-1", "user-1", rule.ID)
+	if err != nil {
+		t.Fatalf("GetRuleByID() error = %v", err)
+	}
+	if got.MatchCardinality != models.MatchCardinalityOneToMany || got.MaxGroupSize != 4 || !got.CompareAcrossCurrencies || len(got.Conditions) != 1 {
+		t.Errorf("GetRuleByID() = %+v, want the updated settings", got)
+	}
+
+	if _, err := service.UpdateGroupMatching("tenant-2", "user-1", rule.ID, models.MatchCardinalityOneToOne, 0, 2, 1, 1); err != repository.ErrRuleNotFound {
+		t.Errorf("UpdateGroupMatching() in another tenant error = %v, want %v", err, repository.ErrRuleNotFound)
+	}
+}
+
+func TestRuleService_RequiresPermissions(t *testing.T) {
+	ruleRepo := repository.NewRuleRepository()
+	rule := &models.MatchRule{ID: "rule-1", TenantID: "tenant-1", Name: "Amount", Active: true}
+	ruleRepo.CreateRule(rule)
+
+	viewer := NewRuleService(ruleRepo, denyPermission{denied: models.PermUpdateRule})
+	if _, err := viewer.GetRuleByID("tenant-1", "user-1", rule.ID); err != nil {
+		t.Errorf("GetRuleByID() error = %v", err)
+	}
+	if _, err := viewer.UpdateConditions("tenant-1", "user-1", rule.ID, nil); err == nil || !strings.HasPrefix(err.Error(), "unauthorized") {
+		t.Errorf("UpdateConditions() without update permission error = %v, want unauthorized", err)
+	}
+
+	outsider := NewRuleService(ruleRepo, denyPermission{denied: models.PermViewRule})
+	if _, err := outsider.GetAllRules("tenant-1", "user-1"); err == nil || !strings.HasPrefix(err.Error(), "unauthorized") {
+		t.Errorf("GetAllRules() without view permission error = %v, want unauthorized", err)
+	}
+}
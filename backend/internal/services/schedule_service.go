@@ -0,0 +1,352 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+// scheduleTickInterval is how often the scheduler looks for due schedules, and so how late a
+// scheduled run may start
+const scheduleTickInterval = 30 * time.Second
+
+// scheduleBatchSize is the most due schedules the scheduler starts in one tick
+const scheduleBatchSize = 100
+
+// ScheduleService provides methods for managing match set schedules and starts their runs when
+// they are due
+type ScheduleService struct {
+	scheduleRepo   repository.ScheduleRepository
+	matchSetRepo   repository.MatchSetRepository
+	progressRepo   repository.MatchProgressRepository
+	permissionRepo repository.PermissionRepository
+	queueService   *QueueService
+}
+
+// NewScheduleService creates a new schedule service
+func NewScheduleService(
+	scheduleRepo repository.ScheduleRepository,
+	matchSetRepo repository.MatchSetRepository,
+	progressRepo repository.MatchProgressRepository,
+	permissionRepo repository.PermissionRepository,
+	queueService *QueueService,
+) *ScheduleService {
+	return &ScheduleService{
+		scheduleRepo:   scheduleRepo,
+		matchSetRepo:   matchSetRepo,
+		progressRepo:   progressRepo,
+		permissionRepo: permissionRepo,
+		queueService:   queueService,
+	}
+}
+
+// GetSchedules retrieves the schedules of a match set
+func (s *ScheduleService) GetSchedules(matchSetID, userID, tenantID string) ([]models.MatchSetSchedule, error) {
+	// Check if user has permission to view match sets
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermViewMatchSet, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission {
+		return nil, errors.New("unauthorized: requires view match set permission")
+	}
+
+	if _, err := s.tenantMatchSet(matchSetID, tenantID); err != nil {
+		return nil, err
+	}
+
+	return s.scheduleRepo.GetSchedulesByMatchSet(tenantID, matchSetID)
+}
+
+// CreateSchedule adds a schedule to a match set. Its runs act as the user creating it.
+func (s *ScheduleService) CreateSchedule(matchSetID string, schedule *models.MatchSetSchedule, userID, tenantID string) (*models.MatchSetSchedule, error) {
+	if err := s.authorize(userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	matchSet, err := s.tenantMatchSet(matchSetID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateSchedule(schedule, time.Now()); err != nil {
+		return nil, err
+	}
+
+	schedule.ID = ""
+	schedule.MatchSetID = matchSet.ID
+	schedule.TenantID = matchSet.TenantID
+	schedule.CreatedBy = userID
+	schedule.LastRunAt = nil
+	if err := s.scheduleRepo.CreateSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// UpdateSchedule replaces the timing and mode of a schedule. The next run is worked out afresh.
+func (s *ScheduleService) UpdateSchedule(matchSetID, scheduleID string, schedule *models.MatchSetSchedule, userID, tenantID string) (*models.MatchSetSchedule, error) {
+	if err := s.authorize(userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.matchSetSchedule(matchSetID, scheduleID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateSchedule(schedule, time.Now()); err != nil {
+		return nil, err
+	}
+
+	schedule.ID = existing.ID
+	schedule.MatchSetID = existing.MatchSetID
+	schedule.TenantID = existing.TenantID
+	schedule.CreatedBy = existing.CreatedBy
+	schedule.CreatedAt = existing.CreatedAt
+	if err := s.scheduleRepo.UpdateSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// DeleteSchedule removes a schedule from a match set. A run it already started goes on.
+func (s *ScheduleService) DeleteSchedule(matchSetID, scheduleID, userID, tenantID string) error {
+	if err := s.authorize(userID, tenantID); err != nil {
+		return err
+	}
+
+	if _, err := s.matchSetSchedule(matchSetID, scheduleID, tenantID); err != nil {
+		return err
+	}
+
+	return s.scheduleRepo.DeleteSchedule(tenantID, scheduleID)
+}
+
+// GetScheduledRuns retrieves the latest runs of a schedule and their outcomes, newest first
+func (s *ScheduleService) GetScheduledRuns(matchSetID, scheduleID string, limit int, userID, tenantID string) ([]models.ScheduledRun, error) {
+	// Check if user has permission to view match sets
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermViewMatchSet, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission {
+		return nil, errors.New("unauthorized: requires view match set permission")
+	}
+
+	if _, err := s.matchSetSchedule(matchSetID, scheduleID, tenantID); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+
+	return s.scheduleRepo.GetScheduledRuns(scheduleID, limit)
+}
+
+// StartScheduler starts looking for due schedules in the background. Every instance may run a
+// scheduler; each due run is claimed by one of them.
+func (s *ScheduleService) StartScheduler() {
+	go func() {
+		ticker := time.NewTicker(scheduleTickInterval)
+		defer ticker.Stop()
+
+		for now := range ticker.C {
+			if err := s.RunDueSchedules(now); err != nil {
+				log.Printf("Failed to run due schedules: %v", err)
+			}
+		}
+	}()
+
+	log.Println("Match set scheduler started")
+}
+
+// RunDueSchedules starts the runs of the schedules due at now. Runs missed while no scheduler
+// was running are not made up: a schedule due several times starts one run and moves on to its
+// next time after now.
+func (s *ScheduleService) RunDueSchedules(now time.Time) error {
+	schedules, err := s.scheduleRepo.GetDueSchedules(now, scheduleBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range schedules {
+		if err := s.runSchedule(&schedules[i], now); err != nil {
+			log.Printf("Failed to start scheduled run of match set %s: %v", schedules[i].MatchSetID, err)
+		}
+	}
+
+	return nil
+}
+
+// runSchedule claims the due run of a schedule and enqueues it, unless the previous run of the
+// match set is still going
+func (s *ScheduleService) runSchedule(schedule *models.MatchSetSchedule, now time.Time) error {
+	cron, err := parseSchedule(schedule)
+	if err != nil {
+		return err
+	}
+
+	due := *schedule.NextRunAt
+	next := cron.Next(now)
+	if next.IsZero() {
+		return errors.New("schedule " + schedule.ID + " never runs again")
+	}
+
+	claimed, err := s.scheduleRepo.ClaimScheduledRun(schedule.TenantID, schedule.ID, due, next.UTC())
+	if err != nil || !claimed {
+		// Unclaimed runs were started by another instance
+		return err
+	}
+
+	run := &models.ScheduledRun{
+		ScheduleID:   schedule.ID,
+		MatchSetID:   schedule.MatchSetID,
+		ScheduledFor: due,
+		Status:       models.ScheduledRunEnqueued,
+	}
+
+	// Queueing a run behind one still going would only fail on the run's lease later
+	progress, err := s.progressRepo.GetProgress(schedule.TenantID, schedule.MatchSetID)
+	if err != nil && err != repository.ErrMatchProgressNotFound {
+		return err
+	}
+	if err == nil && progress.Status == "Running" && progress.LeaseExpiresAt != nil && progress.LeaseExpiresAt.After(now) {
+		run.Status = models.ScheduledRunSkipped
+		run.RunID = progress.RunID
+		run.Message = "the previous run of the match set was still in progress"
+		run.FinishedAt = &now
+		return s.scheduleRepo.CreateScheduledRun(run)
+	}
+
+	if err := s.scheduleRepo.CreateScheduledRun(run); err != nil {
+		return err
+	}
+
+	if err := s.queueService.SendRunMatchSetMessage(schedule.MatchSetID, schedule.TenantID, schedule.CreatedBy, schedule.Mode, run.ID); err != nil {
+		run.Status = models.ScheduledRunFailed
+		run.Message = "failed to enqueue the run: " + err.Error()
+		return s.scheduleRepo.FinishScheduledRun(run)
+	}
+
+	return nil
+}
+
+// scheduledRunOutcome describes how a scheduled run went from the outcome of RunMatchSet
+func scheduledRunOutcome(progress *models.MatchProgress, runErr error) *models.ScheduledRun {
+	var inProgress *repository.RunInProgressError
+	switch {
+	case errors.As(runErr, &inProgress):
+		return &models.ScheduledRun{
+			Status:  models.ScheduledRunSkipped,
+			RunID:   inProgress.RunID,
+			Message: "the previous run of the match set was still in progress",
+		}
+	case runErr != nil:
+		return &models.ScheduledRun{Status: models.ScheduledRunFailed, Message: runErr.Error()}
+	}
+
+	run := &models.ScheduledRun{RunID: progress.RunID, Status: models.ScheduledRunFailed, Message: progress.Error}
+	switch progress.Status {
+	case "Completed":
+		run.Status = models.ScheduledRunCompleted
+	case "Cancelled":
+		run.Status = models.ScheduledRunCancelled
+	}
+	return run
+}
+
+// authorize checks that a user may manage schedules. Scheduled runs act as the user who created
+// the schedule, so schedules are managed by users who may run the match set.
+func (s *ScheduleService) authorize(userID, tenantID string) error {
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermMatchTransactions, tenantID)
+	if err != nil {
+		return err
+	}
+	if !hasPermission {
+		return errors.New("unauthorized: requires match transactions permission")
+	}
+	return nil
+}
+
+// tenantMatchSet returns a match set when it belongs to the tenant
+func (s *ScheduleService) tenantMatchSet(matchSetID, tenantID string) (*models.MatchSet, error) {
+	matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
+	if err != nil {
+		return nil, err
+	}
+	if matchSet.TenantID != tenantID {
+		return nil, errors.New("match set not found in this tenant")
+	}
+	return matchSet, nil
+}
+
+// matchSetSchedule returns a schedule when it belongs to the match set and the tenant
+func (s *ScheduleService) matchSetSchedule(matchSetID, scheduleID, tenantID string) (*models.MatchSetSchedule, error) {
+	if _, err := s.tenantMatchSet(matchSetID, tenantID); err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.scheduleRepo.GetScheduleByID(tenantID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.MatchSetID != matchSetID {
+		return nil, errors.New("schedule not found in this match set")
+	}
+	return schedule, nil
+}
+
+// parseSchedule parses the cron expression of a schedule in its time zone
+func parseSchedule(schedule *models.MatchSetSchedule) (*CronSchedule, error) {
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return nil, errors.New("invalid schedule: unknown timezone " + schedule.Timezone)
+	}
+	return ParseCronSchedule(schedule.CronExpression, location)
+}
+
+// validateSchedule checks a schedule, applies its defaults and works out its next run after now
+func validateSchedule(schedule *models.MatchSetSchedule, now time.Time) error {
+	schedule.CronExpression = strings.Join(strings.Fields(schedule.CronExpression), " ")
+	if schedule.CronExpression == "" {
+		return errors.New("invalid schedule: cron_expression is required")
+	}
+
+	schedule.Timezone = strings.TrimSpace(schedule.Timezone)
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC"
+	}
+
+	switch schedule.Mode {
+	case "":
+		schedule.Mode = models.RunModeIncremental
+	case models.RunModeFull, models.RunModeIncremental:
+	default:
+		return errors.New("invalid schedule: mode must be full or incremental")
+	}
+
+	cron, err := parseSchedule(schedule)
+	if err != nil {
+		return err
+	}
+
+	next := cron.Next(now)
+	if next.IsZero() {
+		return errors.New("invalid schedule: the cron expression never matches")
+	}
+
+	schedule.NextRunAt = nil
+	if schedule.Active {
+		next = next.UTC()
+		schedule.NextRunAt = &next
+	}
+
+	return nil
+}
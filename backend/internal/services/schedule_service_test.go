@@ -0,0 +1,108 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduleService_RunDueSchedules(t *testing.T) {
+	matchSetRepo := repository.NewMatchSetRepository()
+	ruleRepo := repository.NewRuleRepository()
+	progressRepo := repository.NewMatchProgressRepository()
+	scheduleRepo := repository.NewScheduleRepository()
+
+	rule := &models.MatchRule{ID: "rule-amount", TenantID: "tenant-1", Name: "Amount", Active: true, Conditions: []models.RuleCondition{
+		{Field: models.ConditionFieldAmount, Operator: models.ConditionOpEq},
+	}}
+	ruleRepo.CreateRule(rule)
+
+	matchSet := &models.MatchSet{ID: "set-1", Name: "Bank", TenantID: "tenant-1", RuleID: rule.ID}
+	matchSetRepo.CreateMatchSet(matchSet)
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "ledger")
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "bank")
+
+	matchSetService := NewMatchSetService(
+		matchSetRepo,
+		ruleRepo,
+		repository.NewDataSourceRepository(),
+		repository.NewTransactionRepository(),
+		allowAllPermissions{},
+		repository.NewMatchRepository(),
+		repository.NewUnmatchedTransactionRepository(),
+		progressRepo,
+		repository.NewFXRateRepository(),
+		repository.NewApprovalPolicyRepository(),
+	)
+	queueService := NewQueueService(nil, nil, matchSetService, nil, nil, scheduleRepo, nil)
+	service := NewScheduleService(scheduleRepo, matchSetRepo, progressRepo, allowAllPermissions{}, queueService)
+
+	if _, err := service.CreateSchedule(matchSet.ID, &models.MatchSetSchedule{CronExpression: "0 6 * * *", Timezone: "Mars/Olympus", Active: true}, "user-1", "tenant-1"); err == nil || !strings.Contains(err.Error(), "invalid") {
+		t.Errorf("CreateSchedule() with an unknown timezone error = %v, want an invalid schedule error", err)
+	}
+
+	schedule, err := service.CreateSchedule(matchSet.ID, &models.MatchSetSchedule{CronExpression: "*/5  * * * *", Active: true}, "user-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("CreateSchedule() error = %v", err)
+	}
+	if schedule.Timezone != "UTC" || schedule.Mode != models.RunModeIncremental || schedule.NextRunAt == nil || schedule.NextRunAt.Minute()%5 != 0 {
+		t.Fatalf("CreateSchedule() = %+v, want UTC, incremental, next run on a multiple of 5 minutes", schedule)
+	}
+
+	// Nothing is due before the next run
+	if err := service.RunDueSchedules(schedule.NextRunAt.Add(-time.Second)); err != nil {
+		t.Fatalf("RunDueSchedules() error = %v", err)
+	}
+	if runs, _ := scheduleRepo.GetScheduledRuns(schedule.ID, 10); len(runs) != 0 {
+		t.Fatalf("RunDueSchedules() before the next run recorded %d runs, want 0", len(runs))
+	}
+
+	// A due run is enqueued as the schedule's creator and its outcome recorded. Seeing it due
+	// again, as another instance would, starts nothing.
+	due := *schedule.NextRunAt
+	service.RunDueSchedules(due.Add(time.Second))
+	service.RunDueSchedules(due.Add(time.Second))
+
+	runs, _ := scheduleRepo.GetScheduledRuns(schedule.ID, 10)
+	if len(runs) != 1 || runs[0].Status != models.ScheduledRunCompleted || !runs[0].ScheduledFor.Equal(due) || runs[0].RunID == "" {
+		t.Fatalf("scheduled runs = %+v, want one completed run for %v", runs, due)
+	}
+	schedule, _ = scheduleRepo.GetScheduleByID(schedule.TenantID, schedule.ID)
+	if !schedule.NextRunAt.Equal(due.Add(5*time.Minute)) || !schedule.LastRunAt.Equal(due) {
+		t.Errorf("schedule next run %v, last run %v, want %v and %v", schedule.NextRunAt, schedule.LastRunAt, due.Add(5*time.Minute), due)
+	}
+
+	// A run still going skips the next scheduled run
+	running, _ := progressRepo.AcquireRunLease(matchSet.TenantID, matchSet.ID, time.Hour)
+	service.RunDueSchedules(schedule.NextRunAt.Add(time.Second))
+
+	runs, _ = scheduleRepo.GetScheduledRuns(schedule.ID, 10)
+	if len(runs) != 2 || runs[0].Status != models.ScheduledRunSkipped || runs[0].RunID != running.RunID {
+		t.Errorf("latest scheduled run = %+v, want skipped for run %s", runs[0], running.RunID)
+	}
+}
+
+func TestScheduledRunOutcome(t *testing.T) {
+	tests := []struct {
+		name       string
+		progress   *models.MatchProgress
+		err        error
+		wantStatus string
+	}{
+		{"completed", &models.MatchProgress{RunID: "run-1", Status: "Completed"}, nil, models.ScheduledRunCompleted},
+		{"cancelled", &models.MatchProgress{RunID: "run-1", Status: "Cancelled"}, nil, models.ScheduledRunCancelled},
+		{"failed run", &models.MatchProgress{RunID: "run-1", Status: "Failed", Error: "boom"}, nil, models.ScheduledRunFailed},
+		{"run in progress", nil, &repository.RunInProgressError{RunID: "run-2"}, models.ScheduledRunSkipped},
+		{"not started", nil, repository.ErrMatchSetNotFound, models.ScheduledRunFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scheduledRunOutcome(tt.progress, tt.err); got.Status != tt.wantStatus {
+				t.Errorf("scheduledRunOutcome() status = %s, want %s", got.Status, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -57,7 +57,7 @@ func (s *SchemaService) GetSchemaByID(id, userID, tenantID string) (*models.Data
 	}
 
 	// Get the schema
-	schema, err := s.schemaRepo.GetSchemaByID(id)
+	schema, err := s.schemaRepo.GetSchemaByID(tenantID, id)
 	if err != nil {
 		return nil, err
 	}
@@ -97,7 +97,7 @@ func (s *SchemaService) UpdateSchema(schema *models.DataSourceSchema, userID str
 	}
 
 	// Get the existing schema
-	existingSchema, err := s.schemaRepo.GetSchemaByID(schema.ID)
+	existingSchema, err := s.schemaRepo.GetSchemaByID(schema.TenantID, schema.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -113,7 +113,7 @@ func (s *SchemaService) UpdateSchema(schema *models.DataSourceSchema, userID str
 	}
 
 	// Re-fetch the updated schema with fields
-	return s.schemaRepo.GetSchemaByID(schema.ID)
+	return s.schemaRepo.GetSchemaByID(schema.TenantID, schema.ID)
 }
 
 // DeleteSchema deletes a schema
@@ -128,7 +128,7 @@ func (s *SchemaService) DeleteSchema(id, userID, tenantID string) error {
 	}
 
 	// Get the schema
-	schema, err := s.schemaRepo.GetSchemaByID(id)
+	schema, err := s.schemaRepo.GetSchemaByID(tenantID, id)
 	if err != nil {
 		return err
 	}
@@ -139,7 +139,7 @@ func (s *SchemaService) DeleteSchema(id, userID, tenantID string) error {
 	}
 
 	// Delete the schema
-	return s.schemaRepo.DeleteSchema(id)
+	return s.schemaRepo.DeleteSchema(tenantID, id)
 }
 
 // AddFieldToSchema adds a field to a schema
@@ -154,7 +154,7 @@ func (s *SchemaService) AddFieldToSchema(field *models.SchemaField, userID, tena
 	}
 
 	// Get the schema
-	schema, err := s.schemaRepo.GetSchemaByID(field.SchemaID)
+	schema, err := s.schemaRepo.GetSchemaByID(tenantID, field.SchemaID)
 	if err != nil {
 		return nil, err
 	}
@@ -184,7 +184,7 @@ func (s *SchemaService) UpdateSchemaField(field *models.SchemaField, userID, ten
 	}
 
 	// Get the schema
-	schema, err := s.schemaRepo.GetSchemaByID(field.SchemaID)
+	schema, err := s.schemaRepo.GetSchemaByID(tenantID, field.SchemaID)
 	if err != nil {
 		return nil, err
 	}
@@ -214,7 +214,7 @@ func (s *SchemaService) DeleteSchemaField(fieldID, schemaID, userID, tenantID st
 	}
 
 	// Get the schema
-	schema, err := s.schemaRepo.GetSchemaByID(schemaID)
+	schema, err := s.schemaRepo.GetSchemaByID(tenantID, schemaID)
 	if err != nil {
 		return err
 	}
@@ -240,7 +240,7 @@ func (s *SchemaService) CreateSchemaMapping(mapping *models.SchemaMapping, userI
 	}
 
 	// Get the schema
-	schema, err := s.schemaRepo.GetSchemaByID(mapping.SchemaID)
+	schema, err := s.schemaRepo.GetSchemaByID(tenantID, mapping.SchemaID)
 	if err != nil {
 		return nil, err
 	}
@@ -270,7 +270,7 @@ func (s *SchemaService) GetSchemaMappings(schemaID, userID, tenantID string) ([]
 	}
 
 	// Get the schema
-	schema, err := s.schemaRepo.GetSchemaByID(schemaID)
+	schema, err := s.schemaRepo.GetSchemaByID(tenantID, schemaID)
 	if err != nil {
 		return nil, err
 	}
@@ -296,7 +296,7 @@ func (s *SchemaService) UpdateSchemaMapping(mapping *models.SchemaMapping, userI
 	}
 
 	// Get the schema
-	schema, err := s.schemaRepo.GetSchemaByID(mapping.SchemaID)
+	schema, err := s.schemaRepo.GetSchemaByID(tenantID, mapping.SchemaID)
 	if err != nil {
 		return nil, err
 	}
@@ -326,7 +326,7 @@ func (s *SchemaService) DeleteSchemaMapping(mappingID, schemaID, userID, tenantI
 	}
 
 	// Get the schema
-	schema, err := s.schemaRepo.GetSchemaByID(schemaID)
+	schema, err := s.schemaRepo.GetSchemaByID(tenantID, schemaID)
 	if err != nil {
 		return err
 	}
@@ -352,7 +352,7 @@ func (s *SchemaService) CreateFileParsingConfig(config *models.FileParsingConfig
 	}
 
 	// Get the schema
-	schema, err := s.schemaRepo.GetSchemaByID(config.SchemaID)
+	schema, err := s.schemaRepo.GetSchemaByID(tenantID, config.SchemaID)
 	if err != nil {
 		return nil, err
 	}
@@ -382,7 +382,7 @@ func (s *SchemaService) GetFileParsingConfig(schemaID, fileType, userID, tenantI
 	}
 
 	// Get the schema
-	schema, err := s.schemaRepo.GetSchemaByID(schemaID)
+	schema, err := s.schemaRepo.GetSchemaByID(tenantID, schemaID)
 	if err != nil {
 		return nil, err
 	}
@@ -408,7 +408,7 @@ func (s *SchemaService) UpdateFileParsingConfig(config *models.FileParsingConfig
 	}
 
 	// Get the schema
-	schema, err := s.schemaRepo.GetSchemaByID(config.SchemaID)
+	schema, err := s.schemaRepo.GetSchemaByID(tenantID, config.SchemaID)
 	if err != nil {
 		return nil, err
 	}
@@ -438,7 +438,7 @@ func (s *SchemaService) DeleteFileParsingConfig(configID, schemaID, userID, tena
 	}
 
 	// Get the schema
-	schema, err := s.schemaRepo.GetSchemaByID(schemaID)
+	schema, err := s.schemaRepo.GetSchemaByID(tenantID, schemaID)
 	if err != nil {
 		return err
 	}
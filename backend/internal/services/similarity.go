@@ -0,0 +1,157 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// normalizeReference upper-cases a reference, drops everything but letters and digits and
+// strips leading zeros from each run of digits, so "inv-00123" and "INV 123" compare equal
+func normalizeReference(reference string) string {
+	var b strings.Builder
+	leading := true // At the start of a run of digits
+	for _, r := range reference {
+		switch {
+		case unicode.IsDigit(r):
+			if leading && r == '0' {
+				continue
+			}
+			leading = false
+			b.WriteRune(r)
+		case unicode.IsLetter(r):
+			leading = true
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
+
+// referenceTokens splits a reference into normalized words
+func referenceTokens(reference string) []string {
+	words := strings.FieldsFunc(reference, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		if token := normalizeReference(word); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// levenshteinSimilarity scores two strings from 0 to 1 by edit distance relative to the longer one
+func levenshteinSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+
+	// Two rows of the edit distance matrix are enough
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+// jaroWinklerSimilarity scores two strings from 0 to 1, favouring strings that share a prefix
+func jaroWinklerSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	window := max(max(len(ra), len(rb))/2-1, 0)
+
+	matchedA := make([]bool, len(ra))
+	matchedB := make([]bool, len(rb))
+	matches := 0
+	for i := range ra {
+		lo := max(0, i-window)
+		hi := min(len(rb), i+window+1)
+		for j := lo; j < hi; j++ {
+			if !matchedB[j] && ra[i] == rb[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	// Count matched characters that appear in a different order
+	transpositions := 0
+	j := 0
+	for i := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(ra), len(rb)) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// tokenOverlapSimilarity scores two references from 0 to 1 by the share of words they have in common
+func tokenOverlapSimilarity(a, b string) float64 {
+	ta, tb := referenceTokens(a), referenceTokens(b)
+	if len(ta) == 0 && len(tb) == 0 {
+		return 1
+	}
+
+	set := make(map[string]bool, len(ta))
+	for _, token := range ta {
+		set[token] = true
+	}
+
+	union := len(set)
+	shared := 0
+	seen := make(map[string]bool, len(tb))
+	for _, token := range tb {
+		if seen[token] {
+			continue
+		}
+		seen[token] = true
+		if set[token] {
+			shared++
+		} else {
+			union++
+		}
+	}
+
+	return float64(shared) / float64(union)
+}
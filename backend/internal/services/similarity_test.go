@@ -0,0 +1,49 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalizeReference(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"INV-00123", "INV123"},
+		{"inv 123", "INV123"},
+		{"0042/2024", "422024"},
+		{"A-000", "A"},
+		{"--", ""},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeReference(tt.in); got != tt.want {
+			t.Errorf("normalizeReference(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(a, b string) float64
+		a, b string
+		want float64
+	}{
+		{"levenshtein identical", levenshteinSimilarity, "INV123", "INV123", 1},
+		{"levenshtein one edit", levenshteinSimilarity, "INV123", "INV124", 1 - 1.0/6},
+		{"levenshtein disjoint", levenshteinSimilarity, "ABC", "XYZ", 0},
+		{"jaro-winkler classic", jaroWinklerSimilarity, "MARTHA", "MARHTA", 0.9611},
+		{"jaro-winkler disjoint", jaroWinklerSimilarity, "ABC", "XYZ", 0},
+		{"token overlap", tokenOverlapSimilarity, "ACME Corp payment INV-0042", "Payment from ACME INV 42", 4.0 / 6},
+		{"token overlap empty", tokenOverlapSimilarity, "", "ACME", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(tt.a, tt.b); math.Abs(got-tt.want) > 0.0001 {
+				t.Errorf("similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
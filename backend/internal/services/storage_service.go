@@ -13,6 +13,8 @@ import (
 // StorageService defines methods for file storage operations
 type StorageService interface {
 	UploadFile(tenantID, fileType string, file *multipart.FileHeader) (string, error)
+	SaveFile(tenantID, fileType, filename string, content io.Reader) (string, error)
+	OpenFile(tenantID, fileKey string) (io.ReadCloser, error)
 	GetFileURL(tenantID, fileKey string) (string, error)
 	DeleteFile(tenantID, fileKey string) error
 }
@@ -76,6 +78,49 @@ func (s *LocalStorageService) UploadFile(tenantID, fileType string, file *multip
 	return fileKey, nil
 }
 
+// SaveFile writes generated content to local storage
+func (s *LocalStorageService) SaveFile(tenantID, fileType, filename string, content io.Reader) (string, error) {
+	// Create tenant directory if it doesn't exist
+	tenantDir := filepath.Join(s.basePath, tenantID, fileType)
+	if err := os.MkdirAll(tenantDir, 0755); err != nil {
+		return "", err
+	}
+
+	// Generate a unique file path
+	fileKey := fmt.Sprintf("%s/%s/%d_%s", tenantID, fileType, time.Now().UnixNano(), filepath.Base(filename))
+	filePath := filepath.Join(s.basePath, fileKey)
+
+	// Create the destination file
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+
+	// Copy the file contents, leaving no partial file behind when the content fails
+	if _, err = io.Copy(dst, content); err != nil {
+		dst.Close()
+		os.Remove(filePath)
+		return "", err
+	}
+
+	return fileKey, nil
+}
+
+// OpenFile opens a file in local storage for reading
+func (s *LocalStorageService) OpenFile(tenantID, fileKey string) (io.ReadCloser, error) {
+	// Ensure the file key belongs to the correct tenant
+	if !strings.HasPrefix(fileKey, tenantID+"/") {
+		return nil, fmt.Errorf("unauthorized access to file")
+	}
+
+	file, err := os.Open(filepath.Join(s.basePath, fileKey))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("file not found")
+	}
+	return file, err
+}
+
 // GetFileURL gets the file path for local storage
 func (s *LocalStorageService) GetFileURL(tenantID, fileKey string) (string, error) {
 	// Ensure the file key belongs to the correct tenant
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"backend/internal/models"
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// maxTextTerms bounds the terms of a full-text query
+const maxTextTerms = 16
+
+// parseTextQuery parses a full-text query into its terms. Words separated by spaces are terms of
+// their own and a quoted phrase is a single term; a trailing * makes a term a prefix, as in
+// acm* or "acme co*". Punctuation splits words, so INV-4471 is the phrase "inv 4471".
+func parseTextQuery(query string) ([]models.TextTerm, error) {
+	var terms []models.TextTerm
+	rest := strings.TrimSpace(query)
+	for rest != "" {
+		var chunk string
+		if rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return nil, errors.New("invalid query: unclosed quote")
+			}
+			chunk, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			chunk, rest = rest[:end], rest[end:]
+		}
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+
+		term := models.TextTerm{Words: textQueryWords(chunk), Prefix: strings.HasSuffix(chunk, "*")}
+		if len(term.Words) > 0 {
+			terms = append(terms, term)
+		}
+	}
+
+	if len(terms) == 0 {
+		return nil, errors.New("invalid query: nothing to search for")
+	}
+	if len(terms) > maxTextTerms {
+		return nil, errors.New("invalid query: at most 16 terms")
+	}
+	return terms, nil
+}
+
+// textQueryWords splits part of a query into lower case words of letters and digits
+func textQueryWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
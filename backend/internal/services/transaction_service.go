@@ -4,6 +4,14 @@ import (
 	"backend/internal/models"
 	"backend/internal/repository"
 	"errors"
+
+	"github.com/google/uuid"
+)
+
+// Page sizes of a transaction search
+const (
+	defaultTransactionSearchLimit = 50
+	maxTransactionSearchLimit     = 500
 )
 
 // Error definitions
@@ -14,12 +22,20 @@ var (
 // TransactionService provides methods for managing transactions
 type TransactionService struct {
 	transactionRepo repository.TransactionRepository
+	matchSetRepo    repository.MatchSetRepository
+	permissionRepo  repository.PermissionRepository
 }
 
 // NewTransactionService creates a new transaction service
-func NewTransactionService(transactionRepo repository.TransactionRepository) *TransactionService {
+func NewTransactionService(
+	transactionRepo repository.TransactionRepository,
+	matchSetRepo repository.MatchSetRepository,
+	permissionRepo repository.PermissionRepository,
+) *TransactionService {
 	return &TransactionService{
 		transactionRepo: transactionRepo,
+		matchSetRepo:    matchSetRepo,
+		permissionRepo:  permissionRepo,
 	}
 }
 
@@ -33,9 +49,9 @@ func (s *TransactionService) CreateTransactions(transactions []models.Transactio
 	return s.transactionRepo.CreateTransactions(transactions)
 }
 
-// GetTransactionByID retrieves a transaction by ID
-func (s *TransactionService) GetTransactionByID(id string) (*models.Transaction, error) {
-	transaction, err := s.transactionRepo.GetTransactionByID(id)
+// GetTransactionByID retrieves a transaction of a tenant by ID
+func (s *TransactionService) GetTransactionByID(tenantID, id string) (*models.Transaction, error) {
+	transaction, err := s.transactionRepo.GetTransactionByID(tenantID, id)
 	if err != nil {
 		if err == repository.ErrTransactionNotFound {
 			return nil, ErrTransactionNotFound
@@ -45,24 +61,24 @@ func (s *TransactionService) GetTransactionByID(id string) (*models.Transaction,
 	return transaction, nil
 }
 
-// GetTransactionsByDataSourceID retrieves transactions for a data source
-func (s *TransactionService) GetTransactionsByDataSourceID(dataSourceID string) ([]models.Transaction, error) {
-	return s.transactionRepo.GetTransactionsByDataSourceID(dataSourceID)
+// GetTransactionsByDataSourceID retrieves the transactions of a data source in a tenant
+func (s *TransactionService) GetTransactionsByDataSourceID(tenantID, dataSourceID string) ([]models.Transaction, error) {
+	return s.transactionRepo.GetTransactionsByDataSourceID(tenantID, dataSourceID)
 }
 
-// GetTransactionsByUserID retrieves transactions uploaded by a user
-func (s *TransactionService) GetTransactionsByUserID(userID string) ([]models.Transaction, error) {
-	return s.transactionRepo.GetTransactionsByUserID(userID)
+// GetTransactionsByUserID retrieves the transactions a user created in a tenant
+func (s *TransactionService) GetTransactionsByUserID(tenantID, userID string) ([]models.Transaction, error) {
+	return s.transactionRepo.GetTransactionsByUserID(tenantID, userID)
 }
 
-// GetRecentTransactions retrieves recent transactions up to a limit
-func (s *TransactionService) GetRecentTransactions(limit int) ([]models.Transaction, error) {
-	return s.transactionRepo.GetRecentTransactions(limit)
+// GetRecentTransactions retrieves the recent transactions of a tenant up to a limit
+func (s *TransactionService) GetRecentTransactions(tenantID string, limit int) ([]models.Transaction, error) {
+	return s.transactionRepo.GetRecentTransactions(tenantID, limit)
 }
 
-// DeleteTransaction deletes a transaction
-func (s *TransactionService) DeleteTransaction(id string) error {
-	err := s.transactionRepo.DeleteTransaction(id)
+// DeleteTransaction deletes a transaction of a tenant
+func (s *TransactionService) DeleteTransaction(tenantID, id string) error {
+	err := s.transactionRepo.DeleteTransaction(tenantID, id)
 	if err != nil {
 		if err == repository.ErrTransactionNotFound {
 			return ErrTransactionNotFound
@@ -72,7 +88,152 @@ func (s *TransactionService) DeleteTransaction(id string) error {
 	return nil
 }
 
-// DeleteTransactionsByDataSourceID deletes all transactions for a data source
-func (s *TransactionService) DeleteTransactionsByDataSourceID(dataSourceID string) error {
-	return s.transactionRepo.DeleteTransactionsByDataSourceID(dataSourceID)
+// DeleteTransactionsByDataSourceID deletes all transactions of a data source in a tenant
+func (s *TransactionService) DeleteTransactionsByDataSourceID(tenantID, dataSourceID string) error {
+	return s.transactionRepo.DeleteTransactionsByDataSourceID(tenantID, dataSourceID)
+}
+
+// SearchTransactions retrieves a page of the tenant's transactions matching a filter. A match set
+// narrows the search to its data sources. The next page is requested with the returned cursor
+// and the same filter.
+func (s *TransactionService) SearchTransactions(filter models.TransactionFilter, matchSetID, userID, tenantID string) (*models.TransactionPage, error) {
+	filter, selectsAny, err := s.scopeFilter(filter, matchSetID, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultTransactionSearchLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxTransactionSearchLimit {
+		return nil, errors.New("invalid search: limit must be between 1 and 500")
+	}
+
+	if !selectsAny {
+		return &models.TransactionPage{Transactions: []models.Transaction{}, Limit: filter.Limit}, nil
+	}
+
+	return s.transactionRepo.SearchTransactions(filter)
+}
+
+// CountTransactions counts the tenant's transactions matching a filter, narrowed to the data
+// sources of a match set when one is given
+func (s *TransactionService) CountTransactions(filter models.TransactionFilter, matchSetID, userID, tenantID string) (int, error) {
+	filter, selectsAny, err := s.scopeFilter(filter, matchSetID, userID, tenantID)
+	if err != nil || !selectsAny {
+		return 0, err
+	}
+
+	return s.transactionRepo.CountTransactions(filter)
+}
+
+// SearchTransactionText searches the references and descriptions of the tenant's transactions
+// for a full-text query, narrowed by the statuses and data sources of search and by a match set
+// when one is given. Each hit carries the words that matched it.
+func (s *TransactionService) SearchTransactionText(query string, search models.TextSearch, matchSetID, userID, tenantID string) (*models.TextSearchPage, error) {
+	scope, selectsAny, err := s.scopeFilter(models.TransactionFilter{Statuses: search.Statuses, DataSourceIDs: search.DataSourceIDs}, matchSetID, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if search.Terms, err = parseTextQuery(query); err != nil {
+		return nil, err
+	}
+	if search.Limit == 0 {
+		search.Limit = defaultTransactionSearchLimit
+	}
+	if search.Limit < 0 || search.Limit > maxTransactionSearchLimit {
+		return nil, errors.New("invalid search: limit must be between 1 and 500")
+	}
+	if search.Offset < 0 {
+		return nil, errors.New("invalid search: offset must not be negative")
+	}
+
+	page := &models.TextSearchPage{Hits: []models.TextSearchHit{}, Limit: search.Limit, Offset: search.Offset}
+	if !selectsAny {
+		return page, nil
+	}
+
+	search.TenantID = scope.TenantID
+	search.DataSourceIDs = scope.DataSourceIDs
+	if page.Hits, page.Total, err = s.transactionRepo.SearchTransactionText(search); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// scopeFilter checks that a user may search the transactions of a tenant, validates a filter and
+// scopes it to the tenant and to the data sources of a match set. It reports false when the
+// filter cannot select any transaction.
+func (s *TransactionService) scopeFilter(filter models.TransactionFilter, matchSetID, userID, tenantID string) (models.TransactionFilter, bool, error) {
+	// Check if user has permission to view transactions
+	hasPermission, err := s.permissionRepo.HasPermission(userID, models.PermViewTransactions, tenantID)
+	if err != nil {
+		return filter, false, err
+	}
+	if !hasPermission {
+		return filter, false, errors.New("unauthorized: requires view transactions permission")
+	}
+
+	if filter.DateFrom != nil && filter.DateTo != nil && filter.DateTo.Before(*filter.DateFrom) {
+		return filter, false, errors.New("invalid search: to date must not be before from date")
+	}
+	if filter.AmountMin != nil && filter.AmountMax != nil && *filter.AmountMax < *filter.AmountMin {
+		return filter, false, errors.New("invalid search: max amount must not be below min amount")
+	}
+
+	seen := make(map[string]bool)
+	for _, key := range filter.Sort {
+		if seen[key.Field] {
+			return filter, false, errors.New("invalid sort: " + key.Field + " is sorted by twice")
+		}
+		seen[key.Field] = true
+	}
+
+	// Only the canonical form, as PostgreSQL does not read every form uuid.Parse accepts
+	for _, dataSourceID := range filter.DataSourceIDs {
+		if _, err := uuid.Parse(dataSourceID); err != nil || len(dataSourceID) != 36 {
+			return filter, false, errors.New("invalid search: data source ID " + dataSourceID + " is not a UUID")
+		}
+	}
+
+	if matchSetID != "" {
+		matchSet, err := s.matchSetRepo.GetMatchSetByID(tenantID, matchSetID)
+		if err != nil {
+			return filter, false, err
+		}
+		if matchSet.TenantID != tenantID {
+			return filter, false, errors.New("match set not found in this tenant")
+		}
+
+		dataSources, err := s.matchSetRepo.GetMatchSetDataSources(matchSet.TenantID, matchSet.ID)
+		if err != nil {
+			return filter, false, err
+		}
+
+		// Keep the requested data sources that are in the match set
+		var dataSourceIDs []string
+		for _, dataSource := range dataSources {
+			if len(filter.DataSourceIDs) == 0 || containsID(filter.DataSourceIDs, dataSource.ID) {
+				dataSourceIDs = append(dataSourceIDs, dataSource.ID)
+			}
+		}
+		if len(dataSourceIDs) == 0 {
+			return filter, false, nil
+		}
+		filter.DataSourceIDs = dataSourceIDs
+	}
+
+	filter.TenantID = tenantID
+	return filter, true, nil
+}
+
+// containsID reports whether a list of IDs holds an ID
+func containsID(ids []string, id string) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransactionService_SearchTransactions(t *testing.T) {
+	transactionRepo := repository.NewTransactionRepository()
+	matchSetRepo := repository.NewMatchSetRepository()
+
+	const otherSource = "5f0c9a8e-3b1d-4c2e-9f6a-2d7b8c1e4a90"
+	matchSet := &models.MatchSet{ID: "set-1", Name: "Bank", TenantID: "tenant-1"}
+	matchSetRepo.CreateMatchSet(matchSet)
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "ledger")
+	matchSetRepo.AddDataSourceToMatchSet(matchSet.ID, "bank")
+
+	for _, transaction := range []models.Transaction{
+		tx("t1", 100, 1, "INV-1"),
+		tx("t2", 100, 2, "INV-2"),
+		tx("t3", 250, 2, "INV-3"),
+		tx("t4", 75, 3, "ACH ACME"),
+		tx("t5", 100, 4, "INV-5"),
+		tx("t6", 300, 5, "INV-6"),
+	} {
+		transaction.DataSourceID = "ledger"
+		transaction.Status = "Unmatched"
+		switch transaction.ID {
+		case "t4":
+			transaction.Status = "Matched"
+			transaction.CustomFields = map[string]string{"cost_center": "ops"}
+		case "t6":
+			transaction.DataSourceID = otherSource
+		}
+		transactionRepo.CreateTransaction(&transaction)
+	}
+
+	service := NewTransactionService(transactionRepo, matchSetRepo, allowAllPermissions{})
+	ids := func(page *models.TransactionPage) string {
+		var ids []string
+		for _, transaction := range page.Transactions {
+			ids = append(ids, transaction.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	// Amount descending, then newest first, two at a time
+	filter := models.TransactionFilter{
+		Sort:  []models.TransactionSort{{Field: models.TransactionSortAmount, Desc: true}, {Field: models.TransactionSortDate}},
+		Limit: 2,
+	}
+	var pages []string
+	for {
+		page, err := service.SearchTransactions(filter, "", "user-1", "tenant-1")
+		if err != nil {
+			t.Fatalf("SearchTransactions() error = %v", err)
+		}
+		pages = append(pages, ids(page))
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	if got := strings.Join(pages, " | "); got != "t6,t3 | t1,t2 | t5,t4" {
+		t.Errorf("pages = %s, want t6,t3 | t1,t2 | t5,t4", got)
+	}
+
+	from := time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC)
+	amountMax := 150.0
+	tests := []struct {
+		name       string
+		filter     models.TransactionFilter
+		matchSetID string
+		want       string
+	}{
+		{"default sort is newest first", models.TransactionFilter{}, "", "t6,t5,t4,t3,t2,t1"},
+		{"date and amount range", models.TransactionFilter{DateFrom: &from, AmountMax: &amountMax}, "", "t5,t4,t2"},
+		{"status", models.TransactionFilter{Statuses: []string{"Matched"}}, "", "t4"},
+		{"text ignores case", models.TransactionFilter{Text: "acme"}, "", "t4"},
+		{"custom field", models.TransactionFilter{CustomFields: map[string]string{"cost_center": "ops"}}, "", "t4"},
+		{"match set data sources", models.TransactionFilter{}, matchSet.ID, "t5,t4,t3,t2,t1"},
+		{"data source outside the match set", models.TransactionFilter{DataSourceIDs: []string{otherSource}}, matchSet.ID, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := service.SearchTransactions(tt.filter, tt.matchSetID, "user-1", "tenant-1")
+			if err != nil {
+				t.Fatalf("SearchTransactions() error = %v", err)
+			}
+			if got := ids(page); got != tt.want {
+				t.Errorf("SearchTransactions() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	page, _ := service.SearchTransactions(models.TransactionFilter{Limit: 2}, "", "user-1", "tenant-1")
+	invalid := []struct {
+		name       string
+		filter     models.TransactionFilter
+		matchSetID string
+		tenantID   string
+		want       string
+	}{
+		{"cursor of another sort", models.TransactionFilter{Cursor: page.NextCursor, Sort: []models.TransactionSort{{Field: models.TransactionSortAmount}}}, "", "tenant-1", "invalid cursor"},
+		{"malformed cursor", models.TransactionFilter{Cursor: "not-a-cursor"}, "", "tenant-1", "invalid cursor"},
+		{"unknown sort field", models.TransactionFilter{Sort: []models.TransactionSort{{Field: "password"}}}, "", "tenant-1", "invalid sort"},
+		{"limit too large", models.TransactionFilter{Limit: 501}, "", "tenant-1", "invalid search"},
+		{"malformed data source ID", models.TransactionFilter{DataSourceIDs: []string{"ledger"}}, "", "tenant-1", "invalid search"},
+		{"match set of another tenant", models.TransactionFilter{}, matchSet.ID, "tenant-2", "not found"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.SearchTransactions(tt.filter, tt.matchSetID, "user-1", tt.tenantID); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("SearchTransactions() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransactionService_SearchTransactionText(t *testing.T) {
+	transactionRepo := repository.NewTransactionRepository()
+	for _, transaction := range []models.Transaction{
+		tx("t1", 100, 1, "INV-4471"),
+		tx("t2", 100, 2, ""),
+		tx("t3", 250, 3, "ACME-9"),
+		tx("t4", 75, 4, ""),
+	} {
+		transaction.DataSourceID = "bank"
+		switch transaction.ID {
+		case "t1":
+			transaction.Description = "ACH ACME CORP INV 4471"
+		case "t2":
+			transaction.Description = "ACH Acmee Corporation"
+		case "t3":
+			transaction.Description = "ACME payment"
+		case "t4":
+			transaction.Description = "Wire ACME Corp"
+		}
+		transactionRepo.CreateTransaction(&transaction)
+	}
+
+	service := NewTransactionService(transactionRepo, repository.NewMatchSetRepository(), allowAllPermissions{})
+	ids := func(page *models.TextSearchPage) string {
+		var ids []string
+		for _, hit := range page.Hits {
+			ids = append(ids, hit.Transaction.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		fuzzy bool
+		want  string
+	}{
+		// More matching words rank first, equally ranked hits newest first
+		{"word in either field", "acme", false, "t3,t4,t1"},
+		{"phrase", `"acme corp"`, false, "t4,t1"},
+		{"words in any order", "corp acme", false, "t4,t1"},
+		{"prefix", "corp*", false, "t4,t2,t1"},
+		{"prefix phrase", `"ach acme co*"`, false, "t1"},
+		{"punctuation makes a phrase", "inv-4471", false, "t1"},
+		{"fuzzy", "acme", true, "t3,t4,t2,t1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := service.SearchTransactionText(tt.query, models.TextSearch{Fuzzy: tt.fuzzy}, "", "user-1", "tenant-1")
+			if err != nil {
+				t.Fatalf("SearchTransactionText(%q) error = %v", tt.query, err)
+			}
+			if got := ids(page); got != tt.want || page.Total != len(page.Hits) {
+				t.Errorf("SearchTransactionText(%q) = %s of %d, want %s", tt.query, got, page.Total, tt.want)
+			}
+		})
+	}
+
+	// The phrase is highlighted in both fields, word by word
+	page, err := service.SearchTransactionText(`"inv 4471"`, models.TextSearch{}, "", "user-1", "tenant-1")
+	if err != nil || len(page.Hits) != 1 {
+		t.Fatalf("SearchTransactionText() = %v, %v, want one hit", page, err)
+	}
+	want := []models.TextHighlight{
+		{Field: models.TextFieldReference, Start: 0, End: 3},
+		{Field: models.TextFieldReference, Start: 4, End: 8},
+		{Field: models.TextFieldDescription, Start: 14, End: 17},
+		{Field: models.TextFieldDescription, Start: 18, End: 22},
+	}
+	if got := page.Hits[0].Highlights; len(got) != len(want) {
+		t.Fatalf("Highlights = %+v, want %+v", got, want)
+	} else {
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("Highlights[%d] = %+v, want %+v", i, got[i], want[i])
+			}
+		}
+	}
+
+	for _, query := range []string{"", " - ", `"acme`} {
+		if _, err := service.SearchTransactionText(query, models.TextSearch{}, "", "user-1", "tenant-1"); err == nil || !strings.HasPrefix(err.Error(), "invalid query") {
+			t.Errorf("SearchTransactionText(%q) error = %v, want invalid query", query, err)
+		}
+	}
+}
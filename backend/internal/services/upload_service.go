@@ -31,8 +31,9 @@ func NewUploadService(
 	}
 }
 
-// UploadCSV processes a CSV file and creates transactions
+// UploadCSV processes a CSV file and creates transactions for a data source of a tenant
 func (s *UploadService) UploadCSV(
+	tenantID string,
 	dataSourceID string,
 	fileName string,
 	fileSize int64,
@@ -41,8 +42,17 @@ func (s *UploadService) UploadCSV(
 	columnMapping map[string]int,
 	dateFormat string,
 ) (*models.TransactionUpload, error) {
+	// Make sure the data source exists in the tenant
+	if _, err := s.dataSourceRepo.GetDataSourceByID(tenantID, dataSourceID); err != nil {
+		if err == repository.ErrDataSourceNotFound {
+			return nil, ErrDataSourceNotFound
+		}
+		return nil, err
+	}
+
 	// Create upload record
 	upload := &models.TransactionUpload{
+		TenantID:     tenantID,
 		DataSourceID: dataSourceID,
 		FileName:     fileName,
 		FileSize:     fileSize,
@@ -62,7 +72,7 @@ func (s *UploadService) UploadCSV(
 	// Skip the header row
 	_, err = csvReader.Read()
 	if err != nil {
-		s.updateUploadStatus(upload.ID, "Failed", 0, "Failed to read CSV header: "+err.Error())
+		s.updateUploadStatus(upload, "Failed", 0, "Failed to read CSV header: "+err.Error())
 		return upload, err
 	}
 
@@ -76,11 +86,11 @@ func (s *UploadService) UploadCSV(
 			break
 		}
 		if err != nil {
-			s.updateUploadStatus(upload.ID, "Failed", recordCount, "Error reading CSV: "+err.Error())
+			s.updateUploadStatus(upload, "Failed", recordCount, "Error reading CSV: "+err.Error())
 			return upload, err
 		}
 
-		transaction, err := s.parseTransaction(record, columnMapping, dateFormat, dataSourceID, userID)
+		transaction, err := s.parseTransaction(record, columnMapping, dateFormat, tenantID, dataSourceID, userID)
 		if err != nil {
 			// Log error but continue processing
 			continue
@@ -100,7 +110,7 @@ func (s *UploadService) UploadCSV(
 	}
 
 	// Update upload status
-	s.updateUploadStatus(upload.ID, "Completed", recordCount, "")
+	s.updateUploadStatus(upload, "Completed", recordCount, "")
 
 	// Update the upload with the final status
 	upload.Status = "Completed"
@@ -110,8 +120,8 @@ func (s *UploadService) UploadCSV(
 }
 
 // updateUploadStatus updates the status of an upload
-func (s *UploadService) updateUploadStatus(id string, status string, recordCount int, errorMessage string) error {
-	return s.uploadRepo.UpdateUploadStatus(id, status, recordCount, errorMessage)
+func (s *UploadService) updateUploadStatus(upload *models.TransactionUpload, status string, recordCount int, errorMessage string) error {
+	return s.uploadRepo.UpdateUploadStatus(upload.TenantID, upload.ID, status, recordCount, errorMessage)
 }
 
 // parseTransaction parses a CSV record into a Transaction
@@ -119,10 +129,12 @@ func (s *UploadService) parseTransaction(
 	record []string,
 	columnMapping map[string]int,
 	dateFormat string,
+	tenantID string,
 	dataSourceID string,
 	userID string,
 ) (*models.Transaction, error) {
 	transaction := &models.Transaction{
+		TenantID:     tenantID,
 		DataSourceID: dataSourceID,
 		Status:       "Unmatched",
 		CreatedBy:    userID,
@@ -187,17 +199,17 @@ func (s *UploadService) parseTransaction(
 	return transaction, nil
 }
 
-// GetUploadByID retrieves an upload by ID
-func (s *UploadService) GetUploadByID(id string) (*models.TransactionUpload, error) {
-	return s.uploadRepo.GetUploadByID(id)
+// GetUploadByID retrieves an upload of a tenant by ID
+func (s *UploadService) GetUploadByID(tenantID, id string) (*models.TransactionUpload, error) {
+	return s.uploadRepo.GetUploadByID(tenantID, id)
 }
 
-// GetUploadsByUser retrieves uploads by user
-func (s *UploadService) GetUploadsByUser(userID string) ([]models.TransactionUpload, error) {
-	return s.uploadRepo.GetUploadsByUser(userID)
+// GetUploadsByUser retrieves the uploads of a user in a tenant
+func (s *UploadService) GetUploadsByUser(tenantID, userID string) ([]models.TransactionUpload, error) {
+	return s.uploadRepo.GetUploadsByUser(tenantID, userID)
 }
 
-// GetRecentUploads retrieves recent uploads
-func (s *UploadService) GetRecentUploads(limit int) ([]models.TransactionUpload, error) {
-	return s.uploadRepo.GetRecentUploads(limit)
+// GetRecentUploads retrieves the recent uploads of a tenant
+func (s *UploadService) GetRecentUploads(tenantID string, limit int) ([]models.TransactionUpload, error) {
+	return s.uploadRepo.GetRecentUploads(tenantID, limit)
 }
@@ -17,6 +17,12 @@ func SetupTestDB(t *testing.T) *sql.DB {
 		t.Fatalf("Failed to connect to test database: %v", err)
 	}
 
+	// Tests that need a database are skipped where none is running
+	if err := db.Ping(); err != nil {
+		db.Close()
+		t.Skipf("Test database is not available: %v", err)
+	}
+
 	// Clear test database
 	if _, err := db.Exec(`TRUNCATE users CASCADE`); err != nil {
 		t.Fatalf("Failed to clear test database: %v", err)
@@ -3,7 +3,9 @@ package main
 import (
 	"log"
 	"net/http"
+	"os"
 	"path/filepath"
+	_ "time/tzdata" // Schedules name time zones the host may not have data for
 
 	"backend/internal/db"
 	"backend/internal/handlers"
@@ -30,19 +32,103 @@ func main() {
 	dataSourceRepo := repository.NewDataSourceRepository()
 	roleRepo := repository.NewRoleRepository()
 	transactionRepo := repository.NewTransactionRepository()
+	importRepo := repository.NewImportRepository()
+	matchSetRepo := repository.NewMatchSetRepository()
+	ruleRepo := repository.NewRuleRepository()
+	matchRepo := repository.NewMatchRepository()
+	unmatchedRepo := repository.NewUnmatchedTransactionRepository()
+	matchProgressRepo := repository.NewMatchProgressRepository()
+	fxRateRepo := repository.NewFXRateRepository()
+	approvalPolicyRepo := repository.NewApprovalPolicyRepository()
+	scheduleRepo := repository.NewScheduleRepository()
+	autoRunRepo := repository.NewAutoRunRepository()
+	reportRepo := repository.NewReportRepository()
+	exportRepo := repository.NewExportRepository()
+	schemaRepo := repository.NewSchemaRepository()
+	tenantRepo := repository.NewTenantRepository()
+	uploadRepo := repository.NewUploadRepository()
+	permissionRepo := repository.NewPermissionRepository(roleRepo)
+	adjustmentRepo := repository.NewAdjustmentRepository(transactionRepo, matchRepo)
 
 	// Initialize services
+	storageService, err := services.NewStorageService(map[string]string{"basePath": os.Getenv("STORAGE_PATH")})
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
 	jwtService := services.NewJWTService()
 	roleService := services.NewRoleService(roleRepo, userRepo)
 	dataSourceService := services.NewDataSourceService(dataSourceRepo)
-	transactionService := services.NewTransactionService(transactionRepo)
+	ruleService := services.NewRuleService(ruleRepo, permissionRepo)
+	transactionService := services.NewTransactionService(transactionRepo, matchSetRepo, permissionRepo)
 	userService := services.NewUserService(userRepo, roleService)
+	autoRunService := services.NewAutoRunService(autoRunRepo, matchSetRepo, matchProgressRepo, permissionRepo)
+	ingestService := services.NewIngestService(importRepo, transactionRepo, dataSourceRepo, autoRunService)
+	matchSetService := services.NewMatchSetService(
+		matchSetRepo,
+		ruleRepo,
+		dataSourceRepo,
+		transactionRepo,
+		permissionRepo,
+		matchRepo,
+		unmatchedRepo,
+		matchProgressRepo,
+		fxRateRepo,
+		approvalPolicyRepo,
+	)
+	fxRateService := services.NewFXRateService(fxRateRepo, permissionRepo)
+	matchService := services.NewMatchService(matchRepo, matchSetRepo, ruleRepo, transactionRepo, permissionRepo, fxRateRepo, adjustmentRepo)
+	suggestionService := services.NewMatchSuggestionService(matchSetRepo, ruleRepo, transactionRepo, permissionRepo, fxRateRepo)
+	approvalPolicyService := services.NewApprovalPolicyService(approvalPolicyRepo, matchSetRepo, ruleRepo, permissionRepo)
+	schemaService := services.NewSchemaService(schemaRepo, permissionRepo)
+	uploadService := services.NewUploadService(uploadRepo, transactionRepo, dataSourceRepo)
+	queueService := services.NewQueueService(
+		schemaService,
+		dataSourceService,
+		matchSetService,
+		transactionService,
+		uploadService,
+		scheduleRepo,
+		autoRunService,
+	)
+	exceptionService := services.NewExceptionService(unmatchedRepo, matchSetRepo, tenantRepo, permissionRepo)
+	reportService := services.NewReportService(
+		reportRepo,
+		matchSetRepo,
+		transactionRepo,
+		matchRepo,
+		adjustmentRepo,
+		matchProgressRepo,
+		permissionRepo,
+		storageService,
+	)
+	exportService := services.NewExportService(
+		transactionService,
+		matchRepo,
+		unmatchedRepo,
+		exportRepo,
+		permissionRepo,
+		storageService,
+	)
+	scheduleService := services.NewScheduleService(scheduleRepo, matchSetRepo, matchProgressRepo, permissionRepo, queueService)
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(userRepo, roleService)
+	authHandler := handlers.NewAuthHandler(userRepo, tenantRepo, roleService)
 	dataSourceHandler := handlers.NewDataSourceHandler(dataSourceService, roleService)
-	uploadHandler := handlers.NewUploadHandler(dataSourceService, transactionService, roleService)
+	uploadHandler := handlers.NewUploadHandler(dataSourceService, transactionService, roleService, autoRunService)
 	userHandler := handlers.NewUserHandler(userService, roleService)
+	ingestHandler := handlers.NewIngestHandler(ingestService, roleService)
+	matchSetHandlers := handlers.NewMatchSetHandlers(matchSetService)
+	ruleHandlers := handlers.NewRuleHandlers(ruleService)
+	fxRateHandlers := handlers.NewFXRateHandlers(fxRateService)
+	transactionHandler := handlers.NewTransactionHandler(transactionService, suggestionService)
+	matchHandler := handlers.NewMatchHandler(matchService)
+	adjustmentHandlers := handlers.NewAdjustmentHandlers(matchService)
+	approvalPolicyHandlers := handlers.NewApprovalPolicyHandlers(approvalPolicyService)
+	scheduleHandlers := handlers.NewScheduleHandlers(scheduleService)
+	autoRunHandlers := handlers.NewAutoRunHandlers(autoRunService)
+	exceptionHandlers := handlers.NewExceptionHandlers(exceptionService)
+	reportHandlers := handlers.NewReportHandlers(reportService)
+	exportHandlers := handlers.NewExportHandlers(exportService)
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(jwtService)
@@ -66,6 +152,7 @@ func main() {
 	protected.Use(authMiddleware.RequireAuth)
 	protected.HandleFunc("/auth/google", authHandler.GoogleAuth).Methods("GET")
 	protected.HandleFunc("/auth/google/callback", authHandler.GoogleCallback).Methods("GET")
+	protected.HandleFunc("/auth/switch-tenant", authHandler.SwitchTenant).Methods("POST")
 
 	// Data source routes
 	protected.HandleFunc("/datasources", dataSourceHandler.GetAllDataSources).Methods("GET")
@@ -75,6 +162,45 @@ func main() {
 	protected.HandleFunc("/datasources/{id}", dataSourceHandler.UpdateDataSource).Methods("PUT")
 	protected.HandleFunc("/datasources/{id}", dataSourceHandler.DeleteDataSource).Methods("DELETE")
 
+	// Transaction ingest routes
+	protected.HandleFunc("/datasources/{id}/transactions/batch", ingestHandler.IngestTransactions).Methods("POST")
+
+	// Match set routes
+	matchSetHandlers.RegisterRoutes(protected)
+
+	// Match rule routes
+	ruleHandlers.RegisterRoutes(protected)
+
+	// Exchange rate routes
+	fxRateHandlers.RegisterRoutes(protected)
+
+	// Transaction routes
+	transactionHandler.RegisterRoutes(protected)
+
+	// Match routes
+	matchHandler.RegisterRoutes(protected)
+
+	// Adjustment routes
+	adjustmentHandlers.RegisterRoutes(protected)
+
+	// Auto-approval policy routes
+	approvalPolicyHandlers.RegisterRoutes(protected)
+
+	// Match set schedule routes
+	scheduleHandlers.RegisterRoutes(protected)
+
+	// Match set auto-run routes
+	autoRunHandlers.RegisterRoutes(protected)
+
+	// Exception routes
+	exceptionHandlers.RegisterRoutes(protected)
+
+	// Reconciliation report routes
+	reportHandlers.RegisterRoutes(protected)
+
+	// Export routes
+	exportHandlers.RegisterRoutes(protected)
+
 	// Upload routes
 	protected.HandleFunc("/uploads/transactions", uploadHandler.UploadTransactions).Methods("POST")
 
@@ -94,12 +220,17 @@ func main() {
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000", "http://127.0.0.1:3000", "http://localhost:8080"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-Requested-With", "Accept"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-Requested-With", "Accept", "Idempotency-Key"},
 		AllowCredentials: true,
 		MaxAge:           300,  // Maximum value not ignored by any of major browsers
 		Debug:            true, // Enable debugging for CORS issues
 	})
 
+	// Start background workers
+	queueService.StartListener()
+	scheduleService.StartScheduler()
+	queueService.StartAutoRunner()
+
 	// Start server
 	log.Println("Server starting on port 8080...")
 	log.Fatal(http.ListenAndServe(":8080", c.Handler(r)))